@@ -0,0 +1,398 @@
+package lxd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/units"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// isNetworkName validates the name of a managed network the same way the server does for all network types.
+func isNetworkName(value string) error {
+	err := validate.IsURLSegmentSafe(value)
+	if err != nil {
+		return err
+	}
+
+	if strings.Contains(value, ":") {
+		return fmt.Errorf("Cannot contain %q", ":")
+	}
+
+	return nil
+}
+
+// isBitRate validates a network rate limit (for example 100Mbit).
+func isBitRate(value string) error {
+	_, err := units.ParseBitSizeString(value)
+	return err
+}
+
+// isDiskLimit validates a disk I/O limit, either in bytes per second (for example 10MB) or in IOPS (for example
+// 1000iops).
+func isDiskLimit(value string) error {
+	if strings.HasSuffix(value, "iops") {
+		_, err := strconv.ParseInt(strings.TrimSuffix(value, "iops"), 10, 64)
+		return err
+	}
+
+	_, err := units.ParseByteSizeString(value)
+	return err
+}
+
+// nicValidators are the validation rules applied by the server to the configuration of NIC devices.
+// Checks depending on the server state (such as whether the network or parent interface exists) are left to it.
+var nicValidators = map[string]func(value string) error{
+	"boot.priority":        validate.Optional(validate.IsUint32),
+	"host_name":            validate.Optional(validate.IsInterfaceName),
+	"hwaddr":               validate.Optional(validate.IsNetworkMAC),
+	"ipv4.address":         validate.Optional(validate.IsNetworkAddressV4),
+	"ipv4.routes":          validate.Optional(validate.IsNetworkV4List),
+	"ipv6.address":         validate.Optional(validate.IsNetworkAddressV6),
+	"ipv6.routes":          validate.Optional(validate.IsNetworkV6List),
+	"limits.egress":        validate.Optional(isBitRate),
+	"limits.ingress":       validate.Optional(isBitRate),
+	"limits.max":           validate.Optional(isBitRate),
+	"mtu":                  validate.Optional(validate.IsNetworkMTU),
+	"name":                 validate.Optional(validate.IsInterfaceName),
+	"network":              validate.Optional(isNetworkName),
+	"nictype":              validate.Optional(validate.IsOneOf("bridged", "ipvlan", "macvlan", "p2p", "physical", "routed", "sriov")),
+	"parent":               validate.Optional(validate.IsInterfaceName),
+	"security.acls":        validate.IsAny,
+	"security.promiscuous": validate.Optional(validate.IsBool),
+	"vlan":                 validate.Optional(validate.IsNetworkVLAN),
+}
+
+// diskValidators are the validation rules applied by the server to the configuration of disk devices.
+// Checks depending on the server state (such as whether the pool or source exists) are left to it.
+var diskValidators = map[string]func(value string) error{
+	"boot.priority": validate.Optional(validate.IsUint32),
+	"limits.max":    validate.Optional(isDiskLimit),
+	"limits.read":   validate.Optional(isDiskLimit),
+	"limits.write":  validate.Optional(isDiskLimit),
+	"path":          validate.IsAny,
+	"pool":          validate.IsAny,
+	"readonly":      validate.Optional(validate.IsBool),
+	"required":      validate.Optional(validate.IsBool),
+	"size":          validate.Optional(validate.IsSize),
+	"source":        validate.IsAny,
+}
+
+// networkValidators are the validation rules applied by the server to the configuration of networks.
+var networkValidators = map[string]func(value string) error{
+	"bridge.mtu": validate.Optional(validate.IsNetworkMTU),
+	"ipv4.address": validate.Optional(func(value string) error {
+		if validate.IsOneOf("none", "auto")(value) == nil {
+			return nil
+		}
+
+		return validate.IsListOf(validate.IsNetworkAddressCIDRV4)(value)
+	}),
+	"ipv4.dhcp":        validate.Optional(validate.IsBool),
+	"ipv4.dhcp.ranges": validate.Optional(validate.IsNetworkRangeV4List),
+	"ipv4.nat":         validate.Optional(validate.IsBool),
+	"ipv6.address": validate.Optional(func(value string) error {
+		if validate.IsOneOf("none", "auto")(value) == nil {
+			return nil
+		}
+
+		return validate.IsListOf(validate.IsNetworkAddressCIDRV6)(value)
+	}),
+	"ipv6.dhcp": validate.Optional(validate.IsBool),
+	"ipv6.nat":  validate.Optional(validate.IsBool),
+	"mtu":       validate.Optional(validate.IsNetworkMTU),
+	"parent":    validate.Optional(validate.IsInterfaceName),
+	"vlan":      validate.Optional(validate.IsNetworkVLAN),
+}
+
+// configBuilder accumulates configuration keys along with the errors of the values failing validation.
+type configBuilder struct {
+	config     map[string]string
+	validators map[string]func(value string) error
+	errs       []string
+}
+
+// set sets the key, validating its value if a validation rule is known for it.
+func (b *configBuilder) set(key string, value string) {
+	validator, ok := b.validators[key]
+	if ok {
+		err := validator(value)
+		if err != nil {
+			b.errs = append(b.errs, fmt.Sprintf("Invalid value for %q: %v", key, err))
+			return
+		}
+	}
+
+	b.config[key] = value
+}
+
+// err returns an error listing all the validation failures, if any.
+func (b *configBuilder) err() error {
+	if len(b.errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(b.errs, ", "))
+	}
+
+	return nil
+}
+
+// DeviceBuilder builds the configuration of an instance device, validating the values the same way the
+// server does so that mistakes are caught before sending the request.
+// Keys without a known validation rule, as well as checks depending on the server state or on the combination of
+// keys, are left to the server to validate.
+type DeviceBuilder struct {
+	configBuilder
+}
+
+// Set sets a configuration key of the device.
+func (b *DeviceBuilder) Set(key string, value string) *DeviceBuilder {
+	b.set(key, value)
+	return b
+}
+
+// Build returns the configuration of the device, or an error listing the invalid values.
+func (b *DeviceBuilder) Build() (map[string]string, error) {
+	err := b.err()
+	if err != nil {
+		return nil, err
+	}
+
+	device := make(map[string]string, len(b.config))
+	for k, v := range b.config {
+		device[k] = v
+	}
+
+	return device, nil
+}
+
+// NICBuilder builds the configuration of a NIC device.
+type NICBuilder struct {
+	DeviceBuilder
+}
+
+// NewNIC returns a builder for a NIC device of the given type using the given host interface as its parent.
+func NewNIC(nicType string, parent string) *NICBuilder {
+	b := &NICBuilder{DeviceBuilder{configBuilder{config: map[string]string{"type": "nic"}, validators: nicValidators}}}
+	b.set("nictype", nicType)
+
+	if parent != "" {
+		b.set("parent", parent)
+	}
+
+	return b
+}
+
+// NewBridgedNIC returns a builder for a NIC device connected to the given managed network.
+func NewBridgedNIC(network string) *NICBuilder {
+	b := &NICBuilder{DeviceBuilder{configBuilder{config: map[string]string{"type": "nic"}, validators: nicValidators}}}
+	b.set("network", network)
+
+	return b
+}
+
+// NewMacvlanNIC returns a builder for a macvlan NIC device on top of the given host interface.
+func NewMacvlanNIC(parent string) *NICBuilder {
+	return NewNIC("macvlan", parent)
+}
+
+// NewRoutedNIC returns a builder for a routed NIC device, optionally using the given host interface as its parent.
+func NewRoutedNIC(parent string) *NICBuilder {
+	return NewNIC("routed", parent)
+}
+
+// WithName sets the name of the interface inside the instance.
+func (b *NICBuilder) WithName(name string) *NICBuilder {
+	b.set("name", name)
+	return b
+}
+
+// WithHostName sets the name of the interface on the host.
+func (b *NICBuilder) WithHostName(hostName string) *NICBuilder {
+	b.set("host_name", hostName)
+	return b
+}
+
+// WithHwaddr sets the MAC address of the interface.
+func (b *NICBuilder) WithHwaddr(hwaddr string) *NICBuilder {
+	b.set("hwaddr", hwaddr)
+	return b
+}
+
+// WithMTU sets the MTU of the interface.
+func (b *NICBuilder) WithMTU(mtu uint32) *NICBuilder {
+	b.set("mtu", strconv.FormatUint(uint64(mtu), 10))
+	return b
+}
+
+// WithVLAN sets the VLAN the interface is attached to.
+func (b *NICBuilder) WithVLAN(vlan int) *NICBuilder {
+	b.set("vlan", strconv.Itoa(vlan))
+	return b
+}
+
+// WithIPv4 sets the IPv4 address of the interface.
+func (b *NICBuilder) WithIPv4(address string) *NICBuilder {
+	b.set("ipv4.address", address)
+	return b
+}
+
+// WithIPv6 sets the IPv6 address of the interface.
+func (b *NICBuilder) WithIPv6(address string) *NICBuilder {
+	b.set("ipv6.address", address)
+	return b
+}
+
+// WithLimits sets the ingress and egress rate limits of the interface (for example 100Mbit), empty values
+// leaving the corresponding direction unlimited.
+func (b *NICBuilder) WithLimits(ingress string, egress string) *NICBuilder {
+	if ingress != "" {
+		b.set("limits.ingress", ingress)
+	}
+
+	if egress != "" {
+		b.set("limits.egress", egress)
+	}
+
+	return b
+}
+
+// WithBootPriority sets the boot priority of the interface (for virtual machines).
+func (b *NICBuilder) WithBootPriority(priority uint32) *NICBuilder {
+	b.set("boot.priority", strconv.FormatUint(uint64(priority), 10))
+	return b
+}
+
+// DiskBuilder builds the configuration of a disk device.
+type DiskBuilder struct {
+	DeviceBuilder
+}
+
+// NewRootDisk returns a builder for the root disk device of an instance, stored on the given pool.
+func NewRootDisk(pool string) *DiskBuilder {
+	b := &DiskBuilder{DeviceBuilder{configBuilder{config: map[string]string{"type": "disk"}, validators: diskValidators}}}
+	b.set("path", "/")
+	b.set("pool", pool)
+
+	return b
+}
+
+// NewDisk returns a builder for a disk device sharing the given host path at the given path in the instance.
+func NewDisk(source string, path string) *DiskBuilder {
+	b := &DiskBuilder{DeviceBuilder{configBuilder{config: map[string]string{"type": "disk"}, validators: diskValidators}}}
+	b.set("source", source)
+	b.set("path", path)
+
+	return b
+}
+
+// NewVolumeDisk returns a builder for a disk device attaching the given custom volume at the given path.
+func NewVolumeDisk(pool string, volume string, path string) *DiskBuilder {
+	b := &DiskBuilder{DeviceBuilder{configBuilder{config: map[string]string{"type": "disk"}, validators: diskValidators}}}
+	b.set("pool", pool)
+	b.set("source", volume)
+	b.set("path", path)
+
+	return b
+}
+
+// WithSize sets the size of the disk (for example 10GiB).
+func (b *DiskBuilder) WithSize(size string) *DiskBuilder {
+	b.set("size", size)
+	return b
+}
+
+// WithReadOnly sets whether the disk is read-only.
+func (b *DiskBuilder) WithReadOnly(readOnly bool) *DiskBuilder {
+	b.set("readonly", strconv.FormatBool(readOnly))
+	return b
+}
+
+// WithBootPriority sets the boot priority of the disk (for virtual machines).
+func (b *DiskBuilder) WithBootPriority(priority uint32) *DiskBuilder {
+	b.set("boot.priority", strconv.FormatUint(uint64(priority), 10))
+	return b
+}
+
+// NetworkBuilder builds a request creating a network, validating the values the same way the server does.
+// Keys without a known validation rule, as well as type specific checks, are left to the server to validate.
+type NetworkBuilder struct {
+	configBuilder
+
+	network api.NetworksPost
+}
+
+// NewNetwork returns a builder for a network of the given type.
+func NewNetwork(name string, networkType string) *NetworkBuilder {
+	b := &NetworkBuilder{configBuilder: configBuilder{config: map[string]string{}, validators: networkValidators}}
+	b.network.Name = name
+	b.network.Type = networkType
+
+	return b
+}
+
+// NewBridgeNetwork returns a builder for a bridge network.
+func NewBridgeNetwork(name string) *NetworkBuilder {
+	return NewNetwork(name, "bridge")
+}
+
+// NewMacvlanNetwork returns a builder for a macvlan network on top of the given host interface.
+func NewMacvlanNetwork(name string, parent string) *NetworkBuilder {
+	b := NewNetwork(name, "macvlan")
+	b.set("parent", parent)
+
+	return b
+}
+
+// Set sets a configuration key of the network.
+func (b *NetworkBuilder) Set(key string, value string) *NetworkBuilder {
+	b.set(key, value)
+	return b
+}
+
+// WithDescription sets the description of the network.
+func (b *NetworkBuilder) WithDescription(description string) *NetworkBuilder {
+	b.network.Description = description
+	return b
+}
+
+// WithIPv4 sets the IPv4 address of the network in CIDR notation (or auto or none) and whether to NAT it.
+func (b *NetworkBuilder) WithIPv4(address string, nat bool) *NetworkBuilder {
+	b.set("ipv4.address", address)
+	b.set("ipv4.nat", strconv.FormatBool(nat))
+	return b
+}
+
+// WithIPv6 sets the IPv6 address of the network in CIDR notation (or auto or none) and whether to NAT it.
+func (b *NetworkBuilder) WithIPv6(address string, nat bool) *NetworkBuilder {
+	b.set("ipv6.address", address)
+	b.set("ipv6.nat", strconv.FormatBool(nat))
+	return b
+}
+
+// WithMTU sets the MTU of the network.
+func (b *NetworkBuilder) WithMTU(mtu uint32) *NetworkBuilder {
+	key := "mtu"
+	if b.network.Type == "bridge" {
+		key = "bridge.mtu"
+	}
+
+	b.set(key, strconv.FormatUint(uint64(mtu), 10))
+	return b
+}
+
+// Build returns the request creating the network, or an error listing the invalid values.
+func (b *NetworkBuilder) Build() (api.NetworksPost, error) {
+	err := b.err()
+	if err != nil {
+		return api.NetworksPost{}, err
+	}
+
+	network := b.network
+	network.Config = make(map[string]string, len(b.config))
+	for k, v := range b.config {
+		network.Config[k] = v
+	}
+
+	return network, nil
+}
@@ -143,4 +143,33 @@
 //	if err != nil {
 //	  return err
 //	}
+//
+// # Example - devices and networks
+//
+// This builds the configuration of a network and of the devices of an instance, the values being validated
+// the same way the server does before any request is sent.
+//
+//	// Network creation request
+//	network, err := lxd.NewBridgeNetwork("my-network").WithIPv4("10.0.0.1/24", true).WithIPv6("none", false).Build()
+//	if err != nil {
+//	  return err
+//	}
+//
+//	err = c.CreateNetwork(network)
+//	if err != nil {
+//	  return err
+//	}
+//
+//	// Instance devices
+//	eth0, err := lxd.NewBridgedNIC("my-network").WithName("eth0").WithIPv4("10.0.0.5").Build()
+//	if err != nil {
+//	  return err
+//	}
+//
+//	root, err := lxd.NewRootDisk("default").WithSize("10GiB").Build()
+//	if err != nil {
+//	  return err
+//	}
+//
+//	req.Devices = map[string]map[string]string{"eth0": eth0, "root": root}
 package lxd
@@ -4,6 +4,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
 
 	"github.com/gorilla/websocket"
 	"github.com/pkg/sftp"
@@ -169,6 +170,7 @@ type InstanceServer interface {
 	UpdateInstance(name string, instance api.InstancePut, ETag string) (op Operation, err error)
 	RenameInstance(name string, instance api.InstancePost) (op Operation, err error)
 	MigrateInstance(name string, instance api.InstancePost) (op Operation, err error)
+	CheckInstanceMigration(name string, instance api.InstancePost) (check *api.InstanceMigrationCheck, err error)
 	DeleteInstance(name string) (op Operation, err error)
 	UpdateInstances(state api.InstancesPut, ETag string) (op Operation, err error)
 
@@ -208,6 +210,13 @@ type InstanceServer interface {
 	GetInstanceState(name string) (state *api.InstanceState, ETag string, err error)
 	UpdateInstanceState(name string, state api.InstanceStatePut, ETag string) (op Operation, err error)
 
+	RebuildInstance(instanceName string, req api.InstanceRebuildPost) (op Operation, err error)
+	RebuildInstanceFromImage(source ImageServer, image api.Image, instanceName string, req api.InstanceRebuildPost) (op RemoteOperation, err error)
+
+	GetInstanceSecretNames(instanceName string) (names []string, err error)
+	CreateInstanceSecret(instanceName string, secret api.InstanceSecretsPost) (err error)
+	DeleteInstanceSecret(instanceName string, name string) (err error)
+
 	GetInstanceLogfiles(name string) (logfiles []string, err error)
 	GetInstanceLogfile(name string, filename string) (content io.ReadCloser, err error)
 	DeleteInstanceLogfile(name string, filename string) (err error)
@@ -243,6 +252,8 @@ type InstanceServer interface {
 	GetNetwork(name string) (network *api.Network, ETag string, err error)
 	GetNetworkLeases(name string) (leases []api.NetworkLease, err error)
 	GetNetworkState(name string) (state *api.NetworkState, err error)
+	GetNetworkNetplan(name string) (netplan string, err error)
+	GetNetworkSysctls() (sysctls []api.NetworkSysctl, err error)
 	CreateNetwork(network api.NetworksPost) (err error)
 	UpdateNetwork(name string, network api.NetworkPut, ETag string) (err error)
 	RenameNetwork(name string, network api.NetworkPost) (err error)
@@ -256,6 +267,21 @@ type InstanceServer interface {
 	UpdateNetworkForward(networkName string, listenAddress string, forward api.NetworkForwardPut, ETag string) (err error)
 	DeleteNetworkForward(networkName string, listenAddress string) (err error)
 
+	// Network load balancer functions ("network_load_balancer" API extension)
+	GetNetworkLoadBalancerAddresses(networkName string) ([]string, error)
+	GetNetworkLoadBalancers(networkName string) ([]api.NetworkLoadBalancer, error)
+	GetNetworkLoadBalancer(networkName string, listenAddress string) (loadBalancer *api.NetworkLoadBalancer, ETag string, err error)
+	CreateNetworkLoadBalancer(networkName string, loadBalancer api.NetworkLoadBalancersPost) error
+	UpdateNetworkLoadBalancer(networkName string, listenAddress string, loadBalancer api.NetworkLoadBalancerPut, ETag string) (err error)
+	DeleteNetworkLoadBalancer(networkName string, listenAddress string) (err error)
+
+	// Network DHCP reservation functions ("network_dhcp_reservations" API extension)
+	GetNetworkReservationAddresses(networkName string) ([]string, error)
+	GetNetworkReservations(networkName string) ([]api.NetworkReservation, error)
+	GetNetworkReservation(networkName string, hwaddr string) (reservation *api.NetworkReservation, ETag string, err error)
+	CreateNetworkReservation(networkName string, reservation api.NetworkReservationsPost) error
+	DeleteNetworkReservation(networkName string, hwaddr string) (err error)
+
 	// Network peer functions ("network_peer" API extension)
 	GetNetworkPeerNames(networkName string) ([]string, error)
 	GetNetworkPeers(networkName string) ([]api.NetworkPeer, error)
@@ -292,6 +318,7 @@ type InstanceServer interface {
 	// Operation functions
 	GetOperationUUIDs() (uuids []string, err error)
 	GetOperations() (operations []api.Operation, err error)
+	GetOperationsAllProjects() (operations []api.Operation, err error)
 	GetOperation(uuid string) (op *api.Operation, ETag string, err error)
 	GetOperationWait(uuid string, timeout int) (op *api.Operation, ETag string, err error)
 	GetOperationWaitSecret(uuid string, secret string, timeout int) (op *api.Operation, ETag string, err error)
@@ -317,11 +344,21 @@ type InstanceServer interface {
 	RenameProject(name string, project api.ProjectPost) (op Operation, err error)
 	DeleteProject(name string) (err error)
 
+	// Local RBAC role functions ("rbac_local" API extension)
+	GetRBACRoleNames() (names []string, err error)
+	GetRBACRoles() (roles []api.RBACRole, err error)
+	GetRBACRole(name string) (role *api.RBACRole, ETag string, err error)
+	CreateRBACRole(role api.RBACRolesPost) (err error)
+	UpdateRBACRole(name string, role api.RBACRolePut, ETag string) (err error)
+	RenameRBACRole(name string, role api.RBACRolePost) (err error)
+	DeleteRBACRole(name string) (err error)
+
 	// Storage pool functions ("storage" API extension)
 	GetStoragePoolNames() (names []string, err error)
 	GetStoragePools() (pools []api.StoragePool, err error)
 	GetStoragePool(name string) (pool *api.StoragePool, ETag string, err error)
 	GetStoragePoolResources(name string) (resources *api.ResourcesStoragePool, err error)
+	GetStoragePoolState(name string) (state *api.StoragePoolState, err error)
 	CreateStoragePool(pool api.StoragePoolsPost) (err error)
 	UpdateStoragePool(name string, pool api.StoragePoolPut, ETag string) (err error)
 	DeleteStoragePool(name string) (err error)
@@ -370,6 +407,7 @@ type InstanceServer interface {
 	RenameClusterMember(name string, member api.ClusterMemberPost) (err error)
 	CreateClusterMember(member api.ClusterMembersPost) (op Operation, err error)
 	UpdateClusterCertificate(certs api.ClusterCertificatePut, ETag string) (err error)
+	GetClusterMemberState(name string) (state *api.ClusterMemberState, ETag string, err error)
 	UpdateClusterMemberState(name string, state api.ClusterMemberStatePost) (op Operation, err error)
 	GetClusterGroups() ([]api.ClusterGroup, error)
 	GetClusterGroupNames() ([]string, error)
@@ -379,6 +417,21 @@ type InstanceServer interface {
 	UpdateClusterGroup(name string, group api.ClusterGroupPut, ETag string) error
 	GetClusterGroup(name string) (*api.ClusterGroup, string, error)
 
+	// Task functions ("daemon_tasks" API extension)
+	GetTaskNames() (names []string, err error)
+	GetTasks() (tasks []api.Task, err error)
+	GetTask(name string) (task *api.Task, err error)
+	ControlTask(name string, task api.TaskPost) (err error)
+
+	// Trash functions ("deletion_protection" API extension)
+	GetTrashEntries() (entries []api.TrashEntry, err error)
+	GetTrashInstance(name string) (entry *api.TrashEntry, err error)
+	RestoreTrashInstance(name string, entry api.TrashEntryPost) (op Operation, err error)
+	DeleteTrashInstance(name string) (op Operation, err error)
+	GetTrashStoragePoolVolume(pool string, name string) (entry *api.TrashEntry, err error)
+	RestoreTrashStoragePoolVolume(pool string, name string, entry api.TrashEntryPost) (err error)
+	DeleteTrashStoragePoolVolume(pool string, name string) (err error)
+
 	// Warning functions
 	GetWarningUUIDs() (uuids []string, err error)
 	GetWarnings() (warnings []api.Warning, err error)
@@ -614,6 +667,11 @@ type InstanceExecArgs struct {
 	// Control message handler (window resize, signals, ...)
 	Control func(conn *websocket.Conn)
 
+	// API extension: instance_exec_extra_fds
+	// Additional file descriptors passed to the command (starting at 3), one per requested extra-fds.
+	// Only available over the local unix socket.
+	ExtraFiles []*os.File
+
 	// Channel that will be closed when all data operations are done
 	DataDone chan bool
 }
@@ -225,7 +225,12 @@ func lxdParseResponse(resp *http.Response) (*api.Response, string, error) {
 
 	// Handle errors
 	if response.Type == api.ErrorResponse {
-		return nil, "", api.StatusErrorf(resp.StatusCode, response.Error)
+		err := api.StatusErrorf(resp.StatusCode, response.Error)
+		if response.ErrorType != "" {
+			return nil, "", api.ErrorWithType(response.ErrorType, err)
+		}
+
+		return nil, "", err
 	}
 
 	return &response, etag, nil
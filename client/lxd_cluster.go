@@ -173,6 +173,22 @@ func (r *ProtocolLXD) UpdateClusterCertificate(certs api.ClusterCertificatePut,
 	return nil
 }
 
+// GetClusterMemberState gets state information about a cluster member.
+func (r *ProtocolLXD) GetClusterMemberState(name string) (*api.ClusterMemberState, string, error) {
+	if !r.HasExtension("cluster_member_inventory") {
+		return nil, "", fmt.Errorf("The server is missing the required \"cluster_member_inventory\" API extension")
+	}
+
+	state := api.ClusterMemberState{}
+
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/cluster/members/%s/state", name), nil, "", &state)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &state, etag, nil
+}
+
 // UpdateClusterMemberState evacuates or restores a cluster member.
 func (r *ProtocolLXD) UpdateClusterMemberState(name string, state api.ClusterMemberStatePost) (Operation, error) {
 	if !r.HasExtension("clustering_evacuation") {
@@ -487,6 +487,10 @@ func (r *ProtocolLXD) CreateInstance(instance api.InstancesPost) (Operation, err
 		}
 	}
 
+	if instance.Count > 1 && !r.HasExtension("instances_create_count") {
+		return nil, fmt.Errorf("The server is missing the required \"instances_create_count\" API extension")
+	}
+
 	// Send the request
 	op, _, err := r.queryOperation("POST", path, instance, "")
 	if err != nil {
@@ -979,6 +983,36 @@ func (r *ProtocolLXD) MigrateInstance(name string, instance api.InstancePost) (O
 	return op, nil
 }
 
+// CheckInstanceMigration runs the migration pre-flight checks of the instance against the target cluster member.
+func (r *ProtocolLXD) CheckInstanceMigration(name string, instance api.InstancePost) (*api.InstanceMigrationCheck, error) {
+	if !r.HasExtension("instance_migration_check") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_migration_check\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Quick checks.
+	if r.clusterTarget == "" {
+		return nil, fmt.Errorf("Migration checks require a target cluster member")
+	}
+
+	instance.Migration = true
+	instance.DryRun = true
+
+	check := api.InstanceMigrationCheck{}
+
+	// Send the request
+	_, err = r.queryStruct("POST", fmt.Sprintf("%s/%s", path, url.PathEscape(name)), instance, "", &check)
+	if err != nil {
+		return nil, err
+	}
+
+	return &check, nil
+}
+
 // DeleteInstance requests that LXD deletes the instance.
 func (r *ProtocolLXD) DeleteInstance(name string) (Operation, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -1009,6 +1043,20 @@ func (r *ProtocolLXD) ExecInstance(instanceName string, exec api.InstanceExecPos
 		}
 	}
 
+	if exec.ExtraFDs > 0 {
+		if !r.HasExtension("instance_exec_extra_fds") {
+			return nil, fmt.Errorf("The server is missing the required \"instance_exec_extra_fds\" API extension")
+		}
+
+		if r.httpProtocol != "unix" {
+			return nil, fmt.Errorf("Additional file descriptors can only be passed over the local unix socket")
+		}
+
+		if args == nil || len(args.ExtraFiles) != exec.ExtraFDs {
+			return nil, fmt.Errorf("Expected %d additional files to pass", exec.ExtraFDs)
+		}
+	}
+
 	var uri string
 
 	if r.IsAgent() {
@@ -1052,6 +1100,20 @@ func (r *ProtocolLXD) ExecInstance(instanceName string, exec api.InstanceExecPos
 			go args.Control(conn)
 		}
 
+		// Pass the additional file descriptors
+		if exec.ExtraFDs > 0 {
+			conn, err := r.GetOperationWebsocket(opAPI.ID, fds["extra-fds"])
+			if err != nil {
+				return nil, err
+			}
+
+			err = execSendFiles(conn, args.ExtraFiles)
+			_ = conn.Close()
+			if err != nil {
+				return nil, fmt.Errorf("Failed passing additional file descriptors: %w", err)
+			}
+		}
+
 		if exec.Interactive {
 			// Handle interactive sections
 			if args.Stdin != nil && args.Stdout != nil {
@@ -1918,6 +1980,145 @@ func (r *ProtocolLXD) UpdateInstanceState(name string, state api.InstanceStatePu
 	return op, nil
 }
 
+// RebuildInstance recreates the root volume of the instance from the requested source.
+func (r *ProtocolLXD) RebuildInstance(instanceName string, req api.InstanceRebuildPost) (Operation, error) {
+	if !r.HasExtension("instance_rebuild") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_rebuild\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/rebuild", path, url.PathEscape(instanceName)), req, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// RebuildInstanceFromImage recreates the root volume of the instance from an image, possibly on a remote server.
+func (r *ProtocolLXD) RebuildInstanceFromImage(source ImageServer, image api.Image, instanceName string, req api.InstanceRebuildPost) (RemoteOperation, error) {
+	// Set the minimal source fields
+	req.Source.Type = "image"
+
+	// Optimization for the local image case
+	if r.isSameServer(source) {
+		// Always use fingerprints for local case
+		req.Source.Fingerprint = image.Fingerprint
+		req.Source.Alias = ""
+
+		op, err := r.RebuildInstance(instanceName, req)
+		if err != nil {
+			return nil, err
+		}
+
+		rop := remoteOperation{
+			targetOp: op,
+			chDone:   make(chan bool),
+		}
+
+		// Forward targetOp to remote op
+		go func() {
+			rop.err = rop.targetOp.Wait()
+			close(rop.chDone)
+		}()
+
+		return &rop, nil
+	}
+
+	// Minimal source fields for remote image
+	req.Source.Mode = "pull"
+
+	// If we have an alias and the image is public, use that
+	if req.Source.Alias != "" && image.Public {
+		req.Source.Fingerprint = ""
+	} else {
+		req.Source.Fingerprint = image.Fingerprint
+		req.Source.Alias = ""
+	}
+
+	// Get source server connection information
+	info, err := source.GetConnectionInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Source.Protocol = info.Protocol
+	req.Source.Certificate = info.Certificate
+
+	// Generate secret token if needed
+	if !image.Public {
+		secret, err := source.GetImageSecret(image.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Source.Secret = secret
+	}
+
+	return r.tryRebuildInstance(instanceName, req, info.Addresses)
+}
+
+// tryRebuildInstance attempts to rebuild the instance using each of the image server addresses in turn.
+func (r *ProtocolLXD) tryRebuildInstance(instanceName string, req api.InstanceRebuildPost, urls []string) (RemoteOperation, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("The source server isn't listening on the network")
+	}
+
+	rop := remoteOperation{
+		chDone: make(chan bool),
+	}
+
+	// Forward targetOp to remote op
+	go func() {
+		success := false
+		var errors []remoteOperationResult
+		for _, serverURL := range urls {
+			req.Source.Server = serverURL
+
+			op, err := r.RebuildInstance(instanceName, req)
+			if err != nil {
+				errors = append(errors, remoteOperationResult{URL: serverURL, Error: err})
+				continue
+			}
+
+			rop.handlerLock.Lock()
+			rop.targetOp = op
+			rop.handlerLock.Unlock()
+
+			for _, handler := range rop.handlers {
+				_, _ = rop.targetOp.AddHandler(handler)
+			}
+
+			err = rop.targetOp.Wait()
+			if err != nil {
+				errors = append(errors, remoteOperationResult{URL: serverURL, Error: err})
+
+				if shared.IsConnectionError(err) {
+					continue
+				}
+
+				break
+			}
+
+			success = true
+			break
+		}
+
+		if !success {
+			rop.err = remoteOperationError("Failed instance rebuild", errors)
+		}
+
+		close(rop.chDone)
+	}()
+
+	return &rop, nil
+}
+
 // GetInstanceLogfiles returns a list of logfiles for the instance.
 func (r *ProtocolLXD) GetInstanceLogfiles(name string) ([]string, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2457,6 +2658,10 @@ func (r *ProtocolLXD) CreateInstanceBackup(instanceName string, backup api.Insta
 		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
 	}
 
+	if backup.Format != "" && !r.HasExtension("instance_backup_oci") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_backup_oci\" API extension")
+	}
+
 	// Send the request
 	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/backups", path, url.PathEscape(instanceName)), backup, "")
 	if err != nil {
@@ -2657,3 +2862,66 @@ func (r *ProtocolLXD) proxyMigration(targetOp *operation, targetSecrets map[stri
 
 	return nil
 }
+
+// GetInstanceSecretNames returns the names of the secrets staged for the instance and not yet retrieved.
+func (r *ProtocolLXD) GetInstanceSecretNames(instanceName string) ([]string, error) {
+	if !r.HasExtension("instance_secrets") {
+		return nil, fmt.Errorf("The server is missing the required \"instance_secrets\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the raw URL values.
+	urls := []string{}
+	baseURL := fmt.Sprintf("%s/%s/secrets", path, url.PathEscape(instanceName))
+	_, err = r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// CreateInstanceSecret stages a secret for the instance, retrievable once from inside the instance.
+func (r *ProtocolLXD) CreateInstanceSecret(instanceName string, secret api.InstanceSecretsPost) error {
+	if !r.HasExtension("instance_secrets") {
+		return fmt.Errorf("The server is missing the required \"instance_secrets\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	// Send the request
+	_, _, err = r.query("POST", fmt.Sprintf("%s/%s/secrets", path, url.PathEscape(instanceName)), secret, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteInstanceSecret deletes a secret staged for the instance before it's retrieved.
+func (r *ProtocolLXD) DeleteInstanceSecret(instanceName string, name string) error {
+	if !r.HasExtension("instance_secrets") {
+		return fmt.Errorf("The server is missing the required \"instance_secrets\" API extension")
+	}
+
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	// Send the request
+	_, _, err = r.query("DELETE", fmt.Sprintf("%s/%s/secrets/%s", path, url.PathEscape(instanceName), url.PathEscape(name)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkReservationAddresses returns a list of network DHCP reservation MAC addresses.
+func (r *ProtocolLXD) GetNetworkReservationAddresses(networkName string) ([]string, error) {
+	if !r.HasExtension("network_dhcp_reservations") {
+		return nil, fmt.Errorf(`The server is missing the required "network_dhcp_reservations" API extension`)
+	}
+
+	// Fetch the raw URL values.
+	urls := []string{}
+	baseURL := fmt.Sprintf("/networks/%s/reservations", url.PathEscape(networkName))
+	_, err := r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetNetworkReservations returns a list of Network DHCP reservation structs.
+func (r *ProtocolLXD) GetNetworkReservations(networkName string) ([]api.NetworkReservation, error) {
+	if !r.HasExtension("network_dhcp_reservations") {
+		return nil, fmt.Errorf(`The server is missing the required "network_dhcp_reservations" API extension`)
+	}
+
+	reservations := []api.NetworkReservation{}
+
+	// Fetch the raw value.
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/reservations?recursion=1", url.PathEscape(networkName)), nil, "", &reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// GetNetworkReservation returns a Network DHCP reservation entry for the provided network and MAC address.
+func (r *ProtocolLXD) GetNetworkReservation(networkName string, hwaddr string) (*api.NetworkReservation, string, error) {
+	if !r.HasExtension("network_dhcp_reservations") {
+		return nil, "", fmt.Errorf(`The server is missing the required "network_dhcp_reservations" API extension`)
+	}
+
+	reservation := api.NetworkReservation{}
+
+	// Fetch the raw value.
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/reservations/%s", url.PathEscape(networkName), url.PathEscape(hwaddr)), nil, "", &reservation)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &reservation, etag, nil
+}
+
+// CreateNetworkReservation defines a new network DHCP reservation using the provided struct.
+func (r *ProtocolLXD) CreateNetworkReservation(networkName string, reservation api.NetworkReservationsPost) error {
+	if !r.HasExtension("network_dhcp_reservations") {
+		return fmt.Errorf(`The server is missing the required "network_dhcp_reservations" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("POST", fmt.Sprintf("/networks/%s/reservations", url.PathEscape(networkName)), reservation, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNetworkReservation deletes an existing network DHCP reservation.
+func (r *ProtocolLXD) DeleteNetworkReservation(networkName string, hwaddr string) error {
+	if !r.HasExtension("network_dhcp_reservations") {
+		return fmt.Errorf(`The server is missing the required "network_dhcp_reservations" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("DELETE", fmt.Sprintf("/networks/%s/reservations/%s", url.PathEscape(networkName), url.PathEscape(hwaddr)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
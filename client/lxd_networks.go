@@ -93,12 +93,54 @@ func (r *ProtocolLXD) GetNetworkState(name string) (*api.NetworkState, error) {
 	return &state, nil
 }
 
+// GetNetworkNetplan returns the configuration of a bridge network as a netplan definition.
+func (r *ProtocolLXD) GetNetworkNetplan(name string) (string, error) {
+	if !r.HasExtension("network_netplan") {
+		return "", fmt.Errorf("The server is missing the required \"network_netplan\" API extension")
+	}
+
+	netplan := ""
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/networks/%s/netplan", url.PathEscape(name)), nil, "", &netplan)
+	if err != nil {
+		return "", err
+	}
+
+	return netplan, nil
+}
+
+// GetNetworkSysctls returns the host sysctls changed by LXD, along with their previous and current values.
+func (r *ProtocolLXD) GetNetworkSysctls() ([]api.NetworkSysctl, error) {
+	if !r.HasExtension("network_sysctls") {
+		return nil, fmt.Errorf("The server is missing the required \"network_sysctls\" API extension")
+	}
+
+	sysctls := []api.NetworkSysctl{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", "/network-sysctls", nil, "", &sysctls)
+	if err != nil {
+		return nil, err
+	}
+
+	return sysctls, nil
+}
+
 // CreateNetwork defines a new network using the provided Network struct
 func (r *ProtocolLXD) CreateNetwork(network api.NetworksPost) error {
 	if !r.HasExtension("network") {
 		return fmt.Errorf("The server is missing the required \"network\" API extension")
 	}
 
+	if network.Adopt && !r.HasExtension("network_bridge_adopt") {
+		return fmt.Errorf("The server is missing the required \"network_bridge_adopt\" API extension")
+	}
+
+	if network.Netplan != "" && !r.HasExtension("network_netplan") {
+		return fmt.Errorf("The server is missing the required \"network_netplan\" API extension")
+	}
+
 	// Send the request
 	_, _, err := r.query("POST", "/networks", network, "")
 	if err != nil {
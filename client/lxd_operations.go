@@ -42,6 +42,29 @@ func (r *ProtocolLXD) GetOperations() ([]api.Operation, error) {
 	return operations, nil
 }
 
+// GetOperationsAllProjects returns a list of operations from all projects.
+func (r *ProtocolLXD) GetOperationsAllProjects() ([]api.Operation, error) {
+	if !r.HasExtension("operations_all_projects") {
+		return nil, fmt.Errorf(`The server is missing the required "operations_all_projects" API extension`)
+	}
+
+	apiOperations := map[string][]api.Operation{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", "/operations?recursion=1&all-projects=true", nil, "", &apiOperations)
+	if err != nil {
+		return nil, err
+	}
+
+	// Turn it into just a list of operations
+	operations := []api.Operation{}
+	for _, v := range apiOperations {
+		operations = append(operations, v...)
+	}
+
+	return operations, nil
+}
+
 // GetOperation returns an Operation entry for the provided uuid
 func (r *ProtocolLXD) GetOperation(uuid string) (*api.Operation, string, error) {
 	op := api.Operation{}
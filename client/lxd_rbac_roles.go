@@ -0,0 +1,120 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetRBACRoleNames returns a list of local RBAC role names.
+func (r *ProtocolLXD) GetRBACRoleNames() ([]string, error) {
+	if !r.HasExtension("rbac_local") {
+		return nil, fmt.Errorf(`The server is missing the required "rbac_local" API extension`)
+	}
+
+	// Fetch the raw URL values.
+	urls := []string{}
+	baseURL := "/rbac/roles"
+	_, err := r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetRBACRoles returns a list of local RBAC role structs.
+func (r *ProtocolLXD) GetRBACRoles() ([]api.RBACRole, error) {
+	if !r.HasExtension("rbac_local") {
+		return nil, fmt.Errorf(`The server is missing the required "rbac_local" API extension`)
+	}
+
+	roles := []api.RBACRole{}
+
+	// Fetch the raw value.
+	_, err := r.queryStruct("GET", "/rbac/roles?recursion=1", nil, "", &roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// GetRBACRole returns a local RBAC role entry for the provided name.
+func (r *ProtocolLXD) GetRBACRole(name string) (*api.RBACRole, string, error) {
+	if !r.HasExtension("rbac_local") {
+		return nil, "", fmt.Errorf(`The server is missing the required "rbac_local" API extension`)
+	}
+
+	role := api.RBACRole{}
+
+	// Fetch the raw value.
+	etag, err := r.queryStruct("GET", fmt.Sprintf("/rbac/roles/%s", url.PathEscape(name)), nil, "", &role)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &role, etag, nil
+}
+
+// CreateRBACRole defines a new local RBAC role using the provided struct.
+func (r *ProtocolLXD) CreateRBACRole(role api.RBACRolesPost) error {
+	if !r.HasExtension("rbac_local") {
+		return fmt.Errorf(`The server is missing the required "rbac_local" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("POST", "/rbac/roles", role, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateRBACRole updates the local RBAC role to match the provided struct.
+func (r *ProtocolLXD) UpdateRBACRole(name string, role api.RBACRolePut, ETag string) error {
+	if !r.HasExtension("rbac_local") {
+		return fmt.Errorf(`The server is missing the required "rbac_local" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("PUT", fmt.Sprintf("/rbac/roles/%s", url.PathEscape(name)), role, ETag)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RenameRBACRole renames an existing local RBAC role entry.
+func (r *ProtocolLXD) RenameRBACRole(name string, role api.RBACRolePost) error {
+	if !r.HasExtension("rbac_local") {
+		return fmt.Errorf(`The server is missing the required "rbac_local" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("POST", fmt.Sprintf("/rbac/roles/%s", url.PathEscape(name)), role, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteRBACRole deletes an existing local RBAC role.
+func (r *ProtocolLXD) DeleteRBACRole(name string) error {
+	if !r.HasExtension("rbac_local") {
+		return fmt.Errorf(`The server is missing the required "rbac_local" API extension`)
+	}
+
+	// Send the request.
+	_, _, err := r.query("DELETE", fmt.Sprintf("/rbac/roles/%s", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
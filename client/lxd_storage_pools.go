@@ -126,3 +126,20 @@ func (r *ProtocolLXD) GetStoragePoolResources(name string) (*api.ResourcesStorag
 
 	return &res, nil
 }
+
+// GetStoragePoolState gets the health and usage of a given storage pool
+func (r *ProtocolLXD) GetStoragePoolState(name string) (*api.StoragePoolState, error) {
+	if !r.HasExtension("storage_pool_state") {
+		return nil, fmt.Errorf("The server is missing the required \"storage_pool_state\" API extension")
+	}
+
+	state := api.StoragePoolState{}
+
+	// Fetch the raw value
+	_, err := r.queryStruct("GET", fmt.Sprintf("/storage-pools/%s/state", url.PathEscape(name)), nil, "", &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
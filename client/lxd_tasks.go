@@ -0,0 +1,74 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Task handling functions
+
+// GetTaskNames returns the names of the background tasks of the server.
+func (r *ProtocolLXD) GetTaskNames() ([]string, error) {
+	if !r.HasExtension("daemon_tasks") {
+		return nil, fmt.Errorf("The server is missing the required \"daemon_tasks\" API extension")
+	}
+
+	// Fetch the raw values.
+	urls := []string{}
+	baseURL := "/tasks"
+	_, err := r.queryStruct("GET", baseURL, nil, "", &urls)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse it.
+	return urlsToResourceNames(baseURL, urls...)
+}
+
+// GetTasks returns the background tasks of the server.
+func (r *ProtocolLXD) GetTasks() ([]api.Task, error) {
+	if !r.HasExtension("daemon_tasks") {
+		return nil, fmt.Errorf("The server is missing the required \"daemon_tasks\" API extension")
+	}
+
+	tasks := []api.Task{}
+
+	_, err := r.queryStruct("GET", "/tasks?recursion=1", nil, "", &tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// GetTask returns the background task with the given name.
+func (r *ProtocolLXD) GetTask(name string) (*api.Task, error) {
+	if !r.HasExtension("daemon_tasks") {
+		return nil, fmt.Errorf("The server is missing the required \"daemon_tasks\" API extension")
+	}
+
+	task := api.Task{}
+
+	_, err := r.queryStruct("GET", fmt.Sprintf("/tasks/%s", url.PathEscape(name)), nil, "", &task)
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// ControlTask runs, pauses or resumes the background task with the given name.
+func (r *ProtocolLXD) ControlTask(name string, task api.TaskPost) error {
+	if !r.HasExtension("daemon_tasks") {
+		return fmt.Errorf("The server is missing the required \"daemon_tasks\" API extension")
+	}
+
+	_, _, err := r.query("POST", fmt.Sprintf("/tasks/%s", url.PathEscape(name)), task, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package lxd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetTrashEntries returns the trashed instances and custom storage volumes of the project.
+func (r *ProtocolLXD) GetTrashEntries() ([]api.TrashEntry, error) {
+	if !r.HasExtension("deletion_protection") {
+		return nil, fmt.Errorf(`The server is missing the required "deletion_protection" API extension`)
+	}
+
+	entries := []api.TrashEntry{}
+
+	// Fetch the raw value.
+	_, err := r.queryStruct("GET", "/trash?recursion=1", nil, "", &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetTrashInstance returns the trash entry of the instance kept under the given name.
+func (r *ProtocolLXD) GetTrashInstance(name string) (*api.TrashEntry, error) {
+	if !r.HasExtension("deletion_protection") {
+		return nil, fmt.Errorf(`The server is missing the required "deletion_protection" API extension`)
+	}
+
+	entry := api.TrashEntry{}
+
+	// Fetch the raw value.
+	_, err := r.queryStruct("GET", fmt.Sprintf("/trash/instances/%s", url.PathEscape(name)), nil, "", &entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// RestoreTrashInstance moves the instance kept under the given name out of the trash.
+func (r *ProtocolLXD) RestoreTrashInstance(name string, entry api.TrashEntryPost) (Operation, error) {
+	if !r.HasExtension("deletion_protection") {
+		return nil, fmt.Errorf(`The server is missing the required "deletion_protection" API extension`)
+	}
+
+	// Send the request.
+	op, _, err := r.queryOperation("POST", fmt.Sprintf("/trash/instances/%s", url.PathEscape(name)), entry, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// DeleteTrashInstance purges the instance kept under the given name from the trash.
+func (r *ProtocolLXD) DeleteTrashInstance(name string) (Operation, error) {
+	if !r.HasExtension("deletion_protection") {
+		return nil, fmt.Errorf(`The server is missing the required "deletion_protection" API extension`)
+	}
+
+	// Send the request.
+	op, _, err := r.queryOperation("DELETE", fmt.Sprintf("/trash/instances/%s", url.PathEscape(name)), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// GetTrashStoragePoolVolume returns the trash entry of the custom storage volume kept under the given name.
+func (r *ProtocolLXD) GetTrashStoragePoolVolume(pool string, name string) (*api.TrashEntry, error) {
+	if !r.HasExtension("deletion_protection") {
+		return nil, fmt.Errorf(`The server is missing the required "deletion_protection" API extension`)
+	}
+
+	entry := api.TrashEntry{}
+
+	// Fetch the raw value.
+	path := fmt.Sprintf("/trash/storage-pools/%s/volumes/%s", url.PathEscape(pool), url.PathEscape(name))
+	_, err := r.queryStruct("GET", path, nil, "", &entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// RestoreTrashStoragePoolVolume moves the custom storage volume kept under the given name out of the trash.
+func (r *ProtocolLXD) RestoreTrashStoragePoolVolume(pool string, name string, entry api.TrashEntryPost) error {
+	if !r.HasExtension("deletion_protection") {
+		return fmt.Errorf(`The server is missing the required "deletion_protection" API extension`)
+	}
+
+	// Send the request.
+	path := fmt.Sprintf("/trash/storage-pools/%s/volumes/%s", url.PathEscape(pool), url.PathEscape(name))
+	_, _, err := r.query("POST", path, entry, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTrashStoragePoolVolume purges the custom storage volume kept under the given name from the trash.
+func (r *ProtocolLXD) DeleteTrashStoragePoolVolume(pool string, name string) error {
+	if !r.HasExtension("deletion_protection") {
+		return fmt.Errorf(`The server is missing the required "deletion_protection" API extension`)
+	}
+
+	// Send the request.
+	path := fmt.Sprintf("/trash/storage-pools/%s/volumes/%s", url.PathEscape(pool), url.PathEscape(name))
+	_, _, err := r.query("DELETE", path, nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
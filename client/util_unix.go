@@ -0,0 +1,28 @@
+//go:build !windows
+
+package lxd
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/sys/unix"
+)
+
+// execSendFiles passes the files as SCM_RIGHTS on the unix socket underlying the websocket.
+func execSendFiles(conn *websocket.Conn, files []*os.File) error {
+	unixConn, ok := conn.UnderlyingConn().(*net.UnixConn)
+	if !ok {
+		return fmt.Errorf("Connection isn't a unix socket")
+	}
+
+	fds := make([]int, 0, len(files))
+	for _, f := range files {
+		fds = append(fds, int(f.Fd()))
+	}
+
+	_, _, err := unixConn.WriteMsgUnix([]byte{0}, unix.UnixRights(fds...), nil)
+	return err
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package lxd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gorilla/websocket"
+)
+
+// execSendFiles isn't supported on Windows as there is no way to pass file descriptors.
+func execSendFiles(conn *websocket.Conn, files []*os.File) error {
+	return fmt.Errorf("Passing file descriptors isn't supported on Windows")
+}
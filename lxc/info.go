@@ -366,6 +366,17 @@ func (c *cmdInfo) remoteInfo(d lxd.InstanceServer) error {
 			fmt.Printf("    "+i18n.G("Total: %v")+"\n", units.GetByteSizeStringIEC(int64(resources.Memory.HugepagesTotal), 2))
 		}
 
+		for _, pool := range resources.Memory.HugepagesPools {
+			if pool.Total == 0 {
+				continue
+			}
+
+			fmt.Printf("  "+i18n.G("Hugepages pool (%s):")+"\n", units.GetByteSizeStringIEC(int64(pool.Size), 0))
+			fmt.Printf("    "+i18n.G("Free: %v")+"\n", units.GetByteSizeStringIEC(int64(pool.Free), 2))
+			fmt.Printf("    "+i18n.G("Reserved: %v")+"\n", units.GetByteSizeStringIEC(int64(pool.Reserved), 2))
+			fmt.Printf("    "+i18n.G("Total: %v")+"\n", units.GetByteSizeStringIEC(int64(pool.Total), 2))
+		}
+
 		if len(resources.Memory.Nodes) > 1 {
 			fmt.Printf("  " + i18n.G("NUMA nodes:"+"\n"))
 			for _, node := range resources.Memory.Nodes {
@@ -577,6 +588,18 @@ func (c *cmdInfo) instanceInfo(d lxd.InstanceServer, remote config.Remote, name
 			fmt.Printf("  %s\n", i18n.G("Network usage:"))
 			fmt.Print(networkInfo)
 		}
+
+		// Sampled NIC traffic.
+		if len(inst.State.NetworkCounters) > 0 {
+			fmt.Printf("  %s\n", i18n.G("Network traffic:"))
+			for devName, history := range inst.State.NetworkCounters {
+				fmt.Printf("    %s:\n", devName)
+				fmt.Printf("      %s: %s\n", i18n.G("Bytes received"), units.GetByteSizeString(history.Total.BytesReceived, 2))
+				fmt.Printf("      %s: %s\n", i18n.G("Bytes sent"), units.GetByteSizeString(history.Total.BytesSent, 2))
+				fmt.Printf("      %s: %d\n", i18n.G("Packets received"), history.Total.PacketsReceived)
+				fmt.Printf("      %s: %d\n", i18n.G("Packets sent"), history.Total.PacketsSent)
+			}
+		}
 	}
 
 	// List snapshots
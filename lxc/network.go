@@ -277,6 +277,8 @@ func (c *cmdNetworkAttachProfile) Run(cmd *cobra.Command, args []string) error {
 type cmdNetworkCreate struct {
 	global  *cmdGlobal
 	network *cmdNetwork
+
+	flagAdopt bool
 }
 
 func (c *cmdNetworkCreate) Command() *cobra.Command {
@@ -287,6 +289,7 @@ func (c *cmdNetworkCreate) Command() *cobra.Command {
 
 	cmd.Flags().StringVar(&c.network.flagTarget, "target", "", i18n.G("Cluster member name")+"``")
 	cmd.Flags().StringVarP(&c.network.flagType, "type", "t", "", i18n.G("Network type")+"``")
+	cmd.Flags().BoolVar(&c.flagAdopt, "adopt", false, i18n.G("Adopt an existing unmanaged bridge interface of the same name"))
 
 	cmd.RunE = c.Run
 
@@ -314,6 +317,7 @@ func (c *cmdNetworkCreate) Run(cmd *cobra.Command, args []string) error {
 	network.Name = resource.name
 	network.Config = map[string]string{}
 	network.Type = c.network.flagType
+	network.Adopt = c.flagAdopt
 
 	for i := 1; i < len(args); i++ {
 		entry := strings.SplitN(args[i], "=", 2)
@@ -865,6 +869,49 @@ func (c *cmdNetworkInfo) Run(cmd *cobra.Command, args []string) error {
 		fmt.Printf("  %s: %s\n", i18n.G("Chassis"), state.OVN.Chassis)
 	}
 
+	// Open vSwitch information.
+	if state.OVS != nil {
+		fmt.Println("")
+		fmt.Println(i18n.G("Open vSwitch:"))
+		fmt.Printf("  %s: %v\n", i18n.G("Database connected"), state.OVS.DatabaseConnected)
+		fmt.Printf("  %s: %s\n", i18n.G("Version"), state.OVS.Version)
+
+		if len(state.OVS.Controllers) > 0 {
+			fmt.Printf("  %s:\n", i18n.G("Controllers"))
+			for _, controller := range state.OVS.Controllers {
+				fmt.Printf("    - %s (%s: %v, %s: %s)\n", controller.Target, i18n.G("connected"), controller.Connected, i18n.G("role"), controller.Role)
+			}
+		}
+	}
+
+	// DNS information.
+	if state.DNS != nil {
+		fmt.Println("")
+		fmt.Println(i18n.G("DNS:"))
+
+		if state.DNS.Cache != nil {
+			fmt.Printf("  %s:\n", i18n.G("Cache"))
+			fmt.Printf("    %s: %d\n", i18n.G("Size"), state.DNS.Cache.Size)
+			fmt.Printf("    %s: %d\n", i18n.G("Hits"), state.DNS.Cache.Hits)
+			fmt.Printf("    %s: %d\n", i18n.G("Misses"), state.DNS.Cache.Misses)
+			fmt.Printf("    %s: %d\n", i18n.G("Insertions"), state.DNS.Cache.Insertions)
+			fmt.Printf("    %s: %d\n", i18n.G("Evictions"), state.DNS.Cache.Evictions)
+		}
+
+		if state.DNS.Forkdns != nil {
+			fmt.Printf("  %s:\n", i18n.G("Cluster relay"))
+			fmt.Printf("    %s: %d\n", i18n.G("Queries"), state.DNS.Forkdns.Queries)
+			fmt.Printf("    %s: %d\n", i18n.G("Local answers"), state.DNS.Forkdns.LocalAnswers)
+			fmt.Printf("    %s: %d\n", i18n.G("Relayed queries"), state.DNS.Forkdns.RelayedQueries)
+			fmt.Printf("    %s: %d\n", i18n.G("Relayed answers"), state.DNS.Forkdns.RelayedAnswers)
+			fmt.Printf("    %s: %d\n", i18n.G("Not found"), state.DNS.Forkdns.NotFound)
+
+			for _, upstream := range state.DNS.Forkdns.Upstreams {
+				fmt.Printf("    - %s (%s: %d, %s: %d)\n", upstream.Address, i18n.G("queries"), upstream.Queries, i18n.G("failures"), upstream.Failures)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -1007,9 +1054,16 @@ func (c *cmdNetworkListLeases) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	const layout = "2006/01/02 15:04 UTC"
+
 	data := [][]string{}
 	for _, lease := range leases {
-		entry := []string{lease.Hostname, lease.Hwaddr, lease.Address, strings.ToUpper(lease.Type)}
+		expires := ""
+		if lease.ExpiresAt != nil {
+			expires = lease.ExpiresAt.UTC().Format(layout)
+		}
+
+		entry := []string{lease.Hostname, lease.Hwaddr, lease.Address, strings.ToUpper(lease.Type), expires}
 		if resource.server.IsClustered() {
 			entry = append(entry, lease.Location)
 		}
@@ -1023,6 +1077,7 @@ func (c *cmdNetworkListLeases) Run(cmd *cobra.Command, args []string) error {
 		i18n.G("MAC ADDRESS"),
 		i18n.G("IP ADDRESS"),
 		i18n.G("TYPE"),
+		i18n.G("EXPIRES AT"),
 	}
 	if resource.server.IsClustered() {
 		header = append(header, i18n.G("LOCATION"))
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxc/utils"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+	"github.com/lxc/lxd/shared/i18n"
+)
+
+type cmdRebuild struct {
+	global *cmdGlobal
+
+	flagEmpty bool
+}
+
+func (c *cmdRebuild) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = usage("rebuild", i18n.G("[<remote>:]<image> [<remote>:]<instance>"))
+	cmd.Short = i18n.G("Rebuild instances")
+	cmd.Long = cli.FormatSection(i18n.G("Description"), i18n.G(
+		`Rebuild instances
+
+Recreates the root disk of a stopped instance from a new image (or empty),
+keeping its configuration, devices and network addresses.`))
+	cmd.Example = cli.FormatSection("", i18n.G(`lxc rebuild ubuntu:22.04 u1
+
+lxc rebuild u1 --empty
+    Replace the root disk of u1 with an empty one`))
+
+	cmd.RunE = c.Run
+	cmd.Flags().BoolVar(&c.flagEmpty, "empty", false, i18n.G("Rebuild as an empty instance"))
+
+	return cmd
+}
+
+func (c *cmdRebuild) Run(cmd *cobra.Command, args []string) error {
+	conf := c.global.conf
+
+	// Quick checks.
+	minArgs := 2
+	if c.flagEmpty {
+		minArgs = 1
+	}
+
+	exit, err := c.global.CheckArgs(cmd, args, minArgs, minArgs)
+	if exit {
+		return err
+	}
+
+	// Parse the instance.
+	remote, name, err := conf.ParseRemote(args[len(args)-1])
+	if err != nil {
+		return err
+	}
+
+	d, err := conf.GetInstanceServer(remote)
+	if err != nil {
+		return err
+	}
+
+	req := api.InstanceRebuildPost{}
+
+	if c.flagEmpty {
+		req.Source.Type = "none"
+
+		op, err := d.RebuildInstance(name, req)
+		if err != nil {
+			return err
+		}
+
+		return op.Wait()
+	}
+
+	// Parse the image.
+	iremote, image, err := conf.ParseRemote(args[0])
+	if err != nil {
+		return err
+	}
+
+	var imgRemote lxd.ImageServer
+	if iremote == remote {
+		imgRemote = d
+	} else {
+		imgRemote, err = conf.GetImageServer(iremote)
+		if err != nil {
+			return err
+		}
+	}
+
+	var imgInfo *api.Image
+	if conf.Remotes[iremote].Protocol == "simplestreams" {
+		imgInfo = &api.Image{}
+		imgInfo.Fingerprint = image
+		imgInfo.Public = true
+		req.Source.Alias = image
+	} else {
+		// Attempt to resolve an image alias.
+		alias, _, err := imgRemote.GetImageAlias(image)
+		if err == nil {
+			req.Source.Alias = image
+			image = alias.Target
+		}
+
+		imgInfo, _, err = imgRemote.GetImage(image)
+		if err != nil {
+			return err
+		}
+	}
+
+	op, err := d.RebuildInstanceFromImage(imgRemote, *imgInfo, name, req)
+	if err != nil {
+		return err
+	}
+
+	// Watch the background operation.
+	progress := utils.ProgressRenderer{
+		Format: i18n.G("Retrieving image: %s"),
+		Quiet:  c.global.flagQuiet,
+	}
+
+	_, err = op.AddHandler(progress.UpdateOp)
+	if err != nil {
+		progress.Done("")
+		return err
+	}
+
+	err = utils.CancelableWait(op, &progress)
+	if err != nil {
+		progress.Done("")
+		return fmt.Errorf(i18n.G("Failed rebuilding instance %q: %w"), name, err)
+	}
+
+	progress.Done("")
+
+	return nil
+}
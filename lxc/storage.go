@@ -455,6 +455,7 @@ func (c *cmdStorageInfo) Run(cmd *cobra.Command, args []string) error {
 	descriptionstring := i18n.G("description")
 	totalspacestring := i18n.G("total space")
 	spaceusedstring := i18n.G("space used")
+	healthstring := i18n.G("health")
 
 	// Initialize the usedby map
 	poolusedby[usedbystring] = map[string][]string{}
@@ -491,6 +492,15 @@ func (c *cmdStorageInfo) Run(cmd *cobra.Command, args []string) error {
 		poolinfo[infostring][spaceusedstring] = units.GetByteSizeStringIEC(int64(res.Space.Used), 2)
 	}
 
+	if resource.server.HasExtension("storage_pool_state") {
+		state, err := resource.server.GetStoragePoolState(resource.name)
+		if err != nil {
+			return err
+		}
+
+		poolinfo[infostring][healthstring] = state.Health
+	}
+
 	poolinfodata, err := yaml.Marshal(poolinfo)
 	if err != nil {
 		return err
@@ -1226,6 +1226,14 @@ func (c *cmdStorageVolumeInfo) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if volState.Encryption != nil {
+		if volState.Encryption.Enabled {
+			fmt.Printf(i18n.G("Encryption: %s (key %s)")+"\n", volState.Encryption.Algorithm, volState.Encryption.KeyStatus)
+		} else {
+			fmt.Println(i18n.G("Encryption: none"))
+		}
+	}
+
 	// List snapshots
 	firstSnapshot := true
 	if len(volSnapshots) > 0 {
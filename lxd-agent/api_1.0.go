@@ -16,12 +16,16 @@ var api10Cmd = APIEndpoint{
 
 var api10 = []APIEndpoint{
 	api10Cmd,
+	disksCmd,
 	execCmd,
 	eventsCmd,
 	metricsCmd,
 	operationsCmd,
 	operationCmd,
 	operationWebsocket,
+	proxyCmd,
+	secretsCmd,
+	secretCmd,
 	sftpCmd,
 	stateCmd,
 }
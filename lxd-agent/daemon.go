@@ -1,6 +1,8 @@
 package main
 
 import (
+	"sync"
+
 	"github.com/lxc/lxd/lxd/events"
 )
 
@@ -8,6 +10,10 @@ import (
 type Daemon struct {
 	// Event servers
 	events *events.Server
+
+	// Secrets staged by the host, served once through /dev/lxd.
+	secrets   map[string]string
+	secretsMu sync.Mutex
 }
 
 // newDaemon returns a new Daemon object with the given configuration.
@@ -15,6 +21,7 @@ func newDaemon(debug, verbose bool) *Daemon {
 	lxdEvents := events.NewServer(debug, verbose, nil)
 
 	return &Daemon{
-		events: lxdEvents,
+		events:  lxdEvents,
+		secrets: map[string]string{},
 	}
 }
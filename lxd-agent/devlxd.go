@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -158,6 +159,40 @@ var devlxdDevicesGet = devLxdHandler{"/1.0/devices", func(d *Daemon, w http.Resp
 	return okResponse(instance.Devices, "json")
 }}
 
+var devlxdSecretsGet = devLxdHandler{"/1.0/secrets", func(d *Daemon, w http.ResponseWriter, r *http.Request) *devLxdResponse {
+	d.secretsMu.Lock()
+	defer d.secretsMu.Unlock()
+
+	names := make([]string, 0, len(d.secrets))
+	for name := range d.secrets {
+		names = append(names, fmt.Sprintf("/1.0/secrets/%s", name))
+	}
+
+	sort.Strings(names)
+
+	return okResponse(names, "json")
+}}
+
+var devlxdSecretGet = devLxdHandler{"/1.0/secrets/{name}", func(d *Daemon, w http.ResponseWriter, r *http.Request) *devLxdResponse {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return &devLxdResponse{"bad request", http.StatusBadRequest, "raw"}
+	}
+
+	d.secretsMu.Lock()
+	defer d.secretsMu.Unlock()
+
+	value, ok := d.secrets[name]
+	if !ok {
+		return &devLxdResponse{"not found", http.StatusNotFound, "raw"}
+	}
+
+	// Secrets can only be retrieved once.
+	delete(d.secrets, name)
+
+	return okResponse(value, "raw")
+}}
+
 var handlers = []devLxdHandler{
 	{"/", func(d *Daemon, w http.ResponseWriter, r *http.Request) *devLxdResponse {
 		return okResponse([]string{"/1.0"}, "json")
@@ -168,6 +203,8 @@ var handlers = []devLxdHandler{
 	devlxdMetadataGet,
 	devLxdEventsGet,
 	devlxdDevicesGet,
+	devlxdSecretsGet,
+	devlxdSecretGet,
 }
 
 func hoistReq(f func(*Daemon, http.ResponseWriter, *http.Request) *devLxdResponse, d *Daemon) func(http.ResponseWriter, *http.Request) {
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+var disksCmd = APIEndpoint{
+	Name: "disks",
+	Path: "disks",
+
+	Post: APIEndpointAction{Handler: disksPost},
+}
+
+// disksPost grows the root partition and filesystem to fill the root disk after it has been resized.
+func disksPost(d *Daemon, r *http.Request) response.Response {
+	err := growRootDisk()
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// growRootDisk grows the partition and the filesystem mounted on / to fill the disk they're on.
+func growRootDisk() error {
+	out, err := shared.RunCommand("findmnt", "-n", "-o", "SOURCE,FSTYPE", "/")
+	if err != nil {
+		return fmt.Errorf("Failed finding root filesystem: %w", err)
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return fmt.Errorf("Unexpected root filesystem details %q", strings.TrimSpace(out))
+	}
+
+	source, fsType := fields[0], fields[1]
+
+	// Resolve the block device of the root filesystem in sysfs.
+	devPath, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return fmt.Errorf("Failed resolving root device %q: %w", source, err)
+	}
+
+	sysPath, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", filepath.Base(devPath)))
+	if err != nil {
+		return fmt.Errorf("Root device %q isn't a supported block device: %w", devPath, err)
+	}
+
+	// If the root filesystem is on a partition, the disk is its parent.
+	diskSysPath := sysPath
+	partition, err := os.ReadFile(filepath.Join(sysPath, "partition"))
+	if err == nil {
+		diskSysPath = filepath.Dir(sysPath)
+	}
+
+	// Have the kernel pick up the new size of the disk (not needed for virtio-blk).
+	rescanPath := filepath.Join(diskSysPath, "device", "rescan")
+	if shared.PathExists(rescanPath) {
+		err = os.WriteFile(rescanPath, []byte("1"), 0200)
+		if err != nil {
+			return fmt.Errorf("Failed rescanning disk: %w", err)
+		}
+	}
+
+	if partition != nil {
+		diskPath := filepath.Join("/dev", filepath.Base(diskSysPath))
+		partNum := strings.TrimSpace(string(partition))
+
+		_, err = shared.RunCommand("growpart", diskPath, partNum)
+		if err != nil && !strings.Contains(err.Error(), "NOCHANGE") {
+			return fmt.Errorf("Failed growing partition %s of %q: %w", partNum, diskPath, err)
+		}
+	}
+
+	switch fsType {
+	case "ext2", "ext3", "ext4":
+		_, err = shared.RunCommand("resize2fs", devPath)
+	case "xfs":
+		_, err = shared.RunCommand("xfs_growfs", "/")
+	case "btrfs":
+		_, err = shared.RunCommand("btrfs", "filesystem", "resize", "max", "/")
+	default:
+		return fmt.Errorf("Growing %q root filesystem isn't supported", fsType)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed growing %q root filesystem: %w", fsType, err)
+	}
+
+	logger.Info("Grew root filesystem", logger.Ctx{"device": devPath, "filesystem": fsType})
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/response"
+)
+
+var proxyCmd = APIEndpoint{
+	Name: "proxy",
+	Path: "proxy",
+
+	Get: APIEndpointAction{Handler: proxyHandler},
+}
+
+func proxyHandler(d *Daemon, r *http.Request) response.Response {
+	return &proxyServe{d, r}
+}
+
+type proxyServe struct {
+	d *Daemon
+	r *http.Request
+}
+
+func (r *proxyServe) String() string {
+	return "proxy handler"
+}
+
+// Render connects to the requested address inside the guest and relays the upgraded connection to it.
+// This is used by proxy devices of virtual machines.
+func (r *proxyServe) Render(w http.ResponseWriter) error {
+	// Upgrade to proxy.
+	if r.r.Header.Get("Upgrade") != "proxy" {
+		http.Error(w, "Missing or invalid upgrade header", http.StatusBadRequest)
+		return nil
+	}
+
+	// Connect to the target first so failures can be reported.
+	fields := strings.SplitN(r.r.FormValue("connect"), ":", 2)
+	if len(fields) != 2 || (fields[0] != "tcp" && fields[0] != "unix") {
+		http.Error(w, "Missing or invalid connect address", http.StatusBadRequest)
+		return nil
+	}
+
+	target, err := net.Dial(fields[0], fields[1])
+	if err != nil {
+		http.Error(w, fmt.Errorf("Failed to connect to target: %w", err).Error(), http.StatusBadGateway)
+		return nil
+	}
+
+	defer func() { _ = target.Close() }()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "Webserver doesn't support hijacking", http.StatusInternalServerError)
+
+		return nil
+	}
+
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, fmt.Errorf("Failed to hijack connection: %w", err).Error(), http.StatusInternalServerError)
+
+		return nil
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	err = response.Upgrade(conn, "proxy")
+	if err != nil {
+		return err
+	}
+
+	// Relay until either side is done.
+	done := make(chan struct{}, 2)
+
+	go func() {
+		_, _ = io.Copy(target, bufrw)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(conn, target)
+		done <- struct{}{}
+	}()
+
+	<-done
+
+	return nil
+}
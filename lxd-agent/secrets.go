@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var secretsCmd = APIEndpoint{
+	Path: "secrets",
+
+	Get:  APIEndpointAction{Handler: secretsGet},
+	Post: APIEndpointAction{Handler: secretsPost},
+}
+
+var secretCmd = APIEndpoint{
+	Path: "secrets/{name}",
+
+	Delete: APIEndpointAction{Handler: secretDelete},
+}
+
+// secretsGet returns the names of the secrets not yet retrieved through /dev/lxd.
+func secretsGet(d *Daemon, r *http.Request) response.Response {
+	d.secretsMu.Lock()
+	defer d.secretsMu.Unlock()
+
+	names := make([]string, 0, len(d.secrets))
+	for name := range d.secrets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return response.SyncResponse(true, names)
+}
+
+// secretsPost stores a secret staged by the host until it's retrieved through /dev/lxd.
+func secretsPost(d *Daemon, r *http.Request) response.Response {
+	var secret api.InstanceSecretsPost
+
+	err := json.NewDecoder(r.Body).Decode(&secret)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if secret.Name == "" {
+		return response.BadRequest(fmt.Errorf("Secret name is required"))
+	}
+
+	d.secretsMu.Lock()
+	d.secrets[secret.Name] = secret.Value
+	d.secretsMu.Unlock()
+
+	return response.EmptySyncResponse
+}
+
+// secretDelete deletes a secret which wasn't retrieved through /dev/lxd yet.
+func secretDelete(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	d.secretsMu.Lock()
+	defer d.secretsMu.Unlock()
+
+	_, ok := d.secrets[name]
+	if !ok {
+		return response.NotFound(fmt.Errorf("Secret not found"))
+	}
+
+	delete(d.secrets, name)
+
+	return response.EmptySyncResponse
+}
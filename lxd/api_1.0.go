@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cgroup"
 	"github.com/lxc/lxd/lxd/cluster"
 	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
 	"github.com/lxc/lxd/lxd/config"
@@ -21,6 +22,7 @@ import (
 	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -58,12 +60,16 @@ var api10 = []APIEndpoint{
 	instanceLogsCmd,
 	instanceMetadataCmd,
 	instanceMetadataTemplatesCmd,
+	instanceRebuildCmd,
 	instancesCmd,
+	instanceSecretCmd,
+	instanceSecretsCmd,
 	instanceSFTPCmd,
 	instanceSnapshotCmd,
 	instanceSnapshotsCmd,
 	instanceStateCmd,
 	eventsCmd,
+	exportCmd,
 	imageAliasCmd,
 	imageAliasesCmd,
 	imageCmd,
@@ -75,13 +81,19 @@ var api10 = []APIEndpoint{
 	networkLeasesCmd,
 	networksCmd,
 	networkStateCmd,
+	networkNetplanCmd,
 	networkACLCmd,
 	networkACLsCmd,
 	networkACLLogCmd,
 	networkForwardCmd,
 	networkForwardsCmd,
+	networkLoadBalancerCmd,
+	networkLoadBalancersCmd,
+	networkReservationCmd,
+	networkReservationsCmd,
 	networkPeerCmd,
 	networkPeersCmd,
+	networkSysctlsCmd,
 	networkZoneCmd,
 	networkZonesCmd,
 	networkZoneRecordCmd,
@@ -95,8 +107,11 @@ var api10 = []APIEndpoint{
 	projectCmd,
 	projectsCmd,
 	projectStateCmd,
+	rbacRoleCmd,
+	rbacRolesCmd,
 	storagePoolCmd,
 	storagePoolResourcesCmd,
+	storagePoolStateCmd,
 	storagePoolsCmd,
 	storagePoolVolumesCmd,
 	storagePoolVolumeSnapshotsTypeCmd,
@@ -107,6 +122,11 @@ var api10 = []APIEndpoint{
 	storagePoolVolumeTypeCustomBackupCmd,
 	storagePoolVolumeTypeCustomBackupExportCmd,
 	storagePoolVolumeTypeStateCmd,
+	tasksCmd,
+	taskCmd,
+	trashCmd,
+	trashInstanceCmd,
+	trashStoragePoolVolumeCmd,
 	warningsCmd,
 	warningCmd,
 	metricsCmd,
@@ -203,6 +223,11 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 		authMethods = append(authMethods, "candid")
 	}
 
+	oidcIssuer, _, _, _, _ := s.GlobalConfig.OIDCServer()
+	if oidcIssuer != "" {
+		authMethods = append(authMethods, "oidc")
+	}
+
 	srv := api.ServerUntrusted{
 		APIExtensions: version.APIExtensions,
 		APIStatus:     "stable",
@@ -305,6 +330,7 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 		"seccomp_listener_continue": fmt.Sprintf("%v", d.os.SeccompListenerContinue),
 		"shiftfs":                   fmt.Sprintf("%v", d.os.Shiftfs),
 		"idmapped_mounts":           fmt.Sprintf("%v", d.os.IdmappedMounts),
+		"drm_cgroup":                fmt.Sprintf("%v", d.os.CGInfo.Supports(cgroup.DRM, nil)),
 	}
 
 	drivers := instanceDrivers.DriverStatuses()
@@ -335,6 +361,11 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	env.UnavailableFeatures = map[string]string{}
+	for k, v := range d.os.UnavailableFeatures {
+		env.UnavailableFeatures[k] = v
+	}
+
 	supportedStorageDrivers, usedStorageDrivers := readStoragePoolDriversCache()
 	for driver, version := range usedStorageDrivers {
 		if env.Storage != "" {
@@ -351,7 +382,12 @@ func api10Get(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
-	env.StorageSupportedDrivers = supportedStorageDrivers
+	// Add the detected idmapped mounts support without modifying the cached entries.
+	env.StorageSupportedDrivers = make([]api.ServerStorageDriverInfo, 0, len(supportedStorageDrivers))
+	for _, driver := range supportedStorageDrivers {
+		driver.IdmappedMounts = storagePools.IdmappedMountsDriverSupport(driver.Name)
+		env.StorageSupportedDrivers = append(env.StorageSupportedDrivers, driver)
+	}
 
 	fullSrv := api.Server{ServerUntrusted: srv}
 	fullSrv.Environment = env
@@ -611,7 +647,7 @@ func doApi10Update(d *Daemon, r *http.Request, req api.ServerPut, patch bool) re
 
 		if strings.HasPrefix(k, "candid.") {
 			hasCandid = true
-		} else if strings.HasPrefix(k, "rbac.") {
+		} else if strings.HasPrefix(k, "rbac.") && k != "rbac.local" {
 			hasRBAC = true
 		}
 
@@ -635,7 +671,24 @@ func doApi10Update(d *Daemon, r *http.Request, req api.ServerPut, patch bool) re
 		} else {
 			clusterChanged, err = newClusterConfig.Replace(req.Config)
 		}
-		return err
+
+		if err != nil {
+			return err
+		}
+
+		// Checked on the resulting config as a patch may only set one of them.
+		rbacAPIURL, _, _, _, _, _, _ := newClusterConfig.RBACServer()
+		if rbacAPIURL != "" && newClusterConfig.RBACLocal() {
+			return api.StatusErrorf(http.StatusBadRequest, "Local RBAC and an external RBAC server are mutually exclusive")
+		}
+
+		// The tokens can only be validated against an expected audience.
+		oidcIssuer, oidcClientID, oidcAudience, _, _ := newClusterConfig.OIDCServer()
+		if oidcIssuer != "" && oidcClientID == "" && oidcAudience == "" {
+			return api.StatusErrorf(http.StatusBadRequest, "OIDC authentication requires oidc.client.id or oidc.audience to be set")
+		}
+
+		return nil
 	})
 	if err != nil {
 		switch err.(type) {
@@ -693,9 +746,11 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 
 	maasChanged := false
 	candidChanged := false
+	oidcChanged := false
 	rbacChanged := false
 	bgpChanged := false
 	dnsChanged := false
+	secretsChanged := false
 
 	for key := range clusterChanged {
 		switch key {
@@ -719,6 +774,16 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 			fallthrough
 		case "candid.api.url":
 			candidChanged = true
+		case "oidc.audience":
+			fallthrough
+		case "oidc.claim":
+			fallthrough
+		case "oidc.client.id":
+			fallthrough
+		case "oidc.groups.claim":
+			fallthrough
+		case "oidc.issuer":
+			oidcChanged = true
 		case "cluster.images_minimal_replica":
 			err := autoSyncImages(d.shutdownCtx, d)
 			if err != nil {
@@ -746,6 +811,10 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		case "rbac.api.key":
 			fallthrough
 		case "rbac.expiry":
+			fallthrough
+		case "rbac.local":
+			fallthrough
+		case "rbac.project_patterns":
 			rbacChanged = true
 		case "core.bgp_asn":
 			bgpChanged = true
@@ -762,6 +831,21 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 			bgpChanged = true
 		case "core.dns_address":
 			dnsChanged = true
+		case "secrets.vault.address":
+			fallthrough
+		case "secrets.vault.ca_cert":
+			fallthrough
+		case "secrets.vault.token":
+			secretsChanged = true
+		}
+	}
+
+	// Reconfigure the secrets backend first as other keys may refer to secrets stored in it.
+	if secretsChanged {
+		address, token, caCert := nodeConfig.SecretsVault()
+		err := d.setupSecretsVault(address, token, caCert)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -837,24 +921,40 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	if oidcChanged {
+		issuer, clientID, audience, claim, groupsClaim := clusterConfig.OIDCServer()
+		err := d.setupOIDC(issuer, clientID, audience, claim, groupsClaim)
+		if err != nil {
+			return err
+		}
+	}
+
 	if rbacChanged {
 		apiURL, apiKey, apiExpiry, agentURL, agentUsername, agentPrivateKey, agentPublicKey := clusterConfig.RBACServer()
 
 		// Since RBAC seems to have been set up already, we need to disable it temporarily
 		if d.rbac != nil {
-			err := d.setupExternalAuthentication("", "", 0, "")
-			if err != nil {
-				return err
+			// The external RBAC server also provides the authentication.
+			_, isServer := d.rbac.(*rbac.Server)
+			if isServer {
+				err := d.setupExternalAuthentication("", "", 0, "")
+				if err != nil {
+					return err
+				}
 			}
 
-			d.rbac.StopStatusCheck()
+			d.rbac.Stop()
 			d.rbac = nil
 		}
 
-		err := d.setupRBACServer(apiURL, apiKey, apiExpiry, agentURL, agentUsername, agentPrivateKey, agentPublicKey)
+		err := d.setupRBACServer(apiURL, apiKey, apiExpiry, agentURL, agentUsername, agentPrivateKey, agentPublicKey, clusterConfig.RBACProjectPatterns())
 		if err != nil {
 			return err
 		}
+
+		if d.rbac == nil && clusterConfig.RBACLocal() {
+			d.setupRBACLocal()
+		}
 	}
 
 	if bgpChanged {
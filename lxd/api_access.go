@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/lxd/metrics"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// apiLatencyBuckets are the upper bounds in seconds of the API request latency histogram buckets.
+var apiLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// apiLatencyHistogram tracks the latency of the requests to an endpoint.
+type apiLatencyHistogram struct {
+	buckets []uint64 // Count of requests per bucket (non cumulative), the last one being +Inf.
+	sum     float64
+	count   uint64
+}
+
+// apiEndpointKey identifies an endpoint by method and path template.
+type apiEndpointKey struct {
+	method string
+	path   string
+}
+
+// apiRequestsKey identifies the requests to an endpoint which got the same response status.
+type apiRequestsKey struct {
+	apiEndpointKey
+	status int
+}
+
+var apiLatency = map[apiEndpointKey]*apiLatencyHistogram{}
+var apiRequests = map[apiRequestsKey]uint64{}
+var apiAccessLock sync.Mutex
+
+// apiAccessResponseWriter records the status code of a response.
+type apiAccessResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code and sends it.
+func (w *apiAccessResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush sends any buffered data to the client.
+func (w *apiAccessResponseWriter) Flush() {
+	flusher, ok := w.ResponseWriter.(http.Flusher)
+	if ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection, this is used for websockets and connection upgrades.
+func (w *apiAccessResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("Response writer doesn't support hijacking")
+	}
+
+	w.status = http.StatusSwitchingProtocols
+
+	return hijacker.Hijack()
+}
+
+// apiAccessRecord accounts for a completed API request in the latency histograms and request counters.
+func apiAccessRecord(method string, path string, status int, duration time.Duration) {
+	apiAccessLock.Lock()
+	defer apiAccessLock.Unlock()
+
+	key := apiEndpointKey{method: method, path: path}
+
+	histogram, ok := apiLatency[key]
+	if !ok {
+		histogram = &apiLatencyHistogram{buckets: make([]uint64, len(apiLatencyBuckets)+1)}
+		apiLatency[key] = histogram
+	}
+
+	seconds := duration.Seconds()
+	bucket := sort.SearchFloat64s(apiLatencyBuckets, seconds)
+	histogram.buckets[bucket]++
+	histogram.sum += seconds
+	histogram.count++
+
+	apiRequests[apiRequestsKey{apiEndpointKey: key, status: status}]++
+}
+
+// apiAccessMetrics returns the API latency histograms and request counters.
+func apiAccessMetrics() *metrics.MetricSet {
+	apiAccessLock.Lock()
+	defer apiAccessLock.Unlock()
+
+	set := metrics.NewMetricSet(nil)
+
+	for key, histogram := range apiLatency {
+		cumulative := uint64(0)
+		for i, count := range histogram.buckets {
+			cumulative += count
+
+			le := "+Inf"
+			if i < len(apiLatencyBuckets) {
+				le = strconv.FormatFloat(apiLatencyBuckets[i], 'g', -1, 64)
+			}
+
+			labels := map[string]string{"method": key.method, "path": key.path, "le": le}
+			set.AddSamples(metrics.APIRequestDurationSeconds, metrics.Sample{Value: float64(cumulative), Labels: labels, Suffix: "_bucket"})
+		}
+
+		labels := map[string]string{"method": key.method, "path": key.path}
+		set.AddSamples(metrics.APIRequestDurationSeconds, metrics.Sample{Value: histogram.sum, Labels: labels, Suffix: "_sum"})
+
+		labels = map[string]string{"method": key.method, "path": key.path}
+		set.AddSamples(metrics.APIRequestDurationSeconds, metrics.Sample{Value: float64(histogram.count), Labels: labels, Suffix: "_count"})
+	}
+
+	for key, count := range apiRequests {
+		labels := map[string]string{"method": key.method, "path": key.path, "status": strconv.Itoa(key.status)}
+		set.AddSamples(metrics.APIRequestsTotal, metrics.Sample{Value: float64(count), Labels: labels})
+	}
+
+	return set
+}
+
+// apiAccessLog records a completed API request and logs it if core.access_log is enabled.
+func (d *Daemon) apiAccessLog(r *http.Request, path string, status int, duration time.Duration, username string, protocol string) {
+	apiAccessRecord(r.Method, path, status, duration)
+
+	d.globalConfigMu.Lock()
+	enabled := d.globalConfig != nil && d.globalConfig.AccessLog()
+	d.globalConfigMu.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	logCtx := logger.Ctx{
+		"method":   r.Method,
+		"path":     path,
+		"url":      r.URL.Path,
+		"status":   status,
+		"duration": duration.String(),
+		"ip":       r.RemoteAddr,
+		"protocol": protocol,
+	}
+
+	if protocol == "cluster" {
+		logCtx["fingerprint"] = username
+	} else if username != "" {
+		logCtx["username"] = username
+	}
+
+	logger.Info("API access", logCtx)
+}
@@ -69,6 +69,7 @@ var clusterNodeCmd = APIEndpoint{
 var clusterNodeStateCmd = APIEndpoint{
 	Path: "cluster/members/{name}/state",
 
+	Get:  APIEndpointAction{Handler: clusterNodeStateGet},
 	Post: APIEndpointAction{Handler: clusterNodeStatePost},
 }
 
@@ -724,10 +725,12 @@ func clusterPutJoin(d *Daemon, r *http.Request, req api.ClusterPut) response.Res
 		rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey := s.GlobalConfig.RBACServer()
 
 		if rbacAPIURL != "" {
-			err = d.setupRBACServer(rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey)
+			err = d.setupRBACServer(rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey, s.GlobalConfig.RBACProjectPatterns())
 			if err != nil {
 				return err
 			}
+		} else if s.GlobalConfig.RBACLocal() {
+			d.setupRBACLocal()
 		}
 
 		if candidAPIURL != "" {
@@ -737,6 +740,14 @@ func clusterPutJoin(d *Daemon, r *http.Request, req api.ClusterPut) response.Res
 			}
 		}
 
+		oidcIssuer, oidcClientID, oidcAudience, oidcClaim, oidcGroupsClaim := s.GlobalConfig.OIDCServer()
+		if oidcIssuer != "" {
+			err = d.setupOIDC(oidcIssuer, oidcClientID, oidcAudience, oidcClaim, oidcGroupsClaim)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Start up networks so any post-join changes can be applied now that we have a Node ID.
 		logger.Debug("Starting networks after cluster join")
 		err = networkStartup(s)
@@ -2584,6 +2595,60 @@ func internalClusterRaftNodeDelete(d *Daemon, r *http.Request) response.Response
 	return response.SyncResponse(true, nil)
 }
 
+// swagger:operation GET /1.0/cluster/members/{name}/state cluster cluster_member_state_get
+//
+// Get state of the cluster member
+//
+// Gets state of a specific cluster member, including the history of its hardware inventory.
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: Cluster member state
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/ClusterMemberState"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func clusterNodeStateGet(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Forward request
+	resp := forwardedResponseToNode(d, r, name)
+	if resp != nil {
+		return resp
+	}
+
+	history, err := inventoryHistoryLoad()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, api.ClusterMemberState{InventoryHistory: history})
+}
+
 // swagger:operation POST /1.0/cluster/members/{name}/state cluster cluster_member_state_post
 //
 // Evacuate or restore a cluster member
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// clusterRestartTimeout is how long to wait for a restarted member to come back.
+const clusterRestartTimeout = 5 * time.Minute
+
+var internalRestartCmd = APIEndpoint{
+	Path: "restart",
+
+	Post: APIEndpointAction{Handler: internalRestart},
+}
+
+var internalClusterRollingRestartCmd = APIEndpoint{
+	Path: "cluster/rolling-restart",
+
+	Post: APIEndpointAction{Handler: internalClusterRollingRestart},
+}
+
+// internalRestart restarts the LXD daemon, leaving the instances running.
+func internalRestart(d *Daemon, r *http.Request) response.Response {
+	logger.Info("Asked to restart by API")
+
+	if d.shutdownCtx.Err() != nil {
+		return response.SmartError(fmt.Errorf("Shutdown already in progress"))
+	}
+
+	// Mark the daemon as shutting down right away so that readiness checks fail until it has restarted.
+	d.shutdownCancel()
+
+	go func() {
+		<-r.Context().Done() // Wait until request has finished.
+
+		err := d.Stop(context.Background(), unix.SIGTERM)
+		if err != nil {
+			logger.Warn("Failed stopping LXD daemon cleanly before restart", logger.Ctx{"err": err})
+		}
+
+		if d.systemdSocketActivated {
+			// The daemon gets started again by systemd on the next request.
+			logger.Info("Exiting LXD daemon for restart")
+			os.Exit(0)
+		}
+
+		logger.Info("Restarting LXD daemon")
+		err = util.ReplaceDaemon()
+		if err != nil {
+			logger.Error("Failed restarting LXD daemon", logger.Ctx{"err": err})
+			d.shutdownDoneCh <- err
+		}
+	}()
+
+	return response.ManualResponse(func(w http.ResponseWriter) error {
+		err := response.EmptySyncResponse.Render(w)
+		if err != nil {
+			return err
+		}
+
+		// Send the response before the LXD daemon stops.
+		f, ok := w.(http.Flusher)
+		if ok {
+			f.Flush()
+		} else {
+			return fmt.Errorf("http.ResponseWriter is not type http.Flusher")
+		}
+
+		return nil
+	})
+}
+
+// clusterRestartMembers returns the cluster members in the order they should be restarted along with their raft
+// role. Members without a database role go first, then stand-by members and finally voters, so that the database
+// roles get moved as little as possible. The local member is always last.
+func clusterRestartMembers(d *Daemon) ([]db.NodeInfo, map[string]db.RaftRole, time.Duration, error) {
+	var members []db.NodeInfo
+	var offlineThreshold time.Duration
+
+	err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		members, err = tx.GetNodes()
+		if err != nil {
+			return fmt.Errorf("Failed getting cluster members: %w", err)
+		}
+
+		offlineThreshold, err = tx.GetNodeOfflineThreshold()
+		if err != nil {
+			return fmt.Errorf("Failed getting offline threshold: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, -1, err
+	}
+
+	var raftNodes []db.RaftNode
+	err = d.db.Node.Transaction(func(tx *db.NodeTx) error {
+		var err error
+		raftNodes, err = tx.GetRaftNodes()
+		return err
+	})
+	if err != nil {
+		return nil, nil, -1, fmt.Errorf("Failed getting raft nodes: %w", err)
+	}
+
+	roles := map[string]db.RaftRole{}
+	for _, raftNode := range raftNodes {
+		roles[raftNode.Address] = raftNode.Role
+	}
+
+	localName := d.State().ServerName
+
+	rank := func(member db.NodeInfo) int {
+		if member.Name == localName {
+			return 4
+		}
+
+		role, ok := roles[member.Address]
+		if !ok {
+			return 0
+		}
+
+		switch role {
+		case db.RaftSpare:
+			return 1
+		case db.RaftStandBy:
+			return 2
+		}
+
+		return 3
+	}
+
+	sort.SliceStable(members, func(i, j int) bool { return rank(members[i]) < rank(members[j]) })
+
+	return members, roles, offlineThreshold, nil
+}
+
+// clusterRestartCheckQuorum checks that restarting the given member keeps a majority of the database voters online.
+func clusterRestartCheckQuorum(d *Daemon, member db.NodeInfo) error {
+	members, roles, offlineThreshold, err := clusterRestartMembers(d)
+	if err != nil {
+		return err
+	}
+
+	localName := d.State().ServerName
+	voters := 0
+	othersOnline := 0
+	for _, other := range members {
+		role, ok := roles[other.Address]
+		if !ok || role != db.RaftVoter {
+			continue
+		}
+
+		voters++
+
+		if other.Name == member.Name {
+			continue
+		}
+
+		if other.Name == localName || !other.IsOffline(offlineThreshold) {
+			othersOnline++
+		}
+	}
+
+	if roles[member.Address] == db.RaftVoter && othersOnline <= voters/2 {
+		return fmt.Errorf("Restarting cluster member %q would lose database quorum (%d of %d voters would remain online)", member.Name, othersOnline, voters)
+	}
+
+	return nil
+}
+
+// clusterRestartWait waits for a restarted member to go down, come back up and be seen as online by the cluster.
+func clusterRestartWait(d *Daemon, r *http.Request, member db.NodeInfo, restarted time.Time) error {
+	deadline := time.Now().Add(clusterRestartTimeout)
+
+	ready := func() bool {
+		client, err := cluster.Connect(member.Address, d.endpoints.NetworkCert(), d.serverCert(), r, true)
+		if err != nil {
+			return false
+		}
+
+		_, _, err = client.RawQuery("GET", "/internal/ready", nil, "")
+		return err == nil
+	}
+
+	// Wait for the member to stop answering.
+	for ready() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out waiting for cluster member %q to stop", member.Name)
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	// Wait for the member to be ready again.
+	for !ready() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out waiting for cluster member %q to restart", member.Name)
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	// Wait for the member to heartbeat so that it's seen as online by the rest of the cluster.
+	for {
+		var heartbeat time.Time
+		err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			node, err := tx.GetNodeByName(member.Name)
+			if err != nil {
+				return err
+			}
+
+			heartbeat = node.Heartbeat
+			return nil
+		})
+		if err == nil && heartbeat.After(restarted) {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out waiting for cluster member %q to be back online", member.Name)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+// internalClusterRollingRestart restarts the LXD daemon of all the other cluster members, one at a time.
+// Each member is only restarted if the database quorum can be maintained without it and once the previous member is
+// back online. The local member is left for the caller to restart, after having checked that it can be done safely.
+func internalClusterRollingRestart(d *Daemon, r *http.Request) response.Response {
+	s := d.State()
+
+	run := func(op *operations.Operation) error {
+		clustered, err := cluster.Enabled(d.db.Node)
+		if err != nil {
+			return err
+		}
+
+		if !clustered {
+			return nil
+		}
+
+		members, _, offlineThreshold, err := clusterRestartMembers(d)
+		if err != nil {
+			return err
+		}
+
+		localName := s.ServerName
+		metadata := make(map[string]any)
+
+		for _, member := range members {
+			if member.Name == localName {
+				continue
+			}
+
+			if member.IsOffline(offlineThreshold) {
+				return fmt.Errorf("Cluster member %q is offline", member.Name)
+			}
+
+			err = clusterRestartCheckQuorum(d, member)
+			if err != nil {
+				return err
+			}
+
+			metadata["restart_progress"] = fmt.Sprintf("Restarting %q", member.Name)
+			_ = op.UpdateMetadata(metadata)
+
+			var client lxd.InstanceServer
+			client, err = cluster.Connect(member.Address, d.endpoints.NetworkCert(), d.serverCert(), r, true)
+			if err != nil {
+				return fmt.Errorf("Failed connecting to cluster member %q: %w", member.Name, err)
+			}
+
+			restarted := time.Now()
+			_, _, err = client.RawQuery("POST", "/internal/restart", nil, "")
+			if err != nil {
+				return fmt.Errorf("Failed restarting cluster member %q: %w", member.Name, err)
+			}
+
+			metadata["restart_progress"] = fmt.Sprintf("Waiting for %q to be back online", member.Name)
+			_ = op.UpdateMetadata(metadata)
+
+			err = clusterRestartWait(d, r, member, restarted)
+			if err != nil {
+				return err
+			}
+
+			logger.Info("Restarted cluster member", logger.Ctx{"member": member.Name})
+		}
+
+		// Check that the local member can be restarted by the caller.
+		for _, member := range members {
+			if member.Name == localName {
+				return clusterRestartCheckQuorum(d, member)
+			}
+		}
+
+		return nil
+	}
+
+	op, err := operations.OperationCreate(s, "", operations.OperationClassTask, db.OperationClusterRollingRestart, nil, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+
+	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
+	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var exportCmd = APIEndpoint{
+	Path: "export",
+
+	Get: APIEndpointAction{Handler: exportGet},
+}
+
+// swagger:operation GET /1.0/export server server_export_get
+//
+// Export the server state
+//
+// Returns a consistent snapshot of the declarative server state (server configuration, projects,
+// profiles, networks, network ACLs and storage pools, but no instances) in the `lxd init --preseed` format.
+// Secrets (hidden server configuration keys and network pre-shared keys) are left out.
+//
+// ---
+// produces:
+//   - application/json
+//   - text/plain
+// parameters:
+//   - in: query
+//     name: format
+//     description: Output format (json or yaml)
+//     type: string
+//     example: yaml
+// responses:
+//   "200":
+//     description: Server state
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: object
+//           description: Preseed document
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func exportGet(d *Daemon, r *http.Request) response.Response {
+	format := queryParam(r, "format")
+	if format == "" {
+		format = "json"
+	}
+
+	if !shared.StringInSlice(format, []string{"json", "yaml"}) {
+		return response.BadRequest(fmt.Errorf("Invalid export format %q", format))
+	}
+
+	config, err := serverExport(r.Context(), d.State())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if format == "yaml" {
+		out, err := yaml.Marshal(config)
+		if err != nil {
+			return response.InternalError(err)
+		}
+
+		return response.SyncResponsePlain(true, string(out))
+	}
+
+	return response.SyncResponse(true, config)
+}
+
+// serverExport returns the declarative state of the server as a preseed document.
+// The cluster-wide state is loaded in a single transaction so that the result is consistent.
+func serverExport(ctx context.Context, s *state.State) (*initDataNode, error) {
+	config := &initDataNode{}
+	config.Config = map[string]any{}
+
+	// Member specific server configuration.
+	err := s.DB.Node.Transaction(func(tx *db.NodeTx) error {
+		nodeConfig, err := node.ConfigLoad(tx)
+		if err != nil {
+			return err
+		}
+
+		for key, value := range nodeConfig.Dump() {
+			// Leave out the secrets rather than exporting placeholders.
+			if node.ConfigSchema[key].Hidden {
+				continue
+			}
+
+			config.Config[key] = value
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading member configuration: %w", err)
+	}
+
+	err = s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+		globalConfig, err := clusterConfig.Load(tx)
+		if err != nil {
+			return fmt.Errorf("Failed loading server configuration: %w", err)
+		}
+
+		for key, value := range globalConfig.Dump() {
+			// Leave out the secrets rather than exporting placeholders.
+			if clusterConfig.ConfigSchema[key].Hidden {
+				continue
+			}
+
+			config.Config[key] = value
+		}
+
+		// Projects.
+		projects, err := dbCluster.GetProjects(ctx, tx.Tx(), dbCluster.ProjectFilter{})
+		if err != nil {
+			return fmt.Errorf("Failed loading projects: %w", err)
+		}
+
+		for _, p := range projects {
+			apiProject, err := p.ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return fmt.Errorf("Failed loading project %q: %w", p.Name, err)
+			}
+
+			config.Projects = append(config.Projects, api.ProjectsPost{
+				Name:       apiProject.Name,
+				ProjectPut: apiProject.Writable(),
+			})
+		}
+
+		// Profiles.
+		profiles, err := dbCluster.GetProfiles(ctx, tx.Tx(), dbCluster.ProfileFilter{})
+		if err != nil {
+			return fmt.Errorf("Failed loading profiles: %w", err)
+		}
+
+		for _, p := range profiles {
+			apiProfile, err := p.ToAPI(ctx, tx.Tx())
+			if err != nil {
+				return fmt.Errorf("Failed loading profile %q in project %q: %w", p.Name, p.Project, err)
+			}
+
+			config.Profiles = append(config.Profiles, initDataNodeProfile{
+				ProfilesPost: api.ProfilesPost{
+					Name:       apiProfile.Name,
+					ProfilePut: apiProfile.Writable(),
+				},
+				Project: p.Project,
+			})
+		}
+
+		// Storage pools.
+		pools, err := tx.GetCreatedStoragePools()
+		if err != nil {
+			return fmt.Errorf("Failed loading storage pools: %w", err)
+		}
+
+		for _, pool := range pools {
+			config.StoragePools = append(config.StoragePools, api.StoragePoolsPost{
+				Name:           pool.Name,
+				Driver:         pool.Driver,
+				StoragePoolPut: pool.Writable(),
+			})
+		}
+
+		// Networks (managed only).
+		projectNetworks, err := tx.GetCreatedNetworks()
+		if err != nil {
+			return fmt.Errorf("Failed loading networks: %w", err)
+		}
+
+		for projectName, networks := range projectNetworks {
+			for _, netInfo := range networks {
+				networksPost := internalClusterPostNetwork{}
+				networksPost.Name = netInfo.Name
+				networksPost.Type = netInfo.Type
+				networksPost.NetworkPut = netInfo.Writable()
+				networksPost.Project = projectName
+
+				// Leave out the pre-shared keys.
+				networksPost.Config = make(map[string]string, len(netInfo.Config))
+				for key, value := range netInfo.Config {
+					if !network.IsSecretConfigKey(key) {
+						networksPost.Config[key] = value
+					}
+				}
+
+				config.Networks = append(config.Networks, networksPost)
+			}
+		}
+
+		// Network ACLs.
+		projectACLs, err := tx.GetNetworkACLsAllProjects()
+		if err != nil {
+			return fmt.Errorf("Failed loading network ACLs: %w", err)
+		}
+
+		for projectName, acls := range projectACLs {
+			for _, acl := range acls {
+				config.NetworkACLs = append(config.NetworkACLs, initDataNodeNetworkACL{
+					NetworkACLsPost: api.NetworkACLsPost{
+						NetworkACLPost: acl.NetworkACLPost,
+						NetworkACLPut:  acl.Writable(),
+					},
+					Project: projectName,
+				})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort entries so that exports of the same state are identical, keeping the default project first.
+	projectLess := func(a string, b string) bool {
+		if a == b || b == project.Default {
+			return false
+		}
+
+		return a == project.Default || a < b
+	}
+
+	sort.SliceStable(config.Projects, func(i, j int) bool {
+		return projectLess(config.Projects[i].Name, config.Projects[j].Name)
+	})
+
+	sort.SliceStable(config.Profiles, func(i, j int) bool {
+		if config.Profiles[i].Project != config.Profiles[j].Project {
+			return projectLess(config.Profiles[i].Project, config.Profiles[j].Project)
+		}
+
+		return config.Profiles[i].Name < config.Profiles[j].Name
+	})
+
+	sort.SliceStable(config.Networks, func(i, j int) bool {
+		if config.Networks[i].Project != config.Networks[j].Project {
+			return projectLess(config.Networks[i].Project, config.Networks[j].Project)
+		}
+
+		return config.Networks[i].Name < config.Networks[j].Name
+	})
+
+	// Network ACLs keep their creation order within a project as they may reference each other.
+	sort.SliceStable(config.NetworkACLs, func(i, j int) bool {
+		return projectLess(config.NetworkACLs[i].Project, config.NetworkACLs[j].Project)
+	})
+
+	return config, nil
+}
@@ -45,9 +45,11 @@ var apiInternal = []APIEndpoint{
 	internalClusterAcceptCmd,
 	internalClusterAssignCmd,
 	internalClusterHandoverCmd,
+	internalClusterInstanceMigrationCheckCmd,
 	internalClusterInstanceMovedCmd,
 	internalClusterRaftNodeCmd,
 	internalClusterRebalanceCmd,
+	internalClusterRollingRestartCmd,
 	internalContainerOnStartCmd,
 	internalContainerOnStopCmd,
 	internalContainerOnStopNSCmd,
@@ -56,6 +58,7 @@ var apiInternal = []APIEndpoint{
 	internalImageRefreshCmd,
 	internalRAFTSnapshotCmd,
 	internalReadyCmd,
+	internalRestartCmd,
 	internalShutdownCmd,
 	internalSQLCmd,
 	internalWarningCreateCmd,
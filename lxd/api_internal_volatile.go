@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+var internalVolatileCmd = APIEndpoint{
+	Path: "volatile",
+
+	Get:  APIEndpointAction{Handler: internalVolatileGet},
+	Post: APIEndpointAction{Handler: internalVolatilePost},
+}
+
+// init volatile adds API endpoints to handler slice.
+func init() {
+	apiInternal = append(apiInternal, internalVolatileCmd)
+}
+
+// internalVolatileStaleKeys lists the stale volatile keys of an instance.
+type internalVolatileStaleKeys struct {
+	Project  string            `json:"project" yaml:"project"`
+	Instance string            `json:"instance" yaml:"instance"`
+	Keys     map[string]string `json:"keys" yaml:"keys"` // Stale keys and the reason they are considered stale.
+}
+
+// internalVolatileGet reports the stale volatile keys of the instances on this member.
+func internalVolatileGet(d *Daemon, r *http.Request) response.Response {
+	result, err := volatileStaleKeys(d.State(), false)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// internalVolatilePost removes the stale volatile keys of the instances on this member.
+func internalVolatilePost(d *Daemon, r *http.Request) response.Response {
+	result, err := volatileStaleKeys(d.State(), true)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// volatileStaleKeys returns the stale volatile keys of the instances on this member, removing them if cleanup is
+// true. Instances with an ongoing operation are skipped as their volatile keys may be in the process of changing.
+func volatileStaleKeys(s *state.State, cleanup bool) ([]internalVolatileStaleKeys, error) {
+	insts, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading instances: %w", err)
+	}
+
+	sort.Slice(insts, func(i, j int) bool {
+		if insts[i].Project() != insts[j].Project() {
+			return insts[i].Project() < insts[j].Project()
+		}
+
+		return insts[i].Name() < insts[j].Name()
+	})
+
+	result := []internalVolatileStaleKeys{}
+	for _, inst := range insts {
+		if operationlock.Get(inst.Project(), inst.Name()) != nil {
+			continue
+		}
+
+		stale := instance.VolatileStaleKeys(inst)
+		if len(stale) == 0 {
+			continue
+		}
+
+		if cleanup {
+			changes := make(map[string]string, len(stale))
+			for key := range stale {
+				changes[key] = ""
+			}
+
+			err = inst.VolatileSet(changes)
+			if err != nil {
+				return nil, fmt.Errorf("Failed removing stale volatile keys of instance %q in project %q: %w", inst.Name(), inst.Project(), err)
+			}
+
+			logger.Info("Removed stale volatile keys", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "keys": stale})
+		}
+
+		result = append(result, internalVolatileStaleKeys{Project: inst.Project(), Instance: inst.Name(), Keys: stale})
+	}
+
+	return result, nil
+}
+
+// volatileKeysCleanupTask removes the stale volatile keys of the instances on this member (hourly).
+func volatileKeysCleanupTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		opRun := func(op *operations.Operation) error {
+			_, err := volatileStaleKeys(d.State(), true)
+			return err
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationVolatileKeysCleanup, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start stale volatile keys cleanup operation", logger.Ctx{"err": err})
+			return
+		}
+
+		logger.Debug("Cleaning up stale volatile keys")
+		err = op.Start()
+		if err != nil {
+			logger.Error("Failed to clean up stale volatile keys", logger.Ctx{"err": err})
+		}
+
+		op.Wait(ctx)
+		logger.Debug("Done cleaning up stale volatile keys")
+	}
+
+	// Skip the first run to leave the instances being started on daemon startup alone.
+	return f, task.Every(time.Hour, task.SkipFirst)
+}
@@ -106,6 +106,11 @@ func metricsGet(d *Daemon, r *http.Request) response.Response {
 	// Prepare response.
 	metricSet := metrics.NewMetricSet(nil)
 
+	// Include the server wide API metrics when not filtering by project.
+	if projectName == "" {
+		metricSet.Merge(apiAccessMetrics())
+	}
+
 	// Review the cache.
 	metricsCacheLock.Lock()
 	projectMissing := []string{}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -233,6 +234,19 @@ func projectUsedBy(tx *db.ClusterTx, project *cluster.Project) ([]string, error)
 	usedBy = append(usedBy, networks...)
 	usedBy = append(usedBy, acls...)
 
+	// Trashed instances and volumes are only reachable through the trash API.
+	trashURLs, err := projectTrashURLs(tx, project.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, uri := range usedBy {
+		trashURL, ok := trashURLs[uri]
+		if ok {
+			usedBy[i] = trashURL
+		}
+	}
+
 	return usedBy, nil
 }
 
@@ -684,6 +698,17 @@ func projectChange(d *Daemon, project *api.Project, req api.ProjectPut) response
 			return err
 		}
 
+		if shared.StringInSlice("images.local_aliases", configChanged) && !shared.IsTrue(req.Config["images.local_aliases"]) && shared.IsFalse(project.Config["features.images"]) {
+			hasAliases, err := tx.ProjectHasImageAliases(project.Name)
+			if err != nil {
+				return err
+			}
+
+			if hasAliases {
+				return api.StatusErrorf(http.StatusBadRequest, "Local image aliases must be removed before disabling images.local_aliases")
+			}
+		}
+
 		err = cluster.UpdateProject(context.TODO(), tx.Tx(), project.Name, req)
 		if err != nil {
 			return fmt.Errorf("Persist profile changes: %w", err)
@@ -866,6 +891,15 @@ func projectDelete(d *Daemon, r *http.Request) response.Response {
 		}
 
 		if !empty {
+			trashURLs, err := projectTrashURLs(tx, name)
+			if err != nil {
+				return err
+			}
+
+			if len(trashURLs) > 0 {
+				return fmt.Errorf("Only empty projects can be removed (%d instances and volumes are still in the trash)", len(trashURLs))
+			}
+
 			return fmt.Errorf("Only empty projects can be removed")
 		}
 
@@ -1031,10 +1065,18 @@ func isEitherAllowOrBlockOrManaged(value string) error {
 	return validate.Optional(validate.IsOneOf("block", "allow", "managed"))(value)
 }
 
+func isExpiry(value string) error {
+	_, err := shared.GetSnapshotExpiry(time.Time{}, value)
+	return err
+}
+
 func projectValidateConfig(s *state.State, config map[string]string) error {
 	// Validate the project configuration.
 	projectConfigKeys := map[string]func(value string) error{
 		"backups.compression_algorithm":        validate.IsCompressionAlgorithm,
+		"cluster.placement":                    validate.Optional(validate.IsOneOf("spread", "binpack")),
+		"deletion.protection":                  validate.Optional(validate.IsBool),
+		"deletion.retention":                   isExpiry,
 		"features.profiles":                    validate.Optional(validate.IsBool),
 		"features.images":                      validate.Optional(validate.IsBool),
 		"features.storage.volumes":             validate.Optional(validate.IsBool),
@@ -1043,6 +1085,7 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		"images.auto_update_interval":          validate.Optional(validate.IsInt64),
 		"images.compression_algorithm":         validate.IsCompressionAlgorithm,
 		"images.default_architecture":          validate.Optional(validate.IsArchitecture),
+		"images.local_aliases":                 validate.Optional(validate.IsBool),
 		"images.remote_cache_expiry":           validate.Optional(validate.IsInt64),
 		"limits.instances":                     validate.Optional(validate.IsUint32),
 		"limits.containers":                    validate.Optional(validate.IsUint32),
@@ -1052,6 +1095,8 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		"limits.cpu":                           validate.Optional(validate.IsUint32),
 		"limits.disk":                          validate.Optional(validate.IsSize),
 		"limits.networks":                      validate.Optional(validate.IsUint32),
+		"limits.networks.forwards":             validate.Optional(validate.IsUint32),
+		"limits.networks.subnets":              validate.Optional(validate.IsUint32),
 		"restricted":                           validate.Optional(validate.IsBool),
 		"restricted.backups":                   isEitherAllowOrBlock,
 		"restricted.cluster.groups":            validate.Optional(validate.IsListOf(validate.IsAny)),
@@ -1109,6 +1154,11 @@ func projectValidateConfig(s *state.State, config map[string]string) error {
 		return fmt.Errorf("Projects without their own profiles cannot be restricted")
 	}
 
+	// Local image aliases point to the images of the default project.
+	if shared.IsTrue(config["images.local_aliases"]) && shared.IsTrue(config["features.images"]) {
+		return fmt.Errorf("Local image aliases can only be used in projects without their own images")
+	}
+
 	return nil
 }
 
@@ -9,6 +9,7 @@ import (
 	"text/template"
 
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/sys"
 	"github.com/lxc/lxd/lxd/util"
@@ -48,6 +49,7 @@ profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
 
   # Forkproxy operation
   {{ .logPath }}/** rw,
+  {{ .limitsPath }} r,
   @{PROC}/** rw,
   / rw,
   ptrace (read),
@@ -63,6 +65,15 @@ profile "{{ .name }}" flags=(attach_disconnected,mediate_deleted) {
 {{- end }}
 {{- end }}
 
+{{- if .agentPath }}
+
+  # Relaying through the lxd-agent
+  network vsock stream,
+  {{ .agentPath }}/agent.crt r,
+  {{ .agentPath }}/agent-client.crt r,
+  {{ .agentPath }}/agent-client.key r,
+{{- end }}
+
   # Things that we definitely don't need
   deny @{PROC}/@{pid}/cgroup r,
   deny /sys/module/apparmor/parameters/enabled r,
@@ -111,8 +122,23 @@ func forkproxyProfile(sysOS *sys.OS, inst instance, dev device) (string, error)
 		}
 	}
 
-	fields = strings.SplitN(dev.Config()["connect"], ":", 2)
-	if fields[0] == "unix" && !strings.HasPrefix(fields[1], "@") {
+	// Virtual machines without NAT have their connections relayed by the lxd-agent.
+	agentPath := ""
+	if inst.Type() == instancetype.VM && shared.IsFalseOrEmpty(dev.Config()["nat"]) {
+		agentPath = inst.Path()
+
+		path, err := filepath.EvalSymlinks(agentPath)
+		if err == nil {
+			agentPath = path
+		}
+	}
+
+	for _, connectAddr := range deviceConfig.SplitProxyAddresses(dev.Config()["connect"]) {
+		fields = strings.SplitN(connectAddr, ":", 2)
+		if agentPath != "" || fields[0] != "unix" || strings.HasPrefix(fields[1], "@") {
+			continue
+		}
+
 		if dev.Config()["bind"] == "host" || dev.Config()["bind"] == "" {
 			sockets = append(sockets, fields[1])
 		} else {
@@ -158,8 +184,10 @@ func forkproxyProfile(sysOS *sys.OS, inst instance, dev device) (string, error)
 		"snap":        shared.InSnap(),
 		"exePath":     execPath,
 		"logPath":     inst.LogPath(),
+		"limitsPath":  filepath.Join(inst.DevicesPath(), fmt.Sprintf("proxy.%s.limits", dev.Name())),
 		"libraryPath": strings.Split(os.Getenv("LD_LIBRARY_PATH"), ":"),
 		"sockets":     sockets,
+		"agentPath":   agentPath,
 	})
 	if err != nil {
 		return "", err
@@ -82,13 +82,20 @@ func ExtractWithFds(cmd string, args []string, allowedCmds []string, stdin io.Re
 // The returned cancelFunc should be called when finished with reader to clean up any resources used.
 // This can be done before reading to the end of the tarball if desired.
 func CompressedTarReader(ctx context.Context, r io.ReadSeeker, unpacker []string, sysOS *sys.OS, outputPath string) (*tar.Reader, context.CancelFunc, error) {
-	ctx, cancelFunc := context.WithCancel(ctx)
-
 	_, err := r.Seek(0, 0)
 	if err != nil {
-		return nil, cancelFunc, err
+		return nil, func() {}, err
 	}
 
+	return CompressedTarStreamReader(ctx, r, unpacker, sysOS, outputPath)
+}
+
+// CompressedTarStreamReader returns a tar reader from the supplied (optionally compressed) tarball stream, reading
+// it from its current position. The unpacker arguments are those returned by DetectCompressionFile().
+// The returned cancelFunc should be called when finished with reader to clean up any resources used.
+func CompressedTarStreamReader(ctx context.Context, r io.Reader, unpacker []string, sysOS *sys.OS, outputPath string) (*tar.Reader, context.CancelFunc, error) {
+	ctx, cancelFunc := context.WithCancel(ctx)
+
 	var tr *tar.Reader
 
 	if len(unpacker) > 0 {
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"context"
@@ -53,6 +56,36 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		args.OptimizedStorage = false
 	}
 
+	// Incremental backups are relative to the newest snapshot included in the previous backup.
+	var incremental *backup.Incremental
+	if args.IncrementalFrom != "" {
+		if !args.OptimizedStorage || !pool.Driver().Info().IncrementalBackups {
+			return fmt.Errorf("Storage pool %q doesn't support incremental backups", pool.Name())
+		}
+
+		base, err := backupIncrementalBase(s, sourceInst, args.IncrementalFrom)
+		if err != nil {
+			return err
+		}
+
+		_, parentName, _ := shared.InstanceGetParentAndSnapshotName(args.IncrementalFrom)
+		_, baseName, _ := shared.InstanceGetParentAndSnapshotName(base.Name())
+		incremental = &backup.Incremental{
+			Parent:        parentName,
+			Base:          baseName,
+			BaseCreatedAt: base.CreationDate(),
+		}
+	}
+
+	// Paths can only be excluded when the container files are copied into the tarball.
+	var exclude []string
+	if sourceInst.Type() == instancetype.Container && !args.OptimizedStorage && args.Format != backup.FormatOCI {
+		exclude = shared.SplitNTrimSpace(sourceInst.ExpandedConfig()["backups.exclude"], ",", -1, true)
+		exclude = append(exclude, args.Exclude...)
+	} else if len(args.Exclude) > 0 {
+		return fmt.Errorf("Paths can only be excluded from non-optimized container backups")
+	}
+
 	// Create the database entry.
 	err = s.DB.Cluster.CreateInstanceBackup(args)
 	if err != nil {
@@ -72,57 +105,63 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 	}
 
 	// Detect compression method.
-	var compress string
 	b.SetCompressionAlgorithm(args.CompressionAlgorithm)
-	if b.CompressionAlgorithm() != "" {
-		compress = b.CompressionAlgorithm()
+	compress, err := backupCompressionAlgorithm(s, b.CompressionAlgorithm(), pool, sourceInst.Project())
+	if err != nil {
+		return err
+	}
+
+	var tarFileWriter io.WriteCloser
+	if args.ObjectKey != "" {
+		// Stream the tarball to the S3 bucket, the upload only being completed when the writer is closed.
+		l.Debug("Uploading backup tarball to S3", logger.Ctx{"key": args.ObjectKey})
+		uploader := backup.NewS3Target(s).Upload(args.ObjectKey)
+		revert.Add(func() { _ = uploader.Abort() })
+		tarFileWriter = uploader
 	} else {
-		var p *api.Project
-		err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-			project, err := dbCluster.GetProject(ctx, tx.Tx(), sourceInst.Project())
+		// Create the target path if needed.
+		backupsPath := shared.VarPath("backups", "instances", project.Instance(sourceInst.Project(), sourceInst.Name()))
+		if !shared.PathExists(backupsPath) {
+			err := os.MkdirAll(backupsPath, 0700)
 			if err != nil {
 				return err
 			}
 
-			p, err = project.ToAPI(ctx, tx.Tx())
+			revert.Add(func() { _ = os.Remove(backupsPath) })
+		}
 
-			return err
-		})
+		target := shared.VarPath("backups", "instances", project.Instance(sourceInst.Project(), b.Name()))
+
+		// Setup the tarball writer.
+		l.Debug("Opening backup tarball for writing", logger.Ctx{"path": target})
+		tarFile, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0600)
 		if err != nil {
-			return err
+			return fmt.Errorf("Error opening backup tarball for writing %q: %w", target, err)
 		}
+		defer func() { _ = tarFile.Close() }()
+		revert.Add(func() { _ = os.Remove(target) })
 
-		if p.Config["backups.compression_algorithm"] != "" {
-			compress = p.Config["backups.compression_algorithm"]
-		} else {
-			compress, err = clusterConfig.GetString(s.DB.Cluster, "backups.compression_algorithm")
-			if err != nil {
-				return err
-			}
-		}
+		tarFileWriter = tarFile
 	}
 
-	// Create the target path if needed.
-	backupsPath := shared.VarPath("backups", "instances", project.Instance(sourceInst.Project(), sourceInst.Name()))
-	if !shared.PathExists(backupsPath) {
-		err := os.MkdirAll(backupsPath, 0700)
+	// OCI backups are an uncompressed image layout of the instance root filesystem.
+	if args.Format == backup.FormatOCI {
+		l.Debug("Writing OCI image layout")
+		err = backupWriteOCI(sourceInst, tarFileWriter)
 		if err != nil {
-			return err
+			return fmt.Errorf("Error writing OCI image layout: %w", err)
 		}
 
-		revert.Add(func() { _ = os.Remove(backupsPath) })
-	}
+		err = tarFileWriter.Close()
+		if err != nil {
+			return fmt.Errorf("Error closing tar file: %w", err)
+		}
 
-	target := shared.VarPath("backups", "instances", project.Instance(sourceInst.Project(), b.Name()))
+		revert.Success()
+		s.Events.SendLifecycle(sourceInst.Project(), lifecycle.InstanceBackupCreated.Event(args.Name, b.Instance(), nil))
 
-	// Setup the tarball writer.
-	l.Debug("Opening backup tarball for writing", logger.Ctx{"path": target})
-	tarFileWriter, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return fmt.Errorf("Error opening backup tarball for writing %q: %w", target, err)
+		return nil
 	}
-	defer func() { _ = tarFileWriter.Close() }()
-	revert.Add(func() { _ = os.Remove(target) })
 
 	// Get IDMap to unshift container as the tarball is created.
 	var idmap *idmap.IdmapSet
@@ -138,47 +177,28 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 	tarPipeReader, tarPipeWriter := io.Pipe()
 	defer func() { _ = tarPipeWriter.Close() }() // Ensure that go routine below always ends.
 	tarWriter := instancewriter.NewInstanceTarWriter(tarPipeWriter, idmap)
+	tarWriter.SetExclusions(exclude)
 
 	// Setup tar writer go routine, with optional compression.
 	tarWriterRes := make(chan error, 0)
 	var compressErr error
 
-	backupProgressWriter := &ioprogress.ProgressWriter{
-		Tracker: &ioprogress.ProgressTracker{
-			Handler: func(value, speed int64) {
-				meta := op.Metadata()
-				if meta == nil {
-					meta = make(map[string]any)
-				}
-
-				progressText := fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(value, 2), units.GetByteSizeString(speed, 2))
-				meta["create_backup_progress"] = progressText
-				_ = op.UpdateMetadata(meta)
-			},
-		},
-	}
-
 	go func(resCh chan<- error) {
 		l.Debug("Started backup tarball writer")
 		defer l.Debug("Finished backup tarball writer")
-		if compress != "none" {
-			backupProgressWriter.WriteCloser = tarFileWriter
-			compressErr = compressFile(compress, tarPipeReader, backupProgressWriter)
+		compressErr = backupCompress(op, compress, tarPipeReader, tarFileWriter)
 
-			// If a compression error occurred, close the tarPipeWriter to end the export.
-			if compressErr != nil {
-				_ = tarPipeWriter.Close()
-			}
-		} else {
-			backupProgressWriter.WriteCloser = tarFileWriter
-			_, err = io.Copy(backupProgressWriter, tarPipeReader)
+		// If a compression error occurred, close the tarPipeWriter to end the export.
+		if compressErr != nil {
+			_ = tarPipeWriter.Close()
 		}
-		resCh <- err
+
+		resCh <- compressErr
 	}(tarWriterRes)
 
 	// Write index file.
 	l.Debug("Adding backup index file")
-	err = backupWriteIndex(sourceInst, pool, b.OptimizedStorage(), !b.InstanceOnly(), tarWriter)
+	err = backupWriteIndex(sourceInst, pool, b.OptimizedStorage(), !b.InstanceOnly(), incremental, exclude, tarWriter)
 
 	// Check compression errors.
 	if compressErr != nil {
@@ -190,11 +210,23 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		return fmt.Errorf("Error writing backup index file: %w", err)
 	}
 
-	err = pool.BackupInstance(sourceInst, tarWriter, b.OptimizedStorage(), !b.InstanceOnly(), nil)
+	incrementalBase := ""
+	if incremental != nil {
+		incrementalBase = incremental.Base
+	}
+
+	err = pool.BackupInstance(sourceInst, tarWriter, b.OptimizedStorage(), !b.InstanceOnly(), incrementalBase, nil)
 	if err != nil {
 		return fmt.Errorf("Backup create: %w", err)
 	}
 
+	// Write manifest file.
+	l.Debug("Adding backup manifest file")
+	err = backupWriteManifest(tarWriter)
+	if err != nil {
+		return fmt.Errorf("Error writing backup manifest file: %w", err)
+	}
+
 	// Close off the tarball file.
 	err = tarWriter.Close()
 	if err != nil {
@@ -223,8 +255,65 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 	return nil
 }
 
+// backupWriteOCI exports the container and writes it to w as an OCI image layout.
+func backupWriteOCI(sourceInst instance.Instance, w io.Writer) error {
+	if sourceInst.Type() != instancetype.Container {
+		return fmt.Errorf("OCI backups are only supported for containers")
+	}
+
+	workDir, err := ioutil.TempDir(shared.VarPath("backups"), backup.WorkingDirPrefix)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	// Stream the instance export into the conversion, the export ends if the conversion fails.
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		_, err := sourceInst.Export(pipeWriter, nil, time.Time{})
+		_ = pipeWriter.CloseWithError(err)
+	}()
+
+	err = backup.OCIFromImage(pipeReader, w, workDir)
+	_ = pipeReader.CloseWithError(err)
+
+	return err
+}
+
+// backupIncrementalBase returns the newest snapshot of the instance included in the previous backup, which the
+// volume streams of an incremental backup are made relative to.
+func backupIncrementalBase(s *state.State, sourceInst instance.Instance, parentName string) (instance.Instance, error) {
+	parent, err := s.DB.Cluster.GetInstanceBackup(sourceInst.Project(), parentName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading previous backup %q: %w", parentName, err)
+	}
+
+	snapshots, err := sourceInst.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshots are in age order (oldest first) and all those existing when the previous backup was created
+	// were included in it.
+	var base instance.Instance
+	for _, snapshot := range snapshots {
+		if snapshot.CreationDate().After(parent.CreationDate) {
+			break
+		}
+
+		base = snapshot
+	}
+
+	if base == nil {
+		return nil, fmt.Errorf("No snapshot included in the previous backup %q remains to make an incremental backup from", parentName)
+	}
+
+	return base, nil
+}
+
 // backupWriteIndex generates an index.yaml file and then writes it to the root of the backup tarball.
-func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, optimized bool, snapshots bool, tarWriter *instancewriter.InstanceTarWriter) error {
+func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, optimized bool, snapshots bool, incremental *backup.Incremental, exclude []string, tarWriter *instancewriter.InstanceTarWriter) error {
 	// Indicate whether the driver will include a driver-specific optimized header.
 	poolDriverOptimizedHeader := false
 	if optimized {
@@ -249,6 +338,8 @@ func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, opti
 		OptimizedStorage: &optimized,
 		OptimizedHeader:  &poolDriverOptimizedHeader,
 		Config:           config,
+		Incremental:      incremental,
+		Exclude:          exclude,
 	}
 
 	if snapshots {
@@ -281,15 +372,43 @@ func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, opti
 	return nil
 }
 
+// backupWriteManifest generates a manifest.yaml file containing the size and checksum of the files written to the
+// backup tarball so far and then writes it to the backup tarball. It must be the last file written to the tarball.
+func backupWriteManifest(tarWriter *instancewriter.InstanceTarWriter) error {
+	manifest := backup.Manifest{Files: map[string]backup.ManifestFile{}}
+	for name, checksum := range tarWriter.Checksums() {
+		manifest.Files[name] = backup.ManifestFile{Size: checksum.Size, SHA256: checksum.SHA256}
+	}
+
+	// Convert to YAML.
+	manifestData, err := yaml.Marshal(&manifest)
+	if err != nil {
+		return err
+	}
+
+	manifestFileInfo := instancewriter.FileInfo{
+		FileName:    backup.ManifestFileName,
+		FileSize:    int64(len(manifestData)),
+		FileMode:    0644,
+		FileModTime: time.Now(),
+	}
+
+	// Write to tarball.
+	return tarWriter.WriteFileFromReader(bytes.NewReader(manifestData), &manifestFileInfo)
+}
+
 func pruneExpiredContainerBackupsTask(d *Daemon) (task.Func, task.Schedule) {
 	f := func(ctx context.Context) {
 		opRun := func(op *operations.Operation) error {
-			return pruneExpiredContainerBackups(ctx, d)
+			err := pruneExpiredContainerBackups(ctx, d)
+			task.ReportError(ctx, err)
+			return err
 		}
 
 		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationBackupsExpire, nil, nil, opRun, nil, nil, nil)
 		if err != nil {
 			logger.Error("Failed to start expired instance backups operation", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 			return
 		}
 
@@ -297,6 +416,7 @@ func pruneExpiredContainerBackupsTask(d *Daemon) (task.Func, task.Schedule) {
 		err = op.Start()
 		if err != nil {
 			logger.Error("Failed to expire instance backups", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 		}
 
 		op.Wait(ctx)
@@ -333,7 +453,7 @@ func pruneExpiredContainerBackups(ctx context.Context, d *Daemon) error {
 			return fmt.Errorf("Error loading instance for deleting backup %q: %w", b.Name, err)
 		}
 
-		instBackup := backup.NewInstanceBackup(d.State(), inst, b.ID, b.Name, b.CreationDate, b.ExpiryDate, b.InstanceOnly, b.OptimizedStorage)
+		instBackup := backup.NewInstanceBackup(d.State(), inst, b.ID, b.Name, b.CreationDate, b.ExpiryDate, b.InstanceOnly, b.OptimizedStorage, b.ObjectKey)
 		err = instBackup.Delete()
 		if err != nil {
 			return fmt.Errorf("Error deleting instance backup %q: %w", b.Name, err)
@@ -343,7 +463,7 @@ func pruneExpiredContainerBackups(ctx context.Context, d *Daemon) error {
 	return nil
 }
 
-func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, projectName string, poolName string, volumeName string) error {
+func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, projectName string, poolName string, volumeName string, op *operations.Operation) error {
 	l := logger.AddContext(logger.Log, logger.Ctx{"project": projectName, "storage_volume": volumeName, "name": args.Name})
 	l.Debug("Volume backup started")
 	defer l.Debug("Volume backup finished")
@@ -380,17 +500,10 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 	}
 
 	// Detect compression method.
-	var compress string
-
 	backupRow.CompressionAlgorithm = args.CompressionAlgorithm
-
-	if backupRow.CompressionAlgorithm != "" {
-		compress = backupRow.CompressionAlgorithm
-	} else {
-		compress, err = clusterConfig.GetString(s.DB.Cluster, "backups.compression_algorithm")
-		if err != nil {
-			return err
-		}
+	compress, err := backupCompressionAlgorithm(s, backupRow.CompressionAlgorithm, pool, projectName)
+	if err != nil {
+		return err
 	}
 
 	// Create the target path if needed.
@@ -427,17 +540,14 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 	go func(resCh chan<- error) {
 		l.Debug("Started backup tarball writer")
 		defer l.Debug("Finished backup tarball writer")
-		if compress != "none" {
-			compressErr = compressFile(compress, tarPipeReader, tarFileWriter)
+		compressErr = backupCompress(op, compress, tarPipeReader, tarFileWriter)
 
-			// If a compression error occurred, close the tarPipeWriter to end the export.
-			if compressErr != nil {
-				_ = tarPipeWriter.Close()
-			}
-		} else {
-			_, err = io.Copy(tarFileWriter, tarPipeReader)
+		// If a compression error occurred, close the tarPipeWriter to end the export.
+		if compressErr != nil {
+			_ = tarPipeWriter.Close()
 		}
-		resCh <- err
+
+		resCh <- compressErr
 	}(tarWriterRes)
 
 	// Write index file.
@@ -459,6 +569,13 @@ func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, project
 		return fmt.Errorf("Backup create: %w", err)
 	}
 
+	// Write manifest file.
+	l.Debug("Adding backup manifest file")
+	err = backupWriteManifest(tarWriter)
+	if err != nil {
+		return fmt.Errorf("Error writing backup manifest file: %w", err)
+	}
+
 	// Close off the tarball file.
 	err = tarWriter.Close()
 	if err != nil {
@@ -537,3 +654,142 @@ func volumeBackupWriteIndex(s *state.State, projectName string, volumeName strin
 
 	return nil
 }
+
+// backupCompressionAlgorithm returns the compression algorithm of a new backup, taken from the most specific of
+// the backup itself, its storage pool, its project and the server.
+func backupCompressionAlgorithm(s *state.State, requested string, pool storagePools.Pool, projectName string) (string, error) {
+	if requested != "" {
+		return requested, nil
+	}
+
+	if pool.Driver().Config()["backups.compression_algorithm"] != "" {
+		return pool.Driver().Config()["backups.compression_algorithm"], nil
+	}
+
+	var p *api.Project
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		project, err := dbCluster.GetProject(ctx, tx.Tx(), projectName)
+		if err != nil {
+			return err
+		}
+
+		p, err = project.ToAPI(ctx, tx.Tx())
+
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if p.Config["backups.compression_algorithm"] != "" {
+		return p.Config["backups.compression_algorithm"], nil
+	}
+
+	return clusterConfig.GetString(s.DB.Cluster, "backups.compression_algorithm")
+}
+
+// backupCompress writes the tarball read from r to w, compressed with the given algorithm unless it's "none".
+// The throughput on both sides of the compressor and the compression ratio are reported in the operation
+// metadata as the backup progresses.
+func backupCompress(op *operations.Operation, compress string, r io.ReadCloser, w io.WriteCloser) error {
+	command, err := backup.CompressionCommand(compress)
+	if err != nil {
+		return err
+	}
+
+	var metaLock sync.Mutex
+	var read, written int64
+	start := time.Now()
+
+	updateMetadata := func(key string, value string) {
+		if op == nil {
+			return
+		}
+
+		metaLock.Lock()
+		defer metaLock.Unlock()
+
+		meta := op.Metadata()
+		if meta == nil {
+			meta = make(map[string]any)
+		}
+
+		meta[key] = value
+		meta["create_backup_compression"] = command
+
+		if atomic.LoadInt64(&written) > 0 {
+			meta["create_backup_ratio"] = fmt.Sprintf("%.2f", float64(atomic.LoadInt64(&read))/float64(atomic.LoadInt64(&written)))
+		}
+
+		_ = op.UpdateMetadata(meta)
+	}
+
+	progressReader := &ioprogress.ProgressReader{
+		ReadCloser: r,
+		Tracker: &ioprogress.ProgressTracker{
+			Handler: func(value, speed int64) {
+				atomic.StoreInt64(&read, value)
+				updateMetadata("create_backup_input", fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(value, 2), units.GetByteSizeString(speed, 2)))
+			},
+		},
+	}
+
+	progressWriter := &ioprogress.ProgressWriter{
+		WriteCloser: w,
+		Tracker: &ioprogress.ProgressTracker{
+			Handler: func(value, speed int64) {
+				atomic.StoreInt64(&written, value)
+				updateMetadata("create_backup_progress", fmt.Sprintf("%s (%s/s)", units.GetByteSizeString(value, 2), units.GetByteSizeString(speed, 2)))
+			},
+		},
+	}
+
+	// Count the bytes on both sides, the trackers only reporting once per second.
+	inCounter := &byteCounter{Reader: progressReader}
+	outCounter := &byteCounter{Writer: progressWriter}
+
+	if command != "none" {
+		err = compressFile(command, inCounter, outCounter)
+	} else {
+		_, err = io.Copy(outCounter, inCounter)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Report the final figures.
+	duration := time.Since(start)
+	atomic.StoreInt64(&read, inCounter.count)
+	atomic.StoreInt64(&written, outCounter.count)
+
+	speed := int64(0)
+	if duration.Seconds() > 0 {
+		speed = int64(float64(inCounter.count) / duration.Seconds())
+	}
+
+	updateMetadata("create_backup_progress", fmt.Sprintf("%s in %s (%s/s uncompressed)", units.GetByteSizeString(outCounter.count, 2), duration.Round(time.Second), units.GetByteSizeString(speed, 2)))
+
+	return nil
+}
+
+// byteCounter counts the bytes going through a reader or writer.
+type byteCounter struct {
+	io.Reader
+	io.Writer
+	count int64
+}
+
+// Read reads from the underlying reader, counting the bytes read.
+func (c *byteCounter) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// Write writes to the underlying writer, counting the bytes written.
+func (c *byteCounter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	c.count += int64(n)
+	return n, err
+}
@@ -40,3 +40,8 @@ func (b *CommonBackup) SetCompressionAlgorithm(compression string) {
 func (b *CommonBackup) OptimizedStorage() bool {
 	return b.optimizedStorage
 }
+
+// S3Target returns the S3 bucket backups are stored in according to the server configuration.
+func (b *CommonBackup) S3Target() S3Target {
+	return NewS3Target(b.state)
+}
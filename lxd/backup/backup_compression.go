@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// threadedCompressors lists the compressors able to spread their work over several threads.
+var threadedCompressors = []string{"xz", "zstd"}
+
+// CompressionCommand returns the compression command used to write backup tarballs with the given algorithm.
+//
+// The algorithm may carry its own arguments, such as a compression level (e.g. "zstd -19"). Compressors which
+// support it are made to use all the CPUs unless a thread count is already given, and gzip is replaced by pigz
+// when available as it produces compatible output using all the CPUs.
+func CompressionCommand(algorithm string) (string, error) {
+	if algorithm == "none" || algorithm == "squashfs" {
+		return algorithm, nil
+	}
+
+	fields, err := shellquote.Split(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	if len(fields) == 0 {
+		return "", fmt.Errorf("Empty compression algorithm")
+	}
+
+	switch {
+	case fields[0] == "gzip":
+		_, err := exec.LookPath("pigz")
+		if err == nil {
+			fields[0] = "pigz"
+		}
+
+	case shared.StringInSlice(fields[0], threadedCompressors):
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "-T") || strings.HasPrefix(field, "--threads") {
+				return algorithm, nil
+			}
+		}
+
+		fields = append(fields, "-T0")
+	}
+
+	return shellquote.Join(fields...), nil
+}
@@ -13,7 +13,6 @@ import (
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/shared"
-	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/osarch"
 )
 
@@ -52,14 +51,10 @@ func ParseConfigYamlFile(path string) (*config.Config, error) {
 		return nil, err
 	}
 
+	// Older versions of the format are upgraded as the config is decoded.
 	backupConf := config.Config{}
 	if err := yaml.Unmarshal(data, &backupConf); err != nil {
-		return nil, err
-	}
-
-	// Default to container if type not specified in backup config.
-	if backupConf.Container != nil && backupConf.Container.Type == "" {
-		backupConf.Container.Type = string(api.InstanceTypeContainer)
+		return nil, fmt.Errorf("Failed parsing %q: %w", path, err)
 	}
 
 	return &backupConf, nil
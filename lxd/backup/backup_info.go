@@ -3,6 +3,7 @@ package backup
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -49,6 +50,29 @@ type Info struct {
 	OptimizedHeader  *bool          `json:"optimized_header,omitempty" yaml:"optimized_header,omitempty"` // Optional field to handle older optimized backups that don't have this field.
 	Type             Type           `json:"type,omitempty" yaml:"type,omitempty"`                         // Type of backup.
 	Config           *config.Config `json:"config,omitempty" yaml:"config,omitempty"`                     // Equivalent of backup.yaml but embedded in index for quick retrieval.
+	Incremental      *Incremental   `json:"incremental,omitempty" yaml:"incremental,omitempty"`           // Set when the backup only contains the changes since a previous backup.
+	Exclude          []string       `json:"exclude,omitempty" yaml:"exclude,omitempty"`                   // Paths left out of the backup (excluded directories are kept empty).
+}
+
+// Incremental represents the previous backup an incremental backup depends on.
+// The volume streams of an incremental backup are relative to the newest snapshot included in the previous
+// backup (the base), so restoring it requires the base to be present on the target.
+type Incremental struct {
+	Parent        string    `json:"parent" yaml:"parent"`                   // Name of the previous backup.
+	Base          string    `json:"base" yaml:"base"`                       // Name of the snapshot the volume streams are relative to.
+	BaseCreatedAt time.Time `json:"base_created_at" yaml:"base_created_at"` // Creation date of the base snapshot.
+}
+
+// IncrementalSnapshots returns the snapshots newer than the base snapshot, the only ones whose volume streams
+// are included in an incremental backup. The snapshots must be in age order, oldest first.
+func IncrementalSnapshots(snapshots []string, base string) ([]string, error) {
+	for i, snapName := range snapshots {
+		if snapName == base {
+			return snapshots[i+1:], nil
+		}
+	}
+
+	return nil, fmt.Errorf("Base snapshot %q of incremental backup not found", base)
 }
 
 // GetInfo extracts backup information from a given ReadSeeker.
@@ -126,5 +150,17 @@ func GetInfo(r io.ReadSeeker, sysOS *sys.OS, outputPath string) (*Info, error) {
 		return nil, fmt.Errorf("Backup is missing index.yaml")
 	}
 
+	// Validate the dependency of incremental backups.
+	if result.Incremental != nil {
+		if !*result.OptimizedStorage {
+			return nil, fmt.Errorf("Incremental backups must use optimized storage")
+		}
+
+		_, err = IncrementalSnapshots(result.Snapshots, result.Incremental.Base)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &result, nil
 }
@@ -27,10 +27,11 @@ type InstanceBackup struct {
 
 	instance     Instance
 	instanceOnly bool
+	objectKey    string
 }
 
 // NewInstanceBackup instantiates a new InstanceBackup struct.
-func NewInstanceBackup(state *state.State, inst Instance, ID int, name string, creationDate time.Time, expiryDate time.Time, instanceOnly bool, optimizedStorage bool) *InstanceBackup {
+func NewInstanceBackup(state *state.State, inst Instance, ID int, name string, creationDate time.Time, expiryDate time.Time, instanceOnly bool, optimizedStorage bool, objectKey string) *InstanceBackup {
 	return &InstanceBackup{
 		CommonBackup: CommonBackup{
 			state:            state,
@@ -42,6 +43,7 @@ func NewInstanceBackup(state *state.State, inst Instance, ID int, name string, c
 		},
 		instance:     inst,
 		instanceOnly: instanceOnly,
+		objectKey:    objectKey,
 	}
 }
 
@@ -50,13 +52,23 @@ func (b *InstanceBackup) InstanceOnly() bool {
 	return b.instanceOnly
 }
 
+// ObjectKey returns the key of the S3 object the backup is stored in, or an empty string if stored locally.
+func (b *InstanceBackup) ObjectKey() string {
+	return b.objectKey
+}
+
 // Instance returns the instance to be backed up.
 func (b *InstanceBackup) Instance() Instance {
 	return b.instance
 }
 
 // Rename renames an instance backup.
+// The object of backups stored in S3 is kept under its original key.
 func (b *InstanceBackup) Rename(newName string) error {
+	if b.objectKey != "" {
+		return b.renameRecord(newName)
+	}
+
 	oldBackupPath := shared.VarPath("backups", "instances", project.Instance(b.instance.Project(), b.name))
 	newBackupPath := shared.VarPath("backups", "instances", project.Instance(b.instance.Project(), newName))
 
@@ -91,8 +103,12 @@ func (b *InstanceBackup) Rename(newName string) error {
 		}
 	}
 
-	// Rename the database record.
-	err = b.state.DB.Cluster.RenameInstanceBackup(b.name, newName)
+	return b.renameRecord(newName)
+}
+
+// renameRecord renames the database record of the backup.
+func (b *InstanceBackup) renameRecord(newName string) error {
+	err := b.state.DB.Cluster.RenameInstanceBackup(b.name, newName)
 	if err != nil {
 		return err
 	}
@@ -107,6 +123,14 @@ func (b *InstanceBackup) Rename(newName string) error {
 func (b *InstanceBackup) Delete() error {
 	backupPath := shared.VarPath("backups", "instances", project.Instance(b.instance.Project(), b.name))
 
+	// Delete the S3 object.
+	if b.objectKey != "" {
+		err := b.S3Target().Delete(b.objectKey)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Delete the on-disk data.
 	if shared.PathExists(backupPath) {
 		err := os.RemoveAll(backupPath)
@@ -145,5 +169,6 @@ func (b *InstanceBackup) Render() *api.InstanceBackup {
 		InstanceOnly:     b.instanceOnly,
 		ContainerOnly:    b.instanceOnly,
 		OptimizedStorage: b.optimizedStorage,
+		ObjectKey:        b.objectKey,
 	}
 }
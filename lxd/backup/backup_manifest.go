@@ -0,0 +1,242 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxd/archive"
+	"github.com/lxc/lxd/lxd/sys"
+	"github.com/lxc/lxd/shared"
+)
+
+// ManifestFileName is the name of the manifest file in the backup tarball.
+const ManifestFileName = "backup/manifest.yaml"
+
+// ManifestFile represents the size and SHA256 checksum of a file in the backup tarball.
+type ManifestFile struct {
+	Size   int64  `json:"size" yaml:"size"`
+	SHA256 string `json:"sha256" yaml:"sha256"`
+}
+
+// Manifest represents the list of files in the backup tarball along with their size and checksum.
+// It is written as the last member of the tarball as it is generated while the other files are written.
+type Manifest struct {
+	Files map[string]ManifestFile `json:"files" yaml:"files"`
+}
+
+// ManifestError is returned when the content of a backup tarball doesn't match its manifest.
+type ManifestError struct {
+	// Entries maps the names of the corrupted files to the reason they failed verification.
+	Entries map[string]string
+}
+
+// Error returns the list of corrupted files, one per line.
+func (e ManifestError) Error() string {
+	names := make([]string, 0, len(e.Entries))
+	for name := range e.Entries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Backup failed verification against its manifest (%d corrupted entries):", len(names))
+	for _, name := range names {
+		fmt.Fprintf(&sb, "\n - %s: %s", name, e.Entries[name])
+	}
+
+	return sb.String()
+}
+
+// Verify compares the files found in the backup tarball against the manifest.
+func (m *Manifest) Verify(files map[string]ManifestFile) error {
+	entries := map[string]string{}
+
+	for name, expected := range m.Files {
+		found, ok := files[name]
+		if !ok {
+			entries[name] = "missing"
+		} else if found.Size != expected.Size {
+			entries[name] = fmt.Sprintf("size mismatch (expected %d bytes, got %d)", expected.Size, found.Size)
+		} else if found.SHA256 != expected.SHA256 {
+			entries[name] = fmt.Sprintf("checksum mismatch (expected %s, got %s)", expected.SHA256, found.SHA256)
+		}
+	}
+
+	for name := range files {
+		_, ok := m.Files[name]
+		if !ok {
+			entries[name] = "not in manifest"
+		}
+	}
+
+	if len(entries) > 0 {
+		return ManifestError{Entries: entries}
+	}
+
+	return nil
+}
+
+// VerifyManifest reads the whole backup tarball and checks its files against the manifest.
+// Backups created before the manifest was introduced are not verified.
+func VerifyManifest(r io.ReadSeeker, sysOS *sys.OS, outputPath string) error {
+	tr, cancelFunc, err := TarReader(r, sysOS, outputPath)
+	if err != nil {
+		return err
+	}
+
+	defer cancelFunc()
+
+	return verifyManifestTar(tr)
+}
+
+// verifyManifestTar reads the tarball until its end and checks its files against the manifest.
+func verifyManifestTar(tr *tar.Reader) error {
+	var manifest *Manifest
+	files := map[string]ManifestFile{}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break // End of archive.
+		}
+
+		if err != nil {
+			return fmt.Errorf("Error reading backup file: %w", err)
+		}
+
+		if hdr.Name == ManifestFileName {
+			manifest = &Manifest{}
+			err = yaml.NewDecoder(tr).Decode(manifest)
+			if err != nil {
+				return fmt.Errorf("Failed parsing backup manifest: %w", err)
+			}
+
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		hash := sha256.New()
+		size, err := io.Copy(hash, tr)
+		if err != nil {
+			return fmt.Errorf("Error reading backup file %q: %w", hdr.Name, err)
+		}
+
+		files[hdr.Name] = ManifestFile{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}
+	}
+
+	if manifest == nil {
+		return nil
+	}
+
+	return manifest.Verify(files)
+}
+
+// ManifestVerifier checks a backup tarball against its manifest while it is being written to it, so that
+// the uploaded tarball doesn't need to be read a second time for verification.
+// Writes to the verifier never fail and must be followed by a call to Verify().
+type ManifestVerifier struct {
+	pipeWriter *io.PipeWriter
+	done       chan struct{}
+	checked    bool
+	err        error
+}
+
+// NewManifestVerifier returns a new ManifestVerifier. The outputPath is used to name the AppArmor profile of
+// the decompression process.
+func NewManifestVerifier(sysOS *sys.OS, outputPath string) *ManifestVerifier {
+	pipeReader, pipeWriter := io.Pipe()
+
+	v := &ManifestVerifier{
+		pipeWriter: pipeWriter,
+		done:       make(chan struct{}),
+	}
+
+	go func() {
+		defer close(v.done)
+
+		v.checked, v.err = verifyManifestStream(pipeReader, sysOS, outputPath)
+
+		// Keep consuming the stream so that writes to the verifier never block.
+		_, _ = io.Copy(io.Discard, pipeReader)
+	}()
+
+	return v
+}
+
+// Write feeds the next part of the tarball to the verifier.
+func (v *ManifestVerifier) Write(p []byte) (int, error) {
+	_, _ = v.pipeWriter.Write(p)
+
+	return len(p), nil
+}
+
+// Verify signals the end of the tarball and returns the result of the verification.
+// The returned bool is false if the tarball couldn't be checked while streamed (such as squashfs backups), in
+// which case VerifyManifest() should be used once the tarball has been converted.
+func (v *ManifestVerifier) Verify() (bool, error) {
+	_ = v.pipeWriter.Close()
+	<-v.done
+
+	return v.checked, v.err
+}
+
+// verifyManifestStream checks the tarball read from r against its manifest.
+// The unpacker process is fed through a separate pipe which is closed once the end of the tarball has been
+// reached, so that it terminates even if trailing data hasn't been read.
+func verifyManifestStream(r io.Reader, sysOS *sys.OS, outputPath string) (bool, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(263)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	_, ext, unpacker, err := shared.DetectCompressionFile(bytes.NewReader(header))
+	if err != nil {
+		return false, err
+	}
+
+	if len(unpacker) > 0 && !strings.HasPrefix(ext, ".tar") {
+		return false, nil
+	}
+
+	feedReader, feedWriter := io.Pipe()
+	feedDone := make(chan struct{})
+
+	go func() {
+		defer close(feedDone)
+
+		_, err := io.Copy(feedWriter, br)
+		if err != nil {
+			// The reader side got closed, discard the rest of the stream.
+			_, _ = io.Copy(io.Discard, br)
+		}
+
+		_ = feedWriter.Close()
+	}()
+
+	tr, cancelFunc, err := archive.CompressedTarStreamReader(context.Background(), feedReader, unpacker, sysOS, outputPath)
+	if err == nil {
+		err = verifyManifestTar(tr)
+	}
+
+	_ = feedReader.CloseWithError(io.ErrClosedPipe)
+	cancelFunc()
+	<-feedDone
+
+	return true, err
+}
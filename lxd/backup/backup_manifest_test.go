@@ -0,0 +1,168 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+// manifestTestFiles are the files put in the test tarballs.
+var manifestTestFiles = map[string]string{
+	"backup/index.yaml":     "name: c1\n",
+	"backup/container.bin":  "binary content",
+	"backup/snapshots/snap": "snapshot content",
+}
+
+func manifestTestFile(content string) ManifestFile {
+	hash := sha256.Sum256([]byte(content))
+
+	return ManifestFile{Size: int64(len(content)), SHA256: hex.EncodeToString(hash[:])}
+}
+
+// manifestTestTarball returns a plain tarball containing files followed by a manifest generated from manifestFiles.
+func manifestTestTarball(t *testing.T, files map[string]string, manifestFiles map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	writeFile := func(name string, content []byte) {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		require.NoError(t, err)
+
+		_, err = tw.Write(content)
+		require.NoError(t, err)
+	}
+
+	for name, content := range files {
+		writeFile(name, []byte(content))
+	}
+
+	if manifestFiles != nil {
+		manifest := Manifest{Files: map[string]ManifestFile{}}
+		for name, content := range manifestFiles {
+			manifest.Files[name] = manifestTestFile(content)
+		}
+
+		content, err := yaml.Marshal(manifest)
+		require.NoError(t, err)
+
+		writeFile(ManifestFileName, content)
+	}
+
+	require.NoError(t, tw.Close())
+
+	return buf.Bytes()
+}
+
+func TestManifest_Verify(t *testing.T) {
+	manifest := Manifest{Files: map[string]ManifestFile{
+		"a": manifestTestFile("aaa"),
+		"b": manifestTestFile("bbb"),
+	}}
+
+	cases := []struct {
+		name    string
+		files   map[string]ManifestFile
+		entries map[string]string
+	}{
+		{
+			name:  "matching",
+			files: map[string]ManifestFile{"a": manifestTestFile("aaa"), "b": manifestTestFile("bbb")},
+		},
+		{
+			name:    "missing",
+			files:   map[string]ManifestFile{"a": manifestTestFile("aaa")},
+			entries: map[string]string{"b": "missing"},
+		},
+		{
+			name:    "size mismatch",
+			files:   map[string]ManifestFile{"a": manifestTestFile("aaa"), "b": manifestTestFile("bbbb")},
+			entries: map[string]string{"b": "size mismatch (expected 3 bytes, got 4)"},
+		},
+		{
+			name:    "checksum mismatch",
+			files:   map[string]ManifestFile{"a": manifestTestFile("aaa"), "b": manifestTestFile("bbc")},
+			entries: map[string]string{"b": "checksum mismatch (expected " + manifestTestFile("bbb").SHA256 + ", got " + manifestTestFile("bbc").SHA256 + ")"},
+		},
+		{
+			name:    "not in manifest",
+			files:   map[string]ManifestFile{"a": manifestTestFile("aaa"), "b": manifestTestFile("bbb"), "c": manifestTestFile("ccc")},
+			entries: map[string]string{"c": "not in manifest"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := manifest.Verify(c.files)
+			if c.entries == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			manifestErr, ok := err.(ManifestError)
+			require.True(t, ok, "Expected a ManifestError, got %v", err)
+			assert.Equal(t, c.entries, manifestErr.Entries)
+		})
+	}
+}
+
+// manifestTestTarballs returns the test tarballs along with whether they should pass verification.
+func manifestTestTarballs(t *testing.T) map[string]struct {
+	tarball []byte
+	valid   bool
+} {
+	corrupted := map[string]string{}
+	for name, content := range manifestTestFiles {
+		corrupted[name] = content
+	}
+
+	corrupted["backup/container.bin"] = "binary kontent"
+
+	return map[string]struct {
+		tarball []byte
+		valid   bool
+	}{
+		"valid":       {tarball: manifestTestTarball(t, manifestTestFiles, manifestTestFiles), valid: true},
+		"no manifest": {tarball: manifestTestTarball(t, manifestTestFiles, nil), valid: true},
+		"corrupted":   {tarball: manifestTestTarball(t, corrupted, manifestTestFiles), valid: false},
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	for name, c := range manifestTestTarballs(t) {
+		t.Run(name, func(t *testing.T) {
+			err := VerifyManifest(bytes.NewReader(c.tarball), nil, "")
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.IsType(t, ManifestError{}, err)
+			}
+		})
+	}
+}
+
+func TestManifestVerifier(t *testing.T) {
+	for name, c := range manifestTestTarballs(t) {
+		t.Run(name, func(t *testing.T) {
+			verifier := NewManifestVerifier(nil, "")
+
+			// Write in small chunks and append trailing data, which must not block the writer.
+			_, err := io.CopyBuffer(verifier, struct{ io.Reader }{bytes.NewReader(append(c.tarball, make([]byte, 10240)...))}, make([]byte, 100))
+			require.NoError(t, err)
+
+			checked, err := verifier.Verify()
+			assert.True(t, checked)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.IsType(t, ManifestError{}, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,637 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxd/archive"
+	"github.com/lxc/lxd/lxd/sys"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/instancewriter"
+	"github.com/lxc/lxd/shared/osarch"
+)
+
+// FormatOCI is the backup format producing an OCI image layout.
+const FormatOCI = "oci"
+
+const (
+	ociMediaTypeIndex         = "application/vnd.oci.image.index.v1+json"
+	ociMediaTypeManifest      = "application/vnd.oci.image.manifest.v1+json"
+	ociMediaTypeConfig        = "application/vnd.oci.image.config.v1+json"
+	ociMediaTypeLayerGzip     = "application/vnd.oci.image.layer.v1.tar+gzip"
+	ociAnnotationRefName      = "org.opencontainers.image.ref.name"
+	ociWhiteoutPrefix         = ".wh."
+	ociWhiteoutOpaqueDir      = ".wh..wh..opq"
+	ociImageLayoutVersion     = "1.0.0"
+	ociDefaultCommand         = "/sbin/init"
+	ociDefaultReferenceName   = "latest"
+	ociDockerMediaTypeList    = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ociDockerMediaTypeForeign = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+)
+
+// ociArchitectures maps the LXD architecture names to the OCI (Go) ones.
+var ociArchitectures = map[string]string{
+	"i686":    "386",
+	"x86_64":  "amd64",
+	"armv7l":  "arm",
+	"aarch64": "arm64",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+	"riscv64": "riscv64",
+}
+
+// ociDigestSizes maps the supported digest algorithms to the size of their hashes.
+var ociDigestSizes = map[string]int{
+	"sha256": sha256.Size,
+	"sha512": sha512.Size,
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociIndex struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType,omitempty"`
+	Manifests     []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType,omitempty"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+type ociImageConfig struct {
+	Created      *time.Time       `json:"created,omitempty"`
+	Architecture string           `json:"architecture"`
+	OS           string           `json:"os"`
+	Config       ociRuntimeConfig `json:"config"`
+	RootFS       ociRootFS        `json:"rootfs"`
+}
+
+type ociRuntimeConfig struct {
+	Env    []string          `json:"Env,omitempty"`
+	Cmd    []string          `json:"Cmd,omitempty"`
+	Labels map[string]string `json:"Labels,omitempty"`
+}
+
+type ociRootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+// OCIFromImage converts the unified image tarball of an instance, as produced by its export, read from r into an
+// OCI image layout written as a tarball to w. The root filesystem becomes a single compressed layer and the image
+// properties become the labels of the image configuration. Temporary files are created in workDir.
+func OCIFromImage(r io.Reader, w io.Writer, workDir string) error {
+	layerFile, err := ioutil.TempFile(workDir, "oci_layer_")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = layerFile.Close()
+		_ = os.Remove(layerFile.Name())
+	}()
+
+	// Write the layer, tracking both the digest of the compressed blob and of its content.
+	layerHash := sha256.New()
+	diffHash := sha256.New()
+	gzWriter := gzip.NewWriter(io.MultiWriter(layerFile, layerHash))
+	layerWriter := tar.NewWriter(io.MultiWriter(gzWriter, diffHash))
+
+	var metadata api.ImageMetadata
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed reading image tarball: %w", err)
+		}
+
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if name == "metadata.yaml" {
+			content, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+
+			err = yaml.Unmarshal(content, &metadata)
+			if err != nil {
+				return fmt.Errorf("Failed parsing image metadata: %w", err)
+			}
+
+			continue
+		}
+
+		// Only the root filesystem is part of the layer, templates have no OCI equivalent.
+		if !strings.HasPrefix(name, "rootfs/") {
+			continue
+		}
+
+		hdr.Name = strings.TrimPrefix(name, "rootfs/")
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = strings.TrimPrefix(strings.TrimPrefix(hdr.Linkname, "./"), "rootfs/")
+		}
+
+		err = layerWriter.WriteHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(layerWriter, tr)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = layerWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	err = gzWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	layerInfo, err := layerFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	layer := ociDescriptor{
+		MediaType: ociMediaTypeLayerGzip,
+		Digest:    fmt.Sprintf("sha256:%x", layerHash.Sum(nil)),
+		Size:      layerInfo.Size(),
+	}
+
+	// Generate the image configuration.
+	arch, ok := ociArchitectures[metadata.Architecture]
+	if !ok {
+		return fmt.Errorf("Architecture %q isn't supported by OCI images", metadata.Architecture)
+	}
+
+	created := time.Unix(metadata.CreationDate, 0).UTC()
+	config, err := json.Marshal(ociImageConfig{
+		Created:      &created,
+		Architecture: arch,
+		OS:           "linux",
+		Config:       ociRuntimeConfig{Cmd: []string{ociDefaultCommand}, Labels: metadata.Properties},
+		RootFS:       ociRootFS{Type: "layers", DiffIDs: []string{fmt.Sprintf("sha256:%x", diffHash.Sum(nil))}},
+	})
+	if err != nil {
+		return err
+	}
+
+	manifest, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociMediaTypeManifest,
+		Config:        ociBlobDescriptor(ociMediaTypeConfig, config),
+		Layers:        []ociDescriptor{layer},
+	})
+	if err != nil {
+		return err
+	}
+
+	manifestDescriptor := ociBlobDescriptor(ociMediaTypeManifest, manifest)
+	manifestDescriptor.Annotations = map[string]string{ociAnnotationRefName: ociDefaultReferenceName}
+
+	index, err := json.Marshal(ociIndex{
+		SchemaVersion: 2,
+		MediaType:     ociMediaTypeIndex,
+		Manifests:     []ociDescriptor{manifestDescriptor},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the image layout.
+	tw := tar.NewWriter(w)
+
+	err = ociWriteFile(tw, "oci-layout", []byte(fmt.Sprintf(`{"imageLayoutVersion":"%s"}`, ociImageLayoutVersion)))
+	if err != nil {
+		return err
+	}
+
+	_, err = layerFile.Seek(0, 0)
+	if err != nil {
+		return err
+	}
+
+	err = tw.WriteHeader(&tar.Header{Name: ociBlobPath(layer.Digest), Mode: 0644, Size: layer.Size, ModTime: created, Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, layerFile)
+	if err != nil {
+		return err
+	}
+
+	for _, blob := range [][]byte{config, manifest} {
+		err = ociWriteFile(tw, ociBlobPath(fmt.Sprintf("sha256:%x", sha256.Sum256(blob))), blob)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ociWriteFile(tw, "index.json", index)
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+// OCIToImage converts the OCI image layout in layoutDir into a unified image tarball written to w. The layers of
+// the image are applied in order onto a root filesystem unpacked in workDir. The image properties are combined
+// with the labels of the image configuration. Returns the metadata of the generated image.
+func OCIToImage(layoutDir string, workDir string, sysOS *sys.OS, w io.Writer, properties map[string]string) (*api.ImageMetadata, error) {
+	var index ociIndex
+	err := ociReadJSON(filepath.Join(layoutDir, "index.json"), &index)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(index.Manifests) == 0 {
+		return nil, fmt.Errorf("OCI image layout doesn't contain any image")
+	}
+
+	if shared.StringInSlice(index.Manifests[0].MediaType, []string{ociMediaTypeIndex, ociDockerMediaTypeList}) {
+		return nil, fmt.Errorf("Multi-platform OCI images must be resolved to a single platform")
+	}
+
+	err = ociValidateDigest(index.Manifests[0].Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ociManifest
+	err = ociReadJSON(ociBlobPath(index.Manifests[0].Digest, layoutDir), &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check all the digests before using them as paths.
+	err = ociValidateDigest(manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, layer := range manifest.Layers {
+		err = ociValidateDigest(layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var config ociImageConfig
+	err = ociReadJSON(ociBlobPath(manifest.Config.Digest, layoutDir), &config)
+	if err != nil {
+		return nil, err
+	}
+
+	archID, err := osarch.ArchitectureId(config.Architecture)
+	if err != nil {
+		return nil, err
+	}
+
+	arch, err := osarch.ArchitectureName(archID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the layers.
+	rootfsPath := filepath.Join(workDir, "rootfs")
+	err = os.Mkdir(rootfsPath, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == ociDockerMediaTypeForeign {
+			continue
+		}
+
+		err = ociApplyLayer(ociBlobPath(layer.Digest, layoutDir), rootfsPath, sysOS)
+		if err != nil {
+			return nil, fmt.Errorf("Failed applying layer %q: %w", layer.Digest, err)
+		}
+	}
+
+	// Generate the image metadata.
+	metadata := &api.ImageMetadata{
+		Architecture: arch,
+		CreationDate: time.Now().UTC().Unix(),
+		Properties:   map[string]string{},
+	}
+
+	if config.Created != nil {
+		metadata.CreationDate = config.Created.Unix()
+	}
+
+	for k, v := range config.Config.Labels {
+		metadata.Properties[k] = v
+	}
+
+	for k, v := range properties {
+		metadata.Properties[k] = v
+	}
+
+	content, err := yaml.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(filepath.Join(workDir, "metadata.yaml"), content, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write the image tarball.
+	tarWriter := instancewriter.NewInstanceTarWriter(w, nil)
+	offset := len(workDir) + 1
+	for _, name := range []string{"metadata.yaml", "rootfs"} {
+		err = filepath.Walk(filepath.Join(workDir, name), func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			return tarWriter.WriteFile(path[offset:], path, fi, false)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// ociApplyLayer unpacks the layer at layerPath onto rootfsPath. The files removed by the whiteouts of the layer
+// are deleted from the lower layers first and the whiteout markers are removed after unpacking.
+func ociApplyLayer(layerPath string, rootfsPath string, sysOS *sys.OS) error {
+	f, err := os.Open(layerPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	_, _, unpacker, err := shared.DetectCompressionFile(f)
+	if err != nil {
+		return err
+	}
+
+	tr, cancelFunc, err := archive.CompressedTarReader(context.Background(), f, unpacker, sysOS, rootfsPath)
+	if err != nil {
+		return err
+	}
+
+	var whiteouts []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			cancelFunc()
+			return err
+		}
+
+		name := filepath.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if strings.HasPrefix(filepath.Base(name), ociWhiteoutPrefix) {
+			whiteouts = append(whiteouts, name)
+		}
+	}
+
+	cancelFunc()
+
+	// Resolve the whiteouts within the root filesystem so that the symlinks of the lower layers can't make them
+	// remove files outside of it.
+	root, err := os.Open(rootfsPath)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = root.Close() }()
+
+	for _, whiteout := range whiteouts {
+		err = ociApplyWhiteout(root, whiteout)
+		if err != nil {
+			return fmt.Errorf("Failed applying whiteout %q: %w", whiteout, err)
+		}
+	}
+
+	err = archive.Unpack(layerPath, rootfsPath, false, sysOS, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, whiteout := range whiteouts {
+		dir, base := filepath.Split(whiteout)
+
+		dirFile, err := ociOpenDirInRoot(root, dir)
+		if err != nil {
+			return err
+		}
+
+		err = unix.Unlinkat(int(dirFile.Fd()), base, 0)
+		_ = dirFile.Close()
+		if err != nil && !errors.Is(err, unix.ENOENT) {
+			return fmt.Errorf("Failed removing whiteout %q: %w", whiteout, err)
+		}
+	}
+
+	return nil
+}
+
+// ociApplyWhiteout removes the files hidden by the whiteout from the root filesystem opened as root.
+func ociApplyWhiteout(root *os.File, whiteout string) error {
+	dir, base := filepath.Split(whiteout)
+	if strings.HasPrefix(dir, "../") || strings.HasPrefix(dir, "/") {
+		return fmt.Errorf("Invalid whiteout path")
+	}
+
+	dirFile, err := ociOpenDirInRoot(root, dir)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ENOTDIR) {
+			return nil // Nothing to hide.
+		}
+
+		return err
+	}
+
+	defer func() { _ = dirFile.Close() }()
+
+	if base == ociWhiteoutOpaqueDir {
+		// Hide all the content of the directory from the lower layers.
+		names, err := dirFile.Readdirnames(-1)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			err = ociRemoveAt(dirFile, name)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	name := strings.TrimPrefix(base, ociWhiteoutPrefix)
+	if name == "" || name == "." || name == ".." {
+		return fmt.Errorf("Invalid whiteout path")
+	}
+
+	return ociRemoveAt(dirFile, name)
+}
+
+// ociOpenDirInRoot opens the directory at path, resolving it as if root was the root directory so that absolute
+// symlinks and ".." components can't escape it. Requires Linux kernel >= 5.6.
+func ociOpenDirInRoot(root *os.File, path string) (*os.File, error) {
+	if path == "" {
+		path = "."
+	}
+
+	fd, err := unix.Openat2(int(root.Fd()), path, &unix.OpenHow{
+		Flags:   unix.O_RDONLY | unix.O_DIRECTORY | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_IN_ROOT | unix.RESOLVE_NO_MAGICLINKS,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return os.NewFile(uintptr(fd), path), nil
+}
+
+// ociRemoveAt removes the entry called name from the directory, recursively if it's a directory.
+// Symlinks are removed rather than followed. Missing entries are ignored.
+func ociRemoveAt(dir *os.File, name string) error {
+	err := unix.Unlinkat(int(dir.Fd()), name, 0)
+	if err == nil || errors.Is(err, unix.ENOENT) {
+		return nil
+	}
+
+	if !errors.Is(err, unix.EISDIR) {
+		return err
+	}
+
+	fd, err := unix.Openat(int(dir.Fd()), name, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+
+	subDir := os.NewFile(uintptr(fd), name)
+	defer func() { _ = subDir.Close() }()
+
+	names, err := subDir.Readdirnames(-1)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range names {
+		err = ociRemoveAt(subDir, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return unix.Unlinkat(int(dir.Fd()), name, unix.AT_REMOVEDIR)
+}
+
+// ociBlobDescriptor returns the descriptor of the blob with the given content.
+func ociBlobDescriptor(mediaType string, content []byte) ociDescriptor {
+	return ociDescriptor{
+		MediaType: mediaType,
+		Digest:    fmt.Sprintf("sha256:%x", sha256.Sum256(content)),
+		Size:      int64(len(content)),
+	}
+}
+
+// ociValidateDigest checks that the digest is made of a supported algorithm and of the hex encoded hash.
+func ociValidateDigest(digest string) error {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("Invalid digest %q", digest)
+	}
+
+	size, ok := ociDigestSizes[parts[0]]
+	if !ok {
+		return fmt.Errorf("Unsupported digest algorithm %q", parts[0])
+	}
+
+	if len(parts[1]) != size*2 || strings.Trim(parts[1], "0123456789abcdef") != "" {
+		return fmt.Errorf("Invalid digest %q", digest)
+	}
+
+	return nil
+}
+
+// ociBlobPath returns the path of the blob with the given digest in the image layout, optionally prefixed.
+func ociBlobPath(digest string, prefix ...string) string {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		parts = []string{"sha256", digest}
+	}
+
+	return filepath.Join(append(prefix, "blobs", parts[0], parts[1])...)
+}
+
+// ociReadJSON reads the JSON document at path into target.
+func ociReadJSON(path string, target any) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(content, target)
+	if err != nil {
+		return fmt.Errorf("Failed parsing %q: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+// ociWriteFile writes a regular file with the given content to the tarball.
+func ociWriteFile(tw *tar.Writer, name string, content []byte) error {
+	err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: time.Now(), Typeflag: tar.TypeReg})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(content)
+	return err
+}
@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOCIValidateDigest(t *testing.T) {
+	valid := []string{
+		"sha256:" + strings.Repeat("a", 64),
+		"sha512:" + strings.Repeat("0", 128),
+	}
+
+	for _, digest := range valid {
+		assert.NoError(t, ociValidateDigest(digest), digest)
+	}
+
+	invalid := []string{
+		"",
+		strings.Repeat("a", 64),
+		"sha256:" + strings.Repeat("a", 63),
+		"sha256:" + strings.Repeat("A", 64),
+		"sha256:../../../../etc/passwd",
+		"sha512:" + strings.Repeat("0", 64),
+		"md5:" + strings.Repeat("0", 32),
+		"../sha256:" + strings.Repeat("a", 64),
+	}
+
+	for _, digest := range invalid {
+		assert.Error(t, ociValidateDigest(digest), digest)
+	}
+}
+
+func TestOCIApplyWhiteout(t *testing.T) {
+	tmpDir := t.TempDir()
+	rootfsPath := filepath.Join(tmpDir, "rootfs")
+	outsidePath := filepath.Join(tmpDir, "outside")
+
+	for _, dir := range []string{rootfsPath, outsidePath, filepath.Join(rootfsPath, "etc", "conf.d"), filepath.Join(rootfsPath, "var", "lib")} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+
+	for _, file := range []string{filepath.Join(outsidePath, "file"), filepath.Join(rootfsPath, "etc", "hosts"), filepath.Join(rootfsPath, "etc", "conf.d", "a"), filepath.Join(rootfsPath, "var", "lib", "b")} {
+		require.NoError(t, ioutil.WriteFile(file, []byte("content"), 0644))
+	}
+
+	// Symlinks from a lower layer pointing outside of the root filesystem.
+	require.NoError(t, os.Symlink(outsidePath, filepath.Join(rootfsPath, "escape")))
+	require.NoError(t, os.Symlink("../../outside", filepath.Join(rootfsPath, "var", "escape")))
+
+	root, err := os.Open(rootfsPath)
+	require.NoError(t, err)
+	defer func() { _ = root.Close() }()
+
+	// Whiteouts through the symlinks are resolved within the root filesystem.
+	require.NoError(t, ociApplyWhiteout(root, "escape/.wh.file"))
+	require.NoError(t, ociApplyWhiteout(root, "var/escape/.wh.file"))
+	require.NoError(t, ociApplyWhiteout(root, "escape/.wh..wh..opq"))
+	assert.FileExists(t, filepath.Join(outsidePath, "file"))
+
+	// Whiteouts of symlinks remove the symlinks themselves.
+	require.NoError(t, ociApplyWhiteout(root, ".wh.escape"))
+	assert.NoFileExists(t, filepath.Join(rootfsPath, "escape"))
+	assert.DirExists(t, outsidePath)
+
+	// Regular whiteouts.
+	require.NoError(t, ociApplyWhiteout(root, "etc/.wh.hosts"))
+	assert.NoFileExists(t, filepath.Join(rootfsPath, "etc", "hosts"))
+
+	require.NoError(t, ociApplyWhiteout(root, ".wh.var"))
+	assert.NoDirExists(t, filepath.Join(rootfsPath, "var"))
+
+	require.NoError(t, ociApplyWhiteout(root, "etc/.wh..wh..opq"))
+	assert.DirExists(t, filepath.Join(rootfsPath, "etc"))
+	assert.NoDirExists(t, filepath.Join(rootfsPath, "etc", "conf.d"))
+
+	// Whiteouts for missing paths are ignored.
+	require.NoError(t, ociApplyWhiteout(root, "missing/.wh.file"))
+
+	// Invalid whiteouts.
+	assert.Error(t, ociApplyWhiteout(root, "../.wh.outside"))
+	assert.Error(t, ociApplyWhiteout(root, "etc/.wh..."))
+	assert.FileExists(t, filepath.Join(outsidePath, "file"))
+}
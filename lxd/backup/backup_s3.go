@@ -0,0 +1,380 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lxc/lxd/lxd/state"
+)
+
+// TargetS3 is the backup target storing the backup tarballs in an S3-compatible object storage bucket.
+const TargetS3 = "s3"
+
+// s3PartSize is the size of the parts the backup tarballs are uploaded in.
+// As S3 allows up to 10000 parts per object, this limits the size of a backup to about 312GiB.
+const s3PartSize = 32 * 1024 * 1024
+
+// s3MaxParts is the maximum number of parts of a multipart upload.
+const s3MaxParts = 10000
+
+// s3EmptyPayloadHash is the SHA256 hash of an empty request body.
+const s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Target represents the S3-compatible object storage bucket backups are stored in.
+type S3Target struct {
+	Endpoint  string
+	Bucket    string
+	Prefix    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// NewS3Target returns the S3 bucket backups are stored in according to the server configuration.
+func NewS3Target(s *state.State) S3Target {
+	t := S3Target{}
+	t.Endpoint, t.Bucket, t.Prefix, t.Region, t.AccessKey, t.SecretKey = s.GlobalConfig.BackupsS3()
+
+	return t
+}
+
+// Validate checks that the bucket settings needed to store backups are set.
+func (t S3Target) Validate() error {
+	if t.Endpoint == "" || t.Bucket == "" {
+		return fmt.Errorf("The S3 backup target isn't configured (backups.s3.endpoint and backups.s3.bucket)")
+	}
+
+	if t.AccessKey == "" || t.SecretKey == "" {
+		return fmt.Errorf("The S3 backup target credentials aren't configured (backups.s3.access_key and backups.s3.secret_key)")
+	}
+
+	return nil
+}
+
+// ObjectKey returns the key of the object a backup with the given name is stored under.
+func (t S3Target) ObjectKey(name ...string) string {
+	return path.Join(append([]string{t.Prefix}, name...)...)
+}
+
+// Upload returns a writer uploading everything written to it to the object with the given key.
+// The upload is only completed when the writer is closed, and must be aborted on error.
+func (t S3Target) Upload(key string) *S3Uploader {
+	return &S3Uploader{target: t, key: key, partSize: s3PartSize, maxParts: s3MaxParts}
+}
+
+// Download returns a reader streaming the object with the given key, along with its size.
+func (t S3Target) Download(key string) (io.ReadCloser, int64, error) {
+	resp, err := t.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return resp.Body, resp.ContentLength, nil
+}
+
+// Delete removes the object with the given key.
+func (t S3Target) Delete(key string) error {
+	resp, err := t.do(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	_ = resp.Body.Close()
+
+	return nil
+}
+
+// do sends a signed request for the object with the given key, returning an error for unsuccessful responses.
+// The response body must be closed by the caller.
+func (t S3Target) do(method string, key string, query url.Values, body []byte) (*http.Response, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(t.Endpoint, "/"), s3Escape(t.Bucket, true), s3Escape(key, false)))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid S3 endpoint %q: %w", t.Endpoint, err)
+	}
+
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	t.sign(req, body, time.Now())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed sending S3 request: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer func() { _ = resp.Body.Close() }()
+
+		content, _ := ioutil.ReadAll(resp.Body)
+		err = s3ParseError(key, content)
+		if err == nil {
+			return nil, fmt.Errorf("S3 request for %q failed: %s", key, resp.Status)
+		}
+
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// s3ParseError returns the error held by an S3 error response body, or nil if the body isn't an error.
+func s3ParseError(key string, content []byte) error {
+	s3Err := struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}{}
+
+	err := xml.Unmarshal(content, &s3Err)
+	if err != nil || s3Err.XMLName.Local != "Error" || s3Err.Code == "" {
+		return nil
+	}
+
+	return fmt.Errorf("S3 request for %q failed: %s: %s", key, s3Err.Code, s3Err.Message)
+}
+
+// sign adds the AWS signature version 4 authorization headers to the request.
+func (t S3Target) sign(req *http.Request, body []byte, now time.Time) {
+	region := t.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", amzDate[:8], region)
+
+	payloadHash := s3EmptyPayloadHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, payloadHash, amzDate),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(canonicalHash[:])}, "\n")
+
+	hmacSHA256 := func(key []byte, data string) []byte {
+		h := hmac.New(sha256.New, key)
+		_, _ = h.Write([]byte(data))
+		return h.Sum(nil)
+	}
+
+	signingKey := []byte("AWS4" + t.SecretKey)
+	for _, part := range []string{amzDate[:8], region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", t.AccessKey, scope, signedHeaders, signature))
+}
+
+// s3Escape URI encodes the value as expected by the AWS signature, leaving the slashes alone unless requested.
+func s3Escape(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+// s3CanonicalQuery returns the query string sorted and encoded as expected by the AWS signature.
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, fmt.Sprintf("%s=%s", s3Escape(k, true), s3Escape(v, true)))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// s3CompletedPart represents an uploaded part of a multipart upload.
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// S3Uploader streams the data written to it to an S3 object using a multipart upload, so that only the part
+// being uploaded is held in memory.
+type S3Uploader struct {
+	target   S3Target
+	key      string
+	partSize int
+	maxParts int
+	uploadID string
+	parts    []s3CompletedPart
+	buf      bytes.Buffer
+}
+
+// Write buffers the data and uploads it once a full part is available.
+// The last allowed part is only uploaded on Close, so that data going over the maximum object size is rejected
+// before any part beyond the S3 limit gets sent.
+func (u *S3Uploader) Write(p []byte) (int, error) {
+	if u.buf.Len()+len(p) > (u.maxParts-len(u.parts))*u.partSize {
+		return 0, fmt.Errorf("Backup is larger than the maximum S3 object size of %d bytes", u.maxParts*u.partSize)
+	}
+
+	n, _ := u.buf.Write(p)
+
+	// The data of a failed part stays buffered, so it's still consumed and sent by the next Write or Close.
+	for u.buf.Len() >= u.partSize && len(u.parts) < u.maxParts-1 {
+		err := u.uploadPart(u.buf.Bytes()[:u.partSize])
+		if err != nil {
+			return n, err
+		}
+
+		u.buf.Next(u.partSize)
+	}
+
+	return n, nil
+}
+
+// Close uploads the remaining data and completes the upload.
+func (u *S3Uploader) Close() error {
+	// Small objects are uploaded in a single request.
+	if u.uploadID == "" {
+		resp, err := u.target.do(http.MethodPut, u.key, nil, u.buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		_ = resp.Body.Close()
+		u.buf.Reset()
+
+		return nil
+	}
+
+	if u.buf.Len() > 0 {
+		err := u.uploadPart(u.buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		u.buf.Reset()
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: u.parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := u.target.do(http.MethodPost, u.key, url.Values{"uploadId": []string{u.uploadID}}, body)
+	if err != nil {
+		return fmt.Errorf("Failed completing S3 upload: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	// Completing the upload can fail after the 200 status has already been sent, the error is then in the body.
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Failed reading S3 upload completion response: %w", err)
+	}
+
+	err = s3ParseError(u.key, content)
+	if err != nil {
+		return fmt.Errorf("Failed completing S3 upload: %w", err)
+	}
+
+	return nil
+}
+
+// Abort cancels the upload, discarding the already uploaded parts.
+func (u *S3Uploader) Abort() error {
+	u.buf.Reset()
+
+	if u.uploadID == "" {
+		return nil
+	}
+
+	resp, err := u.target.do(http.MethodDelete, u.key, url.Values{"uploadId": []string{u.uploadID}}, nil)
+	if err != nil {
+		return fmt.Errorf("Failed aborting S3 upload: %w", err)
+	}
+
+	_ = resp.Body.Close()
+
+	return nil
+}
+
+// uploadPart uploads the next part of the object, starting the multipart upload if needed.
+func (u *S3Uploader) uploadPart(data []byte) error {
+	if u.uploadID == "" {
+		resp, err := u.target.do(http.MethodPost, u.key, url.Values{"uploads": []string{""}}, nil)
+		if err != nil {
+			return fmt.Errorf("Failed starting S3 upload: %w", err)
+		}
+
+		defer func() { _ = resp.Body.Close() }()
+
+		result := struct {
+			UploadID string `xml:"UploadId"`
+		}{}
+
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		if err != nil {
+			return fmt.Errorf("Failed parsing S3 upload response: %w", err)
+		}
+
+		u.uploadID = result.UploadID
+	}
+
+	partNumber := len(u.parts) + 1
+	query := url.Values{"partNumber": []string{fmt.Sprintf("%d", partNumber)}, "uploadId": []string{u.uploadID}}
+
+	resp, err := u.target.do(http.MethodPut, u.key, query, data)
+	if err != nil {
+		return fmt.Errorf("Failed uploading part %d to S3: %w", partNumber, err)
+	}
+
+	_ = resp.Body.Close()
+
+	u.parts = append(u.parts, s3CompletedPart{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+
+	return nil
+}
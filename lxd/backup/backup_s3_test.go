@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// s3TestServer is a minimal S3 server handling multipart uploads.
+type s3TestServer struct {
+	mu sync.Mutex
+
+	// Body returned with a 200 status when completing the upload.
+	completeBody string
+
+	// Whether part uploads fail.
+	failParts bool
+
+	parts     []int
+	completed bool
+	aborted   bool
+}
+
+func (s *s3TestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		_, _ = fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>upload</UploadId></InitiateMultipartUploadResult>")
+	case r.Method == http.MethodPut && query.Has("partNumber") && s.failParts:
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprint(w, "<Error><Code>InternalError</Code><Message>We encountered an internal error.</Message></Error>")
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		content, _ := ioutil.ReadAll(r.Body)
+		s.parts = append(s.parts, len(content))
+		w.Header().Set("ETag", fmt.Sprintf("%q", query.Get("partNumber")))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		s.completed = true
+		_, _ = fmt.Fprint(w, s.completeBody)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		s.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprint(w, "<Error><Code>InvalidRequest</Code><Message>Unexpected request</Message></Error>")
+	}
+}
+
+func s3TestUploader(t *testing.T, server *s3TestServer, partSize int, maxParts int) *S3Uploader {
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	target := S3Target{Endpoint: ts.URL, Bucket: "backups", AccessKey: "access", SecretKey: "secret"}
+	u := target.Upload("c1.tar.gz")
+	u.partSize = partSize
+	u.maxParts = maxParts
+
+	return u
+}
+
+func TestS3Uploader(t *testing.T) {
+	server := &s3TestServer{completeBody: "<CompleteMultipartUploadResult><Key>c1.tar.gz</Key></CompleteMultipartUploadResult>"}
+	u := s3TestUploader(t, server, 4, 10)
+
+	_, err := u.Write([]byte("0123456789"))
+	require.NoError(t, err)
+	require.NoError(t, u.Close())
+
+	assert.Equal(t, []int{4, 4, 2}, server.parts)
+	assert.True(t, server.completed)
+}
+
+func TestS3Uploader_CompleteError(t *testing.T) {
+	server := &s3TestServer{completeBody: "<Error><Code>InternalError</Code><Message>We encountered an internal error.</Message></Error>"}
+	u := s3TestUploader(t, server, 4, 10)
+
+	_, err := u.Write([]byte("0123456789"))
+	require.NoError(t, err)
+
+	err = u.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "InternalError")
+}
+
+func TestS3Uploader_PartError(t *testing.T) {
+	server := &s3TestServer{completeBody: "<CompleteMultipartUploadResult><Key>c1.tar.gz</Key></CompleteMultipartUploadResult>", failParts: true}
+	u := s3TestUploader(t, server, 4, 10)
+
+	// The data is consumed even though uploading it failed.
+	n, err := u.Write([]byte("0123456789"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "InternalError")
+	assert.Equal(t, 10, n)
+	assert.Empty(t, server.parts)
+
+	// And is uploaded once the server recovers.
+	server.mu.Lock()
+	server.failParts = false
+	server.mu.Unlock()
+
+	n, err = u.Write(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	require.NoError(t, u.Close())
+
+	assert.Equal(t, []int{4, 4, 2}, server.parts)
+	assert.True(t, server.completed)
+}
+
+func TestS3Uploader_MaxParts(t *testing.T) {
+	server := &s3TestServer{}
+	u := s3TestUploader(t, server, 4, 3)
+
+	// The last allowed part is held back until the upload is completed.
+	_, err := u.Write([]byte("012345678901"))
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 4}, server.parts)
+
+	// Going over the maximum size fails without uploading anything more.
+	_, err = u.Write([]byte("2"))
+	require.Error(t, err)
+	assert.Equal(t, []int{4, 4}, server.parts)
+
+	require.NoError(t, u.Abort())
+	assert.True(t, server.aborted)
+}
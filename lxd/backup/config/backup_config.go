@@ -1,14 +1,95 @@
 package config
 
 import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
 	"github.com/lxc/lxd/shared/api"
 )
 
+// Version is the current version of the backup config format, recorded in the configs written.
+// Configs without a version predate the versioning of the format.
+const Version = 1
+
 // Config represents the config of a backup that can be stored in a backup.yaml file (or embedded in index.yaml).
 type Config struct {
+	Version         uint32                       `yaml:"version,omitempty"`
 	Container       *api.Instance                `yaml:"container,omitempty"` // Used by VM backups too.
 	Snapshots       []*api.InstanceSnapshot      `yaml:"snapshots,omitempty"`
 	Pool            *api.StoragePool             `yaml:"pool,omitempty"`
 	Volume          *api.StorageVolume           `yaml:"volume,omitempty"`
 	VolumeSnapshots []*api.StorageVolumeSnapshot `yaml:"volume_snapshots,omitempty"`
 }
+
+// migrations upgrade a raw backup config from the version they're indexed by to the next one.
+// They operate on the raw YAML so that renamed or restructured fields can be handled.
+var migrations = map[uint32]func(raw map[interface{}]interface{}) error{
+	0: migrateFromV0,
+}
+
+// migrateFromV0 upgrades configs predating the versioning of the format, which didn't always record the type
+// of the instance.
+func migrateFromV0(raw map[interface{}]interface{}) error {
+	container, ok := raw["container"].(map[interface{}]interface{})
+	if ok && (container["type"] == nil || container["type"] == "") {
+		container["type"] = string(api.InstanceTypeContainer)
+	}
+
+	return nil
+}
+
+// UnmarshalYAML decodes a backup config of any version, upgrading older versions of the format to the current one.
+// Configs of newer versions are only accepted if they don't use any field unknown to this version, so that none
+// is silently dropped.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	raw := map[interface{}]interface{}{}
+	err := unmarshal(&raw)
+	if err != nil {
+		return err
+	}
+
+	version := uint32(0)
+	if raw["version"] != nil {
+		v, ok := raw["version"].(int)
+		if !ok || v < 0 {
+			return fmt.Errorf("Invalid backup config version %v", raw["version"])
+		}
+
+		version = uint32(v)
+	}
+
+	for v := version; v < Version; v++ {
+		err = migrations[v](raw)
+		if err != nil {
+			return fmt.Errorf("Failed upgrading backup config from version %d: %w", v, err)
+		}
+	}
+
+	raw["version"] = Version
+
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	// Decode into a type without this method to avoid recursing.
+	type config Config
+	decoded := config{}
+
+	if version > Version {
+		err = yaml.UnmarshalStrict(data, &decoded)
+		if err != nil {
+			return fmt.Errorf("Backup config version %d is newer than the supported version %d and uses unsupported fields: %w", version, Version, err)
+		}
+	} else {
+		err = yaml.Unmarshal(data, &decoded)
+		if err != nil {
+			return err
+		}
+	}
+
+	*c = Config(decoded)
+
+	return nil
+}
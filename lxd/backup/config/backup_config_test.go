@@ -0,0 +1,115 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestConfig_UnmarshalYAMLFromV0(t *testing.T) {
+	data := `
+container:
+  name: c1
+snapshots:
+- name: snap0
+`
+
+	c := Config{}
+	err := yaml.Unmarshal([]byte(data), &c)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(Version), c.Version)
+	require.NotNil(t, c.Container)
+	assert.Equal(t, "c1", c.Container.Name)
+	assert.Equal(t, string(api.InstanceTypeContainer), c.Container.Type)
+	require.Len(t, c.Snapshots, 1)
+	assert.Equal(t, "snap0", c.Snapshots[0].Name)
+
+	// The type of VM backups is kept.
+	data = `
+container:
+  name: v1
+  type: virtual-machine
+`
+
+	c = Config{}
+	err = yaml.Unmarshal([]byte(data), &c)
+	require.NoError(t, err)
+
+	require.NotNil(t, c.Container)
+	assert.Equal(t, string(api.InstanceTypeVM), c.Container.Type)
+}
+
+func TestConfig_UnmarshalYAMLRoundTrip(t *testing.T) {
+	orig := Config{
+		Version: Version,
+		Container: &api.Instance{
+			Name: "v1",
+			Type: string(api.InstanceTypeVM),
+		},
+		Snapshots: []*api.InstanceSnapshot{{Name: "snap0"}},
+		Pool:      &api.StoragePool{Name: "default", Driver: "dir"},
+	}
+
+	data, err := yaml.Marshal(&orig)
+	require.NoError(t, err)
+
+	c := Config{}
+	err = yaml.Unmarshal(data, &c)
+	require.NoError(t, err)
+
+	assert.Equal(t, orig.Version, c.Version)
+	assert.Equal(t, orig.Container.Name, c.Container.Name)
+	assert.Equal(t, orig.Container.Type, c.Container.Type)
+	assert.Equal(t, orig.Snapshots[0].Name, c.Snapshots[0].Name)
+	assert.Equal(t, orig.Pool.Driver, c.Pool.Driver)
+
+	// Decoding doesn't alter the config.
+	newData, err := yaml.Marshal(&c)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(newData))
+}
+
+func TestConfig_UnmarshalYAMLNewerVersion(t *testing.T) {
+	// Newer configs using fields unknown to this version are rejected.
+	data := `
+version: 1000
+container:
+  name: c1
+  type: container
+unknown_field: foo
+`
+
+	c := Config{}
+	err := yaml.Unmarshal([]byte(data), &c)
+	assert.ErrorContains(t, err, "is newer than the supported version")
+
+	// Newer configs only using known fields are accepted.
+	data = `
+version: 1000
+container:
+  name: c1
+  type: container
+`
+
+	c = Config{}
+	err = yaml.Unmarshal([]byte(data), &c)
+	require.NoError(t, err)
+
+	require.NotNil(t, c.Container)
+	assert.Equal(t, "c1", c.Container.Name)
+}
+
+func TestConfig_UnmarshalYAMLInvalidVersion(t *testing.T) {
+	for _, version := range []string{"-1", "foo", "1.5", "[1]"} {
+		data := "version: " + version + "\ncontainer:\n  name: c1\n"
+
+		c := Config{}
+		err := yaml.Unmarshal([]byte(data), &c)
+		assert.ErrorContains(t, err, "Invalid backup config version", "version %s", version)
+	}
+}
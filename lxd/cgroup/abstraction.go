@@ -686,6 +686,21 @@ func (cg *CGroup) SetCPUCfsLimit(limitPeriod int64, limitQuota int64) error {
 	return ErrUnknownVersion
 }
 
+// SetDRMWeight sets the weight of the group when sharing GPUs with other groups
+func (cg *CGroup) SetDRMWeight(limit int64) error {
+	version := cgControllers["drm"]
+	switch version {
+	case Unavailable:
+		return ErrControllerMissing
+	case V1:
+		return ErrControllerMissing
+	case V2:
+		return cg.rw.Set(version, "drm", "drm.weight", fmt.Sprintf("%d", limit))
+	}
+
+	return ErrUnknownVersion
+}
+
 // SetNetIfPrio sets the priority for the process
 func (cg *CGroup) SetNetIfPrio(limit string) error {
 	version := cgControllers["net_prio"]
@@ -764,6 +779,21 @@ func (cg *CGroup) SetCpuset(limit string) error {
 	return ErrUnknownVersion
 }
 
+// SetCpusetMems set the currently allowed set of memory nodes for the cgroups
+func (cg *CGroup) SetCpusetMems(limit string) error {
+	version := cgControllers["cpuset"]
+	switch version {
+	case Unavailable:
+		return ErrControllerMissing
+	case V1:
+		return cg.rw.Set(version, "cpuset", "cpuset.mems", limit)
+	case V2:
+		return cg.rw.Set(version, "cpuset", "cpuset.mems", limit)
+	}
+
+	return ErrUnknownVersion
+}
+
 // GetMemoryStats returns memory stats
 func (cg *CGroup) GetMemoryStats() (map[string]uint64, error) {
 	var (
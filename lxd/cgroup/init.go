@@ -87,6 +87,9 @@ const (
 	// Devices resource control
 	Devices
 
+	// DRM resource control
+	DRM
+
 	// Freezer resource control
 	Freezer
 
@@ -167,6 +170,9 @@ func (info *Info) SupportsVersion(resource Resource) (Backend, bool) {
 	case Devices:
 		val, ok := cgControllers["devices"]
 		return val, ok
+	case DRM:
+		val, ok := cgControllers["drm"]
+		return val, ok
 	case Freezer:
 		val, ok := cgControllers["freezer"]
 		return val, ok
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -13,6 +14,9 @@ import (
 
 	"github.com/lxc/lxd/lxd/config"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/oidc"
+	"github.com/lxc/lxd/lxd/rbac"
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/validate"
 )
 
@@ -39,6 +43,11 @@ func Load(tx *db.ClusterTx) (*Config, error) {
 	return &Config{tx: tx, m: m}, nil
 }
 
+// AccessLog returns whether the API requests should be logged.
+func (c *Config) AccessLog() bool {
+	return c.m.GetBool("core.access_log")
+}
+
 // MetricsAuthentication checks whether metrics API requires authentication.
 func (c *Config) MetricsAuthentication() bool {
 	return c.m.GetBool("core.metrics_authentication")
@@ -80,6 +89,17 @@ func (c *Config) TrustCACertificates() bool {
 	return c.m.GetBool("core.trust_ca_certificates")
 }
 
+// BackupsS3 returns all the S3 settings needed to store backups in a bucket (endpoint, bucket, prefix, region,
+// access key and secret key).
+func (c *Config) BackupsS3() (string, string, string, string, string, string) {
+	return c.m.GetString("backups.s3.endpoint"),
+		c.m.GetString("backups.s3.bucket"),
+		c.m.GetString("backups.s3.prefix"),
+		c.m.GetString("backups.s3.region"),
+		c.m.GetString("backups.s3.access_key"),
+		c.m.GetString("backups.s3.secret_key")
+}
+
 // CandidServer returns all the Candid settings needed to connect to a server.
 func (c *Config) CandidServer() (string, string, int64, string) {
 	return c.m.GetString("candid.api.url"),
@@ -88,6 +108,15 @@ func (c *Config) CandidServer() (string, string, int64, string) {
 		c.m.GetString("candid.domains")
 }
 
+// OIDCServer returns all the OpenID Connect settings needed to validate the tokens of a provider.
+func (c *Config) OIDCServer() (string, string, string, string, string) {
+	return c.m.GetString("oidc.issuer"),
+		c.m.GetString("oidc.client.id"),
+		c.m.GetString("oidc.audience"),
+		c.m.GetString("oidc.claim"),
+		c.m.GetString("oidc.groups.claim")
+}
+
 // RBACServer returns all the Candid settings needed to connect to a server.
 func (c *Config) RBACServer() (string, string, int64, string, string, string, string) {
 	return c.m.GetString("rbac.api.url"),
@@ -99,6 +128,16 @@ func (c *Config) RBACServer() (string, string, int64, string, string, string, st
 		c.m.GetString("rbac.agent.public_key")
 }
 
+// RBACLocal returns whether to use the roles stored in the database for RBAC.
+func (c *Config) RBACLocal() bool {
+	return c.m.GetBool("rbac.local")
+}
+
+// RBACProjectPatterns returns the project name patterns synced to the external RBAC server.
+func (c *Config) RBACProjectPatterns() []string {
+	return shared.SplitNTrimSpace(c.m.GetString("rbac.project_patterns"), ",", -1, true)
+}
+
 // ProxyHTTPS returns the configured HTTPS proxy, if any.
 func (c *Config) ProxyHTTPS() string {
 	return c.m.GetString("core.proxy_https")
@@ -151,6 +190,12 @@ func (c *Config) MaxStandBy() int64 {
 	return c.m.GetInt64("cluster.max_standby")
 }
 
+// NotifyConcurrency returns the maximum number of cluster members notified concurrently of state changes.
+// Zero means no limit.
+func (c *Config) NotifyConcurrency() int64 {
+	return c.m.GetInt64("cluster.notify_concurrency")
+}
+
 // ShutdownTimeout returns the number of minutes to wait for running operation to complete
 // before LXD server shut down
 func (c *Config) ShutdownTimeout() time.Duration {
@@ -253,10 +298,18 @@ func configGet(cluster *db.Cluster) (*Config, error) {
 // ConfigSchema defines available server configuration keys.
 var ConfigSchema = config.Schema{
 	"backups.compression_algorithm":  {Default: "gzip", Validator: validate.IsCompressionAlgorithm},
+	"backups.s3.access_key":          {},
+	"backups.s3.bucket":              {},
+	"backups.s3.endpoint":            {Validator: validate.Optional(httpURLValidator)},
+	"backups.s3.prefix":              {},
+	"backups.s3.region":              {},
+	"backups.s3.secret_key":          {Hidden: true},
 	"cluster.offline_threshold":      {Type: config.Int64, Default: offlineThresholdDefault(), Validator: offlineThresholdValidator},
 	"cluster.images_minimal_replica": {Type: config.Int64, Default: "3", Validator: imageMinimalReplicaValidator},
 	"cluster.max_voters":             {Type: config.Int64, Default: "3", Validator: maxVotersValidator},
 	"cluster.max_standby":            {Type: config.Int64, Default: "2", Validator: maxStandByValidator},
+	"cluster.notify_concurrency":     {Type: config.Int64, Default: "10", Validator: validate.IsUint32},
+	"core.access_log":                {Type: config.Bool},
 	"core.metrics_authentication":    {Type: config.Bool, Default: "true"},
 	"core.bgp_asn":                   {Type: config.Int64, Default: "0", Validator: validate.Optional(validate.IsInRange(0, 4294967294))},
 	"core.https_allowed_headers":     {},
@@ -279,9 +332,15 @@ var ConfigSchema = config.Schema{
 	"images.compression_algorithm":   {Default: "gzip", Validator: validate.IsCompressionAlgorithm},
 	"images.default_architecture":    {Validator: validate.Optional(validate.IsArchitecture)},
 	"images.remote_cache_expiry":     {Type: config.Int64, Default: "10"},
+	"instances.admission_url":        {Validator: validate.Optional(httpURLValidator)},
 	"instances.nic.host_name":        {Validator: validate.Optional(validate.IsOneOf("random", "mac"))},
 	"maas.api.key":                   {},
 	"maas.api.url":                   {},
+	"oidc.audience":                  {},
+	"oidc.claim":                     {},
+	"oidc.client.id":                 {},
+	"oidc.groups.claim":              {},
+	"oidc.issuer":                    {Validator: validate.Optional(oidc.ValidateIssuer)},
 	"rbac.agent.url":                 {},
 	"rbac.agent.username":            {},
 	"rbac.agent.private_key":         {},
@@ -290,6 +349,8 @@ var ConfigSchema = config.Schema{
 	"rbac.api.key":                   {},
 	"rbac.api.url":                   {},
 	"rbac.expiry":                    {Type: config.Int64, Default: "3600"},
+	"rbac.local":                     {Type: config.Bool, Default: "false"},
+	"rbac.project_patterns":          {Validator: validate.Optional(validate.IsListOf(rbac.ValidateProjectPattern))},
 
 	// OVN networking global keys.
 	"network.ovn.integration_bridge":    {Default: "br-int"},
@@ -356,6 +417,19 @@ func maxStandByValidator(value string) error {
 	return nil
 }
 
+func httpURLValidator(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+
+	if !shared.StringInSlice(u.Scheme, []string{"http", "https"}) || u.Host == "" {
+		return fmt.Errorf("Must be an HTTP or HTTPS URL")
+	}
+
+	return nil
+}
+
 func passwordSetter(value string) (string, error) {
 	// Nothing to do on unset
 	if value == "" {
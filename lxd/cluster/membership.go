@@ -461,8 +461,8 @@ func Join(state *state.State, gateway *Gateway, networkCert *shared.CertInfo, se
 				return fmt.Errorf("Failed to get storage pool driver: %w", err)
 			}
 
-			if shared.StringInSlice(driver, []string{"ceph", "cephfs"}) {
-				// For ceph pools we have to create volume
+			if shared.StringInSlice(driver, db.StorageRemoteDriverNames()) {
+				// For remote pools we have to create volume
 				// entries for the joining node.
 				err := tx.UpdateCephStoragePoolAfterNodeJoin(id, node.ID)
 				if err != nil {
@@ -3,10 +3,12 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/lxc/lxd/client"
+	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/state"
@@ -32,9 +34,9 @@ const (
 // NewNotifier builds a Notifier that can be used to notify other peers using
 // the given policy.
 func NewNotifier(state *state.State, networkCert *shared.CertInfo, serverCert *shared.CertInfo, policy NotifierPolicy) (Notifier, error) {
-	address, err := node.ClusterAddress(state.DB.Node)
+	address, nodes, offlineThreshold, concurrency, err := notifyMembers(state)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch node address: %w", err)
+		return nil, err
 	}
 
 	// Fast-track the case where we're not clustered at all.
@@ -43,80 +45,319 @@ func NewNotifier(state *state.State, networkCert *shared.CertInfo, serverCert *s
 		return nullNotifier, nil
 	}
 
+	peers := NotifyResults{}
+	for _, node := range nodes {
+		if node.Address == address || node.Address == "0.0.0.0" {
+			continue // Exclude ourselves
+		}
+
+		if node.IsOffline(offlineThreshold) {
+			// Even if the heartbeat timestamp is not recent
+			// enough, let's try to connect to the node, just in
+			// case the heartbeat is lagging behind for some reason
+			// and the node is actually up.
+			if !HasConnectivity(networkCert, serverCert, node.Address) {
+				switch policy {
+				case NotifyAll:
+					return nil, fmt.Errorf("peer node %s is down", node.Address)
+				case NotifyAlive:
+					continue // Just skip this node
+				case NotifyTryAll:
+				}
+			}
+		}
+		peers = append(peers, NotifyResult{Name: node.Name, Address: node.Address})
+	}
+
+	notifier := func(hook func(lxd.InstanceServer) error) error {
+		results := make(NotifyResults, len(peers))
+		copy(results, peers)
+
+		notifyConcurrently(len(results), concurrency, func(i int) {
+			result := &results[i]
+			logger.Debugf("Notify node %s of state changes", result.Address)
+
+			start := time.Now()
+			defer func() { result.Duration = time.Since(start) }()
+
+			client, err := Connect(result.Address, networkCert, serverCert, nil, true)
+			if err != nil {
+				result.Status = NotifyFailed
+				result.Err = fmt.Errorf("failed to connect: %w", err)
+				return
+			}
+
+			err = hook(client)
+			if err != nil {
+				result.Status = NotifyFailed
+				result.Err = err
+				return
+			}
+
+			result.Status = NotifySucceeded
+		})
+
+		failed := false
+		for i := range results {
+			if results[i].Status != NotifyFailed {
+				continue
+			}
+
+			if shared.IsConnectionError(results[i].Err) && policy == NotifyAlive {
+				logger.Warnf("Could not notify node %s", results[i].Address)
+				results[i].Status = NotifySkipped
+				continue
+			}
+
+			failed = true
+		}
+
+		if failed {
+			return &NotifyError{Results: results}
+		}
+
+		return nil
+	}
+
+	return notifier, nil
+}
+
+// notifyConcurrently calls f for each index from 0 to n-1, running at most limit calls concurrently (no limit if
+// limit is zero), and waits for all of them to return.
+func notifyConcurrently(n int, limit int, f func(i int)) {
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+
+	sem := make(chan struct{}, limit)
+	wg := sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			f(i)
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// notifyMembers returns the local cluster address along with the cluster members, the offline threshold and the
+// maximum number of members to notify concurrently. The address is empty if the server isn't clustered.
+func notifyMembers(state *state.State) (string, []db.NodeInfo, time.Duration, int, error) {
+	address, err := node.ClusterAddress(state.DB.Node)
+	if err != nil {
+		return "", nil, -1, -1, fmt.Errorf("failed to fetch node address: %w", err)
+	}
+
+	if address == "" {
+		return "", nil, -1, -1, nil
+	}
+
 	var nodes []db.NodeInfo
 	var offlineThreshold time.Duration
+	var concurrency int
 	err = state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		offlineThreshold, err = tx.GetNodeOfflineThreshold()
 		if err != nil {
 			return err
 		}
 
+		config, err := clusterConfig.Load(tx)
+		if err != nil {
+			return err
+		}
+
+		concurrency = int(config.NotifyConcurrency())
+
 		nodes, err = tx.GetNodes()
 		if err != nil {
 			return err
 		}
 		return nil
 	})
+	if err != nil {
+		return "", nil, -1, -1, err
+	}
+
+	return address, nodes, offlineThreshold, concurrency, nil
+}
+
+// NotifyStatus is the outcome of notifying a cluster member.
+type NotifyStatus string
+
+// Possible notification outcomes.
+const (
+	NotifySucceeded NotifyStatus = "succeeded" // The hook succeeded against the member.
+	NotifyFailed    NotifyStatus = "failed"    // The member couldn't be reached, the hook failed or timed out.
+	NotifySkipped   NotifyStatus = "skipped"   // The member is offline so wasn't notified.
+)
+
+// NotifyResult is the outcome of notifying a single cluster member.
+type NotifyResult struct {
+	Name     string
+	Address  string
+	Status   NotifyStatus
+	Err      error
+	Duration time.Duration // How long notifying the member took.
+}
+
+// NotifyResults is the outcome of notifying the cluster members.
+type NotifyResults []NotifyResult
+
+// WithStatus returns the results with the given status.
+func (r NotifyResults) WithStatus(status NotifyStatus) NotifyResults {
+	results := NotifyResults{}
+	for _, result := range r {
+		if result.Status == status {
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// Names returns the names of the members in the results.
+func (r NotifyResults) Names() []string {
+	names := make([]string, 0, len(r))
+	for _, result := range r {
+		names = append(names, result.Name)
+	}
+
+	return names
+}
+
+// Err returns a NotifyError listing the members which failed to be notified, or nil if none failed.
+func (r NotifyResults) Err() error {
+	if len(r.WithStatus(NotifyFailed)) == 0 {
+		return nil
+	}
+
+	return &NotifyError{Results: r}
+}
+
+// NotifyError is returned by a Notifier when some of the members couldn't be notified.
+// It holds the outcome of notifying each of the members.
+type NotifyError struct {
+	Results NotifyResults
+}
+
+// Error lists the members which failed to be notified along with how long it took.
+func (e *NotifyError) Error() string {
+	failed := e.Results.WithStatus(NotifyFailed)
+
+	msgs := make([]string, 0, len(failed))
+	for _, result := range failed {
+		msgs = append(msgs, fmt.Sprintf("%q at %s (%s): %v", result.Name, result.Address, result.Duration.Round(time.Millisecond), result.Err))
+	}
+
+	if len(msgs) == 1 {
+		return fmt.Sprintf("failed to notify peer %s", msgs[0])
+	}
+
+	return fmt.Sprintf("failed to notify %d of %d peers: %s", len(failed), len(e.Results), strings.Join(msgs, ", "))
+}
+
+// Unwrap returns the error of the first member which failed to be notified.
+func (e *NotifyError) Unwrap() error {
+	failed := e.Results.WithStatus(NotifyFailed)
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return failed[0].Err
+}
+
+// ResultNotifier is a function that invokes the given function against each member of the cluster excluding the
+// invoking one and returns the outcome for each of them.
+type ResultNotifier func(hook func(lxd.InstanceServer) error) NotifyResults
+
+// NewResultNotifier builds a ResultNotifier that notifies the other members concurrently, up to the configured
+// cluster.notify_concurrency.
+// Unlike NewNotifier with NotifyAll, offline members which can't be reached are skipped rather than causing the
+// whole notification to fail, and a failure notifying one member doesn't prevent notifying the others.
+// If timeout is greater than zero, members for which the hook hasn't returned after it elapses are considered
+// failed (the hook keeps running in the background).
+func NewResultNotifier(state *state.State, networkCert *shared.CertInfo, serverCert *shared.CertInfo, timeout time.Duration) (ResultNotifier, error) {
+	address, nodes, offlineThreshold, concurrency, err := notifyMembers(state)
 	if err != nil {
 		return nil, err
 	}
 
-	peers := []string{}
+	// Fast-track the case where we're not clustered at all.
+	if address == "" {
+		nullNotifier := func(func(lxd.InstanceServer) error) NotifyResults { return NotifyResults{} }
+		return nullNotifier, nil
+	}
+
+	members := NotifyResults{}
 	for _, node := range nodes {
 		if node.Address == address || node.Address == "0.0.0.0" {
 			continue // Exclude ourselves
 		}
 
-		if node.IsOffline(offlineThreshold) {
-			// Even if the heartbeat timestamp is not recent
-			// enough, let's try to connect to the node, just in
-			// case the heartbeat is lagging behind for some reason
-			// and the node is actually up.
-			if !HasConnectivity(networkCert, serverCert, node.Address) {
-				switch policy {
-				case NotifyAll:
-					return nil, fmt.Errorf("peer node %s is down", node.Address)
-				case NotifyAlive:
-					continue // Just skip this node
-				case NotifyTryAll:
-				}
-			}
+		member := NotifyResult{Name: node.Name, Address: node.Address}
+
+		// Check connectivity of members which look offline in case the heartbeat is lagging behind.
+		if node.IsOffline(offlineThreshold) && !HasConnectivity(networkCert, serverCert, node.Address) {
+			member.Status = NotifySkipped
+			member.Err = fmt.Errorf("Cluster member is offline")
 		}
-		peers = append(peers, node.Address)
+
+		members = append(members, member)
 	}
 
-	notifier := func(hook func(lxd.InstanceServer) error) error {
-		errs := make([]error, len(peers))
-		wg := sync.WaitGroup{}
-		wg.Add(len(peers))
-		for i, address := range peers {
-			logger.Debugf("Notify node %s of state changes", address)
-			go func(i int, address string) {
-				defer wg.Done()
-				client, err := Connect(address, networkCert, serverCert, nil, true)
+	notifier := func(hook func(lxd.InstanceServer) error) NotifyResults {
+		results := make(NotifyResults, len(members))
+		copy(results, members)
+
+		notifyConcurrently(len(results), concurrency, func(i int) {
+			result := &results[i]
+			if result.Status == NotifySkipped {
+				return
+			}
+
+			logger.Debug("Notify cluster member of state changes", logger.Ctx{"member": result.Name, "address": result.Address})
+
+			start := time.Now()
+			done := make(chan error, 1)
+			go func() {
+				client, err := Connect(result.Address, networkCert, serverCert, nil, true)
 				if err != nil {
-					errs[i] = fmt.Errorf("failed to connect to peer %s: %w", address, err)
+					done <- fmt.Errorf("Failed to connect to peer: %w", err)
 					return
 				}
-				err = hook(client)
-				if err != nil {
-					errs[i] = fmt.Errorf("failed to notify peer %s: %w", address, err)
-				}
-			}(i, address)
-		}
-		wg.Wait()
-		// TODO: aggregate all errors?
-		for i, err := range errs {
-			if err != nil {
-				if shared.IsConnectionError(err) && policy == NotifyAlive {
-					logger.Warnf("Could not notify node %s", peers[i])
-					continue
-				}
 
-				return err
+				done <- hook(client)
+			}()
+
+			var timeoutCh <-chan time.Time
+			if timeout > 0 {
+				timeoutCh = time.After(timeout)
 			}
-		}
-		return nil
+
+			select {
+			case err := <-done:
+				result.Err = err
+			case <-timeoutCh:
+				result.Err = fmt.Errorf("Timed out after %s", timeout)
+			}
+
+			result.Duration = time.Since(start)
+
+			if result.Err != nil {
+				result.Status = NotifyFailed
+			} else {
+				result.Status = NotifySucceeded
+			}
+		})
+
+		return results
 	}
 
 	return notifier, nil
@@ -2,6 +2,7 @@ package cluster_test
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -55,6 +56,29 @@ func TestNewNotifier(t *testing.T) {
 	}
 }
 
+// The error returned by the notifier holds the outcome for each node.
+func TestNewNotifier_Error(t *testing.T) {
+	state, cleanup := state.NewTestState(t)
+	defer cleanup()
+
+	cert := shared.TestingKeyPair()
+
+	f := notifyFixtures{t: t, state: state}
+	defer f.Nodes(cert, 3)()
+
+	notifier, err := cluster.NewNotifier(state, cert, cert, cluster.NotifyAll)
+	require.NoError(t, err)
+
+	err = notifier(func(client lxd.InstanceServer) error { return fmt.Errorf("boom") })
+	require.Error(t, err)
+
+	notifyErr, ok := err.(*cluster.NotifyError)
+	require.True(t, ok)
+	assert.Len(t, notifyErr.Results, 2)
+	assert.Len(t, notifyErr.Results.WithStatus(cluster.NotifyFailed), 2)
+	assert.Regexp(t, `failed to notify 2 of 2 peers: .* \(.*\): boom`, err.Error())
+}
+
 // Creating a new notifier fails if the policy is set to NotifyAll and one of
 // the nodes is down.
 func TestNewNotify_NotifyAllError(t *testing.T) {
@@ -97,6 +121,33 @@ func TestNewNotify_NotifyAlive(t *testing.T) {
 	assert.Equal(t, 1, i)
 }
 
+// The result notifier skips offline members and reports the outcome for each member.
+func TestNewResultNotifier(t *testing.T) {
+	state, cleanup := state.NewTestState(t)
+	defer cleanup()
+
+	cert := shared.TestingKeyPair()
+
+	f := notifyFixtures{t: t, state: state}
+	defer f.Nodes(cert, 3)()
+
+	f.Down(1)
+	notifier, err := cluster.NewResultNotifier(state, cert, cert, time.Minute)
+	require.NoError(t, err)
+
+	results := notifier(func(client lxd.InstanceServer) error { return nil })
+	require.Len(t, results, 2)
+	assert.Equal(t, []string{"1"}, results.WithStatus(cluster.NotifySkipped).Names())
+	assert.Equal(t, []string{"2"}, results.WithStatus(cluster.NotifySucceeded).Names())
+	assert.NoError(t, results.Err())
+
+	results = notifier(func(client lxd.InstanceServer) error { return fmt.Errorf("boom") })
+	assert.Equal(t, []string{"2"}, results.WithStatus(cluster.NotifyFailed).Names())
+	require.Error(t, results.Err())
+	assert.IsType(t, &cluster.NotifyError{}, results.Err())
+	assert.Regexp(t, `failed to notify peer "2" at .* \(.*\): boom`, results.Err().Error())
+}
+
 // Helper for setting fixtures for Notify tests.
 type notifyFixtures struct {
 	t       *testing.T
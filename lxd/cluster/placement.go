@@ -0,0 +1,129 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PlacementPolicySpread spreads instances across cluster members, preferring the least loaded ones.
+const PlacementPolicySpread = "spread"
+
+// PlacementPolicyBinpack packs instances onto the most loaded cluster members that can still fit them.
+const PlacementPolicyBinpack = "binpack"
+
+// PlacementMember holds the resource usage of a cluster member considered by a placement scheduler.
+// Resources which couldn't be determined are left at zero.
+type PlacementMember struct {
+	Name        string
+	Instances   int
+	MemoryTotal uint64
+	MemoryUsed  uint64
+	CPUThreads  uint64
+	CPULoad     float64
+	PoolTotal   uint64
+	PoolUsed    uint64
+}
+
+// PlacementScheduler scores cluster members for the placement of a new instance.
+type PlacementScheduler interface {
+	// Score returns how suitable the member is for a new instance, a higher score being better.
+	Score(member PlacementMember) float64
+}
+
+var placementSchedulers = map[string]PlacementScheduler{
+	PlacementPolicySpread:  &spreadScheduler{},
+	PlacementPolicyBinpack: &binpackScheduler{},
+}
+
+// GetPlacementScheduler returns the placement scheduler implementing the given policy.
+func GetPlacementScheduler(policy string) (PlacementScheduler, error) {
+	scheduler, ok := placementSchedulers[policy]
+	if !ok {
+		return nil, fmt.Errorf("Unknown placement policy %q (supported: %s)", policy, strings.Join(PlacementPolicies(), ", "))
+	}
+
+	return scheduler, nil
+}
+
+// PlacementPolicies returns the names of the available placement policies.
+func PlacementPolicies() []string {
+	policies := make([]string, 0, len(placementSchedulers))
+	for policy := range placementSchedulers {
+		policies = append(policies, policy)
+	}
+
+	sort.Strings(policies)
+
+	return policies
+}
+
+// placementFreeRatio returns the ratio of free resources of the member, between 0 and 1.
+// It averages the free memory, the idle CPU and the free storage pool space, ignoring those which are unknown.
+// Returns -1 if none of them is known.
+func placementFreeRatio(member PlacementMember) float64 {
+	ratios := []float64{}
+
+	if member.MemoryTotal > 0 {
+		ratios = append(ratios, 1-placementRatio(float64(member.MemoryUsed), float64(member.MemoryTotal)))
+	}
+
+	if member.CPUThreads > 0 {
+		ratios = append(ratios, 1-placementRatio(member.CPULoad, float64(member.CPUThreads)))
+	}
+
+	if member.PoolTotal > 0 {
+		ratios = append(ratios, 1-placementRatio(float64(member.PoolUsed), float64(member.PoolTotal)))
+	}
+
+	if len(ratios) == 0 {
+		return -1
+	}
+
+	total := 0.0
+	for _, ratio := range ratios {
+		total += ratio
+	}
+
+	return total / float64(len(ratios))
+}
+
+// placementRatio returns value/total capped between 0 and 1.
+func placementRatio(value float64, total float64) float64 {
+	ratio := value / total
+	if ratio < 0 {
+		return 0
+	}
+
+	if ratio > 1 {
+		return 1
+	}
+
+	return ratio
+}
+
+// spreadScheduler prefers the members with the most free resources.
+type spreadScheduler struct{}
+
+// Score returns the ratio of free resources of the member, falling back to its instance count when unknown.
+func (s *spreadScheduler) Score(member PlacementMember) float64 {
+	ratio := placementFreeRatio(member)
+	if ratio < 0 {
+		return -float64(member.Instances)
+	}
+
+	return ratio
+}
+
+// binpackScheduler prefers the members with the least free resources.
+type binpackScheduler struct{}
+
+// Score returns the ratio of used resources of the member, falling back to its instance count when unknown.
+func (s *binpackScheduler) Score(member PlacementMember) float64 {
+	ratio := placementFreeRatio(member)
+	if ratio < 0 {
+		return float64(member.Instances)
+	}
+
+	return 1 - ratio
+}
@@ -0,0 +1,49 @@
+package cluster_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/cluster"
+)
+
+func TestPlacementScheduler(t *testing.T) {
+	idle := cluster.PlacementMember{
+		Name:        "idle",
+		Instances:   5,
+		MemoryTotal: 1000,
+		MemoryUsed:  100,
+		CPUThreads:  4,
+		CPULoad:     0.5,
+		PoolTotal:   1000,
+		PoolUsed:    100,
+	}
+
+	busy := cluster.PlacementMember{
+		Name:        "busy",
+		Instances:   1,
+		MemoryTotal: 1000,
+		MemoryUsed:  900,
+		CPUThreads:  4,
+		CPULoad:     6,
+		PoolTotal:   1000,
+		PoolUsed:    800,
+	}
+
+	spread, err := cluster.GetPlacementScheduler(cluster.PlacementPolicySpread)
+	require.NoError(t, err)
+	assert.Greater(t, spread.Score(idle), spread.Score(busy))
+
+	binpack, err := cluster.GetPlacementScheduler(cluster.PlacementPolicyBinpack)
+	require.NoError(t, err)
+	assert.Greater(t, binpack.Score(busy), binpack.Score(idle))
+
+	// Without any resource information, the instance count is used.
+	assert.Greater(t, spread.Score(cluster.PlacementMember{Instances: 1}), spread.Score(cluster.PlacementMember{Instances: 5}))
+	assert.Greater(t, binpack.Score(cluster.PlacementMember{Instances: 5}), binpack.Score(cluster.PlacementMember{Instances: 1}))
+
+	_, err = cluster.GetPlacementScheduler("random")
+	assert.EqualError(t, err, `Unknown placement policy "random" (supported: binpack, spread)`)
+}
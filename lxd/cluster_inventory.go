@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/resources"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// inventoryHistoryMax is the maximum number of hardware inventories kept for the local member.
+const inventoryHistoryMax = 100
+
+// inventoryHistoryMu serializes access to the local inventory history file.
+var inventoryHistoryMu sync.Mutex
+
+// inventoryHistoryLoad returns the hardware inventory history of the local member, oldest first.
+func inventoryHistoryLoad() ([]api.ClusterMemberInventory, error) {
+	history := []api.ClusterMemberInventory{}
+
+	content, err := ioutil.ReadFile(shared.VarPath("inventory.json"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return history, nil
+		}
+
+		return nil, err
+	}
+
+	err = json.Unmarshal(content, &history)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// inventoryRecord records the current hardware inventory of the local member when it differs from the last one
+// recorded, raising a warning if any hardware disappeared or shrunk in the meantime.
+func inventoryRecord(s *state.State) error {
+	inventoryHistoryMu.Lock()
+	defer inventoryHistoryMu.Unlock()
+
+	inventory, err := resources.GetInventory()
+	if err != nil {
+		return err
+	}
+
+	history, err := inventoryHistoryLoad()
+	if err != nil {
+		return err
+	}
+
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		if resources.InventoryEqual(&last, inventory) {
+			return nil
+		}
+
+		drift := resources.InventoryDrift(&last, inventory)
+		if len(drift) > 0 {
+			logger.Warn("Hardware change detected", logger.Ctx{"changes": drift})
+
+			err = s.DB.Cluster.UpsertWarningLocalNode("", -1, -1, db.WarningHardwareDrift, strings.Join(drift, ", "))
+			if err != nil {
+				logger.Warn("Failed to create warning", logger.Ctx{"err": err})
+			}
+		}
+	}
+
+	history = append(history, *inventory)
+	if len(history) > inventoryHistoryMax {
+		history = history[len(history)-inventoryHistoryMax:]
+	}
+
+	content, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(shared.VarPath("inventory.json"), content, 0600)
+}
+
+func inventoryRecordTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		err := inventoryRecord(d.State())
+		if err != nil {
+			logger.Error("Failed recording hardware inventory", logger.Ctx{"err": err})
+		}
+	}
+
+	return f, task.Hourly()
+}
@@ -31,6 +31,7 @@ import (
 	"gopkg.in/macaroon-bakery.v2/httpbakery"
 
 	"github.com/lxc/lxd/lxd/bgp"
+	"github.com/lxc/lxd/lxd/cgroup"
 	"github.com/lxc/lxd/lxd/cluster"
 	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
 	"github.com/lxc/lxd/lxd/daemon"
@@ -45,13 +46,16 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	instanceDrivers "github.com/lxc/lxd/lxd/instance/drivers"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/nicstats"
 	"github.com/lxc/lxd/lxd/maas"
 	networkZone "github.com/lxc/lxd/lxd/network/zone"
 	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/oidc"
 	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/seccomp"
+	"github.com/lxc/lxd/lxd/secrets"
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
@@ -62,6 +66,7 @@ import (
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/lxd/warnings"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/idmap"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
@@ -76,7 +81,7 @@ type Daemon struct {
 	maas        *maas.Controller
 	bgp         *bgp.Server
 	dns         *dns.Server
-	rbac        *rbac.Server
+	rbac        rbac.Authorizer
 
 	// Event servers
 	devlxdEvents *events.DevLXDServer
@@ -104,7 +109,9 @@ type Daemon struct {
 
 	proxy func(req *http.Request) (*url.URL, error)
 
-	externalAuth *externalAuth
+	externalAuth     *externalAuth
+	oidcVerifier     *oidc.Verifier
+	oidcVerifierLock sync.Mutex
 
 	// Stores last heartbeat node information to detect node changes.
 	lastNodeList *cluster.APIHeartbeat
@@ -188,7 +195,10 @@ func (m *IdentityClientWrapper) DeclaredIdentity(ctx context.Context, declared m
 
 // newDaemon returns a new Daemon object with the given configuration.
 func newDaemon(config *DaemonConfig, os *sys.OS) *Daemon {
-	lxdEvents := events.NewServer(daemon.Debug, daemon.Verbose, cluster.EventHubPush)
+	lxdEvents := events.NewServer(daemon.Debug, daemon.Verbose, func(event api.Event) {
+		eventsCacheInvalidate(event)
+		cluster.EventHubPush(event)
+	})
 	devlxdEvents := events.NewDevLXDServer(daemon.Debug, daemon.Verbose)
 	shutdownCtx, shutdownCancel := context.WithCancel(context.Background())
 
@@ -282,7 +292,7 @@ func allowProjectPermission(feature string, permission string) func(d *Daemon, r
 
 // Convenience function around Authenticate
 func (d *Daemon) checkTrustedClient(r *http.Request) error {
-	trusted, _, _, err := d.Authenticate(nil, r)
+	trusted, _, _, _, err := d.Authenticate(nil, r)
 	if !trusted || err != nil {
 		if err != nil {
 			return err
@@ -307,9 +317,9 @@ func (d *Daemon) getTrustedCertificates() map[clusterDB.CertificateType]map[stri
 // will validate the TLS certificate or Macaroon.
 //
 // This does not perform authorization, only validates authentication.
-// Returns whether trusted or not, the username (or certificate fingerprint) of the trusted client, and the type of
-// client that has been authenticated (cluster, unix, candid or tls).
-func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, string, string, error) {
+// Returns whether trusted or not, the username (or certificate fingerprint) of the trusted client, the type of
+// client that has been authenticated (cluster, unix, candid, oidc or tls) and the groups of OIDC users.
+func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, string, string, []string, error) {
 	trustedCerts := d.getTrustedCertificates()
 
 	// Allow internal cluster traffic by checking against the trusted certfificates.
@@ -317,7 +327,7 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		for _, i := range r.TLS.PeerCertificates {
 			trusted, fingerprint := util.CheckTrustState(*i, trustedCerts[clusterDB.CertificateTypeServer], d.endpoints.NetworkCert(), false)
 			if trusted {
-				return true, fingerprint, "cluster", nil
+				return true, fingerprint, "cluster", nil, nil
 			}
 		}
 	}
@@ -327,33 +337,45 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		if w != nil {
 			cred, err := ucred.GetCredFromContext(r.Context())
 			if err != nil {
-				return false, "", "", err
+				return false, "", "", nil, err
 			}
 
 			u, err := user.LookupId(fmt.Sprintf("%d", cred.Uid))
 			if err != nil {
-				return true, fmt.Sprintf("uid=%d", cred.Uid), "unix", nil
+				return true, fmt.Sprintf("uid=%d", cred.Uid), "unix", nil, nil
 			}
 
-			return true, u.Username, "unix", nil
+			return true, u.Username, "unix", nil, nil
 		}
 
-		return true, "", "unix", nil
+		return true, "", "unix", nil, nil
 	}
 
 	// Devlxd unix socket credentials on main API.
 	if r.RemoteAddr == "@devlxd" {
-		return false, "", "", fmt.Errorf("Main API query can't come from /dev/lxd socket")
+		return false, "", "", nil, fmt.Errorf("Main API query can't come from /dev/lxd socket")
 	}
 
 	// Cluster notification with wrong certificate.
 	if isClusterNotification(r) {
-		return false, "", "", fmt.Errorf("Cluster notification isn't using trusted server certificate")
+		return false, "", "", nil, fmt.Errorf("Cluster notification isn't using trusted server certificate")
 	}
 
 	// Bad query, no TLS found.
 	if r.TLS == nil {
-		return false, "", "", fmt.Errorf("Bad/missing TLS on network query")
+		return false, "", "", nil, fmt.Errorf("Bad/missing TLS on network query")
+	}
+
+	oidcVerifier := d.getOIDCVerifier()
+	if oidcVerifier != nil && oidc.IsRequest(r) {
+		// Validate OpenID Connect bearer token.
+		identity, err := oidcVerifier.Auth(r)
+		if err != nil {
+			logger.Debug("Rejecting invalid OIDC token", logger.Ctx{"ip": r.RemoteAddr, "err": err})
+			return false, "", "", nil, nil
+		}
+
+		return true, identity.Username, "oidc", identity.Groups, nil
 	}
 
 	if d.externalAuth != nil && r.Header.Get(httpbakery.BakeryProtocolHeader) != "" {
@@ -369,16 +391,16 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		info, err := authChecker.Allow(ctx, ops...)
 		if err != nil {
 			// Bad macaroon.
-			return false, "", "", err
+			return false, "", "", nil, err
 		}
 
 		if info != nil && info.Identity != nil {
 			// Valid identity macaroon found.
-			return true, info.Identity.Id(), "candid", nil
+			return true, info.Identity.Id(), "candid", nil, nil
 		}
 
 		// Valid macaroon with no identity information.
-		return true, "", "candid", nil
+		return true, "", "candid", nil, nil
 	}
 
 	// Validate normal TLS access.
@@ -389,7 +411,7 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 		for _, i := range r.TLS.PeerCertificates {
 			trusted, username := util.CheckTrustState(*i, trustedCerts[clusterDB.CertificateTypeMetrics], d.endpoints.NetworkCert(), trustCACertificates)
 			if trusted {
-				return true, username, "tls", nil
+				return true, username, "tls", nil, nil
 			}
 		}
 	}
@@ -397,12 +419,12 @@ func (d *Daemon) Authenticate(w http.ResponseWriter, r *http.Request) (bool, str
 	for _, i := range r.TLS.PeerCertificates {
 		trusted, username := util.CheckTrustState(*i, trustedCerts[clusterDB.CertificateTypeClient], d.endpoints.NetworkCert(), trustCACertificates)
 		if trusted {
-			return true, username, "tls", nil
+			return true, username, "tls", nil, nil
 		}
 	}
 
 	// Reject unauthorized.
-	return false, "", "", nil
+	return false, "", "", nil, nil
 }
 
 func writeMacaroonsRequiredResponse(b *identchecker.Bakery, r *http.Request, w http.ResponseWriter, derr *bakery.DischargeRequiredError, expiry int64) {
@@ -488,6 +510,16 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 	}
 
 	route := restAPI.HandleFunc(uri, func(w http.ResponseWriter, r *http.Request) {
+		var username, protocol string
+
+		// Record the request for the access log and latency metrics once handled.
+		start := time.Now()
+		accessWriter := &apiAccessResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		w = accessWriter
+		defer func() {
+			d.apiAccessLog(r, uri, accessWriter.status, time.Since(start), username, protocol)
+		}()
+
 		w.Header().Set("Content-Type", "application/json")
 
 		if !(r.RemoteAddr == "@" && version == "internal") {
@@ -503,7 +535,10 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 		}
 
 		// Authentication
-		trusted, username, protocol, err := d.Authenticate(w, r)
+		var trusted bool
+		var err error
+		var groups []string
+		trusted, username, protocol, groups, err = d.Authenticate(w, r)
 		if err != nil {
 			// If not a macaroon discharge request, return the error
 			_, ok := err.(*bakery.DischargeRequiredError)
@@ -573,11 +608,17 @@ func (d *Daemon) createCmd(restAPI *mux.Router, version string, c APIEndpoint) {
 					return ua, nil
 				}
 
-				// If no external authentication configured, we're done now.
-				if d.externalAuth == nil || d.rbac == nil || r.RemoteAddr == "@" {
+				// If no RBAC backend applies to the user, we're done now.
+				if d.rbac == nil || !shared.StringInSlice(protocol, []string{"candid", "oidc"}) {
 					return ua, nil
 				}
 
+				// Include the groups of OIDC users when the backend supports them.
+				groupAuthorizer, ok := d.rbac.(rbac.GroupAuthorizer)
+				if ok && protocol == "oidc" {
+					return groupAuthorizer.UserGroupsAccess(username, groups)
+				}
+
 				// Validate RBAC permissions.
 				ua, err = d.rbac.UserAccess(username)
 				if err != nil {
@@ -943,6 +984,12 @@ func (d *Daemon) init() error {
 
 	logger.Infof(" - cgroup layout: %s", d.os.CGInfo.Mode())
 
+	if d.os.CGInfo.Supports(cgroup.DRM, nil) {
+		logger.Infof(" - drm cgroup controller: yes")
+	} else {
+		logger.Infof(" - drm cgroup controller: no")
+	}
+
 	for _, w := range dbWarnings {
 		logger.Warnf(" - %s, %s", db.WarningTypeNames[db.WarningType(w.TypeCode)], w.LastMessage)
 	}
@@ -1258,6 +1305,12 @@ func (d *Daemon) init() error {
 
 	dnsAddress := ""
 
+	oidcIssuer := ""
+	oidcClientID := ""
+	oidcAudience := ""
+	oidcClaim := ""
+	oidcGroupsClaim := ""
+
 	rbacAPIURL := ""
 	rbacAPIKey := ""
 	rbacAgentURL := ""
@@ -1265,11 +1318,17 @@ func (d *Daemon) init() error {
 	rbacAgentPrivateKey := ""
 	rbacAgentPublicKey := ""
 	rbacExpiry := int64(0)
+	rbacLocal := false
+	var rbacProjectPatterns []string
 
 	maasAPIURL := ""
 	maasAPIKey := ""
 	maasMachine := ""
 
+	vaultAddress := ""
+	vaultToken := ""
+	vaultCACert := ""
+
 	logger.Info("Loading daemon configuration")
 	err = d.db.Node.Transaction(func(tx *db.NodeTx) error {
 		config, err := node.ConfigLoad(tx)
@@ -1281,12 +1340,19 @@ func (d *Daemon) init() error {
 		bgpAddress = config.BGPAddress()
 		bgpRouterID = config.BGPRouterID()
 		dnsAddress = config.DNSAddress()
+		vaultAddress, vaultToken, vaultCACert = config.SecretsVault()
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
+	// Setup the secrets backend (needed to resolve secret references in the configuration).
+	err = d.setupSecretsVault(vaultAddress, vaultToken, vaultCACert)
+	if err != nil {
+		return err
+	}
+
 	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		config, err := clusterConfig.Load(tx)
 		if err != nil {
@@ -1318,8 +1384,11 @@ func (d *Daemon) init() error {
 	)
 
 	candidAPIURL, candidAPIKey, candidExpiry, candidDomains = d.globalConfig.CandidServer()
+	oidcIssuer, oidcClientID, oidcAudience, oidcClaim, oidcGroupsClaim = d.globalConfig.OIDCServer()
 	maasAPIURL, maasAPIKey = d.globalConfig.MAASController()
 	rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey = d.globalConfig.RBACServer()
+	rbacLocal = d.globalConfig.RBACLocal()
+	rbacProjectPatterns = d.globalConfig.RBACProjectPatterns()
 	d.gateway.HeartbeatOfflineThreshold = d.globalConfig.OfflineThreshold()
 
 	d.endpoints.NetworkUpdateTrustedProxy(d.globalConfig.HTTPSTrustedProxy())
@@ -1327,10 +1396,12 @@ func (d *Daemon) init() error {
 
 	// Setup RBAC authentication.
 	if rbacAPIURL != "" {
-		err = d.setupRBACServer(rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey)
+		err = d.setupRBACServer(rbacAPIURL, rbacAPIKey, rbacExpiry, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey, rbacProjectPatterns)
 		if err != nil {
 			return err
 		}
+	} else if rbacLocal {
+		d.setupRBACLocal()
 	}
 
 	// Setup Candid authentication.
@@ -1341,6 +1412,14 @@ func (d *Daemon) init() error {
 		}
 	}
 
+	// Setup OIDC authentication.
+	if oidcIssuer != "" {
+		err = d.setupOIDC(oidcIssuer, oidcClientID, oidcAudience, oidcClaim, oidcGroupsClaim)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Setup BGP listener.
 	d.bgp = bgp.NewServer()
 	if bgpAddress != "" && bgpASN != 0 && bgpRouterID != "" {
@@ -1352,7 +1431,7 @@ func (d *Daemon) init() error {
 	}
 
 	// Setup DNS listener.
-	d.dns = dns.NewServer(d.db.Cluster, func(name string) (*dns.Zone, error) {
+	d.dns = dns.NewServer(d.db.Cluster, func(name string, full bool) (*dns.Zone, error) {
 		// Fetch the zone.
 		zone, err := networkZone.LoadByName(d.State(), name)
 		if err != nil {
@@ -1360,6 +1439,10 @@ func (d *Daemon) init() error {
 		}
 		zoneInfo := zone.Info()
 
+		if !full {
+			return &dns.Zone{Info: *zoneInfo}, nil
+		}
+
 		zoneBuilder, err := zone.Content()
 		if err != nil {
 			logger.Errorf("Failed to render DNS zone %q: %v", name, err)
@@ -1496,13 +1579,13 @@ func (d *Daemon) startClusterTasks() {
 	go cluster.EventsUpdateListeners(d.endpoints, d.db.Cluster, d.serverCert, nil, d.events.Inject)
 
 	// Heartbeats
-	d.taskClusterHeartbeat = d.clusterTasks.Add(cluster.HeartbeatTask(d.gateway))
+	d.taskClusterHeartbeat = d.clusterTasks.Add(cluster.HeartbeatTask(d.gateway)).SetName("heartbeat")
 
 	// Auto-sync images across the cluster (hourly)
-	d.clusterTasks.Add(autoSyncImagesTask(d))
+	d.clusterTasks.Add(autoSyncImagesTask(d)).SetName("sync-images")
 
 	// Remove orphaned operations
-	d.clusterTasks.Add(autoRemoveOrphanedOperationsTask(d))
+	d.clusterTasks.Add(autoRemoveOrphanedOperationsTask(d)).SetName("remove-orphaned-operations")
 
 	// Start all background tasks
 	d.clusterTasks.Start(d.shutdownCtx)
@@ -1532,34 +1615,63 @@ func (d *Daemon) Ready() error {
 	//        but has not been fully completed.
 	if !d.os.MockMode {
 		// Log expiry (daily)
-		d.tasks.Add(expireLogsTask(d.State()))
+		d.tasks.Add(expireLogsTask(d.State())).SetName("expire-logs")
 
 		// Remove expired images (daily)
-		d.taskPruneImages = d.tasks.Add(pruneExpiredImagesTask(d))
+		d.taskPruneImages = d.tasks.Add(pruneExpiredImagesTask(d)).SetName("prune-images")
 
 		// Auto-update images (every 6 hours, configurable)
-		d.tasks.Add(autoUpdateImagesTask(d))
+		d.tasks.Add(autoUpdateImagesTask(d)).SetName("update-images")
 
 		// Auto-update instance types (daily)
-		d.tasks.Add(instanceRefreshTypesTask(d))
+		d.tasks.Add(instanceRefreshTypesTask(d)).SetName("refresh-instance-types")
 
 		// Remove expired container backups (hourly)
-		d.tasks.Add(pruneExpiredContainerBackupsTask(d))
+		d.tasks.Add(pruneExpiredContainerBackupsTask(d)).SetName("prune-backups")
 
 		// Take snapshot of containers (minutely check of configurable cron expression)
-		d.tasks.Add(autoCreateContainerSnapshotsTask(d))
+		d.tasks.Add(autoCreateContainerSnapshotsTask(d)).SetName("snapshot-instances")
+
+		// Publish instances as images (minutely check of configurable cron expression)
+		d.tasks.Add(autoPublishInstancesTask(d)).SetName("publish-instances")
 
 		// Remove expired instance snapshots (minutely)
-		d.tasks.Add(pruneExpiredInstanceSnapshotsTask(d))
+		d.tasks.Add(pruneExpiredInstanceSnapshotsTask(d)).SetName("prune-instance-snapshots")
 
 		// Remove expired custom volume snapshots (minutely)
-		d.tasks.Add(pruneExpireCustomVolumeSnapshotsTask(d))
+		d.tasks.Add(pruneExpireCustomVolumeSnapshotsTask(d)).SetName("prune-volume-snapshots")
 
 		// Take snapshot of custom volumes (minutely check of configurable cron expression)
-		d.tasks.Add(autoCreateCustomVolumeSnapshotsTask(d))
+		d.tasks.Add(autoCreateCustomVolumeSnapshotsTask(d)).SetName("snapshot-volumes")
 
 		// Remove resolved warnings (daily)
-		d.tasks.Add(pruneResolvedWarningsTask(d))
+		d.tasks.Add(pruneResolvedWarningsTask(d)).SetName("prune-warnings")
+
+		// Probe bridge tunnel remotes (every 10s, per tunnel configurable interval)
+		d.tasks.Add(networkTunnelsHealthCheckTask(d)).SetName("check-network-tunnels")
+
+		// Remove stale instance volatile keys (hourly)
+		d.tasks.Add(volatileKeysCleanupTask(d)).SetName("cleanup-volatile-keys")
+
+		// Sample instance NIC traffic counters (minutely)
+		err := nicstats.Load(shared.VarPath("nicstats.json"))
+		if err != nil {
+			logger.Warn("Failed loading NIC counters", logger.Ctx{"err": err})
+		}
+
+		d.tasks.Add(instanceNICCountersTask(d)).SetName("record-nic-counters")
+
+		// Retry starting instance devices that failed to start (minutely)
+		d.tasks.Add(instanceDevicesRetryTask(d)).SetName("retry-instance-devices")
+
+		// Discard unused storage pool blocks (minutely check of configurable cron expression)
+		d.tasks.Add(storagePoolsDiscardTask(d)).SetName("discard-storage-pools")
+
+		// Record the hardware inventory (hourly)
+		d.tasks.Add(inventoryRecordTask(d)).SetName("record-inventory")
+
+		// Purge the expired trashed instances and volumes (hourly)
+		d.tasks.Add(pruneExpiredTrashTask(d)).SetName("prune-trash")
 	}
 
 	// Start all background tasks
@@ -1846,12 +1958,59 @@ func (d *Daemon) setupExternalAuthentication(authEndpoint string, authPubkey str
 	return nil
 }
 
+// Setup OIDC authentication
+func (d *Daemon) setupOIDC(issuer string, clientID string, audience string, claim string, groupsClaim string) error {
+	// Allow disable OIDC authentication
+	if issuer == "" {
+		d.oidcVerifierLock.Lock()
+		d.oidcVerifier = nil
+		d.oidcVerifierLock.Unlock()
+
+		return nil
+	}
+
+	client, err := util.HTTPClient("", d.proxy)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := oidc.NewVerifier(issuer, clientID, audience, claim, groupsClaim, client)
+	if err != nil {
+		return err
+	}
+
+	d.oidcVerifierLock.Lock()
+	d.oidcVerifier = verifier
+	d.oidcVerifierLock.Unlock()
+
+	return nil
+}
+
+// getOIDCVerifier returns the current OIDC verifier, or nil if OIDC authentication is disabled.
+func (d *Daemon) getOIDCVerifier() *oidc.Verifier {
+	d.oidcVerifierLock.Lock()
+	defer d.oidcVerifierLock.Unlock()
+
+	return d.oidcVerifier
+}
+
 // Setup RBAC
-func (d *Daemon) setupRBACServer(rbacURL string, rbacKey string, rbacExpiry int64, rbacAgentURL string, rbacAgentUsername string, rbacAgentPrivateKey string, rbacAgentPublicKey string) error {
+func (d *Daemon) setupRBACServer(rbacURL string, rbacKey string, rbacExpiry int64, rbacAgentURL string, rbacAgentUsername string, rbacAgentPrivateKey string, rbacAgentPublicKey string, rbacProjectPatterns []string) error {
 	if d.rbac != nil || rbacURL == "" || rbacAgentURL == "" || rbacAgentUsername == "" || rbacAgentPrivateKey == "" || rbacAgentPublicKey == "" {
 		return nil
 	}
 
+	// Resolve the keys which may be stored in an external secrets backend.
+	rbacKey, err := secrets.Resolve(rbacKey)
+	if err != nil {
+		return err
+	}
+
+	rbacAgentPrivateKey, err = secrets.Resolve(rbacAgentPrivateKey)
+	if err != nil {
+		return err
+	}
+
 	// Get a new server struct
 	server, err := rbac.NewServer(rbacURL, rbacKey, rbacAgentURL, rbacAgentUsername, rbacAgentPrivateKey, rbacAgentPublicKey)
 	if err != nil {
@@ -1870,6 +2029,9 @@ func (d *Daemon) setupRBACServer(rbacURL string, rbacKey string, rbacExpiry int6
 		return result, err
 	}
 
+	// Set the project patterns
+	server.SetProjectPatterns(rbacProjectPatterns)
+
 	// Perform full sync when online
 	go func() {
 		for {
@@ -1896,6 +2058,54 @@ func (d *Daemon) setupRBACServer(rbacURL string, rbacKey string, rbacExpiry int6
 	return nil
 }
 
+// Setup local RBAC
+func (d *Daemon) setupRBACLocal() {
+	if d.rbac != nil {
+		return
+	}
+
+	local := rbac.NewLocal()
+
+	// Set user access helper
+	local.UserAccessFunc = func(username string, groups []string) (*rbac.UserAccess, error) {
+		// Groups are referred to in the role users with a prefix.
+		roleUsers := []string{username}
+		for _, group := range groups {
+			roleUsers = append(roleUsers, rbac.GroupPrefix+group)
+		}
+
+		found, admin, projects, projectPatterns, err := d.db.Cluster.GetRBACUserAccess(roleUsers)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return nil, nil
+		}
+
+		return &rbac.UserAccess{Admin: admin, Projects: projects, ProjectPatterns: projectPatterns}, nil
+	}
+
+	d.rbac = local
+}
+
+// Setup the Vault secrets backend
+func (d *Daemon) setupSecretsVault(address string, token string, caCert string) error {
+	if address == "" {
+		secrets.SetBackend(secrets.SchemeVault, nil)
+		return nil
+	}
+
+	backend, err := secrets.NewVault(address, token, caCert)
+	if err != nil {
+		return fmt.Errorf("Failed setting up Vault secrets backend: %w", err)
+	}
+
+	secrets.SetBackend(secrets.SchemeVault, backend)
+
+	return nil
+}
+
 // Setup MAAS
 func (d *Daemon) setupMAASController(server string, key string, machine string) error {
 	var err error
@@ -2,14 +2,18 @@ package main
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/backup"
 	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/lifecycle"
@@ -118,6 +122,16 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 
 			fp = info.Fingerprint
 		}
+	} else if protocol == "oci" {
+		if args.Type == "virtual-machine" {
+			return nil, fmt.Errorf("OCI images can only be used for containers")
+		}
+
+		// Resolve the image reference to the digest of its manifest.
+		fp, err = imageOCIDigest(args.Server, alias)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Ensure we are the only ones operating on this image.
@@ -466,6 +480,11 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 		if err != nil {
 			return nil, err
 		}
+	} else if protocol == "oci" {
+		info, err = d.imageDownloadOCI(args.Server, alias, destName, args.Budget, progress)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		return nil, fmt.Errorf("Unsupported protocol: %v", protocol)
 	}
@@ -475,8 +494,9 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 
 	// We want to enable auto-update only if we were passed an
 	// alias name, so we can figure when the associated
-	// fingerprint changes in the remote.
-	if alias != fp {
+	// fingerprint changes in the remote. Images converted from OCI
+	// don't have a stable fingerprint so can't be auto-updated.
+	if alias != fp && protocol != "oci" {
 		info.AutoUpdate = args.AutoUpdate
 	}
 
@@ -547,3 +567,89 @@ func (d *Daemon) ImageDownload(r *http.Request, op *operations.Operation, args *
 
 	return info, nil
 }
+
+// imageOCIReference returns the reference of an image on an OCI registry as understood by skopeo.
+func imageOCIReference(server string, alias string) string {
+	registry := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+
+	return fmt.Sprintf("docker://%s/%s", strings.TrimSuffix(registry, "/"), alias)
+}
+
+// imageOCIDigest returns the digest (without algorithm) of the manifest of an image on an OCI registry.
+func imageOCIDigest(server string, alias string) (string, error) {
+	out, err := shared.RunCommand("skopeo", "--insecure-policy", "inspect", imageOCIReference(server, alias))
+	if err != nil {
+		return "", fmt.Errorf("Failed inspecting OCI image %q: %w", alias, err)
+	}
+
+	var inspect struct {
+		Digest string `json:"Digest"`
+	}
+
+	err = json.Unmarshal([]byte(out), &inspect)
+	if err != nil {
+		return "", fmt.Errorf("Failed parsing OCI image %q details: %w", alias, err)
+	}
+
+	return strings.TrimPrefix(inspect.Digest, "sha256:"), nil
+}
+
+// imageDownloadOCI downloads an image from an OCI registry and converts it into a unified image written to
+// destName. Returns the details of the converted image.
+func (d *Daemon) imageDownloadOCI(server string, alias string, destName string, budget int64, progress func(ioprogress.ProgressData)) (*api.Image, error) {
+	workDir, err := ioutil.TempDir(shared.VarPath("images"), "lxd_oci_")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	// Download the image layout for the local platform.
+	progress(ioprogress.ProgressData{Text: "Downloading OCI image"})
+	layoutDir := filepath.Join(workDir, "layout")
+	_, err = shared.RunCommand("skopeo", "--insecure-policy", "copy", "--remove-signatures", imageOCIReference(server, alias), fmt.Sprintf("oci:%s:latest", layoutDir))
+	if err != nil {
+		return nil, fmt.Errorf("Failed downloading OCI image %q: %w", alias, err)
+	}
+
+	// Convert it into a unified image.
+	progress(ioprogress.ProgressData{Text: "Converting OCI image"})
+	imageDir := filepath.Join(workDir, "image")
+	err = os.Mkdir(imageDir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(destName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	meta, err := backup.OCIToImage(layoutDir, imageDir, d.os, shared.NewQuotaWriter(io.MultiWriter(f, hash), budget), map[string]string{"description": alias})
+	if err != nil {
+		return nil, fmt.Errorf("Failed converting OCI image %q: %w", alias, err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &api.Image{}
+	info.Fingerprint = fmt.Sprintf("%x", hash.Sum(nil))
+	info.Size = fi.Size()
+	info.Architecture = meta.Architecture
+	info.CreatedAt = time.Unix(meta.CreationDate, 0)
+	info.Properties = meta.Properties
+	info.Type = "container"
+
+	return info, nil
+}
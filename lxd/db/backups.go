@@ -23,6 +23,10 @@ type InstanceBackup struct {
 	InstanceOnly         bool
 	OptimizedStorage     bool
 	CompressionAlgorithm string
+	Format               string
+	ObjectKey            string
+	IncrementalFrom      string
+	Exclude              []string
 }
 
 // StoragePoolVolumeBackup is a value object holding all db-related details about a storage volume backup.
@@ -62,7 +66,8 @@ func (c *Cluster) GetInstanceBackup(projectName string, name string) (InstanceBa
 	q := `
 SELECT instances_backups.id, instances_backups.instance_id,
        instances_backups.creation_date, instances_backups.expiry_date,
-       instances_backups.container_only, instances_backups.optimized_storage
+       instances_backups.container_only, instances_backups.optimized_storage,
+       instances_backups.object_key
     FROM instances_backups
     JOIN instances ON instances.id=instances_backups.instance_id
     JOIN projects ON projects.id=instances.project_id
@@ -70,7 +75,7 @@ SELECT instances_backups.id, instances_backups.instance_id,
 `
 	arg1 := []any{projectName, name}
 	arg2 := []any{&args.ID, &args.InstanceID, &args.CreationDate,
-		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt}
+		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt, &args.ObjectKey}
 	err := dbQueryRowScan(c, q, arg1, arg2)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -101,7 +106,8 @@ func (c *Cluster) GetInstanceBackupWithID(backupID int) (InstanceBackup, error)
 	q := `
 SELECT instances_backups.name, instances_backups.instance_id,
        instances_backups.creation_date, instances_backups.expiry_date,
-       instances_backups.container_only, instances_backups.optimized_storage
+       instances_backups.container_only, instances_backups.optimized_storage,
+       instances_backups.object_key
     FROM instances_backups
     JOIN instances ON instances.id=instances_backups.instance_id
     JOIN projects ON projects.id=instances.project_id
@@ -109,7 +115,7 @@ SELECT instances_backups.name, instances_backups.instance_id,
 `
 	arg1 := []any{backupID}
 	arg2 := []any{&args.Name, &args.InstanceID, &args.CreationDate,
-		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt}
+		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt, &args.ObjectKey}
 	err := dbQueryRowScan(c, q, arg1, arg2)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -171,7 +177,7 @@ func (c *Cluster) CreateInstanceBackup(args InstanceBackup) error {
 			optimizedStorageInt = 1
 		}
 
-		str := fmt.Sprintf("INSERT INTO instances_backups (instance_id, name, creation_date, expiry_date, container_only, optimized_storage) VALUES (?, ?, ?, ?, ?, ?)")
+		str := fmt.Sprintf("INSERT INTO instances_backups (instance_id, name, creation_date, expiry_date, container_only, optimized_storage, object_key) VALUES (?, ?, ?, ?, ?, ?, ?)")
 		stmt, err := tx.tx.Prepare(str)
 		if err != nil {
 			return err
@@ -179,7 +185,7 @@ func (c *Cluster) CreateInstanceBackup(args InstanceBackup) error {
 		defer func() { _ = stmt.Close() }()
 		result, err := stmt.Exec(args.InstanceID, args.Name,
 			args.CreationDate.Unix(), args.ExpiryDate.Unix(), instanceOnlyInt,
-			optimizedStorageInt)
+			optimizedStorageInt, args.ObjectKey)
 		if err != nil {
 			return err
 		}
@@ -242,9 +248,10 @@ func (c *Cluster) GetExpiredInstanceBackups() ([]InstanceBackup, error) {
 	var name string
 	var expiryDate string
 	var instanceID int
+	var objectKey string
 
-	q := `SELECT instances_backups.name, instances_backups.expiry_date, instances_backups.instance_id FROM instances_backups`
-	outfmt := []any{name, expiryDate, instanceID}
+	q := `SELECT instances_backups.name, instances_backups.expiry_date, instances_backups.instance_id, instances_backups.object_key FROM instances_backups`
+	outfmt := []any{name, expiryDate, instanceID, objectKey}
 	dbResults, err := queryScan(c, q, nil, outfmt)
 	if err != nil {
 		return nil, err
@@ -272,6 +279,7 @@ func (c *Cluster) GetExpiredInstanceBackups() ([]InstanceBackup, error) {
 				Name:       r[0].(string),
 				InstanceID: r[2].(int),
 				ExpiryDate: backupExpiry,
+				ObjectKey:  r[3].(string),
 			})
 		}
 	}
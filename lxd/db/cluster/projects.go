@@ -148,6 +148,24 @@ func ProjectHasImages(ctx context.Context, tx *sql.Tx, name string) (bool, error
 	return enabled, nil
 }
 
+// ProjectHasLocalImageAliases is a helper to check if a project without the images feature defines its own
+// image aliases on top of the ones of the default project.
+func ProjectHasLocalImageAliases(ctx context.Context, tx *sql.Tx, name string) (bool, error) {
+	project, err := GetProject(ctx, tx, name)
+	if err != nil {
+		return false, fmt.Errorf("fetch project: %w", err)
+	}
+
+	config, err := GetProjectConfig(ctx, tx, project.ID)
+	if err != nil {
+		return false, err
+	}
+
+	enabled := shared.IsTrue(config["images.local_aliases"])
+
+	return enabled, nil
+}
+
 // UpdateProject updates the project matching the given key parameters.
 func UpdateProject(ctx context.Context, tx *sql.Tx, name string, object api.ProjectPut) error {
 	id, err := GetProjectID(ctx, tx, name)
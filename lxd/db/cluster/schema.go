@@ -121,6 +121,7 @@ CREATE TABLE "instances_backups" (
     expiry_date DATETIME,
     container_only INTEGER NOT NULL default 0,
     optimized_storage INTEGER NOT NULL default 0,
+    object_key TEXT NOT NULL DEFAULT '',
     FOREIGN KEY (instance_id) REFERENCES "instances" (id) ON DELETE CASCADE,
     UNIQUE (instance_id, name)
 );
@@ -166,6 +167,14 @@ CREATE INDEX instances_project_id_and_node_id_and_name_idx ON instances (project
 CREATE INDEX instances_project_id_and_node_id_idx ON instances (project_id,
     node_id);
 CREATE INDEX instances_project_id_idx ON instances (project_id);
+CREATE TABLE instances_secrets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	instance_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	UNIQUE (instance_id, name),
+	FOREIGN KEY (instance_id) REFERENCES instances (id) ON DELETE CASCADE
+);
 CREATE TABLE "instances_snapshots" (
     id INTEGER primary key AUTOINCREMENT NOT NULL,
     instance_id INTEGER NOT NULL,
@@ -258,6 +267,26 @@ CREATE TABLE "networks_forwards_config" (
 	UNIQUE (network_forward_id, key),
 	FOREIGN KEY (network_forward_id) REFERENCES "networks_forwards" (id) ON DELETE CASCADE
 );
+CREATE TABLE networks_load_balancers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_id INTEGER NOT NULL,
+	node_id INTEGER,
+	listen_address TEXT NOT NULL,
+	description TEXT NOT NULL,
+	backends TEXT NOT NULL,
+	ports TEXT NOT NULL,
+	UNIQUE (network_id, node_id, listen_address),
+	FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE,
+	FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_load_balancers_config (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_load_balancer_id INTEGER NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	value TEXT,
+	UNIQUE (network_load_balancer_id, key),
+	FOREIGN KEY (network_load_balancer_id) REFERENCES networks_load_balancers (id) ON DELETE CASCADE
+);
 CREATE TABLE "networks_nodes" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     network_id INTEGER NOT NULL,
@@ -289,6 +318,17 @@ CREATE TABLE "networks_peers_config" (
 	FOREIGN KEY (network_peer_id) REFERENCES "networks_peers" (id) ON DELETE CASCADE
 );
 CREATE UNIQUE INDEX networks_unique_network_id_node_id_key ON "networks_config" (network_id, IFNULL(node_id, -1), key);
+CREATE TABLE networks_reservations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_id INTEGER NOT NULL,
+	hwaddr TEXT NOT NULL,
+	description TEXT NOT NULL,
+	hostname TEXT NOT NULL,
+	ipv4_address TEXT NOT NULL,
+	ipv6_address TEXT NOT NULL,
+	UNIQUE (network_id, hwaddr),
+	FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
 CREATE TABLE "networks_zones" (
 	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
 	project_id INTEGER NOT NULL,
@@ -372,6 +412,7 @@ CREATE TABLE "operations" (
     FOREIGN KEY (node_id) REFERENCES "nodes" (id) ON DELETE CASCADE,
     FOREIGN KEY (project_id) REFERENCES "projects" (id) ON DELETE CASCADE
 );
+CREATE INDEX operations_project_id_idx ON operations (project_id);
 CREATE TABLE "profiles" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     name TEXT NOT NULL,
@@ -419,6 +460,42 @@ CREATE TABLE "projects_config" (
     FOREIGN KEY (project_id) REFERENCES "projects" (id) ON DELETE CASCADE,
     UNIQUE (project_id, key)
 );
+CREATE TABLE rbac_roles (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	admin INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (name)
+);
+CREATE TABLE rbac_roles_permissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	role_id INTEGER NOT NULL,
+	permission TEXT NOT NULL,
+	UNIQUE (role_id, permission),
+	FOREIGN KEY (role_id) REFERENCES rbac_roles (id) ON DELETE CASCADE
+);
+CREATE TABLE rbac_roles_project_patterns (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	role_id INTEGER NOT NULL,
+	pattern TEXT NOT NULL,
+	UNIQUE (role_id, pattern),
+	FOREIGN KEY (role_id) REFERENCES rbac_roles (id) ON DELETE CASCADE
+);
+CREATE TABLE rbac_roles_projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	role_id INTEGER NOT NULL,
+	project_id INTEGER NOT NULL,
+	UNIQUE (role_id, project_id),
+	FOREIGN KEY (role_id) REFERENCES rbac_roles (id) ON DELETE CASCADE,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+CREATE TABLE rbac_roles_users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	role_id INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	UNIQUE (role_id, username),
+	FOREIGN KEY (role_id) REFERENCES rbac_roles (id) ON DELETE CASCADE
+);
 CREATE TABLE "storage_pools" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     name TEXT NOT NULL,
@@ -562,5 +639,5 @@ CREATE TABLE "warnings" (
 );
 CREATE UNIQUE INDEX warnings_unique_node_id_project_id_entity_type_code_entity_id_type_code ON warnings(IFNULL(node_id, -1), IFNULL(project_id, -1), entity_type_code, entity_id, type_code);
 
-INSERT INTO schema (version, updated_at) VALUES (60, strftime("%s"))
+INSERT INTO schema (version, updated_at) VALUES (67, strftime("%s"))
 `
@@ -97,6 +97,156 @@ var updates = map[int]schema.Update{
 	58: updateFromV57,
 	59: updateFromV58,
 	60: updateFromV59,
+	61: updateFromV60,
+	62: updateFromV61,
+	63: updateFromV62,
+	64: updateFromV63,
+	65: updateFromV64,
+	66: updateFromV65,
+	67: updateFromV66,
+}
+
+func updateFromV66(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE networks_load_balancers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_id INTEGER NOT NULL,
+	node_id INTEGER,
+	listen_address TEXT NOT NULL,
+	description TEXT NOT NULL,
+	backends TEXT NOT NULL,
+	ports TEXT NOT NULL,
+	UNIQUE (network_id, node_id, listen_address),
+	FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE,
+	FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_load_balancers_config (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_load_balancer_id INTEGER NOT NULL,
+	key VARCHAR(255) NOT NULL,
+	value TEXT,
+	UNIQUE (network_load_balancer_id, key),
+	FOREIGN KEY (network_load_balancer_id) REFERENCES networks_load_balancers (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed creating network load balancer tables: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV65(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE instances_secrets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	instance_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL,
+	UNIQUE (instance_id, name),
+	FOREIGN KEY (instance_id) REFERENCES instances (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed creating instance secrets table: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV64(tx *sql.Tx) error {
+	_, err := tx.Exec(`ALTER TABLE instances_backups ADD COLUMN object_key TEXT NOT NULL DEFAULT '';`)
+	if err != nil {
+		return fmt.Errorf("Failed adding object key column to instance backups table: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV63(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE rbac_roles_project_patterns (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	role_id INTEGER NOT NULL,
+	pattern TEXT NOT NULL,
+	UNIQUE (role_id, pattern),
+	FOREIGN KEY (role_id) REFERENCES rbac_roles (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed creating RBAC role project patterns table: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV62(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE rbac_roles (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	admin INTEGER NOT NULL DEFAULT 0,
+	UNIQUE (name)
+);
+CREATE TABLE rbac_roles_permissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	role_id INTEGER NOT NULL,
+	permission TEXT NOT NULL,
+	UNIQUE (role_id, permission),
+	FOREIGN KEY (role_id) REFERENCES rbac_roles (id) ON DELETE CASCADE
+);
+CREATE TABLE rbac_roles_projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	role_id INTEGER NOT NULL,
+	project_id INTEGER NOT NULL,
+	UNIQUE (role_id, project_id),
+	FOREIGN KEY (role_id) REFERENCES rbac_roles (id) ON DELETE CASCADE,
+	FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+CREATE TABLE rbac_roles_users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	role_id INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	UNIQUE (role_id, username),
+	FOREIGN KEY (role_id) REFERENCES rbac_roles (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed creating RBAC roles tables: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV61(tx *sql.Tx) error {
+	_, err := tx.Exec(`CREATE INDEX operations_project_id_idx ON operations (project_id);`)
+	if err != nil {
+		return fmt.Errorf("Failed creating operations project index: %w", err)
+	}
+
+	return nil
+}
+
+func updateFromV60(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE networks_reservations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	network_id INTEGER NOT NULL,
+	hwaddr TEXT NOT NULL,
+	description TEXT NOT NULL,
+	hostname TEXT NOT NULL,
+	ipv4_address TEXT NOT NULL,
+	ipv6_address TEXT NOT NULL,
+	UNIQUE (network_id, hwaddr),
+	FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+`)
+	if err != nil {
+		return fmt.Errorf("Failed creating network reservations table: %w", err)
+	}
+
+	return nil
 }
 
 func updateFromV59(tx *sql.Tx) error {
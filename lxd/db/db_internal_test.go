@@ -246,6 +246,43 @@ func (s *dbTestSuite) Test_CreateImageAlias() {
 	s.Equal(alias.Target, "fingerprint")
 }
 
+func (s *dbTestSuite) Test_ResolveImageAlias_local_aliases() {
+	tx, commit := s.CreateTestTx()
+	_, err := tx.Exec(`
+    INSERT INTO projects (name, description) VALUES ('tenant', '');
+    INSERT INTO projects_config (project_id, key, value) VALUES (2, 'features.images', 'false');
+    INSERT INTO projects_config (project_id, key, value) VALUES (2, 'images.local_aliases', 'true');
+    INSERT INTO images (fingerprint, filename, size, architecture, creation_date, expiry_date, upload_date, auto_update, project_id) VALUES ('pinned', 'filename', 1024, 0,  1431547174,  1431547175,  1431547176, 1, 1);
+    INSERT INTO images_aliases (name, image_id, description, project_id) VALUES ('otheralias', 1, '', 1);
+`)
+	s.Nil(err)
+	commit()
+
+	err = s.db.CreateImageAlias("tenant", "somealias", 2, "Pinned image")
+	s.Nil(err)
+
+	// The local alias shadows the one of the default project.
+	_, alias, err := s.db.ResolveImageAlias("tenant", "somealias", true)
+	s.Nil(err)
+	s.Equal("pinned", alias.Target)
+
+	_, alias, err = s.db.ResolveImageAlias("default", "somealias", true)
+	s.Nil(err)
+	s.Equal("fingerprint", alias.Target)
+
+	// Aliases of the default project are inherited but can't be accessed directly.
+	_, alias, err = s.db.ResolveImageAlias("tenant", "otheralias", true)
+	s.Nil(err)
+	s.Equal("fingerprint", alias.Target)
+
+	_, _, err = s.db.GetImageAlias("tenant", "otheralias", true)
+	s.True(api.StatusErrorCheck(err, http.StatusNotFound))
+
+	names, err := s.db.GetImageAliases("tenant")
+	s.Nil(err)
+	s.ElementsMatch([]string{"somealias", "otheralias"}, names)
+}
+
 func (s *dbTestSuite) Test_GetCachedImageSourceFingerprint() {
 	project := "default"
 	imageID, _, err := s.db.GetImage("fingerprint", ImageFilter{Project: &project})
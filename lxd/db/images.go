@@ -14,6 +14,7 @@ import (
 	"github.com/lxc/lxd/lxd/db/cluster"
 	"github.com/lxc/lxd/lxd/db/query"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/osarch"
 )
@@ -185,7 +186,8 @@ func (c *ClusterTx) imageFill(id int, image *api.Image, create, expire, used, up
 		}
 
 	}
-	q := "SELECT name, description FROM images_aliases WHERE image_id=?"
+	// Only list the aliases of the image's own project, not the project-local overrides pointing to it.
+	q := "SELECT name, description FROM images_aliases WHERE image_id=? AND project_id=(SELECT project_id FROM images WHERE id=?)"
 	stmt, err := c.tx.Prepare(q)
 	if err != nil {
 		return err
@@ -193,7 +195,7 @@ func (c *ClusterTx) imageFill(id int, image *api.Image, create, expire, used, up
 
 	defer func() { _ = stmt.Close() }()
 
-	err = query.SelectObjects(stmt, dest, id)
+	err = query.SelectObjects(stmt, dest, id, id)
 	if err != nil {
 		return err
 	}
@@ -707,6 +709,33 @@ func (c *Cluster) DeleteImage(id int) error {
 	})
 }
 
+// imageAliasProject returns the project holding the image aliases of the given project, and whether the aliases
+// of the default project are inherited by it.
+//
+// Projects without their own images use the aliases of the default project, unless images.local_aliases is
+// enabled in which case their own aliases shadow the ones of the default project.
+func imageAliasProject(ctx context.Context, tx *sql.Tx, project string) (string, bool, error) {
+	enabled, err := cluster.ProjectHasImages(ctx, tx, project)
+	if err != nil {
+		return "", false, fmt.Errorf("Check if project has images: %w", err)
+	}
+
+	if enabled {
+		return project, false, nil
+	}
+
+	localAliases, err := cluster.ProjectHasLocalImageAliases(ctx, tx, project)
+	if err != nil {
+		return "", false, fmt.Errorf("Check if project has local image aliases: %w", err)
+	}
+
+	if localAliases {
+		return project, true, nil
+	}
+
+	return "default", false, nil
+}
+
 // GetImageAliases returns the names of the aliases of all images.
 func (c *Cluster) GetImageAliases(project string) ([]string, error) {
 	var names []string
@@ -718,15 +747,33 @@ SELECT images_aliases.name
 `
 
 	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
-		enabled, err := cluster.ProjectHasImages(context.Background(), tx.tx, project)
+		aliasProject, inherit, err := imageAliasProject(ctx, tx.tx, project)
 		if err != nil {
-			return fmt.Errorf("Check if project has images: %w", err)
+			return err
 		}
-		if !enabled {
-			project = "default"
+
+		names, err = query.SelectStrings(tx.tx, q, aliasProject)
+		if err != nil {
+			return err
 		}
-		names, err = query.SelectStrings(tx.tx, q, project)
-		return err
+
+		if !inherit {
+			return nil
+		}
+
+		// Add the aliases of the default project which aren't overridden.
+		defaultNames, err := query.SelectStrings(tx.tx, q, "default")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range defaultNames {
+			if !shared.StringInSlice(name, names) {
+				names = append(names, name)
+			}
+		}
+
+		return nil
 	})
 	if err != nil {
 		return nil, err
@@ -736,9 +783,62 @@ SELECT images_aliases.name
 }
 
 // GetImageAlias returns the alias with the given name in the given project.
+//
+// For projects with local image aliases, only the aliases defined in the project itself are considered. Use
+// ResolveImageAlias to also consider the inherited aliases of the default project.
 func (c *Cluster) GetImageAlias(project, name string, isTrustedClient bool) (int, api.ImageAliasesEntry, error) {
 	id := -1
 	entry := api.ImageAliasesEntry{}
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		aliasProject, _, err := imageAliasProject(ctx, tx.tx, project)
+		if err != nil {
+			return err
+		}
+
+		id, entry, err = getImageAlias(tx.tx, aliasProject, name, isTrustedClient)
+		return err
+	})
+	if err != nil {
+		return -1, entry, err
+	}
+
+	return id, entry, nil
+}
+
+// ResolveImageAlias returns the alias with the given name as seen from the given project.
+//
+// Unlike GetImageAlias, the aliases of the default project are used as a fallback for projects with local
+// image aliases.
+func (c *Cluster) ResolveImageAlias(project, name string, isTrustedClient bool) (int, api.ImageAliasesEntry, error) {
+	id := -1
+	entry := api.ImageAliasesEntry{}
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		aliasProject, inherit, err := imageAliasProject(ctx, tx.tx, project)
+		if err != nil {
+			return err
+		}
+
+		id, entry, err = getImageAlias(tx.tx, aliasProject, name, isTrustedClient)
+		if err == nil || !inherit || !api.StatusErrorCheck(err, http.StatusNotFound) {
+			return err
+		}
+
+		id, entry, err = getImageAlias(tx.tx, "default", name, isTrustedClient)
+		return err
+	})
+	if err != nil {
+		return -1, entry, err
+	}
+
+	return id, entry, nil
+}
+
+// getImageAlias returns the alias with the given name stored in the given project.
+func getImageAlias(tx *sql.Tx, project string, name string, isTrustedClient bool) (int, api.ImageAliasesEntry, error) {
+	id := -1
+	entry := api.ImageAliasesEntry{}
 	q := `SELECT images_aliases.id, images.fingerprint, images.type, images_aliases.description
 			 FROM images_aliases
 			 INNER JOIN images
@@ -750,40 +850,36 @@ func (c *Cluster) GetImageAlias(project, name string, isTrustedClient bool) (int
 		q = q + ` AND images.public=1`
 	}
 
-	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
-		enabled, err := cluster.ProjectHasImages(context.Background(), tx.tx, project)
-		if err != nil {
-			return fmt.Errorf("Check if project has images: %w", err)
-		}
-		if !enabled {
-			project = "default"
+	var fingerprint, description string
+	var imageType int
+
+	arg1 := []any{project, name}
+	arg2 := []any{&id, &fingerprint, &imageType, &description}
+	err := tx.QueryRow(q, arg1...).Scan(arg2...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, entry, api.StatusErrorf(http.StatusNotFound, "Image alias not found")
 		}
-		var fingerprint, description string
-		var imageType int
 
-		arg1 := []any{project, name}
-		arg2 := []any{&id, &fingerprint, &imageType, &description}
-		err = tx.tx.QueryRow(q, arg1...).Scan(arg2...)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return api.StatusErrorf(http.StatusNotFound, "Image alias not found")
-			}
+		return -1, entry, err
+	}
 
-			return err
-		}
+	entry.Name = name
+	entry.Target = fingerprint
+	entry.Description = description
+	entry.Type = instancetype.Type(imageType).String()
 
-		entry.Name = name
-		entry.Target = fingerprint
-		entry.Description = description
-		entry.Type = instancetype.Type(imageType).String()
+	return id, entry, nil
+}
 
-		return nil
-	})
+// ProjectHasImageAliases returns whether any image alias is stored in the given project itself.
+func (c *ClusterTx) ProjectHasImageAliases(project string) (bool, error) {
+	count, err := query.Count(c.tx, "images_aliases", "project_id=(SELECT id FROM projects WHERE name=?)", project)
 	if err != nil {
-		return -1, entry, err
+		return false, err
 	}
 
-	return id, entry, nil
+	return count > 0, nil
 }
 
 // RenameImageAlias renames the alias with the given ID.
@@ -804,15 +900,12 @@ DELETE
  WHERE project_id = (SELECT id FROM projects WHERE name = ?) AND name = ?
 `
 	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
-		enabled, err := cluster.ProjectHasImages(context.Background(), tx.tx, project)
+		aliasProject, _, err := imageAliasProject(ctx, tx.tx, project)
 		if err != nil {
-			return fmt.Errorf("Check if project has images: %w", err)
-		}
-		if !enabled {
-			project = "default"
+			return err
 		}
 
-		_, err = tx.tx.Exec(q, project, name)
+		_, err = tx.tx.Exec(q, aliasProject, name)
 		return err
 	})
 	if err != nil {
@@ -838,15 +931,12 @@ INSERT INTO images_aliases (name, image_id, description, project_id)
      VALUES (?, ?, ?, (SELECT id FROM projects WHERE name = ?))
 `
 	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
-		enabled, err := cluster.ProjectHasImages(context.Background(), tx.tx, project)
+		aliasProject, _, err := imageAliasProject(ctx, tx.tx, project)
 		if err != nil {
-			return fmt.Errorf("Check if project has images: %w", err)
-		}
-		if !enabled {
-			project = "default"
+			return err
 		}
 
-		_, err = tx.tx.Exec(stmt, name, imageID, desc, project)
+		_, err = tx.tx.Exec(stmt, name, imageID, desc, aliasProject)
 		return err
 	})
 	if err != nil {
@@ -0,0 +1,125 @@
+//go:build linux && cgo && !agent
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetInstanceSecretNames returns the names of the secrets staged for the instance with the given ID.
+func (c *Cluster) GetInstanceSecretNames(instanceID int) ([]string, error) {
+	var names []string
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM instances_secrets WHERE instance_id = ? ORDER BY name", instanceID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetInstanceSecrets returns the secrets staged for the instance with the given ID, indexed by name.
+func (c *Cluster) GetInstanceSecrets(instanceID int) (map[string]string, error) {
+	secrets := map[string]string{}
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		rows, err := tx.tx.Query("SELECT name, value FROM instances_secrets WHERE instance_id = ?", instanceID)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			var name, value string
+
+			err = rows.Scan(&name, &value)
+			if err != nil {
+				return err
+			}
+
+			secrets[name] = value
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// CreateInstanceSecret stages a secret for the instance with the given ID, replacing any existing secret with
+// the same name.
+func (c *Cluster) CreateInstanceSecret(instanceID int, name string, value string) error {
+	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		_, err := tx.tx.Exec("INSERT OR REPLACE INTO instances_secrets (instance_id, name, value) VALUES (?, ?, ?)", instanceID, name, value)
+		return err
+	})
+}
+
+// DeleteInstanceSecret deletes the secret with the given name staged for the instance with the given ID.
+func (c *Cluster) DeleteInstanceSecret(instanceID int, name string) error {
+	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		res, err := tx.tx.Exec("DELETE FROM instances_secrets WHERE instance_id = ? AND name = ?", instanceID, name)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected <= 0 {
+			return api.StatusErrorf(http.StatusNotFound, "Instance secret not found")
+		}
+
+		return nil
+	})
+}
+
+// ConsumeInstanceSecret returns the value of the secret with the given name staged for the instance with the
+// given ID and deletes it in the same transaction, so that it can only be retrieved once.
+func (c *Cluster) ConsumeInstanceSecret(instanceID int, name string) (string, error) {
+	var value string
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		err := tx.tx.QueryRow("SELECT value FROM instances_secrets WHERE instance_id = ? AND name = ?", instanceID, name).Scan(&value)
+		if errors.Is(err, sql.ErrNoRows) {
+			return api.StatusErrorf(http.StatusNotFound, "Instance secret not found")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM instances_secrets WHERE instance_id = ? AND name = ?", instanceID, name)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+// DeleteDeliveredInstanceSecret deletes the secret with the given name staged for the instance with the given ID
+// once it's been handed over to the instance, unless it was replaced in the meantime.
+func (c *Cluster) DeleteDeliveredInstanceSecret(instanceID int, name string, value string) error {
+	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		_, err := tx.tx.Exec("DELETE FROM instances_secrets WHERE instance_id = ? AND name = ? AND value = ?", instanceID, name, value)
+		return err
+	})
+}
@@ -317,6 +317,59 @@ SELECT instances.name, nodes.id, nodes.address, nodes.heartbeat, projects.name
 	return result, nil
 }
 
+// TrashedInstance is an instance kept after its deletion from a project with deletion protection.
+type TrashedInstance struct {
+	Project      string
+	Name         string
+	Node         string
+	OriginalName string
+	DeletedAt    string
+}
+
+// GetTrashedInstances returns the trashed instances of the given projects.
+func (c *ClusterTx) GetTrashedInstances(projects []string) ([]TrashedInstance, error) {
+	args := make([]any, 0, len(projects))
+	for _, project := range projects {
+		args = append(args, project)
+	}
+
+	stmt := fmt.Sprintf(`
+SELECT projects.name, instances.name, nodes.name, trash_name.value, coalesce(trash_date.value, '')
+  FROM instances
+  JOIN nodes ON nodes.id = instances.node_id
+  JOIN projects ON projects.id = instances.project_id
+  JOIN instances_config AS trash_name ON trash_name.instance_id = instances.id AND trash_name.key = 'volatile.trash.name'
+  LEFT JOIN instances_config AS trash_date ON trash_date.instance_id = instances.id AND trash_date.key = 'volatile.trash.date'
+  WHERE projects.name IN (%s)
+  ORDER BY instances.id
+`, generateInClauseParams(len(projects)))
+
+	rows, err := c.tx.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	result := []TrashedInstance{}
+	for rows.Next() {
+		inst := TrashedInstance{}
+		err := rows.Scan(&inst.Project, &inst.Name, &inst.Node, &inst.OriginalName, &inst.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, inst)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // ErrInstanceListStop used as return value from InstanceList's instanceFunc when prematurely stopping the search.
 var ErrInstanceListStop = fmt.Errorf("search stopped")
 
@@ -463,8 +516,8 @@ SELECT instances.name, nodes.name, projects.name
 // UpdateInstanceNode changes the name of an instance and the cluster member hosting it.
 // It's meant to be used when moving a non-running instance backed by ceph from one cluster node to another.
 func (c *ClusterTx) UpdateInstanceNode(project, oldName string, newName string, newNode string, volumeType int) error {
-	// First check that the container to be moved is backed by a ceph
-	// volume.
+	// First check that the container to be moved is backed by a remote
+	// storage volume.
 	poolName, err := c.GetInstancePool(project, oldName)
 	if err != nil {
 		return fmt.Errorf("Failed to get instance's storage pool name: %w", err)
@@ -480,8 +533,8 @@ func (c *ClusterTx) UpdateInstanceNode(project, oldName string, newName string,
 		return fmt.Errorf("Failed to get instance's storage pool driver: %w", err)
 	}
 
-	if poolDriver != "ceph" {
-		return fmt.Errorf("Instance's storage pool is not of type ceph")
+	if !shared.StringInSlice(poolDriver, StorageRemoteDriverNames()) {
+		return fmt.Errorf("Instance's storage pool is not remote")
 	}
 
 	// Update the name of the container and of its snapshots, and the node
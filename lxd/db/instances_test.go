@@ -414,6 +414,34 @@ func TestGetInstanceToNodeMap(t *testing.T) {
 		}, result)
 }
 
+// Only instances with a trash name are returned, along with their member.
+func TestGetTrashedInstances(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	nodeID1 := int64(1) // This is the default local member
+
+	nodeID2, err := tx.CreateNode("node2", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	addContainer(t, tx, nodeID1, "c1")
+	addContainer(t, tx, nodeID2, "trash-1")
+	addContainer(t, tx, nodeID1, "trash-2")
+
+	addContainerConfig(t, tx, "trash-1", "volatile.trash.name", "c2")
+	addContainerConfig(t, tx, "trash-1", "volatile.trash.date", "2022-06-01T10:00:00Z")
+	addContainerConfig(t, tx, "trash-2", "volatile.trash.name", "c3")
+
+	result, err := tx.GetTrashedInstances([]string{"default"})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]db.TrashedInstance{
+			{Project: project.Default, Name: "trash-1", Node: "node2", OriginalName: "c2", DeletedAt: "2022-06-01T10:00:00Z"},
+			{Project: project.Default, Name: "trash-2", Node: "none", OriginalName: "c3"},
+		}, result)
+}
+
 func TestGetInstancePool(t *testing.T) {
 	cluster, cleanup := db.NewTestCluster(t)
 	defer cleanup()
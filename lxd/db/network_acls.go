@@ -134,6 +134,71 @@ func (c *Cluster) GetNetworkACL(projectName string, name string) (int64, *api.Ne
 	return id, &acl, nil
 }
 
+// GetNetworkACLsAllProjects returns all Network ACLs keyed by project name, in creation order.
+func (c *ClusterTx) GetNetworkACLsAllProjects() (map[string][]api.NetworkACL, error) {
+	q := `
+		SELECT networks_acls.id, projects.name, networks_acls.name, networks_acls.description, networks_acls.ingress, networks_acls.egress
+		FROM networks_acls
+		JOIN projects ON projects.id = networks_acls.project_id
+		ORDER BY networks_acls.id
+	`
+
+	var aclIDs []int64
+	var aclProjects []string
+	var acls []api.NetworkACL
+
+	err := c.QueryScan(q, func(scan func(dest ...any) error) error {
+		var aclID int64
+		var projectName string
+		var ingressJSON string
+		var egressJSON string
+		var acl api.NetworkACL
+
+		err := scan(&aclID, &projectName, &acl.Name, &acl.Description, &ingressJSON, &egressJSON)
+		if err != nil {
+			return err
+		}
+
+		acl.Ingress = []api.NetworkACLRule{}
+		if ingressJSON != "" {
+			err = json.Unmarshal([]byte(ingressJSON), &acl.Ingress)
+			if err != nil {
+				return fmt.Errorf("Failed unmarshalling ingress rules: %w", err)
+			}
+		}
+
+		acl.Egress = []api.NetworkACLRule{}
+		if egressJSON != "" {
+			err = json.Unmarshal([]byte(egressJSON), &acl.Egress)
+			if err != nil {
+				return fmt.Errorf("Failed unmarshalling egress rules: %w", err)
+			}
+		}
+
+		aclIDs = append(aclIDs, aclID)
+		aclProjects = append(aclProjects, projectName)
+		acls = append(acls, acl)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	projectACLs := make(map[string][]api.NetworkACL)
+
+	for i := range acls {
+		err = networkACLConfig(c, aclIDs[i], &acls[i])
+		if err != nil {
+			return nil, fmt.Errorf("Failed loading config: %w", err)
+		}
+
+		projectACLs[aclProjects[i]] = append(projectACLs[aclProjects[i]], acls[i])
+	}
+
+	return projectACLs, nil
+}
+
 // GetNetworkACLNameAndProjectWithID returns the network ACL name and project name for the given ID.
 func (c *Cluster) GetNetworkACLNameAndProjectWithID(networkACLID int) (string, string, error) {
 	var networkACLName string
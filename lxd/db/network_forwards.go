@@ -472,3 +472,22 @@ func (c *Cluster) GetNetworkForwards(networkID int64, memberSpecific bool) (map[
 
 	return forwards, nil
 }
+
+// GetProjectNetworkForwardsCount returns the number of Network Forwards that belong to networks in the project.
+func (c *ClusterTx) GetProjectNetworkForwardsCount(projectName string) (int, error) {
+	q := `
+	SELECT COUNT(*)
+	FROM networks_forwards
+	JOIN networks ON networks.id = networks_forwards.network_id
+	JOIN projects ON projects.id = networks.project_id
+	WHERE projects.name = ?
+	`
+	count := 0
+
+	err := c.tx.QueryRow(q, projectName).Scan(&count)
+	if err != nil {
+		return -1, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,136 @@
+//go:build linux && cgo && !agent
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// CreateNetworkReservation creates a new Network DHCP reservation.
+func (c *Cluster) CreateNetworkReservation(networkID int64, info *api.NetworkReservationsPost) (int64, error) {
+	var reservationID int64
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		// Insert a new Network reservation record.
+		result, err := tx.tx.Exec(`
+		INSERT INTO networks_reservations
+		(network_id, hwaddr, description, hostname, ipv4_address, ipv6_address)
+		VALUES (?, ?, ?, ?, ?, ?)
+		`, networkID, info.Hwaddr, info.Description, info.Hostname, info.IPv4Address, info.IPv6Address)
+		if err != nil {
+			return err
+		}
+
+		reservationID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return reservationID, nil
+}
+
+// DeleteNetworkReservation deletes an existing Network DHCP reservation.
+func (c *Cluster) DeleteNetworkReservation(networkID int64, reservationID int64) error {
+	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		// Delete existing Network reservation record.
+		res, err := tx.tx.Exec(`
+			DELETE FROM networks_reservations
+			WHERE network_id = ? and id = ?
+		`, networkID, reservationID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected <= 0 {
+			return api.StatusErrorf(http.StatusNotFound, "Network reservation not found")
+		}
+
+		return nil
+	})
+}
+
+// GetNetworkReservation returns the Network DHCP reservation ID and info for the given network ID and MAC address.
+func (c *Cluster) GetNetworkReservation(networkID int64, hwaddr string) (int64, *api.NetworkReservation, error) {
+	q := `
+	SELECT
+		id,
+		hwaddr,
+		description,
+		hostname,
+		ipv4_address,
+		ipv6_address
+	FROM networks_reservations
+	WHERE network_id = ? AND hwaddr = ?
+	`
+
+	var reservationID int64 = int64(-1)
+	var reservation api.NetworkReservation
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		err := tx.tx.QueryRow(q, networkID, hwaddr).Scan(&reservationID, &reservation.Hwaddr, &reservation.Description, &reservation.Hostname, &reservation.IPv4Address, &reservation.IPv6Address)
+		if errors.Is(err, sql.ErrNoRows) {
+			return api.StatusErrorf(http.StatusNotFound, "Network reservation not found")
+		}
+
+		return err
+	})
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return reservationID, &reservation, nil
+}
+
+// GetNetworkReservations returns map of Network DHCP reservations for the given network ID keyed on reservation ID.
+func (c *Cluster) GetNetworkReservations(networkID int64) (map[int64]*api.NetworkReservation, error) {
+	q := `
+	SELECT
+		id,
+		hwaddr,
+		description,
+		hostname,
+		ipv4_address,
+		ipv6_address
+	FROM networks_reservations
+	WHERE network_id = ?
+	`
+
+	reservations := make(map[int64]*api.NetworkReservation)
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		return tx.QueryScan(q, func(scan func(dest ...any) error) error {
+			var reservationID int64 = int64(-1)
+			var reservation api.NetworkReservation
+
+			err := scan(&reservationID, &reservation.Hwaddr, &reservation.Description, &reservation.Hostname, &reservation.IPv4Address, &reservation.IPv6Address)
+			if err != nil {
+				return err
+			}
+
+			reservations[reservationID] = &reservation
+
+			return nil
+		}, networkID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
@@ -1052,24 +1052,21 @@ func (c *ClusterTx) GetNodeOfflineThreshold() (time.Duration, error) {
 	return threshold, nil
 }
 
-// GetNodeWithLeastInstances returns the name of the non-offline node with with
-// the least number of containers (either already created or being created with
-// an operation). If archs is not empty, then return only nodes with an
-// architecture in that list.
-func (c *ClusterTx) GetNodeWithLeastInstances(archs []int, defaultArch int, group string, allowedGroups []string) (string, error) {
+// GetCandidateMembers returns the non-offline members that can be picked by the instance scheduler, taking into
+// account their state, their scheduler configuration and the requested or allowed cluster groups. If archs is not
+// empty, then return only members with an architecture (or personality) in that list.
+func (c *ClusterTx) GetCandidateMembers(archs []int, group string, allowedGroups []string) ([]NodeInfo, error) {
 	threshold, err := c.GetNodeOfflineThreshold()
 	if err != nil {
-		return "", fmt.Errorf("Failed to get offline threshold: %w", err)
+		return nil, fmt.Errorf("Failed to get offline threshold: %w", err)
 	}
 
 	nodes, err := c.GetNodes()
 	if err != nil {
-		return "", fmt.Errorf("Failed to get current cluster members: %w", err)
+		return nil, fmt.Errorf("Failed to get current cluster members: %w", err)
 	}
 
-	name := ""
-	containers := -1
-	isDefaultArchChosen := false
+	candidates := make([]NodeInfo, 0, len(nodes))
 	for _, node := range nodes {
 		// Skip evacuated members.
 		if node.State == ClusterMemberStateEvacuated || node.IsOffline(threshold) {
@@ -1106,46 +1103,92 @@ func (c *ClusterTx) GetNodeWithLeastInstances(archs []int, defaultArch int, grou
 			}
 		}
 
-		// Get member personalities too.
-		personalities, err := osarch.ArchitecturePersonalities(node.Architecture)
-		if err != nil {
-			return "", err
-		}
-
-		supported := []int{node.Architecture}
-		supported = append(supported, personalities...)
+		if len(archs) > 0 {
+			supported, err := node.Architectures()
+			if err != nil {
+				return nil, err
+			}
 
-		match := false
-		isDefaultArch := false
-		for _, entry := range supported {
-			if shared.IntInSlice(entry, archs) {
-				match = true
+			match := false
+			for _, entry := range supported {
+				if shared.IntInSlice(entry, archs) {
+					match = true
+					break
+				}
 			}
-			if entry == defaultArch {
-				isDefaultArch = true
+
+			if !match {
+				continue
 			}
 		}
-		if len(archs) > 0 && !match {
-			continue
+
+		candidates = append(candidates, node)
+	}
+
+	return candidates, nil
+}
+
+// Architectures returns the architecture of the member along with its personalities.
+func (n NodeInfo) Architectures() ([]int, error) {
+	personalities, err := osarch.ArchitecturePersonalities(n.Architecture)
+	if err != nil {
+		return nil, err
+	}
+
+	supported := []int{n.Architecture}
+	supported = append(supported, personalities...)
+
+	return supported, nil
+}
+
+// GetNodeInstancesCount returns the number of instances on the member with the given ID, including those
+// currently being created by an operation.
+func (c *ClusterTx) GetNodeInstancesCount(nodeID int64) (int, error) {
+	// Fetch the number of instances already created on this node.
+	created, err := query.Count(c.tx, "instances", "node_id=?", nodeID)
+	if err != nil {
+		return -1, fmt.Errorf("Failed to get instances count: %w", err)
+	}
+
+	// Fetch the number of instances currently being created on this node.
+	pending, err := query.Count(
+		c.tx, "operations", "node_id=? AND type=?", nodeID, OperationInstanceCreate)
+	if err != nil {
+		return -1, fmt.Errorf("Failed to get pending instances count: %w", err)
+	}
+
+	return created + pending, nil
+}
+
+// GetNodeWithLeastInstances returns the name of the non-offline node with with
+// the least number of containers (either already created or being created with
+// an operation). If archs is not empty, then return only nodes with an
+// architecture in that list.
+func (c *ClusterTx) GetNodeWithLeastInstances(archs []int, defaultArch int, group string, allowedGroups []string) (string, error) {
+	nodes, err := c.GetCandidateMembers(archs, group, allowedGroups)
+	if err != nil {
+		return "", err
+	}
+
+	name := ""
+	containers := -1
+	isDefaultArchChosen := false
+	for _, node := range nodes {
+		supported, err := node.Architectures()
+		if err != nil {
+			return "", err
 		}
+
+		isDefaultArch := shared.IntInSlice(defaultArch, supported)
 		if !isDefaultArch && isDefaultArchChosen {
 			continue
 		}
 
-		// Fetch the number of instances already created on this node.
-		created, err := query.Count(c.tx, "instances", "node_id=?", node.ID)
+		count, err := c.GetNodeInstancesCount(node.ID)
 		if err != nil {
-			return "", fmt.Errorf("Failed to get instances count: %w", err)
-		}
-
-		// Fetch the number of instances currently being created on this node.
-		pending, err := query.Count(
-			c.tx, "operations", "node_id=? AND type=?", node.ID, OperationInstanceCreate)
-		if err != nil {
-			return "", fmt.Errorf("Failed to get pending instances count: %w", err)
+			return "", err
 		}
 
-		count := created + pending
 		if containers == -1 || count < containers || (isDefaultArch == true && isDefaultArchChosen == false) {
 			containers = count
 			name = node.Name
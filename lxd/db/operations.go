@@ -51,6 +51,16 @@ SELECT DISTINCT nodes.address
 	return query.SelectStrings(c.tx, stmt, project)
 }
 
+// GetNodesWithOperationsAllProjects returns a list of nodes that have operations in any project.
+func (c *ClusterTx) GetNodesWithOperationsAllProjects() ([]string, error) {
+	stmt := `
+SELECT DISTINCT nodes.address
+  FROM operations
+  JOIN nodes ON nodes.id = operations.node_id
+`
+	return query.SelectStrings(c.tx, stmt)
+}
+
 // GetOperationsOfType returns a list operations that belong to the specified project and have the desired type.
 func (c *ClusterTx) GetOperationsOfType(projectName string, opType OperationType) ([]Operation, error) {
 	var ops []Operation
@@ -67,6 +67,14 @@ const (
 	OperationClusterMemberRestore
 	OperationCertificateAddToken
 	OperationRemoveOrphanedOperations
+	OperationClusterRollingRestart
+	OperationInstanceNetworkPause
+	OperationInstanceNetworkResume
+	OperationVolatileKeysCleanup
+	OperationStoragePoolDiscard
+	OperationInstanceRebuild
+	OperationInstancePublish
+	OperationTrashExpire
 )
 
 // Description return a human-readable description of the operation type.
@@ -182,6 +190,22 @@ func (t OperationType) Description() string {
 		return "Restoring cluster member"
 	case OperationRemoveOrphanedOperations:
 		return "Remove orphaned operations"
+	case OperationClusterRollingRestart:
+		return "Restarting cluster members"
+	case OperationInstanceNetworkPause:
+		return "Pausing instance network"
+	case OperationInstanceNetworkResume:
+		return "Resuming instance network"
+	case OperationVolatileKeysCleanup:
+		return "Cleaning up stale volatile keys"
+	case OperationStoragePoolDiscard:
+		return "Discarding unused storage pool blocks"
+	case OperationInstanceRebuild:
+		return "Rebuilding instance"
+	case OperationInstancePublish:
+		return "Publishing instance"
+	case OperationTrashExpire:
+		return "Cleaning up expired trash"
 	default:
 		return "Executing operation"
 	}
@@ -204,6 +228,10 @@ func (t OperationType) Permission() string {
 		return "operate-containers"
 	case OperationInstanceUnfreeze:
 		return "operate-containers"
+	case OperationInstanceNetworkPause:
+		return "operate-containers"
+	case OperationInstanceNetworkResume:
+		return "operate-containers"
 	case OperationInstanceStart:
 		return "operate-containers"
 	case OperationInstanceStop:
@@ -237,6 +265,8 @@ func (t OperationType) Permission() string {
 		return "manage-containers"
 	case OperationSnapshotRestore:
 		return "manage-containers"
+	case OperationInstanceRebuild:
+		return "manage-containers"
 
 	case OperationImageDownload:
 		return "manage-images"
@@ -250,6 +280,8 @@ func (t OperationType) Permission() string {
 		return "manage-images"
 	case OperationImagesSynchronize:
 		return "manage-images"
+	case OperationInstancePublish:
+		return "manage-images"
 
 	case OperationCustomVolumeSnapshotsExpire:
 		return "operate-volumes"
@@ -0,0 +1,291 @@
+//go:build linux && cgo && !agent
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetRBACRoleNames returns the names of all local RBAC roles.
+func (c *Cluster) GetRBACRoleNames() ([]string, error) {
+	var names []string
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM rbac_roles ORDER BY name")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetRBACRole returns the ID and info of the local RBAC role with the given name.
+func (c *Cluster) GetRBACRole(name string) (int64, *api.RBACRole, error) {
+	var roleID int64 = int64(-1)
+	var role api.RBACRole
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		err := tx.tx.QueryRow("SELECT id, name, description, admin FROM rbac_roles WHERE name = ?", name).Scan(&roleID, &role.Name, &role.Description, &role.Admin)
+		if errors.Is(err, sql.ErrNoRows) {
+			return api.StatusErrorf(http.StatusNotFound, "RBAC role not found")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		role.Permissions, err = query.SelectStrings(tx.tx, "SELECT permission FROM rbac_roles_permissions WHERE role_id = ? ORDER BY permission", roleID)
+		if err != nil {
+			return err
+		}
+
+		role.Projects, err = query.SelectStrings(tx.tx, `
+		SELECT projects.name FROM rbac_roles_projects
+		JOIN projects ON projects.id = rbac_roles_projects.project_id
+		WHERE rbac_roles_projects.role_id = ?
+		ORDER BY projects.name
+		`, roleID)
+		if err != nil {
+			return err
+		}
+
+		role.ProjectPatterns, err = query.SelectStrings(tx.tx, "SELECT pattern FROM rbac_roles_project_patterns WHERE role_id = ? ORDER BY pattern", roleID)
+		if err != nil {
+			return err
+		}
+
+		role.Users, err = query.SelectStrings(tx.tx, "SELECT username FROM rbac_roles_users WHERE role_id = ? ORDER BY username", roleID)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, nil, err
+	}
+
+	return roleID, &role, nil
+}
+
+// CreateRBACRole creates a new local RBAC role.
+func (c *Cluster) CreateRBACRole(info *api.RBACRolesPost) (int64, error) {
+	var roleID int64
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO rbac_roles (name, description, admin) VALUES (?, ?, ?)", info.Name, info.Description, info.Admin)
+		if err != nil {
+			return err
+		}
+
+		roleID, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		return rbacRoleSetRelations(tx.tx, roleID, &info.RBACRolePut)
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return roleID, nil
+}
+
+// UpdateRBACRole updates the local RBAC role with the given ID.
+func (c *Cluster) UpdateRBACRole(roleID int64, info *api.RBACRolePut) error {
+	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE rbac_roles SET description = ?, admin = ? WHERE id = ?", info.Description, info.Admin, roleID)
+		if err != nil {
+			return err
+		}
+
+		for _, table := range []string{"rbac_roles_permissions", "rbac_roles_project_patterns", "rbac_roles_projects", "rbac_roles_users"} {
+			_, err = tx.tx.Exec("DELETE FROM "+table+" WHERE role_id = ?", roleID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return rbacRoleSetRelations(tx.tx, roleID, info)
+	})
+}
+
+// RenameRBACRole renames the local RBAC role with the given ID.
+func (c *Cluster) RenameRBACRole(roleID int64, newName string) error {
+	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE rbac_roles SET name = ? WHERE id = ?", newName, roleID)
+		return err
+	})
+}
+
+// DeleteRBACRole deletes the local RBAC role with the given ID.
+func (c *Cluster) DeleteRBACRole(roleID int64) error {
+	return c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		res, err := tx.tx.Exec("DELETE FROM rbac_roles WHERE id = ?", roleID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected <= 0 {
+			return api.StatusErrorf(http.StatusNotFound, "RBAC role not found")
+		}
+
+		return nil
+	})
+}
+
+// GetRBACUserAccess returns whether any local RBAC role is assigned to one of the role users (a user name or a
+// prefixed group name), whether those roles grant administrative access and the permissions they grant keyed by
+// project name and by project name pattern.
+func (c *Cluster) GetRBACUserAccess(roleUsers []string) (bool, bool, map[string][]string, map[string][]string, error) {
+	found := false
+	admin := false
+	projects := map[string][]string{}
+	projectPatterns := map[string][]string{}
+
+	err := c.Transaction(context.TODO(), func(ctx context.Context, tx *ClusterTx) error {
+		args := make([]any, 0, len(roleUsers))
+		for _, roleUser := range roleUsers {
+			args = append(args, roleUser)
+		}
+
+		q := fmt.Sprintf(`
+		SELECT DISTINCT rbac_roles.id, rbac_roles.admin FROM rbac_roles
+		JOIN rbac_roles_users ON rbac_roles_users.role_id = rbac_roles.id
+		WHERE rbac_roles_users.username IN %s
+		`, query.Params(len(args)))
+
+		roleIDs := []int64{}
+		err := tx.QueryScan(q, func(scan func(dest ...any) error) error {
+			var roleID int64
+			var roleAdmin bool
+
+			err := scan(&roleID, &roleAdmin)
+			if err != nil {
+				return err
+			}
+
+			found = true
+			admin = admin || roleAdmin
+			roleIDs = append(roleIDs, roleID)
+
+			return nil
+		}, args...)
+		if err != nil {
+			return err
+		}
+
+		for _, roleID := range roleIDs {
+			permissions, err := query.SelectStrings(tx.tx, "SELECT permission FROM rbac_roles_permissions WHERE role_id = ?", roleID)
+			if err != nil {
+				return err
+			}
+
+			projectNames, err := query.SelectStrings(tx.tx, `
+			SELECT projects.name FROM rbac_roles_projects
+			JOIN projects ON projects.id = rbac_roles_projects.project_id
+			WHERE rbac_roles_projects.role_id = ?
+			`, roleID)
+			if err != nil {
+				return err
+			}
+
+			for _, projectName := range projectNames {
+				for _, permission := range permissions {
+					if !shared.StringInSlice(permission, projects[projectName]) {
+						projects[projectName] = append(projects[projectName], permission)
+					}
+				}
+			}
+
+			patterns, err := query.SelectStrings(tx.tx, "SELECT pattern FROM rbac_roles_project_patterns WHERE role_id = ?", roleID)
+			if err != nil {
+				return err
+			}
+
+			for _, pattern := range patterns {
+				for _, permission := range permissions {
+					if !shared.StringInSlice(permission, projectPatterns[pattern]) {
+						projectPatterns[pattern] = append(projectPatterns[pattern], permission)
+					}
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, false, nil, nil, err
+	}
+
+	for _, permissions := range projects {
+		sort.Strings(permissions)
+	}
+
+	for _, permissions := range projectPatterns {
+		sort.Strings(permissions)
+	}
+
+	return found, admin, projects, projectPatterns, nil
+}
+
+// rbacRoleSetRelations records the permissions, projects, project patterns and users of a local RBAC role.
+func rbacRoleSetRelations(tx *sql.Tx, roleID int64, info *api.RBACRolePut) error {
+	for _, permission := range info.Permissions {
+		_, err := tx.Exec("INSERT INTO rbac_roles_permissions (role_id, permission) VALUES (?, ?)", roleID, permission)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, projectName := range info.Projects {
+		var projectID int64
+		err := tx.QueryRow("SELECT id FROM projects WHERE name = ?", projectName).Scan(&projectID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return api.StatusErrorf(http.StatusBadRequest, "Project %q doesn't exist", projectName)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO rbac_roles_projects (role_id, project_id) VALUES (?, ?)", roleID, projectID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range info.ProjectPatterns {
+		_, err := tx.Exec("INSERT INTO rbac_roles_project_patterns (role_id, pattern) VALUES (?, ?)", roleID, pattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, username := range info.Users {
+		_, err := tx.Exec("INSERT INTO rbac_roles_users (role_id, username) VALUES (?, ?)", roleID, username)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
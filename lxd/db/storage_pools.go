@@ -238,6 +238,18 @@ func (c *ClusterTx) GetNonPendingStoragePoolsNamesToIDs() (map[string]int64, err
 	return ids, nil
 }
 
+// GetStoragePoolCreatedNodes returns the names of the members on which the given storage pool has been created.
+func (c *ClusterTx) GetStoragePoolCreatedNodes(poolName string) ([]string, error) {
+	q := `
+SELECT nodes.name FROM nodes
+  JOIN storage_pools_nodes ON storage_pools_nodes.node_id = nodes.id
+  JOIN storage_pools ON storage_pools.id = storage_pools_nodes.storage_pool_id
+WHERE storage_pools.name = ? AND storage_pools_nodes.state = ?
+`
+
+	return query.SelectStrings(c.tx, q, poolName, storagePoolCreated)
+}
+
 // UpdateStoragePoolAfterNodeJoin adds a new entry in the storage_pools_nodes table.
 //
 // It should only be used when a new node joins the cluster, when it's safe to
@@ -692,6 +704,55 @@ func (c *Cluster) GetStoragePoolWithID(poolID int) (int64, *api.StoragePool, map
 	return c.getStoragePool(true, "id=?", poolID)
 }
 
+// GetCreatedStoragePools returns all the storage pools that are in the created state.
+func (c *ClusterTx) GetCreatedStoragePools() ([]api.StoragePool, error) {
+	q := "SELECT id, name, driver, description, state FROM storage_pools WHERE state=? ORDER BY name"
+
+	var poolIDs []int64
+	pools := []api.StoragePool{}
+
+	err := c.QueryScan(q, func(scan func(dest ...any) error) error {
+		var poolID int64
+		var state StoragePoolState
+		var pool api.StoragePool
+
+		err := scan(&poolID, &pool.Name, &pool.Driver, &pool.Description, &state)
+		if err != nil {
+			return err
+		}
+
+		pool.Status = StoragePoolStateToAPIStatus(state)
+
+		poolIDs = append(poolIDs, poolID)
+		pools = append(pools, pool)
+
+		return nil
+	}, storagePoolCreated)
+	if err != nil {
+		return nil, err
+	}
+
+	// Populate config and locations.
+	for i, poolID := range poolIDs {
+		pools[i].Config, err = query.SelectConfig(c.tx, "storage_pools_config", "storage_pool_id=? AND (node_id=? OR node_id IS NULL)", poolID, c.nodeID)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes, err := c.storagePoolNodes(poolID)
+		if err != nil {
+			return nil, err
+		}
+
+		pools[i].Locations = make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			pools[i].Locations = append(pools[i].Locations, node.Name)
+		}
+	}
+
+	return pools, nil
+}
+
 // GetStoragePool returns a single storage pool.
 func (c *Cluster) getStoragePool(onlyCreated bool, where string, args ...any) (int64, *api.StoragePool, map[int64]StoragePoolNode, error) {
 	var err error
@@ -249,3 +249,32 @@ func TestCreateStoragePoolVolume_Snapshot(t *testing.T) {
 	n = cluster.GetNextStorageVolumeSnapshotIndex("p2", "v1", 1, "snap%d")
 	assert.Equal(t, n, 0)
 }
+
+// Only the members on which the pool has actually been created are returned.
+func TestGetStoragePoolCreatedNodes(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	_, err := tx.CreateNode("buzz", "1.2.3.4:666")
+	require.NoError(t, err)
+
+	err = tx.CreatePendingStoragePool("none", "pool1", "dir", map[string]string{"source": "/foo"})
+	require.NoError(t, err)
+
+	err = tx.CreatePendingStoragePool("buzz", "pool1", "dir", map[string]string{"source": "/bar"})
+	require.NoError(t, err)
+
+	poolID, err := tx.GetStoragePoolID("pool1")
+	require.NoError(t, err)
+
+	names, err := tx.GetStoragePoolCreatedNodes("pool1")
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	err = tx.StoragePoolNodeCreated(poolID)
+	require.NoError(t, err)
+
+	names, err = tx.GetStoragePoolCreatedNodes("pool1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"none"}, names)
+}
@@ -56,6 +56,10 @@ const (
 	WarningInstanceTypeNotOperational
 	//WarningStoragePoolUnvailable represents a storage pool that cannot be initialized on the local server.
 	WarningStoragePoolUnvailable
+	// WarningInstanceDeviceStartFailure represents the failure of a non-required instance device to start
+	WarningInstanceDeviceStartFailure
+	// WarningHardwareDrift represents hardware that disappeared or shrunk on the local server
+	WarningHardwareDrift
 )
 
 // WarningTypeNames associates a warning code to its name.
@@ -85,6 +89,8 @@ var WarningTypeNames = map[WarningType]string{
 	WarningInstanceAutostartFailure:               "Failed to autostart instance",
 	WarningInstanceTypeNotOperational:             "Instance type not operational",
 	WarningStoragePoolUnvailable:                  "Storage pool unavailable",
+	WarningInstanceDeviceStartFailure:             "Failed to start instance device",
+	WarningHardwareDrift:                          "Hardware change detected",
 }
 
 // Severity returns the severity of the warning type.
@@ -140,6 +146,10 @@ func (t WarningType) Severity() WarningSeverity {
 		return WarningSeverityLow
 	case WarningStoragePoolUnvailable:
 		return WarningSeverityHigh
+	case WarningInstanceDeviceStartFailure:
+		return WarningSeverityModerate
+	case WarningHardwareDrift:
+		return WarningSeverityModerate
 	}
 
 	return WarningSeverityLow
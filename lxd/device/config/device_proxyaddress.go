@@ -1,5 +1,9 @@
 package config
 
+import (
+	"strings"
+)
+
 // ProxyAddress represents a proxy address configuration.
 type ProxyAddress struct {
 	ConnType string
@@ -7,3 +11,21 @@ type ProxyAddress struct {
 	Address  string
 	Ports    []uint64
 }
+
+// SplitProxyAddresses splits a comma separated list of proxy addresses.
+// As the ports of an address are comma separated too, a new address only starts when a comma is followed by a
+// protocol.
+func SplitProxyAddresses(data string) []string {
+	addrs := []string{}
+
+	for _, field := range strings.Split(data, ",") {
+		if len(addrs) > 0 && !strings.HasPrefix(field, "tcp:") && !strings.HasPrefix(field, "udp:") && !strings.HasPrefix(field, "unix:") {
+			addrs[len(addrs)-1] = addrs[len(addrs)-1] + "," + field
+			continue
+		}
+
+		addrs = append(addrs, field)
+	}
+
+	return addrs
+}
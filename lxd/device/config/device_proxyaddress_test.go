@@ -0,0 +1,24 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitProxyAddresses(t *testing.T) {
+	tests := map[string][]string{
+		"tcp:127.0.0.1:80":                        {"tcp:127.0.0.1:80"},
+		"tcp:127.0.0.1:80,443":                    {"tcp:127.0.0.1:80,443"},
+		"tcp:10.0.0.2:80,tcp:10.0.0.3:80":         {"tcp:10.0.0.2:80", "tcp:10.0.0.3:80"},
+		"udp:10.0.0.2:53,54,udp:[fd00::3]:53,54":  {"udp:10.0.0.2:53,54", "udp:[fd00::3]:53,54"},
+		"unix:/run/a.sock,unix:@b":                {"unix:/run/a.sock", "unix:@b"},
+		"tcp:10.0.0.2:8000-8002,tcp:10.0.0.3:900": {"tcp:10.0.0.2:8000-8002", "tcp:10.0.0.3:900"},
+	}
+
+	for data, expected := range tests {
+		addrs := SplitProxyAddresses(data)
+		if !reflect.DeepEqual(addrs, expected) {
+			t.Errorf("Split of %q returned %v instead of %v", data, addrs, expected)
+		}
+	}
+}
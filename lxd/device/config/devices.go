@@ -2,10 +2,21 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/lxc/lxd/shared/validate"
 )
 
+// genericRules contains the validation rules for the keys supported by all device types.
+// Device types can still define their own rules for these keys.
+var genericRules = map[string]func(value string) error{
+	"boot.priority": validate.Optional(validate.IsUint32),
+	"required":      validate.Optional(validate.IsBool),
+}
+
 // Device represents a LXD container device.
 type Device map[string]string
 
@@ -57,6 +68,16 @@ func (device Device) Validate(rules map[string]func(value string) error) error {
 			continue
 		}
 
+		validator, found := genericRules[k]
+		if found {
+			err := validator(device[k])
+			if err != nil {
+				return fmt.Errorf("Invalid value for device option %q: %w", k, err)
+			}
+
+			continue
+		}
+
 		return fmt.Errorf("Invalid device option %q", k)
 	}
 
@@ -190,6 +211,28 @@ func (list Devices) Sorted() DevicesSortable {
 	return sortable
 }
 
+// PrioritySorted returns all devices in the set sorted by descending boot.priority. The root disk always
+// goes first and devices with the same priority are kept in the order returned by Sorted.
+func (list Devices) PrioritySorted() DevicesSortable {
+	priority := func(d DeviceNamed) int64 {
+		if d.Config["type"] == "disk" && d.Config["path"] == "/" {
+			return math.MaxInt64
+		}
+
+		// Values are validated, so an unset or invalid priority is simply the lowest.
+		value, _ := strconv.ParseInt(d.Config["boot.priority"], 10, 64)
+
+		return value
+	}
+
+	sortable := list.Sorted()
+	sort.SliceStable(sortable, func(i, j int) bool {
+		return priority(sortable[i]) > priority(sortable[j])
+	})
+
+	return sortable
+}
+
 // Reversed returns the name of all devices in the set, sorted reversed.
 func (list Devices) Reversed() DevicesSortable {
 	sortable := DevicesSortable{}
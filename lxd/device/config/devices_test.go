@@ -37,3 +37,40 @@ func TestSortableDevices(t *testing.T) {
 		t.Error("devices reverse sorted incorrectly")
 	}
 }
+
+func TestDevicesPrioritySorted(t *testing.T) {
+	devices := Devices{
+		"dev1": Device{"type": "nic"},
+		"dev2": Device{"type": "nic", "boot.priority": "5"},
+		"dev3": Device{"type": "disk", "path": "/foo", "boot.priority": "10"},
+		"root": Device{"type": "disk", "path": "/"},
+		"dev4": Device{"type": "unix-char", "boot.priority": "5"},
+	}
+
+	expected := DevicesSortable{
+		DeviceNamed{Name: "root", Config: Device{"type": "disk", "path": "/"}},
+		DeviceNamed{Name: "dev3", Config: Device{"type": "disk", "path": "/foo", "boot.priority": "10"}},
+		DeviceNamed{Name: "dev2", Config: Device{"type": "nic", "boot.priority": "5"}},
+		DeviceNamed{Name: "dev4", Config: Device{"type": "unix-char", "boot.priority": "5"}},
+		DeviceNamed{Name: "dev1", Config: Device{"type": "nic"}},
+	}
+
+	result := devices.PrioritySorted()
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("devices priority sorted incorrectly: %v", result)
+	}
+}
+
+func TestDeviceValidateGenericKeys(t *testing.T) {
+	device := Device{"type": "proxy", "required": "false", "boot.priority": "1"}
+	err := device.Validate(map[string]func(value string) error{})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	device["required"] = "maybe"
+	err = device.Validate(map[string]func(value string) error{})
+	if err == nil {
+		t.Error("expected error for invalid required value")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/lxc/lxd/lxd/device/nictype"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/validate"
 )
 
@@ -119,12 +120,23 @@ func New(inst instance.Instance, state *state.State, name string, conf deviceCon
 
 	err = validate.IsDeviceName(name)
 	if err != nil {
-		return dev, err
+		return dev, api.ErrorWithType(api.ErrorTypeInvalidDeviceConfig, err)
+	}
+
+	// Render any templated config values for this instance (the device shares the same config).
+	volatile := map[string]string{}
+	if volatileGet != nil {
+		volatile = volatileGet()
+	}
+
+	err = deviceConfigRender(conf, deviceTemplateContext(inst.Name(), inst.Project(), inst.Type(), name, volatile))
+	if err != nil {
+		return dev, api.ErrorWithType(api.ErrorTypeInvalidDeviceConfig, err)
 	}
 
 	err = dev.validateConfig(inst)
 	if err != nil {
-		return dev, err
+		return dev, api.ErrorWithType(api.ErrorTypeInvalidDeviceConfig, err)
 	}
 
 	return dev, nil
@@ -136,7 +148,13 @@ func New(inst instance.Instance, state *state.State, name string, conf deviceCon
 func Validate(instConfig instance.ConfigReader, state *state.State, name string, conf deviceConfig.Device) error {
 	err := validate.IsDeviceName(name)
 	if err != nil {
-		return err
+		return api.ErrorWithType(api.ErrorTypeInvalidDeviceConfig, err)
+	}
+
+	// Check templated config values render, using placeholders for the instance specific variables.
+	err = deviceConfigRender(conf, deviceTemplateContext("instance", instConfig.Project(), instConfig.Type(), name, nil))
+	if err != nil {
+		return api.ErrorWithType(api.ErrorTypeInvalidDeviceConfig, err)
 	}
 
 	dev, err := load(nil, state, instConfig.Project(), name, conf, nil, nil)
@@ -144,7 +162,12 @@ func Validate(instConfig instance.ConfigReader, state *state.State, name string,
 		return err
 	}
 
-	return dev.validateConfig(instConfig)
+	err = dev.validateConfig(instConfig)
+	if err != nil {
+		return api.ErrorWithType(api.ErrorTypeInvalidDeviceConfig, err)
+	}
+
+	return nil
 }
 
 // LoadByType loads a device by type based on its project and config.
@@ -141,10 +141,25 @@ func DiskMount(srcPath string, dstPath string, readonly bool, recursive bool, pr
 
 	// Remount bind mounts in readonly mode if requested
 	if readonly == true && flags&unix.MS_BIND == unix.MS_BIND {
-		flags = unix.MS_RDONLY | unix.MS_BIND | unix.MS_REMOUNT
-		err = unix.Mount("", dstPath, fsName, uintptr(flags), "")
-		if err != nil {
-			return fmt.Errorf("Unable to mount %q in readonly mode: %w", dstPath, err)
+		if recursive {
+			// A remount only applies to the top mount, so make the whole tree read-only with mount_setattr.
+			attr := unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}
+			err = unix.MountSetattr(-1, dstPath, unix.AT_RECURSIVE, &attr)
+			if err != nil {
+				_ = unix.Unmount(dstPath, unix.MNT_DETACH)
+
+				if errors.Is(err, unix.ENOSYS) {
+					return fmt.Errorf("Recursive read-only bind-mounts require mount_setattr support (Linux 5.12 or later)")
+				}
+
+				return fmt.Errorf("Unable to mount %q recursively in readonly mode: %w", dstPath, err)
+			}
+		} else {
+			flags = unix.MS_RDONLY | unix.MS_BIND | unix.MS_REMOUNT
+			err = unix.Mount("", dstPath, fsName, uintptr(flags), "")
+			if err != nil {
+				return fmt.Errorf("Unable to mount %q in readonly mode: %w", dstPath, err)
+			}
 		}
 	}
 
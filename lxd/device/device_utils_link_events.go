@@ -0,0 +1,67 @@
+package device
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// LinkEvent represents a change of state of a host network interface.
+type LinkEvent struct {
+	Name    string
+	Carrier bool
+}
+
+// linkHandlers stores the event handler callbacks for link events.
+var linkHandlers = map[string]func(LinkEvent) error{}
+
+// linkMutex controls access to the linkHandlers map.
+var linkMutex sync.Mutex
+
+// linkRegisterHandler registers a handler function to be called whenever a host network interface changes state.
+func linkRegisterHandler(inst instance.Instance, deviceName string, handler func(LinkEvent) error) {
+	linkMutex.Lock()
+	defer linkMutex.Unlock()
+
+	// Null delimited string of project name, instance name and device name.
+	key := fmt.Sprintf("%s\000%s\000%s", inst.Project(), inst.Name(), deviceName)
+	linkHandlers[key] = handler
+}
+
+// linkUnregisterHandler removes a registered link handler function for a device.
+func linkUnregisterHandler(inst instance.Instance, deviceName string) {
+	linkMutex.Lock()
+	defer linkMutex.Unlock()
+
+	// Null delimited string of project name, instance name and device name.
+	key := fmt.Sprintf("%s\000%s\000%s", inst.Project(), inst.Name(), deviceName)
+	delete(linkHandlers, key)
+}
+
+// LinkRunHandlers executes any handlers registered for link events.
+func LinkRunHandlers(state *state.State, event *LinkEvent) {
+	linkMutex.Lock()
+	defer linkMutex.Unlock()
+
+	for key, hook := range linkHandlers {
+		keyParts := strings.SplitN(key, "\000", 3)
+		projectName := keyParts[0]
+		instanceName := keyParts[1]
+		deviceName := keyParts[2]
+
+		if hook == nil {
+			delete(linkHandlers, key)
+			continue
+		}
+
+		err := hook(*event)
+		if err != nil {
+			logger.Error("Link event hook failed", logger.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName, "interface": event.Name})
+			continue
+		}
+	}
+}
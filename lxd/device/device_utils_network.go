@@ -499,6 +499,111 @@ func networkSetupHostVethLimits(m deviceConfig.Device) error {
 	return nil
 }
 
+// networkSetupHostVethMirror mirrors the traffic of the host side veth to the target interface.
+// Must be called after networkSetupHostVethLimits as that clears any existing tc configuration on the veth.
+// The direction is from the instance's perspective, so instance egress traffic is mirrored from the ingress
+// qdisc of the host side veth and instance ingress traffic from its root qdisc.
+func networkSetupHostVethMirror(m deviceConfig.Device, target string) error {
+	if target == "" {
+		return nil
+	}
+
+	veth, direction, err := networkHostVethMirrorCheck(m, target)
+	if err != nil {
+		return err
+	}
+
+	// The ingress qdisc only exists already if an egress limit has been applied.
+	if shared.StringInSlice(direction, []string{"both", "egress"}) && m["limits.egress"] == "" && m["limits.max"] == "" {
+		qdisc := &ip.Qdisc{Dev: veth, Handle: "ffff:0", Ingress: true}
+		err := qdisc.Add()
+		if err != nil {
+			return fmt.Errorf("Failed to create ingress tc qdisc: %s", err)
+		}
+	}
+
+	// The root qdisc only exists already if an ingress limit has been applied.
+	// Without a default class unclassified traffic is sent directly to the device.
+	if shared.StringInSlice(direction, []string{"both", "ingress"}) && m["limits.ingress"] == "" && m["limits.max"] == "" {
+		qdiscHTB := &ip.QdiscHTB{Qdisc: ip.Qdisc{Dev: veth, Handle: "1:0", Root: true}}
+		err := qdiscHTB.Add()
+		if err != nil {
+			return fmt.Errorf("Failed to create root tc qdisc: %s", err)
+		}
+	}
+
+	return networkAddHostVethMirrorFilters(veth, direction, target)
+}
+
+// networkUpdateHostVethMirror replaces the target interface the traffic of the host side veth is mirrored to.
+// Must only be called once networkSetupHostVethMirror has set up the mirroring of the veth.
+func networkUpdateHostVethMirror(m deviceConfig.Device, target string) error {
+	veth, direction, err := networkHostVethMirrorCheck(m, target)
+	if err != nil {
+		return err
+	}
+
+	// Remove the filters mirroring to the previous target (if any).
+	for _, parent := range []string{"ffff:0", "1:0"} {
+		filter := &ip.Filter{Dev: veth, Parent: parent, Pref: "1"}
+		_ = filter.Delete()
+	}
+
+	return networkAddHostVethMirrorFilters(veth, direction, target)
+}
+
+// networkHostVethMirrorCheck checks the host side veth and mirror target interfaces exist and returns the veth name
+// along with the mirror direction.
+func networkHostVethMirrorCheck(m deviceConfig.Device, target string) (string, string, error) {
+	veth := m["host_name"]
+
+	if veth == "" || !network.InterfaceExists(veth) {
+		return "", "", fmt.Errorf("Unknown or missing host side veth device %q", veth)
+	}
+
+	if target == veth {
+		return "", "", fmt.Errorf("Cannot mirror host side veth device %q to itself", veth)
+	}
+
+	if !network.InterfaceExists(target) {
+		return "", "", fmt.Errorf("Mirror target interface %q doesn't exist", target)
+	}
+
+	direction := m["mirror.direction"]
+	if direction == "" {
+		direction = "both"
+	}
+
+	return veth, direction, nil
+}
+
+// networkAddHostVethMirrorFilters adds the tc filters mirroring the traffic of the host side veth to the target.
+// The filters use the highest preference so the packets are mirrored before any limit is applied, and continue
+// the classification once mirrored so the limits still apply.
+func networkAddHostVethMirrorFilters(veth string, direction string, target string) error {
+	mirred := &ip.ActionMirred{Dev: target, Control: "continue"}
+
+	// Mirror traffic sent from the instance.
+	if shared.StringInSlice(direction, []string{"both", "egress"}) {
+		filter := &ip.U32Filter{Filter: ip.Filter{Dev: veth, Parent: "ffff:0", Protocol: "all", Pref: "1"}, Value: "0", Mask: "0", Actions: []ip.Action{mirred}}
+		err := filter.Add()
+		if err != nil {
+			return fmt.Errorf("Failed to create ingress mirror tc filter: %s", err)
+		}
+	}
+
+	// Mirror traffic sent to the instance.
+	if shared.StringInSlice(direction, []string{"both", "ingress"}) {
+		filter := &ip.U32Filter{Filter: ip.Filter{Dev: veth, Parent: "1:0", Protocol: "all", Pref: "1"}, Value: "0", Mask: "0", Actions: []ip.Action{mirred}}
+		err := filter.Add()
+		if err != nil {
+			return fmt.Errorf("Failed to create root mirror tc filter: %s", err)
+		}
+	}
+
+	return nil
+}
+
 // networkValidGateway validates the gateway value.
 func networkValidGateway(value string) error {
 	if shared.StringInSlice(value, []string{"none", "auto"}) {
@@ -74,3 +74,24 @@ func ProxyParseAddr(data string) (*deviceConfig.ProxyAddress, error) {
 
 	return newProxyAddr, nil
 }
+
+// ProxyParseAddrs validates a comma separated list of proxy addresses and parses each of them.
+// All the addresses must use the same protocol and number of ports so any of them can serve a connection.
+func ProxyParseAddrs(data string) ([]*deviceConfig.ProxyAddress, error) {
+	addrs := []*deviceConfig.ProxyAddress{}
+
+	for _, field := range deviceConfig.SplitProxyAddresses(data) {
+		addr, err := ProxyParseAddr(field)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(addrs) > 0 && (addr.ConnType != addrs[0].ConnType || addr.Abstract != addrs[0].Abstract || len(addr.Ports) != len(addrs[0].Ports)) {
+			return nil, fmt.Errorf("All addresses must use the same protocol and number of ports")
+		}
+
+		addrs = append(addrs, addr)
+	}
+
+	return addrs, nil
+}
@@ -0,0 +1,106 @@
+package device
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/flosch/pongo2"
+
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+)
+
+// deviceTemplateIndexRegex matches the number at the end of an instance name.
+var deviceTemplateIndexRegex = regexp.MustCompile(`[0-9]+$`)
+
+// deviceTemplateContext returns the variables available to templated device config values.
+// The volatile keys of the device have their dots replaced with underscores so they can be used as variables.
+func deviceTemplateContext(instName string, projectName string, instType instancetype.Type, devName string, volatile map[string]string) pongo2.Context {
+	index := 0
+	match := deviceTemplateIndexRegex.FindString(instName)
+	if match != "" {
+		index, _ = strconv.Atoi(match)
+	}
+
+	volatileVars := map[string]string{}
+	for k, v := range volatile {
+		volatileVars[strings.Replace(k, ".", "_", -1)] = v
+	}
+
+	return pongo2.Context{
+		"instance": map[string]any{
+			"name":    instName,
+			"project": projectName,
+			"type":    instType.String(),
+			"index":   index,
+		},
+		"device": map[string]any{
+			"name": devName,
+		},
+		"volatile": volatileVars,
+	}
+}
+
+// deviceTemplateLoader is a pongo2 template loader which refuses to load any template, so that templated device
+// values can't access files on the host.
+type deviceTemplateLoader struct{}
+
+// Abs returns the name unchanged.
+func (l deviceTemplateLoader) Abs(base string, name string) string {
+	return name
+}
+
+// Get always fails.
+func (l deviceTemplateLoader) Get(path string) (io.Reader, error) {
+	return nil, fmt.Errorf("Loading templates isn't allowed in device config")
+}
+
+// deviceTemplateIsTemplated returns whether the device config value is a template.
+func deviceTemplateIsTemplated(value string) bool {
+	return strings.Contains(value, "{{") || strings.Contains(value, "{%")
+}
+
+// deviceTemplateSet returns a sandboxed template set for rendering device config values, without access to
+// files on the host.
+func deviceTemplateSet() *pongo2.TemplateSet {
+	set := pongo2.NewSet("device", deviceTemplateLoader{})
+
+	for _, tag := range []string{"include", "ssi", "import", "extends"} {
+		_ = set.BanTag(tag)
+	}
+
+	return set
+}
+
+// deviceConfigRender renders the templated values of a device config in place.
+// Values are only rendered once, so rendered values containing template markers are left as is.
+func deviceConfigRender(conf deviceConfig.Device, ctx pongo2.Context) error {
+	var set *pongo2.TemplateSet
+
+	for k, v := range conf {
+		if !deviceTemplateIsTemplated(v) {
+			continue
+		}
+
+		if set == nil {
+			set = deviceTemplateSet()
+		}
+
+		tpl, err := set.FromString("{% autoescape off %}" + v + "{% endautoescape %}")
+		if err != nil {
+			return fmt.Errorf("Failed parsing template of %q: %w", k, err)
+		}
+
+		rendered, err := tpl.Execute(ctx)
+		if err != nil {
+			return fmt.Errorf("Failed rendering template of %q: %w", k, err)
+		}
+
+		conf[k] = rendered
+	}
+
+	return nil
+}
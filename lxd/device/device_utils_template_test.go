@@ -0,0 +1,53 @@
+package device
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+)
+
+func TestDeviceConfigRender(t *testing.T) {
+	ctx := deviceTemplateContext("web3", "default", instancetype.Container, "http", map[string]string{"last_state.hwaddr": "00:16:3e:00:00:01"})
+
+	conf := deviceConfig.Device{
+		"type":    "proxy",
+		"listen":  "tcp:0.0.0.0:{{ 8000 + instance.index }}",
+		"connect": "tcp:127.0.0.1:80",
+		"bind":    "{{ instance.project }}-{{ instance.name }}-{{ device.name }}",
+		"path":    "/srv/{{ volatile.last_state_hwaddr }}",
+	}
+
+	err := deviceConfigRender(conf, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp:0.0.0.0:8003", conf["listen"])
+	assert.Equal(t, "tcp:127.0.0.1:80", conf["connect"])
+	assert.Equal(t, "default-web3-http", conf["bind"])
+	assert.Equal(t, "/srv/00:16:3e:00:00:01", conf["path"])
+
+	// Check instances without a trailing number get index 0.
+	conf = deviceConfig.Device{"listen": "tcp:0.0.0.0:{{ 8000 + instance.index }}"}
+	err = deviceConfigRender(conf, deviceTemplateContext("web", "default", instancetype.Container, "http", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp:0.0.0.0:8000", conf["listen"])
+
+	// Check invalid templates are rejected.
+	conf = deviceConfig.Device{"listen": "tcp:0.0.0.0:{{ instance.index"}
+	err = deviceConfigRender(conf, ctx)
+	assert.Error(t, err)
+
+	// Check templates can't access host files.
+	for _, value := range []string{`{% include "/etc/hostname" %}`, `{% ssi "/etc/hostname" %}`, `{% import "/etc/hostname" foo %}`, `{% extends "/etc/hostname" %}`} {
+		conf = deviceConfig.Device{"source": value}
+		err = deviceConfigRender(conf, ctx)
+		assert.Error(t, err, value)
+	}
+
+	// Check rendered values aren't rendered again.
+	conf = deviceConfig.Device{"source": `{{ "{{ instance.name }}" }}`}
+	err = deviceConfigRender(conf, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "{{ instance.name }}", conf["source"])
+}
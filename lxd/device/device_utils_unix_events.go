@@ -25,13 +25,14 @@ type UnixSubscription struct {
 	Handler func(UnixEvent) (*deviceConfig.RunConfig, error) // The function to run when an event occurs.
 }
 
-// unixHandlers stores the event handler callbacks for Unix events.
-var unixHandlers = map[string]UnixSubscription{}
+// unixHandlers stores the event handler callbacks for Unix events, indexed by device and then by path.
+var unixHandlers = map[string]map[string]UnixSubscription{}
 
 // unixMutex controls access to the unixHandlers map.
 var unixMutex sync.Mutex
 
 // unixRegisterHandler registers a handler function to be called whenever a Unix device event occurs.
+// A device can register handlers for multiple paths, registering the same path again replaces its handler.
 func unixRegisterHandler(s *state.State, inst instance.Instance, deviceName, path string, handler func(UnixEvent) (*deviceConfig.RunConfig, error)) error {
 	if path == "" || handler == nil {
 		return fmt.Errorf("Invalid subscription")
@@ -42,11 +43,22 @@ func unixRegisterHandler(s *state.State, inst instance.Instance, deviceName, pat
 
 	// Null delimited string of project name, instance name and device name.
 	key := fmt.Sprintf("%s\000%s\000%s", inst.Project(), inst.Name(), deviceName)
-	unixHandlers[key] = UnixSubscription{
+	_, exists := unixHandlers[key]
+	if !exists {
+		unixHandlers[key] = map[string]UnixSubscription{}
+	}
+
+	_, watched := unixHandlers[key][path]
+	unixHandlers[key][path] = UnixSubscription{
 		Path:    path,
 		Handler: handler,
 	}
 
+	// The path is already being watched for this device, only the handler needed replacing.
+	if watched {
+		return nil
+	}
+
 	identifier := fmt.Sprintf("%d_%s", inst.ID(), deviceName)
 
 	// Add inotify watcher to its nearest existing ancestor.
@@ -56,6 +68,7 @@ func unixRegisterHandler(s *state.State, inst instance.Instance, deviceName, pat
 		return true
 	})
 	if err != nil {
+		delete(unixHandlers[key], path)
 		return fmt.Errorf("Failed to add %q to watch targets: %w", filepath.Clean(path), err)
 	}
 
@@ -63,7 +76,7 @@ func unixRegisterHandler(s *state.State, inst instance.Instance, deviceName, pat
 	return nil
 }
 
-// unixUnregisterHandler removes a registered Unix handler function for a device.
+// unixUnregisterHandler removes all registered Unix handler functions for a device.
 func unixUnregisterHandler(s *state.State, inst instance.Instance, deviceName string) error {
 	unixMutex.Lock()
 	defer unixMutex.Unlock()
@@ -71,19 +84,21 @@ func unixUnregisterHandler(s *state.State, inst instance.Instance, deviceName st
 	// Null delimited string of project name, instance name and device name.
 	key := fmt.Sprintf("%s\000%s\000%s", inst.Project(), inst.Name(), deviceName)
 
-	sub, exists := unixHandlers[key]
+	subs, exists := unixHandlers[key]
 	if !exists {
 		return nil
 	}
 
-	// Remove active subscription for this device.
+	// Remove active subscriptions for this device.
 	delete(unixHandlers, key)
 
 	identifier := fmt.Sprintf("%d_%s", inst.ID(), deviceName)
 
-	err := s.DevMonitor.Unwatch(sub.Path, identifier)
-	if err != nil {
-		return fmt.Errorf("Failed to remove %q from inotify targets: %w", sub.Path, err)
+	for _, sub := range subs {
+		err := s.DevMonitor.Unwatch(sub.Path, identifier)
+		if err != nil {
+			return fmt.Errorf("Failed to remove %q from inotify targets: %w", sub.Path, err)
+		}
 	}
 
 	return nil
@@ -94,44 +109,46 @@ func unixRunHandlers(state *state.State, event *UnixEvent) {
 	unixMutex.Lock()
 	defer unixMutex.Unlock()
 
-	for key, sub := range unixHandlers {
+	for key, subs := range unixHandlers {
 		keyParts := strings.SplitN(key, "\000", 3)
 		projectName := keyParts[0]
 		instanceName := keyParts[1]
 		deviceName := keyParts[2]
 
-		// Delete subscription if no handler function defined.
-		if sub.Handler == nil {
-			delete(unixHandlers, key)
-			continue
-		}
-
-		// Don't execute handler if subscription path and event paths don't match.
-		if sub.Path != event.Path {
-			continue
-		}
-
-		// Run handler function.
-		runConf, err := sub.Handler(*event)
-		if err != nil {
-			logger.Error("Unix event hook failed", logger.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName, "path": sub.Path})
-			continue
-		}
+		for path, sub := range subs {
+			// Delete subscription if no handler function defined.
+			if sub.Handler == nil {
+				delete(subs, path)
+				continue
+			}
 
-		// If runConf supplied, load instance and call its Unix event handler function so
-		// any instance specific device actions can occur.
-		if runConf != nil {
-			instance, err := instance.LoadByProjectAndName(state, projectName, instanceName)
-			if err != nil {
-				logger.Error("Unix event loading instance failed", logger.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
+			// Don't execute handler if subscription path and event paths don't match.
+			if sub.Path != event.Path {
 				continue
 			}
 
-			err = instance.DeviceEventHandler(runConf)
+			// Run handler function.
+			runConf, err := sub.Handler(*event)
 			if err != nil {
-				logger.Error("Unix event instance handler failed", logger.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
+				logger.Error("Unix event hook failed", logger.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName, "path": sub.Path})
 				continue
 			}
+
+			// If runConf supplied, load instance and call its Unix event handler function so
+			// any instance specific device actions can occur.
+			if runConf != nil {
+				instance, err := instance.LoadByProjectAndName(state, projectName, instanceName)
+				if err != nil {
+					logger.Error("Unix event loading instance failed", logger.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
+					continue
+				}
+
+				err = instance.DeviceEventHandler(runConf)
+				if err != nil {
+					logger.Error("Unix event instance handler failed", logger.Ctx{"err": err, "project": projectName, "instance": instanceName, "device": deviceName})
+					continue
+				}
+			}
 		}
 	}
 }
@@ -143,7 +143,7 @@ func (d *disk) validateConfig(instConf instance.ConfigReader) error {
 	// These come from https://www.kernel.org/doc/Documentation/filesystems/sharedsubtree.txt
 	propagationTypes := []string{"", "private", "shared", "slave", "unbindable", "rshared", "rslave", "runbindable", "rprivate"}
 	validatePropagation := func(input string) error {
-		if !shared.StringInSlice(d.config["bind"], propagationTypes) {
+		if !shared.StringInSlice(input, propagationTypes) {
 			return fmt.Errorf("Invalid propagation value. Must be one of: %s", strings.Join(propagationTypes, ", "))
 		}
 
@@ -204,10 +204,6 @@ func (d *disk) validateConfig(instConf instance.ConfigReader) error {
 		return fmt.Errorf("The recursive option is only supported for additional bind-mounted paths")
 	}
 
-	if shared.IsTrue(d.config["recursive"]) && shared.IsTrue(d.config["readonly"]) {
-		return fmt.Errorf("Recursive read-only bind-mounts aren't currently supported by the kernel")
-	}
-
 	// Check ceph options are only used when ceph or cephfs type source is specified.
 	if !shared.StringHasPrefix(d.config["source"], "ceph:", "cephfs:") && (d.config["ceph.cluster_name"] != "" || d.config["ceph.user_name"] != "") {
 		return fmt.Errorf("Invalid options ceph.cluster_name/ceph.user_name for source %q", d.config["source"])
@@ -581,12 +577,18 @@ func (d *disk) startContainer() (*deviceConfig.RunConfig, error) {
 		if d.config["pool"] != "" {
 			var err error
 			var revertFunc func()
+			var idmapped bool
 
-			revertFunc, srcPath, err = d.mountPoolVolume()
+			revertFunc, srcPath, idmapped, err = d.mountPoolVolume()
 			if err != nil {
 				return nil, diskSourceNotFoundError{msg: "Failed mounting volume", err: err}
 			}
 			revert.Add(revertFunc)
+
+			// The volume isn't shifted on disk, so have it shifted by the mount instead.
+			if idmapped {
+				ownerShift = deviceConfig.MountOwnerShiftDynamic
+			}
 		}
 
 		// Mount the source in the instance devices directory.
@@ -748,7 +750,7 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 					return &runConf, nil
 				}
 
-				revertFunc, mount.DevPath, err = d.mountPoolVolume()
+				revertFunc, mount.DevPath, _, err = d.mountPoolVolume()
 				if err != nil {
 					return nil, diskSourceNotFoundError{msg: "Failed mounting volume", err: err}
 				}
@@ -1125,7 +1127,8 @@ func (w *cgroupWriter) Set(version cgroup.Backend, controller string, key string
 
 // mountPoolVolume mounts the pool volume specified in d.config["source"] from pool specified in d.config["pool"]
 // and return the mount path. If the instance type is container volume will be shifted if needed.
-func (d *disk) mountPoolVolume() (func(), string, error) {
+// Returns whether the volume should be shifted using an idmapped mount rather than on disk.
+func (d *disk) mountPoolVolume() (func(), string, bool, error) {
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -1137,7 +1140,7 @@ func (d *disk) mountPoolVolume() (func(), string, error) {
 	// Currently, <type> must either be empty or "custom".
 	// We do not yet support instance mounts.
 	if filepath.IsAbs(d.config["source"]) {
-		return nil, "", fmt.Errorf(`When the "pool" property is set "source" must specify the name of a volume, not a path`)
+		return nil, "", false, fmt.Errorf(`When the "pool" property is set "source" must specify the name of a volume, not a path`)
 	}
 
 	volumeTypeName := ""
@@ -1155,7 +1158,7 @@ func (d *disk) mountPoolVolume() (func(), string, error) {
 	// Check volume type name is custom.
 	switch volumeTypeName {
 	case db.StoragePoolVolumeTypeNameContainer:
-		return nil, "", fmt.Errorf("Using instance storage volumes is not supported")
+		return nil, "", false, fmt.Errorf("Using instance storage volumes is not supported")
 	case "":
 		// We simply received the name of a storage volume.
 		volumeTypeName = db.StoragePoolVolumeTypeNameCustom
@@ -1163,15 +1166,15 @@ func (d *disk) mountPoolVolume() (func(), string, error) {
 	case db.StoragePoolVolumeTypeNameCustom:
 		break
 	case db.StoragePoolVolumeTypeNameImage:
-		return nil, "", fmt.Errorf("Using image storage volumes is not supported")
+		return nil, "", false, fmt.Errorf("Using image storage volumes is not supported")
 	default:
-		return nil, "", fmt.Errorf("Unknown storage type prefix %q found", volumeTypeName)
+		return nil, "", false, fmt.Errorf("Unknown storage type prefix %q found", volumeTypeName)
 	}
 
 	// Only custom volumes can be attached currently.
 	storageProjectName, err := project.StorageVolumeProject(d.state.DB.Cluster, d.inst.Project(), db.StoragePoolVolumeTypeCustom)
 	if err != nil {
-		return nil, "", err
+		return nil, "", false, err
 	}
 
 	volStorageName := project.StorageVolume(storageProjectName, volumeName)
@@ -1179,36 +1182,71 @@ func (d *disk) mountPoolVolume() (func(), string, error) {
 
 	err = d.pool.MountCustomVolume(storageProjectName, volumeName, nil)
 	if err != nil {
-		return nil, "", fmt.Errorf("Failed mounting storage volume %q of type %q on storage pool %q: %w", volumeName, volumeTypeName, d.pool.Name(), err)
+		return nil, "", false, fmt.Errorf("Failed mounting storage volume %q of type %q on storage pool %q: %w", volumeName, volumeTypeName, d.pool.Name(), err)
 	}
 	revert.Add(func() { _, _ = d.pool.UnmountCustomVolume(storageProjectName, volumeName, nil) })
 
 	_, vol, err := d.state.DB.Cluster.GetLocalStoragePoolVolume(storageProjectName, volumeName, db.StoragePoolVolumeTypeCustom, d.pool.ID())
 	if err != nil {
-		return nil, "", fmt.Errorf("Failed to fetch local storage volume record: %w", err)
+		return nil, "", false, fmt.Errorf("Failed to fetch local storage volume record: %w", err)
 	}
 
+	idmapped := false
 	if d.inst.Type() == instancetype.Container {
-		if vol.ContentType == db.StoragePoolVolumeContentTypeNameFS {
+		if vol.ContentType != db.StoragePoolVolumeContentTypeNameFS {
+			return nil, "", false, fmt.Errorf("Only filesystem volumes are supported for containers")
+		}
+
+		idmapped = d.canIdmapPoolVolume(vol, srcPath)
+		if !idmapped {
 			err = d.storagePoolVolumeAttachShift(storageProjectName, d.pool.Name(), volumeName, db.StoragePoolVolumeTypeCustom, srcPath)
 			if err != nil {
-				return nil, "", fmt.Errorf("Failed shifting storage volume %q of type %q on storage pool %q: %w", volumeName, volumeTypeName, d.pool.Name(), err)
+				return nil, "", false, fmt.Errorf("Failed shifting storage volume %q of type %q on storage pool %q: %w", volumeName, volumeTypeName, d.pool.Name(), err)
 			}
-		} else {
-			return nil, "", fmt.Errorf("Only filesystem volumes are supported for containers")
 		}
 	}
 
 	if vol.ContentType == db.StoragePoolVolumeContentTypeNameBlock {
 		srcPath, err = d.pool.GetCustomVolumeDisk(storageProjectName, volumeName)
 		if err != nil {
-			return nil, "", fmt.Errorf("Failed to get disk path: %w", err)
+			return nil, "", false, fmt.Errorf("Failed to get disk path: %w", err)
 		}
 	}
 
 	revertExternal := revert.Clone() // Clone before calling revert.Success() so we can return the Fail func.
 	revert.Success()
-	return revertExternal.Fail, srcPath, err
+	return revertExternal.Fail, srcPath, idmapped, err
+}
+
+// canIdmapPoolVolume returns whether the custom volume can be shifted for the container using an idmapped mount
+// rather than by shifting its files on disk. This requires the volume to not be shifted on disk already and the
+// filesystem backing it to support idmapped mounts, otherwise the on-disk shifting is used as before.
+func (d *disk) canIdmapPoolVolume(vol *api.StorageVolume, srcPath string) bool {
+	// Shifted volumes already use a shifting mount and unmapped volumes mustn't be shifted at all.
+	if shared.IsTrue(vol.Config["security.shifted"]) || shared.IsTrue(vol.Config["security.unmapped"]) {
+		return false
+	}
+
+	if d.inst.IsPrivileged() {
+		return false
+	}
+
+	// Don't mix with a volume which has been shifted on disk.
+	if vol.Config["volatile.idmap.last"] != "" && vol.Config["volatile.idmap.last"] != "[]" {
+		return false
+	}
+
+	ct, ok := d.inst.(instance.Container)
+	if !ok {
+		return false
+	}
+
+	// Only use idmapped mounts on pools where they were found to work at startup.
+	if !storagePools.IdmappedMountsSupport(d.pool.Name()) {
+		return false
+	}
+
+	return ct.IdmappedStorage(srcPath) == idmap.IdmapStorageIdmapped
 }
 
 // createDevice creates a disk device mount on host.
@@ -5,17 +5,19 @@ import "github.com/lxc/lxd/shared/validate"
 func gpuValidationRules(requiredFields []string, optionalFields []string) map[string]func(value string) error {
 	// Define a set of default validators for each field name.
 	defaultValidators := map[string]func(value string) error{
-		"vendorid":  validate.Optional(validate.IsDeviceID),
-		"productid": validate.Optional(validate.IsDeviceID),
-		"id":        validate.IsAny,
-		"pci":       validate.IsPCIAddress,
-		"uid":       unixValidUserID,
-		"gid":       unixValidUserID,
-		"mode":      unixValidOctalFileMode,
-		"mig.gi":    validate.IsUint8,
-		"mig.ci":    validate.IsUint8,
-		"mig.uuid":  gpuValidMigUUID,
-		"mdev":      validate.IsAny,
+		"vendorid":   validate.Optional(validate.IsDeviceID),
+		"productid":  validate.Optional(validate.IsDeviceID),
+		"id":         validate.IsAny,
+		"pci":        validate.IsPCIAddress,
+		"uid":        unixValidUserID,
+		"gid":        unixValidUserID,
+		"gid.card":   unixValidUserID,
+		"gid.render": unixValidUserID,
+		"mode":       unixValidOctalFileMode,
+		"mig.gi":     validate.IsUint8,
+		"mig.ci":     validate.IsUint8,
+		"mig.uuid":   gpuValidMigUUID,
+		"mdev":       validate.IsAny,
 	}
 
 	validators := map[string]func(value string) error{}
@@ -16,6 +16,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/resources"
+	"github.com/lxc/lxd/lxd/storage/filesystem"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 )
@@ -47,7 +48,7 @@ func (d *gpuPhysical) validateConfig(instConf instance.ConfigReader) error {
 	}
 
 	if instConf.Type() == instancetype.Container || instConf.Type() == instancetype.Any {
-		optionalFields = append(optionalFields, "uid", "gid", "mode")
+		optionalFields = append(optionalFields, "uid", "gid", "gid.card", "gid.render", "mode")
 	}
 
 	err := d.config.Validate(gpuValidationRules(nil, optionalFields))
@@ -85,6 +86,11 @@ func (d *gpuPhysical) validateEnvironment() error {
 	return validatePCIDevice(d.config["pci"])
 }
 
+// CanHotPlug returns whether the device can be managed whilst the instance is running.
+func (d *gpuPhysical) CanHotPlug() bool {
+	return true
+}
+
 // Start is run when the device is added to the container.
 func (d *gpuPhysical) Start() (*deviceConfig.RunConfig, error) {
 	err := d.validateEnvironment()
@@ -103,6 +109,7 @@ func (d *gpuPhysical) Start() (*deviceConfig.RunConfig, error) {
 // Returns RunConfig populated with mount info required to pass the unix-char devices into the container.
 func (d *gpuPhysical) startContainer() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{}
+	driPaths := []string{}
 	gpus, err := resources.GetGPU()
 	if err != nil {
 		return nil, err
@@ -131,10 +138,12 @@ func (d *gpuPhysical) startContainer() (*deviceConfig.RunConfig, error) {
 					return nil, err
 				}
 
-				err = unixDeviceSetupCharNum(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, major, minor, path, false, &runConf)
+				err = unixDeviceSetupCharNum(d.state, d.inst.DevicesPath(), "unix", d.name, d.driNodeConfig(path), major, minor, path, false, &runConf)
 				if err != nil {
 					return nil, err
 				}
+
+				driPaths = append(driPaths, path)
 			}
 
 			if gpu.DRM.RenderName != "" && gpu.DRM.RenderDevice != "" && shared.PathExists(filepath.Join(gpuDRIDevPath, gpu.DRM.RenderName)) {
@@ -144,10 +153,12 @@ func (d *gpuPhysical) startContainer() (*deviceConfig.RunConfig, error) {
 					return nil, err
 				}
 
-				err = unixDeviceSetupCharNum(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, major, minor, path, false, &runConf)
+				err = unixDeviceSetupCharNum(d.state, d.inst.DevicesPath(), "unix", d.name, d.driNodeConfig(path), major, minor, path, false, &runConf)
 				if err != nil {
 					return nil, err
 				}
+
+				driPaths = append(driPaths, path)
 			}
 
 			if gpu.DRM.ControlName != "" && gpu.DRM.ControlDevice != "" && shared.PathExists(filepath.Join(gpuDRIDevPath, gpu.DRM.ControlName)) {
@@ -157,10 +168,12 @@ func (d *gpuPhysical) startContainer() (*deviceConfig.RunConfig, error) {
 					return nil, err
 				}
 
-				err = unixDeviceSetupCharNum(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, major, minor, path, false, &runConf)
+				err = unixDeviceSetupCharNum(d.state, d.inst.DevicesPath(), "unix", d.name, d.driNodeConfig(path), major, minor, path, false, &runConf)
 				if err != nil {
 					return nil, err
 				}
+
+				driPaths = append(driPaths, path)
 			}
 		}
 
@@ -208,9 +221,126 @@ func (d *gpuPhysical) startContainer() (*deviceConfig.RunConfig, error) {
 		return nil, fmt.Errorf("Failed to detect requested GPU device")
 	}
 
+	// Watch the DRM nodes so they can be updated when the host driver is reloaded.
+	runConf.PostHooks = append(runConf.PostHooks, func() error {
+		for _, path := range driPaths {
+			err := unixRegisterHandler(d.state, d.inst, d.name, path, d.driNodeHandler(path))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
 	return &runConf, nil
 }
 
+// driNodeConfig returns the device config to use for the DRM node at the specified path.
+// Render nodes use the gid.render setting and card and control nodes use the gid.card setting, both defaulting
+// to the gid setting.
+func (d *gpuPhysical) driNodeConfig(path string) deviceConfig.Device {
+	nodeConfig := d.config.Clone()
+
+	gidKey := "gid.card"
+	if strings.HasPrefix(filepath.Base(path), "renderD") {
+		gidKey = "gid.render"
+	}
+
+	if d.config[gidKey] != "" {
+		nodeConfig["gid"] = d.config[gidKey]
+	}
+
+	return nodeConfig
+}
+
+// driNodeHandler returns the Unix event handler for the DRM node at the specified path.
+// When the host driver is reloaded the node is removed and re-created, possibly with a different minor number, so
+// the instance device is removed with the node and set up again with the current device number when it reappears.
+func (d *gpuPhysical) driNodeHandler(path string) func(e UnixEvent) (*deviceConfig.RunConfig, error) {
+	s := d.state
+	inst := d.inst
+	deviceName := d.name
+	nodeConfig := d.driNodeConfig(path)
+
+	return func(e UnixEvent) (*deviceConfig.RunConfig, error) {
+		devicesPath := inst.DevicesPath()
+
+		// Derive the host side path for the instance device file.
+		relativeDestPath := strings.TrimPrefix(path, "/")
+		devName := filesystem.PathNameEncode(deviceJoinPath("unix", deviceName, relativeDestPath))
+		devPath := filepath.Join(devicesPath, devName)
+
+		// removeNode populates runConf with the instructions to remove the instance device file.
+		removeNode := func(runConf *deviceConfig.RunConfig) error {
+			err := unixDeviceRemove(devicesPath, "unix", deviceName, relativeDestPath, runConf)
+			if err != nil {
+				return err
+			}
+
+			runConf.PostHooks = append(runConf.PostHooks, func() error {
+				err := unixDeviceDeleteFiles(s, devicesPath, "unix", deviceName, relativeDestPath)
+				if err != nil {
+					return fmt.Errorf("Failed to delete files for device '%s': %w", deviceName, err)
+				}
+
+				return nil
+			})
+
+			return nil
+		}
+
+		runConf := deviceConfig.RunConfig{}
+
+		if e.Action == "add" {
+			dType, major, minor, err := unixDeviceAttributes(e.Path)
+			if err != nil {
+				return nil, err
+			}
+
+			if dType != "c" {
+				return nil, fmt.Errorf("Path specified is not a unix-char device")
+			}
+
+			if shared.PathExists(devPath) {
+				_, oldMajor, oldMinor, err := unixDeviceAttributes(devPath)
+				if err == nil && oldMajor == major && oldMinor == minor {
+					return nil, nil
+				}
+
+				// The device number changed without the removal being seen, remove the stale device first.
+				staleRunConf := deviceConfig.RunConfig{}
+				err = removeNode(&staleRunConf)
+				if err != nil {
+					return nil, err
+				}
+
+				err = inst.DeviceEventHandler(&staleRunConf)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			err = unixDeviceSetupCharNum(s, devicesPath, "unix", deviceName, nodeConfig, major, minor, path, false, &runConf)
+			if err != nil {
+				return nil, err
+			}
+		} else if e.Action == "remove" {
+			// Skip if host side instance device file doesn't exist.
+			if !shared.PathExists(devPath) {
+				return nil, nil
+			}
+
+			err := removeNode(&runConf)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return &runConf, nil
+	}
+}
+
 // startVM detects the requested GPU devices and related virtual functions and rebinds them to the vfio-pci driver.
 func (d *gpuPhysical) startVM() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{}
@@ -337,7 +467,13 @@ func (d *gpuPhysical) Stop() (*deviceConfig.RunConfig, error) {
 	}
 
 	if d.inst.Type() == instancetype.Container {
-		err := unixDeviceRemove(d.inst.DevicesPath(), "unix", d.name, "", &runConf)
+		// Unregister any Unix event handlers for the DRM nodes.
+		err := unixUnregisterHandler(d.state, d.inst, d.name)
+		if err != nil {
+			return nil, err
+		}
+
+		err = unixDeviceRemove(d.inst.DevicesPath(), "unix", d.name, "", &runConf)
 		if err != nil {
 			return nil, err
 		}
@@ -352,6 +488,7 @@ func (d *gpuPhysical) postStop() error {
 		_ = d.volatileSet(map[string]string{
 			"last_state.pci.slot.name": "",
 			"last_state.pci.driver":    "",
+			"last_state.pci.port":      "",
 			"vgpu.uuid":                "",
 		})
 	}()
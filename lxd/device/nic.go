@@ -29,6 +29,7 @@ func nicValidationRules(requiredFields []string, optionalFields []string, instCo
 		"security.ipv4_filtering":              validate.IsAny,
 		"security.ipv6_filtering":              validate.IsAny,
 		"security.port_isolation":              validate.Optional(validate.IsBool),
+		"security.promiscuous":                 validate.Optional(validate.IsBool),
 		"maas.subnet.ipv4":                     validate.IsAny,
 		"maas.subnet.ipv6":                     validate.IsAny,
 		"ipv4.address":                         validate.Optional(validate.IsNetworkAddressV4),
@@ -110,15 +111,22 @@ func nicCheckNamesUnique(instConf instance.ConfigReader) error {
 	seenNICNames := []string{}
 
 	for _, devConfig := range instConf.ExpandedDevices() {
-		if devConfig["type"] != "nic" || devConfig["name"] == "" {
+		if devConfig["type"] != "nic" {
 			continue
 		}
 
-		if shared.StringInSlice(devConfig["name"], seenNICNames) {
-			return fmt.Errorf("Duplicate NIC name detected %q", devConfig["name"])
-		}
+		// Bridged NICs with failover also add a backup interface to the instance.
+		for _, nicName := range []string{devConfig["name"], devConfig["failover.name"]} {
+			if nicName == "" {
+				continue
+			}
 
-		seenNICNames = append(seenNICNames, devConfig["name"])
+			if shared.StringInSlice(nicName, seenNICNames) {
+				return fmt.Errorf("Duplicate NIC name detected %q", nicName)
+			}
+
+			seenNICNames = append(seenNICNames, nicName)
+		}
 	}
 
 	return nil
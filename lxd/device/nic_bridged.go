@@ -25,6 +25,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/ip"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/resources"
@@ -46,6 +47,9 @@ type nicBridged struct {
 	network network.Network // Populated in validateConfig().
 }
 
+// nicBridgedFailoverBannedKeys lists the settings which can't be combined with failover.
+var nicBridgedFailoverBannedKeys = []string{"limits.ingress", "limits.egress", "limits.max", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering", "security.acls", "mirror.target", "vlan", "vlan.tagged"}
+
 // CanHotPlug returns whether the device can be managed whilst the instance is running. Returns true.
 func (d *nicBridged) CanHotPlug() bool {
 	return true
@@ -83,10 +87,22 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		"security.ipv4_filtering",
 		"security.ipv6_filtering",
 		"security.port_isolation",
+		"security.promiscuous",
 		"maas.subnet.ipv4",
 		"maas.subnet.ipv6",
 		"boot.priority",
 		"vlan",
+		"mirror.target",
+		"mirror.direction",
+		"security.acls",
+		"security.acls.default.ingress.action",
+		"security.acls.default.egress.action",
+		"security.acls.default.ingress.logged",
+		"security.acls.default.egress.logged",
+		"dns.names",
+		"failover.parent",
+		"failover.name",
+		"failover.monitor",
 	}
 
 	// checkWithManagedNetwork validates the device's settings against the managed network.
@@ -116,7 +132,7 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 				return fmt.Errorf("Device IP address %q not within network %q subnet", d.config["ipv4.address"], n.Name())
 			}
 
-			parentAddress := netConfig["ipv4.address"]
+			parentAddress := util.BridgePrimaryAddress(netConfig["ipv4.address"])
 			if shared.StringInSlice(parentAddress, []string{"", "none"}) {
 				return nil
 			}
@@ -151,7 +167,7 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 				return fmt.Errorf("Device IP address %q not within network %q subnet", d.config["ipv6.address"], n.Name())
 			}
 
-			parentAddress := netConfig["ipv6.address"]
+			parentAddress := util.BridgePrimaryAddress(netConfig["ipv6.address"])
 			if shared.StringInSlice(parentAddress, []string{"", "none"}) {
 				return nil
 			}
@@ -174,6 +190,11 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		// When we know the parent network is managed, we can validate the NIC's VLAN settings based on
 		// on the bridge driver type.
 		if shared.StringInSlice(netConfig["bridge.driver"], []string{"", "native"}) {
+			// Check VLAN filtering hasn't been disabled on the bridge.
+			if (d.config["vlan"] != "" || d.config["vlan.tagged"] != "") && shared.IsFalse(netConfig["bridge.vlan_filtering"]) {
+				return fmt.Errorf("VLAN settings cannot be used when VLAN filtering is disabled on network %q", n.Name())
+			}
+
 			// Check VLAN 0 isn't set when using a native Linux managed bridge, as not supported.
 			if d.config["vlan"] == "0" {
 				return fmt.Errorf("VLAN ID 0 is not allowed for native Linux bridges")
@@ -238,6 +259,20 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 				d.config[inheritKey] = netConfig[inheritKey]
 			}
 		}
+
+		// Mirror the traffic as configured on the network unless the NIC has its own mirror target.
+		mirrorTarget := netConfig["mirror.target"]
+		if netConfig["mirror.vxlan.remote"] != "" {
+			mirrorTarget = network.BridgeMirrorName(d.config["network"])
+		}
+
+		if mirrorTarget != "" && d.config["mirror.target"] == "" && d.config["failover.parent"] == "" {
+			d.config["mirror.target"] = mirrorTarget
+
+			if d.config["mirror.direction"] == "" && netConfig["mirror.direction"] != "" {
+				d.config["mirror.direction"] = netConfig["mirror.direction"]
+			}
+		}
 	} else {
 		// If no network property supplied, then parent property is required.
 		requiredFields = append(requiredFields, "parent")
@@ -285,6 +320,13 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		}
 	}
 
+	// Check that promiscuous mode isn't being used with MAC filtering, as it allows additional MAC addresses.
+	if shared.IsTrue(d.config["security.promiscuous"]) {
+		if shared.IsTrue(d.config["security.mac_filtering"]) || shared.IsTrue(d.config["security.ipv4_filtering"]) || shared.IsTrue(d.config["security.ipv6_filtering"]) {
+			return fmt.Errorf("Promiscuous mode cannot be used with MAC or IP filtering")
+		}
+	}
+
 	// Check there isn't another NIC with any of the same addresses specified on the same cluster member.
 	// Can only validate this when the instance is supplied (and not doing profile validation).
 	if d.inst != nil {
@@ -417,6 +459,48 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		return nil
 	}
 
+	// Add bridge specific mirror validation rules.
+	rules["mirror.target"] = func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		// Mirroring to another instance's NIC is specified as "<instance>/<device>".
+		instName, devName, found := strings.Cut(value, "/")
+		if !found {
+			return validate.IsInterfaceName(value)
+		}
+
+		if instName == "" || devName == "" {
+			return fmt.Errorf("Mirror target must be either an interface name or in the form <instance>/<device>")
+		}
+
+		return nil
+	}
+
+	rules["mirror.direction"] = validate.Optional(validate.IsOneOf("both", "ingress", "egress"))
+
+	rules["failover.parent"] = validate.Optional(validate.IsInterfaceName)
+	rules["failover.name"] = validate.Optional(validate.IsInterfaceName)
+	rules["failover.monitor"] = validate.Optional(validate.IsInterfaceName)
+
+	rules["dns.names"] = validate.Optional(func(value string) error {
+		if value == "none" {
+			return nil
+		}
+
+		return validate.IsListOf(func(name string) error {
+			for _, label := range strings.Split(name, ".") {
+				err := validate.IsHostname(label)
+				if err != nil {
+					return fmt.Errorf("Invalid DNS name %q: %w", name, err)
+				}
+			}
+
+			return nil
+		})(value)
+	})
+
 	// Add bridge specific ipv4/ipv6 validation rules
 	rules["ipv4.address"] = func(value string) error {
 		if value == "" || value == "none" {
@@ -440,9 +524,104 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		return err
 	}
 
+	// Check failover settings.
+	if d.config["failover.parent"] != "" {
+		if instConf.Type() == instancetype.VM {
+			return fmt.Errorf("Failover is only supported for containers")
+		}
+
+		if d.config["failover.name"] == "" || d.config["failover.monitor"] == "" {
+			return fmt.Errorf(`The "failover.name" and "failover.monitor" properties are required when using "failover.parent"`)
+		}
+
+		if d.config["failover.parent"] == d.config["parent"] {
+			return fmt.Errorf(`The "failover.parent" property must be different from the parent bridge`)
+		}
+
+		// The backup interface is a plain bridge port, so settings applied to the host side interface
+		// can't be used with failover.
+		for _, key := range nicBridgedFailoverBannedKeys {
+			if d.config[key] != "" {
+				return fmt.Errorf("Cannot use %q property in conjunction with %q property", key, "failover.parent")
+			}
+		}
+	} else if d.config["failover.name"] != "" || d.config["failover.monitor"] != "" {
+		return fmt.Errorf(`The "failover.parent" property is required when using failover`)
+	}
+
+	// Check the DNS names aren't registered by another NIC connected to the same network.
+	if d.inst != nil && d.network != nil && d.config["dns.names"] != "" && d.config["dns.names"] != "none" {
+		err = d.checkDNSNamesConflict()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check Security ACLs exist.
+	if d.config["security.acls"] != "" {
+		if d.config["network"] == "" {
+			return fmt.Errorf("Security ACLs can only be used with managed networks")
+		}
+
+		networkProjectName, _, err := project.NetworkProject(d.state.DB.Cluster, instConf.Project())
+		if err != nil {
+			return fmt.Errorf("Failed loading network project name: %w", err)
+		}
+
+		err = acl.Exists(d.state, networkProjectName, shared.SplitNTrimSpace(d.config["security.acls"], ",", -1, true)...)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// checkDNSNamesConflict checks that the names in dns.names aren't registered by a NIC of another instance connected
+// to the same network, either through its own dns.names setting or under its instance name. All cluster members
+// are checked as the DNS zones of the network cover all of them.
+func (d *nicBridged) checkDNSNamesConflict() error {
+	ourNames := shared.SplitNTrimSpace(d.config["dns.names"], ",", -1, true)
+
+	return d.state.DB.Cluster.InstanceList(nil, func(inst db.Instance, p api.Project, profiles []api.Profile) error {
+		// Managed bridge networks can only exist in default project.
+		if project.NetworkProjectFromRecord(&p) != project.Default {
+			return nil
+		}
+
+		// The NICs of the same instance can share names.
+		if instance.IsSameLogicalInstance(d.inst, &inst) {
+			return nil
+		}
+
+		devices := db.ExpandInstanceDevices(deviceConfig.NewDevices(db.DevicesToAPI(inst.Devices)), profiles)
+		for devName, devConfig := range devices {
+			if devConfig["type"] != "nic" || !shared.StringInSlice(devConfig["nictype"], []string{"", "bridged"}) {
+				continue
+			}
+
+			if !network.NICUsesNetwork(devConfig, &api.Network{Name: d.network.Name()}) || devConfig["dns.names"] == "none" {
+				continue
+			}
+
+			names := []string{inst.Name}
+			if devConfig["dns.names"] != "" {
+				names = shared.SplitNTrimSpace(devConfig["dns.names"], ",", -1, true)
+			}
+
+			for _, ourName := range ourNames {
+				for _, name := range names {
+					if strings.EqualFold(ourName, name) {
+						return fmt.Errorf("DNS name %q already used by NIC %q of instance %q", ourName, devName, inst.Name)
+					}
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
 // validateEnvironment checks the runtime environment for correctness.
 func (d *nicBridged) validateEnvironment() error {
 	if d.inst.Type() == instancetype.Container && d.config["name"] == "" {
@@ -453,6 +632,10 @@ func (d *nicBridged) validateEnvironment() error {
 		return fmt.Errorf("Parent device %q doesn't exist", d.config["parent"])
 	}
 
+	if d.config["failover.parent"] != "" && !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", d.config["failover.parent"])) {
+		return fmt.Errorf("Failover parent device %q doesn't exist", d.config["failover.parent"])
+	}
+
 	return nil
 }
 
@@ -464,7 +647,7 @@ func (d *nicBridged) UpdatableFields(oldDevice Type) []string {
 		return []string{}
 	}
 
-	return []string{"limits.ingress", "limits.egress", "limits.max", "ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering"}
+	return []string{"limits.ingress", "limits.egress", "limits.max", "ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering", "mirror.target", "mirror.direction", "security.acls", "security.acls.default.ingress.action", "security.acls.default.egress.action", "security.acls.default.ingress.logged", "security.acls.default.egress.logged", "dns.names"}
 }
 
 // Add is run when a device is added to a non-snapshot instance whether or not the instance is running.
@@ -555,6 +738,12 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 		return nil, err
 	}
 
+	// Apply host-side traffic mirroring.
+	err = d.setupMirror()
+	if err != nil {
+		return nil, err
+	}
+
 	// Disable IPv6 on host-side veth interface (prevents host-side interface getting link-local address)
 	// which isn't needed because the host-side interface is connected to a bridge.
 	err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/disable_ipv6", saveData["host_name"]), "1")
@@ -569,6 +758,13 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 	}
 	revert.Add(r)
 
+	// Apply network ACLs to the host side interface.
+	r, err = d.setupACLs(nil)
+	if err != nil {
+		return nil, err
+	}
+	revert.Add(r)
+
 	// Attach host side veth interface to bridge.
 	err = network.AttachInterface(d.config["parent"], saveData["host_name"])
 	if err != nil {
@@ -599,11 +795,22 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 		err = d.setupNativeBridgePortVLANs(saveData["host_name"])
 	} else {
 		err = d.setupOVSBridgePortVLANs(saveData["host_name"])
+		if err == nil {
+			err = d.setupOVSBridgePortExternalIDs(saveData["host_name"])
+		}
 	}
 	if err != nil {
 		return nil, err
 	}
 
+	// Allow the instance to use additional MAC addresses on the bridge port.
+	if shared.IsTrue(d.config["security.promiscuous"]) {
+		err = d.setupPromiscuous(saveData["host_name"], nativeBridge)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if hairpin mode needs to be enabled.
 	if nativeBridge && d.network != nil {
 		brNetfilterEnabled := false
@@ -620,11 +827,16 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 				return nil, fmt.Errorf("Failed loading network forwards: %w", err)
 			}
 
-			// If br_netfilter is enabled and bridge has forwards, we enable hairpin mode on NIC's
-			// bridge port in case any of the forwards target this NIC and the instance attempts to
-			// connect to the forward's listener. Without hairpin mode on the target of the forward
-			// will not be able to connect to the listener.
-			if len(listenAddresses) > 0 {
+			lbListenAddresses, err := d.state.DB.Cluster.GetNetworkLoadBalancerListenAddresses(d.network.ID(), true)
+			if err != nil {
+				return nil, fmt.Errorf("Failed loading network load balancers: %w", err)
+			}
+
+			// If br_netfilter is enabled and bridge has forwards or load balancers, we enable hairpin
+			// mode on NIC's bridge port in case any of them target this NIC and the instance attempts to
+			// connect to the listener. Without hairpin mode on the target of the forward or load
+			// balancer will not be able to connect to the listener.
+			if len(listenAddresses) > 0 || len(lbListenAddresses) > 0 {
 				link := &ip.Link{Name: saveData["host_name"]}
 				err = link.BridgeLinkSetHairpin(true)
 				if err != nil {
@@ -635,6 +847,17 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 		}
 	}
 
+	// Create the backup interface used for failover.
+	var failoverPeerName string
+	if d.config["failover.parent"] != "" {
+		saveData["failover.host_name"], failoverPeerName, err = d.failoverStart()
+		if err != nil {
+			return nil, err
+		}
+
+		revert.Add(func() { _ = network.InterfaceRemove(saveData["failover.host_name"]) })
+	}
+
 	err = d.volatileSet(saveData)
 	if err != nil {
 		return nil, err
@@ -643,6 +866,12 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{}
 	runConf.PostHooks = []func() error{d.postStart}
 
+	if failoverPeerName != "" {
+		runConf.PostHooks = append(runConf.PostHooks, func() error {
+			return d.failoverPostStart(failoverPeerName)
+		})
+	}
+
 	runConf.NetworkInterface = []deviceConfig.RunConfigItem{
 		{Key: "type", Value: "phys"},
 		{Key: "name", Value: d.config["name"]},
@@ -670,6 +899,8 @@ func (d *nicBridged) postStart() error {
 		return err
 	}
 
+	d.mirrorSourcesRefresh()
+
 	return nil
 }
 
@@ -735,12 +966,25 @@ func (d *nicBridged) Update(oldDevices deviceConfig.Devices, isRunning bool) err
 			return err
 		}
 
+		// Apply host-side traffic mirroring.
+		err = d.setupMirror()
+		if err != nil {
+			return err
+		}
+
 		// Apply and host-side network filters (uses enriched host_name from networkVethFillFromVolatile).
 		r, err := d.setupHostFilters(oldConfig)
 		if err != nil {
 			return err
 		}
 		revert.Add(r)
+
+		// Apply network ACLs to the host side interface.
+		r, err = d.setupACLs(oldConfig)
+		if err != nil {
+			return err
+		}
+		revert.Add(r)
 	}
 
 	// Rebuild dnsmasq entry if needed and reload.
@@ -799,7 +1043,9 @@ func (d *nicBridged) Stop() (*deviceConfig.RunConfig, error) {
 func (d *nicBridged) postStop() error {
 	defer func() {
 		_ = d.volatileSet(map[string]string{
-			"host_name": "",
+			"host_name":          "",
+			"failover.host_name": "",
+			"failover.active":    "",
 		})
 	}()
 
@@ -807,6 +1053,23 @@ func (d *nicBridged) postStop() error {
 
 	networkVethFillFromVolatile(d.config, v)
 
+	// Stop tracking the monitored interface and remove the backup interface.
+	if d.config["failover.parent"] != "" {
+		linkUnregisterHandler(d.inst, d.name)
+
+		if v["failover.host_name"] != "" && network.InterfaceExists(v["failover.host_name"]) {
+			err := network.DetachInterface(d.config["failover.parent"], v["failover.host_name"])
+			if err != nil {
+				return fmt.Errorf("Failed to detach interface %q from %q: %w", v["failover.host_name"], d.config["failover.parent"], err)
+			}
+
+			err = network.InterfaceRemove(v["failover.host_name"])
+			if err != nil {
+				return fmt.Errorf("Failed to remove interface %q: %w", v["failover.host_name"], err)
+			}
+		}
+	}
+
 	if d.config["host_name"] != "" && network.InterfaceExists(d.config["host_name"]) {
 		// Detach host-side end of veth pair from bridge (required for openvswitch particularly).
 		err := network.DetachInterface(d.config["parent"], d.config["host_name"])
@@ -833,6 +1096,13 @@ func (d *nicBridged) postStop() error {
 		d.removeFilters(d.config)
 	}
 
+	if d.config["security.acls"] != "" {
+		err := d.state.Firewall.InstanceClearACLRules(d.inst.Project(), d.inst.Name(), d.name)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -961,6 +1231,118 @@ func (d *nicBridged) setupHostFilters(oldConfig deviceConfig.Device) (revert.Hoo
 	return revertExternal.Fail, nil
 }
 
+// setupACLs applies the network ACLs specified in security.acls to the host side interface. If oldConfig is
+// supplied as part of an update, the rules are only replaced when the ACL settings have changed.
+func (d *nicBridged) setupACLs(oldConfig deviceConfig.Device) (revert.Hook, error) {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if oldConfig != nil {
+		changed := false
+		for _, key := range []string{"security.acls", "security.acls.default.ingress.action", "security.acls.default.egress.action", "security.acls.default.ingress.logged", "security.acls.default.egress.logged"} {
+			if d.config[key] != oldConfig[key] {
+				changed = true
+				break
+			}
+		}
+
+		if !changed {
+			return func() {}, nil
+		}
+
+		// Remove the old rules if ACLs are no longer used.
+		if d.config["security.acls"] == "" && oldConfig["security.acls"] != "" {
+			err := d.state.Firewall.InstanceClearACLRules(d.inst.Project(), d.inst.Name(), d.name)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if d.config["security.acls"] != "" {
+		networkProjectName, _, err := project.NetworkProject(d.state.DB.Cluster, d.inst.Project())
+		if err != nil {
+			return nil, fmt.Errorf("Failed loading network project name: %w", err)
+		}
+
+		// Existing rules are replaced when updating.
+		err = acl.FirewallApplyNICACLRules(d.state, networkProjectName, d.inst.Project(), d.inst.Name(), d.name, d.config["host_name"], d.config)
+		if err != nil {
+			return nil, err
+		}
+
+		if oldConfig == nil || oldConfig["security.acls"] == "" {
+			revert.Add(func() { _ = d.state.Firewall.InstanceClearACLRules(d.inst.Project(), d.inst.Name(), d.name) })
+		}
+	}
+
+	revertExternal := revert.Clone()
+	revert.Success()
+	return revertExternal.Fail, nil
+}
+
+// setupMirror mirrors the NIC's traffic to the host interface or instance NIC specified in mirror.target.
+func (d *nicBridged) setupMirror() error {
+	target := d.config["mirror.target"]
+	if target == "" {
+		return nil
+	}
+
+	// Resolve another instance's NIC to its host side interface.
+	instName, devName, found := strings.Cut(target, "/")
+	if found {
+		targetInst, err := instance.LoadByProjectAndName(d.state, d.inst.Project(), instName)
+		if err != nil {
+			return fmt.Errorf("Failed loading mirror target instance %q: %w", instName, err)
+		}
+
+		// The mirroring is set up once the target instance starts (see mirrorSourcesRefresh).
+		target = targetInst.LocalConfig()[fmt.Sprintf("volatile.%s.host_name", devName)]
+		if target == "" || !targetInst.IsRunning() || !network.InterfaceExists(target) {
+			d.logger.Warn("Mirror target device isn't running, traffic will be mirrored once it starts", logger.Ctx{"target": d.config["mirror.target"]})
+			return nil
+		}
+	}
+
+	return networkSetupHostVethMirror(d.config, target)
+}
+
+// mirrorSourcesRefresh points the traffic mirroring of the running instance NICs using this NIC as their mirror
+// target to its new host side interface, as that changes every time the instance starts.
+func (d *nicBridged) mirrorSourcesRefresh() {
+	hostName := d.volatileGet()["host_name"]
+	if hostName == "" {
+		return
+	}
+
+	insts, err := instance.LoadNodeAll(d.state, instancetype.Any)
+	if err != nil {
+		d.logger.Warn("Failed loading instances to refresh traffic mirroring", logger.Ctx{"err": err})
+		return
+	}
+
+	target := fmt.Sprintf("%s/%s", d.inst.Name(), d.name)
+	for _, inst := range insts {
+		if inst.Project() != d.inst.Project() || !inst.IsRunning() {
+			continue
+		}
+
+		for devName, devConfig := range inst.ExpandedDevices() {
+			if devConfig["type"] != "nic" || devConfig["mirror.target"] != target {
+				continue
+			}
+
+			sourceConfig := devConfig.Clone()
+			sourceConfig["host_name"] = inst.LocalConfig()[fmt.Sprintf("volatile.%s.host_name", devName)]
+
+			err = networkUpdateHostVethMirror(sourceConfig, hostName)
+			if err != nil {
+				d.logger.Warn("Failed refreshing traffic mirroring", logger.Ctx{"source": project.Instance(inst.Project(), inst.Name()), "device": devName, "err": err})
+			}
+		}
+	}
+}
+
 // removeFilters removes any network level filters defined for the instance.
 func (d *nicBridged) removeFilters(m deviceConfig.Device) {
 	if m["hwaddr"] == "" {
@@ -1516,6 +1898,52 @@ func (d *nicBridged) setupNativeBridgePortVLANs(hostName string) error {
 	return nil
 }
 
+// setupPromiscuous configures the host side interface and its bridge port so that frames for MAC addresses other
+// than the NIC's own, such as those of nested instances or VRRP virtual addresses, reach the instance.
+func (d *nicBridged) setupPromiscuous(hostName string, nativeBridge bool) error {
+	link := &ip.Link{Name: hostName}
+
+	err := link.SetPromisc(true)
+	if err != nil {
+		return err
+	}
+
+	err = link.SetAllMulticast(true)
+	if err != nil {
+		return err
+	}
+
+	if nativeBridge {
+		// Learn the additional MAC addresses used behind the port and deliver unknown unicast and
+		// multicast traffic to it.
+		err = link.BridgeLinkSetLearning(true)
+		if err != nil {
+			return err
+		}
+
+		return link.BridgeLinkSetFlood(true)
+	}
+
+	// Openvswitch learns the MAC addresses behind the port with the default normal action, so only make
+	// sure that multicast traffic isn't filtered by snooping.
+	ovs := openvswitch.NewOVS()
+
+	return ovs.BridgePortSet(hostName, "other_config:mcast-snooping-flood=true", "other_config:mcast-snooping-flood-reports=true")
+}
+
+// setupOVSBridgePortExternalIDs sets external IDs identifying the instance NIC on the openvswitch bridge port, so
+// that external OpenFlow controllers can associate the port with the instance when programming flows.
+func (d *nicBridged) setupOVSBridgePortExternalIDs(hostName string) error {
+	ovs := openvswitch.NewOVS()
+
+	return ovs.InterfaceSetExternalIDs(hostName, map[string]string{
+		"attached-mac": d.config["hwaddr"],
+		"lxd-project":  d.inst.Project(),
+		"lxd-instance": d.inst.Name(),
+		"lxd-device":   d.name,
+	})
+}
+
 // setupOVSBridgePortVLANs configures the bridge port with the specified VLAN settings on the openvswitch bridge.
 func (d *nicBridged) setupOVSBridgePortVLANs(hostName string) error {
 	ovs := openvswitch.NewOVS()
@@ -1596,8 +2024,8 @@ func (d *nicBridged) State() (*api.InstanceStateNetwork, error) {
 	if d.network != nil {
 		// Extract subnet sizes from bridge addresses if available.
 		netConfig := d.network.Config()
-		_, v4subnet, _ := net.ParseCIDR(netConfig["ipv4.address"])
-		_, v6subnet, _ := net.ParseCIDR(netConfig["ipv6.address"])
+		_, v4subnet, _ := net.ParseCIDR(util.BridgePrimaryAddress(netConfig["ipv4.address"]))
+		_, v6subnet, _ := net.ParseCIDR(util.BridgePrimaryAddress(netConfig["ipv6.address"]))
 
 		if v4subnet != nil {
 			mask, _ := v4subnet.Mask.Size()
@@ -1735,5 +2163,126 @@ func (d *nicBridged) Register() error {
 		return err
 	}
 
+	// Resume tracking the monitored interface.
+	if d.config["failover.parent"] != "" {
+		networkVethFillFromVolatile(d.config, d.volatileGet())
+
+		err = d.failoverRegister()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// failoverStart creates the backup veth pair of the NIC and attaches its host side to the failover parent.
+// The peer end gets the same MAC address as the primary interface. Returns the host and peer names.
+func (d *nicBridged) failoverStart() (string, string, error) {
+	revert := revert.New()
+	defer revert.Fail()
+
+	hostName, err := d.generateHostName("veth", "")
+	if err != nil {
+		return "", "", err
+	}
+
+	failoverConfig := d.config.Clone()
+	failoverConfig["parent"] = d.config["failover.parent"]
+
+	peerName, _, err := networkCreateVethPair(hostName, failoverConfig)
+	if err != nil {
+		return "", "", err
+	}
+
+	revert.Add(func() { _ = network.InterfaceRemove(hostName) })
+
+	// Disable IPv6 on the host side as for the primary interface.
+	err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/disable_ipv6", hostName), "1")
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", err
+	}
+
+	// Attempt to disable router advertisement acceptance.
+	err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/accept_ra", hostName), "0")
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", err
+	}
+
+	err = network.AttachInterface(d.config["failover.parent"], hostName)
+	if err != nil {
+		return "", "", err
+	}
+
+	revert.Success()
+	return hostName, peerName, nil
+}
+
+// failoverPostStart moves the backup interface into the running container and starts tracking the monitored
+// interface. The interface is left down inside the container, so that it can be enslaved to a bond.
+func (d *nicBridged) failoverPostStart(peerName string) error {
+	pid := d.inst.InitPID()
+	if pid <= 0 {
+		return fmt.Errorf("Failed to get the instance's init PID")
+	}
+
+	link := &ip.Link{Name: peerName}
+	err := link.SetNetnsAndName(fmt.Sprintf("%d", pid), d.config["failover.name"])
+	if err != nil {
+		return fmt.Errorf("Failed to move interface %q into the instance: %w", peerName, err)
+	}
+
+	return d.failoverRegister()
+}
+
+// failoverRegister applies the state of the monitored interface and tracks its changes.
+func (d *nicBridged) failoverRegister() error {
+	carrier, err := os.ReadFile(fmt.Sprintf("/sys/class/net/%s/carrier", d.config["failover.monitor"]))
+	err = d.failoverSwitch(err == nil && strings.TrimSpace(string(carrier)) == "1")
+	if err != nil {
+		return err
+	}
+
+	linkRegisterHandler(d.inst, d.name, func(e LinkEvent) error {
+		if e.Name != d.config["failover.monitor"] {
+			return nil
+		}
+
+		return d.failoverSwitch(e.Carrier)
+	})
+
 	return nil
 }
+
+// failoverSwitch brings up the host side of the primary interface and down the one of the backup interface if
+// the monitored interface has carrier, and the other way around otherwise. The instance sees the carrier of its
+// interfaces change accordingly, which an active-backup bond inside the instance uses to pick the active one.
+func (d *nicBridged) failoverSwitch(carrier bool) error {
+	v := d.volatileGet()
+
+	active := "primary"
+	upLink := &ip.Link{Name: d.config["host_name"]}
+	downLink := &ip.Link{Name: v["failover.host_name"]}
+	if !carrier {
+		active = "backup"
+		upLink, downLink = downLink, upLink
+	}
+
+	err := upLink.SetUp()
+	if err != nil {
+		return fmt.Errorf("Failed to bring up interface %q: %w", upLink.Name, err)
+	}
+
+	err = downLink.SetDown()
+	if err != nil {
+		return fmt.Errorf("Failed to bring down interface %q: %w", downLink.Name, err)
+	}
+
+	if v["failover.active"] == active {
+		return nil
+	}
+
+	d.logger.Info("Switched active failover interface", logger.Ctx{"active": active, "monitor": d.config["failover.monitor"]})
+
+	return d.volatileSet(map[string]string{"failover.active": active})
+}
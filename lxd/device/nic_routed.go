@@ -13,9 +13,12 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/ip"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/sys"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/validate"
 )
@@ -85,6 +88,11 @@ func (d *nicRouted) validateConfig(instConf instance.ConfigReader) error {
 	rules["gvrp"] = validate.Optional(validate.IsBool)
 	rules["ipv4.neighbor_probe"] = validate.Optional(validate.IsBool)
 	rules["ipv6.neighbor_probe"] = validate.Optional(validate.IsBool)
+	rules["ipv4.host_rp_filter"] = validate.Optional(validate.IsOneOf("0", "1", "2"))
+	rules["ipv6.host_accept_ra"] = validate.Optional(validate.IsOneOf("0", "1", "2"))
+	rules["ipv4.parent_forwarding"] = validate.Optional(validate.IsBool)
+	rules["ipv6.parent_forwarding"] = validate.Optional(validate.IsBool)
+	rules["ipv6.parent_proxy_ndp"] = validate.Optional(validate.IsBool)
 
 	err = d.config.Validate(rules)
 	if err != nil {
@@ -128,6 +136,11 @@ func (d *nicRouted) validateEnvironment() error {
 		return fmt.Errorf("Requires name property to start")
 	}
 
+	err := d.state.OS.CheckFeature(sys.FeatureSysctl)
+	if err != nil {
+		return err
+	}
+
 	if d.config["parent"] != "" {
 		// Check parent interface exists (don't use d.effectiveParentName here as we want to check the
 		// parent of any VLAN interface exists too). The VLAN interface will be created later if needed.
@@ -343,14 +356,24 @@ func (d *nicRouted) Start() (*deviceConfig.RunConfig, error) {
 		return nil, err
 	}
 
-	// Attempt to disable IPv6 router advertisement acceptance from instance.
-	err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/accept_ra", saveData["host_name"]), "0")
+	// Attempt to disable IPv6 router advertisement acceptance from instance (unless overridden).
+	acceptRA := "0"
+	if d.config["ipv6.host_accept_ra"] != "" {
+		acceptRA = d.config["ipv6.host_accept_ra"]
+	}
+
+	err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/accept_ra", saveData["host_name"]), acceptRA)
 	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
 
-	// Prevent source address spoofing by requiring a return path.
-	err = util.SysctlSet(fmt.Sprintf("net/ipv4/conf/%s/rp_filter", saveData["host_name"]), "1")
+	// Prevent source address spoofing by requiring a return path (unless overridden).
+	rpFilter := "1"
+	if d.config["ipv4.host_rp_filter"] != "" {
+		rpFilter = d.config["ipv4.host_rp_filter"]
+	}
+
+	err = util.SysctlSet(fmt.Sprintf("net/ipv4/conf/%s/rp_filter", saveData["host_name"]), rpFilter)
 	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
@@ -522,29 +545,52 @@ func (d *nicRouted) Start() (*deviceConfig.RunConfig, error) {
 
 // setupParentSysctls configures the required sysctls on the parent to allow l2proxy to work.
 // Because of our policy not to modify sysctls on existing interfaces, this should only be called
-// if we created the parent interface.
+// if we created the parent interface. Sysctls whose override key is set to false are left to the host
+// and only checked.
 func (d *nicRouted) setupParentSysctls(parentName string) error {
+	setupSysctl := func(family string, name string, key string) error {
+		path := fmt.Sprintf("net/%s/conf/%s/%s", family, parentName, name)
+
+		if d.config[key] == "" || shared.IsTrue(d.config[key]) {
+			err := util.SysctlSet(path, "1")
+			if err != nil {
+				return fmt.Errorf("Error setting net sysctl %s: %w", path, err)
+			}
+
+			return nil
+		}
+
+		sysctlVal, err := util.SysctlGet(path)
+		if err != nil {
+			return fmt.Errorf("Error reading net sysctl %s: %w", path, err)
+		}
+
+		if sysctlVal != "1\n" {
+			// Replace . in parent name with / for sysctl formatting.
+			return fmt.Errorf("Routed mode requires sysctl net.%s.conf.%s.%s=1 when %q is false", family, strings.Replace(parentName, ".", "/", -1), name, key)
+		}
+
+		return nil
+	}
+
 	if d.config["ipv4.address"] != "" {
 		// Set necessary sysctls for use with l2proxy parent in routed mode.
-		ipv4FwdPath := fmt.Sprintf("net/ipv4/conf/%s/forwarding", parentName)
-		err := util.SysctlSet(ipv4FwdPath, "1")
+		err := setupSysctl("ipv4", "forwarding", "ipv4.parent_forwarding")
 		if err != nil {
-			return fmt.Errorf("Error setting net sysctl %s: %w", ipv4FwdPath, err)
+			return err
 		}
 	}
 
 	if d.config["ipv6.address"] != "" {
 		// Set necessary sysctls use with l2proxy parent in routed mode.
-		ipv6FwdPath := fmt.Sprintf("net/ipv6/conf/%s/forwarding", parentName)
-		err := util.SysctlSet(ipv6FwdPath, "1")
+		err := setupSysctl("ipv6", "forwarding", "ipv6.parent_forwarding")
 		if err != nil {
-			return fmt.Errorf("Error setting net sysctl %s: %w", ipv6FwdPath, err)
+			return err
 		}
 
-		ipv6ProxyNdpPath := fmt.Sprintf("net/ipv6/conf/%s/proxy_ndp", parentName)
-		err = util.SysctlSet(ipv6ProxyNdpPath, "1")
+		err = setupSysctl("ipv6", "proxy_ndp", "ipv6.parent_proxy_ndp")
 		if err != nil {
-			return fmt.Errorf("Error setting net sysctl %s: %w", ipv6ProxyNdpPath, err)
+			return err
 		}
 	}
 
@@ -575,6 +621,63 @@ func (d *nicRouted) Update(oldDevices deviceConfig.Devices, isRunning bool) erro
 	return nil
 }
 
+// State gets the state of a routed NIC from its configured addresses and host side interface counters.
+func (d *nicRouted) State() (*api.InstanceStateNetwork, error) {
+	v := d.volatileGet()
+
+	// Populate device config with volatile fields if needed.
+	networkVethFillFromVolatile(d.config, v)
+
+	addresses := []api.InstanceStateNetworkAddress{}
+	for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+		for _, addr := range shared.SplitNTrimSpace(d.config[fmt.Sprintf("%s.address", keyPrefix)], ",", -1, true) {
+			address := api.InstanceStateNetworkAddress{
+				Family:  "inet",
+				Address: addr,
+				Netmask: "32",
+				Scope:   "global",
+			}
+
+			if keyPrefix == "ipv6" {
+				address.Family = "inet6"
+				address.Netmask = "128"
+			}
+
+			addresses = append(addresses, address)
+		}
+	}
+
+	mtu := 0
+	iface, err := net.InterfaceByName(d.config["host_name"])
+	if err == nil {
+		mtu = iface.MTU
+	}
+
+	// Retrieve the host counters, as we report the values from the instance's point of view,
+	// those counters need to be reversed below.
+	hostCounters, err := resources.GetNetworkCounters(d.config["host_name"])
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting network interface counters: %w", err)
+	}
+
+	network := api.InstanceStateNetwork{
+		Addresses: addresses,
+		Counters: api.InstanceStateNetworkCounters{
+			BytesReceived:   hostCounters.BytesSent,
+			BytesSent:       hostCounters.BytesReceived,
+			PacketsReceived: hostCounters.PacketsSent,
+			PacketsSent:     hostCounters.PacketsReceived,
+		},
+		Hwaddr:   d.config["hwaddr"],
+		HostName: d.config["host_name"],
+		Mtu:      mtu,
+		State:    "up",
+		Type:     "broadcast",
+	}
+
+	return &network, nil
+}
+
 // Stop is run when the device is removed from the instance.
 func (d *nicRouted) Stop() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{
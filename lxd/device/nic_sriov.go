@@ -9,6 +9,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/sys"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -131,6 +132,12 @@ func (d *nicSRIOV) validateEnvironment() error {
 		return fmt.Errorf("Requires name property to start")
 	}
 
+	// Setting up the virtual functions is done through /sys.
+	err := d.state.OS.CheckFeature(sys.FeatureSysfs)
+	if err != nil {
+		return err
+	}
+
 	if !network.InterfaceExists(d.config["parent"]) {
 		return fmt.Errorf("Parent device %q doesn't exist", d.config["parent"])
 	}
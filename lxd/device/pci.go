@@ -46,6 +46,11 @@ func (d *pci) validateEnvironment() error {
 	return validatePCIDevice(d.config["address"])
 }
 
+// CanHotPlug returns whether the device can be managed whilst the instance is running.
+func (d *pci) CanHotPlug() bool {
+	return true
+}
+
 // Start is run when the device is added to the instance.
 func (d *pci) Start() (*deviceConfig.RunConfig, error) {
 	err := d.validateEnvironment()
@@ -107,6 +112,7 @@ func (d *pci) postStop() error {
 		_ = d.volatileSet(map[string]string{
 			"last_state.pci.slot.name": "",
 			"last_state.pci.driver":    "",
+			"last_state.pci.port":      "",
 		})
 	}()
 
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"net"
 	"os"
 	"path/filepath"
@@ -11,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"golang.org/x/sys/unix"
 	liblxc "gopkg.in/lxc/go-lxc.v2"
 
 	"github.com/lxc/lxd/lxd/apparmor"
@@ -49,6 +52,10 @@ type proxyProcInfo struct {
 	securityUID    string
 	securityGID    string
 	proxyProtocol  string
+	connectBalance string
+	listenRecreate string
+	agentVsockID   string
+	agentPath      string
 	inheritFds     []*os.File
 }
 
@@ -63,6 +70,11 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		return err
 	}
 
+	validateAddrs := func(input string) error {
+		_, err := ProxyParseAddrs(input)
+		return err
+	}
+
 	// Supported bind types are: "host" or "instance" (or "guest" or "container", legacy options equivalent to "instance").
 	// If an empty value is supplied the default behavior is to assume "host" bind mode.
 	validateBind := func(input string) error {
@@ -74,16 +86,21 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 	}
 
 	rules := map[string]func(string) error{
-		"listen":         validate.Required(validateAddr),
-		"connect":        validate.Required(validateAddr),
-		"bind":           validate.Optional(validateBind),
-		"mode":           validate.Optional(unixValidOctalFileMode),
-		"nat":            validate.Optional(validate.IsBool),
-		"gid":            validate.Optional(unixValidUserID),
-		"uid":            validate.Optional(unixValidUserID),
-		"security.uid":   validate.Optional(unixValidUserID),
-		"security.gid":   validate.Optional(unixValidUserID),
-		"proxy_protocol": validate.Optional(validate.IsBool),
+		"listen":             validate.Required(validateAddr),
+		"connect":            validate.Required(validateAddrs),
+		"connect.balance":    validate.Optional(validate.IsOneOf("round-robin", "hash")),
+		"bind":               validate.Optional(validateBind),
+		"listen.recreate":    validate.Optional(validate.IsBool),
+		"mode":               validate.Optional(unixValidOctalFileMode),
+		"nat":                validate.Optional(validate.IsBool),
+		"gid":                validate.Optional(unixValidUserID),
+		"uid":                validate.Optional(unixValidUserID),
+		"security.uid":       validate.Optional(unixValidUserID),
+		"security.gid":       validate.Optional(unixValidUserID),
+		"proxy_protocol":     validate.Optional(validate.IsBool),
+		"udp.timeout":        validate.Optional(validate.IsInRange(1, math.MaxInt32)),
+		"limits.connections": validate.Optional(validate.IsUint32),
+		"limits.rate":        validate.Optional(validate.IsUint32),
 	}
 
 	err := d.config.Validate(rules)
@@ -91,25 +108,38 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		return err
 	}
 
-	if instConf.Type() == instancetype.VM && shared.IsFalseOrEmpty(d.config["nat"]) {
-		return fmt.Errorf("Only NAT mode is supported for proxies on VM instances")
-	}
-
 	listenAddr, err := ProxyParseAddr(d.config["listen"])
 	if err != nil {
 		return err
 	}
 
-	connectAddr, err := ProxyParseAddr(d.config["connect"])
+	connectAddrs, err := ProxyParseAddrs(d.config["connect"])
 	if err != nil {
 		return err
 	}
 
+	connectAddr := connectAddrs[0]
+
+	// Without NAT, the connections to virtual machines are relayed from the host by the lxd-agent.
+	if instConf.Type() == instancetype.VM && shared.IsFalseOrEmpty(d.config["nat"]) {
+		if d.config["bind"] != "" && d.config["bind"] != "host" {
+			return fmt.Errorf("Only host-bound proxies are supported on VM instances")
+		}
+
+		if listenAddr.ConnType == "udp" || connectAddr.ConnType == "udp" {
+			return fmt.Errorf("Only tcp and unix addresses are supported for proxies on VM instances without NAT")
+		}
+	}
+
 	if (listenAddr.ConnType != "unix" && len(connectAddr.Ports) > len(listenAddr.Ports)) || (listenAddr.ConnType == "unix" && len(connectAddr.Ports) > 1) {
 		// Cannot support single address (or port) -> multiple port.
 		return fmt.Errorf("Mismatch between listen port(s) and connect port(s) count")
 	}
 
+	if shared.IsTrue(d.config["nat"]) && (d.config["udp.timeout"] != "" || d.config["limits.connections"] != "" || d.config["limits.rate"] != "") {
+		return fmt.Errorf("Connection limits and the UDP timeout can't be used in NAT mode")
+	}
+
 	if shared.IsTrue(d.config["proxy_protocol"]) && (!strings.HasPrefix(d.config["connect"], "tcp") || shared.IsTrue(d.config["nat"])) {
 		return fmt.Errorf("The PROXY header can only be sent to tcp servers in non-nat mode")
 	}
@@ -119,6 +149,10 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		return fmt.Errorf("Only proxy devices for non-abstract unix sockets can carry uid, gid, or mode properties")
 	}
 
+	if shared.IsTrue(d.config["listen.recreate"]) && (!strings.HasPrefix(d.config["listen"], "unix:") || strings.HasPrefix(d.config["listen"], "unix:@")) {
+		return fmt.Errorf("Only proxy devices listening on non-abstract unix sockets can recreate them")
+	}
+
 	if shared.IsTrue(d.config["nat"]) {
 		if d.inst != nil {
 			// Default project always has networks feature so don't bother loading the project config
@@ -153,6 +187,10 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 			return fmt.Errorf("Only host-bound proxies can use NAT")
 		}
 
+		if len(connectAddrs) > 1 {
+			return fmt.Errorf("Only a single connect address can be used in NAT mode")
+		}
+
 		// Support TCP <-> TCP and UDP <-> UDP only.
 		if listenAddr.ConnType == "unix" || connectAddr.ConnType == "unix" || listenAddr.ConnType != connectAddr.ConnType {
 			return fmt.Errorf("Proxying %s <-> %s is not supported when using NAT", listenAddr.ConnType, connectAddr.ConnType)
@@ -219,11 +257,22 @@ func (d *proxy) Start() (*deviceConfig.RunConfig, error) {
 				return err
 			}
 
+			err = d.checkListenOwner()
+			if err != nil {
+				return fmt.Errorf("Failed to start device %q: %w", d.name, err)
+			}
+
 			devFileName := fmt.Sprintf("proxy.%s", d.name)
 			pidPath := filepath.Join(d.inst.DevicesPath(), devFileName)
 			logFileName := fmt.Sprintf("proxy.%s.log", d.name)
 			logPath := filepath.Join(d.inst.LogPath(), logFileName)
 
+			// Write the connection limits.
+			err = d.writeLimits()
+			if err != nil {
+				return fmt.Errorf("Failed to start device %q: %w", d.name, err)
+			}
+
 			// Load the apparmor profile
 			err = apparmor.ForkproxyLoad(d.state.OS, d.inst, d)
 			if err != nil {
@@ -246,6 +295,11 @@ func (d *proxy) Start() (*deviceConfig.RunConfig, error) {
 				proxyValues.securityGID,
 				proxyValues.securityUID,
 				proxyValues.proxyProtocol,
+				d.limitsPath(),
+				proxyValues.connectBalance,
+				proxyValues.agentVsockID,
+				proxyValues.agentPath,
+				proxyValues.listenRecreate,
 			}
 
 			p, err := subprocess.NewProcess(command, forkproxyargs, logPath, logPath)
@@ -349,6 +403,11 @@ func (d *proxy) Stop() (*deviceConfig.RunConfig, error) {
 		return nil, err
 	}
 
+	err = os.Remove(d.limitsPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
 	// Unload apparmor profile.
 	err = apparmor.ForkproxyUnload(d.state.OS, d.inst, d)
 	if err != nil {
@@ -480,6 +539,10 @@ func (d *proxy) rewriteHostAddr(addr string) string {
 }
 
 func (d *proxy) setupProxyProcInfo() (*proxyProcInfo, error) {
+	if d.inst.Type() == instancetype.VM {
+		return d.setupProxyProcInfoVM()
+	}
+
 	cname := project.Instance(d.inst.Project(), d.inst.Name())
 	cc, err := liblxc.NewContainer(cname, d.state.OS.LxcPath)
 	if err != nil {
@@ -526,7 +589,12 @@ func (d *proxy) setupProxyProcInfo() (*proxyProcInfo, error) {
 		connectPid = lxdPid
 		connectPidFd = fmt.Sprintf("%d", lxdPidFd)
 
-		connectAddr = d.rewriteHostAddr(connectAddr)
+		connectAddrs := deviceConfig.SplitProxyAddresses(connectAddr)
+		for i := range connectAddrs {
+			connectAddrs[i] = d.rewriteHostAddr(connectAddrs[i])
+		}
+
+		connectAddr = strings.Join(connectAddrs, ",")
 	default:
 		return nil, fmt.Errorf("Invalid binding side given. Must be \"host\" or \"instance\"")
 	}
@@ -549,12 +617,178 @@ func (d *proxy) setupProxyProcInfo() (*proxyProcInfo, error) {
 		securityGID:    d.config["security.gid"],
 		securityUID:    d.config["security.uid"],
 		proxyProtocol:  d.config["proxy_protocol"],
+		connectBalance: d.config["connect.balance"],
+		listenRecreate: d.config["listen.recreate"],
 		inheritFds:     inheritFd,
 	}
 
 	return p, nil
 }
 
+// setupProxyProcInfoVM returns the forkproxy settings of virtual machines. Both sides of the proxy are on the
+// host, connections being relayed into the guest by the lxd-agent.
+func (d *proxy) setupProxyProcInfoVM() (*proxyProcInfo, error) {
+	agentVsockID := d.inst.LocalConfig()["volatile.vsock_id"]
+	if agentVsockID == "" {
+		return nil, fmt.Errorf("Instance has no vsock ID to reach its agent")
+	}
+
+	lxdPid := strconv.Itoa(os.Getpid())
+
+	lxdPidFd := -1
+	var inheritFd []*os.File
+	if d.state.OS.PidFds {
+		dPidFd, err := shared.PidFdOpen(os.Getpid(), 0)
+		if err == nil {
+			inheritFd = []*os.File{dPidFd}
+			lxdPidFd = 3
+		}
+	}
+
+	listenAddrMode := "0644"
+	if d.config["mode"] != "" {
+		listenAddrMode = d.config["mode"]
+	}
+
+	p := &proxyProcInfo{
+		listenPid:      lxdPid,
+		listenPidFd:    fmt.Sprintf("%d", lxdPidFd),
+		connectPid:     lxdPid,
+		connectPidFd:   fmt.Sprintf("%d", lxdPidFd),
+		connectAddr:    d.config["connect"],
+		listenAddr:     d.rewriteHostAddr(d.config["listen"]),
+		listenAddrGID:  d.config["gid"],
+		listenAddrUID:  d.config["uid"],
+		listenAddrMode: listenAddrMode,
+		securityGID:    d.config["security.gid"],
+		securityUID:    d.config["security.uid"],
+		proxyProtocol:  d.config["proxy_protocol"],
+		connectBalance: d.config["connect.balance"],
+		listenRecreate: d.config["listen.recreate"],
+		agentVsockID:   agentVsockID,
+		agentPath:      d.inst.Path(),
+		inheritFds:     inheritFd,
+	}
+
+	return p, nil
+}
+
+// checkListenOwner checks that the owner requested for a unix socket created inside a container is mapped in its
+// idmap. The socket being created from within the container's user namespace, uid and gid are given in the
+// container's terms and translated by the kernel.
+func (d *proxy) checkListenOwner() error {
+	if d.inst.Type() != instancetype.Container || (d.config["uid"] == "" && d.config["gid"] == "") {
+		return nil
+	}
+
+	if !shared.StringInSlice(d.config["bind"], []string{"instance", "guest", "container"}) {
+		return nil
+	}
+
+	c, ok := d.inst.(instance.Container)
+	if !ok {
+		return nil
+	}
+
+	idmapSet, err := c.CurrentIdmap()
+	if err != nil {
+		return fmt.Errorf("Failed getting the instance idmap: %w", err)
+	}
+
+	// Privileged containers share the host ids.
+	if idmapSet == nil {
+		return nil
+	}
+
+	var uid, gid int64
+	if d.config["uid"] != "" {
+		uid, err = strconv.ParseInt(d.config["uid"], 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.config["gid"] != "" {
+		gid, err = strconv.ParseInt(d.config["gid"], 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+
+	hostUID, hostGID := idmapSet.ShiftFromNs(uid, gid)
+	if d.config["uid"] != "" && hostUID == -1 {
+		return fmt.Errorf("UID %d of the listening socket isn't mapped in the instance", uid)
+	}
+
+	if d.config["gid"] != "" && hostGID == -1 {
+		return fmt.Errorf("GID %d of the listening socket isn't mapped in the instance", gid)
+	}
+
+	return nil
+}
+
+// limitsPath returns the path of the file holding the connection limits of the forkproxy process.
+func (d *proxy) limitsPath() string {
+	return filepath.Join(d.inst.DevicesPath(), fmt.Sprintf("proxy.%s.limits", d.name))
+}
+
+// writeLimits writes the connection limits read by the forkproxy process.
+// The file is rewritten in place as the forkproxy process keeps it open to reload it.
+func (d *proxy) writeLimits() error {
+	var sb strings.Builder
+	for _, key := range []string{"udp.timeout", "limits.connections", "limits.rate"} {
+		sb.WriteString(fmt.Sprintf("%s=%s\n", key, d.config[key]))
+	}
+
+	err := ioutil.WriteFile(d.limitsPath(), []byte(sb.String()), 0600)
+	if err != nil {
+		return fmt.Errorf("Failed writing proxy limits: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatableFields returns a list of fields that can be updated without triggering a device remove & add.
+func (d *proxy) UpdatableFields(oldDevice Type) []string {
+	// Check old and new device types match.
+	_, match := oldDevice.(*proxy)
+	if !match {
+		return []string{}
+	}
+
+	return []string{"udp.timeout", "limits.connections", "limits.rate"}
+}
+
+// Update applies the new connection limits to the running forkproxy process.
+func (d *proxy) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
+	if !isRunning || shared.IsTrue(d.config["nat"]) {
+		return nil
+	}
+
+	pidPath := filepath.Join(d.inst.DevicesPath(), fmt.Sprintf("proxy.%s", d.name))
+	if !shared.PathExists(pidPath) {
+		return nil
+	}
+
+	err := d.writeLimits()
+	if err != nil {
+		return err
+	}
+
+	p, err := subprocess.ImportProcess(pidPath)
+	if err != nil {
+		return fmt.Errorf("Could not read pid file: %w", err)
+	}
+
+	// Ask forkproxy to reload the limits.
+	err = p.Signal(int64(unix.SIGHUP))
+	if err != nil && err != subprocess.ErrNotRunning {
+		return fmt.Errorf("Unable to signal forkproxy: %w", err)
+	}
+
+	return nil
+}
+
 func (d *proxy) killProxyProc(pidPath string) error {
 	// If the pid file doesn't exist, there is no process to kill.
 	if !shared.PathExists(pidPath) {
@@ -8,6 +8,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"golang.org/x/sys/unix"
 
@@ -315,6 +316,75 @@ func deviceNetlinkListener() (chan []string, chan []string, chan device.USBEvent
 	return chCPU, chNetwork, chUSB, chUnix, nil
 }
 
+// deviceLinkListener listens for host network interface state changes.
+func deviceLinkListener() (chan device.LinkEvent, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+
+	nl := unix.SockaddrNetlink{
+		Family: unix.AF_NETLINK,
+		Groups: unix.RTMGRP_LINK,
+	}
+
+	err = unix.Bind(fd, &nl)
+	if err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+
+	chLink := make(chan device.LinkEvent)
+
+	go func(chLink chan device.LinkEvent) {
+		b := make([]byte, os.Getpagesize()*4)
+		for {
+			r, err := unix.Read(fd, b)
+			if err != nil {
+				continue
+			}
+
+			msgs, err := syscall.ParseNetlinkMessage(b[:r])
+			if err != nil {
+				continue
+			}
+
+			for _, msg := range msgs {
+				if msg.Header.Type != unix.RTM_NEWLINK {
+					continue
+				}
+
+				attrs, err := syscall.ParseNetlinkRouteAttr(&msg)
+				if err != nil {
+					continue
+				}
+
+				event := device.LinkEvent{}
+				hasCarrier := false
+				for _, attr := range attrs {
+					switch attr.Attr.Type {
+					case unix.IFLA_IFNAME:
+						event.Name = strings.TrimRight(string(attr.Value), "\x00")
+					case unix.IFLA_CARRIER:
+						if len(attr.Value) > 0 {
+							hasCarrier = true
+							event.Carrier = attr.Value[0] == 1
+						}
+					}
+				}
+
+				if event.Name == "" || !hasCarrier {
+					continue
+				}
+
+				chLink <- event
+			}
+		}
+	}(chLink)
+
+	return chLink, nil
+}
+
 func deviceTaskBalance(s *state.State) {
 	min := func(x, y int) int {
 		if x < y {
@@ -377,6 +447,8 @@ func deviceTaskBalance(s *state.State) {
 
 	fixedInstances := map[int64][]instance.Instance{}
 	balancedInstances := map[instance.Instance]int{}
+	numaInstances := map[instance.Instance][]uint64{}
+	numaCpus := map[instance.Instance][]int64{}
 	for _, c := range instances {
 		conf := c.ExpandedConfig()
 		cpulimit, ok := conf["limits.cpu"]
@@ -388,6 +460,29 @@ func deviceTaskBalance(s *state.State) {
 			continue
 		}
 
+		// Restrict to the CPUs of the NUMA nodes the instance has been placed on.
+		nodes, err := instance.NUMANodes(c)
+		if err != nil {
+			logger.Error("Problem parsing instance NUMA nodes", logger.Ctx{"name": c.Name(), "err": err})
+		} else if len(nodes) > 0 {
+			nodesCpus, err := instance.NUMANodesCPUs(nodes)
+			if err != nil {
+				logger.Error("Problem getting CPUs of NUMA nodes", logger.Ctx{"name": c.Name(), "err": err})
+			} else {
+				numaInstances[c] = nodes
+				numaCpus[c] = nodesCpus
+
+				if conf["limits.cpu"] == "" {
+					nodesCpusSlice := []string{}
+					for _, id := range nodesCpus {
+						nodesCpusSlice = append(nodesCpusSlice, fmt.Sprintf("%d", id))
+					}
+
+					cpulimit = strings.Join(nodesCpusSlice, ",")
+				}
+			}
+		}
+
 		count, err := strconv.Atoi(cpulimit)
 		if err == nil {
 			// Load-balance
@@ -457,6 +552,13 @@ func deviceTaskBalance(s *state.State) {
 			if count == 0 {
 				break
 			}
+
+			// Only use the CPUs of the instance's NUMA nodes.
+			allowedCpus, found := numaCpus[ctn]
+			if found && !shared.Int64InSlice(cpu.id, allowedCpus) {
+				continue
+			}
+
 			count -= 1
 
 			id := cpu.strId
@@ -488,6 +590,20 @@ func deviceTaskBalance(s *state.State) {
 		if err != nil {
 			logger.Error("balance: Unable to set cpuset", logger.Ctx{"name": ctn.Name(), "err": err, "value": strings.Join(set, ",")})
 		}
+
+		// Bind the memory to the instance's NUMA nodes.
+		nodes, ok := numaInstances[ctn]
+		if ok {
+			nodesSlice := []string{}
+			for _, node := range nodes {
+				nodesSlice = append(nodesSlice, fmt.Sprintf("%d", node))
+			}
+
+			err = cg.SetCpusetMems(strings.Join(nodesSlice, ","))
+			if err != nil {
+				logger.Error("balance: Unable to set cpuset memory nodes", logger.Ctx{"name": ctn.Name(), "err": err, "value": strings.Join(nodesSlice, ",")})
+			}
+		}
 	}
 }
 
@@ -533,8 +649,16 @@ func deviceEventListener(s *state.State) {
 		return
 	}
 
+	chLink, err := deviceLinkListener()
+	if err != nil {
+		logger.Errorf("scheduler: Couldn't setup link listener: %v", err)
+		return
+	}
+
 	for {
 		select {
+		case e := <-chLink:
+			device.LinkRunHandlers(s, &e)
 		case e := <-chNetlinkCPU:
 			if len(e) != 2 {
 				logger.Errorf("Scheduler: received an invalid cpu hotplug event")
@@ -26,6 +26,7 @@ import (
 	"github.com/lxc/lxd/lxd/ucred"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/version"
 )
@@ -181,6 +182,39 @@ var devlxdDevicesGet = devLxdHandler{"/1.0/devices", func(d *Daemon, c instance.
 	return okResponse(c.ExpandedDevices(), "json")
 }}
 
+var devlxdSecretsGet = devLxdHandler{"/1.0/secrets", func(d *Daemon, c instance.Instance, w http.ResponseWriter, r *http.Request) *devLxdResponse {
+	names, err := d.db.Cluster.GetInstanceSecretNames(c.ID())
+	if err != nil {
+		return &devLxdResponse{"internal server error", http.StatusInternalServerError, "raw"}
+	}
+
+	secrets := make([]string, 0, len(names))
+	for _, name := range names {
+		secrets = append(secrets, fmt.Sprintf("/1.0/secrets/%s", name))
+	}
+
+	return okResponse(secrets, "json")
+}}
+
+var devlxdSecretGet = devLxdHandler{"/1.0/secrets/{name}", func(d *Daemon, c instance.Instance, w http.ResponseWriter, r *http.Request) *devLxdResponse {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return &devLxdResponse{"bad request", http.StatusBadRequest, "raw"}
+	}
+
+	// The secret is deleted as it's retrieved so that it can only be retrieved once.
+	value, err := d.db.Cluster.ConsumeInstanceSecret(c.ID(), name)
+	if err != nil {
+		if api.StatusErrorCheck(err, http.StatusNotFound) {
+			return &devLxdResponse{"not found", http.StatusNotFound, "raw"}
+		}
+
+		return &devLxdResponse{"internal server error", http.StatusInternalServerError, "raw"}
+	}
+
+	return okResponse(value, "raw")
+}}
+
 var handlers = []devLxdHandler{
 	{"/", func(d *Daemon, c instance.Instance, w http.ResponseWriter, r *http.Request) *devLxdResponse {
 		return okResponse([]string{"/1.0"}, "json")
@@ -192,6 +226,8 @@ var handlers = []devLxdHandler{
 	devlxdEventsGet,
 	devlxdImageExport,
 	devlxdDevicesGet,
+	devlxdSecretsGet,
+	devlxdSecretGet,
 }
 
 func hoistReq(f func(*Daemon, instance.Instance, http.ResponseWriter, *http.Request) *devLxdResponse, d *Daemon) func(http.ResponseWriter, *http.Request) {
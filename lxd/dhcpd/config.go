@@ -0,0 +1,178 @@
+package dhcpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config represents the configuration of the builtin DHCP server for a network.
+type Config struct {
+	// Name of the interface to serve.
+	Interface string `json:"interface"`
+
+	// Path to the leases file (uses the dnsmasq leases file format).
+	LeasesPath string `json:"leases_path"`
+
+	// Path to the directory of static allocations (uses the dnsmasq dhcp-hostsfile format).
+	HostsPath string `json:"hosts_path"`
+
+	IPv4 *ConfigIPv4 `json:"ipv4,omitempty"`
+	IPv6 *ConfigIPv6 `json:"ipv6,omitempty"`
+}
+
+// ConfigIPv4 represents the DHCPv4 configuration.
+type ConfigIPv4 struct {
+	// Address and subnet of the server in CIDR notation.
+	Address string `json:"address"`
+
+	// Dynamic allocation ranges in the form "<start>-<end>".
+	Ranges []string `json:"ranges"`
+
+	// Gateway to hand out to clients. Defaults to the server address.
+	Gateway string `json:"gateway,omitempty"`
+
+	// Lease time (accepts the same values as the ipv4.dhcp.expiry network key).
+	LeaseTime string `json:"lease_time,omitempty"`
+
+	MTU          uint32   `json:"mtu,omitempty"`
+	Nameservers  []string `json:"nameservers,omitempty"`
+	DomainSearch []string `json:"domain_search,omitempty"`
+}
+
+// ConfigIPv6 represents the router advertisement configuration.
+type ConfigIPv6 struct {
+	// Address and subnet of the server in CIDR notation.
+	Address string `json:"address"`
+
+	// Whether to advertise the subnet for stateless address autoconfiguration.
+	Autonomous bool `json:"autonomous"`
+
+	MTU          uint32   `json:"mtu,omitempty"`
+	Nameservers  []string `json:"nameservers,omitempty"`
+	DomainSearch []string `json:"domain_search,omitempty"`
+}
+
+// LoadConfig reads the configuration from the given path.
+func LoadConfig(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = json.Unmarshal(content, config)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing DHCP server config %q: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// Save writes the configuration to the given path.
+func (c *Config) Save(path string) error {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+// ParseLeaseTime parses a lease time in one of the formats supported by dnsmasq.
+// Returns 0 for infinite leases.
+func ParseLeaseTime(value string) (time.Duration, error) {
+	if value == "" {
+		return time.Hour, nil
+	}
+
+	if value == "infinite" {
+		return 0, nil
+	}
+
+	seconds, err := strconv.ParseUint(value, 10, 32)
+	if err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < time.Minute || duration.Seconds() > math.MaxUint32 {
+		return 0, fmt.Errorf("Invalid lease time %q", value)
+	}
+
+	return duration, nil
+}
+
+// ipRange represents a range of IPv4 addresses.
+type ipRange struct {
+	start uint32
+	end   uint32
+}
+
+// parseRanges parses a list of "<start>-<end>" IPv4 ranges.
+func parseRanges(ranges []string) ([]ipRange, error) {
+	parsed := make([]ipRange, 0, len(ranges))
+	for _, r := range ranges {
+		startStr, endStr, found := strings.Cut(r, "-")
+		if !found {
+			return nil, fmt.Errorf("Invalid IP range %q", r)
+		}
+
+		start := net.ParseIP(strings.TrimSpace(startStr)).To4()
+		end := net.ParseIP(strings.TrimSpace(endStr)).To4()
+		if start == nil || end == nil || ipToUint32(start) > ipToUint32(end) {
+			return nil, fmt.Errorf("Invalid IP range %q", r)
+		}
+
+		parsed = append(parsed, ipRange{start: ipToUint32(start), end: ipToUint32(end)})
+	}
+
+	return parsed, nil
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	ip = ip.To4()
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+}
+
+func uint32ToIP(n uint32) net.IP {
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)).To4()
+}
+
+// HostNameservers returns the upstream nameservers of the host, skipping local resolvers that are not
+// reachable from the instances.
+func HostNameservers() []string {
+	nameservers := []string{}
+
+	for _, path := range []string{"/run/systemd/resolve/resolv.conf", "/etc/resolv.conf"} {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || fields[0] != "nameserver" {
+				continue
+			}
+
+			ip := net.ParseIP(fields[1])
+			if ip == nil || ip.IsLoopback() {
+				continue
+			}
+
+			nameservers = append(nameservers, ip.String())
+		}
+
+		if len(nameservers) > 0 {
+			break
+		}
+	}
+
+	return nameservers
+}
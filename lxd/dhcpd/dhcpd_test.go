@@ -0,0 +1,125 @@
+package dhcpd
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseHost(t *testing.T) {
+	host, err := ParseHost("00:16:3e:1a:2b:3c,10.0.0.10,[fd42::10],c1")
+	require.NoError(t, err)
+	assert.Equal(t, "00:16:3e:1a:2b:3c", host.MAC.String())
+	assert.Equal(t, "10.0.0.10", host.IPv4.String())
+	assert.Equal(t, "fd42::10", host.IPv6.String())
+	assert.Equal(t, "c1", host.Hostname)
+
+	host, err = ParseHost("00:16:3e:1a:2b:3c,c1")
+	require.NoError(t, err)
+	assert.Nil(t, host.IPv4)
+	assert.Equal(t, "c1", host.Hostname)
+
+	_, err = ParseHost("c1,10.0.0.10")
+	assert.Error(t, err)
+}
+
+func Test_leasesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dnsmasq.leases")
+	mac, _ := net.ParseMAC("00:16:3e:1a:2b:3c")
+
+	leases := map[string]*Lease{
+		mac.String(): {Expiry: time.Unix(1700000000, 0), MAC: mac, IP: net.ParseIP("10.0.0.10").To4(), Hostname: "c1"},
+	}
+
+	err := WriteLeases(path, leases)
+	require.NoError(t, err)
+
+	read, err := ReadLeases(path)
+	require.NoError(t, err)
+	require.Len(t, read, 1)
+	assert.Equal(t, leases[mac.String()], read[mac.String()])
+}
+
+func Test_parseLeaseTime(t *testing.T) {
+	for value, expected := range map[string]time.Duration{"": time.Hour, "infinite": 0, "3600": time.Hour, "45m": 45 * time.Minute} {
+		leaseTime, err := ParseLeaseTime(value)
+		require.NoError(t, err)
+		assert.Equal(t, expected, leaseTime)
+	}
+
+	_, err := ParseLeaseTime("forever")
+	assert.Error(t, err)
+}
+
+func Test_serverV4Handle(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{
+		Interface:  "lxdbr0",
+		LeasesPath: filepath.Join(dir, "dnsmasq.leases"),
+		HostsPath:  filepath.Join(dir, "dnsmasq.hosts"),
+		IPv4: &ConfigIPv4{
+			Address: "10.0.0.1/24",
+			Ranges:  []string{"10.0.0.2-10.0.0.3"},
+		},
+	}
+
+	s, err := newServerV4(config)
+	require.NoError(t, err)
+
+	request := func(mac string, msgType layers.DHCPMsgType, requested net.IP) *layers.DHCPv4 {
+		hwaddr, _ := net.ParseMAC(mac)
+		req := &layers.DHCPv4{Operation: layers.DHCPOpRequest, ClientIP: net.IPv4zero, ClientHWAddr: hwaddr}
+		req.Options = append(req.Options, layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(msgType)}))
+		if requested != nil {
+			req.Options = append(req.Options, layers.NewDHCPOption(layers.DHCPOptRequestIP, requested.To4()))
+		}
+
+		return req
+	}
+
+	now := time.Now()
+
+	// Offered addresses are held for the client.
+	offer, err := s.handle(request("00:16:3e:00:00:01", layers.DHCPMsgTypeDiscover, nil), now)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", offer.YourClientIP.String())
+
+	offer, err = s.handle(request("00:16:3e:00:00:02", layers.DHCPMsgTypeDiscover, nil), now)
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.3", offer.YourClientIP.String())
+
+	// Requesting an address held for another client is refused.
+	nak, err := s.handle(request("00:16:3e:00:00:02", layers.DHCPMsgTypeRequest, net.ParseIP("10.0.0.2")), now)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{byte(layers.DHCPMsgTypeNak)}, nak.Options[0].Data)
+
+	ack, err := s.handle(request("00:16:3e:00:00:01", layers.DHCPMsgTypeRequest, net.ParseIP("10.0.0.2")), now)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{byte(layers.DHCPMsgTypeAck)}, ack.Options[0].Data)
+
+	leases, err := ReadLeases(config.LeasesPath)
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	assert.Equal(t, "10.0.0.2", leases["00:16:3e:00:00:01"].IP.String())
+
+	// The range is exhausted until the offer expires.
+	_, err = s.handle(request("00:16:3e:00:00:03", layers.DHCPMsgTypeDiscover, nil), now)
+	assert.Error(t, err)
+
+	offer, err = s.handle(request("00:16:3e:00:00:03", layers.DHCPMsgTypeDiscover, nil), now.Add(2*offerLeaseTime))
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.3", offer.YourClientIP.String())
+
+	// Released leases are removed.
+	_, err = s.handle(request("00:16:3e:00:00:01", layers.DHCPMsgTypeRelease, nil), now)
+	require.NoError(t, err)
+
+	leases, err = ReadLeases(config.LeasesPath)
+	require.NoError(t, err)
+	assert.Len(t, leases, 0)
+}
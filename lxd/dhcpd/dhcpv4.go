@@ -0,0 +1,495 @@
+package dhcpd
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// offerLeaseTime is how long an offered address is kept for the client before it is requested.
+const offerLeaseTime = time.Minute
+
+// validHostname matches the client supplied host names that are recorded in the leases file.
+var validHostname = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// allocator decides which address to give to a client.
+type allocator struct {
+	server net.IP
+	subnet *net.IPNet
+	ranges []ipRange
+	hosts  []Host
+	leases map[string]*Lease
+	offers map[string]*Lease
+}
+
+// staticHost returns the static allocation for the MAC address (if any).
+func (a *allocator) staticHost(mac net.HardwareAddr) *Host {
+	for i := range a.hosts {
+		if a.hosts[i].MAC.String() == mac.String() {
+			return &a.hosts[i]
+		}
+	}
+
+	return nil
+}
+
+// available returns whether the address can be given to the client with the MAC address.
+func (a *allocator) available(ip net.IP, mac net.HardwareAddr, now time.Time) bool {
+	if ip.Equal(a.server) || !a.subnet.Contains(ip) {
+		return false
+	}
+
+	for _, host := range a.hosts {
+		if host.IPv4 != nil && host.IPv4.Equal(ip) && host.MAC.String() != mac.String() {
+			return false
+		}
+	}
+
+	for _, leases := range []map[string]*Lease{a.leases, a.offers} {
+		for key, lease := range leases {
+			if lease.IP.Equal(ip) && key != mac.String() && !lease.Expired(now) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// inRanges returns whether the address is part of the dynamic allocation ranges.
+func (a *allocator) inRanges(ip net.IP) bool {
+	n := ipToUint32(ip)
+	for _, r := range a.ranges {
+		if n >= r.start && n <= r.end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allocate returns the address to give to the client, in order of preference its static allocation, the
+// address of its previous lease, the address it requested and finally the first free dynamic address.
+func (a *allocator) allocate(mac net.HardwareAddr, requested net.IP, now time.Time) (net.IP, error) {
+	host := a.staticHost(mac)
+	if host != nil && host.IPv4 != nil {
+		return host.IPv4, nil
+	}
+
+	lease := a.leases[mac.String()]
+	if lease != nil && a.available(lease.IP, mac, now) {
+		return lease.IP, nil
+	}
+
+	if requested != nil && a.inRanges(requested) && a.available(requested, mac, now) {
+		return requested.To4(), nil
+	}
+
+	for _, r := range a.ranges {
+		for n := r.start; n <= r.end && n >= r.start; n++ {
+			ip := uint32ToIP(n)
+			if a.available(ip, mac, now) {
+				return ip, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("No free addresses left")
+}
+
+// serverV4 is a DHCPv4 server.
+type serverV4 struct {
+	mu sync.Mutex
+
+	config    *Config
+	address   net.IP
+	subnet    *net.IPNet
+	ranges    []ipRange
+	leaseTime time.Duration
+	leases    map[string]*Lease
+	offers    map[string]*Lease
+}
+
+// newServerV4 creates a new DHCPv4 server from the config.
+func newServerV4(config *Config) (*serverV4, error) {
+	s := &serverV4{offers: map[string]*Lease{}}
+
+	err := s.load(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s.leases, err = ReadLeases(config.LeasesPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading leases: %w", err)
+	}
+
+	return s, nil
+}
+
+// load applies the config to the server.
+func (s *serverV4) load(config *Config) error {
+	address, subnet, err := net.ParseCIDR(config.IPv4.Address)
+	if err != nil {
+		return fmt.Errorf("Invalid IPv4 address %q: %w", config.IPv4.Address, err)
+	}
+
+	ranges, err := parseRanges(config.IPv4.Ranges)
+	if err != nil {
+		return err
+	}
+
+	leaseTime, err := ParseLeaseTime(config.IPv4.LeaseTime)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config = config
+	s.address = address.To4()
+	s.subnet = subnet
+	s.ranges = ranges
+	s.leaseTime = leaseTime
+
+	return nil
+}
+
+// listen opens the DHCPv4 socket on the interface.
+func (s *serverV4) listen(ctx context.Context) (net.PacketConn, error) {
+	lc := net.ListenConfig{
+		Control: func(network string, address string, c syscall.RawConn) error {
+			var sockErr error
+			err := c.Control(func(fd uintptr) {
+				sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1)
+				if sockErr != nil {
+					return
+				}
+
+				sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_BROADCAST, 1)
+				if sockErr != nil {
+					return
+				}
+
+				sockErr = unix.BindToDevice(int(fd), s.config.Interface)
+			})
+			if err != nil {
+				return err
+			}
+
+			return sockErr
+		},
+	}
+
+	return lc.ListenPacket(ctx, "udp4", "0.0.0.0:67")
+}
+
+// serve handles DHCPv4 requests until the context is cancelled.
+func (s *serverV4) serve(ctx context.Context) error {
+	conn, err := s.listen(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed listening for DHCPv4 requests on %q: %w", s.config.Interface, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		// The decoded packet references the data, so don't share the read buffer with stored leases.
+		data := make([]byte, n)
+		copy(data, buf[:n])
+
+		req := &layers.DHCPv4{}
+		err = req.DecodeFromBytes(data, gopacket.NilDecodeFeedback)
+		if err != nil || req.Operation != layers.DHCPOpRequest {
+			continue
+		}
+
+		reply, err := s.handle(req, time.Now())
+		if err != nil {
+			logger.Warn("Failed handling DHCPv4 request", logger.Ctx{"mac": req.ClientHWAddr.String(), "err": err})
+			continue
+		}
+
+		if reply == nil {
+			continue
+		}
+
+		err = s.send(conn, addr, req, reply)
+		if err != nil {
+			logger.Warn("Failed sending DHCPv4 reply", logger.Ctx{"mac": req.ClientHWAddr.String(), "err": err})
+		}
+	}
+}
+
+// send writes the reply to the client.
+func (s *serverV4) send(conn net.PacketConn, addr net.Addr, req *layers.DHCPv4, reply *layers.DHCPv4) error {
+	buf := gopacket.NewSerializeBuffer()
+	err := reply.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true})
+	if err != nil {
+		return err
+	}
+
+	// Clients that already have an address can be reached directly, all others only by broadcast.
+	dst := &net.UDPAddr{IP: net.IPv4bcast, Port: 68}
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if ok && !req.ClientIP.IsUnspecified() && req.ClientIP.Equal(udpAddr.IP) {
+		dst.IP = udpAddr.IP
+	}
+
+	_, err = conn.WriteTo(buf.Bytes(), dst)
+	return err
+}
+
+// handle processes a request and returns the reply to send (if any).
+func (s *serverV4) handle(req *layers.DHCPv4, now time.Time) (*layers.DHCPv4, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var msgType layers.DHCPMsgType
+	var requestedIP net.IP
+	var serverID net.IP
+	var hostname string
+	var clientID string
+
+	for _, opt := range req.Options {
+		switch opt.Type {
+		case layers.DHCPOptMessageType:
+			if len(opt.Data) == 1 {
+				msgType = layers.DHCPMsgType(opt.Data[0])
+			}
+
+		case layers.DHCPOptRequestIP:
+			if len(opt.Data) == 4 {
+				requestedIP = net.IP(opt.Data).To4()
+			}
+
+		case layers.DHCPOptServerID:
+			if len(opt.Data) == 4 {
+				serverID = net.IP(opt.Data).To4()
+			}
+
+		case layers.DHCPOptHostname:
+			if validHostname.Match(opt.Data) {
+				hostname = string(opt.Data)
+			}
+
+		case layers.DHCPOptClientID:
+			clientID = fmt.Sprintf("%x", opt.Data)
+		}
+	}
+
+	hosts, err := ReadHosts(s.config.HostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed reading static allocations: %w", err)
+	}
+
+	alloc := &allocator{server: s.address, subnet: s.subnet, ranges: s.ranges, hosts: hosts, leases: s.leases, offers: s.offers}
+	mac := req.ClientHWAddr
+
+	host := alloc.staticHost(mac)
+	if host != nil && host.Hostname != "" {
+		hostname = host.Hostname
+	}
+
+	switch msgType {
+	case layers.DHCPMsgTypeDiscover:
+		ip, err := alloc.allocate(mac, requestedIP, now)
+		if err != nil {
+			return nil, err
+		}
+
+		// Hold the address for the client while it decides.
+		s.offers[mac.String()] = &Lease{Expiry: now.Add(offerLeaseTime), MAC: mac, IP: ip}
+
+		return s.reply(req, layers.DHCPMsgTypeOffer, ip, hostname), nil
+
+	case layers.DHCPMsgTypeRequest:
+		// The client has selected another server.
+		if serverID != nil && !serverID.Equal(s.address) {
+			return nil, nil
+		}
+
+		// Renewing clients don't include the requested IP option.
+		if requestedIP == nil {
+			requestedIP = req.ClientIP.To4()
+		}
+
+		ip, err := alloc.allocate(mac, requestedIP, now)
+		if err != nil || requestedIP == nil || !ip.Equal(requestedIP) {
+			return s.reply(req, layers.DHCPMsgTypeNak, nil, ""), nil
+		}
+
+		lease := &Lease{MAC: mac, IP: ip, Hostname: hostname, ClientID: clientID}
+		if s.leaseTime > 0 {
+			lease.Expiry = now.Add(s.leaseTime)
+		}
+
+		s.leases[mac.String()] = lease
+		delete(s.offers, mac.String())
+
+		err = s.saveLeases(now)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.reply(req, layers.DHCPMsgTypeAck, ip, hostname), nil
+
+	case layers.DHCPMsgTypeRelease, layers.DHCPMsgTypeDecline:
+		delete(s.offers, mac.String())
+
+		lease := s.leases[mac.String()]
+		if lease == nil {
+			return nil, nil
+		}
+
+		delete(s.leases, mac.String())
+
+		return nil, s.saveLeases(now)
+
+	case layers.DHCPMsgTypeInform:
+		return s.reply(req, layers.DHCPMsgTypeAck, nil, ""), nil
+	}
+
+	return nil, nil
+}
+
+// saveLeases removes the expired leases and offers and writes the leases to the leases file.
+func (s *serverV4) saveLeases(now time.Time) error {
+	for _, leases := range []map[string]*Lease{s.leases, s.offers} {
+		for key, lease := range leases {
+			if lease.Expired(now) {
+				delete(leases, key)
+			}
+		}
+	}
+
+	return WriteLeases(s.config.LeasesPath, s.leases)
+}
+
+// reply builds a reply of the given type to the request.
+func (s *serverV4) reply(req *layers.DHCPv4, msgType layers.DHCPMsgType, ip net.IP, hostname string) *layers.DHCPv4 {
+	reply := &layers.DHCPv4{
+		Operation:    layers.DHCPOpReply,
+		HardwareType: req.HardwareType,
+		Xid:          req.Xid,
+		Flags:        req.Flags,
+		ClientIP:     req.ClientIP,
+		YourClientIP: net.IPv4zero,
+		NextServerIP: net.IPv4zero,
+		RelayAgentIP: req.RelayAgentIP,
+		ClientHWAddr: req.ClientHWAddr,
+	}
+
+	if ip != nil {
+		reply.YourClientIP = ip
+	}
+
+	reply.Options = append(reply.Options,
+		layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(msgType)}),
+		layers.NewDHCPOption(layers.DHCPOptServerID, s.address),
+	)
+
+	if msgType == layers.DHCPMsgTypeNak {
+		return reply
+	}
+
+	if ip != nil {
+		leaseTime := make([]byte, 4)
+		if s.leaseTime > 0 {
+			binary.BigEndian.PutUint32(leaseTime, uint32(s.leaseTime.Seconds()))
+		} else {
+			binary.BigEndian.PutUint32(leaseTime, 0xffffffff)
+		}
+
+		reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOptLeaseTime, leaseTime))
+	}
+
+	reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOptSubnetMask, s.subnet.Mask))
+
+	router := s.address
+	if s.config.IPv4.Gateway != "" {
+		router = net.ParseIP(s.config.IPv4.Gateway).To4()
+	}
+
+	if router != nil {
+		reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOptRouter, router))
+	}
+
+	nameservers := []byte{}
+	for _, nameserver := range s.config.IPv4.Nameservers {
+		ip := net.ParseIP(nameserver).To4()
+		if ip != nil {
+			nameservers = append(nameservers, ip...)
+		}
+	}
+
+	if len(nameservers) > 0 {
+		reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOptDNS, nameservers))
+	}
+
+	if s.config.IPv4.MTU > 0 {
+		mtu := make([]byte, 2)
+		binary.BigEndian.PutUint16(mtu, uint16(s.config.IPv4.MTU))
+		reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOptInterfaceMTU, mtu))
+	}
+
+	if len(s.config.IPv4.DomainSearch) > 0 {
+		reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOpt(119), encodeDomainSearch(s.config.IPv4.DomainSearch)))
+	}
+
+	if hostname != "" {
+		reply.Options = append(reply.Options, layers.NewDHCPOption(layers.DHCPOptHostname, []byte(hostname)))
+	}
+
+	return reply
+}
+
+// encodeDomainSearch encodes the domains in the format of the domain search option (RFC 3397).
+func encodeDomainSearch(domains []string) []byte {
+	buf := []byte{}
+	for _, domain := range domains {
+		for _, label := range strings.Split(strings.Trim(domain, "."), ".") {
+			if label == "" || len(label) > 63 {
+				continue
+			}
+
+			buf = append(buf, byte(len(label)))
+			buf = append(buf, label...)
+		}
+
+		buf = append(buf, 0)
+	}
+
+	// DHCP options are limited to 255 bytes.
+	if len(buf) > 255 {
+		buf = buf[:0]
+	}
+
+	return buf
+}
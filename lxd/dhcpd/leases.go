@@ -0,0 +1,210 @@
+package dhcpd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Host represents a static allocation.
+type Host struct {
+	MAC      net.HardwareAddr
+	IPv4     net.IP
+	IPv6     net.IP
+	Hostname string
+}
+
+// Lease represents a dynamic DHCPv4 lease.
+type Lease struct {
+	// Zero for infinite leases.
+	Expiry   time.Time
+	MAC      net.HardwareAddr
+	IP       net.IP
+	Hostname string
+	ClientID string
+}
+
+// Expired returns whether the lease has expired at the given time.
+func (l *Lease) Expired(now time.Time) bool {
+	return !l.Expiry.IsZero() && now.After(l.Expiry)
+}
+
+// ParseHost parses a line in the dnsmasq dhcp-hostsfile format ("<mac>[,<ipv4>][,[<ipv6>]][,<hostname>]").
+func ParseHost(line string) (*Host, error) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+
+	mac, err := net.ParseMAC(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid MAC address in static allocation %q", line)
+	}
+
+	host := &Host{MAC: mac}
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "[") && strings.HasSuffix(field, "]") {
+			host.IPv6 = net.ParseIP(strings.Trim(field, "[]"))
+			if host.IPv6 == nil {
+				return nil, fmt.Errorf("Invalid IPv6 address in static allocation %q", line)
+			}
+
+			continue
+		}
+
+		ip := net.ParseIP(field)
+		if ip != nil && ip.To4() != nil {
+			host.IPv4 = ip.To4()
+			continue
+		}
+
+		host.Hostname = field
+	}
+
+	return host, nil
+}
+
+// ReadHosts reads the static allocations from all the files in the given directory.
+func ReadHosts(path string) ([]Host, error) {
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	hosts := []Host{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			host, err := ParseHost(line)
+			if err != nil {
+				return nil, err
+			}
+
+			hosts = append(hosts, *host)
+		}
+	}
+
+	return hosts, nil
+}
+
+// ReadLeases reads the DHCPv4 leases from a file in the dnsmasq leases file format.
+// Returns no leases if the file doesn't exist.
+func ReadLeases(path string) (map[string]*Lease, error) {
+	leases := map[string]*Lease{}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return leases, nil
+		}
+
+		return nil, err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 5 {
+			continue
+		}
+
+		expiry, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		mac, err := net.ParseMAC(fields[1])
+		if err != nil {
+			continue
+		}
+
+		ip := net.ParseIP(fields[2]).To4()
+		if ip == nil {
+			continue
+		}
+
+		lease := &Lease{MAC: mac, IP: ip}
+		if expiry > 0 {
+			lease.Expiry = time.Unix(expiry, 0)
+		}
+
+		if fields[3] != "*" {
+			lease.Hostname = fields[3]
+		}
+
+		if fields[4] != "*" {
+			lease.ClientID = fields[4]
+		}
+
+		leases[mac.String()] = lease
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// WriteLeases atomically writes the leases to a file in the dnsmasq leases file format.
+func WriteLeases(path string, leases map[string]*Lease) error {
+	keys := make([]string, 0, len(leases))
+	for key := range leases {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		lease := leases[key]
+
+		var expiry int64
+		if !lease.Expiry.IsZero() {
+			expiry = lease.Expiry.Unix()
+		}
+
+		hostname := lease.Hostname
+		if hostname == "" {
+			hostname = "*"
+		}
+
+		clientID := lease.ClientID
+		if clientID == "" {
+			clientID = "*"
+		}
+
+		sb.WriteString(fmt.Sprintf("%d %s %s %s %s\n", expiry, lease.MAC.String(), lease.IP.String(), hostname, clientID))
+	}
+
+	tmpPath := path + ".tmp"
+	err := ioutil.WriteFile(tmpPath, []byte(sb.String()), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,167 @@
+package dhcpd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/mdlayher/ndp"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// raInterval is the interval between unsolicited router advertisements.
+const raInterval = 2 * time.Minute
+
+// raRouterLifetime is the router lifetime advertised to clients.
+const raRouterLifetime = 30 * time.Minute
+
+// serverRA is an IPv6 router advertisement server.
+type serverRA struct {
+	config func() *Config
+}
+
+// listen opens the NDP socket on the interface, waiting for its link-local address to be usable.
+func (s *serverRA) listen(ctx context.Context) (*ndp.Conn, *net.Interface, error) {
+	for {
+		ifi, err := net.InterfaceByName(s.config().Interface)
+		if err == nil {
+			conn, _, err := ndp.Listen(ifi, ndp.LinkLocal)
+			if err == nil {
+				return conn, ifi, nil
+			}
+
+			logger.Debug("Waiting for link-local address to send router advertisements", logger.Ctx{"interface": ifi.Name, "err": err})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// serve sends router advertisements periodically and in reply to router solicitations until the context
+// is cancelled.
+func (s *serverRA) serve(ctx context.Context) error {
+	conn, ifi, err := s.listen(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	err = conn.JoinGroup(netip.MustParseAddr("ff02::2"))
+	if err != nil {
+		return fmt.Errorf("Failed joining all routers multicast group: %w", err)
+	}
+
+	solicited := make(chan struct{}, 1)
+	go func() {
+		for {
+			msg, _, _, err := conn.ReadFrom()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				continue // Ignore the ICMPv6 messages that aren't NDP messages.
+			}
+
+			_, ok := msg.(*ndp.RouterSolicitation)
+			if !ok {
+				continue
+			}
+
+			select {
+			case solicited <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(raInterval)
+	defer ticker.Stop()
+
+	for {
+		ra, err := s.advertisement(ifi)
+		if err != nil {
+			return err
+		}
+
+		err = conn.WriteTo(ra, nil, netip.IPv6LinkLocalAllNodes())
+		if err != nil && ctx.Err() == nil {
+			logger.Warn("Failed sending router advertisement", logger.Ctx{"interface": ifi.Name, "err": err})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-solicited:
+		}
+	}
+}
+
+// advertisement builds the router advertisement from the current config.
+func (s *serverRA) advertisement(ifi *net.Interface) (*ndp.RouterAdvertisement, error) {
+	config := s.config().IPv6
+
+	prefix, err := netip.ParsePrefix(config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid IPv6 address %q: %w", config.Address, err)
+	}
+
+	prefix = prefix.Masked()
+
+	ra := &ndp.RouterAdvertisement{
+		CurrentHopLimit: 64,
+		RouterLifetime:  raRouterLifetime,
+		Options: []ndp.Option{
+			&ndp.PrefixInformation{
+				PrefixLength:                   uint8(prefix.Bits()),
+				OnLink:                         true,
+				AutonomousAddressConfiguration: config.Autonomous,
+				ValidLifetime:                  2 * time.Hour,
+				PreferredLifetime:              time.Hour,
+				Prefix:                         prefix.Addr(),
+			},
+			&ndp.LinkLayerAddress{
+				Direction: ndp.Source,
+				Addr:      ifi.HardwareAddr,
+			},
+		},
+	}
+
+	if config.MTU > 0 {
+		ra.Options = append(ra.Options, ndp.NewMTU(config.MTU))
+	}
+
+	nameservers := []netip.Addr{}
+	for _, nameserver := range config.Nameservers {
+		addr, err := netip.ParseAddr(nameserver)
+		if err == nil && addr.Is6() {
+			nameservers = append(nameservers, addr)
+		}
+	}
+
+	if len(nameservers) > 0 {
+		ra.Options = append(ra.Options, &ndp.RecursiveDNSServer{Lifetime: raRouterLifetime, Servers: nameservers})
+	}
+
+	if len(config.DomainSearch) > 0 {
+		ra.Options = append(ra.Options, &ndp.DNSSearchList{Lifetime: raRouterLifetime, DomainNames: config.DomainSearch})
+	}
+
+	return ra, nil
+}
@@ -0,0 +1,92 @@
+// Package dhcpd implements the builtin DHCPv4 and IPv6 router advertisement server used by bridge networks
+// as an alternative to dnsmasq.
+package dhcpd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Server is the builtin DHCP server for a network.
+type Server struct {
+	mu sync.Mutex
+
+	config *Config
+	v4     *serverV4
+}
+
+// NewServer creates a new server from the config.
+func NewServer(config *Config) (*Server, error) {
+	s := &Server{config: config}
+
+	if config.IPv4 != nil {
+		v4, err := newServerV4(config)
+		if err != nil {
+			return nil, err
+		}
+
+		s.v4 = v4
+	}
+
+	return s, nil
+}
+
+// Reload applies an updated config. Changing the interface or the enabled protocols requires a restart.
+func (s *Server) Reload(config *Config) error {
+	if config.Interface != s.currentConfig().Interface || (config.IPv4 == nil) != (s.v4 == nil) {
+		return fmt.Errorf("Interface or enabled protocols cannot be changed without restarting")
+	}
+
+	if s.v4 != nil {
+		err := s.v4.load(config)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+
+	return nil
+}
+
+// currentConfig returns the config currently in use.
+func (s *Server) currentConfig() *Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.config
+}
+
+// Run serves the network until the context is cancelled or one of the protocols fails.
+func (s *Server) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errCh := make(chan error, 2)
+	running := 0
+
+	if s.v4 != nil {
+		running++
+		go func() { errCh <- s.v4.serve(ctx) }()
+	}
+
+	if s.config.IPv6 != nil {
+		running++
+		ra := &serverRA{config: s.currentConfig}
+		go func() { errCh <- ra.serve(ctx) }()
+	}
+
+	var err error
+	for i := 0; i < running; i++ {
+		runErr := <-errCh
+		if runErr != nil && err == nil {
+			err = runErr
+			cancel()
+		}
+	}
+
+	return err
+}
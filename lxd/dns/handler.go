@@ -39,8 +39,9 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		return
 	}
 
-	// Check that it's AXFR.
-	if r.Question[0].Qtype != dns.TypeAXFR {
+	// Check that it's a supported query.
+	qtype := r.Question[0].Qtype
+	if r.Question[0].Qclass != dns.ClassINET && r.Question[0].Qclass != dns.ClassANY {
 		m := new(dns.Msg)
 		m.SetRcode(r, dns.RcodeNotImplemented)
 		err := w.WriteMsg(m)
@@ -51,7 +52,7 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	}
 
 	// Extract the request information.
-	name := strings.TrimSuffix(r.Question[0].Name, ".")
+	name := strings.ToLower(strings.TrimSuffix(r.Question[0].Name, "."))
 	ip, _, err := net.SplitHostPort(w.RemoteAddr().String())
 	if err != nil {
 		m := new(dns.Msg)
@@ -63,13 +64,34 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		return
 	}
 
+	// Check the TSIG signature before doing any lookup.
+	tsig := r.IsTsig()
+	tsigStatus := w.TsigStatus() == nil
+	if tsig != nil && !tsigStatus {
+		// On auth failure, return NXDOMAIN to avoid information leaks.
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeNameError)
+		err := w.WriteMsg(m)
+		if err != nil {
+			logger.Error("Unable to write message", logger.Ctx{"err": err})
+		}
+		return
+	}
+
 	// Prepare the response.
 	m := new(dns.Msg)
 	m.SetReply(r)
 	m.Authoritative = true
 
-	// Load the zone.
-	zone, err := d.server.zoneRetriever(name)
+	// Find the zone.
+	// Zone transfers are requested for the zone itself, other queries can be for any name within a zone.
+	var zone *Zone
+	if qtype == dns.TypeAXFR || qtype == dns.TypeIXFR {
+		zone, err = d.server.zone(name, false)
+	} else {
+		zone, err = d.findZone(name)
+	}
+
 	if err != nil {
 		// On failure, return NXDOMAIN.
 		m := new(dns.Msg)
@@ -81,8 +103,8 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		return
 	}
 
-	// Check access.
-	if !d.isAllowed(zone.Info, ip, r.IsTsig(), w.TsigStatus() == nil) {
+	// Check access before rendering the zone.
+	if !d.isAllowed(zone.Info, ip, tsig, tsigStatus) {
 		// On auth failure, return NXDOMAIN to avoid information leaks.
 		m := new(dns.Msg)
 		m.SetRcode(r, dns.RcodeNameError)
@@ -93,31 +115,39 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		return
 	}
 
-	zoneRR := dns.NewZoneParser(strings.NewReader(zone.Content), "", "")
-	for {
-		rr, ok := zoneRR.Next()
-		if !ok {
-			err := zoneRR.Err()
-			if err != nil {
-				logger.Errorf("Bad DNS record in zone %q: %v", name, err)
+	// Load the zone content.
+	zone, err = d.server.zone(zone.Info.Name, true)
+	if err != nil {
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeServerFailure)
+		err := w.WriteMsg(m)
+		if err != nil {
+			logger.Error("Unable to write message", logger.Ctx{"err": err})
+		}
+		return
+	}
 
-				m := new(dns.Msg)
-				m.SetRcode(r, dns.RcodeFormatError)
-				err := w.WriteMsg(m)
-				if err != nil {
-					logger.Error("Unable to write message", logger.Ctx{"err": err})
-				}
-				return
-			}
+	records, err := zone.Records()
+	if err != nil {
+		logger.Errorf("Bad DNS record in zone %q: %v", zone.Info.Name, err)
 
-			break
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeFormatError)
+		err := w.WriteMsg(m)
+		if err != nil {
+			logger.Error("Unable to write message", logger.Ctx{"err": err})
 		}
+		return
+	}
 
-		m.Answer = append(m.Answer, rr)
+	if qtype == dns.TypeAXFR || qtype == dns.TypeIXFR {
+		// Incremental transfers aren't supported, so IXFR is answered with the full zone (RFC 1995).
+		m.Answer = records
+	} else {
+		d.answer(m, records, r.Question[0])
 	}
 
-	tsig := r.IsTsig()
-	if tsig != nil && w.TsigStatus() == nil {
+	if tsig != nil {
 		m.SetTsig(tsig.Hdr.Name, tsig.Algorithm, 300, time.Now().Unix())
 	}
 
@@ -129,6 +159,62 @@ func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	return
 }
 
+// findZone returns the most specific zone containing the specified name.
+func (d *dnsHandler) findZone(name string) (*Zone, error) {
+	labels := dns.SplitDomainName(name)
+	for i := range labels {
+		zone, err := d.server.zone(strings.Join(labels[i:], "."), false)
+		if err == nil {
+			return zone, nil
+		}
+	}
+
+	return nil, fmt.Errorf("No zone found for %q", name)
+}
+
+// answer fills the response with the records of the zone matching the question.
+// If no record matches, the zone's SOA record is added to the authority section, with NXDOMAIN returned if there
+// are no records for the name at all.
+func (d *dnsHandler) answer(m *dns.Msg, records []dns.RR, question dns.Question) {
+	var soa dns.RR
+	nameExists := false
+
+	for _, rr := range records {
+		hdr := rr.Header()
+
+		if hdr.Rrtype == dns.TypeSOA && soa == nil {
+			soa = rr
+		}
+
+		if !strings.EqualFold(hdr.Name, dns.Fqdn(question.Name)) {
+			continue
+		}
+
+		nameExists = true
+
+		// Skip the duplicate SOA record closing the zone.
+		if hdr.Rrtype == dns.TypeSOA && rr != soa {
+			continue
+		}
+
+		if question.Qtype == dns.TypeANY || hdr.Rrtype == question.Qtype || hdr.Rrtype == dns.TypeCNAME {
+			m.Answer = append(m.Answer, rr)
+		}
+	}
+
+	if len(m.Answer) > 0 {
+		return
+	}
+
+	if !nameExists {
+		m.Rcode = dns.RcodeNameError
+	}
+
+	if soa != nil {
+		m.Ns = append(m.Ns, soa)
+	}
+}
+
 func (d *dnsHandler) isAllowed(zone api.NetworkZone, ip string, tsig *dns.TSIG, tsigStatus bool) bool {
 	type peer struct {
 		address string
@@ -0,0 +1,160 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// testResponseWriter records the message written by the handler.
+type testResponseWriter struct {
+	remoteAddr net.Addr
+	msg        *dns.Msg
+}
+
+func (w *testResponseWriter) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 53}
+}
+
+func (w *testResponseWriter) RemoteAddr() net.Addr {
+	return w.remoteAddr
+}
+
+func (w *testResponseWriter) WriteMsg(m *dns.Msg) error {
+	w.msg = m
+	return nil
+}
+
+func (w *testResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *testResponseWriter) Close() error {
+	return nil
+}
+
+func (w *testResponseWriter) TsigStatus() error {
+	return nil
+}
+
+func (w *testResponseWriter) TsigTimersOnly(bool) {}
+
+func (w *testResponseWriter) Hijack() {}
+
+const testZoneContent = `example.net. 3600 IN SOA example.net. ns1.example.net. 1 120 60 86400 30
+example.net. 300 IN NS ns1.example.net.
+c1.example.net. 300 IN A 192.0.2.10
+c1.example.net. 300 IN AAAA 2001:db8::10
+example.net. 3600 IN SOA example.net. ns1.example.net. 1 120 60 86400 30`
+
+// testServer returns a server for the example.net zone, allowing queries from 192.0.2.2, along with the lookups
+// done through its zone retriever.
+func testServer() (*Server, *[]string) {
+	lookups := []string{}
+
+	retriever := func(name string, full bool) (*Zone, error) {
+		lookups = append(lookups, fmt.Sprintf("%s:%v", name, full))
+
+		if name != "example.net" {
+			return nil, fmt.Errorf("Zone not found")
+		}
+
+		zone := &Zone{Info: api.NetworkZone{Name: name, NetworkZonePut: api.NetworkZonePut{Config: map[string]string{"peers.ns.address": "192.0.2.2"}}}}
+		if full {
+			zone.Content = testZoneContent
+		}
+
+		return zone, nil
+	}
+
+	return NewServer(nil, retriever), &lookups
+}
+
+func testQuery(s *Server, client string, name string, qtype uint16) *dns.Msg {
+	r := new(dns.Msg)
+	r.SetQuestion(name, qtype)
+
+	w := &testResponseWriter{remoteAddr: &net.UDPAddr{IP: net.ParseIP(client), Port: 5353}}
+	dnsHandler{server: s}.ServeDNS(w, r)
+
+	return w.msg
+}
+
+func TestServeDNS_Query(t *testing.T) {
+	s, lookups := testServer()
+
+	m := testQuery(s, "192.0.2.2", "c1.example.net.", dns.TypeA)
+	require.Equal(t, dns.RcodeSuccess, m.Rcode)
+	require.Len(t, m.Answer, 1)
+	assert.Equal(t, "192.0.2.10", m.Answer[0].(*dns.A).A.String())
+	assert.Equal(t, []string{"c1.example.net:false", "example.net:false", "example.net:true"}, *lookups)
+
+	// Missing record types return the SOA record.
+	m = testQuery(s, "192.0.2.2", "c1.example.net.", dns.TypeTXT)
+	require.Equal(t, dns.RcodeSuccess, m.Rcode)
+	require.Empty(t, m.Answer)
+	require.Len(t, m.Ns, 1)
+	assert.Equal(t, dns.TypeSOA, m.Ns[0].Header().Rrtype)
+
+	// Missing names return NXDOMAIN.
+	m = testQuery(s, "192.0.2.2", "c2.example.net.", dns.TypeA)
+	require.Equal(t, dns.RcodeNameError, m.Rcode)
+
+	// Only the new name was looked up, the rest came from the cache.
+	assert.Equal(t, []string{"c1.example.net:false", "example.net:false", "example.net:true", "c2.example.net:false"}, *lookups)
+}
+
+func TestServeDNS_Transfer(t *testing.T) {
+	s, _ := testServer()
+
+	for _, qtype := range []uint16{dns.TypeAXFR, dns.TypeIXFR} {
+		m := testQuery(s, "192.0.2.2", "example.net.", qtype)
+		require.Equal(t, dns.RcodeSuccess, m.Rcode)
+		assert.Len(t, m.Answer, 5)
+	}
+}
+
+func TestServeDNS_NotAllowed(t *testing.T) {
+	s, lookups := testServer()
+
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAXFR} {
+		m := testQuery(s, "192.0.2.3", "example.net.", qtype)
+		require.Equal(t, dns.RcodeNameError, m.Rcode)
+		assert.Empty(t, m.Answer)
+	}
+
+	// The zone content is never rendered for clients which aren't allowed.
+	assert.Equal(t, []string{"example.net:false"}, *lookups)
+}
+
+func TestServer_zone(t *testing.T) {
+	s, lookups := testServer()
+
+	zone, err := s.zone("example.net", false)
+	require.NoError(t, err)
+	assert.Empty(t, zone.Content)
+
+	// Full lookups aren't served by cached partial lookups.
+	zone, err = s.zone("example.net", true)
+	require.NoError(t, err)
+	assert.Equal(t, testZoneContent, zone.Content)
+
+	// Partial lookups are served by cached full lookups.
+	zone, err = s.zone("example.net", false)
+	require.NoError(t, err)
+	assert.Equal(t, testZoneContent, zone.Content)
+
+	// Failures are cached too.
+	_, err = s.zone("example.org", false)
+	require.Error(t, err)
+	_, err = s.zone("example.org", true)
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"example.net:false", "example.net:true", "example.org:false"}, *lookups)
+}
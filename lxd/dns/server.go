@@ -2,6 +2,7 @@ package dns
 
 import (
 	"sync"
+	"time"
 
 	"github.com/miekg/dns"
 
@@ -12,7 +13,22 @@ import (
 )
 
 // ZoneRetriever is a function which fetches a DNS zone.
-type ZoneRetriever func(name string) (*Zone, error)
+// The zone content is only needed when full is true, otherwise only the zone information is used.
+type ZoneRetriever func(name string, full bool) (*Zone, error)
+
+// zoneCacheExpiry is how long the zone lookups are cached for.
+const zoneCacheExpiry = 5 * time.Second
+
+// zoneCacheSize is the maximum number of cached zone lookups.
+const zoneCacheSize = 1024
+
+// zoneCacheEntry is a cached zone lookup.
+type zoneCacheEntry struct {
+	zone    *Zone
+	err     error
+	full    bool
+	expires time.Time
+}
 
 // Server represents a DNS server instance.
 type Server struct {
@@ -27,15 +43,54 @@ type Server struct {
 	address string
 
 	mu sync.Mutex
+
+	// Cache of the recent zone lookups.
+	zoneCache   map[string]zoneCacheEntry
+	zoneCacheMu sync.Mutex
 }
 
 // NewServer returns a new server instance.
 func NewServer(db *db.Cluster, retriever ZoneRetriever) *Server {
 	// Setup new struct.
-	s := &Server{db: db, zoneRetriever: retriever}
+	s := &Server{db: db, zoneRetriever: retriever, zoneCache: map[string]zoneCacheEntry{}}
 	return s
 }
 
+// zone returns the specified zone, using the cached lookup if recent enough.
+// Failed lookups are cached too so that queries for unknown names don't repeatedly hit the database.
+func (s *Server) zone(name string, full bool) (*Zone, error) {
+	s.zoneCacheMu.Lock()
+	entry, ok := s.zoneCache[name]
+	s.zoneCacheMu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) && (entry.full || !full || entry.err != nil) {
+		return entry.zone, entry.err
+	}
+
+	zone, err := s.zoneRetriever(name, full)
+
+	s.zoneCacheMu.Lock()
+	defer s.zoneCacheMu.Unlock()
+
+	now := time.Now()
+	if len(s.zoneCache) >= zoneCacheSize {
+		for k, v := range s.zoneCache {
+			if now.After(v.expires) {
+				delete(s.zoneCache, k)
+			}
+		}
+
+		// Start over if the cache is still full.
+		if len(s.zoneCache) >= zoneCacheSize {
+			s.zoneCache = map[string]zoneCacheEntry{}
+		}
+	}
+
+	s.zoneCache[name] = zoneCacheEntry{zone: zone, err: err, full: full, expires: now.Add(zoneCacheExpiry)}
+
+	return zone, err
+}
+
 // Start sets up the DNS listener.
 func (s *Server) Start(address string) error {
 	// Locking.
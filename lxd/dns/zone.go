@@ -1,6 +1,10 @@
 package dns
 
 import (
+	"strings"
+
+	"github.com/miekg/dns"
+
 	"github.com/lxc/lxd/shared/api"
 )
 
@@ -9,3 +13,25 @@ type Zone struct {
 	Info    api.NetworkZone
 	Content string
 }
+
+// Records parses the zone content and returns its records.
+func (z *Zone) Records() ([]dns.RR, error) {
+	records := []dns.RR{}
+
+	zoneRR := dns.NewZoneParser(strings.NewReader(z.Content), "", "")
+	for {
+		rr, ok := zoneRR.Next()
+		if !ok {
+			err := zoneRR.Err()
+			if err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		records = append(records, rr)
+	}
+
+	return records, nil
+}
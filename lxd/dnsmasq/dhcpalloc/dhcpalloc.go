@@ -13,6 +13,7 @@ import (
 	"github.com/mdlayher/netx/eui64"
 
 	"github.com/lxc/lxd/lxd/dnsmasq"
+	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -179,7 +180,8 @@ func (t *Transaction) AllocateIPv6() (net.IP, error) {
 // It first checks whether there is an existing allocation for the instance.
 // If no previous allocation, then a free IP is picked from the ranges configured.
 func (t *Transaction) getDHCPFreeIPv4(usedIPs map[[4]byte]dnsmasq.DHCPAllocation, deviceStaticFileName string, mac net.HardwareAddr) (net.IP, error) {
-	lxdIP, subnet, err := net.ParseCIDR(t.opts.Network.Config()["ipv4.address"])
+	// Allocate from the primary subnet (the first one listed) of the network.
+	lxdIP, subnet, err := net.ParseCIDR(util.BridgePrimaryAddress(t.opts.Network.Config()["ipv4.address"]))
 	if err != nil {
 		return nil, err
 	}
@@ -248,7 +250,8 @@ func (t *Transaction) getDHCPFreeIPv4(usedIPs map[[4]byte]dnsmasq.DHCPAllocation
 // device's MAC address. Finally if stateful custom ranges are enabled, then a free IP is picked
 // from the ranges configured.
 func (t *Transaction) getDHCPFreeIPv6(usedIPs map[[16]byte]dnsmasq.DHCPAllocation, deviceStaticFileName string, mac net.HardwareAddr) (net.IP, error) {
-	lxdIP, subnet, err := net.ParseCIDR(t.opts.Network.Config()["ipv6.address"])
+	// Allocate from the primary subnet (the first one listed) of the network.
+	lxdIP, subnet, err := net.ParseCIDR(util.BridgePrimaryAddress(t.opts.Network.Config()["ipv6.address"]))
 	if err != nil {
 		return nil, err
 	}
@@ -6,19 +6,27 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/miekg/dns"
+
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/storage/filesystem"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/subprocess"
 	"github.com/lxc/lxd/shared/version"
 )
 
 const staticAllocationDeviceSeparator = "."
 
+// reservationFilePrefix is the prefix of the network DHCP reservation files.
+// It starts with an underscore so that it can't clash with the name of an instance device static allocation.
+const reservationFilePrefix = "_reservation"
+
 // DHCPAllocation represents an IP allocation from dnsmasq.
 type DHCPAllocation struct {
 	IP             net.IP
@@ -71,6 +79,35 @@ func RemoveStaticEntry(network string, projectName string, instanceName string,
 	return nil
 }
 
+// UpdateReservationEntry writes a single dhcp-host line for a network DHCP reservation.
+func UpdateReservationEntry(network string, hwaddr string, ipv4Address string, ipv6Address string, hostname string) error {
+	hwaddr = strings.ToLower(hwaddr)
+	line := hwaddr
+
+	if ipv4Address != "" {
+		line += fmt.Sprintf(",%s", ipv4Address)
+	}
+
+	if ipv6Address != "" {
+		line += fmt.Sprintf(",[%s]", ipv6Address)
+	}
+
+	if hostname != "" {
+		line += fmt.Sprintf(",%s", hostname)
+	}
+
+	if line == hwaddr {
+		return nil
+	}
+
+	err := ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.hosts", ReservationFileName(hwaddr)), []byte(line+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // Kill kills dnsmasq for a particular network (or optionally reloads it).
 func Kill(name string, reload bool) error {
 	pidPath := shared.VarPath("networks", name, "dnsmasq.pid")
@@ -261,9 +298,123 @@ func DHCPAllAllocations(network string) (map[[4]byte]DHCPAllocation, map[[16]byt
 	return IPv4s, IPv6s, nil
 }
 
+// DHCPLease represents a dynamic lease recorded in the dnsmasq leases file.
+type DHCPLease struct {
+	Expiry   time.Time        // Zero for leases that never expire.
+	MAC      net.HardwareAddr // Only recorded for IPv4 leases.
+	IP       net.IP
+	Hostname string
+}
+
+// DHCPLeases returns the dynamic leases recorded in the dnsmasq leases file of a network.
+// Returns an empty list if the leases file doesn't exist.
+func DHCPLeases(network string) ([]DHCPLease, error) {
+	leases := []DHCPLease{}
+
+	file, err := os.Open(shared.VarPath("networks", network, "dnsmasq.leases"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return leases, nil
+		}
+
+		return nil, err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 5 {
+			continue // Skip the server DUID line.
+		}
+
+		expiry, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing lease expiry: %v", fields[0])
+		}
+
+		lease := DHCPLease{IP: net.ParseIP(fields[2])}
+		if lease.IP == nil {
+			return nil, fmt.Errorf("Error parsing IP address: %v", fields[2])
+		}
+
+		if expiry > 0 {
+			lease.Expiry = time.Unix(expiry, 0)
+		}
+
+		// MAC only available in IPv4 leases, IPv6 leases record the IAID instead.
+		if lease.IP.To4() != nil {
+			lease.MAC, err = net.ParseMAC(fields[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if fields[3] != "*" {
+			lease.Hostname = fields[3]
+		}
+
+		leases = append(leases, lease)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
 // StaticAllocationFileName returns the file name to use for a dnsmasq instance device static allocation.
 func StaticAllocationFileName(projectName string, instanceName string, deviceName string) string {
 	escapedDeviceName := filesystem.PathNameEncode(deviceName)
 
 	return strings.Join([]string{project.Instance(projectName, instanceName), escapedDeviceName}, staticAllocationDeviceSeparator)
 }
+
+// ReservationFileName returns the file name to use for a dnsmasq network DHCP reservation.
+func ReservationFileName(hwaddr string) string {
+	return strings.Join([]string{reservationFilePrefix, strings.ReplaceAll(strings.ToLower(hwaddr), ":", "")}, staticAllocationDeviceSeparator)
+}
+
+// CacheStats queries the cache statistics of the dnsmasq process listening on the specified address.
+// The statistics are retrieved using the CHAOS class TXT records dnsmasq provides.
+func CacheStats(address string) (*api.NetworkStateDNSCache, error) {
+	stats := api.NetworkStateDNSCache{}
+
+	fields := map[string]*int64{
+		"cachesize.bind.":  &stats.Size,
+		"insertions.bind.": &stats.Insertions,
+		"evictions.bind.":  &stats.Evictions,
+		"hits.bind.":       &stats.Hits,
+		"misses.bind.":     &stats.Misses,
+	}
+
+	for name, field := range fields {
+		req := dns.Msg{}
+		req.Id = dns.Id()
+		req.Question = []dns.Question{{Name: name, Qtype: dns.TypeTXT, Qclass: dns.ClassCHAOS}}
+
+		resp, err := dns.Exchange(&req, address)
+		if err != nil {
+			return nil, fmt.Errorf("Failed querying %q: %w", name, err)
+		}
+
+		if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 1 {
+			return nil, fmt.Errorf("Unexpected response for %q (rcode %s)", name, dns.RcodeToString[resp.Rcode])
+		}
+
+		txt, ok := resp.Answer[0].(*dns.TXT)
+		if !ok || len(txt.Txt) != 1 {
+			return nil, fmt.Errorf("Unexpected record for %q", name)
+		}
+
+		*field, err = strconv.ParseInt(txt.Txt[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for %q: %w", name, err)
+		}
+	}
+
+	return &stats, nil
+}
@@ -1,9 +1,15 @@
 package dnsmasq
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/shared"
 )
 
 func Test_staticAllocationFileName(t *testing.T) {
@@ -13,3 +19,40 @@ func Test_staticAllocationFileName(t *testing.T) {
 	fileName := StaticAllocationFileName(projectName, instanceName, deviceName)
 	assert.Equal(t, "test.project_test-instance.test-.--_----.device", fileName)
 }
+
+func Test_reservationFileName(t *testing.T) {
+	fileName := ReservationFileName("00:16:3E:1A:2B:3C")
+	assert.Equal(t, "_reservation.00163e1a2b3c", fileName)
+}
+
+func Test_dhcpLeases(t *testing.T) {
+	t.Setenv("LXD_DIR", t.TempDir())
+
+	leases, err := DHCPLeases("lxdbr0")
+	require.NoError(t, err)
+	assert.Len(t, leases, 0)
+
+	err = os.MkdirAll(shared.VarPath("networks", "lxdbr0"), 0755)
+	require.NoError(t, err)
+
+	content := `1700000000 00:16:3e:1a:2b:3c 10.0.0.10 c1 01:00:16:3e:1a:2b:3c
+duid 00:01:00:01:2b:3c:4d:5e:00:16:3e:00:00:01
+0 1234567 fd42::10 * 00:04:aa:bb:cc:dd
+`
+	err = ioutil.WriteFile(shared.VarPath("networks", "lxdbr0", "dnsmasq.leases"), []byte(content), 0644)
+	require.NoError(t, err)
+
+	leases, err = DHCPLeases("lxdbr0")
+	require.NoError(t, err)
+	require.Len(t, leases, 2)
+
+	assert.Equal(t, time.Unix(1700000000, 0), leases[0].Expiry)
+	assert.Equal(t, "00:16:3e:1a:2b:3c", leases[0].MAC.String())
+	assert.Equal(t, "10.0.0.10", leases[0].IP.String())
+	assert.Equal(t, "c1", leases[0].Hostname)
+
+	assert.True(t, leases[1].Expiry.IsZero())
+	assert.Nil(t, leases[1].MAC)
+	assert.Equal(t, "fd42::10", leases[1].IP.String())
+	assert.Equal(t, "", leases[1].Hostname)
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/db/cluster"
 	"github.com/lxc/lxd/lxd/events"
+	instanceDrivers "github.com/lxc/lxd/lxd/instance/drivers"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/rbac"
 	"github.com/lxc/lxd/lxd/request"
@@ -43,7 +46,13 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 	allProjects := shared.IsTrue(queryParam(r, "all-projects"))
 	projectQueryParam := queryParam(r, "project")
 	if allProjects && projectQueryParam != "" {
-		response.BadRequest(fmt.Errorf("Cannot specify a project when requesting events for all projects"))
+		_ = response.BadRequest(fmt.Errorf("Cannot specify a project when requesting events for all projects")).Render(w)
+		return nil
+	}
+
+	// Only administrators can see the events of all projects.
+	if allProjects && !rbac.UserIsAdmin(r) {
+		_ = response.Forbidden(fmt.Errorf("Only administrators can request events for all projects")).Render(w)
 		return nil
 	}
 
@@ -63,6 +72,12 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 				return err
 			}
 		}
+
+		// Restricted clients can only see the events of the projects they have access to.
+		if !rbac.UserHasPermission(r, projectName, "view") {
+			_ = response.Forbidden(nil).Render(w)
+			return nil
+		}
 	}
 
 	types := strings.Split(r.FormValue("type"), ",")
@@ -148,7 +163,10 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 
 	listenerConnection := events.NewWebsocketListenerConnection(conn)
 
-	listener, err := d.events.AddListener(projectName, allProjects, listenerConnection, types, excludeSources, recvFunc, excludeLocations)
+	// Restricted clients don't get the server wide events, only those of their project.
+	projectOnly := !allProjects && !rbac.UserIsAdmin(r)
+
+	listener, err := d.events.AddListener(projectName, allProjects, projectOnly, listenerConnection, types, excludeSources, recvFunc, excludeLocations)
 	if err != nil {
 		return err
 	}
@@ -180,7 +198,7 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 //     example: logging,lifecycle
 //   - in: query
 //     name: all-projects
-//     description: Retrieve instances from all projects
+//     description: Retrieve events from all projects (administrators only)
 //     type: boolean
 // responses:
 //   "200":
@@ -194,3 +212,52 @@ func eventsSocket(d *Daemon, r *http.Request, w http.ResponseWriter) error {
 func eventsGet(d *Daemon, r *http.Request) response.Response {
 	return &eventsServe{req: r, d: d}
 }
+
+// eventsCacheInvalidate drops the cached resources and instance state affected by a locally produced event.
+func eventsCacheInvalidate(event api.Event) {
+	if event.Type != "lifecycle" {
+		return
+	}
+
+	lifecycleEvent := api.EventLifecycle{}
+	err := json.Unmarshal(event.Metadata, &lifecycleEvent)
+	if err != nil {
+		return
+	}
+
+	// Changes to instances, networks and storage pools may all be reflected in the server resources.
+	for _, prefix := range []string{"instance-", "network-", "storage-pool-"} {
+		if strings.HasPrefix(lifecycleEvent.Action, prefix) {
+			resourcesCacheInvalidate()
+			break
+		}
+	}
+
+	if !strings.HasPrefix(lifecycleEvent.Action, "instance-") {
+		return
+	}
+
+	u, err := url.Parse(lifecycleEvent.Source)
+	if err != nil {
+		return
+	}
+
+	// Sources look like /1.0/instances/NAME and /1.0/instances/NAME/snapshots/SNAPSHOT.
+	fields := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	if len(fields) < 3 || fields[1] != "instances" {
+		return
+	}
+
+	projectName := event.Project
+	if projectName == "" {
+		projectName = project.Default
+	}
+
+	instanceDrivers.InstanceStateCacheInvalidate(projectName, fields[2])
+
+	// Renamed instances are also cached under their old name.
+	oldName, ok := lifecycleEvent.Context["old_name"].(string)
+	if ok && lifecycleEvent.Action == "instance-renamed" {
+		instanceDrivers.InstanceStateCacheInvalidate(projectName, oldName)
+	}
+}
@@ -65,11 +65,16 @@ func (s *Server) SetLocalLocation(location string) {
 }
 
 // AddListener creates and returns a new event listener.
-func (s *Server) AddListener(projectName string, allProjects bool, connection EventListenerConnection, messageTypes []string, excludeSources []EventSource, recvFunc EventHandler, excludeLocations []string) (*Listener, error) {
+// If projectOnly is true, the listener only receives events tied to its project and not the server wide ones.
+func (s *Server) AddListener(projectName string, allProjects bool, projectOnly bool, connection EventListenerConnection, messageTypes []string, excludeSources []EventSource, recvFunc EventHandler, excludeLocations []string) (*Listener, error) {
 	if allProjects && projectName != "" {
 		return nil, fmt.Errorf("Cannot specify project name when listening for events on all projects")
 	}
 
+	if allProjects && projectOnly {
+		return nil, fmt.Errorf("Cannot restrict listener to project events when listening for events on all projects")
+	}
+
 	listener := &Listener{
 		listenerCommon: listenerCommon{
 			EventListenerConnection: connection,
@@ -81,6 +86,7 @@ func (s *Server) AddListener(projectName string, allProjects bool, connection Ev
 
 		allProjects:      allProjects,
 		projectName:      projectName,
+		projectOnly:      projectOnly,
 		excludeSources:   excludeSources,
 		excludeLocations: excludeLocations,
 	}
@@ -101,6 +107,8 @@ func (s *Server) AddListener(projectName string, allProjects bool, connection Ev
 
 // SendLifecycle broadcasts a lifecycle event.
 func (s *Server) SendLifecycle(projectName string, event api.EventLifecycle) {
+	event.SchemaVersion = api.EventLifecycleSchemaVersion
+
 	_ = s.Send(projectName, "lifecycle", event)
 }
 
@@ -178,6 +186,11 @@ func (s *Server) broadcast(event api.Event, eventSource EventSource) error {
 			continue
 		}
 
+		// If the listener is restricted to its project, don't deliver server wide events.
+		if event.Project == "" && listener.projectOnly {
+			continue
+		}
+
 		if sourceInSlice(eventSource, listener.excludeSources) {
 			continue
 		}
@@ -224,6 +237,7 @@ type Listener struct {
 
 	allProjects      bool
 	projectName      string
+	projectOnly      bool
 	excludeSources   []EventSource
 	excludeLocations []string
 }
@@ -10,9 +10,10 @@ type FeatureOpts struct {
 
 // SNATOpts specify how SNAT rules are setup.
 type SNATOpts struct {
-	Append      bool       // Append rules (has no effect if driver doesn't support it).
-	Subnet      *net.IPNet // Subnet of source network used to identify candidate traffic.
-	SNATAddress net.IP     // SNAT IP address to use. If nil then MASQUERADE is used.
+	Append      bool         // Append rules (has no effect if driver doesn't support it).
+	Subnets     []*net.IPNet // Subnets of source network used to identify candidate traffic.
+	SNATAddress net.IP       // SNAT IP address to use. If nil then MASQUERADE is used.
+	Exclude     []*net.IPNet // Destination subnets the traffic to isn't translated (e.g. peered networks).
 }
 
 // Opts for setting up the firewall.
@@ -22,6 +23,8 @@ type Opts struct {
 	SNATV4     *SNATOpts    // Enable IPv4 SNAT with specified options. Off if not provided.
 	SNATV6     *SNATOpts    // Enable IPv6 SNAT with specified options. Off if not provided.
 	ACL        bool         // Enable ACL during setup.
+
+	PeerInterfaces []string // Interfaces of peered networks the traffic is always forwarded to and from.
 }
 
 // ACLRule represents an ACL rule that can be added to a firewall.
@@ -47,3 +50,28 @@ type AddressForward struct {
 	ListenPorts   []uint64
 	TargetPorts   []uint64
 }
+
+// LoadBalancerPolicyRoundRobin distributes new connections to the load balancer targets in turn.
+const LoadBalancerPolicyRoundRobin = "round-robin"
+
+// LoadBalancerPolicyRandom distributes new connections to randomly chosen load balancer targets.
+const LoadBalancerPolicyRandom = "random"
+
+// LoadBalancerPolicyLeastConn distributes new connections to the load balancer target with the fewest active
+// connections. It isn't supported by the NAT load balancers, which don't track connections per target.
+const LoadBalancerPolicyLeastConn = "least-conn"
+
+// LoadBalancerTarget represents a target address and port of a NAT load balancer.
+type LoadBalancerTarget struct {
+	Address net.IP
+	Port    uint64
+}
+
+// LoadBalancer represents a NAT load balancer distributing connections on a listen port to several targets.
+type LoadBalancer struct {
+	ListenAddress net.IP
+	Protocol      string
+	ListenPort    uint64
+	Targets       []LoadBalancerTarget
+	Policy        string // Either LoadBalancerPolicyRoundRobin or LoadBalancerPolicyRandom.
+}
@@ -189,13 +189,15 @@ func (d Nftables) hostVersion() (*version.DottedVersion, error) {
 	return version.Parse(strings.TrimPrefix(lines[1], "v"))
 }
 
-// networkSetupForwardingPolicy allows forwarding dependent on boolean argument
-func (d Nftables) networkSetupForwardingPolicy(networkName string, ip4Allow *bool, ip6Allow *bool) error {
+// networkSetupForwardingPolicy allows forwarding dependent on boolean argument, always allowing the traffic with
+// the peer interfaces.
+func (d Nftables) networkSetupForwardingPolicy(networkName string, ip4Allow *bool, ip6Allow *bool, peerInterfaces []string) error {
 	tplFields := map[string]any{
 		"namespace":      nftablesNamespace,
 		"chainSeparator": nftablesChainSeparator,
 		"networkName":    networkName,
 		"family":         "inet",
+		"peerInterfaces": peerInterfaces,
 	}
 
 	if ip4Allow != nil {
@@ -230,7 +232,7 @@ func (d Nftables) networkSetupForwardingPolicy(networkName string, ip4Allow *boo
 // If srcIP is non-nil then SNAT is used with the specified address, otherwise MASQUERADE mode is used.
 // Append mode is always on and so the append argument is ignored.
 func (d Nftables) networkSetupOutboundNAT(networkName string, SNATV4 *SNATOpts, SNATV6 *SNATOpts) error {
-	rules := make(map[string]*SNATOpts, 0)
+	rules := make(map[string]map[string]any, 0)
 
 	tplFields := map[string]any{
 		"namespace":      nftablesNamespace,
@@ -239,13 +241,33 @@ func (d Nftables) networkSetupOutboundNAT(networkName string, SNATV4 *SNATOpts,
 		"family":         "inet",
 	}
 
+	// Match the traffic from any of the network's subnets to destinations outside of all of them and of the
+	// excluded subnets.
+	natRule := func(opts *SNATOpts) map[string]any {
+		subnets := make([]string, 0, len(opts.Subnets))
+		for _, subnet := range opts.Subnets {
+			subnets = append(subnets, subnet.String())
+		}
+
+		destinations := append([]string{}, subnets...)
+		for _, subnet := range opts.Exclude {
+			destinations = append(destinations, subnet.String())
+		}
+
+		return map[string]any{
+			"subnets":      fmt.Sprintf("{%s}", strings.Join(subnets, ",")),
+			"destinations": fmt.Sprintf("{%s}", strings.Join(destinations, ",")),
+			"SNATAddress":  opts.SNATAddress,
+		}
+	}
+
 	// If SNAT IP not supplied then use the IP of the outbound interface (MASQUERADE).
 	if SNATV4 != nil {
-		rules["ip"] = SNATV4
+		rules["ip"] = natRule(SNATV4)
 	}
 
 	if SNATV6 != nil {
-		rules["ip6"] = SNATV6
+		rules["ip6"] = natRule(SNATV6)
 	}
 
 	tplFields["rules"] = rules
@@ -345,7 +367,7 @@ func (d Nftables) NetworkSetup(networkName string, opts Opts) error {
 			ip6ForwardingAllow = &opts.FeaturesV6.ForwardingAllow
 		}
 
-		err := d.networkSetupForwardingPolicy(networkName, ip4ForwardingAllow, ip6ForwardingAllow)
+		err := d.networkSetupForwardingPolicy(networkName, ip4ForwardingAllow, ip6ForwardingAllow, opts.PeerInterfaces)
 		if err != nil {
 			return err
 		}
@@ -366,6 +388,7 @@ func (d Nftables) NetworkClear(networkName string, _ bool, _ []uint) error {
 		"fwd", "pstrt", "in", "out", // Chains used for network operation rules.
 		"aclin", "aclout", "aclfwd", "acl", // Chains used by ACL rules.
 		"fwdprert", "fwdout", "fwdpstrt", // Chains used by Address Forward rules.
+		"lbprert", "lbout", "lbpstrt", // Chains used by Load Balancer rules.
 	}
 
 	// Remove chains created by network rules.
@@ -661,14 +684,129 @@ func (d Nftables) InstanceClearRPFilter(projectName string, instanceName string,
 	return nil
 }
 
+// InstanceSetupConntrackLimit places the connections originating from the instance's host side interfaces in
+// the specified conntrack zone and drops new connections once limit of them are tracked.
+func (d Nftables) InstanceSetupConntrackLimit(projectName string, instanceName string, hostNames []string, zone uint16, limit uint64) error {
+	instanceLabel := project.Instance(projectName, instanceName)
+
+	// Remove any existing rules first so that the set of host interfaces is replaced.
+	err := d.InstanceClearConntrackLimit(projectName, instanceName)
+	if err != nil {
+		return err
+	}
+
+	tplFields := map[string]any{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"instanceLabel":  instanceLabel,
+		"hostNames":      hostNames,
+		"zone":           zone,
+		"limit":          limit,
+		"family":         "inet",
+	}
+
+	err = d.applyNftConfig(nftablesInstanceConntrackLimit, tplFields)
+	if err != nil {
+		return fmt.Errorf("Failed adding conntrack limit rules for instance %q (%s): %w", instanceLabel, tplFields["family"], err)
+	}
+
+	return nil
+}
+
+// InstanceClearConntrackLimit removes the conntrack zone and limit rules of the specified instance.
+func (d Nftables) InstanceClearConntrackLimit(projectName string, instanceName string) error {
+	instanceLabel := project.Instance(projectName, instanceName)
+
+	err := d.removeChains([]string{"inet"}, instanceLabel, "ctzone", "ctlimit")
+	if err != nil {
+		return fmt.Errorf("Failed clearing conntrack limit rules for instance %q: %w", instanceLabel, err)
+	}
+
+	return nil
+}
+
 // NetworkApplyACLRules applies ACL rules to the existing firewall chains.
 func (d Nftables) NetworkApplyACLRules(networkName string, rules []ACLRule) error {
+	nftRules, err := d.aclRulesToNftRules(networkName, rules)
+	if err != nil {
+		return err
+	}
+
+	tplFields := map[string]any{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"networkName":    networkName,
+		"family":         "inet",
+		"rules":          nftRules,
+	}
+	config := &strings.Builder{}
+	err = nftablesNetACLRules.Execute(config, tplFields)
+	if err != nil {
+		return fmt.Errorf("Failed running %q template: %w", nftablesNetACLRules.Name(), err)
+	}
+
+	_, err = shared.RunCommand("nft", config.String())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InstanceSetupACLRules applies ACL rules to the traffic of a bridged instance device's host interface.
+// Existing rules for the device are replaced.
+func (d Nftables) InstanceSetupACLRules(projectName string, instanceName string, deviceName string, hostName string, rules []ACLRule) error {
+	deviceLabel := d.instanceDeviceLabel(projectName, instanceName, deviceName)
+
+	nftRules, err := d.aclRulesToNftRules(hostName, rules)
+	if err != nil {
+		return err
+	}
+
+	tplFields := map[string]any{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"deviceLabel":    deviceLabel,
+		"hostName":       hostName,
+		"family":         "bridge",
+		"rules":          nftRules,
+	}
+
+	config := &strings.Builder{}
+	err = nftablesInstanceACL.Execute(config, tplFields)
+	if err != nil {
+		return fmt.Errorf("Failed running %q template: %w", nftablesInstanceACL.Name(), err)
+	}
+
+	_, err = shared.RunCommand("nft", config.String())
+	if err != nil {
+		return fmt.Errorf("Failed adding ACL rules for instance device %q (%s): %w", deviceLabel, tplFields["family"], err)
+	}
+
+	return nil
+}
+
+// InstanceClearACLRules removes the ACL rules of a bridged instance device.
+func (d Nftables) InstanceClearACLRules(projectName string, instanceName string, deviceName string) error {
+	deviceLabel := d.instanceDeviceLabel(projectName, instanceName, deviceName)
+
+	// Remove the chains jumping to the ACL chain first.
+	err := d.removeChains([]string{"bridge"}, deviceLabel, "aclin", "aclout", "aclfwd", "acl")
+	if err != nil {
+		return fmt.Errorf("Failed clearing ACL rules for instance device %q: %w", deviceLabel, err)
+	}
+
+	return nil
+}
+
+// aclRulesToNftRules converts the ACL rules into nftables rules matching traffic to and from the interface.
+func (d Nftables) aclRulesToNftRules(ifaceName string, rules []ACLRule) ([]string, error) {
 	nftRules := make([]string, 0)
 	for _, rule := range rules {
 		// First try generating rules with IPv4 or IP agnostic criteria.
-		nftRule, partial, err := d.aclRuleCriteriaToRules(networkName, 4, &rule)
+		nftRule, partial, err := d.aclRuleCriteriaToRules(ifaceName, 4, &rule)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if nftRule != "" {
@@ -678,40 +816,22 @@ func (d Nftables) NetworkApplyACLRules(networkName string, rules []ACLRule) erro
 		if partial {
 			// If we couldn't fully generate the ruleset with only IPv4 or IP agnostic criteria, then
 			// fill in the remaining parts using IPv6 criteria.
-			nftRule, _, err = d.aclRuleCriteriaToRules(networkName, 6, &rule)
+			nftRule, _, err = d.aclRuleCriteriaToRules(ifaceName, 6, &rule)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 			if nftRule == "" {
-				return fmt.Errorf("Invalid empty rule generated")
+				return nil, fmt.Errorf("Invalid empty rule generated")
 			}
 
 			nftRules = append(nftRules, nftRule)
 		} else if nftRule == "" {
-			return fmt.Errorf("Invalid empty rule generated")
+			return nil, fmt.Errorf("Invalid empty rule generated")
 		}
 	}
 
-	tplFields := map[string]any{
-		"namespace":      nftablesNamespace,
-		"chainSeparator": nftablesChainSeparator,
-		"networkName":    networkName,
-		"family":         "inet",
-		"rules":          nftRules,
-	}
-	config := &strings.Builder{}
-	err := nftablesNetACLRules.Execute(config, tplFields)
-	if err != nil {
-		return fmt.Errorf("Failed running %q template: %w", nftablesNetACLRules.Name(), err)
-	}
-
-	_, err = shared.RunCommand("nft", config.String())
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return nftRules, nil
 }
 
 // aclRuleCriteriaToRules converts an ACL rule into 1 or more nftables rules.
@@ -1052,3 +1172,118 @@ func (d Nftables) NetworkApplyForwards(networkName string, rules []AddressForwar
 
 	return nil
 }
+
+// NetworkApplyLoadBalancers apply network load balancer rules to firewall.
+func (d Nftables) NetworkApplyLoadBalancers(networkName string, rules []LoadBalancer) error {
+	var dnatRules []map[string]any
+	var snatRules []map[string]any
+
+	snatTargets := make(map[string]struct{})
+
+	for ruleIndex, rule := range rules {
+		if rule.ListenAddress == nil {
+			return fmt.Errorf("Invalid rule %d, listen address is required", ruleIndex)
+		}
+
+		if rule.Protocol == "" || rule.ListenPort == 0 {
+			return fmt.Errorf("Invalid rule %d, protocol and listen port are required", ruleIndex)
+		}
+
+		targetsLen := len(rule.Targets)
+		if targetsLen < 1 {
+			return fmt.Errorf("Invalid rule %d, at least one target is required", ruleIndex)
+		}
+
+		var numgenMode string
+
+		switch rule.Policy {
+		case LoadBalancerPolicyRoundRobin:
+			numgenMode = "inc"
+		case LoadBalancerPolicyRandom:
+			numgenMode = "random"
+		default:
+			return fmt.Errorf("Invalid rule %d, unsupported policy %q", ruleIndex, rule.Policy)
+		}
+
+		ipFamily := "ip"
+		if rule.ListenAddress.To4() == nil {
+			ipFamily = "ip6"
+		}
+
+		listenAddressStr := rule.ListenAddress.String()
+
+		for i, target := range rule.Targets {
+			if target.Address == nil {
+				return fmt.Errorf("Invalid rule %d, target address is required", ruleIndex)
+			}
+
+			targetAddressStr := target.Address.String()
+
+			// Format the destination host/port as appropriate.
+			targetDest := fmt.Sprintf("%s:%d", targetAddressStr, target.Port)
+			if ipFamily == "ip6" {
+				targetDest = fmt.Sprintf("[%s]:%d", targetAddressStr, target.Port)
+			}
+
+			// Each target rule picks one out of every N new connections reaching it, N being the number of
+			// targets left, so that connections are spread evenly. The last target takes the remainder.
+			var numgen string
+			if i < targetsLen-1 {
+				numgen = fmt.Sprintf("%s mod %d == 0", numgenMode, targetsLen-i)
+			}
+
+			dnatRules = append(dnatRules, map[string]any{
+				"ipFamily":      ipFamily,
+				"protocol":      rule.Protocol,
+				"listenAddress": listenAddressStr,
+				"listenPorts":   rule.ListenPort,
+				"numgen":        numgen,
+				"targetDest":    targetDest,
+			})
+
+			// Only add one hairpin NAT rule per target address and port.
+			snatTarget := fmt.Sprintf("%s/%s", rule.Protocol, targetDest)
+			_, found := snatTargets[snatTarget]
+			if !found {
+				snatTargets[snatTarget] = struct{}{}
+				snatRules = append(snatRules, map[string]any{
+					"ipFamily":    ipFamily,
+					"protocol":    rule.Protocol,
+					"targetHost":  targetAddressStr,
+					"targetPorts": target.Port,
+				})
+			}
+		}
+	}
+
+	tplFields := map[string]any{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"chainPrefix":    "lb", // Differentiate from network and proxy device forwards.
+		"family":         "inet",
+		"label":          networkName,
+		"dnatRules":      dnatRules,
+		"snatRules":      snatRules,
+	}
+
+	// Apply rules or remove chains if no rules generated.
+	if len(dnatRules) > 0 {
+		config := &strings.Builder{}
+		err := nftablesNetProxyNAT.Execute(config, tplFields)
+		if err != nil {
+			return fmt.Errorf("Failed running %q template: %w", nftablesNetProxyNAT.Name(), err)
+		}
+
+		_, err = shared.RunCommand("nft", config.String())
+		if err != nil {
+			return err
+		}
+	} else {
+		err := d.removeChains([]string{"inet", "ip", "ip6"}, networkName, "lbprert", "lbout", "lbpstrt")
+		if err != nil {
+			return fmt.Errorf("Failed clearing nftables load balancer rules for network %q: %w", networkName, err)
+		}
+	}
+
+	return nil
+}
@@ -14,6 +14,11 @@ var nftablesNetForwardingPolicy = template.Must(template.New("nftablesNetForward
 chain fwd{{.chainSeparator}}{{.networkName}} {
 	type filter hook forward priority 0; policy accept;
 
+	{{- range .peerInterfaces}}
+	iifname "{{$.networkName}}" oifname "{{.}}" accept
+	iifname "{{.}}" oifname "{{$.networkName}}" accept
+	{{- end}}
+
 	{{if .ip4Action -}}
 	ip version 4 oifname "{{.networkName}}" {{.ip4Action}}
 	ip version 4 iifname "{{.networkName}}" {{.ip4Action}}
@@ -32,9 +37,9 @@ chain pstrt{{.chainSeparator}}{{.networkName}} {
 
 	{{- range $ipFamily, $config := .rules}}
 	{{if $config.SNATAddress -}}
-	{{$ipFamily}} saddr {{$config.Subnet}} {{$ipFamily}} daddr != {{$config.Subnet}} snat {{$config.SNATAddress}}
+	{{$ipFamily}} saddr {{$config.subnets}} {{$ipFamily}} daddr != {{$config.destinations}} snat {{$config.SNATAddress}}
 	{{else -}}
-	{{$ipFamily}} saddr {{$config.Subnet}} {{$ipFamily}} daddr != {{$config.Subnet}} masquerade
+	{{$ipFamily}} saddr {{$config.subnets}} {{$ipFamily}} daddr != {{$config.destinations}} masquerade
 	{{- end}}
 	{{- end}}
 }
@@ -89,14 +94,14 @@ table {{.family}} {{.namespace}} {
 	chain {{.chainPrefix}}prert{{.chainSeparator}}{{.label}} {
 		type nat hook prerouting priority -100; policy accept;
 		{{- range .dnatRules}}
-		{{.ipFamily}} daddr {{.listenAddress}} {{if .protocol}}{{.protocol}} dport {{.listenPorts}}{{end}} dnat to {{.targetDest}}
+		{{.ipFamily}} daddr {{.listenAddress}} {{if .protocol}}{{.protocol}} dport {{.listenPorts}}{{end}} {{if .numgen}}numgen {{.numgen}} {{end}}dnat to {{.targetDest}}
 		{{- end}}
 	}
 
 	chain {{.chainPrefix}}out{{.chainSeparator}}{{.label}} {
 		type nat hook output priority -100; policy accept;
 		{{- range .dnatRules}}
-		{{.ipFamily}} daddr {{.listenAddress}} {{if .protocol}}{{.protocol}} dport {{.listenPorts}}{{end}} dnat to {{.targetDest}}
+		{{.ipFamily}} daddr {{.listenAddress}} {{if .protocol}}{{.protocol}} dport {{.listenPorts}}{{end}} {{if .numgen}}numgen {{.numgen}} {{end}}dnat to {{.targetDest}}
 		{{- end}}
 	}
 
@@ -174,6 +179,80 @@ table {{.family}} {{.namespace}} {
 }
 `))
 
+// nftablesInstanceACL defines the rules applying network ACLs to a bridged instance device. The ACL chain is
+// reached from the input and output hooks for traffic between the instance and the LXD host and from the forward
+// hook for traffic between the instance and the rest of the bridge. Non-IP traffic and the core ICMP, DHCP and DNS
+// traffic needed for the instance's network to work is always allowed.
+var nftablesInstanceACL = template.Must(template.New("nftablesInstanceACL").Parse(`
+add table {{.family}} {{.namespace}}
+add chain {{.family}} {{.namespace}} acl{{.chainSeparator}}{{.deviceLabel}}
+add chain {{.family}} {{.namespace}} aclin{{.chainSeparator}}{{.deviceLabel}} {type filter hook input priority filter; policy accept;}
+add chain {{.family}} {{.namespace}} aclout{{.chainSeparator}}{{.deviceLabel}} {type filter hook output priority filter; policy accept;}
+add chain {{.family}} {{.namespace}} aclfwd{{.chainSeparator}}{{.deviceLabel}} {type filter hook forward priority filter; policy accept;}
+flush chain {{.family}} {{.namespace}} acl{{.chainSeparator}}{{.deviceLabel}}
+flush chain {{.family}} {{.namespace}} aclin{{.chainSeparator}}{{.deviceLabel}}
+flush chain {{.family}} {{.namespace}} aclout{{.chainSeparator}}{{.deviceLabel}}
+flush chain {{.family}} {{.namespace}} aclfwd{{.chainSeparator}}{{.deviceLabel}}
+
+table {{.family}} {{.namespace}} {
+	chain aclin{{.chainSeparator}}{{.deviceLabel}} {
+		iifname "{{.hostName}}" ether type != {ip, ip6} accept
+
+		# Allow DNS to LXD host.
+		iifname "{{.hostName}}" tcp dport 53 accept
+		iifname "{{.hostName}}" udp dport 53 accept
+
+		# Allow DHCP to LXD host.
+		iifname "{{.hostName}}" udp dport 67 accept
+		iifname "{{.hostName}}" udp dport 547 accept
+
+		# Allow core ICMPv4 to LXD host.
+		iifname "{{.hostName}}" icmp type {3, 11, 12} accept
+
+		# Allow core ICMPv6 to LXD host.
+		iifname "{{.hostName}}" icmpv6 type {1, 2, 3, 4, 133, 135, 136, 143} accept
+
+		iifname "{{.hostName}}" jump acl{{.chainSeparator}}{{.deviceLabel}}
+	}
+
+	chain aclout{{.chainSeparator}}{{.deviceLabel}} {
+		oifname "{{.hostName}}" ether type != {ip, ip6} accept
+
+		# Allow DHCP from LXD host.
+		oifname "{{.hostName}}" udp sport 67 accept
+		oifname "{{.hostName}}" udp sport 547 accept
+
+		# Allow core ICMPv4 from LXD host.
+		oifname "{{.hostName}}" icmp type {3, 11, 12} accept
+
+		# Allow ICMPv6 ping from host into network as dnsmasq uses this to probe IP allocations.
+		oifname "{{.hostName}}" icmpv6 type {1, 2, 3, 4, 128, 134, 135, 136, 143} accept
+
+		oifname "{{.hostName}}" jump acl{{.chainSeparator}}{{.deviceLabel}}
+	}
+
+	chain aclfwd{{.chainSeparator}}{{.deviceLabel}} {
+		iifname "{{.hostName}}" ether type != {ip, ip6} accept
+		oifname "{{.hostName}}" ether type != {ip, ip6} accept
+
+		# Allow core ICMPv6 between the instance and its neighbours.
+		iifname "{{.hostName}}" icmpv6 type {1, 2, 3, 4, 135, 136, 143} accept
+		oifname "{{.hostName}}" icmpv6 type {1, 2, 3, 4, 135, 136, 143} accept
+
+		iifname "{{.hostName}}" jump acl{{.chainSeparator}}{{.deviceLabel}}
+		oifname "{{.hostName}}" jump acl{{.chainSeparator}}{{.deviceLabel}}
+	}
+
+	chain acl{{.chainSeparator}}{{.deviceLabel}} {
+		ct state established,related accept
+
+		{{- range .rules}}
+		{{.}}
+		{{- end}}
+	}
+}
+`))
+
 // nftablesInstanceBridgeFilter defines the rules needed for MAC, IPv4 and IPv6 bridge security filtering.
 // To prevent instances from using IPs that are different from their assigned IPs we use ARP and NDP filtering
 // to prevent neighbour advertisements that are not allowed. However in order for DHCPv4 & DHCPv6 to work back to
@@ -253,6 +332,21 @@ chain fwd{{.chainSeparator}}{{.deviceLabel}} {
 }
 `))
 
+// nftablesInstanceConntrackLimit defines the rules placing the connections originating from the instance's
+// host side interfaces in their own conntrack zone and limiting how many of them can be tracked.
+// The zone is only set for the original direction so that replies coming from other interfaces still match.
+var nftablesInstanceConntrackLimit = template.Must(template.New("nftablesInstanceConntrackLimit").Parse(`
+chain ctzone{{.chainSeparator}}{{.instanceLabel}} {
+	type filter hook prerouting priority -300; policy accept;
+	iifname { {{- range .hostNames}}"{{.}}",{{end -}} } ct original zone set {{.zone}}
+}
+
+chain ctlimit{{.chainSeparator}}{{.instanceLabel}} {
+	type filter hook prerouting priority -150; policy accept;
+	iifname { {{- range .hostNames}}"{{.}}",{{end -}} } ct state new ct count over {{.limit}} drop
+}
+`))
+
 // nftablesInstanceRPFilter defines the rules to perform reverse path filtering.
 var nftablesInstanceRPFilter = template.Must(template.New("nftablesInstanceRPFilter").Parse(`
 chain prert{{.chainSeparator}}{{.deviceLabel}} {
@@ -163,6 +163,12 @@ func (d Xtables) networkForwardIPTablesComment(networkName string) string {
 	return fmt.Sprintf("LXD network-forward %s", networkName)
 }
 
+// networkLoadBalancerIPTablesComment returns the iptables comment that is added to each network load balancer
+// related rule.
+func (d Xtables) networkLoadBalancerIPTablesComment(networkName string) string {
+	return fmt.Sprintf("LXD network-load-balancer %s", networkName)
+}
+
 // networkSetupNICFilteringChain creates the NIC filtering chain if it doesn't exist, and adds the jump rules to
 // the INPUT and FORWARD filter chains. Must be called after networkSetupForwardingPolicy so that the rules are
 // prepended before the default fowarding policy rules.
@@ -314,9 +320,10 @@ func (d Xtables) networkSetupACLFilteringChains(networkName string) error {
 	return nil
 }
 
-// networkSetupForwardingPolicy allows forwarding dependent on boolean argument. Must be called before
-// networkSetupNICFilteringChains so the default forwarding policy rules are processed after NIC filtering rules.
-func (d Xtables) networkSetupForwardingPolicy(networkName string, ipVersion uint, allow bool) error {
+// networkSetupForwardingPolicy allows forwarding dependent on boolean argument, always allowing the traffic with
+// the peer interfaces. Must be called before networkSetupNICFilteringChains so the default forwarding policy rules
+// are processed after NIC filtering rules.
+func (d Xtables) networkSetupForwardingPolicy(networkName string, ipVersion uint, allow bool, peerInterfaces []string) error {
 	forwardType := "REJECT"
 	if allow {
 		forwardType = "ACCEPT"
@@ -334,41 +341,75 @@ func (d Xtables) networkSetupForwardingPolicy(networkName string, ipVersion uint
 		return err
 	}
 
+	// Always allow the traffic with peered networks (prepended so it's matched first).
+	for _, peerInterface := range peerInterfaces {
+		err = d.iptablesPrepend(ipVersion, comment, "filter", "FORWARD", "-i", networkName, "-o", peerInterface, "-j", "ACCEPT")
+		if err != nil {
+			return err
+		}
+
+		err = d.iptablesPrepend(ipVersion, comment, "filter", "FORWARD", "-i", peerInterface, "-o", networkName, "-j", "ACCEPT")
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// networkSetupOutboundNAT configures outbound NAT.
+// networkSetupOutboundNAT configures outbound NAT of the traffic from the subnets to destinations outside of them.
 // If srcIP is non-nil then SNAT is used with the specified address, otherwise MASQUERADE mode is used.
-func (d Xtables) networkSetupOutboundNAT(networkName string, subnet *net.IPNet, srcIP net.IP, appendRule bool) error {
+func (d Xtables) networkSetupOutboundNAT(networkName string, subnets []*net.IPNet, exclude []*net.IPNet, srcIP net.IP, appendRule bool) error {
 	family := uint(4)
-	if subnet.IP.To4() == nil {
+	if subnets[0].IP.To4() == nil {
 		family = 6
 	}
 
-	args := []string{
-		"-s", subnet.String(),
-		"!", "-d", subnet.String(),
-	}
+	rules := [][]string{}
+	for _, subnet := range subnets {
+		// Don't translate the traffic between the subnets of the network.
+		for _, dstSubnet := range subnets {
+			if dstSubnet != subnet {
+				rules = append(rules, []string{"-s", subnet.String(), "-d", dstSubnet.String(), "-j", "RETURN"})
+			}
+		}
 
-	// If SNAT IP not supplied then use the IP of the outbound interface (MASQUERADE).
-	if srcIP == nil {
-		args = append(args, "-j", "MASQUERADE")
-	} else {
-		args = append(args, "-j", "SNAT", "--to", srcIP.String())
+		// Nor the traffic to the excluded subnets.
+		for _, dstSubnet := range exclude {
+			rules = append(rules, []string{"-s", subnet.String(), "-d", dstSubnet.String(), "-j", "RETURN"})
+		}
+
+		args := []string{
+			"-s", subnet.String(),
+			"!", "-d", subnet.String(),
+		}
+
+		// If SNAT IP not supplied then use the IP of the outbound interface (MASQUERADE).
+		if srcIP == nil {
+			args = append(args, "-j", "MASQUERADE")
+		} else {
+			args = append(args, "-j", "SNAT", "--to", srcIP.String())
+		}
+
+		rules = append(rules, args)
 	}
 
 	comment := d.networkIPTablesComment(networkName)
 
 	if appendRule {
-		err := d.iptablesAppend(family, comment, "nat", "POSTROUTING", args...)
-		if err != nil {
-			return err
+		for _, args := range rules {
+			err := d.iptablesAppend(family, comment, "nat", "POSTROUTING", args...)
+			if err != nil {
+				return err
+			}
 		}
-
 	} else {
-		err := d.iptablesPrepend(family, comment, "nat", "POSTROUTING", args...)
-		if err != nil {
-			return err
+		// Prepend the rules in reverse order so that they keep their order.
+		for i := len(rules) - 1; i >= 0; i-- {
+			err := d.iptablesPrepend(family, comment, "nat", "POSTROUTING", rules[i]...)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -440,14 +481,14 @@ func (d Xtables) networkSetupDHCPv4Checksum(networkName string) error {
 // NetworkSetup configure network firewall.
 func (d Xtables) NetworkSetup(networkName string, opts Opts) error {
 	if opts.SNATV4 != nil {
-		err := d.networkSetupOutboundNAT(networkName, opts.SNATV4.Subnet, opts.SNATV4.SNATAddress, opts.SNATV4.Append)
+		err := d.networkSetupOutboundNAT(networkName, opts.SNATV4.Subnets, opts.SNATV4.Exclude, opts.SNATV4.SNATAddress, opts.SNATV4.Append)
 		if err != nil {
 			return err
 		}
 	}
 
 	if opts.SNATV6 != nil {
-		err := d.networkSetupOutboundNAT(networkName, opts.SNATV6.Subnet, opts.SNATV6.SNATAddress, opts.SNATV6.Append)
+		err := d.networkSetupOutboundNAT(networkName, opts.SNATV6.Subnets, opts.SNATV6.Exclude, opts.SNATV6.SNATAddress, opts.SNATV6.Append)
 		if err != nil {
 			return err
 		}
@@ -466,7 +507,7 @@ func (d Xtables) NetworkSetup(networkName string, opts Opts) error {
 			}
 		}
 
-		err := d.networkSetupForwardingPolicy(networkName, 4, opts.FeaturesV4.ForwardingAllow)
+		err := d.networkSetupForwardingPolicy(networkName, 4, opts.FeaturesV4.ForwardingAllow, opts.PeerInterfaces)
 		if err != nil {
 			return err
 		}
@@ -480,7 +521,7 @@ func (d Xtables) NetworkSetup(networkName string, opts Opts) error {
 			}
 		}
 
-		err := d.networkSetupForwardingPolicy(networkName, 6, opts.FeaturesV6.ForwardingAllow)
+		err := d.networkSetupForwardingPolicy(networkName, 6, opts.FeaturesV6.ForwardingAllow, opts.PeerInterfaces)
 		if err != nil {
 			return err
 		}
@@ -752,10 +793,11 @@ func (d Xtables) NetworkClear(networkName string, delete bool, ipVersions []uint
 	comments := []string{
 		d.networkIPTablesComment(networkName),
 		d.networkForwardIPTablesComment(networkName),
+		d.networkLoadBalancerIPTablesComment(networkName),
 	}
 
 	for _, ipVersion := range ipVersions {
-		// Clear any rules associated to the network and network address forwards.
+		// Clear any rules associated to the network, network address forwards and load balancers.
 		err := d.iptablesClear(ipVersion, comments, "filter", "mangle", "nat")
 		if err != nil {
 			return err
@@ -1368,6 +1410,69 @@ func (d Xtables) InstanceClearRPFilter(projectName string, instanceName string,
 	return nil
 }
 
+// InstanceSetupConntrackLimit places the connections originating from the instance's host side interfaces in
+// the specified conntrack zone and drops new connections once limit of them are tracked.
+func (d Xtables) InstanceSetupConntrackLimit(projectName string, instanceName string, hostNames []string, zone uint16, limit uint64) error {
+	comment := fmt.Sprintf("LXD container %s conntrack", project.Instance(projectName, instanceName))
+
+	// Remove any existing rules first so that the set of host interfaces is replaced.
+	err := d.InstanceClearConntrackLimit(projectName, instanceName)
+	if err != nil {
+		return err
+	}
+
+	ipVersions := []uint{4}
+	if shared.PathExists("/proc/sys/net/ipv6") {
+		ipVersions = append(ipVersions, 6)
+	}
+
+	for _, ipVersion := range ipVersions {
+		for _, hostName := range hostNames {
+			// Only set the zone for the original direction so that replies from other interfaces still match.
+			err = d.iptablesPrepend(ipVersion, comment, "raw", "PREROUTING", "-i", hostName, "-j", "CT", "--zone-orig", fmt.Sprintf("%d", zone))
+			if err != nil {
+				return err
+			}
+
+			err = d.iptablesPrepend(ipVersion, comment, "mangle", "PREROUTING", "-i", hostName, "-m", "conntrack", "--ctstate", "NEW", "-m", "connlimit", "--connlimit-above", fmt.Sprintf("%d", limit), "--connlimit-mask", "0", "-j", "DROP")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// InstanceClearConntrackLimit removes the conntrack zone and limit rules of the specified instance.
+func (d Xtables) InstanceClearConntrackLimit(projectName string, instanceName string) error {
+	comment := fmt.Sprintf("LXD container %s conntrack", project.Instance(projectName, instanceName))
+	errs := []error{}
+
+	for _, ipVersion := range []uint{4, 6} {
+		err := d.iptablesClear(ipVersion, []string{comment}, "raw", "mangle")
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Failed to remove conntrack limit rules for %q: %v", instanceName, errs)
+	}
+
+	return nil
+}
+
+// InstanceSetupACLRules isn't supported by the xtables driver as bridged traffic bypasses iptables.
+func (d Xtables) InstanceSetupACLRules(projectName string, instanceName string, deviceName string, hostName string, rules []ACLRule) error {
+	return fmt.Errorf("Network ACLs on bridged NICs require the nftables firewall driver")
+}
+
+// InstanceClearACLRules is a no-op as the xtables driver doesn't support ACL rules on instance devices.
+func (d Xtables) InstanceClearACLRules(projectName string, instanceName string, deviceName string) error {
+	return nil
+}
+
 // iptablesChainExists checks whether a chain exists in a table, and whether it has any rules.
 func (d Xtables) iptablesChainExists(ipVersion uint, table string, chain string) (bool, bool, error) {
 	var cmd string
@@ -1577,3 +1682,97 @@ func (d Xtables) NetworkApplyForwards(networkName string, rules []AddressForward
 
 	return nil
 }
+
+// NetworkApplyLoadBalancers apply network load balancer rules to firewall.
+func (d Xtables) NetworkApplyLoadBalancers(networkName string, rules []LoadBalancer) error {
+	comment := d.networkLoadBalancerIPTablesComment(networkName)
+
+	// Clear any load balancer rules associated to the network.
+	for _, ipVersion := range []uint{4, 6} {
+		err := d.iptablesClear(ipVersion, []string{comment}, "nat")
+		if err != nil {
+			return err
+		}
+	}
+
+	for ruleIndex, rule := range rules {
+		if rule.ListenAddress == nil {
+			return fmt.Errorf("Invalid rule %d, listen address is required", ruleIndex)
+		}
+
+		if rule.Protocol == "" || rule.ListenPort == 0 {
+			return fmt.Errorf("Invalid rule %d, protocol and listen port are required", ruleIndex)
+		}
+
+		targetsLen := len(rule.Targets)
+		if targetsLen < 1 {
+			return fmt.Errorf("Invalid rule %d, at least one target is required", ruleIndex)
+		}
+
+		if rule.Policy != LoadBalancerPolicyRoundRobin && rule.Policy != LoadBalancerPolicyRandom {
+			return fmt.Errorf("Invalid rule %d, unsupported policy %q", ruleIndex, rule.Policy)
+		}
+
+		ipVersion := uint(4)
+		if rule.ListenAddress.To4() == nil {
+			ipVersion = 6
+		}
+
+		listenAddressStr := rule.ListenAddress.String()
+		listenPortStr := fmt.Sprintf("%d", rule.ListenPort)
+
+		// Add the target rules in reverse order as they are prepended, so that the last target ends up as
+		// the catch-all rule after the others.
+		for i := targetsLen - 1; i >= 0; i-- {
+			target := rule.Targets[i]
+			if target.Address == nil {
+				return fmt.Errorf("Invalid rule %d, target address is required", ruleIndex)
+			}
+
+			targetAddressStr := target.Address.String()
+			targetPortStr := fmt.Sprintf("%d", target.Port)
+
+			// Format the destination host/port as appropriate.
+			targetDest := fmt.Sprintf("%s:%d", targetAddressStr, target.Port)
+			if ipVersion == 6 {
+				targetDest = fmt.Sprintf("[%s]:%d", targetAddressStr, target.Port)
+			}
+
+			// Each target rule picks one out of every N new connections reaching it, N being the number of
+			// targets left, so that connections are spread evenly. The last target takes the remainder.
+			var statistic []string
+			if i < targetsLen-1 {
+				if rule.Policy == LoadBalancerPolicyRandom {
+					statistic = []string{"-m", "statistic", "--mode", "random", "--probability", fmt.Sprintf("%.8f", 1/float64(targetsLen-i))}
+				} else {
+					statistic = []string{"-m", "statistic", "--mode", "nth", "--every", fmt.Sprintf("%d", targetsLen-i), "--packet", "0"}
+				}
+			}
+
+			match := []string{"-p", rule.Protocol, "--destination", listenAddressStr, "--dport", listenPortStr}
+			match = append(match, statistic...)
+			match = append(match, "-j", "DNAT", "--to-destination", targetDest)
+
+			// outbound <-> instance.
+			err := d.iptablesPrepend(ipVersion, comment, "nat", "PREROUTING", match...)
+			if err != nil {
+				return err
+			}
+
+			// host <-> instance.
+			err = d.iptablesPrepend(ipVersion, comment, "nat", "OUTPUT", match...)
+			if err != nil {
+				return err
+			}
+
+			// instance <-> instance.
+			// Requires instance's bridge port has hairpin mode enabled when br_netfilter is loaded.
+			err = d.iptablesPrepend(ipVersion, comment, "nat", "POSTROUTING", "-p", rule.Protocol, "--source", targetAddressStr, "--destination", targetAddressStr, "--dport", targetPortStr, "-j", "MASQUERADE")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -15,6 +15,7 @@ type Firewall interface {
 	NetworkClear(networkName string, delete bool, ipVersions []uint) error
 	NetworkApplyACLRules(networkName string, rules []drivers.ACLRule) error
 	NetworkApplyForwards(networkName string, rules []drivers.AddressForward) error
+	NetworkApplyLoadBalancers(networkName string, rules []drivers.LoadBalancer) error
 
 	InstanceSetupBridgeFilter(projectName string, instanceName string, deviceName string, parentName string, hostName string, hwAddr string, IPv4Nets []*net.IPNet, IPv6Nets []*net.IPNet, parentManaged bool) error
 	InstanceClearBridgeFilter(projectName string, instanceName string, deviceName string, parentName string, hostName string, hwAddr string, IPv4Nets []*net.IPNet, IPv6Nets []*net.IPNet) error
@@ -24,4 +25,10 @@ type Firewall interface {
 
 	InstanceSetupRPFilter(projectName string, instanceName string, deviceName string, hostName string) error
 	InstanceClearRPFilter(projectName string, instanceName string, deviceName string) error
+
+	InstanceSetupACLRules(projectName string, instanceName string, deviceName string, hostName string, rules []drivers.ACLRule) error
+	InstanceClearACLRules(projectName string, instanceName string, deviceName string) error
+
+	InstanceSetupConntrackLimit(projectName string, instanceName string, hostNames []string, zone uint16, limit uint64) error
+	InstanceClearConntrackLimit(projectName string, instanceName string) error
 }
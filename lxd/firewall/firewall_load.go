@@ -1,15 +1,68 @@
 package firewall
 
 import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
 	"github.com/lxc/lxd/lxd/firewall/drivers"
 	"github.com/lxc/lxd/shared/logger"
 )
 
+var firewallDrivers = map[string]func() Firewall{
+	"nftables": func() Firewall { return drivers.Nftables{} },
+	"xtables":  func() Firewall { return drivers.Xtables{} },
+}
+
+// ErrUnknownDriver is the "Unknown driver" error.
+var ErrUnknownDriver = fmt.Errorf("Unknown driver")
+
+// Load returns the firewall driver with the specified name, checking it's compatible with the host.
+func Load(driverName string) (Firewall, error) {
+	driverFunc, ok := firewallDrivers[driverName]
+	if !ok {
+		return nil, ErrUnknownDriver
+	}
+
+	d := driverFunc()
+
+	_, err := d.Compat()
+	if err != nil {
+		return nil, fmt.Errorf("Firewall driver %q isn't compatible with the host: %w", driverName, err)
+	}
+
+	return d, nil
+}
+
+// AllDriverNames returns a list of all firewall driver names.
+func AllDriverNames() []string {
+	driverNames := make([]string, 0, len(firewallDrivers))
+	for driverName := range firewallDrivers {
+		driverNames = append(driverNames, driverName)
+	}
+
+	sort.Strings(driverNames)
+
+	return driverNames
+}
+
 // New returns an appropriate firewall implementation.
-// Uses xtables if nftables isn't compatible or isn't in use already, otherwise uses nftables.
+// The driver can be forced with the LXD_FIREWALL_DRIVER environment variable, otherwise uses xtables if nftables
+// isn't compatible or isn't in use already, otherwise uses nftables.
 func New() Firewall {
-	nftables := drivers.Nftables{}
-	xtables := drivers.Xtables{}
+	driverName := os.Getenv("LXD_FIREWALL_DRIVER")
+	if driverName != "" {
+		d, err := Load(driverName)
+		if err == nil {
+			return d
+		}
+
+		logger.Warn("Failed loading the requested firewall driver, falling back to detection", logger.Ctx{"driver": driverName, "supported": strings.Join(AllDriverNames(), ", "), "err": err})
+	}
+
+	nftables := firewallDrivers["nftables"]()
+	xtables := firewallDrivers["xtables"]()
 
 	nftablesInUse, nftablesCompatErr := nftables.Compat()
 	if nftablesCompatErr != nil {
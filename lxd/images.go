@@ -185,10 +185,9 @@ func compressFile(compress string, infile io.Reader, outfile io.Writer) error {
  * This function takes a container or snapshot from the local image server and
  * exports it as an image.
  */
-func imgPostInstanceInfo(d *Daemon, r *http.Request, req api.ImagesPost, op *operations.Operation, builddir string, budget int64) (*api.Image, error) {
+func imgPostInstanceInfo(d *Daemon, projectName string, req api.ImagesPost, op *operations.Operation, builddir string, budget int64) (*api.Image, error) {
 	info := api.Image{}
 	info.Properties = map[string]string{}
-	projectName := projectParam(r)
 	name := req.Source.Name
 	ctype := req.Source.Type
 	if ctype == "" || name == "" {
@@ -221,6 +220,21 @@ func imgPostInstanceInfo(d *Daemon, r *http.Request, req api.ImagesPost, op *ope
 		return nil, err
 	}
 
+	// Check the instance (or the parent of the snapshot) isn't in the trash.
+	parent := c
+	if c.IsSnapshot() {
+		parentName, _, _ := shared.InstanceGetParentAndSnapshotName(name)
+		parent, err = instance.LoadByProjectAndName(d.State(), projectName, parentName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = instanceTrashedCheck(parent)
+	if err != nil {
+		return nil, err
+	}
+
 	info.Type = c.Type().String()
 
 	// Build the actual image file
@@ -1052,7 +1066,7 @@ func imagesPost(d *Daemon, r *http.Request) response.Response {
 			} else {
 				/* Processing image creation from container */
 				imagePublishLock.Lock()
-				info, err = imgPostInstanceInfo(d, r, req, op, builddir, budget)
+				info, err = imgPostInstanceInfo(d, projectName, req, op, builddir, budget)
 				imagePublishLock.Unlock()
 			}
 		}
@@ -1514,12 +1528,15 @@ func imagesGet(d *Daemon, r *http.Request) response.Response {
 func autoUpdateImagesTask(d *Daemon) (task.Func, task.Schedule) {
 	f := func(ctx context.Context) {
 		opRun := func(op *operations.Operation) error {
-			return autoUpdateImages(ctx, d)
+			err := autoUpdateImages(ctx, d)
+			task.ReportError(ctx, err)
+			return err
 		}
 
 		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationImagesUpdate, nil, nil, opRun, nil, nil, nil)
 		if err != nil {
 			logger.Error("Failed to start image update operation", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 			return
 		}
 
@@ -1532,6 +1549,7 @@ func autoUpdateImagesTask(d *Daemon) (task.Func, task.Schedule) {
 		err = op.Start()
 		if err != nil {
 			logger.Error("Failed to update images", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 		}
 
 		op.Wait(ctx)
@@ -2073,12 +2091,15 @@ func autoUpdateImage(ctx context.Context, d *Daemon, op *operations.Operation, i
 func pruneExpiredImagesTask(d *Daemon) (task.Func, task.Schedule) {
 	f := func(ctx context.Context) {
 		opRun := func(op *operations.Operation) error {
-			return pruneExpiredImages(ctx, d, op)
+			err := pruneExpiredImages(ctx, d, op)
+			task.ReportError(ctx, err)
+			return err
 		}
 
 		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationImagesExpire, nil, nil, opRun, nil, nil, nil)
 		if err != nil {
 			logger.Error("Failed to start expired image operation", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 			return
 		}
 
@@ -2091,6 +2112,7 @@ func pruneExpiredImagesTask(d *Daemon) (task.Func, task.Schedule) {
 		err = op.Start()
 		if err != nil {
 			logger.Error("Failed to expire images", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 		}
 
 		op.Wait(ctx)
@@ -2382,116 +2404,122 @@ func imageDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	do := func(op *operations.Operation) error {
-		// Lock this operation to ensure that concurrent image operations don't conflict.
-		// Other operations will wait for this one to finish.
-		unlock := d.imageOperationLock(imgInfo.Fingerprint)
-		defer unlock()
-
-		// Check image still exists and another request hasn't removed it since we resolved the image
-		// fingerprint above.
-		exist, err := d.db.Cluster.ImageExists(projectName, imgInfo.Fingerprint)
+		return doImageDelete(d, projectName, imgID, imgInfo, op, isClusterNotification(r))
+	}
+
+	resources := map[string][]string{}
+	resources["images"] = []string{imgInfo.Fingerprint}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationImageDelete, resources, nil, do, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// doImageDelete deletes the image from the project, removing it from disk and storage pools unless it is
+// still used by other projects. When clusterNotification is false, the other cluster members are notified
+// so they can remove their copy of the image too.
+func doImageDelete(d *Daemon, projectName string, imgID int, imgInfo *api.Image, op *operations.Operation, clusterNotification bool) error {
+	// Lock this operation to ensure that concurrent image operations don't conflict.
+	// Other operations will wait for this one to finish.
+	unlock := d.imageOperationLock(imgInfo.Fingerprint)
+	defer unlock()
+
+	// Check image still exists and another request hasn't removed it since its fingerprint was resolved.
+	exist, err := d.db.Cluster.ImageExists(projectName, imgInfo.Fingerprint)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		return api.StatusErrorf(http.StatusNotFound, "Image not found")
+	}
+
+	if !clusterNotification {
+		// Check if the image being deleted is actually still
+		// referenced by other projects. In that case we don't want to
+		// physically delete it just yet, but just to remove the
+		// relevant database entry.
+		referenced, err := d.db.Cluster.ImageIsReferencedByOtherProjects(projectName, imgInfo.Fingerprint)
 		if err != nil {
 			return err
 		}
 
-		if !exist {
-			return api.StatusErrorf(http.StatusNotFound, "Image not found")
-		}
-
-		if !isClusterNotification(r) {
-			// Check if the image being deleted is actually still
-			// referenced by other projects. In that case we don't want to
-			// physically delete it just yet, but just to remove the
-			// relevant database entry.
-			referenced, err := d.db.Cluster.ImageIsReferencedByOtherProjects(projectName, imgInfo.Fingerprint)
+		if referenced {
+			err := d.db.Cluster.DeleteImage(imgID)
 			if err != nil {
-				return err
+				return fmt.Errorf("Error deleting image info from the database: %w", err)
 			}
 
-			if referenced {
-				err := d.db.Cluster.DeleteImage(imgID)
-				if err != nil {
-					return fmt.Errorf("Error deleting image info from the database: %w", err)
-				}
+			return nil
+		}
 
-				return nil
-			}
+		// Notify the other nodes about the removed image so they can remove it from disk too.
+		notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), d.serverCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
 
-			// Notify the other nodes about the removed image so they can remove it from disk too.
-			notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), d.serverCert(), cluster.NotifyAll)
+		err = notifier(func(client lxd.InstanceServer) error {
+			op, err := client.UseProject(projectName).DeleteImage(imgInfo.Fingerprint)
 			if err != nil {
-				return err
+				return fmt.Errorf("Failed to request to delete image from peer node: %w", err)
 			}
 
-			err = notifier(func(client lxd.InstanceServer) error {
-				op, err := client.UseProject(projectName).DeleteImage(imgInfo.Fingerprint)
-				if err != nil {
-					return fmt.Errorf("Failed to request to delete image from peer node: %w", err)
-				}
-
-				err = op.Wait()
-				if err != nil {
-					return fmt.Errorf("Failed to delete image from peer node: %w", err)
-				}
-
-				return nil
-			})
+			err = op.Wait()
 			if err != nil {
-				return err
+				return fmt.Errorf("Failed to delete image from peer node: %w", err)
 			}
-		}
 
-		// Delete the pool volumes.
-		poolIDs, err := d.db.Cluster.GetPoolsWithImage(imgInfo.Fingerprint)
+			return nil
+		})
 		if err != nil {
 			return err
 		}
+	}
 
-		poolNames, err := d.db.Cluster.GetPoolNamesFromIDs(poolIDs)
-		if err != nil {
-			return err
-		}
+	// Delete the pool volumes.
+	poolIDs, err := d.db.Cluster.GetPoolsWithImage(imgInfo.Fingerprint)
+	if err != nil {
+		return err
+	}
 
-		for _, poolName := range poolNames {
-			pool, err := storagePools.LoadByName(d.State(), poolName)
-			if err != nil {
-				return fmt.Errorf("Error loading storage pool %q to delete image %q: %w", poolName, imgInfo.Fingerprint, err)
-			}
+	poolNames, err := d.db.Cluster.GetPoolNamesFromIDs(poolIDs)
+	if err != nil {
+		return err
+	}
 
-			// Only perform the deletion of remote volumes on the server handling the request.
-			if !isClusterNotification(r) || !pool.Driver().Info().Remote {
-				err = pool.DeleteImage(imgInfo.Fingerprint, op)
-				if err != nil {
-					return fmt.Errorf("Error deleting image %q from storage pool %q: %w", imgInfo.Fingerprint, pool.Name(), err)
-				}
-			}
+	for _, poolName := range poolNames {
+		pool, err := storagePools.LoadByName(d.State(), poolName)
+		if err != nil {
+			return fmt.Errorf("Error loading storage pool %q to delete image %q: %w", poolName, imgInfo.Fingerprint, err)
 		}
 
-		// Remove the database entry.
-		if !isClusterNotification(r) {
-			err = d.db.Cluster.DeleteImage(imgID)
+		// Only perform the deletion of remote volumes on the server handling the request.
+		if !clusterNotification || !pool.Driver().Info().Remote {
+			err = pool.DeleteImage(imgInfo.Fingerprint, op)
 			if err != nil {
-				return fmt.Errorf("Error deleting image info from the database: %w", err)
+				return fmt.Errorf("Error deleting image %q from storage pool %q: %w", imgInfo.Fingerprint, pool.Name(), err)
 			}
 		}
+	}
 
-		// Remove main image file from disk.
-		imageDeleteFromDisk(imgInfo.Fingerprint)
-
-		d.State().Events.SendLifecycle(projectName, lifecycle.ImageDeleted.Event(imgInfo.Fingerprint, projectName, op.Requestor(), nil))
-
-		return nil
+	// Remove the database entry.
+	if !clusterNotification {
+		err = d.db.Cluster.DeleteImage(imgID)
+		if err != nil {
+			return fmt.Errorf("Error deleting image info from the database: %w", err)
+		}
 	}
 
-	resources := map[string][]string{}
-	resources["images"] = []string{imgInfo.Fingerprint}
+	// Remove main image file from disk.
+	imageDeleteFromDisk(imgInfo.Fingerprint)
 
-	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationImageDelete, resources, nil, do, nil, nil, r)
-	if err != nil {
-		return response.InternalError(err)
-	}
+	d.State().Events.SendLifecycle(projectName, lifecycle.ImageDeleted.Event(imgInfo.Fingerprint, projectName, op.Requestor(), nil))
 
-	return operations.OperationResponse(op)
+	return nil
 }
 
 // Helper to delete an image file from the local images directory.
@@ -3060,7 +3088,7 @@ func imageAliasesGet(d *Daemon, r *http.Request) response.Response {
 			responseStr = append(responseStr, url)
 
 		} else {
-			_, alias, err := d.db.Cluster.GetImageAlias(projectName, name, true)
+			_, alias, err := d.db.Cluster.ResolveImageAlias(projectName, name, true)
 			if err != nil {
 				continue
 			}
@@ -3166,7 +3194,7 @@ func imageAliasGet(d *Daemon, r *http.Request) response.Response {
 
 	public := d.checkTrustedClient(r) != nil || allowProjectPermission("images", "view")(d, r) != response.EmptySyncResponse
 
-	_, alias, err := d.db.Cluster.GetImageAlias(projectName, name, !public)
+	_, alias, err := d.db.Cluster.ResolveImageAlias(projectName, name, !public)
 	if err != nil {
 		return response.SmartError(err)
 	}
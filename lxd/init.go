@@ -14,8 +14,21 @@ type initDataNode struct {
 	api.ServerPut `yaml:",inline"`
 	Networks      []internalClusterPostNetwork `json:"networks" yaml:"networks"`
 	StoragePools  []api.StoragePoolsPost       `json:"storage_pools" yaml:"storage_pools"`
-	Profiles      []api.ProfilesPost           `json:"profiles" yaml:"profiles"`
+	Profiles      []initDataNodeProfile        `json:"profiles" yaml:"profiles"`
 	Projects      []api.ProjectsPost           `json:"projects" yaml:"projects"`
+	NetworkACLs   []initDataNodeNetworkACL     `json:"network_acls" yaml:"network_acls"`
+}
+
+type initDataNodeProfile struct {
+	api.ProfilesPost `yaml:",inline"`
+
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
+}
+
+type initDataNodeNetworkACL struct {
+	api.NetworkACLsPost `yaml:",inline"`
+
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
 }
 
 type initDataCluster struct {
@@ -202,6 +215,78 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 		return nil
 	}
 
+	// Apply network ACL configuration function.
+	applyNetworkACL := func(acl initDataNodeNetworkACL) error {
+		currentACL, etag, err := d.UseProject(acl.Project).GetNetworkACL(acl.Name)
+		if err != nil {
+			// Create the network ACL if doesn't exist.
+			err := d.UseProject(acl.Project).CreateNetworkACL(acl.NetworkACLsPost)
+			if err != nil {
+				return fmt.Errorf("Failed to create network ACL %q in project %q: %w", acl.Name, acl.Project, err)
+			}
+
+			// Setup reverter.
+			revert.Add(func() { _ = d.UseProject(acl.Project).DeleteNetworkACL(acl.Name) })
+
+			return nil
+		}
+
+		// Prepare the update.
+		newACL := api.NetworkACLPut{}
+		err = shared.DeepCopy(currentACL.Writable(), &newACL)
+		if err != nil {
+			return fmt.Errorf("Failed to copy configuration of network ACL %q in project %q: %w", acl.Name, acl.Project, err)
+		}
+
+		// Description override.
+		if acl.Description != "" {
+			newACL.Description = acl.Description
+		}
+
+		// Config overrides.
+		for k, v := range acl.Config {
+			newACL.Config[k] = fmt.Sprintf("%v", v)
+		}
+
+		// Rules overrides.
+		if acl.Ingress != nil {
+			newACL.Ingress = acl.Ingress
+		}
+
+		if acl.Egress != nil {
+			newACL.Egress = acl.Egress
+		}
+
+		// Apply it.
+		err = d.UseProject(acl.Project).UpdateNetworkACL(currentACL.Name, newACL, etag)
+		if err != nil {
+			return fmt.Errorf("Failed to update network ACL %q in project %q: %w", acl.Name, acl.Project, err)
+		}
+
+		// Setup reverter.
+		revert.Add(func() {
+			_ = d.UseProject(acl.Project).UpdateNetworkACL(currentACL.Name, currentACL.Writable(), "")
+		})
+
+		return nil
+	}
+
+	// Apply network ACLs in the default project before its networks (so that networks can reference them).
+	for i := range config.NetworkACLs {
+		if config.NetworkACLs[i].Project == "" {
+			config.NetworkACLs[i].Project = project.Default
+		}
+
+		if config.NetworkACLs[i].Project != project.Default {
+			continue
+		}
+
+		err := applyNetworkACL(config.NetworkACLs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Apply networks in the default project before other projects config applied (so that if the projects
 	// depend on a network in the default project they can have their config applied successfully).
 	for i := range config.Networks {
@@ -298,6 +383,18 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 		}
 	}
 
+	// Apply network ACLs in non-default projects after project config applied (so that their projects exist).
+	for i := range config.NetworkACLs {
+		if config.NetworkACLs[i].Project == project.Default {
+			continue
+		}
+
+		err := applyNetworkACL(config.NetworkACLs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Apply networks in non-default projects after project config applied (so that their projects exist).
 	for i := range config.Networks {
 		if config.Networks[i].Project == project.Default {
@@ -312,35 +409,32 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 
 	// Apply profile configuration.
 	if config.Profiles != nil && len(config.Profiles) > 0 {
-		// Get the list of profiles.
-		profileNames, err := d.GetProfileNames()
-		if err != nil {
-			return nil, fmt.Errorf("Failed to retrieve list of profiles: %w", err)
-		}
+		// List of profiles per project.
+		profileNames := map[string][]string{}
 
 		// Profile creator.
-		createProfile := func(profile api.ProfilesPost) error {
+		createProfile := func(c lxd.InstanceServer, profile api.ProfilesPost) error {
 			// Create the profile if doesn't exist.
-			err := d.CreateProfile(profile)
+			err := c.CreateProfile(profile)
 			if err != nil {
 				return fmt.Errorf("Failed to create profile %q: %w", profile.Name, err)
 			}
 
 			// Setup reverter.
-			revert.Add(func() { _ = d.DeleteProfile(profile.Name) })
+			revert.Add(func() { _ = c.DeleteProfile(profile.Name) })
 			return nil
 		}
 
 		// Profile updater.
-		updateProfile := func(profile api.ProfilesPost) error {
+		updateProfile := func(c lxd.InstanceServer, profile api.ProfilesPost) error {
 			// Get the current profile.
-			currentProfile, etag, err := d.GetProfile(profile.Name)
+			currentProfile, etag, err := c.GetProfile(profile.Name)
 			if err != nil {
 				return fmt.Errorf("Failed to retrieve current profile %q: %w", profile.Name, err)
 			}
 
 			// Setup reverter.
-			revert.Add(func() { _ = d.UpdateProfile(currentProfile.Name, currentProfile.Writable(), "") })
+			revert.Add(func() { _ = c.UpdateProfile(currentProfile.Name, currentProfile.Writable(), "") })
 
 			// Prepare the update.
 			newProfile := api.ProfilePut{}
@@ -375,7 +469,7 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 			}
 
 			// Apply it.
-			err = d.UpdateProfile(currentProfile.Name, newProfile, etag)
+			err = c.UpdateProfile(currentProfile.Name, newProfile, etag)
 			if err != nil {
 				return fmt.Errorf("Failed to update profile %q: %w", profile.Name, err)
 			}
@@ -384,9 +478,29 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 		}
 
 		for _, profile := range config.Profiles {
+			projectName := profile.Project
+			if projectName == "" {
+				projectName = project.Default
+			}
+
+			c := d.UseProject(projectName)
+
+			// Get the list of profiles.
+			names, ok := profileNames[projectName]
+			if !ok {
+				var err error
+
+				names, err = c.GetProfileNames()
+				if err != nil {
+					return nil, fmt.Errorf("Failed to retrieve list of profiles in project %q: %w", projectName, err)
+				}
+
+				profileNames[projectName] = names
+			}
+
 			// New profile.
-			if !shared.StringInSlice(profile.Name, profileNames) {
-				err := createProfile(profile)
+			if !shared.StringInSlice(profile.Name, names) {
+				err := createProfile(c, profile.ProfilesPost)
 				if err != nil {
 					return nil, err
 				}
@@ -395,7 +509,7 @@ func initDataNodeApply(d lxd.InstanceServer, config initDataNode) (func(), error
 			}
 
 			// Existing profile.
-			err := updateProfile(profile)
+			err := updateProfile(c, profile.ProfilesPost)
 			if err != nil {
 				return nil, err
 			}
@@ -15,19 +15,29 @@ import (
 	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/nicstats"
 	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/locking"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 )
 
 // Helper functions
 
+// instanceUpdateLock acquires a lock for updating an instance's configuration and returns the unlock function.
+// This serializes updates so that the ETag check of a request and the update it makes are atomic.
+func instanceUpdateLock(projectName string, name string) locking.UnlockFunc {
+	return locking.Lock(fmt.Sprintf("InstanceUpdate_%s", project.Instance(projectName, name)))
+}
+
 // instanceCreateAsEmpty creates an empty instance.
 func instanceCreateAsEmpty(d *Daemon, args db.InstanceArgs) (instance.Instance, error) {
 	revert := revert.New()
@@ -79,6 +89,38 @@ func instanceImageTransfer(d *Daemon, r *http.Request, projectName string, hash
 	return nil
 }
 
+// instanceImageEnsureLocal transfers the image from another cluster member if it isn't available locally.
+func instanceImageEnsureLocal(d *Daemon, r *http.Request, projectName string, fingerprint string) error {
+	// Check if the image is available locally or it's on another member.
+	// Ensure we are the only ones operating on this image. Otherwise another instance created at the same
+	// time may also arrive at the conclusion that the image doesn't exist on this cluster member and then
+	// think it needs to download the image and store the record in the database as well, which will lead to
+	// duplicate record errors.
+	unlock := d.imageOperationLock(fingerprint)
+	defer unlock()
+
+	nodeAddress, err := d.db.Cluster.LocateImage(fingerprint)
+	if err != nil {
+		return fmt.Errorf("Locate image %q in the cluster: %w", fingerprint, err)
+	}
+
+	if nodeAddress != "" {
+		// The image is available from another node, let's try to import it.
+		err = instanceImageTransfer(d, r, projectName, fingerprint, nodeAddress)
+		if err != nil {
+			return fmt.Errorf("Failed transferring image %q from %q: %w", fingerprint, nodeAddress, err)
+		}
+
+		// As the image record already exists in the project, just add the node ID to the image.
+		err = d.db.Cluster.AddImageToLocalNode(projectName, fingerprint)
+		if err != nil {
+			return fmt.Errorf("Failed adding transferred image %q record to local cluster member: %w", fingerprint, err)
+		}
+	}
+
+	return nil
+}
+
 // instanceCreateFromImage creates an instance from a rootfs image.
 func instanceCreateFromImage(d *Daemon, r *http.Request, args db.InstanceArgs, hash string, op *operations.Operation) (instance.Instance, error) {
 	revert := revert.New()
@@ -107,37 +149,11 @@ func instanceCreateFromImage(d *Daemon, r *http.Request, args db.InstanceArgs, h
 		return nil, fmt.Errorf("Requested image's type '%s' doesn't match instance type '%s'", imgType, args.Type)
 	}
 
-	// Check if the image is available locally or it's on another member.
-	// Ensure we are the only ones operating on this image. Otherwise another instance created at the same
-	// time may also arrive at the conclusion that the image doesn't exist on this cluster member and then
-	// think it needs to download the image and store the record in the database as well, which will lead to
-	// duplicate record errors.
-	unlock := d.imageOperationLock(img.Fingerprint)
-
-	nodeAddress, err := s.DB.Cluster.LocateImage(hash)
+	err = instanceImageEnsureLocal(d, r, args.Project, img.Fingerprint)
 	if err != nil {
-		unlock()
-		return nil, fmt.Errorf("Locate image %q in the cluster: %w", hash, err)
-	}
-
-	if nodeAddress != "" {
-		// The image is available from another node, let's try to import it.
-		err = instanceImageTransfer(d, r, args.Project, img.Fingerprint, nodeAddress)
-		if err != nil {
-			unlock()
-			return nil, fmt.Errorf("Failed transferring image %q from %q: %w", img.Fingerprint, nodeAddress, err)
-		}
-
-		// As the image record already exists in the project, just add the node ID to the image.
-		err = d.db.Cluster.AddImageToLocalNode(args.Project, img.Fingerprint)
-		if err != nil {
-			unlock()
-			return nil, fmt.Errorf("Failed adding transferred image %q record to local cluster member: %w", img.Fingerprint, err)
-		}
+		return nil, err
 	}
 
-	unlock() // Image is available locally.
-
 	// Set the "image.*" keys.
 	if img.Properties != nil {
 		for k, v := range img.Properties {
@@ -430,6 +446,11 @@ func autoCreateContainerSnapshotsTask(d *Daemon) (task.Func, task.Schedule) {
 		// Figure out which need snapshotting (if any)
 		instances := []instance.Instance{}
 		for _, c := range allInstances {
+			// Trashed instances are left alone until they get restored.
+			if instanceIsTrashed(c) {
+				continue
+			}
+
 			schedule, ok := c.ExpandedConfig()["snapshots.schedule"]
 			if !ok || schedule == "" {
 				continue
@@ -524,6 +545,104 @@ func autoCreateContainerSnapshots(ctx context.Context, d *Daemon, instances []in
 	return nil
 }
 
+func instanceNICCountersTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		// Only the instance records are needed, the host side interface names of the NICs of running
+		// instances being recorded in their volatile config.
+		var instances []db.Instance
+		err := s.DB.Cluster.Transaction(ctx, func(ctx context.Context, tx *db.ClusterTx) error {
+			var err error
+			instances, err = tx.GetLocalInstancesInProject(db.InstanceFilter{})
+			return err
+		})
+		if err != nil {
+			logger.Error("Failed loading instances for NIC counters sampling", logger.Ctx{"err": err})
+			return
+		}
+
+		now := time.Now()
+		localInstances := map[string]bool{}
+		for _, inst := range instances {
+			localInstances[project.Instance(inst.Project, inst.Name)] = true
+
+			for key, hostName := range inst.Config {
+				fields := strings.Split(key, ".")
+				if len(fields) != 3 || fields[0] != "volatile" || fields[2] != "host_name" {
+					continue
+				}
+
+				// The host name is cleared on stop, so only the NICs of running instances are sampled.
+				devName := fields[1]
+				if hostName == "" || !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", hostName)) {
+					continue
+				}
+
+				hostCounters, err := resources.GetNetworkCounters(hostName)
+				if err != nil {
+					logger.Warn("Failed getting NIC counters", logger.Ctx{"project": inst.Project, "instance": inst.Name, "device": devName, "err": err})
+					continue
+				}
+
+				// The host side counters are reversed to report the traffic from the instance's point of view.
+				nicstats.Record(inst.Project, inst.Name, devName, hostName, api.InstanceStateNetworkCounters{
+					BytesReceived:   hostCounters.BytesSent,
+					BytesSent:       hostCounters.BytesReceived,
+					PacketsReceived: hostCounters.PacketsSent,
+					PacketsSent:     hostCounters.PacketsReceived,
+				}, now)
+			}
+		}
+
+		// Forget about the instances that are gone from this member.
+		nicstats.Prune(func(projectName string, instanceName string) bool {
+			return localInstances[project.Instance(projectName, instanceName)]
+		})
+
+		err = nicstats.Save(shared.VarPath("nicstats.json"))
+		if err != nil {
+			logger.Warn("Failed saving NIC counters", logger.Ctx{"err": err})
+		}
+	}
+
+	return f, task.Every(nicstats.Interval)
+}
+
+func instanceDevicesRetryTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		instances, err := instance.LoadNodeAll(s, instancetype.Any)
+		if err != nil {
+			logger.Error("Failed loading instances for device start retry", logger.Ctx{"err": err})
+			return
+		}
+
+		for _, inst := range instances {
+			// Skip instances without failed devices or with an ongoing operation.
+			failed := false
+			for key := range inst.LocalConfig() {
+				if strings.HasPrefix(key, shared.ConfigVolatilePrefix) && strings.HasSuffix(key, ".start_error") {
+					failed = true
+					break
+				}
+			}
+
+			if !failed || operationlock.Get(inst.Project(), inst.Name()) != nil {
+				continue
+			}
+
+			err = inst.RetryDevices()
+			if err != nil {
+				logger.Warn("Failed retrying instance devices", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			}
+		}
+	}
+
+	return f, task.Every(time.Minute)
+}
+
 func pruneExpiredInstanceSnapshotsTask(d *Daemon) (task.Func, task.Schedule) {
 	f := func(ctx context.Context) {
 		s := d.State()
@@ -557,6 +676,7 @@ func pruneExpiredInstanceSnapshotsTask(d *Daemon) (task.Func, task.Schedule) {
 		})
 		if err != nil {
 			logger.Error("Failed to get expired instance snapshots", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 			return
 		}
 
@@ -569,16 +689,20 @@ func pruneExpiredInstanceSnapshotsTask(d *Daemon) (task.Func, task.Schedule) {
 		snapshots, err := instance.LoadAllInternal(s, expiredSnapshots)
 		if err != nil {
 			logger.Error("Failed to load expired instance snapshots", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 			return
 		}
 
 		opRun := func(op *operations.Operation) error {
-			return pruneExpiredInstanceSnapshots(ctx, d, snapshots)
+			err := pruneExpiredInstanceSnapshots(ctx, d, snapshots)
+			task.ReportError(ctx, err)
+			return err
 		}
 
 		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationSnapshotsExpire, nil, nil, opRun, nil, nil, nil)
 		if err != nil {
 			logger.Error("Failed to start expired instance snapshots operation", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 			return
 		}
 
@@ -587,6 +711,7 @@ func pruneExpiredInstanceSnapshotsTask(d *Daemon) (task.Func, task.Schedule) {
 		err = op.Start()
 		if err != nil {
 			logger.Error("Failed to remove expired instance snapshots", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 		}
 
 		op.Wait(ctx)
@@ -22,19 +22,24 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/ip"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/maas"
+	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/warnings"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/osarch"
 )
 
 // ErrInstanceIsStopped indicates that the instance is stopped.
-var ErrInstanceIsStopped error = fmt.Errorf("The instance is already stopped")
+var ErrInstanceIsStopped error = api.ErrorWithType(api.ErrorTypeInstanceNotRunning, fmt.Errorf("The instance is already stopped"))
 
 // common provides structure common to all instance types.
 type common struct {
@@ -429,6 +434,114 @@ func (d *common) deviceVolatileSetFunc(devName string) func(save map[string]stri
 	}
 }
 
+// deviceStartErrorKey returns the volatile key recording why a device failed to start.
+func deviceStartErrorKey(devName string) string {
+	return fmt.Sprintf("volatile.%s.start_error", devName)
+}
+
+// deviceStartErrorsReset adds the changes needed to clear any previously recorded device start errors to
+// volatileSet and resolves the associated warning. Used when the instance is (re)started.
+func (d *common) deviceStartErrorsReset(volatileSet map[string]string) {
+	found := false
+	for k := range d.localConfig {
+		if strings.HasPrefix(k, shared.ConfigVolatilePrefix) && strings.HasSuffix(k, ".start_error") {
+			volatileSet[k] = ""
+			found = true
+		}
+	}
+
+	if found {
+		_ = warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(d.state.DB.Cluster, d.project, db.WarningInstanceDeviceStartFailure, dbCluster.TypeInstance, d.id)
+	}
+}
+
+// deviceStartFailed handles a device failing to start. Devices with required set to false don't prevent
+// the instance from starting, instead the error is recorded, a warning is raised and the device is retried
+// later by RetryDevices. Returns true if the failure has been handled this way and the device can be skipped.
+func (d *common) deviceStartFailed(dev device.Device, startErr error) bool {
+	if !shared.IsFalse(dev.Config()["required"]) {
+		return false
+	}
+
+	d.logger.Warn("Failed to start device that isn't required, skipping", logger.Ctx{"device": dev.Name(), "err": startErr})
+
+	err := d.VolatileSet(map[string]string{deviceStartErrorKey(dev.Name()): startErr.Error()})
+	if err != nil {
+		d.logger.Warn("Failed recording device start error", logger.Ctx{"device": dev.Name(), "err": err})
+	}
+
+	err = d.state.DB.Cluster.UpsertWarningLocalNode(d.project, dbCluster.TypeInstance, d.id, db.WarningInstanceDeviceStartFailure, fmt.Sprintf("Failed to start device %q: %v", dev.Name(), startErr))
+	if err != nil {
+		d.logger.Warn("Failed to create warning", logger.Ctx{"err": err})
+	}
+
+	return true
+}
+
+// devicesState returns the start status of the devices of a running instance.
+func (d *common) devicesState() map[string]api.InstanceStateDevice {
+	devices := make(map[string]api.InstanceStateDevice, len(d.expandedDevices))
+	for devName := range d.expandedDevices {
+		startErr := d.localConfig[deviceStartErrorKey(devName)]
+		if startErr != "" {
+			devices[devName] = api.InstanceStateDevice{Status: "failed", Error: startErr}
+			continue
+		}
+
+		devices[devName] = api.InstanceStateDevice{Status: "started"}
+	}
+
+	return devices
+}
+
+// retryDevices tries to start the hot-pluggable devices of a running instance that previously failed to
+// start, using the driver specific load and start functions. Devices that start successfully have their
+// recorded start error cleared, and the warning is resolved once all of them have started.
+func (d *common) retryDevices(load func(devName string, rawConfig deviceConfig.Device) (device.Device, error), start func(dev device.Device) error) error {
+	failed := 0
+	for _, entry := range d.expandedDevices.Sorted() {
+		if d.localConfig[deviceStartErrorKey(entry.Name)] == "" {
+			continue
+		}
+
+		dev, err := load(entry.Name, entry.Config)
+		if err != nil || !dev.CanHotPlug() {
+			failed++
+			continue // Will be retried when the instance is next started.
+		}
+
+		err = dev.PreStartCheck()
+		if err == nil {
+			err = start(dev)
+		}
+
+		if err != nil {
+			d.logger.Debug("Retrying device start failed", logger.Ctx{"device": entry.Name, "err": err})
+			failed++
+
+			err = d.VolatileSet(map[string]string{deviceStartErrorKey(entry.Name): err.Error()})
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		d.logger.Info("Started device after retry", logger.Ctx{"device": entry.Name})
+
+		err = d.VolatileSet(map[string]string{deviceStartErrorKey(entry.Name): ""})
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed == 0 {
+		return warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(d.state.DB.Cluster, d.project, db.WarningInstanceDeviceStartFailure, dbCluster.TypeInstance, d.id)
+	}
+
+	return nil
+}
+
 // expandConfig applies the config of each profile in order, followed by the local config.
 func (d *common) expandConfig(profiles []api.Profile) error {
 	if profiles == nil && len(d.profiles) > 0 {
@@ -516,6 +629,180 @@ func (d *common) restartCommon(inst instance.Instance, timeout time.Duration) er
 	return nil
 }
 
+// networkPauseCommon brings the host side interfaces of the instance's NICs down (paused) or back up, keeping
+// their configuration, so that a running instance can be isolated from the network. The paused state is kept
+// across restarts of the instance and applies to the NICs added or updated while paused.
+// NICs without a host side interface (like physical NICs) are left untouched.
+func (d *common) networkPauseCommon(inst instance.Instance, paused bool) error {
+	if !inst.IsRunning() {
+		return fmt.Errorf("The instance isn't running")
+	}
+
+	if shared.IsTrue(d.localConfig["volatile.network.paused"]) == paused {
+		if paused {
+			return fmt.Errorf("The instance network is already paused")
+		}
+
+		return fmt.Errorf("The instance network isn't paused")
+	}
+
+	hostNames := d.networkPauseHostNames()
+	if paused && len(hostNames) == 0 {
+		return fmt.Errorf("The instance has no NICs which can be paused")
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	setLink := func(link *ip.Link, up bool) error {
+		if up {
+			return link.SetUp()
+		}
+
+		return link.SetDown()
+	}
+
+	for _, hostName := range hostNames {
+		link := &ip.Link{Name: hostName}
+
+		err := setLink(link, !paused)
+		if err != nil {
+			return fmt.Errorf("Failed changing state of host interface %q: %w", hostName, err)
+		}
+
+		revert.Add(func() { _ = setLink(link, paused) })
+	}
+
+	value := ""
+	if paused {
+		value = "true"
+	}
+
+	err := d.VolatileSet(map[string]string{"volatile.network.paused": value})
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+
+	if paused {
+		d.logger.Info("Paused instance network")
+		d.state.Events.SendLifecycle(d.project, lifecycle.InstanceNetworkPaused.Event(inst, nil))
+	} else {
+		d.logger.Info("Resumed instance network")
+		d.state.Events.SendLifecycle(d.project, lifecycle.InstanceNetworkResumed.Event(inst, nil))
+	}
+
+	return nil
+}
+
+// networkPauseHostNames returns the existing host side interfaces of the instance's NICs.
+func (d *common) networkPauseHostNames() []string {
+	hostNames := []string{}
+	for _, entry := range d.expandedDevices.Sorted() {
+		if entry.Config["type"] != "nic" {
+			continue
+		}
+
+		hostName := d.localConfig[fmt.Sprintf("volatile.%s.host_name", entry.Name)]
+		if hostName == "" || !network.InterfaceExists(hostName) {
+			d.logger.Debug("Skipping NIC without host side interface for network pause", logger.Ctx{"device": entry.Name})
+			continue
+		}
+
+		hostNames = append(hostNames, hostName)
+	}
+
+	return hostNames
+}
+
+// networkPauseApply brings the host side interfaces of the instance's NICs down if its network is paused.
+// This keeps the NICs started, added or updated while the network is paused isolated.
+func (d *common) networkPauseApply() error {
+	if !shared.IsTrue(d.localConfig["volatile.network.paused"]) {
+		return nil
+	}
+
+	for _, hostName := range d.networkPauseHostNames() {
+		link := &ip.Link{Name: hostName}
+		err := link.SetDown()
+		if err != nil {
+			return fmt.Errorf("Failed pausing host interface %q: %w", hostName, err)
+		}
+	}
+
+	return nil
+}
+
+// conntrackZone returns the conntrack zone used for the connections of the instance's NICs.
+// Zone 0 is the default zone of the host so it is never used.
+func (d *common) conntrackZone() uint16 {
+	return uint16(d.id%65535) + 1
+}
+
+// conntrackLimitApply programs the limits.network.conntrack firewall rules for the host side interfaces of
+// the instance's NICs, or removes them if no limit is set.
+func (d *common) conntrackLimitApply() error {
+	if d.expandedConfig["limits.network.conntrack"] == "" {
+		return d.state.Firewall.InstanceClearConntrackLimit(d.project, d.name)
+	}
+
+	limit, err := strconv.ParseUint(d.expandedConfig["limits.network.conntrack"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid limits.network.conntrack: %w", err)
+	}
+
+	hostNames := []string{}
+	for _, entry := range d.expandedDevices.Sorted() {
+		if entry.Config["type"] != "nic" {
+			continue
+		}
+
+		hostName := d.localConfig[fmt.Sprintf("volatile.%s.host_name", entry.Name)]
+		if hostName == "" || !network.InterfaceExists(hostName) {
+			continue
+		}
+
+		hostNames = append(hostNames, hostName)
+	}
+
+	if len(hostNames) == 0 {
+		return d.state.Firewall.InstanceClearConntrackLimit(d.project, d.name)
+	}
+
+	err = d.state.Firewall.InstanceSetupConntrackLimit(d.project, d.name, hostNames, d.conntrackZone(), limit)
+	if err != nil {
+		return fmt.Errorf("Failed applying conntrack limit: %w", err)
+	}
+
+	return nil
+}
+
+// conntrackState returns the connection tracking usage of a running instance, or nil if no limit is set.
+func (d *common) conntrackState() *api.InstanceStateConntrack {
+	limit, err := strconv.ParseInt(d.expandedConfig["limits.network.conntrack"], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	conntrack := &api.InstanceStateConntrack{Limit: limit, Zone: int64(d.conntrackZone())}
+
+	out, err := shared.RunCommand("conntrack", "-L", "-w", fmt.Sprintf("%d", conntrack.Zone))
+	if err != nil {
+		d.logger.Debug("Failed listing conntrack entries", logger.Ctx{"err": err})
+		conntrack.Usage = -1
+		return conntrack
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			conntrack.Usage++
+		}
+	}
+
+	return conntrack
+}
+
 // runHooks executes the callback functions returned from a function.
 func (d *common) runHooks(hooks []func() error) error {
 	// Run any post start hooks.
@@ -529,6 +816,108 @@ func (d *common) runHooks(hooks []func() error) error {
 	return nil
 }
 
+// rebuildCommon handles the common part of the rebuild process.
+// The root volume is recreated from the image (or empty if img is nil) while the configuration, devices and
+// volatile keys such as NIC MAC addresses are preserved.
+func (d *common) rebuildCommon(inst instance.Instance, img *api.Image, op *operations.Operation) error {
+	instOp, err := operationlock.Create(d.Project(), d.Name(), operationlock.ActionRebuild, false, false)
+	if err != nil {
+		return fmt.Errorf("Failed to create instance rebuild operation: %w", err)
+	}
+
+	defer instOp.Done(nil)
+
+	if inst.IsRunning() {
+		return fmt.Errorf("The instance must be stopped to be rebuilt")
+	}
+
+	snapshots, err := inst.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) > 0 {
+		return fmt.Errorf("The instance can't be rebuilt while it has snapshots")
+	}
+
+	pool, err := d.getStoragePool()
+	if err != nil {
+		return err
+	}
+
+	// Replace the image related configuration.
+	args := db.InstanceArgs{
+		Architecture: inst.Architecture(),
+		Config:       map[string]string{},
+		Description:  inst.Description(),
+		Devices:      inst.LocalDevices(),
+		Ephemeral:    inst.IsEphemeral(),
+		Profiles:     inst.Profiles(),
+		Project:      inst.Project(),
+		Type:         inst.Type(),
+		Snapshot:     inst.IsSnapshot(),
+	}
+
+	for k, v := range inst.LocalConfig() {
+		if strings.HasPrefix(k, "image.") || k == "volatile.base_image" {
+			continue
+		}
+
+		args.Config[k] = v
+	}
+
+	if img != nil {
+		args.Architecture, err = osarch.ArchitectureId(img.Architecture)
+		if err != nil {
+			return err
+		}
+
+		for k, v := range img.Properties {
+			args.Config[fmt.Sprintf("image.%s", k)] = v
+		}
+
+		args.Config["volatile.base_image"] = img.Fingerprint
+	}
+
+	// The new root volume isn't shifted yet.
+	if inst.Type() == instancetype.Container {
+		args.Config["volatile.last_state.idmap"] = "[]"
+	}
+
+	d.logger.Info("Rebuilding instance", logger.Ctx{"image": args.Config["volatile.base_image"]})
+
+	err = pool.DeleteInstance(inst, op)
+	if err != nil {
+		return err
+	}
+
+	if img != nil {
+		err = pool.CreateInstanceFromImage(inst, img.Fingerprint, op)
+	} else {
+		err = pool.CreateInstance(inst, op)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed creating instance root volume: %w", err)
+	}
+
+	err = inst.Update(args, false)
+	if err != nil {
+		return err
+	}
+
+	// Let cloud-init run again against the new root volume.
+	err = d.resetInstanceID()
+	if err != nil {
+		return err
+	}
+
+	d.state.Events.SendLifecycle(d.project, lifecycle.InstanceRebuilt.Event(inst, nil))
+	d.logger.Info("Rebuilt instance", logger.Ctx{"image": args.Config["volatile.base_image"]})
+
+	return nil
+}
+
 // snapshot handles the common part of the snapshoting process.
 func (d *common) snapshotCommon(inst instance.Instance, name string, expiry time.Time, stateful bool) error {
 	revert := revert.New()
@@ -638,7 +1027,7 @@ func (d *common) isRunningStatusCode(statusCode api.StatusCode) bool {
 // isStartableStatusCode returns an error if the status code means the instance cannot be started currently.
 func (d *common) isStartableStatusCode(statusCode api.StatusCode) error {
 	if d.isRunningStatusCode(statusCode) {
-		return fmt.Errorf("The instance is already running")
+		return api.ErrorWithType(api.ErrorTypeInstanceRunning, fmt.Errorf("The instance is already running"))
 	}
 
 	// If the instance process exists but is crashed, don't allow starting until its been cleaned up, as it
@@ -968,8 +1357,13 @@ func (d *common) recordLastState() error {
 	})
 }
 
+// coreSchedulingEnabled returns whether the instance should get its own core scheduling domain.
+func (d *common) coreSchedulingEnabled() bool {
+	return d.state.OS.CoreScheduling && shared.IsTrueOrEmpty(d.expandedConfig["security.core_scheduling"])
+}
+
 func (d *common) setCoreSched(pids []int) error {
-	if !d.state.OS.CoreScheduling {
+	if !d.coreSchedulingEnabled() {
 		return nil
 	}
 
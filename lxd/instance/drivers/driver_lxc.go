@@ -40,11 +40,13 @@ import (
 	"github.com/lxc/lxd/lxd/device/nictype"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/nicstats"
 	"github.com/lxc/lxd/lxd/instance/operationlock"
 	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/locking"
 	"github.com/lxc/lxd/lxd/metrics"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/revert"
@@ -694,15 +696,17 @@ func (d *lxc) initLXC(config bool) error {
 		}
 	}
 
-	if d.state.OS.ContainerCoreScheduling {
-		err = lxcSetConfigItem(cc, "lxc.sched.core", "1")
-		if err != nil {
-			return err
-		}
-	} else if d.state.OS.CoreScheduling {
-		err = lxcSetConfigItem(cc, "lxc.hook.start-host", fmt.Sprintf("/proc/%d/exe forkcoresched 1", os.Getpid()))
-		if err != nil {
-			return err
+	if d.coreSchedulingEnabled() {
+		if d.state.OS.ContainerCoreScheduling {
+			err = lxcSetConfigItem(cc, "lxc.sched.core", "1")
+			if err != nil {
+				return err
+			}
+		} else {
+			err = lxcSetConfigItem(cc, "lxc.hook.start-host", fmt.Sprintf("/proc/%d/exe forkcoresched 1", os.Getpid()))
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -1204,6 +1208,22 @@ func (d *lxc) initLXC(config bool) error {
 		}
 	}
 
+	// GPU share (experimental, mainline kernels don't have the drm cgroup controller).
+	gpuShare := d.expandedConfig["limits.gpu.share"]
+	if gpuShare != "" && !d.state.OS.CGInfo.Supports(cgroup.DRM, cg) {
+		d.logger.Warn("Ignoring limits.gpu.share as the drm cgroup controller is missing")
+	} else if gpuShare != "" {
+		valueInt, err := strconv.ParseInt(gpuShare, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		err = cg.SetDRMWeight(valueInt)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Processes
 	if d.state.OS.CGInfo.Supports(cgroup.Pids, cg) {
 		processes := d.expandedConfig["limits.processes"]
@@ -1236,6 +1256,35 @@ func (d *lxc) initLXC(config bool) error {
 				}
 			}
 		}
+
+		// Reservations from the host huge page pools take precedence over the plain limits.
+		for key := range instance.HugepagesPoolSizes {
+			value := d.expandedConfig[key]
+			if value != "" {
+				value, err := units.ParseByteSizeString(value)
+				if err != nil {
+					return err
+				}
+
+				err = cg.SetHugepagesLimit(strings.TrimPrefix(key, "limits.memory.hugepages."), value)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Huge page pools mounts
+	for key := range instance.HugepagesPoolSizes {
+		if d.expandedConfig[key] == "" {
+			continue
+		}
+
+		mountName := d.hugepagesMountName(key)
+		err = lxcSetConfigItem(cc, "lxc.mount.entry", fmt.Sprintf("%s dev/%s none bind,create=dir,optional 0 0", filepath.Join(d.DevicesPath(), mountName), mountName))
+		if err != nil {
+			return err
+		}
 	}
 
 	// Setup process limits
@@ -1980,9 +2029,16 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 		return "", nil, err
 	}
 
+	// Select the NUMA nodes to place the instance on.
+	_, err = instance.NUMANodesAllocate(d.state, d)
+	if err != nil {
+		return "", nil, err
+	}
+
 	// Cleanup any existing leftover devices
 	_ = d.removeUnixDevices()
 	_ = d.removeDiskDevices()
+	_ = d.removeHugepagesMounts()
 
 	// Create any missing directories.
 	err = os.MkdirAll(d.LogPath(), 0700)
@@ -1995,6 +2051,13 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 		return "", nil, err
 	}
 
+	err = d.hugepagesMount()
+	if err != nil {
+		return "", nil, err
+	}
+
+	revert.Add(func() { _ = d.removeHugepagesMounts() })
+
 	err = os.MkdirAll(d.ShmountsPath(), 0711)
 	if err != nil {
 		return "", nil, err
@@ -2009,6 +2072,9 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 		volatileSet["volatile.uuid"] = instUUID
 	}
 
+	// Clear any device start errors from the previous run.
+	d.deviceStartErrorsReset(volatileSet)
+
 	// Apply any volatile changes that need to be made.
 	err = d.VolatileSet(volatileSet)
 	if err != nil {
@@ -2020,10 +2086,10 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 	nicID := -1
 	nvidiaDevices := []string{}
 
-	sortedDevices := d.expandedDevices.Sorted()
+	sortedDevices := d.expandedDevices.PrioritySorted()
 	startDevices := make([]device.Device, len(sortedDevices))
 
-	// Load devices in sorted order, this ensures that device mounts are added in path order.
+	// Load devices in priority then sorted order, this ensures that device mounts are added in path order.
 	// Loading all devices first means that validation of all devices occurs before starting any of them.
 	for i, entry := range sortedDevices {
 		dev, err := d.deviceLoad(entry.Name, entry.Config)
@@ -2047,6 +2113,10 @@ func (d *lxc) startCommon() (string, []func() error, error) {
 		// Start the device.
 		runConf, err := d.deviceStart(dev, false)
 		if err != nil {
+			if d.deviceStartFailed(dev, err) {
+				continue
+			}
+
 			return "", nil, fmt.Errorf("Failed to start device %q: %w", dev.Name(), err)
 		}
 
@@ -2358,6 +2428,14 @@ func (d *lxc) Start(stateful bool) error {
 	}
 	defer op.Done(nil)
 
+	// Check the huge page reservations once the start operation exists so that concurrent starts account for
+	// each other.
+	err = instance.HugepagesCheck(d.state, d)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	if !daemon.SharedMountsSetup {
 		err = fmt.Errorf("Daemon failed to setup shared mounts base. Does security.nesting need to be turned on?")
 		op.Done(err)
@@ -2426,6 +2504,15 @@ func (d *lxc) Start(stateful bool) error {
 			return err
 		}
 
+		// Keep the NICs isolated if the instance network is paused.
+		err = d.networkPauseApply()
+		if err != nil {
+			op.Done(err)
+			_ = d.Stop(false)
+
+			return err
+		}
+
 		if op.Action() == "start" {
 			d.logger.Info("Started container", ctxMap)
 			d.state.Events.SendLifecycle(d.project, lifecycle.InstanceStarted.Event(d, nil))
@@ -2511,6 +2598,15 @@ func (d *lxc) Start(stateful bool) error {
 		return err
 	}
 
+	// Keep the NICs isolated if the instance network is paused.
+	err = d.networkPauseApply()
+	if err != nil {
+		op.Done(err)
+		_ = d.Stop(false)
+
+		return err
+	}
+
 	if op.Action() == "start" {
 		d.logger.Info("Started container", ctxMap)
 		d.state.Events.SendLifecycle(d.project, lifecycle.InstanceStarted.Event(d, nil))
@@ -2582,6 +2678,12 @@ func (d *lxc) onStart(_ map[string]string) error {
 		}(d)
 	}
 
+	// Apply connection tracking limit.
+	err = d.conntrackLimitApply()
+	if err != nil {
+		return err
+	}
+
 	// Record last start state.
 	err = d.recordLastState()
 	if err != nil {
@@ -2635,19 +2737,14 @@ func (d *lxc) Stop(stateful bool) error {
 			return err
 		}
 
-		criuMigrationArgs := instance.CriuMigrationArgs{
-			Cmd:          liblxc.MIGRATE_DUMP,
-			StateDir:     stateDir,
-			Function:     "snapshot",
-			Stop:         true,
-			ActionScript: false,
-			DumpDir:      "",
-			PreDumpDir:   "",
-		}
-
 		// Checkpoint
-		err = d.Migrate(&criuMigrationArgs)
+		err = d.statefulDump(stateDir, "snapshot", true)
 		if err != nil {
+			if !d.IsRunning() {
+				// The dump failed after the container got stopped, don't leave a partial state around.
+				_ = os.RemoveAll(stateDir)
+			}
+
 			op.Done(err)
 			return err
 		}
@@ -2973,6 +3070,11 @@ func (d *lxc) onStop(args map[string]string) error {
 		// Clean up devices.
 		d.cleanupDevices(false, "")
 
+		err = d.state.Firewall.InstanceClearConntrackLimit(d.project, d.name)
+		if err != nil {
+			d.logger.Error("Failed clearing conntrack limit", logger.Ctx{"err": err})
+		}
+
 		// Remove directory ownership (to avoid issue if uidmap is re-used)
 		err := os.Chown(d.Path(), 0, 0)
 		if err != nil {
@@ -3017,6 +3119,13 @@ func (d *lxc) onStop(args map[string]string) error {
 			return
 		}
 
+		// Clean all the huge page pools mounts
+		err = d.removeHugepagesMounts()
+		if err != nil {
+			op.Done(fmt.Errorf("Failed to remove huge pages mounts: %w", err))
+			return
+		}
+
 		// Log and emit lifecycle if not user triggered
 		if instanceInitiated {
 			ctxMap := logger.Ctx{
@@ -3145,6 +3254,28 @@ func (d *lxc) Freeze() error {
 	return err
 }
 
+// NetworkPause brings the host side of the instance's NICs down without removing them.
+func (d *lxc) NetworkPause() error {
+	return d.networkPauseCommon(d, true)
+}
+
+// NetworkResume brings the host side of the instance's NICs back up after NetworkPause.
+func (d *lxc) NetworkResume() error {
+	return d.networkPauseCommon(d, false)
+}
+
+// RetryDevices tries to start the devices that failed to start when the instance was started.
+func (d *lxc) RetryDevices() error {
+	if !d.IsRunning() {
+		return nil
+	}
+
+	return d.retryDevices(d.deviceLoad, func(dev device.Device) error {
+		_, err := d.deviceStart(dev, true)
+		return err
+	})
+}
+
 // Unfreeze unfreezes the instance.
 func (d *lxc) Unfreeze() error {
 	ctxMap := logger.Ctx{
@@ -3310,7 +3441,7 @@ func (d *lxc) RenderFull() (*api.InstanceFull, any, error) {
 	ct := api.InstanceFull{Instance: *base.(*api.Instance)}
 
 	// Add the ContainerState
-	ct.State, err = d.renderState(ct.StatusCode)
+	ct.State, err = d.renderStateCached(ct.StatusCode, d.renderState)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -3369,7 +3500,13 @@ func (d *lxc) renderState(statusCode api.StatusCode) (*api.InstanceState, error)
 		status.Processes = d.processesState()
 	}
 
+	if d.isRunningStatusCode(statusCode) {
+		status.Devices = d.devicesState()
+		status.Conntrack = d.conntrackState()
+	}
+
 	status.Disk = d.diskState()
+	status.NetworkCounters = nicstats.Get(d.project, d.name)
 
 	d.release()
 
@@ -3378,7 +3515,7 @@ func (d *lxc) renderState(statusCode api.StatusCode) (*api.InstanceState, error)
 
 // RenderState renders just the running state of the instance.
 func (d *lxc) RenderState() (*api.InstanceState, error) {
-	return d.renderState(d.statusCode())
+	return d.renderStateCached(d.statusCode(), d.renderState)
 }
 
 // Snapshot takes a new snapshot.
@@ -3404,16 +3541,6 @@ func (d *lxc) Snapshot(name string, expiry time.Time, stateful bool) error {
 		 * was frozen. Until that's fixed, all calls to Unfreeze()
 		 * after snapshotting will fail.
 		 */
-		criuMigrationArgs := instance.CriuMigrationArgs{
-			Cmd:          liblxc.MIGRATE_DUMP,
-			StateDir:     d.StatePath(),
-			Function:     "snapshot",
-			Stop:         false,
-			ActionScript: false,
-			DumpDir:      "",
-			PreDumpDir:   "",
-		}
-
 		// Create the state path and Make sure we don't keep state around after the snapshot has been made.
 		err = os.MkdirAll(d.StatePath(), 0700)
 		if err != nil {
@@ -3423,7 +3550,7 @@ func (d *lxc) Snapshot(name string, expiry time.Time, stateful bool) error {
 		defer func() { _ = os.RemoveAll(d.StatePath()) }()
 
 		// Dump the state.
-		err = d.Migrate(&criuMigrationArgs)
+		err = d.statefulDump(d.StatePath(), "snapshot", false)
 		if err != nil {
 			return err
 		}
@@ -3781,6 +3908,11 @@ func (d *lxc) Delete(force bool) error {
 	return nil
 }
 
+// Rebuild recreates the container's root volume from an image (or empty if img is nil).
+func (d *lxc) Rebuild(img *api.Image, op *operations.Operation) error {
+	return d.rebuildCommon(d, img, op)
+}
+
 // Rename renames the instance. Accepts an argument to enable applying deferred TemplateTriggerRename.
 func (d *lxc) Rename(newName string, applyTemplateTrigger bool) error {
 	oldName := d.Name()
@@ -3973,7 +4105,7 @@ func (d *lxc) CGroupSet(key string, value string) error {
 // Update applies updated config.
 func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 	// Setup a new operation
-	op, err := operationlock.CreateWaitGet(d.Project(), d.Name(), operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore}, false, false)
+	op, err := operationlock.CreateWaitGet(d.Project(), d.Name(), operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore, operationlock.ActionRebuild}, false, false)
 	if err != nil {
 		return fmt.Errorf("Failed to create instance update operation: %w", err)
 	}
@@ -4001,8 +4133,14 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 	}
 
 	if userRequested {
+		// Submit the new config to the admission hook (if any).
+		err := instance.AdmissionCheck(d.state, "update", d.dbType, d.name, &args)
+		if err != nil {
+			return err
+		}
+
 		// Validate the new config
-		err := instance.ValidConfig(d.state.OS, args.Config, false, d.dbType)
+		err = instance.ValidConfig(d.state.OS, args.Config, false, d.dbType)
 		if err != nil {
 			return fmt.Errorf("Invalid config: %w", err)
 		}
@@ -4372,6 +4510,47 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 				if err != nil {
 					return err
 				}
+			} else if key == "limits.gpu.share" {
+				if !d.state.OS.CGInfo.Supports(cgroup.DRM, cg) {
+					d.logger.Warn("Ignoring limits.gpu.share as the drm cgroup controller is missing")
+					continue
+				}
+
+				// Default weight of the drm cgroup controller.
+				valueInt := int64(100)
+				if value != "" {
+					valueInt, err = strconv.ParseInt(value, 10, 64)
+					if err != nil {
+						return err
+					}
+				}
+
+				err = cg.SetDRMWeight(valueInt)
+				if err != nil {
+					return err
+				}
+			} else if strings.HasPrefix(key, "limits.memory.hugepages.") {
+				err = instance.HugepagesCheck(d.state, d)
+				if err != nil {
+					return err
+				}
+
+				if !d.state.OS.CGInfo.Supports(cgroup.Hugetlb, cg) {
+					continue
+				}
+
+				valueInt := int64(-1)
+				if value != "" {
+					valueInt, err = units.ParseByteSizeString(value)
+					if err != nil {
+						return err
+					}
+				}
+
+				err = cg.SetHugepagesLimit(strings.TrimPrefix(key, "limits.memory.hugepages."), valueInt)
+				if err != nil {
+					return err
+				}
 			} else if key == "limits.memory" || strings.HasPrefix(key, "limits.memory.") {
 				// Skip if no memory CGroup
 				if !d.state.OS.CGInfo.Supports(cgroup.Memory, cg) {
@@ -4530,7 +4709,20 @@ func (d *lxc) Update(args db.InstanceArgs, userRequested bool) error {
 				if err != nil {
 					return err
 				}
-			} else if key == "limits.cpu" {
+			} else if key == "limits.network.conntrack" {
+				err := d.conntrackLimitApply()
+				if err != nil {
+					return err
+				}
+			} else if key == "limits.cpu" || strings.HasPrefix(key, "limits.numa.") {
+				// Re-select the NUMA nodes
+				if strings.HasPrefix(key, "limits.numa.") || d.expandedConfig["limits.numa.policy"] != "" {
+					_, err = instance.NUMANodesAllocate(d.state, d)
+					if err != nil {
+						return err
+					}
+				}
+
 				// Trigger a scheduler re-run
 				cgroup.TaskSchedulerTrigger("container", d.name, "changed")
 			} else if key == "limits.cpu.priority" || key == "limits.cpu.allowance" {
@@ -4827,6 +5019,13 @@ func (d *lxc) updateDevices(removeDevices deviceConfig.Devices, addDevices devic
 		}
 	}
 
+	if instanceRunning {
+		err := d.networkPauseApply()
+		if err != nil {
+			return err
+		}
+	}
+
 	revert.Success()
 	return nil
 }
@@ -5183,6 +5382,11 @@ func (d *lxc) Migrate(args *instance.CriuMigrationArgs) error {
 
 		configPath := filepath.Join(d.LogPath(), "lxc.conf")
 
+		// Restore the final dump if the state was checkpointed using pre-dumps.
+		if args.DumpDir == "" {
+			args.DumpDir = statefulDumpDir(args.StateDir)
+		}
+
 		if args.DumpDir != "" {
 			finalStateDir = fmt.Sprintf("%s/%s", args.StateDir, args.DumpDir)
 		}
@@ -5790,7 +5994,7 @@ func (d *lxc) ConsoleLog(opts liblxc.ConsoleLogOptions) (string, error) {
 }
 
 // Exec executes a command inside the instance.
-func (d *lxc) Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File) (instance.Cmd, error) {
+func (d *lxc) Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File, extraFiles []*os.File) (instance.Cmd, error) {
 	// Prepare the environment
 	envSlice := []string{}
 
@@ -5819,12 +6023,14 @@ func (d *lxc) Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, st
 		fmt.Sprintf("%d", req.Group),
 	}
 
-	if d.state.OS.CoreScheduling && !d.state.OS.ContainerCoreScheduling {
+	if d.coreSchedulingEnabled() && !d.state.OS.ContainerCoreScheduling {
 		args = append(args, "1")
 	} else {
 		args = append(args, "0")
 	}
 
+	args = append(args, fmt.Sprintf("%d", len(extraFiles)))
+
 	args = append(args, "--")
 	args = append(args, "env")
 	args = append(args, envSlice...)
@@ -5866,6 +6072,7 @@ func (d *lxc) Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, st
 	}
 
 	cmd.ExtraFiles = []*os.File{stdin, stdout, stderr, wStatus}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, extraFiles...)
 	err = cmd.Start()
 	_ = wStatus.Close()
 	if err != nil {
@@ -6606,6 +6813,59 @@ func (d *lxc) FillNetworkDevice(name string, m deviceConfig.Device) (deviceConfi
 	return newDevice, nil
 }
 
+// hugepagesMountName returns the name of the mount of the huge page pool selected by the configuration key.
+func (d *lxc) hugepagesMountName(key string) string {
+	return fmt.Sprintf("hugepages-%s", strings.TrimPrefix(key, "limits.memory.hugepages."))
+}
+
+// hugepagesMount mounts a private hugetlbfs for each host huge page pool the instance reserves memory from.
+// Those are then bind-mounted into the container, the size of each mount being limited to the reservation.
+func (d *lxc) hugepagesMount() error {
+	for key, pageSize := range instance.HugepagesPoolSizes {
+		value := d.expandedConfig[key]
+		if value == "" {
+			continue
+		}
+
+		size, err := units.ParseByteSizeString(value)
+		if err != nil {
+			return err
+		}
+
+		mountPath := filepath.Join(d.DevicesPath(), d.hugepagesMountName(key))
+		err = os.MkdirAll(mountPath, 0711)
+		if err != nil {
+			return err
+		}
+
+		err = unix.Mount("hugetlbfs", mountPath, "hugetlbfs", 0, fmt.Sprintf("pagesize=%d,size=%d,mode=01777", pageSize, size))
+		if err != nil {
+			return fmt.Errorf("Failed mounting hugetlbfs on %q: %w", mountPath, err)
+		}
+	}
+
+	return nil
+}
+
+// removeHugepagesMounts unmounts and removes the huge page pools mounts of the instance.
+func (d *lxc) removeHugepagesMounts() error {
+	for key := range instance.HugepagesPoolSizes {
+		mountPath := filepath.Join(d.DevicesPath(), d.hugepagesMountName(key))
+		if !shared.PathExists(mountPath) {
+			continue
+		}
+
+		_ = unix.Unmount(mountPath, unix.MNT_DETACH)
+
+		err := os.Remove(mountPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (d *lxc) removeDiskDevices() error {
 	// Check that we indeed have devices to remove
 	if !shared.PathExists(d.DevicesPath()) {
@@ -0,0 +1,386 @@
+package drivers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	liblxc "gopkg.in/lxc/go-lxc.v2"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/migration"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// criuFinalDumpDir is the name of the directory holding the final dump when pre-dumps were taken.
+const criuFinalDumpDir = "final"
+
+// criuWarnDeviceTypes are the device types which CRIU can only checkpoint as long as no process keeps their device
+// nodes open.
+var criuWarnDeviceTypes = []string{"gpu", "infiniband", "pci", "tpm", "unix-block", "unix-char", "unix-hotplug", "usb"}
+
+// criuWarnNICTypes are the NIC types which CRIU may fail to restore depending on its version and the host setup.
+var criuWarnNICTypes = []string{"ipvlan", "macvlan"}
+
+// criuBlockedNICTypes are the NIC types which CRIU can't checkpoint as they move a host network device into the
+// container.
+var criuBlockedNICTypes = []string{"physical", "sriov"}
+
+// criuNetlinkProtocols are the netlink protocols supported by CRIU.
+var criuNetlinkProtocols = []uint64{
+	0,  // NETLINK_ROUTE
+	12, // NETLINK_NETFILTER
+	15, // NETLINK_KOBJECT_UEVENT
+	16, // NETLINK_GENERIC
+}
+
+// criuNetlinkSocket represents a netlink socket as listed in /proc/net/netlink.
+type criuNetlinkSocket struct {
+	protocol uint64
+	pending  bool
+}
+
+// criuParseNetlinkSockets parses the content of /proc/net/netlink, returning the netlink sockets indexed by inode.
+func criuParseNetlinkSockets(content string) map[uint64]criuNetlinkSocket {
+	sockets := map[uint64]criuNetlinkSocket{}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		// sk Eth Pid Groups Rmem Wmem Dump Locks Drops Inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[0] == "sk" {
+			continue
+		}
+
+		protocol, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		sockets[inode] = criuNetlinkSocket{
+			protocol: protocol,
+			pending:  fields[4] != "0" || fields[5] != "0" || fields[6] != "0",
+		}
+	}
+
+	return sockets
+}
+
+// criuProcesses returns the PIDs of the processes running in the container's PID namespace.
+func (d *lxc) criuProcesses() ([]int, error) {
+	initPID := d.InitPID()
+	if initPID <= 0 {
+		return nil, fmt.Errorf("Container isn't running")
+	}
+
+	pidNS, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/pid", initPID))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	pids := []int{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		ns, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/pid", pid))
+		if err != nil || ns != pidNS {
+			continue
+		}
+
+		pids = append(pids, pid)
+	}
+
+	sort.Ints(pids)
+
+	return pids, nil
+}
+
+// StatefulCheck returns the list of features in use by the container which prevent CRIU from checkpointing it,
+// along with the ones which may make the checkpoint fail. The blockers cover the network devices moved into the
+// container, the processes attached to a terminal from outside the container and the netlink sockets CRIU doesn't
+// know how to handle. The warnings cover the devices which CRIU can only handle as long as they aren't in use.
+func (d *lxc) StatefulCheck() ([]string, []string, error) {
+	blockers := []string{}
+	warnings := []string{}
+
+	_, err := exec.LookPath("criu")
+	if err != nil {
+		blockers = append(blockers, "CRIU isn't installed")
+	}
+
+	// Devices.
+	for _, name := range d.expandedDevices.Sorted() {
+		dev := name.Config
+		if shared.StringInSlice(dev["type"], criuWarnDeviceTypes) {
+			warnings = append(warnings, fmt.Sprintf("Device %q of type %q can't be checkpointed while in use", name.Name, dev["type"]))
+		} else if dev["type"] == "nic" && shared.StringInSlice(dev["nictype"], criuBlockedNICTypes) {
+			blockers = append(blockers, fmt.Sprintf("Device %q of NIC type %q", name.Name, dev["nictype"]))
+		} else if dev["type"] == "nic" && shared.StringInSlice(dev["nictype"], criuWarnNICTypes) {
+			warnings = append(warnings, fmt.Sprintf("Device %q of NIC type %q may not be restored by CRIU", name.Name, dev["nictype"]))
+		}
+	}
+
+	if !d.IsRunning() {
+		return blockers, warnings, nil
+	}
+
+	// Pre-dumps require memory tracking.
+	if shared.IsTrue(d.expandedConfig["migration.incremental.memory.stateful"]) {
+		err = d.Migrate(&instance.CriuMigrationArgs{Cmd: liblxc.MIGRATE_FEATURE_CHECK, Features: liblxc.FEATURE_MEM_TRACK})
+		if err != nil {
+			blockers = append(blockers, "CRIU memory tracking isn't supported (required by migration.incremental.memory.stateful)")
+		}
+	}
+
+	// Processes.
+	pids, err := d.criuProcesses()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var hostPts unix.Stat_t
+	err = unix.Stat("/dev/pts/ptmx", &hostPts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := os.ReadFile(fmt.Sprintf("/proc/%d/net/netlink", d.InitPID()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	netlinkSockets := criuParseNetlinkSockets(string(content))
+
+	for _, pid := range pids {
+		comm, _ := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+		process := fmt.Sprintf("%s (PID %d)", strings.TrimSpace(string(comm)), pid)
+
+		fdDir := fmt.Sprintf("/proc/%d/fd", pid)
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			// The process may have exited in the meantime.
+			continue
+		}
+
+		hostTTY := false
+		for _, fd := range fds {
+			fdPath := filepath.Join(fdDir, fd.Name())
+
+			target, err := os.Readlink(fdPath)
+			if err != nil {
+				continue
+			}
+
+			if strings.HasPrefix(target, "/dev/pts/") && !hostTTY {
+				var st unix.Stat_t
+				err := unix.Stat(fdPath, &st)
+				if err == nil && st.Dev == hostPts.Dev {
+					hostTTY = true
+					blockers = append(blockers, fmt.Sprintf("Process %s is attached to a terminal outside the container", process))
+				}
+
+				continue
+			}
+
+			var inode uint64
+			_, err = fmt.Sscanf(target, "socket:[%d]", &inode)
+			if err != nil {
+				continue
+			}
+
+			socket, ok := netlinkSockets[inode]
+			if !ok {
+				continue
+			}
+
+			if !shared.Uint64InSlice(socket.protocol, criuNetlinkProtocols) {
+				blockers = append(blockers, fmt.Sprintf("Process %s uses a netlink socket of unsupported protocol %d", process, socket.protocol))
+			} else if socket.pending {
+				blockers = append(blockers, fmt.Sprintf("Process %s has a netlink socket with pending data", process))
+			}
+		}
+	}
+
+	return blockers, warnings, nil
+}
+
+// statefulDumpFailure records the details of a failed checkpoint in the operation metadata.
+func (d *lxc) statefulDumpFailure(stage string, stateDir string, dumpDir string, err error) {
+	if d.op == nil {
+		return
+	}
+
+	meta := d.op.Metadata()
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+
+	failure := map[string]any{
+		"stage":   stage,
+		"error":   err.Error(),
+		"running": d.IsRunning(),
+	}
+
+	method := "dump"
+	if dumpDir != criuFinalDumpDir && dumpDir != "" {
+		method = "pre-dump"
+	}
+
+	log, logErr := getCRIULogErrors(filepath.Join(stateDir, dumpDir), method)
+	if logErr == nil && log != "" {
+		failure["criu_log"] = strings.Split(log, "\n")
+	}
+
+	meta["stateful_failure"] = failure
+	_ = d.op.UpdateMetadata(meta)
+}
+
+// statefulDump checkpoints the container into stateDir.
+// If migration.incremental.memory.stateful is enabled, pre-dumps are taken while the container keeps running until either
+// migration.incremental.memory.iterations is reached or the last pre-dump skipped migration.incremental.memory.goal
+// percent of the memory pages. The final dump then only has to write the remaining pages, shortening the freeze.
+func (d *lxc) statefulDump(stateDir string, function string, stop bool) error {
+	blockers, warnings, err := d.StatefulCheck()
+	if err != nil {
+		return fmt.Errorf("Failed checking CRIU compatibility: %w", err)
+	}
+
+	if len(warnings) > 0 {
+		d.logger.Warn("Checkpointing container using devices CRIU may not handle", logger.Ctx{"warnings": warnings})
+	}
+
+	if d.op != nil && (len(blockers) > 0 || len(warnings) > 0) {
+		meta := d.op.Metadata()
+		if meta == nil {
+			meta = make(map[string]any)
+		}
+
+		if len(blockers) > 0 {
+			meta["stateful_blockers"] = blockers
+		}
+
+		if len(warnings) > 0 {
+			meta["stateful_warnings"] = warnings
+		}
+
+		_ = d.op.UpdateMetadata(meta)
+	}
+
+	if len(blockers) > 0 {
+		return fmt.Errorf("The container can't be checkpointed:\n - %s", strings.Join(blockers, "\n - "))
+	}
+
+	iterations := 0
+	if shared.IsTrue(d.expandedConfig["migration.incremental.memory.stateful"]) {
+		iterations = 10
+		if d.expandedConfig["migration.incremental.memory.iterations"] != "" {
+			iterations, err = strconv.Atoi(d.expandedConfig["migration.incremental.memory.iterations"])
+			if err != nil {
+				return fmt.Errorf("Invalid migration.incremental.memory.iterations: %w", err)
+			}
+		}
+	}
+
+	goal := 70
+	if d.expandedConfig["migration.incremental.memory.goal"] != "" {
+		goal, err = strconv.Atoi(d.expandedConfig["migration.incremental.memory.goal"])
+		if err != nil {
+			return fmt.Errorf("Invalid migration.incremental.memory.goal: %w", err)
+		}
+	}
+
+	preDumpDir := ""
+	for i := 1; i <= iterations; i++ {
+		dumpDir := fmt.Sprintf("%03d", i)
+		stage := fmt.Sprintf("pre-dump %d/%d", i, iterations)
+		d.updateProgress(fmt.Sprintf("Checkpoint: %s", stage))
+
+		err = os.MkdirAll(filepath.Join(stateDir, dumpDir), 0700)
+		if err != nil {
+			return err
+		}
+
+		err = d.Migrate(&instance.CriuMigrationArgs{
+			Cmd:        liblxc.MIGRATE_PRE_DUMP,
+			StateDir:   stateDir,
+			Function:   function,
+			DumpDir:    dumpDir,
+			PreDumpDir: preDumpDir,
+		})
+		if err != nil {
+			d.statefulDumpFailure(stage, stateDir, dumpDir, err)
+			return err
+		}
+
+		preDumpDir = dumpDir
+
+		written, skipped, err := migration.ReadCriuStatsDump(filepath.Join(stateDir, dumpDir))
+		if err != nil {
+			// Without stats, move on to the final dump.
+			d.logger.Warn("Failed reading CRIU pre-dump statistics", logger.Ctx{"err": err})
+			break
+		}
+
+		if written+skipped > 0 && skipped*100/(written+skipped) >= uint64(goal) {
+			break
+		}
+	}
+
+	dumpDir := ""
+	if preDumpDir != "" {
+		dumpDir = criuFinalDumpDir
+
+		err = os.MkdirAll(filepath.Join(stateDir, dumpDir), 0700)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.updateProgress("Checkpoint: dump")
+
+	err = d.Migrate(&instance.CriuMigrationArgs{
+		Cmd:        liblxc.MIGRATE_DUMP,
+		StateDir:   stateDir,
+		Function:   function,
+		Stop:       stop,
+		DumpDir:    dumpDir,
+		PreDumpDir: preDumpDir,
+	})
+	if err != nil {
+		d.statefulDumpFailure("dump", stateDir, dumpDir, err)
+		return err
+	}
+
+	d.updateProgress("")
+
+	return nil
+}
+
+// statefulDumpDir returns the directory, relative to stateDir, holding the dump to restore.
+func statefulDumpDir(stateDir string) string {
+	if shared.PathExists(filepath.Join(stateDir, criuFinalDumpDir)) {
+		return criuFinalDumpDir
+	}
+
+	return ""
+}
@@ -0,0 +1,30 @@
+package drivers
+
+import (
+	"testing"
+)
+
+func TestCRIUParseNetlinkSockets(t *testing.T) {
+	content := `sk               Eth Pid        Groups   Rmem     Wmem     Dump  Locks    Drops    Inode
+0000000000000000 0   1          00000551 0        0        0     2        0        31437
+0000000000000000 9   0          00000000 0        0        0     2        0        31438
+0000000000000000 15  0          00000001 4096     0        0     2        0        31439
+`
+
+	sockets := criuParseNetlinkSockets(content)
+	if len(sockets) != 3 {
+		t.Fatalf("Expected 3 sockets, got %d", len(sockets))
+	}
+
+	if sockets[31437].protocol != 0 || sockets[31437].pending {
+		t.Fatalf("Unexpected route socket: %+v", sockets[31437])
+	}
+
+	if sockets[31438].protocol != 9 {
+		t.Fatalf("Unexpected audit socket protocol: %d", sockets[31438].protocol)
+	}
+
+	if !sockets[31439].pending {
+		t.Fatalf("Expected the uevent socket to have pending data")
+	}
+}
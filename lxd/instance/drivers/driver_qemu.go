@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/flosch/pongo2"
@@ -45,10 +46,12 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/drivers/qmp"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/nicstats"
 	"github.com/lxc/lxd/lxd/instance/operationlock"
 	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/metrics"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/response"
@@ -89,12 +92,23 @@ const qemuNetDevIDPrefix = "lxd_"
 // qemuBlockDevIDPrefix used as part of the name given QEMU blockdevs generated from user added devices.
 const qemuBlockDevIDPrefix = "lxd_"
 
+// qemuSnapshotOverlayNodeName is the node name of the temporary overlay used when snapshotting a running VM.
+const qemuSnapshotOverlayNodeName = "lxd_snapshot_overlay"
+
+// qemuSnapshotOverlays records the instances whose root disk writes are redirected to a snapshot overlay by
+// this process, keyed on project prefixed instance name.
+var qemuSnapshotOverlays = map[string]struct{}{}
+var qemuSnapshotOverlaysMu sync.Mutex
+
 // qemuSparseUSBPorts is the amount of sparse USB ports for VMs.
 // 4 are reserved, and the other 4 can be used for any USB device.
 const qemuSparseUSBPorts = 8
 
 var errQemuAgentOffline = fmt.Errorf("LXD VM agent isn't currently running")
 
+// qemuAgentSecretsLock serialises the delivery of the secrets to the agents so that they're only sent once.
+var qemuAgentSecretsLock sync.Mutex
+
 type monitorHook func(m *qmp.Monitor) error
 
 // qemuLoad creates a Qemu instance from the supplied InstanceArgs.
@@ -314,6 +328,9 @@ type qemu struct {
 	// Do not use these variables directly, instead use their associated get functions so they
 	// will be initialised on demand.
 	architectureName string
+
+	// Whether root disk writes are currently redirected to a temporary snapshot overlay.
+	snapshotOverlayActive bool
 }
 
 // getAgentClient returns the current agent client handle. To avoid TLS setup each time this
@@ -363,7 +380,7 @@ func (d *qemu) getMonitorEventHandler() func(event string, data map[string]any)
 	state := d.state
 
 	return func(event string, data map[string]any) {
-		if !shared.StringInSlice(event, []string{"SHUTDOWN", "RESET"}) {
+		if !shared.StringInSlice(event, []string{"SHUTDOWN", "RESET", qmp.EventAgentStarted}) {
 			return // Don't bother loading the instance from DB if we aren't going to handle the event.
 		}
 
@@ -380,7 +397,13 @@ func (d *qemu) getMonitorEventHandler() func(event string, data map[string]any)
 			}
 		}
 
-		if event == "RESET" {
+		if event == qmp.EventAgentStarted {
+			// Hand the secrets staged while the agent wasn't running over to it.
+			err = inst.(*qemu).AgentSecretsDeliver()
+			if err != nil {
+				d.logger.Error("Failed delivering secrets to lxd-agent", logger.Ctx{"err": err})
+			}
+		} else if event == "RESET" {
 			// As we cannot start QEMU with the -no-reboot flag, because we have to issue a
 			// system_reset QMP command to have the devices bootindex applied, then we need to handle
 			// the RESET events triggered from a guest-reset operation and prevent QEMU internally
@@ -504,6 +527,12 @@ func (d *qemu) generateAgentCert() (string, string, string, string, error) {
 
 // Freeze freezes the instance.
 func (d *qemu) Freeze() error {
+	// While the root disk writes go to a snapshot overlay the underlying disk is already stable, so there is
+	// no need to pause the VM.
+	if d.snapshotOverlayActive {
+		return nil
+	}
+
 	// Connect to the monitor.
 	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
 	if err != nil {
@@ -599,9 +628,29 @@ func (d *qemu) onStop(target string) error {
 
 	// Cleanup.
 	d.cleanupDevices() // Must be called before unmount.
+
+	err = d.state.Firewall.InstanceClearConntrackLimit(d.project, d.name)
+	if err != nil {
+		d.logger.Error("Failed clearing conntrack limit", logger.Ctx{"err": err})
+	}
+
 	_ = os.Remove(d.pidFilePath())
 	_ = os.Remove(d.monitorPath())
 
+	// Merge the root disk writes of an interrupted snapshot, unless the snapshot is still being taken, in
+	// which case it's done on next start.
+	if !d.snapshotOverlayInProgress() {
+		mountInfo, err := d.mount()
+		if err == nil {
+			err = d.snapshotOverlayRecover(mountInfo.DiskPath)
+			_ = d.unmount()
+		}
+
+		if err != nil {
+			d.logger.Error("Failed merging snapshot overlay into root disk", logger.Ctx{"err": err})
+		}
+	}
+
 	// Stop the storage for the instance.
 	_ = op.ResetTimeout(waitTimeout)
 	err = d.unmount()
@@ -663,6 +712,10 @@ func (d *qemu) Shutdown(timeout time.Duration) error {
 		return ErrInstanceIsStopped
 	}
 
+	if d.snapshotOverlayInProgress() {
+		return fmt.Errorf("The instance can't be stopped while a snapshot of it is being taken")
+	}
+
 	// Setup a new operation.
 	// Allow inheriting of ongoing restart operation (we are called from restartCommon).
 	// Allow reuse when creating a new stop operation. This allows the Stop() function to inherit operation.
@@ -864,11 +917,85 @@ func (d *qemu) restoreState(monitor *qmp.Monitor) error {
 	return nil
 }
 
-// saveState dumps the current VM state to disk.
-// Once dumped, the VM is in a paused state and it's up to the caller to resume or kill it.
-func (d *qemu) saveState(monitor *qmp.Monitor) error {
+// stateCPUFlagsPath returns the path of the file recording the host CPU flags the VM state was saved with.
+func (d *qemu) stateCPUFlagsPath() string {
+	return filepath.Join(d.Path(), "state.cpu")
+}
+
+// hostCPUFlags returns the CPU flags of the host as listed in /proc/cpuinfo.
+func hostCPUFlags() ([]string, error) {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(fields[0])
+		if key == "flags" || key == "Features" {
+			return strings.Fields(fields[1]), nil
+		}
+	}
+
+	return []string{}, nil
+}
+
+// stateCPUCheck checks that the host CPU provides all the CPU flags the VM state was saved with.
+// As the VM uses the host CPU model, restoring its state on a CPU lacking some of those flags would fail or crash
+// the guest. States saved without recording the CPU flags aren't checked.
+func (d *qemu) stateCPUCheck() error {
+	content, err := os.ReadFile(d.stateCPUFlagsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	hostFlags, err := hostCPUFlags()
+	if err != nil {
+		return fmt.Errorf("Failed getting host CPU flags: %w", err)
+	}
+
+	missing := []string{}
+	for _, flag := range strings.Fields(string(content)) {
+		if !shared.StringInSlice(flag, hostFlags) {
+			missing = append(missing, flag)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("The instance state can't be restored on this host, CPU flags missing: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// saveState dumps the current VM state to disk, compressed.
+// When background is set, the VM keeps running while its memory is written and the state matches the time the
+// dump started. Otherwise, once dumped, the VM is in a paused state and it's up to the caller to resume or kill it.
+func (d *qemu) saveState(monitor *qmp.Monitor, background bool) error {
 	_ = os.Remove(d.StatePath())
 
+	// Record the host CPU flags so that the state is only restored on compatible hosts.
+	hostFlags, err := hostCPUFlags()
+	if err != nil {
+		return fmt.Errorf("Failed getting host CPU flags: %w", err)
+	}
+
+	err = os.WriteFile(d.stateCPUFlagsPath(), []byte(strings.Join(hostFlags, " ")), 0600)
+	if err != nil {
+		return err
+	}
+
 	// Prepare the state file.
 	stateFile, err := os.Create(d.StatePath())
 	if err != nil {
@@ -901,7 +1028,12 @@ func (d *qemu) saveState(monitor *qmp.Monitor) error {
 	}
 
 	// Issue the migration command.
-	err = monitor.Migrate("fd:migration")
+	if background {
+		err = monitor.MigrateBackground("fd:migration")
+	} else {
+		err = monitor.Migrate("fd:migration")
+	}
+
 	if err != nil {
 		_ = compressedState.Close()
 		_ = stateFile.Close()
@@ -942,6 +1074,14 @@ func (d *qemu) validateStartup(stateful bool) error {
 		return fmt.Errorf("Stateful start requires migration.stateful to be set to true")
 	}
 
+	// The saved state can only be restored on a host with a compatible CPU.
+	if stateful {
+		err = d.stateCPUCheck()
+		if err != nil {
+			return err
+		}
+	}
+
 	// The "size.state" of the instance root disk device must be larger than the instance memory.
 	// Otherwise, there will not be enough disk space to write the instance state to disk during any subsequent stops.
 	// (Only check when migration.stateful is true, otherwise the memory won't be dumped when this instance stops).
@@ -976,6 +1116,41 @@ func (d *qemu) validateStartup(stateful bool) error {
 		}
 	}
 
+	// The VM memory can only be backed by a single huge page pool and the reservation must cover all of it.
+	hugepagesKey := ""
+	for key := range instance.HugepagesPoolSizes {
+		if d.expandedConfig[key] == "" {
+			continue
+		}
+
+		if hugepagesKey != "" {
+			return fmt.Errorf("Only one of %q and %q can be set on virtual machines", hugepagesKey, key)
+		}
+
+		hugepagesKey = key
+	}
+
+	if hugepagesKey != "" {
+		memoryLimitStr := qemuDefaultMemSize
+		if d.expandedConfig["limits.memory"] != "" {
+			memoryLimitStr = d.expandedConfig["limits.memory"]
+		}
+
+		memoryLimit, err := units.ParseByteSizeString(memoryLimitStr)
+		if err != nil {
+			return err
+		}
+
+		reserved, err := units.ParseByteSizeString(d.expandedConfig[hugepagesKey])
+		if err != nil {
+			return err
+		}
+
+		if reserved < memoryLimit {
+			return fmt.Errorf("%q must be at least as large as limits.memory", hugepagesKey)
+		}
+	}
+
 	return nil
 }
 
@@ -989,6 +1164,10 @@ func (d *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	if d.snapshotOverlayInProgress() {
+		return fmt.Errorf("The instance can't be started while a snapshot of it is being taken")
+	}
+
 	// Ensure secureboot is turned off for images that are not secureboot enabled
 	if shared.IsFalse(d.localConfig["image.requirements.secureboot"]) && shared.IsTrueOrEmpty(d.expandedConfig["security.secureboot"]) {
 		return fmt.Errorf("The image used by this instance is incompatible with secureboot. Please set security.secureboot=false on the instance")
@@ -1006,6 +1185,14 @@ func (d *qemu) Start(stateful bool) error {
 	}
 	defer op.Done(nil)
 
+	// Check the huge page reservations once the start operation exists so that concurrent starts account for
+	// each other.
+	err = instance.HugepagesCheck(d.state, d)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Ensure the correct vhost_vsock kernel module is loaded before establishing the vsock.
 	err = util.LoadModule("vhost_vsock")
 	if err != nil {
@@ -1045,6 +1232,20 @@ func (d *qemu) Start(stateful bool) error {
 
 	revert.Add(func() { _ = d.unmount() })
 
+	// Merge the root disk writes of an interrupted snapshot before booting from the root disk.
+	err = d.snapshotOverlayRecover(mountInfo.DiskPath)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
+	// Select the NUMA nodes to place the instance on.
+	_, err = instance.NUMANodesAllocate(d.state, d)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	volatileSet := make(map[string]string)
 
 	// Update vsock ID in volatile if needed for recovery (do this before UpdateBackupFile() call).
@@ -1102,6 +1303,9 @@ func (d *qemu) Start(stateful bool) error {
 		volatileSet["volatile.apply_nvram"] = ""
 	}
 
+	// Clear any device start errors from the previous run.
+	d.deviceStartErrorsReset(volatileSet)
+
 	// Apply any volatile changes that need to be made.
 	err = d.VolatileSet(volatileSet)
 	if err != nil {
@@ -1140,6 +1344,10 @@ func (d *qemu) Start(stateful bool) error {
 		// Start the device.
 		runConf, err := d.deviceStart(dev, false)
 		if err != nil {
+			if d.deviceStartFailed(dev, err) {
+				continue
+			}
+
 			op.Done(err)
 			return fmt.Errorf("Failed to start device %q: %w", dev.Name(), err)
 		}
@@ -1362,8 +1570,8 @@ func (d *qemu) Start(stateful bool) error {
 	}
 
 	// Handle hugepages on architectures where we don't set NUMA nodes.
-	if d.architecture != osarch.ARCH_64BIT_INTEL_X86 && shared.IsTrue(d.expandedConfig["limits.memory.hugepages"]) {
-		hugetlb, err := util.HugepagesPath()
+	if d.architecture != osarch.ARCH_64BIT_INTEL_X86 && d.hugepagesEnabled() {
+		hugetlb, err := d.hugepagesPath()
 		if err != nil {
 			op.Done(err)
 			return err
@@ -1507,6 +1715,13 @@ func (d *qemu) Start(stateful bool) error {
 		}
 	}
 
+	// Restrict the vCPUs to the NUMA node backing their virtual socket.
+	err = d.numaSetAffinity(pids)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Run monitor hooks from devices.
 	for _, monHook := range monHooks {
 		err = monHook(monitor)
@@ -1545,6 +1760,7 @@ func (d *qemu) Start(stateful bool) error {
 	if stateful {
 		// Cleanup state.
 		_ = os.Remove(d.StatePath())
+		_ = os.Remove(d.stateCPUFlagsPath())
 		d.stateful = false
 
 		err = d.state.DB.Cluster.UpdateInstanceStatefulFlag(d.id, false)
@@ -1554,6 +1770,13 @@ func (d *qemu) Start(stateful bool) error {
 		}
 	}
 
+	// Apply connection tracking limit.
+	err = d.conntrackLimitApply()
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Record last start state.
 	err = d.recordLastState()
 	if err != nil {
@@ -1573,6 +1796,14 @@ func (d *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	// Keep the NICs isolated if the instance network is paused.
+	err = d.networkPauseApply()
+	if err != nil {
+		op.Done(err)
+		_ = d.Stop(false)
+		return err
+	}
+
 	if op.Action() == "start" {
 		d.state.Events.SendLifecycle(d.project, lifecycle.InstanceStarted.Event(d, nil))
 	}
@@ -1767,6 +1998,20 @@ func (d *qemu) deviceStart(dev device.Device, instanceRunning bool) (*deviceConf
 				}
 			}
 
+			if len(runConf.GPUDevice) > 0 {
+				err = d.deviceAttachPCI(dev.Name(), runConf.GPUDevice)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if len(runConf.PCIDevice) > 0 {
+				err = d.deviceAttachPCI(dev.Name(), runConf.PCIDevice)
+				if err != nil {
+					return nil, err
+				}
+			}
+
 			// If running, run post start hooks now (if not running LXD will run them
 			// once the instance is started).
 			err = d.runHooks(runConf.PostHooks)
@@ -1953,6 +2198,14 @@ func (d *qemu) deviceStop(dev device.Device, instanceRunning bool) error {
 				return err
 			}
 		}
+
+		// Detach PCI and GPU devices from running instance before they are given back to the host.
+		if shared.StringInSlice(configCopy["type"], []string{"pci", "gpu"}) {
+			err = d.deviceDetachPCI(dev.Name())
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	if runConf != nil {
@@ -2836,6 +3089,41 @@ func (d *qemu) addCPUMemoryConfig(cfg *[]cfgSection) (int, error) {
 		cpuOpts.cpuCores = cpuCount
 		cpuOpts.cpuThreads = 1
 		hostNodes = []uint64{0}
+
+		// If placed on specific NUMA nodes, expose one socket per node with its memory bound to the node.
+		numaNodes, err := instance.NUMANodes(d)
+		if err != nil {
+			return -1, err
+		}
+
+		if len(numaNodes) > 0 {
+			if cpuCount%len(numaNodes) != 0 {
+				return -1, fmt.Errorf("limits.cpu (%d) must be a multiple of the number of NUMA nodes (%d)", cpuCount, len(numaNodes))
+			}
+
+			cpuOpts.cpuSockets = len(numaNodes)
+			cpuOpts.cpuCores = cpuCount / len(numaNodes)
+
+			numa := []qemuNumaEntry{}
+			numaIDs := []uint64{}
+			for i := range numaNodes {
+				numaIDs = append(numaIDs, uint64(i))
+
+				for core := 0; core < cpuOpts.cpuCores; core++ {
+					numa = append(numa, qemuNumaEntry{
+						node:   uint64(i),
+						socket: uint64(i),
+						core:   uint64(core),
+						thread: 0,
+					})
+				}
+			}
+
+			hostNodes = numaNodes
+			cpuOpts.cpuNumaNodes = numaIDs
+			cpuOpts.cpuNumaMapping = numa
+			cpuOpts.cpuNumaHostNodes = hostNodes
+		}
 	} else {
 		// Expand to a set of CPU identifiers and get the pinning map.
 		nrSockets, nrCores, nrThreads, vcpus, numaNodes, err := d.cpuTopology(cpus)
@@ -2901,8 +3189,8 @@ func (d *qemu) addCPUMemoryConfig(cfg *[]cfgSection) (int, error) {
 	}
 
 	cpuOpts.hugepages = ""
-	if shared.IsTrue(d.expandedConfig["limits.memory.hugepages"]) {
-		hugetlb, err := util.HugepagesPath()
+	if d.hugepagesEnabled() {
+		hugetlb, err := d.hugepagesPath()
 		if err != nil {
 			return -1, err
 		}
@@ -3629,48 +3917,66 @@ func (d *qemu) addGPUDevConfig(cfg *[]cfgSection, bus *qemuBus, gpuConfig []devi
 		iommuGroupPath = filepath.Join("/sys/bus/pci/devices", pciSlotName, "iommu_group", "devices")
 	}
 
-	if shared.PathExists(iommuGroupPath) {
-		// Extract parent slot name by removing any virtual function ID.
-		parts := strings.SplitN(pciSlotName, ".", 2)
-		prefix := parts[0]
+	iommuSlotNames, err := pciRelatedFunctions(iommuGroupPath, pciSlotName)
+	if err != nil {
+		return err
+	}
+
+	for _, iommuSlotName := range iommuSlotNames {
+		// Add VF device without VGA mode to qemu config.
+		devBus, devAddr, multi := bus.allocate(fmt.Sprintf("lxd_%s", devName))
+		gpuDevPhysicalOpts := qemuGPUDevPhysicalOpts{
+			dev: qemuDevOpts{
+				busName:       bus.name,
+				devBus:        devBus,
+				devAddr:       devAddr,
+				multifunction: multi,
+			},
+			// Generate associated device name by combining main device name and VF ID.
+			devName:     fmt.Sprintf("%s_%s", devName, devAddr),
+			pciSlotName: iommuSlotName,
+			vga:         false,
+			vgpu:        "",
+		}
 
-		// Iterate the members of the IOMMU group and override any that match the parent slot name prefix.
-		err := filepath.Walk(iommuGroupPath, func(path string, _ os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
+		*cfg = append(*cfg, qemuGPUDevPhysical(&gpuDevPhysicalOpts)...)
+	}
 
-			iommuSlotName := filepath.Base(path) // Virtual function's address is dir name.
-
-			// Match any VFs that are related to the GPU device (but not the GPU device itself).
-			if strings.HasPrefix(iommuSlotName, prefix) && iommuSlotName != pciSlotName {
-				// Add VF device without VGA mode to qemu config.
-				devBus, devAddr, multi := bus.allocate(fmt.Sprintf("lxd_%s", devName))
-				gpuDevPhysicalOpts := qemuGPUDevPhysicalOpts{
-					dev: qemuDevOpts{
-						busName:       bus.name,
-						devBus:        devBus,
-						devAddr:       devAddr,
-						multifunction: multi,
-					},
-					// Generate associated device name by combining main device name and VF ID.
-					devName:     fmt.Sprintf("%s_%s", devName, devAddr),
-					pciSlotName: iommuSlotName,
-					vga:         false,
-					vgpu:        "",
-				}
+	return nil
+}
 
-				*cfg = append(*cfg, qemuGPUDevPhysical(&gpuDevPhysicalOpts)...)
-			}
+// pciRelatedFunctions returns the slot names of the members of the IOMMU group that are related to the PCI device
+// (such as the audio function of a GPU), excluding the device itself.
+func pciRelatedFunctions(iommuGroupPath string, pciSlotName string) ([]string, error) {
+	if !shared.PathExists(iommuGroupPath) {
+		return nil, nil
+	}
 
-			return nil
-		})
+	// Extract parent slot name by removing any virtual function ID.
+	parts := strings.SplitN(pciSlotName, ".", 2)
+	prefix := parts[0]
+
+	// Iterate the members of the IOMMU group and return any that match the parent slot name prefix.
+	var slotNames []string
+	err := filepath.Walk(iommuGroupPath, func(path string, _ os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+
+		iommuSlotName := filepath.Base(path) // Virtual function's address is dir name.
+
+		// Match any VFs that are related to the device (but not the device itself).
+		if strings.HasPrefix(iommuSlotName, prefix) && iommuSlotName != pciSlotName {
+			slotNames = append(slotNames, iommuSlotName)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return slotNames, nil
 }
 
 func (d *qemu) addUSBDeviceConfig(usbDev deviceConfig.USBDeviceItem) (monitorHook, error) {
@@ -3802,6 +4108,10 @@ func (d *qemu) Stop(stateful bool) error {
 		return ErrInstanceIsStopped
 	}
 
+	if d.snapshotOverlayInProgress() {
+		return fmt.Errorf("The instance can't be stopped while a snapshot of it is being taken")
+	}
+
 	// Check for stateful.
 	if stateful && shared.IsFalseOrEmpty(d.expandedConfig["migration.stateful"]) {
 		return fmt.Errorf("Stateful stop requires migration.stateful to be set to true")
@@ -3854,7 +4164,7 @@ func (d *qemu) Stop(stateful bool) error {
 		go d.pidWait(10*time.Minute, op)
 
 		// Dump the state.
-		err := d.saveState(monitor)
+		err := d.saveState(monitor, false)
 		if err != nil {
 			op.Done(err)
 			return err
@@ -3910,8 +4220,34 @@ func (d *qemu) Stop(stateful bool) error {
 	return nil
 }
 
+// NetworkPause brings the host side of the instance's NICs down without removing them.
+func (d *qemu) NetworkPause() error {
+	return d.networkPauseCommon(d, true)
+}
+
+// NetworkResume brings the host side of the instance's NICs back up after NetworkPause.
+func (d *qemu) NetworkResume() error {
+	return d.networkPauseCommon(d, false)
+}
+
+// RetryDevices tries to start the devices that failed to start when the instance was started.
+func (d *qemu) RetryDevices() error {
+	if !d.IsRunning() {
+		return nil
+	}
+
+	return d.retryDevices(d.deviceLoad, func(dev device.Device) error {
+		_, err := d.deviceStart(dev, true)
+		return err
+	})
+}
+
 // Unfreeze restores the instance to running.
 func (d *qemu) Unfreeze() error {
+	if d.snapshotOverlayActive {
+		return nil
+	}
+
 	// Connect to the monitor.
 	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
 	if err != nil {
@@ -3937,6 +4273,7 @@ func (d *qemu) IsPrivileged() bool {
 func (d *qemu) Snapshot(name string, expiry time.Time, stateful bool) error {
 	var err error
 	var monitor *qmp.Monitor
+	var overlayMonitor *qmp.Monitor
 
 	// Deal with state.
 	if stateful {
@@ -3956,15 +4293,77 @@ func (d *qemu) Snapshot(name string, expiry time.Time, stateful bool) error {
 			return err
 		}
 
-		// Dump the state.
-		err = d.saveState(monitor)
+		if shared.IsTrue(d.expandedConfig["snapshots.stateful.background"]) {
+			// Pause the VM so that the memory state and the root disk overlay match the same point in time.
+			err = monitor.Pause()
+			if err != nil {
+				return err
+			}
+
+			err = d.snapshotOverlayStart(monitor)
+			if err != nil {
+				_ = monitor.Start()
+				return fmt.Errorf("Failed redirecting root disk writes to snapshot overlay: %w", err)
+			}
+
+			overlayMonitor = monitor
+
+			// Dump the state while the VM runs, QEMU resumes it once the device state has been saved.
+			// Fallback to a regular dump if QEMU or the kernel lack background snapshot support.
+			err = d.saveState(monitor, true)
+			if err != nil {
+				d.logger.Warn("Failed background dump of the instance state, retrying with the instance paused", logger.Ctx{"err": err})
+				err = d.saveState(monitor, false)
+			}
+
+			if err == nil {
+				// The root disk is unchanged since the pause, let the VM run while the snapshot is taken.
+				err = monitor.Start()
+			}
+
+			if err != nil {
+				_ = d.snapshotOverlayCommit(monitor)
+				_ = monitor.Start()
+				return err
+			}
+		} else {
+			// Dump the state.
+			err = d.saveState(monitor, false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// On pools that can't snapshot cheaply, redirect the root disk writes to a temporary overlay so the
+	// storage level copy can be taken without pausing the VM for its whole duration.
+	if !stateful && d.IsRunning() && d.snapshotOverlaySupported() {
+		overlayMonitor, err = qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
 		if err != nil {
 			return err
 		}
+
+		err = d.snapshotOverlayStart(overlayMonitor)
+		if err != nil {
+			return fmt.Errorf("Failed redirecting root disk writes to snapshot overlay: %w", err)
+		}
 	}
 
 	// Create the snapshot.
 	err = d.snapshotCommon(d, name, expiry, stateful)
+
+	// Merge the overlay back into the root disk whether or not the snapshot succeeded.
+	if overlayMonitor != nil {
+		commitErr := d.snapshotOverlayCommit(overlayMonitor)
+		if commitErr != nil {
+			d.logger.Error("Failed merging snapshot overlay into root disk", logger.Ctx{"err": commitErr})
+
+			if err == nil {
+				err = commitErr
+			}
+		}
+	}
+
 	if err != nil {
 		return err
 	}
@@ -3977,6 +4376,8 @@ func (d *qemu) Snapshot(name string, expiry time.Time, stateful bool) error {
 			return err
 		}
 
+		_ = os.Remove(d.stateCPUFlagsPath())
+
 		err = monitor.Start()
 		if err != nil {
 			return err
@@ -3986,22 +4387,274 @@ func (d *qemu) Snapshot(name string, expiry time.Time, stateful bool) error {
 	return nil
 }
 
-// Restore restores an instance snapshot.
-func (d *qemu) Restore(source instance.Instance, stateful bool) error {
-	op, err := operationlock.Create(d.Project(), d.Name(), operationlock.ActionRestore, false, false)
+// snapshotOverlayPath returns the path of the temporary overlay used when snapshotting a running VM.
+func (d *qemu) snapshotOverlayPath() string {
+	return filepath.Join(d.DevicesPath(), "snapshot.overlay.qcow2")
+}
+
+// snapshotOverlaySupported returns whether the root disk should be switched to a temporary overlay while a
+// snapshot of the running VM is taken. This is used for local pools which would otherwise need to pause the VM
+// while its disk is copied.
+func (d *qemu) snapshotOverlaySupported() bool {
+	pool, err := d.getStoragePool()
 	if err != nil {
-		return fmt.Errorf("Failed to create instance restore operation: %w", err)
+		return false
 	}
-	defer op.Done(nil)
 
-	var ctxMap logger.Ctx
+	info := pool.Driver().Info()
 
-	// Stop the instance.
-	wasRunning := false
-	if d.IsRunning() {
-		wasRunning = true
+	return !info.Remote && info.RunningCopyFreeze
+}
 
-		ephemeral := d.IsEphemeral()
+// snapshotOverlayStart adds a temporary qcow2 overlay on top of the root disk and makes it the active layer.
+// From then on all guest writes go to the overlay and the root disk itself stays unchanged.
+func (d *qemu) snapshotOverlayStart(monitor *qmp.Monitor) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	// An overlay left active by a previous LXD process holds guest writes until the instance is restarted.
+	if shared.IsTrue(d.localConfig["volatile.snapshot_overlay"]) {
+		return fmt.Errorf("A snapshot overlay is still active, the instance must be restarted to merge it")
+	}
+
+	key := project.Instance(d.project, d.name)
+
+	qemuSnapshotOverlaysMu.Lock()
+	_, found := qemuSnapshotOverlays[key]
+	if !found {
+		qemuSnapshotOverlays[key] = struct{}{}
+	}
+
+	qemuSnapshotOverlaysMu.Unlock()
+
+	if found {
+		return fmt.Errorf("A snapshot overlay is already active")
+	}
+
+	revert.Add(d.snapshotOverlayRelease)
+
+	rootDiskName, _, err := d.getRootDiskDevice()
+	if err != nil {
+		return err
+	}
+
+	nodeName := d.blockNodeName(filesystem.PathNameEncode(rootDiskName))
+
+	sizeBytes, err := monitor.GetBlockNodeSize(nodeName)
+	if err != nil {
+		return err
+	}
+
+	overlayPath := d.snapshotOverlayPath()
+	_, err = shared.RunCommand("qemu-img", "create", "-f", "qcow2", overlayPath, fmt.Sprintf("%d", sizeBytes))
+	if err != nil {
+		return fmt.Errorf("Failed creating snapshot overlay %q: %w", overlayPath, err)
+	}
+
+	revert.Add(func() { _ = os.Remove(overlayPath) })
+
+	f, err := os.OpenFile(overlayPath, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("Failed opening snapshot overlay %q: %w", overlayPath, err)
+	}
+
+	defer func() { _ = f.Close() }()
+
+	info, err := monitor.SendFileWithFDSet(qemuSnapshotOverlayNodeName, f, false)
+	if err != nil {
+		return fmt.Errorf("Failed sending file descriptor of %q: %w", overlayPath, err)
+	}
+
+	revert.Add(func() { _ = monitor.RemoveFDFromFDSet(qemuSnapshotOverlayNodeName) })
+
+	blockDev := map[string]any{
+		"driver":    "qcow2",
+		"node-name": qemuSnapshotOverlayNodeName,
+		"backing":   nil, // Attached by blockdev-snapshot.
+		"file": map[string]any{
+			"driver":   "file",
+			"filename": fmt.Sprintf("/dev/fdset/%d", info.ID),
+		},
+	}
+
+	err = monitor.AddBlockNode(blockDev)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = monitor.RemoveBlockDevice(qemuSnapshotOverlayNodeName) })
+
+	// Record that guest writes may be held in the overlay, so that they get merged into the root disk if the
+	// snapshot is interrupted.
+	err = d.VolatileSet(map[string]string{"volatile.snapshot_overlay": "true"})
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = d.VolatileSet(map[string]string{"volatile.snapshot_overlay": ""}) })
+
+	err = monitor.BlockDevSnapshot(nodeName, qemuSnapshotOverlayNodeName)
+	if err != nil {
+		return err
+	}
+
+	d.snapshotOverlayActive = true
+
+	revert.Success()
+	return nil
+}
+
+// snapshotOverlayCommit merges the writes collected in the temporary overlay back into the root disk using an
+// active block commit job, pivots the root disk back onto its original node and removes the overlay.
+// If the commit fails, the overlay is left in place and merged on next start.
+func (d *qemu) snapshotOverlayCommit(monitor *qmp.Monitor) error {
+	defer d.snapshotOverlayRelease()
+
+	jobID := fmt.Sprintf("%s_commit", qemuSnapshotOverlayNodeName)
+
+	err := monitor.BlockCommit(jobID, qemuSnapshotOverlayNodeName)
+	if err != nil {
+		return err
+	}
+
+	// Wait for the job to be ready, at which point the overlay and root disk are in sync and are kept in sync.
+	completed := false
+	for {
+		jobs, err := monitor.GetBlockJobs()
+		if err != nil {
+			return err
+		}
+
+		var job *qmp.BlockJob
+		for i := range jobs {
+			if jobs[i].Device == jobID {
+				job = &jobs[i]
+				break
+			}
+		}
+
+		if job == nil {
+			if !completed {
+				return fmt.Errorf("Block commit job %q disappeared before completion", jobID)
+			}
+
+			break // Job has finished and pivoted back to the root disk.
+		}
+
+		if job.Ready && !completed {
+			err = monitor.BlockJobComplete(jobID)
+			if err != nil {
+				return err
+			}
+
+			completed = true
+		} else if !completed && job.Len > 0 {
+			d.updateProgress(fmt.Sprintf("Merging snapshot overlay: %d%%", job.Offset*100/job.Len))
+		}
+
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	d.snapshotOverlayActive = false
+
+	// The guest writes now go to the root disk again, so the overlay mustn't be merged anymore.
+	err = d.VolatileSet(map[string]string{"volatile.snapshot_overlay": ""})
+	if err != nil {
+		return err
+	}
+
+	err = monitor.RemoveBlockDevice(qemuSnapshotOverlayNodeName)
+	if err != nil {
+		return err
+	}
+
+	err = monitor.RemoveFDFromFDSet(qemuSnapshotOverlayNodeName)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(d.snapshotOverlayPath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// snapshotOverlayRelease records that this process doesn't handle a snapshot overlay for the instance anymore.
+func (d *qemu) snapshotOverlayRelease() {
+	qemuSnapshotOverlaysMu.Lock()
+	delete(qemuSnapshotOverlays, project.Instance(d.project, d.name))
+	qemuSnapshotOverlaysMu.Unlock()
+}
+
+// snapshotOverlayInProgress returns whether this process is taking a snapshot of the instance using an overlay.
+func (d *qemu) snapshotOverlayInProgress() bool {
+	qemuSnapshotOverlaysMu.Lock()
+	defer qemuSnapshotOverlaysMu.Unlock()
+
+	_, found := qemuSnapshotOverlays[project.Instance(d.project, d.name)]
+
+	return found
+}
+
+// snapshotOverlayRecover merges the guest writes held in the overlay of an interrupted snapshot (because the VM
+// stopped or LXD restarted while it was taken) into the root disk at diskPath, and removes the overlay.
+// Must only be called while QEMU isn't running.
+func (d *qemu) snapshotOverlayRecover(diskPath string) error {
+	overlayPath := d.snapshotOverlayPath()
+
+	if shared.IsTrue(d.localConfig["volatile.snapshot_overlay"]) && shared.PathExists(overlayPath) {
+		if diskPath == "" {
+			return fmt.Errorf("No root disk path available to merge snapshot overlay %q into", overlayPath)
+		}
+
+		d.logger.Warn("Merging snapshot overlay of interrupted snapshot into root disk", logger.Ctx{"overlay": overlayPath})
+
+		// The backing file isn't recorded in the overlay as it was attached by QEMU.
+		_, err := shared.RunCommand("qemu-img", "rebase", "-u", "-f", "qcow2", "-b", diskPath, "-F", "raw", overlayPath)
+		if err != nil {
+			return fmt.Errorf("Failed setting backing file of snapshot overlay %q: %w", overlayPath, err)
+		}
+
+		_, err = shared.RunCommand("qemu-img", "commit", "-f", "qcow2", overlayPath)
+		if err != nil {
+			return fmt.Errorf("Failed merging snapshot overlay %q: %w", overlayPath, err)
+		}
+	}
+
+	// Without the volatile key, any leftover overlay was already merged.
+	err := os.Remove(overlayPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if d.localConfig["volatile.snapshot_overlay"] != "" {
+		err = d.VolatileSet(map[string]string{"volatile.snapshot_overlay": ""})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Restore restores an instance snapshot.
+func (d *qemu) Restore(source instance.Instance, stateful bool) error {
+	op, err := operationlock.Create(d.Project(), d.Name(), operationlock.ActionRestore, false, false)
+	if err != nil {
+		return fmt.Errorf("Failed to create instance restore operation: %w", err)
+	}
+	defer op.Done(nil)
+
+	var ctxMap logger.Ctx
+
+	// Stop the instance.
+	wasRunning := false
+	if d.IsRunning() {
+		wasRunning = true
+
+		ephemeral := d.IsEphemeral()
 		if ephemeral {
 			// Unset ephemeral flag.
 			args := db.InstanceArgs{
@@ -4103,6 +4756,11 @@ func (d *qemu) Restore(source instance.Instance, stateful bool) error {
 	return nil
 }
 
+// Rebuild recreates the instance's root volume from an image (or empty if img is nil).
+func (d *qemu) Rebuild(img *api.Image, op *operations.Operation) error {
+	return d.rebuildCommon(d, img, op)
+}
+
 // Rename the instance. Accepts an argument to enable applying deferred TemplateTriggerRename.
 func (d *qemu) Rename(newName string, applyTemplateTrigger bool) error {
 	oldName := d.Name()
@@ -4270,7 +4928,7 @@ func (d *qemu) Rename(newName string, applyTemplateTrigger bool) error {
 // Update the instance config.
 func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	// Setup a new operation.
-	op, err := operationlock.CreateWaitGet(d.Project(), d.Name(), operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore}, false, false)
+	op, err := operationlock.CreateWaitGet(d.Project(), d.Name(), operationlock.ActionUpdate, []operationlock.Action{operationlock.ActionRestart, operationlock.ActionRestore, operationlock.ActionRebuild}, false, false)
 	if err != nil {
 		return fmt.Errorf("Failed to create instance update operation: %w", err)
 	}
@@ -4302,8 +4960,14 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	}
 
 	if userRequested {
+		// Submit the new config to the admission hook (if any).
+		err := instance.AdmissionCheck(d.state, "update", d.dbType, d.name, &args)
+		if err != nil {
+			return err
+		}
+
 		// Validate the new config.
-		err := instance.ValidConfig(d.state.OS, args.Config, false, d.dbType)
+		err = instance.ValidConfig(d.state.OS, args.Config, false, d.dbType)
 		if err != nil {
 			return fmt.Errorf("Invalid config: %w", err)
 		}
@@ -4490,11 +5154,19 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		return err
 	}
 
+	if isRunning && !d.IsSnapshot() {
+		err = d.rootDiskResize(oldExpandedDevices)
+		if err != nil {
+			return fmt.Errorf("Failed resizing root disk of running VM: %w", err)
+		}
+	}
+
 	if isRunning {
 		// Only certain keys can be changed on a running VM.
 		liveUpdateKeys := []string{
 			"cluster.evacuate",
 			"limits.memory",
+			"limits.network.conntrack",
 			"security.agent.metrics",
 			"security.secureboot",
 		}
@@ -4553,6 +5225,11 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 						return fmt.Errorf("Failed updating memory limit: %w", err)
 					}
 				}
+			} else if key == "limits.network.conntrack" {
+				err = d.conntrackLimitApply()
+				if err != nil {
+					return err
+				}
 			} else if key == "security.secureboot" {
 				// Defer rebuilding nvram until next start.
 				d.localConfig["volatile.apply_nvram"] = "true"
@@ -4668,13 +5345,83 @@ func (d *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	return nil
 }
 
+// numaSetAffinity restricts the vCPU threads to the host CPUs of the NUMA node backing their virtual socket.
+// This is a no-op if the VM isn't placed on specific NUMA nodes or if its CPUs are pinned.
+func (d *qemu) numaSetAffinity(pids []int) error {
+	_, err := strconv.Atoi(d.expandedConfig["limits.cpu"])
+	if d.expandedConfig["limits.cpu"] != "" && err != nil {
+		return nil
+	}
+
+	numaNodes, err := instance.NUMANodes(d)
+	if err != nil {
+		return err
+	}
+
+	if len(numaNodes) == 0 {
+		return nil
+	}
+
+	coresPerNode := len(pids) / len(numaNodes)
+	if coresPerNode == 0 {
+		return fmt.Errorf("QEMU has less vCPUs than NUMA nodes")
+	}
+
+	for i, pid := range pids {
+		nodeIndex := i / coresPerNode
+		if nodeIndex >= len(numaNodes) {
+			nodeIndex = len(numaNodes) - 1
+		}
+
+		cpus, err := instance.NUMANodesCPUs([]uint64{numaNodes[nodeIndex]})
+		if err != nil {
+			return err
+		}
+
+		set := unix.CPUSet{}
+		for _, cpu := range cpus {
+			set.Set(int(cpu))
+		}
+
+		err = unix.SchedSetaffinity(pid, &set)
+		if err != nil {
+			return fmt.Errorf("Failed setting vCPU affinity: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// hugepagesEnabled returns whether the VM memory is backed by huge pages.
+func (d *qemu) hugepagesEnabled() bool {
+	for key := range instance.HugepagesPoolSizes {
+		if d.expandedConfig[key] != "" {
+			return true
+		}
+	}
+
+	return shared.IsTrue(d.expandedConfig["limits.memory.hugepages"])
+}
+
+// hugepagesPath returns the path of the hugetlbfs mount backing the VM memory.
+// When a host huge page pool is selected, a mount using its page size is required.
+func (d *qemu) hugepagesPath() (string, error) {
+	for key, pageSize := range instance.HugepagesPoolSizes {
+		if d.expandedConfig[key] != "" {
+			return util.HugepagesPathForSize(pageSize)
+		}
+	}
+
+	return util.HugepagesPath()
+}
+
 // updateMemoryLimit live updates the VM's memory limit by reszing the balloon device.
 func (d *qemu) updateMemoryLimit(newLimit string) error {
 	if newLimit == "" {
 		return nil
 	}
 
-	if shared.IsTrue(d.expandedConfig["limits.memory.hugepages"]) {
+	if d.hugepagesEnabled() {
 		return fmt.Errorf("Cannot live update memory limit when using huge pages")
 	}
 
@@ -4840,6 +5587,13 @@ func (d *qemu) updateDevices(removeDevices deviceConfig.Devices, addDevices devi
 		}
 	}
 
+	if instanceRunning {
+		err := d.networkPauseApply()
+		if err != nil {
+			return err
+		}
+	}
+
 	revert.Success()
 	return nil
 }
@@ -5540,7 +6294,11 @@ func (d *qemu) Console(protocol string) (*os.File, chan error, error) {
 }
 
 // Exec a command inside the instance.
-func (d *qemu) Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File) (instance.Cmd, error) {
+func (d *qemu) Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File, extraFiles []*os.File) (instance.Cmd, error) {
+	if len(extraFiles) > 0 {
+		return nil, fmt.Errorf("Passing additional file descriptors isn't supported for virtual machines")
+	}
+
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -5690,7 +6448,7 @@ func (d *qemu) RenderFull() (*api.InstanceFull, any, error) {
 	vmState := api.InstanceFull{Instance: *base.(*api.Instance)}
 
 	// Add the InstanceState.
-	vmState.State, err = d.renderState(vmState.StatusCode)
+	vmState.State, err = d.renderStateCached(vmState.StatusCode, d.renderState)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -5791,11 +6549,15 @@ func (d *qemu) renderState(statusCode api.StatusCode) (*api.InstanceState, error
 				}
 			}
 		}
+
+		status.Devices = d.devicesState()
+		status.Conntrack = d.conntrackState()
 	}
 
 	status.Pid = int64(pid)
 	status.Status = statusCode.String()
 	status.StatusCode = statusCode
+	status.NetworkCounters = nicstats.Get(d.project, d.name)
 	status.Disk, err = d.diskState()
 	if err != nil && !errors.Is(err, storageDrivers.ErrNotSupported) {
 		d.logger.Warn("Error getting disk usage", logger.Ctx{"err": err})
@@ -5806,7 +6568,7 @@ func (d *qemu) renderState(statusCode api.StatusCode) (*api.InstanceState, error
 
 // RenderState returns just state info about the instance.
 func (d *qemu) RenderState() (*api.InstanceState, error) {
-	return d.renderState(d.statusCode())
+	return d.renderStateCached(d.statusCode(), d.renderState)
 }
 
 // diskState gets disk usage info.
@@ -5832,6 +6594,87 @@ func (d *qemu) diskState() (map[string]api.InstanceStateDisk, error) {
 	return disk, nil
 }
 
+// rootDiskResize makes a running VM use the new size of its root disk once it has been grown by the disk
+// device update. The disk is resized in QEMU and the agent is asked to grow the root partition and filesystem.
+func (d *qemu) rootDiskResize(oldExpandedDevices deviceConfig.Devices) error {
+	rootDiskName, rootDiskConf, err := d.getRootDiskDevice()
+	if err != nil {
+		return err
+	}
+
+	oldRootDiskName, oldRootDiskConf, err := shared.GetRootDiskDevice(oldExpandedDevices.CloneNative())
+	if err != nil || oldRootDiskName != rootDiskName || oldRootDiskConf["size"] == rootDiskConf["size"] {
+		return nil
+	}
+
+	// Nothing to do if the resize has been deferred until next start.
+	if d.localConfig[fmt.Sprintf("volatile.%s.apply_quota", rootDiskName)] != "" {
+		return nil
+	}
+
+	mountInfo, err := d.mount()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = d.unmount() }()
+
+	// Use the actual size of the disk, as it may have been rounded by the storage driver.
+	var sizeBytes int64
+	if mountInfo.DiskPath != "" {
+		sizeBytes, err = storageDrivers.BlockDiskSizeBytes(mountInfo.DiskPath)
+	} else {
+		sizeBytes, err = units.ParseByteSizeString(rootDiskConf["size"])
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed getting root disk size: %w", err)
+	}
+
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	err = monitor.BlockResize(d.blockNodeName(filesystem.PathNameEncode(rootDiskName)), sizeBytes)
+	if err != nil {
+		return err
+	}
+
+	// Without the agent the guest OS only sees the bigger disk, leave the partition to it.
+	err = d.agentGrowRootDisk()
+	if err != nil {
+		d.logger.Warn("Failed growing root partition and filesystem inside VM", logger.Ctx{"err": err})
+	}
+
+	d.logger.Info("Resized root disk of running VM", logger.Ctx{"size": sizeBytes})
+
+	return nil
+}
+
+// agentGrowRootDisk connects to the agent inside of the VM and asks it to grow the root partition and
+// filesystem to fill the root disk.
+func (d *qemu) agentGrowRootDisk() error {
+	client, err := d.getAgentClient()
+	if err != nil {
+		return err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		return fmt.Errorf("Failed connecting to agent: %w", err)
+	}
+
+	defer agent.Disconnect()
+
+	_, _, err = agent.RawQuery("POST", "/1.0/disks", nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // agentGetState connects to the agent inside of the VM and does
 // an API call to get the current state.
 func (d *qemu) agentGetState() (*api.InstanceState, error) {
@@ -6223,6 +7066,114 @@ func (d *qemu) devlxdEventSend(eventType string, eventMessage map[string]any) er
 	return nil
 }
 
+// agentSecretsConnect returns a client for the agent of the VM, or nil if the VM or its agent isn't running.
+func (d *qemu) agentSecretsConnect() (lxd.InstanceServer, error) {
+	if !d.IsRunning() {
+		return nil, nil
+	}
+
+	client, err := d.getAgentClient()
+	if err != nil {
+		if err == errQemuAgentOffline {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	agent, err := lxd.ConnectLXDHTTP(nil, client)
+	if err != nil {
+		d.logger.Error("Failed to connect to lxd-agent", logger.Ctx{"err": err})
+		return nil, fmt.Errorf("Failed to connect to lxd-agent")
+	}
+
+	return agent, nil
+}
+
+// AgentSecretsDeliver hands the secrets staged for the VM over to its agent, which serves them once through
+// /dev/lxd. Secrets are removed from the database as they're handed over. Nothing is done if the agent isn't
+// running, the secrets are then delivered once it starts.
+func (d *qemu) AgentSecretsDeliver() error {
+	qemuAgentSecretsLock.Lock()
+	defer qemuAgentSecretsLock.Unlock()
+
+	secrets, err := d.state.DB.Cluster.GetInstanceSecrets(d.id)
+	if err != nil {
+		return err
+	}
+
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	agent, err := d.agentSecretsConnect()
+	if err != nil || agent == nil {
+		return err
+	}
+
+	defer agent.Disconnect()
+
+	for name, value := range secrets {
+		_, _, err = agent.RawQuery("POST", "/1.0/secrets", api.InstanceSecretsPost{Name: name, Value: value}, "")
+		if err != nil {
+			return fmt.Errorf("Failed sending secret %q to lxd-agent: %w", name, err)
+		}
+
+		err = d.state.DB.Cluster.DeleteDeliveredInstanceSecret(d.id, name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	d.logger.Debug("Delivered secrets to lxd-agent", logger.Ctx{"count": len(secrets)})
+
+	return nil
+}
+
+// AgentSecretNames returns the names of the secrets held by the agent and not yet retrieved.
+func (d *qemu) AgentSecretNames() ([]string, error) {
+	agent, err := d.agentSecretsConnect()
+	if err != nil || agent == nil {
+		return nil, err
+	}
+
+	defer agent.Disconnect()
+
+	resp, _, err := agent.RawQuery("GET", "/1.0/secrets", nil, "")
+	if err != nil {
+		return nil, fmt.Errorf("Failed listing lxd-agent secrets: %w", err)
+	}
+
+	names := []string{}
+	err = resp.MetadataAsStruct(&names)
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// AgentSecretDelete deletes the secret held by the agent, returning whether it was found.
+func (d *qemu) AgentSecretDelete(name string) (bool, error) {
+	agent, err := d.agentSecretsConnect()
+	if err != nil || agent == nil {
+		return false, err
+	}
+
+	defer agent.Disconnect()
+
+	_, _, err = agent.RawQuery("DELETE", api.NewURL().Path("1.0", "secrets", name).String(), nil, "")
+	if err != nil {
+		if api.StatusErrorCheck(err, http.StatusNotFound) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("Failed deleting lxd-agent secret: %w", err)
+	}
+
+	return true, nil
+}
+
 func (d *qemu) writeInstanceData() error {
 	// Only write instance-data file if security.devlxd is true.
 	if !(d.expandedConfig["security.devlxd"] == "" || shared.IsTrue(d.expandedConfig["security.devlxd"])) {
@@ -6517,6 +7468,190 @@ func (d *qemu) deviceDetachUSB(usbDev deviceConfig.USBDeviceItem) error {
 	return nil
 }
 
+// deviceAttachPCI hotplugs a physical PCI device into a free PCIe port of a running instance. Any related
+// functions in the device's IOMMU group (such as the audio function of a GPU) are added into the same port.
+func (d *qemu) deviceAttachPCI(deviceName string, pciConfig []deviceConfig.RunConfigItem) error {
+	var pciSlotName string
+	for _, pciItem := range pciConfig {
+		if pciItem.Key == "pciSlotName" {
+			pciSlotName = pciItem.Value
+		}
+	}
+
+	if pciSlotName == "" {
+		return fmt.Errorf("Device didn't provide a PCI slot name to use")
+	}
+
+	_, qemuBus, err := d.qemuArchConfig(d.architecture)
+	if err != nil {
+		return err
+	}
+
+	if qemuBus != "pcie" {
+		return fmt.Errorf("PCI devices can only be hotplugged on instances using a PCIe bus")
+	}
+
+	iommuSlotNames, err := pciRelatedFunctions(filepath.Join("/sys/bus/pci/devices", pciSlotName, "iommu_group", "devices"), pciSlotName)
+	if err != nil {
+		return err
+	}
+
+	if len(iommuSlotNames) > 7 {
+		return fmt.Errorf("Device has too many related functions to be hotplugged")
+	}
+
+	// Check if the agent is running.
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	// Find a free PCIe port. The last one is used so that the earlier ports remain available for the NICs
+	// which expect to be hotplugged into the port they would have used at boot time.
+	pciDevs, err := monitor.QueryPCI()
+	if err != nil {
+		return err
+	}
+
+	portName := ""
+	for _, pciDev := range pciDevs {
+		if strings.HasPrefix(pciDev.DevID, busDevicePortPrefix) && len(pciDev.Bridge.Devices) == 0 {
+			portName = pciDev.DevID
+		}
+	}
+
+	if portName == "" {
+		return fmt.Errorf("No free PCIe port available to hotplug the device into")
+	}
+
+	d.logger.Debug("Using PCIe port to hotplug PCI device into", logger.Ctx{"device": deviceName, "port": portName})
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// The other functions of a multi-function device must be added before function 0, as adding function 0
+	// is what makes the guest see the device.
+	deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, deviceName)
+	for i, iommuSlotName := range iommuSlotNames {
+		devAddr := fmt.Sprintf("00.%d", i+1)
+		relatedDeviceID := fmt.Sprintf("%s_%s", deviceID, devAddr)
+
+		err = monitor.AddDevice(map[string]string{
+			"id":     relatedDeviceID,
+			"driver": "vfio-pci",
+			"bus":    portName,
+			"addr":   devAddr,
+			"host":   iommuSlotName,
+		})
+		if err != nil {
+			return fmt.Errorf("Failed adding related PCI function %q: %w", iommuSlotName, err)
+		}
+
+		revert.Add(func() { _ = monitor.RemoveDevice(relatedDeviceID) })
+	}
+
+	qemuDev := map[string]string{
+		"id":     deviceID,
+		"driver": "vfio-pci",
+		"bus":    portName,
+		"addr":   "00.0",
+		"host":   pciSlotName,
+	}
+
+	if len(iommuSlotNames) > 0 {
+		qemuDev["multifunction"] = "on"
+	}
+
+	err = monitor.AddDevice(qemuDev)
+	if err != nil {
+		return fmt.Errorf("Failed adding PCI device: %w", err)
+	}
+
+	revert.Add(func() { _ = monitor.RemoveDevice(deviceID) })
+
+	err = d.VolatileSet(map[string]string{fmt.Sprintf("volatile.%s.last_state.pci.port", deviceName): portName})
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// deviceDetachPCI removes a physical PCI device (and any related functions) from a running instance and waits for
+// the guest to release it, so that it is safe to give the device back to the host afterwards.
+func (d *qemu) deviceDetachPCI(deviceName string) error {
+	_, qemuBus, err := d.qemuArchConfig(d.architecture)
+	if err != nil {
+		return err
+	}
+
+	if qemuBus != "pcie" {
+		return fmt.Errorf("PCI devices can only be hot removed on instances using a PCIe bus")
+	}
+
+	// Check if the agent is running.
+	monitor, err := qmp.Connect(d.monitorPath(), qemuSerialChardevName, d.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	deviceID := fmt.Sprintf("%s%s", qemuDeviceIDPrefix, deviceName)
+
+	// devicesAttached returns the IDs of the device's functions still attached to a PCIe port.
+	devicesAttached := func() ([]string, error) {
+		pciDevs, err := monitor.QueryPCI()
+		if err != nil {
+			return nil, err
+		}
+
+		var deviceIDs []string
+		for _, pciDev := range pciDevs {
+			for _, bridgeDev := range pciDev.Bridge.Devices {
+				if bridgeDev.DevID == deviceID || strings.HasPrefix(bridgeDev.DevID, fmt.Sprintf("%s_", deviceID)) {
+					deviceIDs = append(deviceIDs, bridgeDev.DevID)
+				}
+			}
+		}
+
+		return deviceIDs, nil
+	}
+
+	// Removing function 0 unplugs the whole slot, including any related functions.
+	err = monitor.RemoveDevice(deviceID)
+	if err != nil {
+		return fmt.Errorf("Failed removing PCI device: %w", err)
+	}
+
+	// Wait until the guest has released the device (or we timeout waiting).
+	waitDuration := time.Duration(time.Second * time.Duration(10))
+	waitUntil := time.Now().Add(waitDuration)
+	for {
+		deviceIDs, err := devicesAttached()
+		if err != nil {
+			return fmt.Errorf("Failed getting PCI devices to check for PCI device detach: %w", err)
+		}
+
+		if len(deviceIDs) == 0 {
+			break
+		}
+
+		if time.Now().After(waitUntil) {
+			return fmt.Errorf("Failed to detach PCI device after %v", waitDuration)
+		}
+
+		d.logger.Debug("Waiting for PCI device to be detached", logger.Ctx{"device": deviceName})
+		time.Sleep(time.Second * time.Duration(2))
+	}
+
+	err = d.VolatileSet(map[string]string{fmt.Sprintf("volatile.%s.last_state.pci.port", deviceName): ""})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // Block node names may only be up to 31 characters long, so use a hash if longer.
 func (d *qemu) blockNodeName(name string) string {
 	if len(name) > 27 {
@@ -162,6 +162,36 @@ func (m *Monitor) Migrate(uri string) error {
 	}
 
 	// Wait until it completes or fails.
+	return m.migrateWait()
+}
+
+// MigrateBackground starts a migration to the provided target while letting the VM run.
+// The migration stream holds the state of the VM at the time the migration started, guest memory writes are
+// tracked by QEMU (background-snapshot capability) so that the original pages get written first.
+func (m *Monitor) MigrateBackground(uri string) error {
+	err := m.MigrateSetCapabilities(map[string]bool{"background-snapshot": true})
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = m.MigrateSetCapabilities(map[string]bool{"background-snapshot": false}) }()
+
+	return m.Migrate(uri)
+}
+
+// MigrateSetCapabilities sets the migration capabilities.
+func (m *Monitor) MigrateSetCapabilities(capabilities map[string]bool) error {
+	caps := []map[string]any{}
+	for capability, state := range capabilities {
+		caps = append(caps, map[string]any{"capability": capability, "state": state})
+	}
+
+	args := map[string]any{"capabilities": caps}
+	return m.run("migrate-set-capabilities", args, nil)
+}
+
+// migrateWait waits until the current migration completes or fails.
+func (m *Monitor) migrateWait() error {
 	for {
 		time.Sleep(1 * time.Second)
 
@@ -560,3 +590,125 @@ func (m *Monitor) AddSecret(id string, secret string) error {
 
 	return nil
 }
+
+// AddBlockNode adds a block node without attaching it to a device.
+func (m *Monitor) AddBlockNode(blockDev map[string]any) error {
+	err := m.run("blockdev-add", blockDev, nil)
+	if err != nil {
+		return fmt.Errorf("Failed adding block node: %w", err)
+	}
+
+	return nil
+}
+
+// GetBlockNodeSize returns the virtual size in bytes of the block node with the given node name.
+func (m *Monitor) GetBlockNodeSize(nodeName string) (int64, error) {
+	// Prepare the response.
+	var resp struct {
+		Return []struct {
+			NodeName string `json:"node-name"`
+			Image    struct {
+				VirtualSize int64 `json:"virtual-size"`
+			} `json:"image"`
+		} `json:"return"`
+	}
+
+	err := m.run("query-named-block-nodes", map[string]bool{"flat": true}, &resp)
+	if err != nil {
+		return -1, fmt.Errorf("Failed querying block nodes: %w", err)
+	}
+
+	for _, node := range resp.Return {
+		if node.NodeName == nodeName {
+			return node.Image.VirtualSize, nil
+		}
+	}
+
+	return -1, fmt.Errorf("Block node %q not found", nodeName)
+}
+
+// BlockResize resizes the block node with the given node name to the given size in bytes.
+func (m *Monitor) BlockResize(nodeName string, sizeBytes int64) error {
+	args := map[string]any{
+		"node-name": nodeName,
+		"size":      sizeBytes,
+	}
+
+	err := m.run("block_resize", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed resizing block node: %w", err)
+	}
+
+	return nil
+}
+
+// BlockDevSnapshot makes the overlay block node the new active layer on top of the given block node.
+// The overlay must already have been added and must not have a backing file attached.
+func (m *Monitor) BlockDevSnapshot(nodeName string, overlayNodeName string) error {
+	args := map[string]string{
+		"node":    nodeName,
+		"overlay": overlayNodeName,
+	}
+
+	err := m.run("blockdev-snapshot", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed creating block device snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// BlockJob represents a running block job.
+type BlockJob struct {
+	Device string `json:"device"`
+	Type   string `json:"type"`
+	Len    int64  `json:"len"`
+	Offset int64  `json:"offset"`
+	Ready  bool   `json:"ready"`
+	Status string `json:"status"`
+}
+
+// GetBlockJobs returns the list of running block jobs.
+func (m *Monitor) GetBlockJobs() ([]BlockJob, error) {
+	// Prepare the response.
+	var resp struct {
+		Return []BlockJob `json:"return"`
+	}
+
+	err := m.run("query-block-jobs", nil, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("Failed querying block jobs: %w", err)
+	}
+
+	return resp.Return, nil
+}
+
+// BlockCommit starts a block job with the given ID which merges the active layer of the given block node into its
+// backing node. Once the job is ready it must be completed with BlockJobComplete.
+func (m *Monitor) BlockCommit(jobID string, nodeName string) error {
+	args := map[string]string{
+		"job-id": jobID,
+		"device": nodeName,
+	}
+
+	err := m.run("block-commit", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed starting block commit: %w", err)
+	}
+
+	return nil
+}
+
+// BlockJobComplete completes a block job that has reached the ready state.
+func (m *Monitor) BlockJobComplete(jobID string) error {
+	args := map[string]string{
+		"device": jobID,
+	}
+
+	err := m.run("block-job-complete", args, nil)
+	if err != nil {
+		return fmt.Errorf("Failed completing block job: %w", err)
+	}
+
+	return nil
+}
@@ -19,6 +19,9 @@ var monitorsLock sync.Mutex
 // RingbufSize is the size of the agent serial ringbuffer in bytes
 var RingbufSize = 16
 
+// EventAgentStarted is the event passed to the event handler when the agent is detected as started.
+const EventAgentStarted = "LXD-AGENT-STARTED"
+
 // Monitor represents a QMP monitor.
 type Monitor struct {
 	path string
@@ -60,6 +63,10 @@ func (m *Monitor) start() error {
 
 			m.agentReadyMu.Lock()
 			if status == "STARTED" {
+				if !m.agentReady && m.eventHandler != nil {
+					go m.eventHandler(EventAgentStarted, nil)
+				}
+
 				m.agentReady = true
 			} else if status == "STOPPED" {
 				m.agentReady = false
@@ -0,0 +1,90 @@
+package drivers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// stateCacheTTL is how long a rendered instance state is kept around.
+// This avoids re-computing the full state (disk usage, network counters, ...) when the state is polled repeatedly
+// by monitoring systems. Entries are dropped early through InstanceStateCacheInvalidate.
+const stateCacheTTL = 5 * time.Second
+
+type stateCacheEntry struct {
+	state      *api.InstanceState
+	statusCode api.StatusCode
+	expiry     time.Time
+}
+
+var stateCache = map[string]stateCacheEntry{}
+var stateCacheLock sync.Mutex
+
+// stateCacheKey returns the cache key of an instance.
+func stateCacheKey(projectName string, instanceName string) string {
+	return fmt.Sprintf("%s/%s", projectName, instanceName)
+}
+
+// InstanceStateCacheInvalidate drops the cached state of an instance.
+func InstanceStateCacheInvalidate(projectName string, instanceName string) {
+	stateCacheLock.Lock()
+	defer stateCacheLock.Unlock()
+
+	delete(stateCache, stateCacheKey(projectName, instanceName))
+}
+
+// renderStateCached returns the cached state of the instance if still valid, otherwise renders it using the
+// provided function and caches the result. A cached state is only used if the instance status hasn't changed.
+func (d *common) renderStateCached(statusCode api.StatusCode, renderState func(statusCode api.StatusCode) (*api.InstanceState, error)) (*api.InstanceState, error) {
+	key := stateCacheKey(d.project, d.name)
+
+	stateCacheLock.Lock()
+	entry, ok := stateCache[key]
+	stateCacheLock.Unlock()
+
+	if ok && entry.statusCode == statusCode && entry.expiry.After(time.Now()) {
+		return stateCopy(entry.state)
+	}
+
+	state, err := renderState(statusCode)
+	if err != nil {
+		return nil, err
+	}
+
+	stateCacheLock.Lock()
+	stateCache[key] = stateCacheEntry{
+		state:      state,
+		statusCode: statusCode,
+		expiry:     time.Now().Add(stateCacheTTL),
+	}
+
+	// Drop any expired entries so that deleted instances don't linger.
+	for k, v := range stateCache {
+		if v.expiry.Before(time.Now()) {
+			delete(stateCache, k)
+		}
+	}
+
+	stateCacheLock.Unlock()
+
+	return stateCopy(state)
+}
+
+// stateCopy returns a deep copy of the instance state so that callers can't modify the cached one.
+func stateCopy(state *api.InstanceState) (*api.InstanceState, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := &api.InstanceState{}
+	err = json.Unmarshal(data, copied)
+	if err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
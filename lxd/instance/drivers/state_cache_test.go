@@ -0,0 +1,59 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+func TestRenderStateCached(t *testing.T) {
+	d := &common{project: "default", name: "c1"}
+	defer InstanceStateCacheInvalidate(d.project, d.name)
+
+	calls := 0
+	render := func(statusCode api.StatusCode) (*api.InstanceState, error) {
+		calls++
+		return &api.InstanceState{StatusCode: statusCode}, nil
+	}
+
+	// Second call with the same status is served from the cache.
+	for i := 0; i < 2; i++ {
+		state, err := d.renderStateCached(api.Running, render)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if state.Pid != 0 {
+			t.Fatalf("Cached state was modified by a caller")
+		}
+
+		// Modifying the returned state mustn't affect the cached one.
+		state.Pid = 1234
+	}
+
+	if calls != 1 {
+		t.Fatalf("Expected 1 render, got %d", calls)
+	}
+
+	// A status change isn't served from the cache.
+	state, err := d.renderStateCached(api.Stopped, render)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 || state.StatusCode != api.Stopped {
+		t.Fatalf("Expected a new render for a changed status")
+	}
+
+	// Invalidation drops the cached state.
+	InstanceStateCacheInvalidate(d.project, d.name)
+
+	_, err = d.renderStateCached(api.Stopped, render)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("Expected a new render after invalidation")
+	}
+}
@@ -0,0 +1,111 @@
+package instance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	clusterConfig "github.com/lxc/lxd/lxd/cluster/config"
+	"github.com/lxc/lxd/lxd/db"
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// admissionTimeout is how long to wait for the admission hook to reply.
+const admissionTimeout = 10 * time.Second
+
+// AdmissionCheck submits the proposed configuration of an instance to the admission hook configured in
+// "instances.admission_url" (if any) before it is committed. The hook can reject the configuration, or replace
+// the instance's own config and devices, in which case args is updated accordingly. Volatile keys can't be changed
+// by the hook. If the hook can't be reached, the configuration is rejected.
+func AdmissionCheck(s *state.State, operation string, instanceType instancetype.Type, name string, args *db.InstanceArgs) error {
+	hookURL, err := clusterConfig.GetString(s.DB.Cluster, "instances.admission_url")
+	if err != nil {
+		return fmt.Errorf(`Failed getting "instances.admission_url" config: %w`, err)
+	}
+
+	if hookURL == "" {
+		return nil
+	}
+
+	profiles, err := s.DB.Cluster.GetProfiles(args.Project, args.Profiles)
+	if err != nil {
+		return fmt.Errorf("Failed loading profiles: %w", err)
+	}
+
+	req := api.InstanceAdmissionRequest{
+		Operation:       operation,
+		Project:         args.Project,
+		Name:            name,
+		Type:            instanceType.String(),
+		Profiles:        args.Profiles,
+		Config:          args.Config,
+		Devices:         args.Devices.CloneNative(),
+		ExpandedConfig:  db.ExpandInstanceConfig(args.Config, profiles),
+		ExpandedDevices: db.ExpandInstanceDevices(args.Devices, profiles).CloneNative(),
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{Proxy: s.Proxy},
+		Timeout:   admissionTimeout,
+	}
+
+	resp, err := client.Post(hookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("Failed contacting instance admission hook: %w", err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Instance admission hook returned unexpected status %q", resp.Status)
+	}
+
+	decision := api.InstanceAdmissionResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&decision)
+	if err != nil {
+		return fmt.Errorf("Failed parsing instance admission hook response: %w", err)
+	}
+
+	if !decision.Allowed {
+		if decision.Reason == "" {
+			decision.Reason = "No reason given"
+		}
+
+		return api.StatusErrorf(http.StatusForbidden, "Instance %q rejected by admission hook: %s", name, decision.Reason)
+	}
+
+	if decision.Config != nil {
+		config := make(map[string]string, len(decision.Config))
+		for k, v := range decision.Config {
+			if !strings.HasPrefix(k, shared.ConfigVolatilePrefix) {
+				config[k] = v
+			}
+		}
+
+		for k, v := range args.Config {
+			if strings.HasPrefix(k, shared.ConfigVolatilePrefix) {
+				config[k] = v
+			}
+		}
+
+		args.Config = config
+	}
+
+	if decision.Devices != nil {
+		args.Devices = deviceConfig.NewDevices(decision.Devices)
+	}
+
+	return nil
+}
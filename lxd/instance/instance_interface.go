@@ -70,6 +70,9 @@ type Instance interface {
 	Stop(stateful bool) error
 	Restart(timeout time.Duration) error
 	Unfreeze() error
+	NetworkPause() error
+	NetworkResume() error
+	RetryDevices() error
 	RegisterDevices()
 	SaveConfigFile() error
 
@@ -78,6 +81,7 @@ type Instance interface {
 
 	// Snapshots & migration & backups.
 	Restore(source Instance, stateful bool) error
+	Rebuild(img *api.Image, op *operations.Operation) error
 	Snapshot(name string, expiry time.Time, stateful bool) error
 	Snapshots() ([]Instance, error)
 	Backups() ([]backup.InstanceBackup, error)
@@ -100,7 +104,7 @@ type Instance interface {
 
 	// Console - Allocate and run a console tty or a spice Unix socket.
 	Console(protocol string) (*os.File, chan error, error)
-	Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File) (Cmd, error)
+	Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File, extraFiles []*os.File) (Cmd, error)
 
 	// Status
 	Render(options ...func(response any) error) (any, any, error)
@@ -171,6 +175,15 @@ type Container interface {
 	IdmappedStorage(path string) idmap.IdmapStorageType
 }
 
+// VM interface is for VM specific functions.
+type VM interface {
+	Instance
+
+	AgentSecretsDeliver() error
+	AgentSecretNames() ([]string, error)
+	AgentSecretDelete(name string) (bool, error)
+}
+
 // CriuMigrationArgs arguments for CRIU migration.
 type CriuMigrationArgs struct {
 	Cmd          uint
@@ -9,8 +9,10 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/flosch/pongo2"
@@ -25,6 +27,7 @@ import (
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/instance/operationlock"
 	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/seccomp"
 	"github.com/lxc/lxd/lxd/state"
@@ -34,6 +37,7 @@ import (
 	"github.com/lxc/lxd/shared/idmap"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/osarch"
+	"github.com/lxc/lxd/shared/units"
 	"github.com/lxc/lxd/shared/validate"
 	"github.com/lxc/lxd/shared/version"
 )
@@ -633,7 +637,7 @@ func BackupLoadByName(s *state.State, project, name string) (*backup.InstanceBac
 		return nil, fmt.Errorf("Load instance from database: %w", err)
 	}
 
-	return backup.NewInstanceBackup(s, instance, args.ID, name, args.CreationDate, args.ExpiryDate, args.InstanceOnly, args.OptimizedStorage), nil
+	return backup.NewInstanceBackup(s, instance, args.ID, name, args.CreationDate, args.ExpiryDate, args.InstanceOnly, args.OptimizedStorage, args.ObjectKey), nil
 }
 
 // ResolveImage takes an instance source and returns a hash suitable for instance creation or download.
@@ -647,7 +651,7 @@ func ResolveImage(s *state.State, project string, source api.InstanceSource) (st
 			return source.Alias, nil
 		}
 
-		_, alias, err := s.DB.Cluster.GetImageAlias(project, source.Alias, true)
+		_, alias, err := s.DB.Cluster.ResolveImageAlias(project, source.Alias, true)
 		if err != nil {
 			return "", err
 		}
@@ -930,6 +934,14 @@ func CreateInternal(s *state.State, args db.InstanceArgs, clearLogDir bool, reve
 		}
 	}
 
+	// Submit the proposed config to the admission hook (if any).
+	if !args.Snapshot {
+		err = AdmissionCheck(s, "create", args.Type, args.Name, &args)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Validate instance config.
 	err = ValidConfig(s.OS, args.Config, false, args.Type)
 	if err != nil {
@@ -1248,3 +1260,186 @@ func RuntimeLiblxcVersionAtLeast(version string, major int, minor int, micro int
 
 	return true
 }
+
+// HugepagesPoolSizes maps the configuration keys reserving memory from the host huge page pools to the size in
+// bytes of the huge pages of each pool.
+var HugepagesPoolSizes = map[string]uint64{
+	"limits.memory.hugepages.2MB": 2 * 1024 * 1024,
+	"limits.memory.hugepages.1GB": 1024 * 1024 * 1024,
+}
+
+// HugepagesReserved returns the amount of memory in bytes the instance reserves from the host huge page pools,
+// keyed by huge page size.
+func HugepagesReserved(inst Instance) (map[uint64]uint64, error) {
+	reserved := map[uint64]uint64{}
+
+	for key, pageSize := range HugepagesPoolSizes {
+		value := inst.ExpandedConfig()[key]
+		if value == "" {
+			continue
+		}
+
+		amount, err := units.ParseByteSizeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %q: %w", key, err)
+		}
+
+		if amount > 0 {
+			reserved[pageSize] = uint64(amount)
+		}
+	}
+
+	return reserved, nil
+}
+
+// hugepagesLock serializes the huge page reservation checks so that starting instances can't over-commit the pools.
+var hugepagesLock sync.Mutex
+
+// HugepagesReservedAll returns the amount of memory in bytes reserved from the host huge page pools by the running
+// and starting instances on this member, keyed by huge page size. The instance with the skipID ID isn't accounted
+// for.
+func HugepagesReservedAll(s *state.State, skipID int) (map[uint64]uint64, error) {
+	insts, err := LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading instances: %w", err)
+	}
+
+	reservedAll := map[uint64]uint64{}
+	for _, inst := range insts {
+		if inst.ID() == skipID {
+			continue
+		}
+
+		// Account for running instances as well as the ones being started.
+		if !inst.IsRunning() {
+			op := operationlock.Get(inst.Project(), inst.Name())
+			if op == nil || !op.ActionMatch(operationlock.ActionStart, operationlock.ActionRestart, operationlock.ActionRestore) {
+				continue
+			}
+		}
+
+		reserved, err := HugepagesReserved(inst)
+		if err != nil {
+			return nil, err
+		}
+
+		for pageSize, amount := range reserved {
+			reservedAll[pageSize] += amount
+		}
+	}
+
+	return reservedAll, nil
+}
+
+// HugepagesCheck checks that the host huge page pools have enough room left for the reservations of the instance.
+func HugepagesCheck(s *state.State, inst Instance) error {
+	reserved, err := HugepagesReserved(inst)
+	if err != nil {
+		return err
+	}
+
+	if len(reserved) == 0 {
+		return nil
+	}
+
+	hugepagesLock.Lock()
+	defer hugepagesLock.Unlock()
+
+	pools, err := resources.GetHugepagesPools()
+	if err != nil {
+		return err
+	}
+
+	reservedAll, err := HugepagesReservedAll(s, inst.ID())
+	if err != nil {
+		return err
+	}
+
+	for pageSize, amount := range reserved {
+		var pool *api.ResourcesMemoryHugepages
+		for i := range pools {
+			if pools[i].Size == pageSize {
+				pool = &pools[i]
+				break
+			}
+		}
+
+		pageSizeStr := units.GetByteSizeStringIEC(int64(pageSize), 0)
+		if pool == nil {
+			return fmt.Errorf("The host doesn't support %s huge pages", pageSizeStr)
+		}
+
+		available := uint64(0)
+		if pool.Total > reservedAll[pageSize] {
+			available = pool.Total - reservedAll[pageSize]
+		}
+
+		if amount > available {
+			return fmt.Errorf("Not enough memory left in the host %s huge page pool (requested %s, available %s)", pageSizeStr, units.GetByteSizeStringIEC(int64(amount), 2), units.GetByteSizeStringIEC(int64(available), 2))
+		}
+	}
+
+	return nil
+}
+
+// VolatileStaleKeys returns the volatile keys of the instance that are no longer relevant, along with the reason
+// they are considered stale. These are the device keys left behind by devices that have since been removed from the
+// instance and the host interface names recorded for a stopped instance whose interface doesn't exist anymore.
+func VolatileStaleKeys(inst Instance) map[string]string {
+	stale := map[string]string{}
+
+	// Sort the device names longest first so that a device name can't be mistaken for the prefix of another.
+	devNames := make([]string, 0, len(inst.ExpandedDevices()))
+	for devName := range inst.ExpandedDevices() {
+		devNames = append(devNames, devName)
+	}
+
+	sort.Slice(devNames, func(i, j int) bool { return len(devNames[i]) > len(devNames[j]) })
+
+	for key, value := range inst.LocalConfig() {
+		if !strings.HasPrefix(key, shared.ConfigVolatilePrefix) {
+			continue
+		}
+
+		// Skip the instance wide volatile keys.
+		_, found := shared.InstanceConfigKeysAny[key]
+		if found {
+			continue
+		}
+
+		_, found = shared.InstanceConfigKeysContainer[key]
+		if found && inst.Type() == instancetype.Container {
+			continue
+		}
+
+		_, found = shared.InstanceConfigKeysVM[key]
+		if found && inst.Type() == instancetype.VM {
+			continue
+		}
+
+		devName := ""
+		for _, name := range devNames {
+			if strings.HasPrefix(key, fmt.Sprintf("%s%s.", shared.ConfigVolatilePrefix, name)) {
+				devName = name
+				break
+			}
+		}
+
+		if devName == "" {
+			stale[key] = "Device doesn't exist"
+			continue
+		}
+
+		// A running instance's interfaces may have been moved into its network namespace.
+		if strings.HasSuffix(key, ".host_name") && !inst.IsRunning() && !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", value)) {
+			stale[key] = "Host interface doesn't exist"
+		}
+
+		// Device start errors are only meaningful while the instance is running.
+		if strings.HasSuffix(key, ".start_error") && !inst.IsRunning() {
+			stale[key] = "Instance isn't running"
+		}
+	}
+
+	return stale
+}
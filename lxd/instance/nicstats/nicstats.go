@@ -0,0 +1,194 @@
+package nicstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// Interval is the interval at which the traffic counters of the instance NICs are sampled.
+const Interval time.Duration = time.Minute
+
+// MaxSamples is the number of samples kept for each NIC.
+const MaxSamples = 60
+
+// nicHistory holds the sampled traffic counters of an instance NIC.
+type nicHistory struct {
+	hostName string
+	last     api.InstanceStateNetworkCounters // Raw counters at the previous sample.
+	total    api.InstanceStateNetworkCounters
+	samples  []api.InstanceStateNetworkCountersSample
+}
+
+var historiesLock sync.Mutex
+var histories = make(map[string]map[string]*nicHistory)
+
+// counterDelta returns the difference between two counter values.
+// If the counter went backwards the interface was re-created, so its whole current value is new traffic.
+func counterDelta(last int64, current int64) int64 {
+	if current < last {
+		return current
+	}
+
+	return current - last
+}
+
+// Record records the current traffic counters of an instance NIC as read from its host side interface.
+// A sample with the traffic since the previous call is added, except on the first call for the NIC which only
+// records the starting point.
+func Record(projectName string, instanceName string, deviceName string, hostName string, counters api.InstanceStateNetworkCounters, timestamp time.Time) {
+	historiesLock.Lock()
+	defer historiesLock.Unlock()
+
+	instanceKey := project.Instance(projectName, instanceName)
+	nics, ok := histories[instanceKey]
+	if !ok {
+		nics = make(map[string]*nicHistory)
+		histories[instanceKey] = nics
+	}
+
+	h, ok := nics[deviceName]
+	if !ok {
+		nics[deviceName] = &nicHistory{hostName: hostName, last: counters}
+		return
+	}
+
+	// A new host interface starts counting from zero.
+	if h.hostName != hostName {
+		h.hostName = hostName
+		h.last = api.InstanceStateNetworkCounters{}
+	}
+
+	delta := api.InstanceStateNetworkCounters{
+		BytesReceived:          counterDelta(h.last.BytesReceived, counters.BytesReceived),
+		BytesSent:              counterDelta(h.last.BytesSent, counters.BytesSent),
+		PacketsReceived:        counterDelta(h.last.PacketsReceived, counters.PacketsReceived),
+		PacketsSent:            counterDelta(h.last.PacketsSent, counters.PacketsSent),
+		ErrorsReceived:         counterDelta(h.last.ErrorsReceived, counters.ErrorsReceived),
+		ErrorsSent:             counterDelta(h.last.ErrorsSent, counters.ErrorsSent),
+		PacketsDroppedOutbound: counterDelta(h.last.PacketsDroppedOutbound, counters.PacketsDroppedOutbound),
+		PacketsDroppedInbound:  counterDelta(h.last.PacketsDroppedInbound, counters.PacketsDroppedInbound),
+	}
+
+	h.total.BytesReceived += delta.BytesReceived
+	h.total.BytesSent += delta.BytesSent
+	h.total.PacketsReceived += delta.PacketsReceived
+	h.total.PacketsSent += delta.PacketsSent
+	h.total.ErrorsReceived += delta.ErrorsReceived
+	h.total.ErrorsSent += delta.ErrorsSent
+	h.total.PacketsDroppedOutbound += delta.PacketsDroppedOutbound
+	h.total.PacketsDroppedInbound += delta.PacketsDroppedInbound
+
+	h.samples = append(h.samples, api.InstanceStateNetworkCountersSample{Timestamp: timestamp, Counters: delta})
+	if len(h.samples) > MaxSamples {
+		h.samples = h.samples[len(h.samples)-MaxSamples:]
+	}
+
+	h.last = counters
+}
+
+// Get returns the sampled traffic counters of the NICs of an instance, indexed by device name.
+func Get(projectName string, instanceName string) map[string]api.InstanceStateNetworkCountersHistory {
+	historiesLock.Lock()
+	defer historiesLock.Unlock()
+
+	result := make(map[string]api.InstanceStateNetworkCountersHistory)
+	for deviceName, h := range histories[project.Instance(projectName, instanceName)] {
+		samples := make([]api.InstanceStateNetworkCountersSample, len(h.samples))
+		copy(samples, h.samples)
+
+		result[deviceName] = api.InstanceStateNetworkCountersHistory{
+			HostName: h.hostName,
+			Interval: int64(Interval / time.Second),
+			Total:    h.total,
+			Samples:  samples,
+		}
+	}
+
+	return result
+}
+
+// Prune removes the NICs of the instances for which keep returns false (for example because they were deleted or
+// moved to another cluster member).
+func Prune(keep func(projectName string, instanceName string) bool) {
+	historiesLock.Lock()
+	defer historiesLock.Unlock()
+
+	for instanceKey := range histories {
+		if !keep(project.InstanceParts(instanceKey)) {
+			delete(histories, instanceKey)
+		}
+	}
+}
+
+// savedHistory is the representation of the sampled traffic counters of a NIC written by Save.
+type savedHistory struct {
+	HostName string                                   `json:"host_name"`
+	Last     api.InstanceStateNetworkCounters         `json:"last"`
+	Total    api.InstanceStateNetworkCounters         `json:"total"`
+	Samples  []api.InstanceStateNetworkCountersSample `json:"samples"`
+}
+
+// Save writes the sampled traffic counters to path so that they survive restarts of LXD.
+func Save(path string) error {
+	historiesLock.Lock()
+	saved := make(map[string]map[string]savedHistory, len(histories))
+	for instanceKey, nics := range histories {
+		saved[instanceKey] = make(map[string]savedHistory, len(nics))
+		for deviceName, h := range nics {
+			saved[instanceKey][deviceName] = savedHistory{HostName: h.hostName, Last: h.last, Total: h.total, Samples: h.samples}
+		}
+	}
+
+	data, err := json.Marshal(saved)
+	historiesLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash doesn't leave a truncated file behind.
+	err = ioutil.WriteFile(path+".tmp", data, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed writing NIC counters: %w", err)
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// Load restores the sampled traffic counters written by Save. A missing file isn't an error.
+// As the instances keep running while LXD restarts, the traffic since the last sample is accounted for on the next
+// sample if their host side interfaces haven't changed.
+func Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("Failed reading NIC counters: %w", err)
+	}
+
+	saved := map[string]map[string]savedHistory{}
+	err = json.Unmarshal(data, &saved)
+	if err != nil {
+		return fmt.Errorf("Failed parsing NIC counters: %w", err)
+	}
+
+	historiesLock.Lock()
+	defer historiesLock.Unlock()
+
+	for instanceKey, nics := range saved {
+		histories[instanceKey] = make(map[string]*nicHistory, len(nics))
+		for deviceName, h := range nics {
+			histories[instanceKey][deviceName] = &nicHistory{hostName: h.HostName, last: h.Last, total: h.Total, samples: h.Samples}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package instance
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/instance/operationlock"
+	"github.com/lxc/lxd/lxd/resources"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+)
+
+// NUMA placement policies (limits.numa.policy).
+const (
+	// NUMAPolicyBalanced places the instance on the least used NUMA nodes.
+	NUMAPolicyBalanced = "balanced"
+
+	// NUMAPolicyIsolated places the instance on NUMA nodes not used by any other instance.
+	NUMAPolicyIsolated = "isolated"
+
+	// NUMAPolicyManual places the instance on the NUMA nodes listed in limits.numa.nodes.
+	NUMAPolicyManual = "manual"
+)
+
+// numaLock serializes the NUMA node selections so that starting instances don't pick the same isolated nodes.
+var numaLock sync.Mutex
+
+// numaNode represents a host NUMA node considered for placement.
+type numaNode struct {
+	id       uint64
+	cpus     []int64
+	load     float64
+	isolated bool
+}
+
+// NUMANodesParse parses a comma separated list of NUMA nodes.
+func NUMANodesParse(value string) ([]uint64, error) {
+	nodes := []uint64{}
+	if value == "" {
+		return nodes, nil
+	}
+
+	for _, field := range strings.Split(value, ",") {
+		node, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid NUMA node %q: %w", field, err)
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// NUMANodes returns the host NUMA nodes the instance has been placed on (volatile.numa.nodes).
+// Returns an empty list if the instance isn't using a NUMA policy.
+func NUMANodes(inst Instance) ([]uint64, error) {
+	return NUMANodesParse(inst.LocalConfig()["volatile.numa.nodes"])
+}
+
+// NUMANodesCPUs returns the online and non-isolated host CPU threads of the given NUMA nodes.
+func NUMANodesCPUs(nodes []uint64) ([]int64, error) {
+	cpu, err := resources.GetCPU()
+	if err != nil {
+		return nil, err
+	}
+
+	cpus := []int64{}
+	for _, socket := range cpu.Sockets {
+		for _, core := range socket.Cores {
+			for _, thread := range core.Threads {
+				if !thread.Online || thread.Isolated || !shared.Uint64InSlice(thread.NUMANode, nodes) {
+					continue
+				}
+
+				cpus = append(cpus, thread.ID)
+			}
+		}
+	}
+
+	sort.Slice(cpus, func(i, j int) bool { return cpus[i] < cpus[j] })
+
+	return cpus, nil
+}
+
+// numaCPUCount returns the number of CPUs requested by the instance, 0 meaning no specific amount.
+func numaCPUCount(inst Instance) (int, error) {
+	limit := inst.ExpandedConfig()["limits.cpu"]
+	if limit == "" {
+		if inst.Type() == instancetype.VM {
+			return 1, nil
+		}
+
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(limit)
+	if err != nil {
+		return -1, fmt.Errorf("limits.numa.policy can't be combined with a pinned limits.cpu")
+	}
+
+	return count, nil
+}
+
+// NUMANodesAllocate selects the host NUMA nodes the instance should be placed on according to its
+// limits.numa.policy and records them in volatile.numa.nodes. Returns an empty list if no policy is set.
+func NUMANodesAllocate(s *state.State, inst Instance) ([]uint64, error) {
+	numaLock.Lock()
+	defer numaLock.Unlock()
+
+	policy := inst.ExpandedConfig()["limits.numa.policy"]
+	if policy == "" {
+		if inst.LocalConfig()["volatile.numa.nodes"] != "" {
+			err := inst.VolatileSet(map[string]string{"volatile.numa.nodes": ""})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return []uint64{}, nil
+	}
+
+	cpuCount, err := numaCPUCount(inst)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := numaHostNodes(s, inst.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []uint64{}
+
+	switch policy {
+	case NUMAPolicyManual:
+		selected, err = NUMANodesParse(inst.ExpandedConfig()["limits.numa.nodes"])
+		if err != nil {
+			return nil, err
+		}
+
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("limits.numa.nodes must be set when using the %q NUMA policy", NUMAPolicyManual)
+		}
+
+		for _, id := range selected {
+			found := false
+			for _, node := range nodes {
+				if node.id == id {
+					found = true
+
+					if node.isolated {
+						return nil, fmt.Errorf("NUMA node %d is dedicated to another instance", id)
+					}
+
+					break
+				}
+			}
+
+			if !found {
+				return nil, fmt.Errorf("NUMA node %d doesn't exist", id)
+			}
+		}
+
+	case NUMAPolicyBalanced, NUMAPolicyIsolated:
+		// Least used nodes first.
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return nodes[i].load/float64(len(nodes[i].cpus)) < nodes[j].load/float64(len(nodes[j].cpus))
+		})
+
+		candidates := []uint64{}
+		threads := 0
+		found := false
+		for _, node := range nodes {
+			if node.isolated || (policy == NUMAPolicyIsolated && node.load > 0) {
+				continue
+			}
+
+			candidates = append(candidates, node.id)
+			threads += len(node.cpus)
+
+			// The vCPUs of virtual machines are spread evenly across the NUMA nodes, so keep adding nodes
+			// until their number divides the CPU count.
+			if threads >= cpuCount && (inst.Type() != instancetype.VM || cpuCount%len(candidates) == 0) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("Not enough available NUMA nodes for the %q NUMA policy", policy)
+		}
+
+		selected = candidates
+	}
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i] < selected[j] })
+
+	selectedStr := make([]string, 0, len(selected))
+	for _, id := range selected {
+		selectedStr = append(selectedStr, strconv.FormatUint(id, 10))
+	}
+
+	err = inst.VolatileSet(map[string]string{"volatile.numa.nodes": strings.Join(selectedStr, ",")})
+	if err != nil {
+		return nil, err
+	}
+
+	return selected, nil
+}
+
+// numaHostNodes returns the host NUMA nodes along with their load from the instances placed on them.
+// The load of a node is the number of CPUs requested by the instances placed on it, spread across their nodes.
+// The instance with the skipID ID isn't accounted for.
+func numaHostNodes(s *state.State, skipID int) ([]*numaNode, error) {
+	cpu, err := resources.GetCPU()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := map[uint64]*numaNode{}
+	for _, socket := range cpu.Sockets {
+		for _, core := range socket.Cores {
+			for _, thread := range core.Threads {
+				if !thread.Online || thread.Isolated {
+					continue
+				}
+
+				node, ok := nodes[thread.NUMANode]
+				if !ok {
+					node = &numaNode{id: thread.NUMANode}
+					nodes[thread.NUMANode] = node
+				}
+
+				node.cpus = append(node.cpus, thread.ID)
+			}
+		}
+	}
+
+	insts, err := LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading instances: %w", err)
+	}
+
+	for _, inst := range insts {
+		if inst.ID() == skipID {
+			continue
+		}
+
+		// Account for running instances as well as the ones being started.
+		if !inst.IsRunning() {
+			op := operationlock.Get(inst.Project(), inst.Name())
+			if op == nil || !op.ActionMatch(operationlock.ActionStart, operationlock.ActionRestart) {
+				continue
+			}
+		}
+
+		instNodes, err := NUMANodes(inst)
+		if err != nil || len(instNodes) == 0 {
+			continue
+		}
+
+		count, err := numaCPUCount(inst)
+		if err != nil {
+			continue
+		}
+
+		for _, id := range instNodes {
+			node, ok := nodes[id]
+			if !ok {
+				continue
+			}
+
+			if inst.ExpandedConfig()["limits.numa.policy"] == NUMAPolicyIsolated {
+				node.isolated = true
+			}
+
+			if count == 0 {
+				// The instance may use all the CPUs of its nodes.
+				node.load += float64(len(node.cpus)) / float64(len(instNodes))
+			} else {
+				node.load += float64(count) / float64(len(instNodes))
+			}
+		}
+	}
+
+	list := make([]*numaNode, 0, len(nodes))
+	for _, node := range nodes {
+		list = append(list, node)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].id < list[j].id })
+
+	return list, nil
+}
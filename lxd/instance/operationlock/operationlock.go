@@ -33,6 +33,9 @@ const ActionRestart Action = "restart"
 // ActionRestore for restoring an instance.
 const ActionRestore Action = "restore"
 
+// ActionRebuild for rebuilding an instance.
+const ActionRebuild Action = "rebuild"
+
 // ActionUpdate for updating an instance.
 const ActionUpdate Action = "update"
 
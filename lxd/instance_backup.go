@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/lxc/lxd/lxd/backup"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
@@ -21,6 +24,7 @@ import (
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/validate"
 	"github.com/lxc/lxd/shared/version"
 )
 
@@ -245,6 +249,11 @@ func instanceBackupsPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	err = instanceTrashedCheck(inst)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	rj := shared.Jmap{}
 	err = json.NewDecoder(r.Body).Decode(&rj)
 	if err != nil {
@@ -306,9 +315,62 @@ func instanceBackupsPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Backup names may not contain slashes"))
 	}
 
+	if !shared.StringInSlice(req.Format, []string{"", "lxd", backup.FormatOCI}) {
+		return response.BadRequest(fmt.Errorf("Invalid backup format %q", req.Format))
+	}
+
+	if req.Format == backup.FormatOCI && inst.Type() != instancetype.Container {
+		return response.BadRequest(fmt.Errorf("OCI backups are only supported for containers"))
+	}
+
 	fullName := name + shared.SnapshotDelimiter + req.Name
 	instanceOnly := req.InstanceOnly || req.ContainerOnly
 
+	objectKey := ""
+	switch req.Target {
+	case "":
+	case backup.TargetS3:
+		target := backup.NewS3Target(d.State())
+		err = target.Validate()
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		objectKey = target.ObjectKey(projectName, name, req.Name)
+	default:
+		return response.BadRequest(fmt.Errorf("Invalid backup target %q", req.Target))
+	}
+
+	incrementalFrom := ""
+	if req.IncrementalFrom != "" {
+		if req.Format == backup.FormatOCI || instanceOnly || !req.OptimizedStorage {
+			return response.BadRequest(fmt.Errorf("Incremental backups must be optimized and include the snapshots"))
+		}
+
+		incrementalFrom = name + shared.SnapshotDelimiter + req.IncrementalFrom
+		parent, err := instance.BackupLoadByName(d.State(), projectName, incrementalFrom)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed loading previous backup %q: %w", req.IncrementalFrom, err))
+		}
+
+		if !parent.OptimizedStorage() || parent.InstanceOnly() {
+			return response.BadRequest(fmt.Errorf("Previous backup %q must be optimized and include the snapshots", req.IncrementalFrom))
+		}
+	}
+
+	if len(req.Exclude) > 0 {
+		if inst.Type() != instancetype.Container || req.Format == backup.FormatOCI || req.OptimizedStorage {
+			return response.BadRequest(fmt.Errorf("Paths can only be excluded from non-optimized container backups"))
+		}
+
+		for _, exclusion := range req.Exclude {
+			err = validate.IsBackupExclusion(exclusion)
+			if err != nil {
+				return response.BadRequest(err)
+			}
+		}
+	}
+
 	backup := func(op *operations.Operation) error {
 		args := db.InstanceBackup{
 			Name:                 fullName,
@@ -318,6 +380,10 @@ func instanceBackupsPost(d *Daemon, r *http.Request) response.Response {
 			InstanceOnly:         instanceOnly,
 			OptimizedStorage:     req.OptimizedStorage,
 			CompressionAlgorithm: req.CompressionAlgorithm,
+			Format:               req.Format,
+			ObjectKey:            objectKey,
+			IncrementalFrom:      incrementalFrom,
+			Exclude:              req.Exclude,
 		}
 
 		err := backupCreate(d.State(), args, inst, op)
@@ -669,6 +735,31 @@ func instanceBackupExportGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	// Stream backups stored in S3 from the bucket.
+	if backup.ObjectKey() != "" {
+		reader, size, err := backup.S3Target().Download(backup.ObjectKey())
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		d.State().Events.SendLifecycle(projectName, lifecycle.InstanceBackupRetrieved.Event(name, backup.Instance(), nil))
+
+		return response.ManualResponse(func(w http.ResponseWriter) error {
+			defer func() { _ = reader.Close() }()
+
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("inline;filename=%s", path.Base(backup.ObjectKey())))
+			if size >= 0 {
+				w.Header().Set("Content-Length", fmt.Sprintf("%d", size))
+			}
+
+			w.WriteHeader(http.StatusOK)
+
+			_, err := io.Copy(w, reader)
+			return err
+		})
+	}
+
 	ent := response.FileResponseEntry{
 		Path: shared.VarPath("backups", "instances", project.Instance(projectName, backup.Name())),
 	}
@@ -466,7 +466,7 @@ func instanceConsolePost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if !inst.IsRunning() {
-		return response.BadRequest(fmt.Errorf("Instance is not running"))
+		return response.BadRequest(api.ErrorWithType(api.ErrorTypeInstanceNotRunning, fmt.Errorf("Instance is not running")))
 	}
 
 	if inst.IsFrozen() {
@@ -12,6 +12,7 @@ import (
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 )
 
 // swagger:operation DELETE /1.0/instances/{name} instances instance_delete
@@ -70,10 +71,20 @@ func instanceDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if inst.IsRunning() {
-		return response.BadRequest(fmt.Errorf("Instance is running"))
+		return response.BadRequest(api.ErrorWithType(api.ErrorTypeInstanceRunning, fmt.Errorf("Instance is running")))
+	}
+
+	protected, _, err := projectDeletionProtection(d.State(), projectName)
+	if err != nil {
+		return response.SmartError(err)
 	}
 
 	rmct := func(op *operations.Operation) error {
+		// Keep the instance in the trash, unless it's already there.
+		if protected && !instanceIsTrashed(inst) {
+			return instanceTrash(inst)
+		}
+
 		return inst.Delete(false)
 	}
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -20,11 +21,13 @@ import (
 
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/endpoints/listeners"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/ucred"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
@@ -33,10 +36,14 @@ import (
 )
 
 const execWSControl = -1
+const execWSExtraFDs = -2
 const execWSStdin = 0
 const execWSStdout = 1
 const execWSStderr = 2
 
+// execExtraFDsMax is the maximum number of additional file descriptors which can be passed to a command.
+const execExtraFDsMax = 64
+
 type execWs struct {
 	req api.InstanceExecPost
 
@@ -48,6 +55,7 @@ type execWs struct {
 	controlConnectedCtx   context.Context
 	controlConnectedDone  func()
 	fds                   map[int]string
+	extraFDsConn          *net.UnixConn
 	s                     *state.State
 }
 
@@ -56,6 +64,8 @@ func (s *execWs) Metadata() any {
 	for fd, secret := range s.fds {
 		if fd == execWSControl {
 			fds["control"] = secret
+		} else if fd == execWSExtraFDs {
+			fds["extra-fds"] = secret
 		} else {
 			fds[strconv.Itoa(fd)] = secret
 		}
@@ -77,6 +87,16 @@ func (s *execWs) Connect(op *operations.Operation, r *http.Request, w http.Respo
 
 	for fd, fdSecret := range s.fds {
 		if secret == fdSecret {
+			// The additional file descriptors are received on the unix socket underlying the websocket.
+			var unixConn *net.UnixConn
+			if fd == execWSExtraFDs {
+				var err error
+				unixConn, err = execUnixConn(r)
+				if err != nil {
+					return err
+				}
+			}
+
 			conn, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
 			if err != nil {
 				return err
@@ -89,6 +109,10 @@ func (s *execWs) Connect(op *operations.Operation, r *http.Request, w http.Respo
 			if found && val == nil {
 				s.conns[fd] = conn
 
+				if fd == execWSExtraFDs {
+					s.extraFDsConn = unixConn
+				}
+
 				if fd == execWSControl {
 					s.controlConnectedDone() // Control connection connected.
 				}
@@ -262,11 +286,37 @@ func (s *execWs) Do(op *operations.Operation) error {
 		return cmdErr
 	}
 
-	cmd, err := s.instance.Exec(s.req, stdin, stdout, stderr)
+	// Receive the additional file descriptors from the client.
+	var extraFiles []*os.File
+	defer func() {
+		for _, f := range extraFiles {
+			_ = f.Close()
+		}
+	}()
+
+	if s.req.ExtraFDs > 0 {
+		s.connsLock.Lock()
+		conn := s.extraFDsConn
+		s.connsLock.Unlock()
+
+		extraFiles, err = execReceiveFDs(conn, s.req.ExtraFDs)
+		if err != nil {
+			return finisher(-1, err)
+		}
+	}
+
+	cmd, err := s.instance.Exec(s.req, stdin, stdout, stderr, extraFiles)
 	if err != nil {
 		return finisher(-1, err)
 	}
 
+	// The command holds its own copies of the additional file descriptors now.
+	for _, f := range extraFiles {
+		_ = f.Close()
+	}
+
+	extraFiles = nil
+
 	l := logger.AddContext(logger.Log, logger.Ctx{"project": s.instance.Project(), "instance": s.instance.Name(), "PID": cmd.PID(), "interactive": s.req.Interactive})
 	l.Debug("Instance process started")
 
@@ -463,6 +513,9 @@ func (s *execWs) Do(op *operations.Operation) error {
 // In non-interactive mode, you'll get one websocket for each of stdin, stdout and stderr.
 // In interactive mode, a single bi-directional websocket is used for stdin and stdout/stderr.
 //
+// Containers can also be passed additional file descriptors (starting at 3) by local clients.
+// Those are sent as SCM_RIGHTS on the unix socket underlying the "extra-fds" websocket.
+//
 // An additional "control" socket is always added on top which can be used for out of band communication with LXD.
 // This allows sending signals and window sizing information through.
 //
@@ -517,6 +570,21 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	if post.ExtraFDs != 0 {
+		if post.ExtraFDs < 0 || post.ExtraFDs > execExtraFDsMax {
+			return response.BadRequest(fmt.Errorf("Number of additional file descriptors must be between 0 and %d", execExtraFDsMax))
+		}
+
+		if !post.WaitForWS {
+			return response.BadRequest(fmt.Errorf("Additional file descriptors require wait-for-websocket"))
+		}
+
+		_, err = execUnixConn(r)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	// Forward the request if the container is remote.
 	client, err := cluster.ConnectIfInstanceIsRemote(d.db.Cluster, projectName, name, d.endpoints.NetworkCert(), d.serverCert(), r, instanceType)
 	if err != nil {
@@ -524,6 +592,10 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if client != nil {
+		if post.ExtraFDs > 0 {
+			return response.BadRequest(fmt.Errorf("Additional file descriptors can only be passed to instances on the local cluster member"))
+		}
+
 		url := api.NewURL().Path("1.0", "instances", name, "exec").Project(projectName)
 		resp, _, err := client.RawQuery("POST", url.String(), post, "")
 		if err != nil {
@@ -543,14 +615,23 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	err = instanceTrashedCheck(inst)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	if !inst.IsRunning() {
-		return response.BadRequest(fmt.Errorf("Instance is not running"))
+		return response.BadRequest(api.ErrorWithType(api.ErrorTypeInstanceNotRunning, fmt.Errorf("Instance is not running")))
 	}
 
 	if inst.IsFrozen() {
 		return response.BadRequest(fmt.Errorf("Instance is frozen"))
 	}
 
+	if post.ExtraFDs > 0 && inst.Type() != instancetype.Container {
+		return response.BadRequest(fmt.Errorf("Additional file descriptors are only supported for containers"))
+	}
+
 	// Process environment.
 	if post.Environment == nil {
 		post.Environment = map[string]string{}
@@ -624,6 +705,10 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 			ws.conns[execWSStderr] = nil
 		}
 
+		if post.ExtraFDs > 0 {
+			ws.conns[execWSExtraFDs] = nil
+		}
+
 		ws.requiredConnectedCtx, ws.requiredConnectedDone = context.WithCancel(context.Background())
 		ws.controlConnectedCtx, ws.controlConnectedDone = context.WithCancel(context.Background())
 
@@ -680,7 +765,7 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 		}
 
 		// Run the command.
-		cmd, err := inst.Exec(post, nil, stdout, stderr)
+		cmd, err := inst.Exec(post, nil, stdout, stderr, nil)
 		if err != nil {
 			return err
 		}
@@ -718,3 +803,65 @@ func instanceExecPost(d *Daemon, r *http.Request) response.Response {
 
 	return operations.OperationResponse(op)
 }
+
+// execUnixConn returns the local unix socket connection the request came in on.
+func execUnixConn(r *http.Request) (*net.UnixConn, error) {
+	if r.RemoteAddr == "@" && r.TLS == nil {
+		switch conn := ucred.GetConnFromContext(r.Context()).(type) {
+		case *net.UnixConn:
+			return conn, nil
+		case listeners.BufferedUnixConn:
+			return conn.Unix(), nil
+		}
+	}
+
+	return nil, fmt.Errorf("Additional file descriptors can only be passed over the local unix socket")
+}
+
+// execReceiveFDs receives the additional file descriptors sent by the client as SCM_RIGHTS along with a single byte.
+func execReceiveFDs(conn *net.UnixConn, count int) ([]*os.File, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("Missing connection for the additional file descriptors")
+	}
+
+	err := conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = conn.SetReadDeadline(time.Time{}) }()
+
+	buf := make([]byte, 1)
+	oob := make([]byte, unix.CmsgSpace(count*4))
+	_, oobn, flags, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return nil, fmt.Errorf("Failed receiving additional file descriptors: %w", err)
+	}
+
+	msgs, err := unix.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing additional file descriptors: %w", err)
+	}
+
+	files := []*os.File{}
+	for _, msg := range msgs {
+		fds, err := unix.ParseUnixRights(&msg)
+		if err != nil {
+			continue
+		}
+
+		for _, fd := range fds {
+			files = append(files, os.NewFile(uintptr(fd), fmt.Sprintf("exec-fd-%d", 3+len(files))))
+		}
+	}
+
+	if flags&unix.MSG_CTRUNC != 0 || len(files) != count {
+		for _, f := range files {
+			_ = f.Close()
+		}
+
+		return nil, fmt.Errorf("Expected %d additional file descriptors but received %d", count, len(files))
+	}
+
+	return files, nil
+}
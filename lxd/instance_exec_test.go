@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// execTestConns returns both ends of a connected unix socket.
+func execTestConns(t *testing.T) (*net.UnixConn, *net.UnixConn) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	require.NoError(t, err)
+
+	conns := make([]*net.UnixConn, 0, len(fds))
+	for _, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		conn, err := net.FileConn(f)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		t.Cleanup(func() { _ = conn.Close() })
+		conns = append(conns, conn.(*net.UnixConn))
+	}
+
+	return conns[0], conns[1]
+}
+
+func TestExecReceiveFDs(t *testing.T) {
+	client, server := execTestConns(t)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+	defer func() { _ = w.Close() }()
+
+	_, _, err = client.WriteMsgUnix([]byte{0}, unix.UnixRights(int(w.Fd()), int(r.Fd())), nil)
+	require.NoError(t, err)
+
+	files, err := execReceiveFDs(server, 2)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	// The received file descriptors refer to the ones which were sent.
+	_, err = files[0].Write([]byte("hello"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 5)
+	_, err = files[1].Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+
+	for _, f := range files {
+		require.NoError(t, f.Close())
+	}
+}
+
+func TestExecReceiveFDs_Mismatch(t *testing.T) {
+	client, server := execTestConns(t)
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+	defer func() { _ = w.Close() }()
+
+	// Fewer file descriptors than expected.
+	_, _, err = client.WriteMsgUnix([]byte{0}, unix.UnixRights(int(w.Fd())), nil)
+	require.NoError(t, err)
+
+	_, err = execReceiveFDs(server, 2)
+	assert.Error(t, err)
+
+	// No file descriptors at all.
+	_, err = client.Write([]byte{0})
+	require.NoError(t, err)
+
+	_, err = execReceiveFDs(server, 1)
+	assert.Error(t, err)
+
+	_, err = execReceiveFDs(nil, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/device/pci"
+	"github.com/lxc/lxd/lxd/instance"
+	instanceDrivers "github.com/lxc/lxd/lxd/instance/drivers"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/resources"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/idmap"
+	"github.com/lxc/lxd/shared/osarch"
+)
+
+var internalClusterInstanceMigrationCheckCmd = APIEndpoint{
+	Path: "cluster/instance-migration-check",
+
+	Post: APIEndpointAction{Handler: internalClusterInstanceMigrationCheckPost},
+}
+
+// internalClusterInstanceMigrationCheckRequest describes the instance to be checked by the target member.
+type internalClusterInstanceMigrationCheckRequest struct {
+	// Instance type name.
+	Type string `json:"type" yaml:"type"`
+
+	// Instance architecture ID.
+	Architecture int `json:"architecture" yaml:"architecture"`
+
+	// Whether the instance is running and will be migrated live.
+	Live bool `json:"live" yaml:"live"`
+
+	// Expanded instance config and devices.
+	Config  map[string]string    `json:"config" yaml:"config"`
+	Devices deviceConfig.Devices `json:"devices" yaml:"devices"`
+
+	// CPU flags of the source member (VMs only).
+	CPUFlags []string `json:"cpu_flags" yaml:"cpu_flags"`
+}
+
+// instanceMigrationChecks accumulates the results of the migration pre-flight checks.
+type instanceMigrationChecks []api.InstanceMigrationCheckResult
+
+func (c *instanceMigrationChecks) add(checkType string, name string, status string, format string, args ...any) {
+	*c = append(*c, api.InstanceMigrationCheckResult{
+		Type:    checkType,
+		Name:    name,
+		Status:  status,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// instanceMigrationCheck validates that inst can be moved to targetNode without moving any data.
+// The checks which depend on the target's local state are run by the target member itself.
+func instanceMigrationCheck(d *Daemon, r *http.Request, inst instance.Instance, targetNode string, sourceNodeOffline bool, live bool) (*api.InstanceMigrationCheck, error) {
+	checks := instanceMigrationChecks{}
+
+	if targetNode == inst.Location() {
+		checks.add("member", targetNode, api.InstanceMigrationCheckFailed, "Target must be different than instance's current location")
+	} else {
+		checks.add("member", targetNode, api.InstanceMigrationCheckPassed, "Target member is online")
+	}
+
+	if sourceNodeOffline {
+		pool, err := storagePools.LoadByInstance(d.State(), inst)
+		if err != nil {
+			return nil, fmt.Errorf("Failed loading instance storage pool: %w", err)
+		}
+
+		if !pool.Driver().Info().Remote {
+			checks.add("member", inst.Location(), api.InstanceMigrationCheckFailed, "The cluster member hosting the instance is offline")
+		}
+	}
+
+	backups, err := d.db.Cluster.GetInstanceBackups(inst.Project(), inst.Name())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch instance's backups: %w", err)
+	}
+
+	if len(backups) > 0 {
+		checks.add("instance", inst.Name(), api.InstanceMigrationCheckFailed, "Instance has backups")
+	}
+
+	req := internalClusterInstanceMigrationCheckRequest{
+		Type:         inst.Type().String(),
+		Architecture: inst.Architecture(),
+		Live:         live && inst.IsRunning(),
+		Config:       inst.ExpandedConfig(),
+		Devices:      inst.ExpandedDevices(),
+	}
+
+	// The CPU flags are only meaningful when gathered on the member hosting the instance.
+	if inst.Type() == instancetype.VM && !sourceNodeOffline {
+		cpu, err := resources.GetCPU()
+		if err != nil {
+			return nil, fmt.Errorf("Failed getting CPU information: %w", err)
+		}
+
+		req.CPUFlags = instanceMigrationCPUFlags(cpu)
+	}
+
+	var targetAddress string
+	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		node, err := tx.GetNodeByName(targetNode)
+		if err != nil {
+			return fmt.Errorf("Failed to get target member: %w", err)
+		}
+
+		targetAddress = node.Address
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := cluster.Connect(targetAddress, d.endpoints.NetworkCert(), d.serverCert(), r, true)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to target member %q: %w", targetNode, err)
+	}
+
+	url := api.NewURL().Project(inst.Project()).Path("internal", "cluster", "instance-migration-check")
+	resp, _, err := client.RawQuery("POST", url.String(), req, "")
+	if err != nil {
+		return nil, fmt.Errorf("Failed running migration checks on target member %q: %w", targetNode, err)
+	}
+
+	targetChecks := instanceMigrationChecks{}
+	err = resp.MetadataAsStruct(&targetChecks)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing migration checks of target member %q: %w", targetNode, err)
+	}
+
+	checks = append(checks, targetChecks...)
+
+	result := &api.InstanceMigrationCheck{
+		Target:     targetNode,
+		Compatible: true,
+		Checks:     checks,
+	}
+
+	for _, check := range checks {
+		if check.Status == api.InstanceMigrationCheckFailed {
+			result.Compatible = false
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// instanceMigrationCPUFlags returns the CPU flags common to all sockets.
+func instanceMigrationCPUFlags(cpu *api.ResourcesCPU) []string {
+	var flags []string
+	for i, socket := range cpu.Sockets {
+		if i == 0 {
+			flags = socket.Flags
+			continue
+		}
+
+		common := []string{}
+		for _, flag := range flags {
+			if shared.StringInSlice(flag, socket.Flags) {
+				common = append(common, flag)
+			}
+		}
+
+		flags = common
+	}
+
+	return flags
+}
+
+// Run the instance migration pre-flight checks which depend on the local state of the target member.
+func internalClusterInstanceMigrationCheckPost(d *Daemon, r *http.Request) response.Response {
+	req := internalClusterInstanceMigrationCheckRequest{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	instType, err := instancetype.New(req.Type)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	checks, err := instanceMigrationCheckLocal(d.State(), projectParam(r), instType, req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, checks)
+}
+
+// instanceMigrationCheckLocal checks whether the local member can host the described instance.
+func instanceMigrationCheckLocal(s *state.State, projectName string, instType instancetype.Type, req internalClusterInstanceMigrationCheckRequest) (instanceMigrationChecks, error) {
+	checks := instanceMigrationChecks{}
+
+	// Instance driver and architecture.
+	driver, ok := instanceDrivers.DriverStatuses()[instType]
+	if !ok || !driver.Supported {
+		checks.add("architecture", instType.String(), api.InstanceMigrationCheckFailed, "Instance type %q isn't supported by the target", instType.String())
+	}
+
+	archName, err := osarch.ArchitectureName(req.Architecture)
+	if err != nil {
+		return nil, err
+	}
+
+	if shared.IntInSlice(req.Architecture, s.OS.Architectures) {
+		checks.add("architecture", archName, api.InstanceMigrationCheckPassed, "Architecture is supported by the target")
+	} else {
+		checks.add("architecture", archName, api.InstanceMigrationCheckFailed, "Architecture isn't supported by the target")
+	}
+
+	// CPU flags.
+	if instType == instancetype.VM && req.CPUFlags != nil {
+		cpu, err := resources.GetCPU()
+		if err != nil {
+			return nil, fmt.Errorf("Failed getting CPU information: %w", err)
+		}
+
+		localFlags := instanceMigrationCPUFlags(cpu)
+		missing := []string{}
+		for _, flag := range req.CPUFlags {
+			if !shared.StringInSlice(flag, localFlags) {
+				missing = append(missing, flag)
+			}
+		}
+
+		if len(missing) == 0 {
+			checks.add("cpu", "", api.InstanceMigrationCheckPassed, "Target CPU provides all source CPU flags")
+		} else if req.Live {
+			checks.add("cpu", "", api.InstanceMigrationCheckFailed, "Target CPU is missing flags required for live migration: %s", strings.Join(missing, ", "))
+		} else {
+			checks.add("cpu", "", api.InstanceMigrationCheckWarning, "Target CPU is missing flags available on the source: %s", strings.Join(missing, ", "))
+		}
+	}
+
+	// ID map.
+	if instType == instancetype.Container && shared.IsFalseOrEmpty(req.Config["security.privileged"]) {
+		instanceMigrationCheckIdmap(&checks, s, req.Config)
+	}
+
+	// Devices.
+	for _, entry := range req.Devices.Sorted() {
+		name := entry.Name
+		dev := entry.Config
+
+		switch dev["type"] {
+		case "disk":
+			instanceMigrationCheckDisk(&checks, s, projectName, name, dev)
+		case "nic":
+			instanceMigrationCheckNIC(&checks, s, projectName, name, dev)
+		case "gpu":
+			instanceMigrationCheckGPU(&checks, name, dev)
+		case "usb":
+			instanceMigrationCheckUSB(&checks, name, dev)
+		case "pci":
+			instanceMigrationCheckPCI(&checks, name, dev)
+		case "unix-char", "unix-block":
+			path := dev["source"]
+			if path == "" {
+				path = dev["path"]
+			}
+
+			if shared.PathExists(path) {
+				checks.add("device", name, api.InstanceMigrationCheckPassed, "Device %q is present on the target", path)
+			} else if shared.IsFalse(dev["required"]) {
+				checks.add("device", name, api.InstanceMigrationCheckWarning, "Optional device %q is missing on the target", path)
+			} else {
+				checks.add("device", name, api.InstanceMigrationCheckFailed, "Device %q is missing on the target", path)
+			}
+		}
+	}
+
+	return checks, nil
+}
+
+// instanceMigrationCheckIdmap checks that an ID map can be allocated for an unprivileged container.
+func instanceMigrationCheckIdmap(checks *instanceMigrationChecks, s *state.State, config map[string]string) {
+	if s.OS.IdmapSet == nil || len(s.OS.IdmapSet.Idmap) == 0 {
+		checks.add("idmap", "", api.InstanceMigrationCheckFailed, "Target has no uid/gid allocation configured")
+		return
+	}
+
+	rawMaps, err := idmap.ParseRawIdmap(config["raw.idmap"])
+	if err != nil {
+		checks.add("idmap", "", api.InstanceMigrationCheckFailed, "Failed parsing raw.idmap: %v", err)
+		return
+	}
+
+	set := idmap.IdmapSet{Idmap: make([]idmap.IdmapEntry, len(s.OS.IdmapSet.Idmap))}
+	copy(set.Idmap, s.OS.IdmapSet.Idmap)
+	for _, entry := range rawMaps {
+		err := set.AddSafe(entry)
+		if err == idmap.ErrHostIdIsSubId {
+			checks.add("idmap", "", api.InstanceMigrationCheckFailed, "raw.idmap host ID %d is within the target's allocated range", entry.Hostid)
+			return
+		}
+	}
+
+	if shared.IsTrue(config["security.idmap.isolated"]) {
+		size := int64(65536)
+		if config["security.idmap.size"] != "" && config["security.idmap.size"] != "auto" {
+			size, err = strconv.ParseInt(config["security.idmap.size"], 10, 64)
+			if err != nil {
+				checks.add("idmap", "", api.InstanceMigrationCheckFailed, "Invalid security.idmap.size: %v", err)
+				return
+			}
+		}
+
+		// The first 65536 IDs of the range are reserved for non-isolated containers.
+		available := s.OS.IdmapSet.Idmap[0].Maprange - 65536
+		if size > available {
+			checks.add("idmap", "", api.InstanceMigrationCheckFailed, "Target can only allocate %d isolated IDs, %d are needed", available, size)
+			return
+		}
+	}
+
+	checks.add("idmap", "", api.InstanceMigrationCheckPassed, "Target can allocate the instance's ID map")
+}
+
+// instanceMigrationCheckDisk checks that the storage used by a disk device is available on the target.
+func instanceMigrationCheckDisk(checks *instanceMigrationChecks, s *state.State, projectName string, name string, dev map[string]string) {
+	if dev["pool"] == "" {
+		source := dev["source"]
+		if source == "" || !strings.HasPrefix(source, "/") {
+			return
+		}
+
+		if shared.PathExists(source) {
+			checks.add("device", name, api.InstanceMigrationCheckPassed, "Disk source %q is present on the target", source)
+		} else if shared.IsFalse(dev["required"]) {
+			checks.add("device", name, api.InstanceMigrationCheckWarning, "Optional disk source %q is missing on the target", source)
+		} else {
+			checks.add("device", name, api.InstanceMigrationCheckFailed, "Disk source %q is missing on the target", source)
+		}
+
+		return
+	}
+
+	pool, err := storagePools.LoadByName(s, dev["pool"])
+	if err != nil {
+		checks.add("storage_pool", dev["pool"], api.InstanceMigrationCheckFailed, "Failed loading storage pool: %v", err)
+		return
+	}
+
+	status := pool.LocalStatus()
+	if status != api.StoragePoolStatusCreated {
+		checks.add("storage_pool", dev["pool"], api.InstanceMigrationCheckFailed, "Storage pool isn't available on the target (status %q)", status)
+		return
+	}
+
+	checks.add("storage_pool", dev["pool"], api.InstanceMigrationCheckPassed, "Storage pool is available on the target")
+
+	// The root disk is created on the target pool during the migration.
+	if dev["path"] == "/" || dev["source"] == "" {
+		return
+	}
+
+	shifted := shared.IsTrue(dev["shift"])
+
+	storageProjectName, err := project.StorageVolumeProject(s.DB.Cluster, projectName, db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		checks.add("device", name, api.InstanceMigrationCheckFailed, "Failed loading storage project: %v", err)
+		return
+	}
+
+	// GetLocalStoragePoolVolume returns a volume with an empty Location field for remote drivers.
+	_, vol, err := s.DB.Cluster.GetLocalStoragePoolVolume(storageProjectName, dev["source"], db.StoragePoolVolumeTypeCustom, pool.ID())
+	if err != nil {
+		checks.add("device", name, api.InstanceMigrationCheckFailed, "Custom volume %q isn't available on the target", dev["source"])
+		return
+	}
+
+	checks.add("device", name, api.InstanceMigrationCheckPassed, "Custom volume %q is available on the target", dev["source"])
+
+	if shared.IsTrue(vol.Config["security.shifted"]) {
+		shifted = true
+	}
+
+	if shifted && !s.OS.IdmappedMounts && !s.OS.Shiftfs {
+		checks.add("idmap", name, api.InstanceMigrationCheckFailed, "Target supports neither idmapped mounts nor shiftfs which are needed for shifted disks")
+	}
+
+}
+
+// instanceMigrationCheckNIC checks that the network or parent interface used by a NIC device exists on the target.
+func instanceMigrationCheckNIC(checks *instanceMigrationChecks, s *state.State, projectName string, name string, dev map[string]string) {
+	if dev["network"] != "" {
+		networkProjectName, _, err := project.NetworkProject(s.DB.Cluster, projectName)
+		if err != nil {
+			checks.add("network", dev["network"], api.InstanceMigrationCheckFailed, "Failed loading network project: %v", err)
+			return
+		}
+
+		n, err := network.LoadByName(s, networkProjectName, dev["network"])
+		if err != nil {
+			checks.add("network", dev["network"], api.InstanceMigrationCheckFailed, "Failed loading network: %v", err)
+			return
+		}
+
+		status := n.LocalStatus()
+		if status != api.NetworkStatusCreated {
+			checks.add("network", dev["network"], api.InstanceMigrationCheckFailed, "Network isn't available on the target (status %q)", status)
+			return
+		}
+
+		checks.add("network", dev["network"], api.InstanceMigrationCheckPassed, "Network is available on the target")
+		return
+	}
+
+	if dev["parent"] != "" {
+		if !network.InterfaceExists(dev["parent"]) {
+			checks.add("network", dev["parent"], api.InstanceMigrationCheckFailed, "Parent interface of device %q is missing on the target", name)
+			return
+		}
+
+		checks.add("network", dev["parent"], api.InstanceMigrationCheckPassed, "Parent interface of device %q is present on the target", name)
+		return
+	}
+
+	checks.add("device", name, api.InstanceMigrationCheckPassed, "Device doesn't depend on the target")
+}
+
+// instanceMigrationCheckGPU checks that a matching GPU exists on the target.
+func instanceMigrationCheckGPU(checks *instanceMigrationChecks, name string, dev map[string]string) {
+	gpus, err := resources.GetGPU()
+	if err != nil {
+		checks.add("device", name, api.InstanceMigrationCheckFailed, "Failed getting GPU information: %v", err)
+		return
+	}
+
+	pciAddress := dev["pci"]
+	if pciAddress != "" {
+		pciAddress = pci.NormaliseAddress(pciAddress)
+	}
+
+	for _, gpu := range gpus.Cards {
+		if (dev["vendorid"] != "" && gpu.VendorID != dev["vendorid"]) ||
+			(pciAddress != "" && gpu.PCIAddress != pciAddress) ||
+			(dev["productid"] != "" && gpu.ProductID != dev["productid"]) ||
+			(dev["id"] != "" && (gpu.DRM == nil || fmt.Sprintf("%d", gpu.DRM.ID) != dev["id"])) {
+			continue
+		}
+
+		checks.add("device", name, api.InstanceMigrationCheckPassed, "Matching GPU %q is present on the target", gpu.PCIAddress)
+		return
+	}
+
+	checks.add("device", name, api.InstanceMigrationCheckFailed, "No matching GPU is present on the target")
+}
+
+// instanceMigrationCheckUSB checks that a matching USB device exists on the target.
+func instanceMigrationCheckUSB(checks *instanceMigrationChecks, name string, dev map[string]string) {
+	usbs, err := resources.GetUSB()
+	if err != nil {
+		checks.add("device", name, api.InstanceMigrationCheckFailed, "Failed getting USB information: %v", err)
+		return
+	}
+
+	for _, usb := range usbs.Devices {
+		if (dev["vendorid"] != "" && usb.VendorID != dev["vendorid"]) || (dev["productid"] != "" && usb.ProductID != dev["productid"]) {
+			continue
+		}
+
+		checks.add("device", name, api.InstanceMigrationCheckPassed, "Matching USB device is present on the target")
+		return
+	}
+
+	// USB devices are hotplugged when they appear unless required.
+	if shared.IsTrue(dev["required"]) {
+		checks.add("device", name, api.InstanceMigrationCheckFailed, "No matching USB device is present on the target")
+		return
+	}
+
+	checks.add("device", name, api.InstanceMigrationCheckWarning, "No matching USB device is currently present on the target")
+}
+
+// instanceMigrationCheckPCI checks that the PCI device exists on the target.
+func instanceMigrationCheckPCI(checks *instanceMigrationChecks, name string, dev map[string]string) {
+	address := pci.NormaliseAddress(dev["address"])
+	if shared.PathExists(fmt.Sprintf("/sys/bus/pci/devices/%s", address)) {
+		checks.add("device", name, api.InstanceMigrationCheckPassed, "PCI device %q is present on the target", address)
+		return
+	}
+
+	checks.add("device", name, api.InstanceMigrationCheckFailed, "PCI device %q is missing on the target", address)
+}
@@ -26,7 +26,10 @@ import (
 //
 // Partially update the instance
 //
-// Updates a subset of the instance configuration
+// Updates a subset of the instance configuration.
+//
+// Devices are updated individually: a device passed in the request replaces the existing
+// device of the same name, a device set to null is removed and other devices are kept.
 //
 // ---
 // consumes:
@@ -80,11 +83,19 @@ func instancePatch(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	unlock := instanceUpdateLock(projectName, name)
+	defer unlock()
+
 	c, err := instance.LoadByProjectAndName(d.State(), projectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	err = instanceTrashedCheck(c)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	// Validate the ETag
 	etag := []any{c.Architecture(), c.LocalConfig(), c.LocalDevices(), c.IsEphemeral(), c.Profiles()}
 	err = util.EtagCheck(r, etag)
@@ -153,9 +164,27 @@ func instancePatch(d *Daemon, r *http.Request) response.Response {
 	if req.Devices == nil {
 		req.Devices = c.LocalDevices().CloneNative()
 	} else {
-		for k, v := range c.LocalDevices() {
-			_, ok := req.Devices[k]
+		localDevices := c.LocalDevices()
+
+		// Devices set to null are removed.
+		removedDevices := []string{}
+		for k, v := range req.Devices {
+			if v != nil {
+				continue
+			}
+
+			_, ok := localDevices[k]
 			if !ok {
+				return response.BadRequest(fmt.Errorf("Device %q doesn't exist", k))
+			}
+
+			delete(req.Devices, k)
+			removedDevices = append(removedDevices, k)
+		}
+
+		for k, v := range localDevices {
+			_, ok := req.Devices[k]
+			if !ok && !shared.StringInSlice(k, removedDevices) {
 				req.Devices[k] = v
 			}
 		}
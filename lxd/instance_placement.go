@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/request"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/units"
+)
+
+// instancePlacementCtxKey is the request context key holding the placement candidates of a new instance.
+const instancePlacementCtxKey request.CtxKey = "instance_placement"
+
+// instancePlacementCandidate records how a cluster member was considered when placing a new instance.
+type instancePlacementCandidate struct {
+	Member      string `json:"member"`
+	Instances   int    `json:"instances"`
+	DefaultArch bool   `json:"default_architecture"`
+	PoolFree    int64  `json:"pool_free"`
+	MemoryFree  int64  `json:"memory_free,omitempty"`
+	Load        string `json:"load,omitempty"`
+	Score       string `json:"score,omitempty"`
+	Selected    bool   `json:"selected"`
+	Reason      string `json:"reason,omitempty"`
+
+	member cluster.PlacementMember
+	score  float64
+}
+
+// betterThan returns whether the candidate should be preferred over the other one.
+// Members supporting the default architecture come first. Then, if a placement policy is in use, the ones with the
+// highest score, otherwise the ones with the least instances and finally the ones with the most free space in the
+// storage pool.
+func (c *instancePlacementCandidate) betterThan(other *instancePlacementCandidate, scored bool) bool {
+	if c.DefaultArch != other.DefaultArch {
+		return c.DefaultArch
+	}
+
+	if scored && c.score != other.score {
+		return c.score > other.score
+	}
+
+	if c.Instances != other.Instances {
+		return c.Instances < other.Instances
+	}
+
+	return c.PoolFree > other.PoolFree
+}
+
+// instancePlacementMetadata returns the operation metadata describing how the instance was placed.
+// Returns nil if the instance wasn't placed automatically.
+func instancePlacementMetadata(r *http.Request) any {
+	candidates, ok := r.Context().Value(instancePlacementCtxKey).([]instancePlacementCandidate)
+	if !ok {
+		return nil
+	}
+
+	return map[string]any{"placement": candidates}
+}
+
+// instancePlacementRootDiskSize returns the size in bytes of the root disk requested for a new instance.
+// It looks at the instance's own root disk device, then at its profiles and finally at the pool's "volume.size".
+// Returns 0 if no size is set.
+func instancePlacementRootDiskSize(d *Daemon, projectName string, req *api.InstancesPost, poolName string) (int64, error) {
+	size := ""
+
+	_, rootDiskDevice, _ := shared.GetRootDiskDevice(req.Devices)
+	if rootDiskDevice != nil {
+		size = rootDiskDevice["size"]
+	}
+
+	if size == "" {
+		for _, profileName := range req.Profiles {
+			_, profile, err := d.db.Cluster.GetProfile(projectName, profileName)
+			if err != nil {
+				return -1, err
+			}
+
+			_, profileRootDiskDevice, _ := shared.GetRootDiskDevice(profile.Devices)
+			if profileRootDiskDevice != nil && profileRootDiskDevice["size"] != "" {
+				// Keep going as we want the last one in the profile chain.
+				size = profileRootDiskDevice["size"]
+			}
+		}
+	}
+
+	if size == "" {
+		_, pool, _, err := d.db.Cluster.GetStoragePool(poolName)
+		if err != nil {
+			return -1, err
+		}
+
+		size = pool.Config["volume.size"]
+	}
+
+	if size == "" {
+		return 0, nil
+	}
+
+	return units.ParseByteSizeString(size)
+}
+
+// instancePlacementPolicy returns the placement policy of a new instance.
+// It looks at the instance's own config, then at its profiles and finally at the project's config.
+func instancePlacementPolicy(d *Daemon, projectName string, projectConfig map[string]string, req *api.InstancesPost) (string, error) {
+	policy := req.Config["cluster.placement"]
+
+	if policy == "" {
+		for _, profileName := range req.Profiles {
+			_, profile, err := d.db.Cluster.GetProfile(projectName, profileName)
+			if err != nil {
+				return "", err
+			}
+
+			if profile.Config["cluster.placement"] != "" {
+				// Keep going as we want the last one in the profile chain.
+				policy = profile.Config["cluster.placement"]
+			}
+		}
+	}
+
+	if policy == "" {
+		policy = projectConfig["cluster.placement"]
+	}
+
+	return policy, nil
+}
+
+// instancePlacementResources returns the system resources of a cluster member.
+func instancePlacementResources(d *Daemon, r *http.Request, member db.NodeInfo) (*api.Resources, error) {
+	s := d.State()
+
+	if member.Name == s.ServerName {
+		return resourcesGetCached(s)
+	}
+
+	client, err := cluster.Connect(member.Address, d.endpoints.NetworkCert(), d.serverCert(), r, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetServerResources()
+}
+
+// instancePlacementPoolResources returns the storage pool resources of a cluster member.
+func instancePlacementPoolResources(d *Daemon, r *http.Request, poolName string, member db.NodeInfo) (*api.ResourcesStoragePool, error) {
+	s := d.State()
+
+	if member.Name == s.ServerName {
+		pool, err := storagePools.LoadByName(s, poolName)
+		if err != nil {
+			return nil, err
+		}
+
+		return pool.GetResources()
+	}
+
+	client, err := cluster.Connect(member.Address, d.endpoints.NetworkCert(), d.serverCert(), r, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetStoragePoolResources(poolName)
+}
+
+// instancePlacement picks the cluster member on which to create a new instance.
+// Only the members on which the storage pool has been created and which have enough free space in it for the root
+// disk (when it can be determined) are eligible. If a placement policy is set, the eligible members are then scored
+// by its scheduler based on their free memory, CPU load and storage pool free space. Returns an empty member name
+// if no member is eligible, along with the scoring of all the candidate members.
+func instancePlacement(d *Daemon, r *http.Request, poolName string, rootDiskSize int64, policy string, archs []int, defaultArch int, group string, allowedGroups []string) (string, []instancePlacementCandidate, error) {
+	var scheduler cluster.PlacementScheduler
+	if policy != "" {
+		var err error
+		scheduler, err = cluster.GetPlacementScheduler(policy)
+		if err != nil {
+			return "", nil, api.StatusErrorf(http.StatusBadRequest, "%v", err)
+		}
+	}
+
+	var members []db.NodeInfo
+	var poolMembers []string
+	candidates := []instancePlacementCandidate{}
+
+	err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		members, err = tx.GetCandidateMembers(archs, group, allowedGroups)
+		if err != nil {
+			return err
+		}
+
+		if poolName != "" {
+			poolMembers, err = tx.GetStoragePoolCreatedNodes(poolName)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, member := range members {
+			count, err := tx.GetNodeInstancesCount(member.ID)
+			if err != nil {
+				return err
+			}
+
+			supported, err := member.Architectures()
+			if err != nil {
+				return err
+			}
+
+			candidates = append(candidates, instancePlacementCandidate{
+				Member:      member.Name,
+				Instances:   count,
+				DefaultArch: shared.IntInSlice(defaultArch, supported),
+				PoolFree:    -1,
+				member: cluster.PlacementMember{
+					Name:      member.Name,
+					Instances: count,
+				},
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Check the storage pool and the resources on each candidate member in parallel.
+	wg := sync.WaitGroup{}
+	for i := range candidates {
+		candidate := &candidates[i]
+
+		if poolName != "" && !shared.StringInSlice(candidate.Member, poolMembers) {
+			candidate.Reason = fmt.Sprintf("Storage pool %q isn't available", poolName)
+			continue
+		}
+
+		wg.Add(1)
+		go func(candidate *instancePlacementCandidate, member db.NodeInfo) {
+			defer wg.Done()
+
+			if scheduler != nil {
+				res, err := instancePlacementResources(d, r, member)
+				if err != nil {
+					// Don't reject a member only because its resources couldn't be determined.
+					logger.Warn("Failed getting resources of cluster member", logger.Ctx{"member": member.Name, "err": err})
+				} else {
+					candidate.member.MemoryTotal = res.Memory.Total
+					candidate.member.MemoryUsed = res.Memory.Used
+					candidate.member.CPUThreads = res.CPU.Total
+					candidate.member.CPULoad = res.Load.Average1
+
+					candidate.MemoryFree = int64(res.Memory.Total) - int64(res.Memory.Used)
+					candidate.Load = fmt.Sprintf("%.2f", res.Load.Average1)
+				}
+			}
+
+			if poolName == "" {
+				return
+			}
+
+			res, err := instancePlacementPoolResources(d, r, poolName, member)
+			if err != nil {
+				// Don't reject a member only because its free space couldn't be determined.
+				logger.Warn("Failed getting storage pool resources of cluster member", logger.Ctx{"pool": poolName, "member": member.Name, "err": err})
+				return
+			}
+
+			candidate.member.PoolTotal = res.Space.Total
+			candidate.member.PoolUsed = res.Space.Used
+
+			candidate.PoolFree = 0
+			if res.Space.Total > res.Space.Used {
+				candidate.PoolFree = int64(res.Space.Total - res.Space.Used)
+			}
+
+			if rootDiskSize > 0 && candidate.PoolFree < rootDiskSize {
+				candidate.Reason = fmt.Sprintf("Not enough free space in storage pool %q", poolName)
+			}
+		}(candidate, members[i])
+	}
+
+	wg.Wait()
+
+	var best *instancePlacementCandidate
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate.Reason != "" {
+			continue
+		}
+
+		if scheduler != nil {
+			candidate.score = scheduler.Score(candidate.member)
+			candidate.Score = fmt.Sprintf("%.3f", candidate.score)
+		}
+
+		if best == nil || candidate.betterThan(best, scheduler != nil) {
+			best = candidate
+		}
+	}
+
+	if best == nil {
+		return "", candidates, nil
+	}
+
+	best.Selected = true
+
+	return best.Member, candidates, nil
+}
+
+// instancePlacementCheckTarget checks that the storage pool has been created on the targeted cluster member.
+func instancePlacementCheckTarget(d *Daemon, poolName string, targetNode string) error {
+	var poolMembers []string
+
+	err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		poolMembers, err = tx.GetStoragePoolCreatedNodes(poolName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !shared.StringInSlice(targetNode, poolMembers) {
+		return api.StatusErrorf(http.StatusBadRequest, "Storage pool %q isn't available on cluster member %q", poolName, targetNode)
+	}
+
+	return nil
+}
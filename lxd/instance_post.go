@@ -45,6 +45,9 @@ var internalClusterInstanceMovedCmd = APIEndpoint{
 // operation with progress data, for the pull case, it will be a websocket
 // operation with a number of secrets to be passed to the target server.
 //
+// When migrating to another cluster member with `dry_run` set, no data is
+// moved and a report of the migration pre-flight checks is returned instead.
+//
 // ---
 // consumes:
 //   - application/json
@@ -62,6 +65,26 @@ var internalClusterInstanceMovedCmd = APIEndpoint{
 //     schema:
 //       $ref: "#/definitions/InstancePost"
 // responses:
+//   "200":
+//     description: Migration check
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/InstanceMigrationCheck"
 //   "202":
 //     $ref: "#/responses/Operation"
 //   "400":
@@ -107,7 +130,7 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 		// If the target node is offline, we return an error.
 		//
 		// If the source node is offline and the container is backed by
-		// ceph, we'll just assume that the container is not running
+		// remote storage, we'll just assume that the container is not running
 		// and it's safe to move it.
 		//
 		// TODO: add some sort of "force" flag to the API, to signal
@@ -181,7 +204,7 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 	//    container is offline. We don't want to forward to the request to
 	//    that node and we don't want to load the container here (since
 	//    it's not a local container): we'll be able to handle the request
-	//    at all only if the container is backed by ceph. We'll check for
+	//    at all only if the container is backed by remote storage. We'll check for
 	//    that just below.
 	//
 	// Cases 1. and 2. are the ones for which the conditional will be true
@@ -228,7 +251,26 @@ func instancePost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	err = instanceTrashedCheck(inst)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	if req.Migration {
+		// Migration pre-flight checks.
+		if req.DryRun {
+			if targetNode == "" {
+				return response.BadRequest(fmt.Errorf("Migration checks require a target cluster member"))
+			}
+
+			check, err := instanceMigrationCheck(d, r, inst, targetNode, sourceNodeOffline, req.Live)
+			if err != nil {
+				return response.SmartError(err)
+			}
+
+			return response.SyncResponse(true, check)
+		}
+
 		// Server-side pool migration.
 		if req.Pool != "" {
 			// Setup the instance move operation.
@@ -534,7 +576,7 @@ func instancePostProjectMigration(d *Daemon, inst instance.Instance, newName str
 	return nil
 }
 
-// Move a non-ceph container to another cluster node.
+// Move a container not backed by remote storage to another cluster node.
 func instancePostClusteringMigrate(d *Daemon, r *http.Request, inst instance.Instance, newName string, newNode string, stateful bool, allowInconsistent bool) (func(op *operations.Operation) error, error) {
 	var sourceAddress string
 	var targetAddress string
@@ -725,10 +767,10 @@ func instancePostClusteringMigrate(d *Daemon, r *http.Request, inst instance.Ins
 	return run, nil
 }
 
-// Special case migrating a container backed response.Responseby ceph across two cluster nodes.
-func instancePostClusteringMigrateWithCeph(d *Daemon, r *http.Request, inst instance.Instance, pool storagePools.Pool, newName string, sourceNodeOffline bool, newNode string, stateful bool) (func(op *operations.Operation) error, error) {
-	if pool.Driver().Info().Name != "ceph" {
-		return nil, fmt.Errorf("Source instance's storage pool is not of type ceph")
+// Special case migrating an instance backed by remote storage (such as ceph) across two cluster nodes.
+func instancePostClusteringMigrateWithRemoteStorage(d *Daemon, r *http.Request, inst instance.Instance, pool storagePools.Pool, newName string, sourceNodeOffline bool, newNode string, stateful bool) (func(op *operations.Operation) error, error) {
+	if !pool.Driver().Info().Remote {
+		return nil, fmt.Errorf("Source instance's storage pool is not remote")
 	}
 
 	var err error
@@ -798,13 +840,13 @@ func instancePostClusteringMigrateWithCeph(d *Daemon, r *http.Request, inst inst
 			return err
 		}
 
-		// Trigger a rename in the Ceph driver.
+		// Trigger a rename in the remote storage driver.
 		args := migration.VolumeSourceArgs{
 			Data: project.Instance(inst.Project(), newName), // Indicate new storage volume name.
 		}
 		err = pool.MigrateInstance(inst, nil, &args, op)
 		if err != nil {
-			return fmt.Errorf("Failed to migrate ceph RBD volume: %w", err)
+			return fmt.Errorf("Failed to migrate remote storage volume: %w", err)
 		}
 
 		// Re-link the database entries against the new node name.
@@ -883,7 +925,7 @@ func instancePostClusteringMigrateWithCeph(d *Daemon, r *http.Request, inst inst
 
 // Notification that an instance was moved.
 //
-// At the moment it's used for ceph-based instances, where the target node needs
+// At the moment it's used for instances on remote storage, where the target node needs
 // to create the appropriate mount points.
 func internalClusterInstanceMovedPost(d *Daemon, r *http.Request) response.Response {
 	projectName := projectParam(r)
@@ -926,13 +968,13 @@ func migrateInstance(d *Daemon, r *http.Request, inst instance.Instance, targetN
 		return fmt.Errorf("Target must be different than instance's current location")
 	}
 
-	// Check if we are migrating a ceph-based instance.
+	// Check if we are migrating an instance on remote storage.
 	pool, err := storagePools.LoadByInstance(d.State(), inst)
 	if err != nil {
 		return fmt.Errorf("Failed loading instance storage pool: %w", err)
 	}
-	if pool.Driver().Info().Name == "ceph" {
-		f, err := instancePostClusteringMigrateWithCeph(d, r, inst, pool, req.Name, sourceNodeOffline, targetNode, req.Live)
+	if pool.Driver().Info().Remote {
+		f, err := instancePostClusteringMigrateWithRemoteStorage(d, r, inst, pool, req.Name, sourceNodeOffline, targetNode, req.Live)
 		if err != nil {
 			return err
 		}
@@ -940,8 +982,8 @@ func migrateInstance(d *Daemon, r *http.Request, inst instance.Instance, targetN
 		return f(op)
 	}
 
-	// If this is not a ceph-based instance, make sure that the source node is online, and we didn't get here
-	// only to handle the case where the instance is ceph-based.
+	// If this is not an instance on remote storage, make sure that the source node is online, and we didn't
+	// get here only to handle the case where the instance is on remote storage.
 	if sourceNodeOffline {
 		err := fmt.Errorf("The cluster member hosting the instance is offline")
 		return err
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// autoPublishInstancesTask publishes instances as images according to their publish.schedule.
+func autoPublishInstancesTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+		dbInstances := []db.Instance{}
+
+		// Get instances.
+		err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			// Get all projects.
+			allProjects, err := dbCluster.GetProjects(context.Background(), tx.Tx(), dbCluster.ProjectFilter{})
+			if err != nil {
+				return fmt.Errorf("Failed loading projects: %w", err)
+			}
+
+			// Filter projects that aren't allowed to have snapshots (publishing goes through one).
+			for _, p := range allProjects {
+				err = project.AllowSnapshotCreation(tx, &p)
+				if err != nil {
+					continue
+				}
+
+				// Get instances.
+				filter := db.InstanceTypeFilter(instancetype.Any)
+				filter.Project = &p.Name
+
+				entries, err := tx.GetLocalInstancesInProject(filter)
+				if err != nil {
+					return err
+				}
+
+				dbInstances = append(dbInstances, entries...)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return
+		}
+
+		// Load the instances.
+		allInstances, err := instance.LoadAllInternal(s, dbInstances)
+		if err != nil {
+			return
+		}
+
+		// Figure out which need publishing (if any).
+		instances := []instance.Instance{}
+		for _, inst := range allInstances {
+			// Trashed instances are left alone until they get restored.
+			if instanceIsTrashed(inst) {
+				continue
+			}
+
+			schedule := inst.ExpandedConfig()["publish.schedule"]
+			if schedule == "" {
+				continue
+			}
+
+			// Check if publishing is scheduled.
+			if !snapshotIsScheduledNow(schedule, int64(inst.ID())) {
+				continue
+			}
+
+			instances = append(instances, inst)
+		}
+
+		// Publish each instance in its own operation so they can be tracked individually.
+		for _, inst := range instances {
+			inst := inst
+
+			opRun := func(op *operations.Operation) error {
+				return instancePublishScheduled(d, inst, op)
+			}
+
+			resources := map[string][]string{}
+			resources["instances"] = []string{inst.Name()}
+
+			op, err := operations.OperationCreate(s, inst.Project(), operations.OperationClassTask, db.OperationInstancePublish, resources, nil, opRun, nil, nil, nil)
+			if err != nil {
+				logger.Error("Failed to start instance publish operation", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+				continue
+			}
+
+			logger.Info("Publishing scheduled instance image", logger.Ctx{"project": inst.Project(), "instance": inst.Name()})
+
+			err = op.Start()
+			if err != nil {
+				logger.Error("Failed to publish scheduled instance image", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+				continue
+			}
+
+			op.Wait(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+		}
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+// instancePublishScheduled snapshots the instance and publishes the snapshot as an image, pointing the
+// publish.alias image alias at it and pruning previously published images beyond publish.retention.
+func instancePublishScheduled(d *Daemon, inst instance.Instance, op *operations.Operation) error {
+	s := d.State()
+	projectName := inst.Project()
+
+	// Possibly set a quota on the amount of disk space this project is allowed to use.
+	var budget int64
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+
+		budget, err = project.GetImageSpaceBudget(tx, projectName)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Snapshot the instance so it can be published without being stopped.
+	snapName := fmt.Sprintf("publish-%s", time.Now().UTC().Format("20060102-150405"))
+	snapFullName := inst.Name() + shared.SnapshotDelimiter + snapName
+	err = inst.Snapshot(snapName, time.Time{}, false)
+	if err != nil {
+		return fmt.Errorf("Failed creating snapshot: %w", err)
+	}
+
+	defer func() {
+		snap, err := instance.LoadByProjectAndName(s, projectName, snapFullName)
+		if err == nil {
+			err = snap.Delete(true)
+		}
+
+		if err != nil {
+			logger.Warn("Failed deleting publish snapshot", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "snapshot": snapName, "err": err})
+		}
+	}()
+
+	builddir, err := ioutil.TempDir(shared.VarPath("images"), "lxd_build_")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.RemoveAll(builddir) }()
+
+	req := api.ImagesPost{
+		Source: &api.ImagesPostSource{
+			Type: "snapshot",
+			Name: snapFullName,
+		},
+	}
+
+	imagePublishLock.Lock()
+	info, err := imgPostInstanceInfo(d, projectName, req, op, builddir, budget)
+	imagePublishLock.Unlock()
+	if err != nil {
+		return fmt.Errorf("Failed publishing image: %w", err)
+	}
+
+	err = op.UpdateMetadata(map[string]any{
+		"fingerprint": info.Fingerprint,
+		"size":        strconv.FormatInt(info.Size, 10),
+	})
+	if err != nil {
+		return err
+	}
+
+	imgID, _, err := s.DB.Cluster.GetImage(info.Fingerprint, db.ImageFilter{Project: &projectName})
+	if err != nil {
+		return fmt.Errorf("Fetch image %q: %w", info.Fingerprint, err)
+	}
+
+	// Point the alias at the new image.
+	aliasName := inst.ExpandedConfig()["publish.alias"]
+	if aliasName == "" {
+		aliasName = inst.Name()
+	}
+
+	aliasID, alias, err := s.DB.Cluster.GetImageAlias(projectName, aliasName, true)
+	if err != nil {
+		if !response.IsNotFoundError(err) {
+			return fmt.Errorf("Fetch image alias %q: %w", aliasName, err)
+		}
+
+		err = s.DB.Cluster.CreateImageAlias(projectName, aliasName, imgID, "")
+	} else {
+		err = s.DB.Cluster.UpdateImageAlias(aliasID, imgID, alias.Description)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed setting image alias %q: %w", aliasName, err)
+	}
+
+	// Sync the images between each node in the cluster on demand.
+	err = imageSyncBetweenNodes(d, nil, projectName, info.Fingerprint)
+	if err != nil {
+		return fmt.Errorf("Failed syncing image between nodes: %w", err)
+	}
+
+	s.Events.SendLifecycle(projectName, lifecycle.ImageCreated.Event(info.Fingerprint, projectName, op.Requestor(), logger.Ctx{"type": info.Type}))
+
+	// Record the published image and prune the ones beyond the retention.
+	fingerprints := shared.SplitNTrimSpace(inst.LocalConfig()["volatile.publish.fingerprints"], ",", -1, true)
+	fingerprints = append(fingerprints, info.Fingerprint)
+
+	retention := inst.ExpandedConfig()["publish.retention"]
+	if retention != "" {
+		keep, err := strconv.Atoi(retention)
+		if err != nil {
+			return fmt.Errorf("Invalid publish.retention %q: %w", retention, err)
+		}
+
+		for len(fingerprints) > keep+1 {
+			fingerprint := fingerprints[0]
+			fingerprints = fingerprints[1:]
+
+			oldImgID, oldImgInfo, err := s.DB.Cluster.GetImage(fingerprint, db.ImageFilter{Project: &projectName})
+			if err != nil {
+				if response.IsNotFoundError(err) {
+					continue
+				}
+
+				return fmt.Errorf("Fetch image %q: %w", fingerprint, err)
+			}
+
+			err = doImageDelete(d, projectName, oldImgID, oldImgInfo, op, false)
+			if err != nil && !response.IsNotFoundError(err) {
+				return fmt.Errorf("Failed deleting previously published image %q: %w", fingerprint, err)
+			}
+		}
+	}
+
+	return inst.VolatileSet(map[string]string{"volatile.publish.fingerprints": strings.Join(fingerprints, ",")})
+}
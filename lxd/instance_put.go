@@ -16,6 +16,7 @@ import (
 	"github.com/lxc/lxd/lxd/operations"
 	projecthelpers "github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -81,11 +82,22 @@ func instancePut(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	// Hold the update lock until the operation has run.
+	unlock := instanceUpdateLock(projectName, name)
+	revert := revert.New()
+	defer revert.Fail()
+	revert.Add(func() { unlock() })
+
 	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	err = instanceTrashedCheck(inst)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	// Validate the ETag
 	etag := []any{inst.Architecture(), inst.LocalConfig(), inst.LocalDevices(), inst.IsEphemeral(), inst.Profiles()}
 	err = util.EtagCheck(r, etag)
@@ -151,11 +163,18 @@ func instancePut(d *Daemon, r *http.Request) response.Response {
 		resources["containers"] = resources["instances"]
 	}
 
-	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, opType, resources, nil, do, nil, nil, r)
+	run := func(op *operations.Operation) error {
+		defer unlock()
+
+		return do(op)
+	}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, opType, resources, nil, run, nil, nil, r)
 	if err != nil {
 		return response.InternalError(err)
 	}
 
+	revert.Success()
 	return operations.OperationResponse(op)
 }
 
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var instanceRebuildCmd = APIEndpoint{
+	Name: "instanceRebuild",
+	Path: "instances/{name}/rebuild",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerRebuild", Path: "containers/{name}/rebuild"},
+		{Name: "vmRebuild", Path: "virtual-machines/{name}/rebuild"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceRebuildPost, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
+// swagger:operation POST /1.0/instances/{name}/rebuild instances instance_rebuild_post
+//
+// Rebuild an instance
+//
+// Recreates the root volume of a stopped instance from a new image (or empty), keeping its configuration,
+// devices and volatile keys such as NIC MAC addresses.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: instance
+//     description: Rebuild request
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstanceRebuildPost"
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceRebuildPost(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if shared.IsSnapshot(name) {
+		return response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	req := api.InstanceRebuildPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !shared.StringInSlice(req.Source.Type, []string{"image", "none"}) {
+		return response.BadRequest(fmt.Errorf("Invalid rebuild source type %q", req.Source.Type))
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = instanceTrashedCheck(inst)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.IsRunning() {
+		return response.BadRequest(api.ErrorWithType(api.ErrorTypeInstanceRunning, fmt.Errorf("The instance must be stopped to be rebuilt")))
+	}
+
+	var hash string
+	if req.Source.Type == "image" {
+		hash, err = instance.ResolveImage(d.State(), projectName, req.Source)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
+	run := func(op *operations.Operation) error {
+		inst.SetOperation(op)
+
+		if req.Source.Type == "none" {
+			return inst.Rebuild(nil, op)
+		}
+
+		img, err := instanceImageFromSource(d, r, op, projectName, hash, req.Source, api.InstanceType(inst.Type().String()))
+		if err != nil {
+			return err
+		}
+
+		if img.Type != inst.Type().String() {
+			return fmt.Errorf("Requested image's type %q doesn't match instance type %q", img.Type, inst.Type())
+		}
+
+		err = instanceImageEnsureLocal(d, r, projectName, img.Fingerprint)
+		if err != nil {
+			return err
+		}
+
+		err = d.db.Cluster.UpdateImageLastUseDate(img.Fingerprint, time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("Error updating image last use date: %w", err)
+		}
+
+		return inst.Rebuild(img, op)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceRebuild, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/validate"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// instanceSecretLoad loads the instance targeted by the request, forwarding the request if it's located on
+// another cluster member.
+func instanceSecretLoad(d *Daemon, r *http.Request) (instance.Instance, response.Response) {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	projectName := projectParam(r)
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if shared.IsSnapshot(name) {
+		return nil, response.BadRequest(fmt.Errorf("Invalid instance name"))
+	}
+
+	// Handle requests targeted to an instance on a different node.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instanceType)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	if resp != nil {
+		return nil, resp
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), projectName, name)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	return inst, nil
+}
+
+// swagger:operation GET /1.0/instances/{name}/secrets instances instance_secrets_get
+//
+// Get the secrets
+//
+// Returns a list of the secrets staged for the instance and not yet retrieved (URLs).
+// Secret values can only be retrieved from inside the instance.
+// For virtual machines, this includes the secrets held by the running agent.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/instances/foo/secrets/db-password"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceSecretsGet(d *Daemon, r *http.Request) response.Response {
+	inst, resp := instanceSecretLoad(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	names, err := d.db.Cluster.GetInstanceSecretNames(inst.ID())
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.Type() == instancetype.VM {
+		agentNames, err := inst.(instance.VM).AgentSecretNames()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		for _, name := range agentNames {
+			if !shared.StringInSlice(name, names) {
+				names = append(names, name)
+			}
+		}
+
+		sort.Strings(names)
+	}
+
+	urls := make([]string, 0, len(names))
+	for _, name := range names {
+		urls = append(urls, api.NewURL().Path(version.APIVersion, "instances", inst.Name(), "secrets", name).String())
+	}
+
+	return response.SyncResponse(true, urls)
+}
+
+// swagger:operation POST /1.0/instances/{name}/secrets instances instance_secrets_post
+//
+// Stage a secret
+//
+// Stages a secret for the instance, retrievable exactly once from inside the
+// instance through /dev/lxd. A secret with the same name is replaced.
+// For virtual machines, the secret is handed over to the agent as soon as
+// it's running. Secrets held by the agent are lost when the virtual machine stops.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: secret
+//     description: Secret
+//     required: true
+//     schema:
+//       $ref: "#/definitions/InstanceSecretsPost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceSecretsPost(d *Daemon, r *http.Request) response.Response {
+	inst, resp := instanceSecretLoad(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	req := api.InstanceSecretsPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = validate.IsNotEmpty(req.Name)
+	if err == nil {
+		err = validate.IsURLSegmentSafe(req.Name)
+	}
+
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid secret name: %w", err))
+	}
+
+	err = d.db.Cluster.CreateInstanceSecret(inst.ID(), req.Name, req.Value)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.Type() == instancetype.VM {
+		err = inst.(instance.VM).AgentSecretsDeliver()
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation DELETE /1.0/instances/{name}/secrets/{secret} instances instance_secret_delete
+//
+// Delete a secret
+//
+// Deletes a secret staged for the instance before it's retrieved.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func instanceSecretDelete(d *Daemon, r *http.Request) response.Response {
+	inst, resp := instanceSecretLoad(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	secretName, err := url.PathUnescape(mux.Vars(r)["secretName"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = d.db.Cluster.DeleteInstanceSecret(inst.ID(), secretName)
+	if err != nil && (inst.Type() != instancetype.VM || !api.StatusErrorCheck(err, http.StatusNotFound)) {
+		return response.SmartError(err)
+	}
+
+	// The secret may already have been handed over to the agent of the virtual machine.
+	if err != nil {
+		found, err := inst.(instance.VM).AgentSecretDelete(secretName)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if !found {
+			return response.NotFound(fmt.Errorf("Instance secret not found"))
+		}
+	}
+
+	return response.EmptySyncResponse
+}
@@ -177,6 +177,11 @@ func instanceStatePut(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	err = instanceTrashedCheck(inst)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	// Actually perform the change.
 	opType, err := instanceActionToOptype(req.Action)
 	if err != nil {
@@ -211,6 +216,10 @@ func instanceActionToOptype(action string) (db.OperationType, error) {
 		return db.OperationInstanceFreeze, nil
 	case shared.Unfreeze:
 		return db.OperationInstanceUnfreeze, nil
+	case shared.NetworkPause:
+		return db.OperationInstanceNetworkPause, nil
+	case shared.NetworkResume:
+		return db.OperationInstanceNetworkResume, nil
 	}
 
 	return db.OperationUnknown, fmt.Errorf("Unknown action: '%s'", action)
@@ -239,6 +248,10 @@ func doInstanceStatePut(inst instance.Instance, req api.InstanceStatePut) error
 		return inst.Freeze()
 	case shared.Unfreeze:
 		return inst.Unfreeze()
+	case shared.NetworkPause:
+		return inst.NetworkPause()
+	case shared.NetworkResume:
+		return inst.NetworkResume()
 	}
 
 	return fmt.Errorf("Unknown action: '%s'", req.Action)
@@ -201,6 +201,29 @@ var instanceBackupExportCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: instanceBackupExportGet, AccessHandler: allowProjectPermission("containers", "view")},
 }
 
+var instanceSecretsCmd = APIEndpoint{
+	Name: "instanceSecrets",
+	Path: "instances/{name}/secrets",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerSecrets", Path: "containers/{name}/secrets"},
+		{Name: "vmSecrets", Path: "virtual-machines/{name}/secrets"},
+	},
+
+	Get:  APIEndpointAction{Handler: instanceSecretsGet, AccessHandler: allowProjectPermission("containers", "view")},
+	Post: APIEndpointAction{Handler: instanceSecretsPost, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
+var instanceSecretCmd = APIEndpoint{
+	Name: "instanceSecret",
+	Path: "instances/{name}/secrets/{secretName}",
+	Aliases: []APIEndpointAlias{
+		{Name: "containerSecret", Path: "containers/{name}/secrets/{secretName}"},
+		{Name: "vmSecret", Path: "virtual-machines/{name}/secrets/{secretName}"},
+	},
+
+	Delete: APIEndpointAction{Handler: instanceSecretDelete, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
 type instanceAutostartList []instance.Instance
 
 func (slice instanceAutostartList) Len() int {
@@ -230,7 +253,18 @@ func instancesStart(s *state.State, instances []instance.Instance) {
 	instancesStartMu.Lock()
 	defer instancesStartMu.Unlock()
 
-	sort.Sort(instanceAutostartList(instances))
+	// Start the dependencies of instances first, then by autostart priority.
+	depths := instanceDependencyDepths(instances, false)
+	sort.SliceStable(instances, func(i, j int) bool {
+		iDepth := depths[project.Instance(instances[i].Project(), instances[i].Name())]
+		jDepth := depths[project.Instance(instances[j].Project(), instances[j].Name())]
+
+		if iDepth != jDepth {
+			return iDepth < jDepth
+		}
+
+		return instanceAutostartList(instances).Less(i, j)
+	})
 
 	maxAttempts := 3
 
@@ -248,6 +282,11 @@ func instancesStart(s *state.State, instances []instance.Instance) {
 			continue
 		}
 
+		// Trashed instances are left alone until they get restored.
+		if instanceIsTrashed(inst) {
+			continue
+		}
+
 		// If already running, we're done.
 		if inst.IsRunning() {
 			continue
@@ -326,6 +365,72 @@ func (slice instanceStopList) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
 
+// instanceDependencyDepths returns the length of the longest chain of boot.depends dependencies of each
+// instance within the list, keyed by project.Instance name. With reverse set, the chain of instances
+// depending on each instance is measured instead. Dependencies on instances outside the list are ignored
+// and so are the dependencies closing a cycle.
+func instanceDependencyDepths(instances []instance.Instance, reverse bool) map[string]int {
+	edges := make(map[string][]string, len(instances))
+	for _, inst := range instances {
+		edges[project.Instance(inst.Project(), inst.Name())] = nil
+	}
+
+	for _, inst := range instances {
+		name := project.Instance(inst.Project(), inst.Name())
+
+		for _, depName := range shared.SplitNTrimSpace(inst.ExpandedConfig()["boot.depends"], ",", -1, true) {
+			dep := project.Instance(inst.Project(), depName)
+
+			_, found := edges[dep]
+			if !found || dep == name {
+				continue
+			}
+
+			if reverse {
+				edges[dep] = append(edges[dep], name)
+			} else {
+				edges[name] = append(edges[name], dep)
+			}
+		}
+	}
+
+	depths := make(map[string]int, len(instances))
+	visiting := make(map[string]bool, len(instances))
+
+	var depth func(name string) int
+	depth = func(name string) int {
+		d, found := depths[name]
+		if found {
+			return d
+		}
+
+		visiting[name] = true
+
+		for _, next := range edges[name] {
+			if visiting[next] {
+				logger.Warn("Ignoring cyclic instance dependency", logger.Ctx{"instance": name, "dependency": next})
+				continue
+			}
+
+			nextDepth := depth(next) + 1
+			if nextDepth > d {
+				d = nextDepth
+			}
+		}
+
+		visiting[name] = false
+		depths[name] = d
+
+		return d
+	}
+
+	for name := range edges {
+		depth(name)
+	}
+
+	return depths
+}
+
 // Return all local instances on disk (if instance is running, it will attempt to populate the instance's local
 // and expanded config using the backup.yaml file). It will clear the instance's profiles property to avoid needing
 // to enrich them from the database.
@@ -393,31 +498,55 @@ func instancesOnDisk(s *state.State) ([]instance.Instance, error) {
 }
 
 func instancesShutdown(s *state.State, instances []instance.Instance) {
-	var wg sync.WaitGroup
+	// Stop instances before the ones they depend on, then by stop priority.
+	depths := instanceDependencyDepths(instances, true)
+	depthOf := func(inst instance.Instance) int {
+		return depths[project.Instance(inst.Project(), inst.Name())]
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		iDepth := depthOf(instances[i])
+		jDepth := depthOf(instances[j])
 
-	sort.Sort(instanceStopList(instances))
+		if iDepth != jDepth {
+			return iDepth < jDepth
+		}
 
-	var lastPriority int
+		return instanceStopList(instances).Less(i, j)
+	})
 
-	if len(instances) != 0 {
-		lastPriority, _ = strconv.Atoi(instances[0].ExpandedConfig()["boot.stop.priority"])
-	}
+	// Split the running instances into groups which are stopped concurrently.
+	var groups [][]instance.Instance
+	var lastDepth, lastPriority int
 
 	for _, inst := range instances {
+		if !inst.IsRunning() {
+			continue
+		}
+
+		depth := depthOf(inst)
 		priority, _ := strconv.Atoi(inst.ExpandedConfig()["boot.stop.priority"])
 
-		// Enforce shutdown priority
-		if priority != lastPriority {
+		if len(groups) == 0 || depth != lastDepth || priority != lastPriority {
+			lastDepth = depth
 			lastPriority = priority
-
-			// Wait for instances with higher priority to finish
-			wg.Wait()
+			groups = append(groups, nil)
 		}
 
-		// Stop the instance if running.
-		if inst.IsRunning() {
+		groups[len(groups)-1] = append(groups[len(groups)-1], inst)
+	}
+
+	for i, group := range groups {
+		logger.Info("Stopping instance group", logger.Ctx{"group": i + 1, "groups": len(groups), "instances": len(group)})
+
+		// Each instance is given its own shutdown timeout before being forcefully stopped, so the group
+		// takes at most as long as the largest of them.
+		var wg sync.WaitGroup
+		for _, inst := range group {
 			wg.Add(1)
 			go func(inst instance.Instance) {
+				defer wg.Done()
+
 				// Determine how long to wait for the instance to shutdown cleanly.
 				timeoutSeconds := 30
 				value, ok := inst.ExpandedConfig()["boot.host_shutdown_timeout"]
@@ -440,10 +569,9 @@ func instancesShutdown(s *state.State, instances []instance.Instance) {
 					// when LXD restarts the instance will be started again.
 					_ = inst.VolatileSet(map[string]string{"volatile.last_state.power": "RUNNING"})
 				}
-
-				wg.Done()
 			}(inst)
 		}
+
+		wg.Wait()
 	}
-	wg.Wait()
 }
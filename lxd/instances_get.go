@@ -303,6 +303,30 @@ func doInstancesGet(d *Daemon, r *http.Request) (any, error) {
 			return err
 		}
 
+		// Trashed instances are only listed through the trash API.
+		trashedInstances, err := tx.GetTrashedInstances(filteredProjects)
+		if err != nil {
+			return err
+		}
+
+		if len(trashedInstances) > 0 {
+			trashed := make(map[[2]string]bool, len(trashedInstances))
+			for _, inst := range trashedInstances {
+				trashed[[2]string{inst.Project, inst.Name}] = true
+			}
+
+			for address, projectsInstances := range nodesProjectsInstances {
+				visible := make([][2]string, 0, len(projectsInstances))
+				for _, projectInstance := range projectsInstances {
+					if !trashed[projectInstance] {
+						visible = append(visible, projectInstance)
+					}
+				}
+
+				nodesProjectsInstances[address] = visible
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
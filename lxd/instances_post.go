@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dustinkirkland/golang-petname"
@@ -31,14 +32,19 @@ import (
 	"github.com/lxc/lxd/lxd/request"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/osarch"
 )
 
-func createFromImage(d *Daemon, r *http.Request, projectName string, req *api.InstancesPost) response.Response {
+// instanceCreateNamesLock serializes the generation of names for new instances.
+var instanceCreateNamesLock sync.Mutex
+
+func createFromImage(d *Daemon, r *http.Request, projectName string, req *api.InstancesPost, names []string) response.Response {
 	if d.db.Cluster.LocalNodeIsEvacuated() {
 		return response.Forbidden(fmt.Errorf("Cluster member is evacuated"))
 	}
@@ -54,113 +60,176 @@ func createFromImage(d *Daemon, r *http.Request, projectName string, req *api.In
 	}
 
 	run := func(op *operations.Operation) error {
-		args := db.InstanceArgs{
-			Project:     projectName,
-			Config:      req.Config,
-			Type:        dbType,
-			Description: req.Description,
-			Devices:     deviceConfig.NewDevices(req.Devices),
-			Ephemeral:   req.Ephemeral,
-			Name:        req.Name,
-			Profiles:    req.Profiles,
-		}
-
-		err := instance.ValidName(args.Name, args.Snapshot)
+		for _, name := range names {
+			err := instance.ValidName(name, false)
+			if err != nil {
+				return err
+			}
+		}
+
+		info, err := instanceImageFromSource(d, r, op, projectName, hash, req.Source, req.Type)
 		if err != nil {
 			return err
 		}
 
-		var info *api.Image
-		if req.Source.Server != "" {
-			var autoUpdate bool
-			var p *api.Project
-			err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-				project, err := dbCluster.GetProject(ctx, tx.Tx(), projectName)
-				if err != nil {
-					return err
-				}
-
-				p, err = project.ToAPI(ctx, tx.Tx())
+		architecture, err := osarch.ArchitectureId(info.Architecture)
+		if err != nil {
+			return err
+		}
 
-				return err
-			})
-			if err != nil {
-				return err
+		return instanceCreateEach(d, projectName, names, func(name string) error {
+			args := db.InstanceArgs{
+				Project:      projectName,
+				Architecture: architecture,
+				Config:       util.CopyConfig(req.Config),
+				Type:         dbType,
+				Description:  req.Description,
+				Devices:      deviceConfig.NewDevices(req.Devices),
+				Ephemeral:    req.Ephemeral,
+				Name:         name,
+				Profiles:     req.Profiles,
 			}
 
-			if p.Config["images.auto_update_cached"] != "" {
-				autoUpdate = shared.IsTrue(p.Config["images.auto_update_cached"])
-			} else {
-				autoUpdate, err = clusterConfig.GetBool(d.db.Cluster, "images.auto_update_cached")
-				if err != nil {
-					return err
-				}
-			}
+			_, err := instanceCreateFromImage(d, r, args, info.Fingerprint, op)
+			return err
+		})
+	}
 
-			// Detect image type based on instance type requested.
-			imgType := "container"
-			if req.Type == "virtual-machine" {
-				imgType = "virtual-machine"
-			}
+	resources := map[string][]string{}
+	resources["instances"] = names
 
-			var budget int64
-			err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-				budget, err = project.GetImageSpaceBudget(tx, projectName)
-				return err
-			})
+	if dbType == instancetype.Container {
+		resources["containers"] = resources["instances"]
+	}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceCreate, resources, instanceCreateMetadata(r, names), run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// instanceCreateEach calls create for each of the instance names, deleting the instances already created if one
+// of them fails so that either all or none of the instances exist.
+func instanceCreateEach(d *Daemon, projectName string, names []string, create func(name string) error) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	for _, name := range names {
+		err := create(name)
+		if err != nil {
+			return err
+		}
+
+		instName := name
+		revert.Add(func() {
+			inst, err := instance.LoadByProjectAndName(d.State(), projectName, instName)
 			if err != nil {
-				return err
+				return
 			}
 
-			info, err = d.ImageDownload(r, op, &ImageDownloadArgs{
-				Server:       req.Source.Server,
-				Protocol:     req.Source.Protocol,
-				Certificate:  req.Source.Certificate,
-				Secret:       req.Source.Secret,
-				Alias:        hash,
-				SetCached:    true,
-				Type:         imgType,
-				AutoUpdate:   autoUpdate,
-				Public:       false,
-				PreferCached: true,
-				ProjectName:  projectName,
-				Budget:       budget,
-			})
+			err = inst.Delete(true)
 			if err != nil {
-				return err
+				logger.Warn("Failed deleting instance after failed bulk creation", logger.Ctx{"project": projectName, "instance": instName, "err": err})
 			}
-		} else {
-			_, info, err = d.db.Cluster.GetImage(hash, db.ImageFilter{Project: &projectName})
+		})
+	}
+
+	revert.Success()
+	return nil
+}
+
+// instanceCreateMetadata returns the operation metadata for an instance creation, listing the names of the
+// instances being created alongside the placement decision.
+func instanceCreateMetadata(r *http.Request, names []string) map[string]any {
+	metadata := map[string]any{"instances": names}
+
+	placement, ok := instancePlacementMetadata(r).(map[string]any)
+	if ok {
+		for k, v := range placement {
+			metadata[k] = v
+		}
+	}
+
+	return metadata
+}
+
+// instanceImageFromSource returns the image to create an instance from, downloading it first when the source
+// refers to a remote server.
+func instanceImageFromSource(d *Daemon, r *http.Request, op *operations.Operation, projectName string, hash string, source api.InstanceSource, instType api.InstanceType) (*api.Image, error) {
+	var info *api.Image
+	var err error
+
+	if source.Server != "" {
+		var autoUpdate bool
+		var p *api.Project
+		err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			project, err := dbCluster.GetProject(ctx, tx.Tx(), projectName)
 			if err != nil {
 				return err
 			}
-		}
 
-		args.Architecture, err = osarch.ArchitectureId(info.Architecture)
-		if err != nil {
+			p, err = project.ToAPI(ctx, tx.Tx())
+
 			return err
+		})
+		if err != nil {
+			return nil, err
 		}
 
-		_, err = instanceCreateFromImage(d, r, args, info.Fingerprint, op)
-		return err
-	}
+		if p.Config["images.auto_update_cached"] != "" {
+			autoUpdate = shared.IsTrue(p.Config["images.auto_update_cached"])
+		} else {
+			autoUpdate, err = clusterConfig.GetBool(d.db.Cluster, "images.auto_update_cached")
+			if err != nil {
+				return nil, err
+			}
+		}
 
-	resources := map[string][]string{}
-	resources["instances"] = []string{req.Name}
+		// Detect image type based on instance type requested.
+		imgType := "container"
+		if instType == "virtual-machine" {
+			imgType = "virtual-machine"
+		}
 
-	if dbType == instancetype.Container {
-		resources["containers"] = resources["instances"]
-	}
+		var budget int64
+		err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			budget, err = project.GetImageSpaceBudget(tx, projectName)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
 
-	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceCreate, resources, nil, run, nil, nil, r)
-	if err != nil {
-		return response.InternalError(err)
+		info, err = d.ImageDownload(r, op, &ImageDownloadArgs{
+			Server:       source.Server,
+			Protocol:     source.Protocol,
+			Certificate:  source.Certificate,
+			Secret:       source.Secret,
+			Alias:        hash,
+			SetCached:    true,
+			Type:         imgType,
+			AutoUpdate:   autoUpdate,
+			Public:       false,
+			PreferCached: true,
+			ProjectName:  projectName,
+			Budget:       budget,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		_, info, err = d.db.Cluster.GetImage(hash, db.ImageFilter{Project: &projectName})
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return operations.OperationResponse(op)
+	return info, nil
 }
 
-func createFromNone(d *Daemon, r *http.Request, projectName string, req *api.InstancesPost) response.Response {
+func createFromNone(d *Daemon, r *http.Request, projectName string, req *api.InstancesPost, names []string) response.Response {
 	if d.db.Cluster.LocalNodeIsEvacuated() {
 		return response.Forbidden(fmt.Errorf("Cluster member is evacuated"))
 	}
@@ -170,38 +239,41 @@ func createFromNone(d *Daemon, r *http.Request, projectName string, req *api.Ins
 		return response.BadRequest(err)
 	}
 
-	args := db.InstanceArgs{
-		Project:     projectName,
-		Config:      req.Config,
-		Type:        dbType,
-		Description: req.Description,
-		Devices:     deviceConfig.NewDevices(req.Devices),
-		Ephemeral:   req.Ephemeral,
-		Name:        req.Name,
-		Profiles:    req.Profiles,
-	}
-
+	var architecture int
 	if req.Architecture != "" {
-		architecture, err := osarch.ArchitectureId(req.Architecture)
+		architecture, err = osarch.ArchitectureId(req.Architecture)
 		if err != nil {
 			return response.InternalError(err)
 		}
-		args.Architecture = architecture
 	}
 
 	run := func(op *operations.Operation) error {
-		_, err := instanceCreateAsEmpty(d, args)
-		return err
+		return instanceCreateEach(d, projectName, names, func(name string) error {
+			args := db.InstanceArgs{
+				Project:      projectName,
+				Architecture: architecture,
+				Config:       util.CopyConfig(req.Config),
+				Type:         dbType,
+				Description:  req.Description,
+				Devices:      deviceConfig.NewDevices(req.Devices),
+				Ephemeral:    req.Ephemeral,
+				Name:         name,
+				Profiles:     req.Profiles,
+			}
+
+			_, err := instanceCreateAsEmpty(d, args)
+			return err
+		})
 	}
 
 	resources := map[string][]string{}
-	resources["instances"] = []string{req.Name}
+	resources["instances"] = names
 
 	if dbType == instancetype.Container {
 		resources["containers"] = resources["instances"]
 	}
 
-	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceCreate, resources, nil, run, nil, nil, r)
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceCreate, resources, instanceCreateMetadata(r, names), run, nil, nil, r)
 	if err != nil {
 		return response.InternalError(err)
 	}
@@ -423,7 +495,7 @@ func createFromMigration(d *Daemon, r *http.Request, projectName string, req *ap
 			return response.InternalError(err)
 		}
 	} else {
-		op, err = operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceCreate, resources, nil, run, nil, nil, r)
+		op, err = operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceCreate, resources, instancePlacementMetadata(r), run, nil, nil, r)
 		if err != nil {
 			return response.InternalError(err)
 		}
@@ -491,7 +563,7 @@ func createFromCopy(d *Daemon, r *http.Request, projectName string, req *api.Ins
 				return response.SmartError(err)
 			}
 
-			if pool.Driver != "ceph" {
+			if !shared.StringInSlice(pool.Driver, db.StorageRemoteDriverNames()) {
 				// Redirect to migration
 				return clusterCopyContainerInternal(d, r, source, projectName, req)
 			}
@@ -596,7 +668,7 @@ func createFromCopy(d *Daemon, r *http.Request, projectName string, req *api.Ins
 		resources["containers"] = resources["instances"]
 	}
 
-	op, err := operations.OperationCreate(d.State(), targetProject, operations.OperationClassTask, db.OperationInstanceCreate, resources, nil, run, nil, nil, r)
+	op, err := operations.OperationCreate(d.State(), targetProject, operations.OperationClassTask, db.OperationInstanceCreate, resources, instancePlacementMetadata(r), run, nil, nil, r)
 	if err != nil {
 		return response.InternalError(err)
 	}
@@ -604,6 +676,35 @@ func createFromCopy(d *Daemon, r *http.Request, projectName string, req *api.Ins
 	return operations.OperationResponse(op)
 }
 
+// createFromS3Backup imports the backup stored under the requested key in the configured S3 bucket.
+func createFromS3Backup(d *Daemon, r *http.Request, projectName string, req *api.InstancesPost) response.Response {
+	if req.Source.ObjectKey == "" {
+		return response.BadRequest(fmt.Errorf("Must specify the object key of the backup"))
+	}
+
+	target := backup.NewS3Target(d.State())
+	err := target.Validate()
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Use the pool of the requested root disk if any.
+	pool := ""
+	_, rootDev, err := shared.GetRootDiskDevice(req.Devices)
+	if err == nil {
+		pool = rootDev["pool"]
+	}
+
+	reader, _, err := target.Download(req.Source.ObjectKey)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	defer func() { _ = reader.Close() }()
+
+	return createFromBackup(d, r, projectName, reader, pool, req.Name)
+}
+
 func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Reader, pool string, instanceName string) response.Response {
 	revert := revert.New()
 	defer revert.Fail()
@@ -616,12 +717,20 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 	defer func() { _ = os.Remove(backupFile.Name()) }()
 	revert.Add(func() { _ = backupFile.Close() })
 
-	// Stream uploaded backup data into temporary file.
-	_, err = io.Copy(backupFile, data)
+	// Stream uploaded backup data into temporary file, verifying it against its manifest at the same time.
+	verifier := backup.NewManifestVerifier(d.State().OS, backupFile.Name())
+	_, err = io.Copy(io.MultiWriter(backupFile, verifier), data)
 	if err != nil {
+		_, _ = verifier.Verify()
 		return response.InternalError(err)
 	}
 
+	logger.Debug("Verifying backup file against its manifest")
+	manifestChecked, err := verifier.Verify()
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Detect squashfs compression and convert to tarball.
 	_, err = backupFile.Seek(0, 0)
 	if err != nil {
@@ -669,6 +778,16 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 	if err != nil {
 		return response.BadRequest(err)
 	}
+
+	// Backups which couldn't be verified while uploaded are verified once converted to a tarball.
+	if !manifestChecked {
+		logger.Debug("Verifying converted backup file against its manifest")
+		err = backup.VerifyManifest(backupFile, d.State().OS, backupFile.Name())
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	bInfo.Project = projectName
 
 	// Override pool.
@@ -718,6 +837,14 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 		return response.InternalError(err)
 	}
 
+	// Incremental backups are restored on top of the instance restored from the previous backup.
+	if bInfo.Incremental != nil {
+		err = checkIncrementalBackupTarget(d.State(), *bInfo)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	// Copy reverter so far so we can use it inside run after this function has finished.
 	runRevert := revert.Clone()
 
@@ -785,6 +912,47 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 	return operations.OperationResponse(op)
 }
 
+// checkIncrementalBackupTarget checks that the instance an incremental backup is restored on top of is stopped
+// and that its latest snapshot is the base snapshot the volume streams of the backup are relative to.
+func checkIncrementalBackupTarget(s *state.State, bInfo backup.Info) error {
+	inst, err := instance.LoadByProjectAndName(s, bInfo.Project, bInfo.Name)
+	if err != nil {
+		if response.IsNotFoundError(err) {
+			return fmt.Errorf("Incremental backup requires the instance %q restored from its previous backup %q", bInfo.Name, bInfo.Incremental.Parent)
+		}
+
+		return err
+	}
+
+	if inst.IsRunning() {
+		return fmt.Errorf("Instance %q must be stopped to restore an incremental backup on top of it", bInfo.Name)
+	}
+
+	poolName, err := inst.StoragePool()
+	if err != nil {
+		return err
+	}
+
+	if poolName != bInfo.Pool {
+		return fmt.Errorf("Instance %q is on storage pool %q rather than %q", bInfo.Name, poolName, bInfo.Pool)
+	}
+
+	snapshots, err := inst.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) > 0 {
+		latest := snapshots[len(snapshots)-1]
+		_, latestName, _ := shared.InstanceGetParentAndSnapshotName(latest.Name())
+		if latestName == bInfo.Incremental.Base && latest.CreationDate().Unix() == bInfo.Incremental.BaseCreatedAt.Unix() {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("The latest snapshot of instance %q isn't the base snapshot %q of the incremental backup", bInfo.Name, bInfo.Incremental.Base)
+}
+
 // swagger:operation POST /1.0/instances instances instances_post
 //
 // Create a new instance
@@ -792,7 +960,7 @@ func createFromBackup(d *Daemon, r *http.Request, projectName string, data io.Re
 // Creates a new instance on LXD.
 // Depending on the source, this can create an instance from an existing
 // local image, remote image, existing local instance or snapshot, remote
-// migration stream or backup file.
+// migration stream, backup file or backup stored in S3.
 //
 // ---
 // consumes:
@@ -847,6 +1015,11 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
+	// Import backups stored in the configured S3 bucket like uploaded ones.
+	if req.Source.Type == "backup" {
+		return createFromS3Backup(d, r, targetProjectName, &req)
+	}
+
 	// Set type from URL if missing
 	urlType, err := urlInstanceTypeDetect(r)
 	if err != nil {
@@ -877,6 +1050,8 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	var placement []instancePlacementCandidate
+
 	// Check if clustered.
 	clustered, err := cluster.Enabled(d.db.Node)
 	if err != nil {
@@ -946,33 +1121,85 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 			return response.BadRequest(err)
 		}
 
-		err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-			defaultArch := ""
-			if targetProject.Config["images.default_architecture"] != "" {
-				defaultArch = targetProject.Config["images.default_architecture"]
-			} else {
-				defaultArch = s.GlobalConfig.ImagesDefaultArchitecture()
+		defaultArch := ""
+		if targetProject.Config["images.default_architecture"] != "" {
+			defaultArch = targetProject.Config["images.default_architecture"]
+		} else {
+			defaultArch = s.GlobalConfig.ImagesDefaultArchitecture()
+		}
+
+		defaultArchID := -1
+		if defaultArch != "" {
+			defaultArchID, err = osarch.ArchitectureId(defaultArch)
+			if err != nil {
+				return response.SmartError(err)
 			}
+		}
+
+		// Find the storage pool the root disk will be created on.
+		poolName, _, _, _, resp := instanceFindStoragePool(d, targetProjectName, &req)
+		if resp != nil {
+			return resp
+		}
 
-			defaultArchID := -1
-			if defaultArch != "" {
-				defaultArchID, err = osarch.ArchitectureId(defaultArch)
+		policy, err := instancePlacementPolicy(d, targetProjectName, targetProject.Config, &req)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if poolName != "" || policy != "" {
+			// Only consider the members which have the storage pool and enough free space in it.
+			var rootDiskSize int64
+			if poolName != "" {
+				rootDiskSize, err = instancePlacementRootDiskSize(d, targetProjectName, &req, poolName)
 				if err != nil {
-					return err
+					return response.SmartError(err)
 				}
 			}
 
-			var err error
-			targetNode, err = tx.GetNodeWithLeastInstances(architectures, defaultArchID, group, allowedGroups)
-			return err
-		})
-		if err != nil {
-			return response.SmartError(err)
+			targetNode, placement, err = instancePlacement(d, r, poolName, rootDiskSize, policy, architectures, defaultArchID, group, allowedGroups)
+			if err != nil {
+				return response.SmartError(err)
+			}
+		} else {
+			err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+				var err error
+				targetNode, err = tx.GetNodeWithLeastInstances(architectures, defaultArchID, group, allowedGroups)
+				return err
+			})
+			if err != nil {
+				return response.SmartError(err)
+			}
 		}
 
 		if targetNode == "" {
+			reasons := []string{}
+			for _, candidate := range placement {
+				reasons = append(reasons, fmt.Sprintf("%s: %s", candidate.Member, candidate.Reason))
+			}
+
+			if len(reasons) > 0 {
+				return response.BadRequest(fmt.Errorf("No suitable cluster member could be found (%s)", strings.Join(reasons, ", ")))
+			}
+
 			return response.BadRequest(fmt.Errorf("No suitable cluster member could be found"))
 		}
+
+		// Record the placement decision so it can be inspected in the operation metadata.
+		r = r.WithContext(context.WithValue(r.Context(), instancePlacementCtxKey, placement))
+	} else if clustered && !isClusterNotification(r) {
+		// Check the targeted member has the storage pool before starting the creation.
+		poolName, _, _, _, resp := instanceFindStoragePool(d, targetProjectName, &req)
+		if resp != nil {
+			return resp
+		}
+
+		if poolName != "" {
+			err = instancePlacementCheckTarget(d, poolName, targetNode)
+			if err != nil {
+				return response.SmartError(err)
+			}
+		}
 	}
 
 	if targetNode != "" {
@@ -996,6 +1223,14 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 			}
 
 			opAPI := op.Get()
+			if placement != nil {
+				if opAPI.Metadata == nil {
+					opAPI.Metadata = map[string]any{}
+				}
+
+				opAPI.Metadata["placement"] = placement
+			}
+
 			return operations.ForwardedOperationResponse(targetProjectName, &opAPI)
 		}
 	}
@@ -1025,6 +1260,22 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Invalid instance name: %q is reserved for snapshots", shared.SnapshotDelimiter))
 	}
 
+	if req.Count < 0 {
+		return response.BadRequest(fmt.Errorf("Invalid instance count %d", req.Count))
+	}
+
+	if req.Count > 1 && !shared.StringInSlice(req.Source.Type, []string{"image", "none"}) {
+		return response.BadRequest(fmt.Errorf("Creating multiple instances is only supported for %q and %q sources", "image", "none"))
+	}
+
+	// Names are generated against the database and the creations still in flight, so serialize generation
+	// until the operation holding the new names has been registered.
+	var names []string
+	if req.Name == "" || req.Count > 1 {
+		instanceCreateNamesLock.Lock()
+		defer instanceCreateNamesLock.Unlock()
+	}
+
 	// Check that the project's limits are not violated. Also, possibly
 	// automatically assign a name.
 	//
@@ -1058,27 +1309,21 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 			return err
 		}
 
-		if req.Name == "" {
-			names, err := tx.GetInstanceNames(targetProjectName)
+		if req.Name == "" || req.Count > 1 {
+			names, err = instanceCreateNames(tx, targetProjectName, req)
 			if err != nil {
 				return err
 			}
 
-			i := 0
-			for {
-				i++
-				req.Name = strings.ToLower(petname.Generate(2, "-"))
-				if !shared.StringInSlice(req.Name, names) {
-					break
-				}
-
-				if i > 100 {
-					return fmt.Errorf("Couldn't generate a new unique name after 100 tries")
-				}
+			if req.Name == "" {
+				logger.Debugf("No name provided, creating %s", strings.Join(names, ", "))
 			}
 
-			logger.Debugf("No name provided, creating %s", req.Name)
+			req.Name = names[0]
+		} else {
+			names = []string{req.Name}
 		}
+
 		return nil
 	})
 	if err != nil {
@@ -1087,9 +1332,9 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 
 	switch req.Source.Type {
 	case "image":
-		return createFromImage(d, r, targetProjectName, &req)
+		return createFromImage(d, r, targetProjectName, &req, names)
 	case "none":
-		return createFromNone(d, r, targetProjectName, &req)
+		return createFromNone(d, r, targetProjectName, &req, names)
 	case "migration":
 		return createFromMigration(d, r, targetProjectName, &req)
 	case "copy":
@@ -1099,12 +1344,71 @@ func instancesPost(d *Daemon, r *http.Request) response.Response {
 	}
 }
 
+// instanceCreateNames returns the names of the instances to create for the request.
+// When a name is provided along with a count, the instances are named <name>-<index>, otherwise unique names are
+// generated. Names used by existing instances or by instance creations still in progress are never returned.
+func instanceCreateNames(tx *db.ClusterTx, projectName string, req api.InstancesPost) ([]string, error) {
+	existing, err := tx.GetInstanceNames(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Include the names reserved by running creation operations.
+	for _, op := range operations.Clone() {
+		if op.Type() != db.OperationInstanceCreate || op.Project() != projectName || op.Status().IsFinal() {
+			continue
+		}
+
+		existing = append(existing, op.Resources()["instances"]...)
+	}
+
+	count := req.Count
+	if count < 1 {
+		count = 1
+	}
+
+	names := make([]string, 0, count)
+	for i := 1; i <= count; i++ {
+		if req.Name != "" {
+			name := fmt.Sprintf("%s-%d", req.Name, i)
+			if shared.StringInSlice(name, existing) {
+				return nil, api.StatusErrorf(http.StatusConflict, "Instance %q already exists", name)
+			}
+
+			names = append(names, name)
+			continue
+		}
+
+		tries := 0
+		for {
+			tries++
+			name := strings.ToLower(petname.Generate(2, "-"))
+			if !shared.StringInSlice(name, existing) && !shared.StringInSlice(name, names) {
+				names = append(names, name)
+				break
+			}
+
+			if tries > 100 {
+				return nil, fmt.Errorf("Couldn't generate a new unique name after 100 tries")
+			}
+		}
+	}
+
+	return names, nil
+}
+
 func instanceFindStoragePool(d *Daemon, projectName string, req *api.InstancesPost) (string, string, string, map[string]string, response.Response) {
 	// Grab the container's root device if one is specified
 	storagePool := ""
 	storagePoolProfile := ""
 
-	localRootDiskDeviceKey, localRootDiskDevice, _ := shared.GetRootDiskDevice(req.Devices)
+	// Work on a copy of the root disk device as the request is used again after this.
+	localRootDiskDeviceKey, reqRootDiskDevice, _ := shared.GetRootDiskDevice(req.Devices)
+	localRootDiskDevice := make(map[string]string, len(reqRootDiskDevice))
+	for k, v := range reqRootDiskDevice {
+		localRootDiskDevice[k] = v
+	}
+
 	if localRootDiskDeviceKey != "" {
 		storagePool = localRootDiskDevice["pool"]
 	}
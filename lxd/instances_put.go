@@ -99,6 +99,11 @@ func instancesPut(d *Daemon, r *http.Request) response.Response {
 			continue
 		}
 
+		// Trashed instances are left alone until they get restored.
+		if instanceIsTrashed(inst) {
+			continue
+		}
+
 		switch action {
 		case shared.Freeze:
 			if !inst.IsRunning() {
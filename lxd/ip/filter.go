@@ -38,12 +38,47 @@ func (a *ActionPolice) AddAction() []string {
 	return result
 }
 
+// ActionMirred represents an action of 'mirred' type that mirrors packets to another device
+type ActionMirred struct {
+	Dev     string
+	Control string // Action control once the packet is mirrored (e.g. "continue").
+}
+
+// AddAction generates a part of command specific for 'mirred' action
+func (a *ActionMirred) AddAction() []string {
+	result := []string{"action", "mirred", "egress", "mirror", "dev", a.Dev}
+	if a.Control != "" {
+		result = append(result, a.Control)
+	}
+
+	return result
+}
+
 // Filter represents filter object
 type Filter struct {
 	Dev      string
 	Parent   string
 	Protocol string
 	Flowid   string
+	Pref     string
+}
+
+// Delete deletes the filters of a node matching the parent and preference
+func (filter *Filter) Delete() error {
+	cmd := []string{"filter", "del", "dev", filter.Dev}
+	if filter.Parent != "" {
+		cmd = append(cmd, "parent", filter.Parent)
+	}
+
+	if filter.Pref != "" {
+		cmd = append(cmd, "pref", filter.Pref)
+	}
+
+	_, err := shared.RunCommand("tc", cmd...)
+	if err != nil {
+		return err
+	}
+	return nil
 }
 
 // U32Filter represents universal 32bit traffic control filter
@@ -61,6 +96,10 @@ func (u32 *U32Filter) Add() error {
 		cmd = append(cmd, "parent", u32.Parent)
 	}
 
+	if u32.Pref != "" {
+		cmd = append(cmd, "pref", u32.Pref)
+	}
+
 	cmd = append(cmd, "protocol", u32.Protocol)
 	cmd = append(cmd, "u32", "match", "u32", u32.Value, u32.Mask)
 
@@ -71,6 +71,34 @@ func (l *Link) SetMTU(mtu string) error {
 	return nil
 }
 
+// SetPromisc sets the promiscuous mode of the link device
+func (l *Link) SetPromisc(promisc bool) error {
+	promiscState := "on"
+	if !promisc {
+		promiscState = "off"
+	}
+
+	_, err := shared.RunCommand("ip", "link", "set", "dev", l.Name, "promisc", promiscState)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetAllMulticast sets the all-multicast mode of the link device
+func (l *Link) SetAllMulticast(allMulticast bool) error {
+	allMulticastState := "on"
+	if !allMulticast {
+		allMulticastState = "off"
+	}
+
+	_, err := shared.RunCommand("ip", "link", "set", "dev", l.Name, "allmulticast", allMulticastState)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 // SetAddress sets the address of the link device
 func (l *Link) SetAddress(address string) error {
 	_, err := shared.RunCommand("ip", "link", "set", "dev", l.Name, "address", address)
@@ -116,6 +144,15 @@ func (l *Link) SetNetns(netns string) error {
 	return nil
 }
 
+// SetNetnsAndName moves the link to the selected network namespace and renames it there
+func (l *Link) SetNetnsAndName(netns string, newName string) error {
+	_, err := shared.RunCommand("ip", "link", "set", "dev", l.Name, "netns", netns, "name", newName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 // SetVfAddress changes the address for the specified vf
 func (l *Link) SetVfAddress(vf string, address string) error {
 	_, err := shared.TryRunCommand("ip", "link", "set", "dev", l.Name, "vf", vf, "mac", address)
@@ -379,3 +416,31 @@ func (l *Link) BridgeLinkSetHairpin(hairpin bool) error {
 	}
 	return nil
 }
+
+// BridgeLinkSetLearning sets bridge 'learning' attribute on a port
+func (l *Link) BridgeLinkSetLearning(learning bool) error {
+	learningState := "on"
+	if !learning {
+		learningState = "off"
+	}
+
+	_, err := shared.RunCommand("bridge", "link", "set", "dev", l.Name, "learning", learningState)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// BridgeLinkSetFlood sets bridge 'flood' and 'mcast_flood' attributes on a port
+func (l *Link) BridgeLinkSetFlood(flood bool) error {
+	floodState := "on"
+	if !flood {
+		floodState = "off"
+	}
+
+	_, err := shared.RunCommand("bridge", "link", "set", "dev", l.Name, "flood", floodState, "mcast_flood", floodState)
+	if err != nil {
+		return err
+	}
+	return nil
+}
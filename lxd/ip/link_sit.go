@@ -0,0 +1,37 @@
+package ip
+
+import (
+	"github.com/lxc/lxd/shared"
+)
+
+// Sit represents arguments for link of type sit
+type Sit struct {
+	Link
+	Local            string
+	SixRDPrefix      string
+	SixRDRelayPrefix string
+}
+
+// additionalArgs generates sit specific arguments
+func (s *Sit) additionalArgs() []string {
+	return []string{"local", s.Local, "ttl", "64"}
+}
+
+// Add adds new virtual link, configured as an IPv6 rapid deployment (6rd) tunnel if a 6rd prefix is set
+func (s *Sit) Add() error {
+	err := s.Link.add("sit", s.additionalArgs())
+	if err != nil {
+		return err
+	}
+
+	if s.SixRDPrefix == "" {
+		return nil
+	}
+
+	_, err = shared.RunCommand("ip", "tunnel", "6rd", "dev", s.Name, "6rd-prefix", s.SixRDPrefix, "6rd-relay_prefix", s.SixRDRelayPrefix)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package ip
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// XfrmState represents arguments for an IPsec ESP transport mode security association.
+// Traffic is encrypted with AES-CBC and authenticated with HMAC-SHA256. Extended sequence numbers are used along
+// with a replay window so that replayed packets are dropped.
+type XfrmState struct {
+	Src     string
+	Dst     string
+	SPI     uint32
+	ReqID   uint32
+	EncKey  []byte
+	AuthKey []byte
+}
+
+// Add adds the security association, replacing any existing one with the same source, destination and SPI.
+func (x *XfrmState) Add() error {
+	_ = x.Delete()
+
+	_, err := shared.RunCommand("ip", "xfrm", "state", "add",
+		"src", x.Src, "dst", x.Dst, "proto", "esp", "spi", fmt.Sprintf("0x%08x", x.SPI),
+		"reqid", fmt.Sprintf("%d", x.ReqID), "mode", "transport",
+		"enc", "cbc(aes)", fmt.Sprintf("0x%s", hex.EncodeToString(x.EncKey)),
+		"auth-trunc", "hmac(sha256)", fmt.Sprintf("0x%s", hex.EncodeToString(x.AuthKey)), "128",
+		"replay-window", "128", "flag", "esn")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete deletes the security association.
+func (x *XfrmState) Delete() error {
+	_, err := shared.RunCommand("ip", "xfrm", "state", "delete", "src", x.Src, "dst", x.Dst, "proto", "esp", "spi", fmt.Sprintf("0x%08x", x.SPI))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// XfrmStateFlush deletes all the security associations using the specified request ID.
+func XfrmStateFlush(reqID uint32) error {
+	_, err := shared.RunCommand("ip", "xfrm", "state", "deleteall", "proto", "esp", "reqid", fmt.Sprintf("%d", reqID))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// XfrmPolicy represents arguments for an IPsec policy requiring ESP transport mode for the selected traffic.
+type XfrmPolicy struct {
+	Src   string
+	Dst   string
+	Proto string
+	Port  string // Destination port of the selected traffic (UDP only).
+	Dir   string // Either "in" or "out".
+	ReqID uint32
+}
+
+func (x *XfrmPolicy) selector() []string {
+	args := []string{"src", x.Src, "dst", x.Dst, "proto", x.Proto}
+	if x.Port != "" {
+		args = append(args, "dport", x.Port)
+	}
+
+	return append(args, "dir", x.Dir)
+}
+
+// Add adds the policy or updates it if it already exists.
+func (x *XfrmPolicy) Add() error {
+	args := append([]string{"xfrm", "policy", "update"}, x.selector()...)
+	args = append(args, "tmpl", "proto", "esp", "reqid", fmt.Sprintf("%d", x.ReqID), "mode", "transport")
+
+	_, err := shared.RunCommand("ip", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete deletes the policy.
+func (x *XfrmPolicy) Delete() error {
+	_, err := shared.RunCommand("ip", append([]string{"xfrm", "policy", "delete"}, x.selector()...)...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
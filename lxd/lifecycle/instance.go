@@ -28,6 +28,7 @@ const (
 	InstancePaused           = InstanceAction("paused")
 	InstanceResumed          = InstanceAction("resumed")
 	InstanceRestored         = InstanceAction("restored")
+	InstanceRebuilt          = InstanceAction("rebuilt")
 	InstanceDeleted          = InstanceAction("deleted")
 	InstanceRenamed          = InstanceAction("renamed")
 	InstanceUpdated          = InstanceAction("updated")
@@ -38,6 +39,8 @@ const (
 	InstanceFileRetrieved    = InstanceAction("file-retrieved")
 	InstanceFilePushed       = InstanceAction("file-pushed")
 	InstanceFileDeleted      = InstanceAction("file-deleted")
+	InstanceNetworkPaused    = InstanceAction("network-paused")
+	InstanceNetworkResumed   = InstanceAction("network-resumed")
 )
 
 // Event creates the lifecycle event for an action on an instance.
@@ -23,6 +23,14 @@ const (
 	NetworkDeleted = NetworkAction("deleted")
 	NetworkUpdated = NetworkAction("updated")
 	NetworkRenamed = NetworkAction("renamed")
+
+	NetworkTunnelDown     = NetworkAction("tunnel-down")
+	NetworkTunnelUp       = NetworkAction("tunnel-up")
+	NetworkTunnelFailover = NetworkAction("tunnel-failover")
+
+	NetworkLeaseCreated = NetworkAction("lease-created")
+	NetworkLeaseRenewed = NetworkAction("lease-renewed")
+	NetworkLeaseExpired = NetworkAction("lease-expired")
 )
 
 // Event creates the lifecycle event for an action on a network device.
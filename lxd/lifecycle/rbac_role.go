@@ -0,0 +1,32 @@
+package lifecycle
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// RBACRoleAction represents a lifecycle event action for local RBAC roles.
+type RBACRoleAction string
+
+// All supported lifecycle events for local RBAC roles.
+const (
+	RBACRoleCreated = RBACRoleAction("created")
+	RBACRoleDeleted = RBACRoleAction("deleted")
+	RBACRoleUpdated = RBACRoleAction("updated")
+	RBACRoleRenamed = RBACRoleAction("renamed")
+)
+
+// Event creates the lifecycle event for an action on a local RBAC role.
+func (a RBACRoleAction) Event(name string, requestor *api.EventLifecycleRequestor, ctx map[string]any) api.EventLifecycle {
+	eventType := fmt.Sprintf("rbac-role-%s", a)
+	u := fmt.Sprintf("/1.0/rbac/roles/%s", url.PathEscape(name))
+
+	return api.EventLifecycle{
+		Action:    eventType,
+		Source:    u,
+		Context:   ctx,
+		Requestor: requestor,
+	}
+}
@@ -120,6 +120,10 @@ func main() {
 	forkconsoleCmd := cmdForkconsole{global: &globalCmd}
 	app.AddCommand(forkconsoleCmd.Command())
 
+	// forkdhcp sub-command
+	forkDHCPCmd := cmdForkDHCP{global: &globalCmd}
+	app.AddCommand(forkDHCPCmd.Command())
+
 	// forkdns sub-command
 	forkDNSCmd := cmdForkDNS{global: &globalCmd}
 	app.AddCommand(forkDNSCmd.Command())
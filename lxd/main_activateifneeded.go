@@ -135,6 +135,13 @@ func (c *cmdActivateifneeded) Run(cmd *cobra.Command, args []string) error {
 			_, err := lxd.ConnectLXDUnix("", nil)
 			return err
 		}
+
+		// Check for scheduled instance publishing
+		if config["publish.schedule"] != "" {
+			logger.Debugf("Daemon has scheduled instance publishing, activating...")
+			_, err := lxd.ConnectLXDUnix("", nil)
+			return err
+		}
 	}
 
 	// Check for scheduled volume snapshots
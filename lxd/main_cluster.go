@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"golang.org/x/sys/unix"
 	"gopkg.in/yaml.v2"
@@ -56,6 +57,10 @@ func (c *cmdCluster) Command() *cobra.Command {
 	clusterShow := cmdClusterShow{global: c.global}
 	cmd.AddCommand(clusterShow.Command())
 
+	// Restart all cluster members.
+	rollingRestart := cmdClusterRollingRestart{global: c.global}
+	cmd.AddCommand(rollingRestart.Command())
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
@@ -502,3 +507,72 @@ Do you want to proceed? (yes/no): `)
 	}
 	return nil
 }
+
+type cmdClusterRollingRestart struct {
+	global *cmdGlobal
+
+	flagTimeout int
+}
+
+func (c *cmdClusterRollingRestart) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "rolling-restart"
+	cmd.Short = "Restart the LXD daemon of all cluster members, one at a time"
+	cmd.Long = `Description:
+  Restart the LXD daemon of all cluster members, one at a time
+
+  Each member is restarted only once the previous one is back online and
+  only if the database quorum can be maintained while it restarts.
+  The local member is restarted last. Instances keep running throughout.
+`
+	cmd.RunE = c.Run
+	cmd.Flags().IntVarP(&c.flagTimeout, "timeout", "t", 300, "Number of seconds to wait for the local daemon to be back"+"``")
+
+	return cmd
+}
+
+func (c *cmdClusterRollingRestart) Run(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		_ = cmd.Help()
+		return fmt.Errorf("Unexpected arguments")
+	}
+
+	d, err := lxd.ConnectLXDUnix("", nil)
+	if err != nil {
+		return fmt.Errorf("Failed to connect to LXD daemon: %w", err)
+	}
+
+	// Restart the other members.
+	op, _, err := d.RawOperation("POST", "/internal/cluster/rolling-restart", nil, "")
+	if err != nil {
+		return err
+	}
+
+	err = op.Wait()
+	if err != nil {
+		return err
+	}
+
+	// Restart the local member.
+	_, _, err = d.RawQuery("POST", "/internal/restart", nil, "")
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(time.Duration(c.flagTimeout) * time.Second)
+	for {
+		time.Sleep(time.Second)
+
+		d, err := lxd.ConnectLXDUnix("", nil)
+		if err == nil {
+			_, _, err = d.RawQuery("GET", "/internal/ready", nil, "")
+			if err == nil {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("LXD daemon still not running after %ds timeout (%v)", c.flagTimeout, err)
+		}
+	}
+}
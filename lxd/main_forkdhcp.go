@@ -0,0 +1,97 @@
+// forkdhcp provides the builtin DHCPv4 and router advertisement server for bridge networks.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/lxd/dhcpd"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+type cmdForkDHCP struct {
+	global *cmdGlobal
+}
+
+func (c *cmdForkDHCP) Command() *cobra.Command {
+	// Main subcommand
+	cmd := &cobra.Command{}
+	cmd.Use = "forkdhcp <config path>"
+	cmd.Short = "Internal DHCP server for bridge networks"
+	cmd.Long = `Description:
+  Spawns the builtin DHCPv4 and IPv6 router advertisement server used by bridge networks
+  configured with dns.provider=builtin instead of dnsmasq.
+  Leases are recorded in the same format as the dnsmasq leases file and the static allocations
+  are read from the dnsmasq hosts directory of the network.
+  Sending SIGHUP reloads the configuration file.
+`
+	cmd.RunE = c.Run
+	cmd.Hidden = true
+
+	return cmd
+}
+
+func (c *cmdForkDHCP) Run(cmd *cobra.Command, args []string) error {
+	// Quick checks.
+	if len(args) < 1 {
+		_ = cmd.Help()
+
+		if len(args) == 0 {
+			return nil
+		}
+
+		return fmt.Errorf("Missing required arguments")
+	}
+
+	err := logger.InitLogger("", "lxd-forkdhcp", c.global.flagLogVerbose, c.global.flagLogDebug, nil)
+	if err != nil {
+		return err
+	}
+
+	configPath := args[0]
+	config, err := dhcpd.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	server, err := dhcpd.NewServer(config)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Reload the config on SIGHUP (static allocations are read on each request) and stop on SIGTERM.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, unix.SIGHUP, unix.SIGTERM, unix.SIGINT)
+	go func() {
+		for sig := range sigCh {
+			if sig != unix.SIGHUP {
+				cancel()
+				return
+			}
+
+			config, err := dhcpd.LoadConfig(configPath)
+			if err == nil {
+				err = server.Reload(config)
+			}
+
+			if err != nil {
+				logger.Error("Failed reloading config", logger.Ctx{"err": err})
+				continue
+			}
+
+			logger.Info("Reloaded config")
+		}
+	}()
+
+	logger.Info("Started")
+
+	return server.Run(ctx)
+}
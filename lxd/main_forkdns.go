@@ -3,6 +3,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/dnsutil"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -27,11 +29,40 @@ type cmdForkDNS struct {
 type dnsHandler struct {
 	domain    string
 	leaseFile string
+	listenIP  string
 }
 
 var dnsServersFileLock sync.Mutex
 var dnsServersList []string
 
+var dnsStatsLock sync.Mutex
+var dnsStats api.NetworkStateDNSForkdns
+var dnsUpstreamStats = map[string]*api.NetworkStateDNSForkdnsUpstream{}
+
+// statsUpdate applies the given function to the statistics safely.
+func statsUpdate(f func(stats *api.NetworkStateDNSForkdns)) {
+	dnsStatsLock.Lock()
+	f(&dnsStats)
+	dnsStatsLock.Unlock()
+}
+
+// statsUpstream records a query relayed to the specified server.
+func statsUpstream(server string, failed bool) {
+	dnsStatsLock.Lock()
+	defer dnsStatsLock.Unlock()
+
+	upstream, ok := dnsUpstreamStats[server]
+	if !ok {
+		upstream = &api.NetworkStateDNSForkdnsUpstream{Address: server}
+		dnsUpstreamStats[server] = upstream
+	}
+
+	upstream.Queries++
+	if failed {
+		upstream.Failures++
+	}
+}
+
 // serversFileMonitor performs an initial load of the server list and then waits for the file to be
 // modified before triggering a reload.
 func serversFileMonitor(watcher *fsnotify.Watcher, networkName string) {
@@ -79,6 +110,14 @@ func (h *dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 	msg := dns.Msg{}
 	msg.SetReply(r)
 
+	// Answer statistics queries separately so they aren't counted.
+	if len(r.Question) == 1 && r.Question[0].Qclass == dns.ClassCHAOS && r.Question[0].Name == network.ForkdnsStatsName {
+		h.handleStats(w, r)
+		return
+	}
+
+	statsUpdate(func(stats *api.NetworkStateDNSForkdns) { stats.Queries++ })
+
 	// We only support single questions for now
 	if len(r.Question) != 1 {
 		msg.SetRcode(r, dns.RcodeNameError)
@@ -106,12 +145,66 @@ func (h *dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
 		msg.SetRcode(r, dns.RcodeNameError)
 	}
 
+	if msg.Rcode == dns.RcodeNameError {
+		statsUpdate(func(stats *api.NetworkStateDNSForkdns) { stats.NotFound++ })
+	}
+
 	err = w.WriteMsg(&msg)
 	if err != nil {
 		logger.Errorf("Failed sending response for %s: %v", r.Question[0].Name, err)
 	}
 }
 
+// handleStats answers the statistics query with a TXT record containing the statistics as JSON.
+// Only queries coming from the host itself (using the listen address as source) are answered.
+func (h *dnsHandler) handleStats(w dns.ResponseWriter, r *dns.Msg) {
+	msg := dns.Msg{}
+	msg.SetReply(r)
+
+	remoteIP, _, err := net.SplitHostPort(w.RemoteAddr().String())
+	if err != nil || remoteIP != h.listenIP || r.Question[0].Qtype != dns.TypeTXT {
+		msg.SetRcode(r, dns.RcodeRefused)
+	} else {
+		dnsStatsLock.Lock()
+		stats := dnsStats
+		stats.Upstreams = make([]api.NetworkStateDNSForkdnsUpstream, 0, len(dnsUpstreamStats))
+		for _, upstream := range dnsUpstreamStats {
+			stats.Upstreams = append(stats.Upstreams, *upstream)
+		}
+
+		dnsStatsLock.Unlock()
+
+		data, err := json.Marshal(stats)
+		if err != nil {
+			msg.SetRcode(r, dns.RcodeServerFailure)
+		} else {
+			// TXT strings are limited to 255 bytes.
+			txt := []string{}
+			for len(data) > 255 {
+				txt = append(txt, string(data[:255]))
+				data = data[255:]
+			}
+
+			txt = append(txt, string(data))
+
+			msg.Answer = append(msg.Answer, &dns.TXT{
+				Hdr: dns.RR_Header{
+					Name:   r.Question[0].Name,
+					Rrtype: dns.TypeTXT,
+					Class:  dns.ClassCHAOS,
+					Ttl:    0,
+				},
+				Txt: txt,
+			})
+		}
+	}
+
+	err = w.WriteMsg(&msg)
+	if err != nil {
+		logger.Errorf("Failed sending stats response: %v", err)
+	}
+}
+
 // handlePTR answers requests for reverse DNS records.
 // It is used with cluster networking to provide cluster wide DNS PTR resolution by consulting the
 // local DHCP leases file and if not found, then relaying the question to the other cluster member's
@@ -132,6 +225,7 @@ func (h *dnsHandler) handlePTR(r *dns.Msg) (dns.Msg, error) {
 
 		// Record found in local DHCP leases file, generate answer response and send.
 		if hostname != "" {
+			statsUpdate(func(stats *api.NetworkStateDNSForkdns) { stats.LocalAnswers++ })
 			msg.Authoritative = true
 			msg.Answer = append(msg.Answer, &dns.PTR{
 				Hdr: dns.RR_Header{
@@ -161,6 +255,8 @@ func (h *dnsHandler) handlePTR(r *dns.Msg) (dns.Msg, error) {
 	servers := dnsServersList
 	dnsServersFileLock.Unlock()
 
+	statsUpdate(func(stats *api.NetworkStateDNSForkdns) { stats.RelayedQueries++ })
+
 	// Query all the servers.
 	for _, server := range servers {
 		req := dns.Msg{}
@@ -170,11 +266,13 @@ func (h *dnsHandler) handlePTR(r *dns.Msg) (dns.Msg, error) {
 		req.Id = r.Id
 
 		resp, err := dns.Exchange(&req, fmt.Sprintf("%s:1053", server))
+		statsUpstream(server, err != nil)
 		if err != nil || len(resp.Answer) == 0 {
 			// Error or empty response, try the next one
 			continue
 		}
 
+		statsUpdate(func(stats *api.NetworkStateDNSForkdns) { stats.RelayedAnswers++ })
 		return *resp, nil
 	}
 
@@ -234,6 +332,7 @@ func (h *dnsHandler) handleA(r *dns.Msg) (dns.Msg, error) {
 
 		// Record found in local DHCP leases file, generate answer response and send.
 		if ip != "" {
+			statsUpdate(func(stats *api.NetworkStateDNSForkdns) { stats.LocalAnswers++ })
 			msg.Authoritative = true
 			msg.Answer = append(msg.Answer, &dns.A{
 				Hdr: dns.RR_Header{
@@ -261,6 +360,8 @@ func (h *dnsHandler) handleA(r *dns.Msg) (dns.Msg, error) {
 	servers := dnsServersList
 	dnsServersFileLock.Unlock()
 
+	statsUpdate(func(stats *api.NetworkStateDNSForkdns) { stats.RelayedQueries++ })
+
 	// Query all the servers.
 	for _, server := range servers {
 		req := dns.Msg{}
@@ -270,11 +371,13 @@ func (h *dnsHandler) handleA(r *dns.Msg) (dns.Msg, error) {
 		req.Id = r.Id
 
 		resp, err := dns.Exchange(&req, fmt.Sprintf("%s:1053", server))
+		statsUpstream(server, err != nil)
 		if err != nil || resp.Rcode != dns.RcodeSuccess {
 			// Error sending request or error response, try next server.
 			continue
 		}
 
+		statsUpdate(func(stats *api.NetworkStateDNSForkdns) { stats.RelayedAnswers++ })
 		return *resp, nil
 	}
 
@@ -325,6 +428,8 @@ func (c *cmdForkDNS) Command() *cobra.Command {
   unable to answer it from the local lease file.
   When "recursion desired" flag is set to no, this indicates the request has been sent from another
   forkdns process, and the local dnsmasq lease file only is parsed to try and answer the query.
+  Query statistics are returned as JSON for CHAOS class TXT queries of "stats.forkdns" coming from
+  the listen address.
 `
 	cmd.RunE = c.Run
 	cmd.Hidden = true
@@ -372,9 +477,15 @@ func (c *cmdForkDNS) Run(cmd *cobra.Command, args []string) error {
 		Net:  "udp",
 	}
 
+	listenIP, _, err := net.SplitHostPort(args[0])
+	if err != nil {
+		return fmt.Errorf("Invalid listen address %q: %w", args[0], err)
+	}
+
 	srv.Handler = &dnsHandler{
 		domain:    args[1],
 		leaseFile: shared.VarPath("networks", networkName, "dnsmasq.leases"),
+		listenIP:  listenIP,
 	}
 
 	err = srv.ListenAndServe()
@@ -149,7 +149,49 @@ restart:
 #define EXEC_STDOUT_FD 4
 #define EXEC_STDERR_FD 5
 #define EXEC_PIPE_FD 6
-#define ARRAY_SIZE(arr) (sizeof(arr) / sizeof((arr)[0]))
+#define EXEC_EXTRA_FD 7
+
+struct forkexec_payload {
+	lxc_attach_command_t command;
+	int extra_fds;
+};
+
+// Moves the count file descriptors starting at from down to the ones
+// starting at to and closes whatever is left of the original ones.
+static int forkexec_remap_fds(int from, int to, int count)
+{
+	if (to < 0 || to >= from || count < 0) {
+		errno = EINVAL;
+		return -1;
+	}
+
+	for (int i = 0; i < count; i++) {
+		// The target is always below the source so this never
+		// overwrites a file descriptor which hasn't been moved yet.
+		if (dup2(from + i, to + i) < 0)
+			return -1;
+	}
+
+	for (int fd = from; fd < from + count; fd++) {
+		if (fd >= to + count)
+			close(fd);
+	}
+
+	return 0;
+}
+
+// Runs in the attached process, moves the additional file descriptors
+// passed by LXD from EXEC_EXTRA_FD onwards down to 3 onwards so they're
+// found where the command expects them and then runs the command.
+static int forkexec_run_command(void *payload)
+{
+	struct forkexec_payload *p = payload;
+
+	if (forkexec_remap_fds(EXEC_EXTRA_FD, 3, p->extra_fds) < 0)
+		_exit(EXIT_FAILURE);
+
+	return lxc_attach_run_command(&p->command);
+}
 
 // We use a separate function because cleanup macros are called during stack
 // unwinding if I'm not mistaken and if the compiler knows it exits it won't
@@ -165,10 +207,14 @@ __attribute__ ((noinline)) static int __forkexec(void)
 	char *cwd = NULL;
 	pid_t init_pid;
 	lxc_attach_options_t attach_options = LXC_ATTACH_OPTIONS_DEFAULT;
-	lxc_attach_command_t command = {
-		.program = NULL,
+	struct forkexec_payload payload = {
+		.command = {
+			.program = NULL,
+		},
+		.extra_fds = 0,
 	};
-	int fds_to_ignore[] = {EXEC_STDIN_FD, EXEC_STDOUT_FD, EXEC_STDERR_FD, EXEC_PIPE_FD};
+	__do_free int *fds_to_ignore = NULL;
+	size_t len_fds_to_ignore;
 	ssize_t ret;
 	pid_t attached_pid;
 	uid_t uid;
@@ -196,6 +242,9 @@ __attribute__ ((noinline)) static int __forkexec(void)
 	coresched = atoi(advance_arg(true));
 	if (coresched != 0 && coresched != 1)
 		_exit(EXIT_FAILURE);
+	payload.extra_fds = atoi(advance_arg(true));
+	if (payload.extra_fds < 0)
+		_exit(EXIT_FAILURE);
 
 	for (char *arg = NULL, *section = NULL; (arg = advance_arg(false)); ) {
 		if (!strcmp(arg, "--") && (!section || strcmp(section, "cmd"))) {
@@ -226,7 +275,19 @@ __attribute__ ((noinline)) static int __forkexec(void)
 	if (!argvp || !*argvp)
 		return log_error(EXIT_FAILURE, "No command specified");
 
-	ret = lxd_close_range(EXEC_PIPE_FD + 1, UINT_MAX, CLOSE_RANGE_UNSHARE);
+	len_fds_to_ignore = 4 + payload.extra_fds;
+	fds_to_ignore = malloc(sizeof(int) * len_fds_to_ignore);
+	if (!fds_to_ignore)
+		return log_error(EXIT_FAILURE, "Failed to allocate file descriptor array");
+
+	fds_to_ignore[0] = EXEC_STDIN_FD;
+	fds_to_ignore[1] = EXEC_STDOUT_FD;
+	fds_to_ignore[2] = EXEC_STDERR_FD;
+	fds_to_ignore[3] = EXEC_PIPE_FD;
+	for (int i = 0; i < payload.extra_fds; i++)
+		fds_to_ignore[4 + i] = EXEC_EXTRA_FD + i;
+
+	ret = lxd_close_range(EXEC_EXTRA_FD + payload.extra_fds, UINT_MAX, CLOSE_RANGE_UNSHARE);
 	if (ret) {
 		// Fallback to close_inherited() when the syscall is not
 		// available or when CLOSE_RANGE_UNSHARE isn't supported.
@@ -234,7 +295,7 @@ __attribute__ ((noinline)) static int __forkexec(void)
 		// available but openSUSE Leap 15.3 seems to have a partial
 		// backport without CLOSE_RANGE_UNSHARE support.
 		if (errno == ENOSYS || errno == EINVAL)
-			ret = close_inherited(fds_to_ignore, ARRAY_SIZE(fds_to_ignore));
+			ret = close_inherited(fds_to_ignore, len_fds_to_ignore);
 	}
 	if (ret)
 		return log_error(EXIT_FAILURE, "Aborting attach to prevent leaking file descriptors into container");
@@ -261,10 +322,10 @@ __attribute__ ((noinline)) static int __forkexec(void)
 	attach_options.stderr_fd = 5;
 	attach_options.uid = uid;
 	attach_options.gid = gid;
-	command.program = argvp[0];
-	command.argv = argvp;
+	payload.command.program = argvp[0];
+	payload.command.argv = argvp;
 
-	ret = c->attach(c, lxc_attach_run_command, &command, &attach_options, &attached_pid);
+	ret = c->attach(c, forkexec_run_command, &payload, &attach_options, &attached_pid);
 	if (ret < 0)
 		return EXIT_FAILURE;
 
@@ -342,7 +403,7 @@ type cmdForkexec struct {
 func (c *cmdForkexec) Command() *cobra.Command {
 	// Main subcommand
 	cmd := &cobra.Command{}
-	cmd.Use = "forkexec <container name> <containers path> <config> <cwd> <uid> <gid> <coresched> -- env [key=value...] -- cmd <args...>"
+	cmd.Use = "forkexec <container name> <containers path> <config> <cwd> <uid> <gid> <coresched> <extra fds> -- env [key=value...] -- cmd <args...>"
 	cmd.Short = "Execute a task inside the container"
 	cmd.Long = `Description:
   Execute a task inside the container
@@ -359,3 +420,14 @@ func (c *cmdForkexec) Command() *cobra.Command {
 func (c *cmdForkexec) Run(cmd *cobra.Command, args []string) error {
 	return fmt.Errorf("This command should have been intercepted in cgo")
 }
+
+// forkexecRemapFDs moves count file descriptors starting at from down to the ones starting at to, the same way
+// the additional file descriptors are moved in place before running the command.
+func forkexecRemapFDs(from int, to int, count int) error {
+	ret, err := C.forkexec_remap_fds(C.int(from), C.int(to), C.int(count))
+	if ret < 0 {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// forkexecTestFDIsOpen returns whether the file descriptor is open.
+func forkexecTestFDIsOpen(fd int) bool {
+	_, err := unix.FcntlInt(uintptr(fd), unix.F_GETFD, 0)
+	return err == nil
+}
+
+// forkexecTestPipes creates count pipes and duplicates their write ends onto the file descriptors starting at from.
+// It returns the read ends of the pipes.
+func forkexecTestPipes(t *testing.T, from int, count int) []*os.File {
+	readers := make([]*os.File, 0, count)
+	for i := 0; i < count; i++ {
+		require.False(t, forkexecTestFDIsOpen(from+i), "File descriptor %d is already in use", from+i)
+
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+
+		require.NoError(t, unix.Dup3(int(w.Fd()), from+i, 0))
+		require.NoError(t, w.Close())
+
+		t.Cleanup(func() { _ = r.Close() })
+		readers = append(readers, r)
+	}
+
+	return readers
+}
+
+func TestForkexecRemapFDs(t *testing.T) {
+	cases := []struct {
+		name string
+		from int
+		to   int
+	}{
+		{"Disjoint", 510, 500},
+		{"Overlapping", 501, 500},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			count := 3
+			readers := forkexecTestPipes(t, c.from, count)
+
+			t.Cleanup(func() {
+				for fd := c.to; fd < c.from+count; fd++ {
+					_ = unix.Close(fd)
+				}
+			})
+
+			require.NoError(t, forkexecRemapFDs(c.from, c.to, count))
+
+			// Each file descriptor now refers to the pipe which was at the matching original one.
+			for i := 0; i < count; i++ {
+				_, err := unix.Write(c.to+i, []byte{byte(i)})
+				require.NoError(t, err)
+
+				buf := make([]byte, 1)
+				_, err = readers[i].Read(buf)
+				require.NoError(t, err)
+				assert.Equal(t, byte(i), buf[0])
+			}
+
+			// The original file descriptors which weren't overwritten are closed.
+			for fd := c.from; fd < c.from+count; fd++ {
+				assert.Equal(t, fd < c.to+count, forkexecTestFDIsOpen(fd), "File descriptor %d", fd)
+			}
+		})
+	}
+}
+
+func TestForkexecRemapFDs_Invalid(t *testing.T) {
+	assert.Error(t, forkexecRemapFDs(500, 500, 1))
+	assert.Error(t, forkexecRemapFDs(500, 510, 1))
+	assert.Error(t, forkexecRemapFDs(510, 500, -1))
+
+	// Nothing to move.
+	assert.NoError(t, forkexecRemapFDs(510, 500, 0))
+}
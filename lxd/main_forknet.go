@@ -109,7 +109,7 @@ void forknet(void)
 		forkdonetinfo(pidfd, ns_fd);
 	}
 
-	if (strcmp(command, "detach") == 0)
+	if (strcmp(command, "detach") == 0 || strcmp(command, "mtu") == 0)
 		forkdonetdetach(cur);
 }
 */
@@ -146,6 +146,13 @@ func (c *cmdForknet) Command() *cobra.Command {
 	cmdDetach.RunE = c.RunDetach
 	cmd.AddCommand(cmdDetach)
 
+	// mtu
+	cmdMTU := &cobra.Command{}
+	cmdMTU.Use = "mtu <netns file> <ifname> <mtu>"
+	cmdMTU.Args = cobra.ExactArgs(3)
+	cmdMTU.RunE = c.RunMTU
+	cmd.AddCommand(cmdMTU)
+
 	// Workaround for subcommand usage errors. See: https://github.com/spf13/cobra/issues/706
 	cmd.Args = cobra.NoArgs
 	cmd.Run = func(cmd *cobra.Command, args []string) { _ = cmd.Usage() }
@@ -215,3 +222,19 @@ func (c *cmdForknet) RunDetach(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func (c *cmdForknet) RunMTU(cmd *cobra.Command, args []string) error {
+	ifName := args[1]
+	mtu := args[2]
+
+	if ifName == "" {
+		return fmt.Errorf("ifname argument is required")
+	}
+
+	if mtu == "" {
+		return fmt.Errorf("mtu argument is required")
+	}
+
+	link := &ip.Link{Name: ifName}
+	return link.SetMTU(mtu)
+}
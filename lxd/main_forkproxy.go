@@ -1,17 +1,26 @@
 package main
 
 import (
+	"bufio"
+	"crypto/tls"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 	"golang.org/x/sys/unix"
 
@@ -21,6 +30,7 @@ import (
 	"github.com/lxc/lxd/lxd/daemon"
 	"github.com/lxc/lxd/lxd/device"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/vsock"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/netutils"
 )
@@ -282,10 +292,281 @@ type udpSession struct {
 	timerLock sync.Mutex
 }
 
+// Connection limits (reloaded from the limits file on SIGHUP)
+var proxyLimits = forkproxyLimits{udpTimeout: 30 * time.Minute}
+var proxyLimitsLock sync.Mutex
+
+// Active connection tracking (used to enforce the connection limit on stream listeners)
+var proxyConnections int
+var proxyConnectionsLock sync.Mutex
+
+// New connections per source address during the current second (used to enforce the rate limit)
+var proxySourceRates = map[string]int{}
+var proxySourceRatesSecond int64
+
+// Distribution of new connections between the connect targets ("round-robin" or "hash")
+var proxyBalance string
+var proxyNextTarget uint64
+
+// udpTargetConn is the connection to the first target of a UDP listener, it carries all the targets of the
+// listener so new UDP sessions can be distributed between them.
+type udpTargetConn struct {
+	net.Conn
+	targets []string
+}
+
+// Connection to the lxd-agent used to reach the targets of virtual machines (unset for containers)
+var proxyAgentVsockID uint32
+var proxyAgentTLSConfig *tls.Config
+
+// agentConn is a connection relayed by the lxd-agent, reading through the buffer used for its upgrade.
+type agentConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *agentConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+type forkproxyLimits struct {
+	udpTimeout     time.Duration
+	maxConnections int
+	rate           int
+}
+
+// loadLimits parses the "key=value" lines of the limits file.
+func loadLimits(f *os.File) (forkproxyLimits, error) {
+	limits := forkproxyLimits{udpTimeout: 30 * time.Minute}
+
+	_, err := f.Seek(0, io.SeekStart)
+	if err != nil {
+		return limits, err
+	}
+
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return limits, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found || value == "" {
+			continue
+		}
+
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return limits, fmt.Errorf("Invalid value %q for %q: %w", value, key, err)
+		}
+
+		switch key {
+		case "udp.timeout":
+			limits.udpTimeout = time.Duration(number) * time.Second
+		case "limits.connections":
+			limits.maxConnections = number
+		case "limits.rate":
+			limits.rate = number
+		}
+	}
+
+	return limits, nil
+}
+
+// getLimits returns the current connection limits.
+func getLimits() forkproxyLimits {
+	proxyLimitsLock.Lock()
+	defer proxyLimitsLock.Unlock()
+
+	return proxyLimits
+}
+
+// allowSource returns whether a new connection from the address is within the per-source rate limit.
+func allowSource(addr net.Addr, rate int) bool {
+	if rate <= 0 || addr == nil {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+
+	proxyLimitsLock.Lock()
+	defer proxyLimitsLock.Unlock()
+
+	now := time.Now().Unix()
+	if now != proxySourceRatesSecond {
+		proxySourceRates = map[string]int{}
+		proxySourceRatesSecond = now
+	}
+
+	if proxySourceRates[host] >= rate {
+		return false
+	}
+
+	proxySourceRates[host]++
+
+	return true
+}
+
+// connectTargets returns the targets connections received by the listener at the given index are relayed to.
+func connectTargets(lAddr *deviceConfig.ProxyAddress, cAddrs []*deviceConfig.ProxyAddress, lAddrIndex int) []string {
+	targets := make([]string, 0, len(cAddrs))
+
+	for _, cAddr := range cAddrs {
+		if cAddr.ConnType == "unix" {
+			targets = append(targets, cAddr.Address)
+			continue
+		}
+
+		// Single or multiple port -> single port
+		connectPort := cAddr.Ports[0]
+		if lAddr.ConnType != "unix" && len(cAddr.Ports) > 1 {
+			// multiple port -> multiple port
+			connectPort = cAddr.Ports[lAddrIndex]
+		}
+
+		targets = append(targets, net.JoinHostPort(cAddr.Address, fmt.Sprintf("%d", connectPort)))
+	}
+
+	return targets
+}
+
+// loadAgent loads the certificates used to connect to the lxd-agent of a virtual machine from its path.
+func loadAgent(vsockID string, path string) error {
+	id, err := strconv.ParseUint(vsockID, 10, 32)
+	if err != nil {
+		return fmt.Errorf("Invalid vsock ID %q: %w", vsockID, err)
+	}
+
+	files := map[string]string{}
+	for _, name := range []string{"agent.crt", "agent-client.crt", "agent-client.key"} {
+		content, err := ioutil.ReadFile(filepath.Join(path, name))
+		if err != nil {
+			return err
+		}
+
+		files[name] = string(content)
+	}
+
+	tlsConfig, err := shared.GetTLSConfigMem(files["agent-client.crt"], files["agent-client.key"], "", files["agent.crt"], false)
+	if err != nil {
+		return err
+	}
+
+	proxyAgentVsockID = uint32(id)
+	proxyAgentTLSConfig = tlsConfig
+
+	return nil
+}
+
+// dialTarget connects to the target, going through the lxd-agent for virtual machines.
+func dialTarget(connType string, address string) (net.Conn, error) {
+	if proxyAgentTLSConfig == nil {
+		return net.Dial(connType, address)
+	}
+
+	conn, err := vsock.Dial(proxyAgentVsockID, shared.HTTPSDefaultPort)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, proxyAgentTLSConfig)
+	err = tlsConn.Handshake()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	u := &url.URL{
+		Scheme:   "https",
+		Host:     "custom.socket",
+		Path:     "/1.0/proxy",
+		RawQuery: url.Values{"connect": []string{fmt.Sprintf("%s:%s", connType, address)}}.Encode(),
+	}
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+
+	req.Header["Upgrade"] = []string{"proxy"}
+	req.Header["Connection"] = []string{"Upgrade"}
+
+	err = req.Write(tlsConn)
+	if err != nil {
+		_ = tlsConn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(tlsConn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = tlsConn.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = tlsConn.Close()
+		return nil, fmt.Errorf("Failed connecting through the agent: %s", resp.Status)
+	}
+
+	return &agentConn{Conn: tlsConn, reader: reader}, nil
+}
+
+// pickTarget returns the target a new connection from the source address is relayed to.
+func pickTarget(targets []string, source net.Addr) string {
+	if len(targets) == 1 {
+		return targets[0]
+	}
+
+	if proxyBalance == "hash" && source != nil {
+		// Hash the source host only so all connections of a client go to the same target.
+		host, _, err := net.SplitHostPort(source.String())
+		if err != nil {
+			host = source.String()
+		}
+
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(host))
+
+		return targets[h.Sum32()%uint32(len(targets))]
+	}
+
+	return targets[(atomic.AddUint64(&proxyNextTarget, 1)-1)%uint64(len(targets))]
+}
+
+// acquireConnection records a new active connection, returning false if the connection limit is reached.
+func acquireConnection(maxConnections int) bool {
+	proxyConnectionsLock.Lock()
+	defer proxyConnectionsLock.Unlock()
+
+	if maxConnections > 0 && proxyConnections >= maxConnections {
+		return false
+	}
+
+	proxyConnections++
+
+	return true
+}
+
+// releaseConnection records the end of an active connection.
+func releaseConnection() {
+	proxyConnectionsLock.Lock()
+	proxyConnections--
+	proxyConnectionsLock.Unlock()
+}
+
 func (c *cmdForkproxy) Command() *cobra.Command {
 	// Main subcommand
 	cmd := &cobra.Command{}
-	cmd.Use = "forkproxy <listen PID> <listen PidFd> <listen address> <connect PID> <connect PidFd> <connect address> <log path> <pid path> <listen gid> <listen uid> <listen mode> <security gid> <security uid>"
+	cmd.Use = "forkproxy <listen PID> <listen PidFd> <listen address> <connect PID> <connect PidFd> <connect addresses> <listen gid> <listen uid> <listen mode> <security gid> <security uid> <proxy protocol> <limits path> <connect balance> <agent vsock ID> <agent path> <listen recreate>"
 	cmd.Short = "Setup network connection proxying"
 	cmd.Long = `Description:
   Setup network connection proxying
@@ -294,7 +575,7 @@ func (c *cmdForkproxy) Command() *cobra.Command {
   container, connecting one side to the host and the other to the
   container.
 `
-	cmd.Args = cobra.ExactArgs(12)
+	cmd.Args = cobra.ExactArgs(16)
 	cmd.RunE = c.Run
 	cmd.Hidden = true
 
@@ -311,18 +592,10 @@ func rearmUDPFd(epFd C.int, connFd C.int) {
 	}
 }
 
-func listenerInstance(epFd C.int, lAddr *deviceConfig.ProxyAddress, cAddr *deviceConfig.ProxyAddress, connFd C.int, lStruct *lStruct, proxy bool) error {
-	// Single or multiple port -> single port
-	connectAddr := cAddr.Address
-	if cAddr.ConnType != "unix" {
-		connectPort := cAddr.Ports[0]
-		if lAddr.ConnType != "unix" && cAddr.ConnType != "unix" && len(cAddr.Ports) > 1 {
-			// multiple port -> multiple port
-			connectPort = cAddr.Ports[(*lStruct).lAddrIndex]
-		}
-
-		connectAddr = net.JoinHostPort(cAddr.Address, fmt.Sprintf("%d", connectPort))
-	}
+func listenerInstance(epFd C.int, lAddr *deviceConfig.ProxyAddress, cAddrs []*deviceConfig.ProxyAddress, connFd C.int, lStruct *lStruct, proxy bool) error {
+	// All the connect addresses share the same protocol.
+	cAddr := cAddrs[0]
+	targets := connectTargets(lAddr, cAddrs, (*lStruct).lAddrIndex)
 
 	if lAddr.ConnType == "udp" {
 		// This only handles udp <-> udp. The C constructor will have verified this before
@@ -334,14 +607,15 @@ func listenerInstance(epFd C.int, lAddr *deviceConfig.ProxyAddress, cAddr *devic
 				return
 			}
 
-			dstConn, err := net.Dial(cAddr.ConnType, connectAddr)
+			// New UDP sessions pick their own target, this one is used for their address family.
+			dstConn, err := net.Dial(cAddr.ConnType, targets[0])
 			if err != nil {
 				fmt.Printf("Warning: Failed to connect to target: %v\n", err)
 				rearmUDPFd(epFd, connFd)
 				return
 			}
 
-			genericRelay(srcConn, dstConn, true)
+			genericRelay(srcConn, &udpTargetConn{Conn: dstConn, targets: targets}, true)
 			rearmUDPFd(epFd, connFd)
 		}()
 
@@ -356,9 +630,23 @@ func listenerInstance(epFd C.int, lAddr *deviceConfig.ProxyAddress, cAddr *devic
 		return err
 	}
 
-	dstConn, err := net.Dial(cAddr.ConnType, connectAddr)
+	limits := getLimits()
+	if !allowSource(srcConn.RemoteAddr(), limits.rate) {
+		_ = srcConn.Close()
+		fmt.Printf("Warning: Rejected connection from %s: Rate limit reached\n", srcConn.RemoteAddr())
+		return nil
+	}
+
+	if !acquireConnection(limits.maxConnections) {
+		_ = srcConn.Close()
+		fmt.Printf("Warning: Rejected connection from %s: Connection limit reached\n", srcConn.RemoteAddr())
+		return nil
+	}
+
+	dstConn, err := dialTarget(cAddr.ConnType, pickTarget(targets, srcConn.RemoteAddr()))
 	if err != nil {
 		_ = srcConn.Close()
+		releaseConnection()
 		fmt.Printf("Warning: Failed to connect to target: %v\n", err)
 		return err
 	}
@@ -389,12 +677,17 @@ func listenerInstance(epFd C.int, lAddr *deviceConfig.ProxyAddress, cAddr *devic
 		}
 	}
 
-	if cAddr.ConnType == "unix" && lAddr.ConnType == "unix" {
+	if cAddr.ConnType == "unix" && lAddr.ConnType == "unix" && proxyAgentTLSConfig == nil {
 		// Handle OOB if both src and dst are using unix sockets
-		go unixRelay(srcConn, dstConn)
+		go func() {
+			unixRelay(srcConn, dstConn)
+			releaseConnection()
+		}()
 	} else {
-
-		go genericRelay(srcConn, dstConn, false)
+		go func() {
+			genericRelay(srcConn, dstConn, false)
+			releaseConnection()
+		}()
 	}
 
 	return nil
@@ -413,7 +706,7 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	// Quick checks.
-	if len(args) != 12 {
+	if len(args) != 17 {
 		_ = cmd.Help()
 
 		if len(args) == 0 {
@@ -435,11 +728,14 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	connectAddr := args[5]
-	cAddr, err := device.ProxyParseAddr(connectAddr)
+	cAddrs, err := device.ProxyParseAddrs(connectAddr)
 	if err != nil {
 		return err
 	}
 
+	cAddr := cAddrs[0]
+	proxyBalance = args[13]
+
 	if (lAddr.ConnType == "udp" || lAddr.ConnType == "tcp") && cAddr.ConnType == "udp" || cAddr.ConnType == "tcp" {
 		err := fmt.Errorf("Invalid port range")
 		if len(lAddr.Ports) > 1 && len(cAddr.Ports) > 1 && (len(cAddr.Ports) != len(lAddr.Ports)) {
@@ -493,49 +789,23 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 		}
 
 		if lAddr.ConnType == "unix" && !lAddr.Abstract {
-			var err error
-
-			listenAddrGID := -1
-			if args[6] != "" {
-				listenAddrGID, err = strconv.Atoi(args[6])
-				if err != nil {
-					return err
-				}
-			}
-
-			listenAddrUID := -1
-			if args[7] != "" {
-				listenAddrUID, err = strconv.Atoi(args[7])
-				if err != nil {
-					return err
-				}
-			}
-
-			if listenAddrGID != -1 || listenAddrUID != -1 {
-				err = os.Chown(lAddr.Address, listenAddrUID, listenAddrGID)
-				if err != nil {
-					return err
-				}
+			err = setupListenerSocket(lAddr.Address, args[6], args[7], args[8])
+			if err != nil {
+				return err
 			}
 
-			var listenAddrMode os.FileMode
-			if args[8] != "" {
-				tmp, err := strconv.ParseUint(args[8], 8, 0)
-				if err != nil {
-					return err
-				}
-
-				listenAddrMode = os.FileMode(tmp)
-				err = os.Chmod(lAddr.Address, listenAddrMode)
-				if err != nil {
-					return err
-				}
+			// Keep recreating the socket whenever it goes away.
+			if shared.IsTrue(args[16]) {
+				return watchListenerSocket(lAddr.Address, args[6], args[7], args[8])
 			}
 		}
 
 		return err
 	}
 
+	// The listener process passes new listeners whenever it recreates the unix socket.
+	recreate := lAddr.ConnType == "unix" && !lAddr.Abstract && shared.IsTrue(args[16])
+
 	addrRecvCount := 1
 	if lAddr.ConnType != "unix" {
 		addrRecvCount = len(lAddr.Ports)
@@ -564,7 +834,10 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 
 		files = append(files, f)
 	}
-	_ = unix.Close(forkproxyUDSSockFDNum)
+
+	if !recreate {
+		_ = unix.Close(forkproxyUDSSockFDNum)
+	}
 
 	var listenerMap map[int]*lStruct
 
@@ -585,12 +858,39 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 				return err
 			}
 			listenerMap[int(f.Fd())] = &lStruct{
+				f:          f,
 				lConn:      &listener,
 				lAddrIndex: i,
 			}
 		}
 	}
 
+	// Load the connection limits (the file is kept open to reload them after dropping privileges)
+	var limitsFile *os.File
+	if args[12] != "" {
+		limitsFile, err = os.Open(args[12])
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if limitsFile != nil {
+			limits, err := loadLimits(limitsFile)
+			if err != nil {
+				return err
+			}
+
+			proxyLimits = limits
+		}
+	}
+
+	// Load the agent certificates of virtual machines before dropping privileges
+	if args[14] != "" {
+		err = loadAgent(args[14], args[15])
+		if err != nil {
+			return fmt.Errorf("Failed loading agent certificates: %w", err)
+		}
+	}
+
 	// Drop privilege if requested
 	gid := uint64(0)
 	if args[9] != "" {
@@ -619,6 +919,28 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, unix.SIGTERM)
 
+	// Handle SIGHUP which is sent when the connection limits are updated
+	if limitsFile != nil {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, unix.SIGHUP)
+
+		go func() {
+			for range hup {
+				limits, err := loadLimits(limitsFile)
+				if err != nil {
+					fmt.Printf("Warning: Failed to reload limits: %v\n", err)
+					continue
+				}
+
+				proxyLimitsLock.Lock()
+				proxyLimits = limits
+				proxyLimitsLock.Unlock()
+
+				fmt.Printf("Status: Reloaded limits\n")
+			}
+		}()
+	}
+
 	if lAddr.ConnType == "unix" && !lAddr.Abstract {
 		defer func() { _ = os.Remove(lAddr.Address) }()
 	}
@@ -663,6 +985,16 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if recreate {
+		var ev C.struct_epoll_event
+		ev.events = C.EPOLLIN
+		*(*C.int)(unsafe.Pointer(&ev.data)) = C.int(forkproxyUDSSockFDNum)
+		ret := C.epoll_ctl(epFd, C.EPOLL_CTL_ADD, C.int(forkproxyUDSSockFDNum), &ev)
+		if ret < 0 {
+			return fmt.Errorf("Error: Failed to add listener process socket to epoll instance")
+		}
+	}
+
 	// This line is used by LXD to check forkproxy has started OK.
 	fmt.Println("Status: Started")
 
@@ -677,12 +1009,21 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 
 		for i := C.int(0); i < nfds; i++ {
 			curFd := *(*C.int)(unsafe.Pointer(&events[i].data))
+			if recreate && int(curFd) == forkproxyUDSSockFDNum {
+				err := replaceListener(epFd, listenerMap)
+				if err != nil {
+					fmt.Printf("Warning: Failed to replace the recreated listener: %s\n", err)
+				}
+
+				continue
+			}
+
 			srcConn, ok := listenerMap[int(curFd)]
 			if !ok {
 				continue
 			}
 
-			err := listenerInstance(epFd, lAddr, cAddr, curFd, srcConn, args[11] == "true")
+			err := listenerInstance(epFd, lAddr, cAddrs, curFd, srcConn, args[11] == "true")
 			if err != nil {
 				fmt.Printf("Warning: Failed to prepare new listener instance: %s\n", err)
 			}
@@ -696,6 +1037,9 @@ func (c *cmdForkproxy) Run(cmd *cobra.Command, args []string) error {
 func proxyCopy(dst net.Conn, src net.Conn) error {
 	var err error
 
+	// Keep the targets of new UDP sessions as dst gets replaced by the target of each session.
+	udpTargets, _ := dst.(*udpTargetConn)
+
 	// Attempt casting to UDP connections
 	srcUdp, srcIsUdp := src.(*net.UDPConn)
 	dstUdp, dstIsUdp := dst.(*net.UDPConn)
@@ -715,8 +1059,28 @@ func proxyCopy(dst net.Conn, src net.Conn) error {
 				us, ok := udpSessions[addr.String()]
 				udpSessionsLock.Unlock()
 
+				limits := getLimits()
+
 				if !ok {
-					dc, err := net.Dial(dst.RemoteAddr().Network(), dst.RemoteAddr().String())
+					udpSessionsLock.Lock()
+					sessions := len(udpSessions)
+					udpSessionsLock.Unlock()
+
+					// Drop the datagram if the new session isn't allowed.
+					if limits.maxConnections > 0 && sessions >= limits.maxConnections {
+						goto rAgain
+					}
+
+					if !allowSource(addr, limits.rate) {
+						goto rAgain
+					}
+
+					target := dst.RemoteAddr().String()
+					if udpTargets != nil {
+						target = pickTarget(udpTargets.targets, addr)
+					}
+
+					dc, err := net.Dial(dst.RemoteAddr().Network(), target)
 					if err != nil {
 						return err
 					}
@@ -731,7 +1095,7 @@ func proxyCopy(dst net.Conn, src net.Conn) error {
 					udpSessionsLock.Unlock()
 
 					go func() { _ = proxyCopy(src, dc) }()
-					us.timer = time.AfterFunc(30*time.Minute, func() {
+					us.timer = time.AfterFunc(limits.udpTimeout, func() {
 						_ = us.target.Close()
 
 						udpSessionsLock.Lock()
@@ -741,7 +1105,7 @@ func proxyCopy(dst net.Conn, src net.Conn) error {
 				}
 
 				us.timerLock.Lock()
-				us.timer.Reset(30 * time.Minute)
+				us.timer.Reset(limits.udpTimeout)
 				us.timerLock.Unlock()
 
 				dst = us.target
@@ -779,7 +1143,7 @@ func proxyCopy(dst net.Conn, src net.Conn) error {
 				}
 
 				us.timerLock.Lock()
-				us.timer.Reset(30 * time.Minute)
+				us.timer.Reset(getLimits().udpTimeout)
 				us.timerLock.Unlock()
 
 				nw, ew = dstUdp.WriteTo(buf[0:nr], us.client)
@@ -995,6 +1359,197 @@ func tryListenUDP(protocol string, addr string) (*os.File, error) {
 	return file, err
 }
 
+// setupListenerSocket applies the requested ownership and mode to a unix socket. As this runs in the user namespace
+// of the listener, the uid and gid are those of the namespace.
+func setupListenerSocket(path string, gid string, uid string, mode string) error {
+	var err error
+
+	listenAddrGID := -1
+	if gid != "" {
+		listenAddrGID, err = strconv.Atoi(gid)
+		if err != nil {
+			return err
+		}
+	}
+
+	listenAddrUID := -1
+	if uid != "" {
+		listenAddrUID, err = strconv.Atoi(uid)
+		if err != nil {
+			return err
+		}
+	}
+
+	if listenAddrGID != -1 || listenAddrUID != -1 {
+		err = os.Chown(path, listenAddrUID, listenAddrGID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if mode != "" {
+		tmp, err := strconv.ParseUint(mode, 8, 0)
+		if err != nil {
+			return err
+		}
+
+		err = os.Chmod(path, os.FileMode(tmp))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// watchListenerSocket recreates the unix socket whenever it's removed, passing the new listener to the proxy
+// process. It returns once the proxy process is gone.
+func watchListenerSocket(path string, gid string, uid string, mode string) error {
+	// Don't outlive the proxy process.
+	err := unix.Prctl(unix.PR_SET_PDEATHSIG, uintptr(unix.SIGKILL), 0, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = watcher.Close() }()
+
+	dir := filepath.Dir(path)
+	err = watcher.Add(dir)
+	if err != nil {
+		return err
+	}
+
+	recreateListener := func() error {
+		file, err := getListenerFile("unix", path)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = file.Close() }()
+
+		err = setupListenerSocket(path, gid, uid, mode)
+		if err != nil {
+			return err
+		}
+
+	sAgain:
+		err = netutils.AbstractUnixSendFd(forkproxyUDSSockFDNum, int(file.Fd()))
+		if err != nil {
+			errno, ok := shared.GetErrno(err)
+			if ok && (errno == unix.EAGAIN) {
+				goto sAgain
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if event.Op&(fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+
+			// The directory holding the socket is gone, wait for it to come back.
+			if event.Name == dir {
+				for !shared.PathExists(dir) {
+					time.Sleep(time.Second)
+				}
+
+				err = watcher.Add(dir)
+				if err != nil {
+					return err
+				}
+			} else if event.Name != path {
+				continue
+			}
+
+			if shared.PathExists(path) {
+				continue
+			}
+
+			err = recreateListener()
+			if err != nil {
+				errno, ok := shared.GetErrno(err)
+				if ok && errno == unix.EPIPE {
+					return nil
+				}
+
+				fmt.Printf("Warning: Failed to recreate %q: %v\n", path, err)
+				continue
+			}
+
+			fmt.Printf("Status: Recreated %q\n", path)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			fmt.Printf("Warning: Failed watching %q: %v\n", path, err)
+		}
+	}
+}
+
+// replaceListener receives a recreated unix socket listener from the listener process and swaps it in for the
+// current one.
+func replaceListener(epFd C.int, listenerMap map[int]*lStruct) error {
+	f, err := netutils.AbstractUnixReceiveFd(forkproxyUDSSockFDNum, netutils.UnixFdsAcceptExact)
+	if err != nil || f == nil {
+		// The listener process is gone, stop waiting for new listeners.
+		C.epoll_ctl(epFd, C.EPOLL_CTL_DEL, C.int(forkproxyUDSSockFDNum), nil)
+		_ = unix.Close(forkproxyUDSSockFDNum)
+
+		if err == nil {
+			err = fmt.Errorf("No listener received")
+		}
+
+		return err
+	}
+
+	listener, err := net.FileListener(f)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	for fd, l := range listenerMap {
+		C.epoll_ctl(epFd, C.EPOLL_CTL_DEL, C.int(fd), nil)
+		_ = (*l.lConn).Close()
+		_ = l.f.Close()
+		delete(listenerMap, fd)
+	}
+
+	listenerMap[int(f.Fd())] = &lStruct{
+		f:          f,
+		lConn:      &listener,
+		lAddrIndex: 0,
+	}
+
+	var ev C.struct_epoll_event
+	ev.events = C.EPOLLIN
+	*(*C.int)(unsafe.Pointer(&ev.data)) = C.int(f.Fd())
+	ret := C.epoll_ctl(epFd, C.EPOLL_CTL_ADD, C.int(f.Fd()), &ev)
+	if ret < 0 {
+		return fmt.Errorf("Failed to add listener fd to epoll instance")
+	}
+
+	fmt.Printf("Status: Replaced listener\n")
+
+	return nil
+}
+
 func getListenerFile(protocol string, addr string) (*os.File, error) {
 	if protocol == "udp" {
 		return tryListenUDP("udp", addr)
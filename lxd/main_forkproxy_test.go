@@ -189,3 +189,22 @@ func TestParseAddr(t *testing.T) {
 		require.Equal(t, tt.expected, addr)
 	}
 }
+
+func TestParseAddrs(t *testing.T) {
+	addrs, err := device.ProxyParseAddrs("tcp:10.0.0.2:80,81,tcp:[fd00::3]:90-91")
+	require.NoError(t, err)
+	require.Equal(t, []*deviceConfig.ProxyAddress{
+		{ConnType: "tcp", Address: "10.0.0.2", Ports: []uint64{80, 81}},
+		{ConnType: "tcp", Address: "fd00::3", Ports: []uint64{90, 91}},
+	}, addrs)
+
+	targets := connectTargets(&deviceConfig.ProxyAddress{ConnType: "tcp", Address: "0.0.0.0", Ports: []uint64{8080, 8081}}, addrs, 1)
+	require.Equal(t, []string{"10.0.0.2:81", "[fd00::3]:91"}, targets)
+
+	// Mixing protocols or numbers of ports is rejected.
+	_, err = device.ProxyParseAddrs("tcp:10.0.0.2:80,udp:10.0.0.3:80")
+	require.Error(t, err)
+
+	_, err = device.ProxyParseAddrs("tcp:10.0.0.2:80,81,tcp:10.0.0.3:80")
+	require.Error(t, err)
+}
@@ -11,19 +11,54 @@ import (
 )
 
 func (c *cmdInit) RunDump(d lxd.InstanceServer) error {
-	currentServer, _, err := d.GetServer()
+	var config initDataNode
+	var err error
+
+	// Use the server side export when available as it also covers profiles and network ACLs of all projects.
+	if d.HasExtension("server_export") {
+		resp, _, err := d.RawQuery("GET", "/1.0/export", nil, "")
+		if err != nil {
+			return fmt.Errorf("Failed to export current server configuration: %w", err)
+		}
+
+		err = resp.MetadataAsStruct(&config)
+		if err != nil {
+			return fmt.Errorf("Failed to export current server configuration: %w", err)
+		}
+	} else {
+		config, err = c.dumpLegacy(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	out, err := yaml.Marshal(config)
 	if err != nil {
 		return fmt.Errorf("Failed to retrieve current server configuration: %w", err)
 	}
 
+	fmt.Printf("%s\n", out)
+
+	return nil
+}
+
+// dumpLegacy builds the preseed document from the individual API endpoints, for servers without the
+// export endpoint.
+func (c *cmdInit) dumpLegacy(d lxd.InstanceServer) (initDataNode, error) {
 	var config initDataNode
+
+	currentServer, _, err := d.GetServer()
+	if err != nil {
+		return config, fmt.Errorf("Failed to retrieve current server configuration: %w", err)
+	}
+
 	config.Config = currentServer.Config
 
 	// Only retrieve networks in the default project as the preseed format doesn't support creating
 	// projects at this time.
 	networks, err := d.UseProject(project.Default).GetNetworks()
 	if err != nil {
-		return fmt.Errorf("Failed to retrieve current server network configuration for project %q: %w", project.Default, err)
+		return config, fmt.Errorf("Failed to retrieve current server network configuration for project %q: %w", project.Default, err)
 	}
 
 	for _, network := range networks {
@@ -44,7 +79,7 @@ func (c *cmdInit) RunDump(d lxd.InstanceServer) error {
 
 	storagePools, err := d.GetStoragePools()
 	if err != nil {
-		return fmt.Errorf("Failed to retrieve current server configuration: %w", err)
+		return config, fmt.Errorf("Failed to retrieve current server configuration: %w", err)
 	}
 
 	for _, storagePool := range storagePools {
@@ -59,7 +94,7 @@ func (c *cmdInit) RunDump(d lxd.InstanceServer) error {
 
 	profiles, err := d.GetProfiles()
 	if err != nil {
-		return fmt.Errorf("Failed to retrieve current server configuration: %w", err)
+		return config, fmt.Errorf("Failed to retrieve current server configuration: %w", err)
 	}
 
 	for _, profile := range profiles {
@@ -69,12 +104,12 @@ func (c *cmdInit) RunDump(d lxd.InstanceServer) error {
 		profilesPost.Devices = profile.Devices
 		profilesPost.Name = profile.Name
 
-		config.Profiles = append(config.Profiles, profilesPost)
+		config.Profiles = append(config.Profiles, initDataNodeProfile{ProfilesPost: profilesPost})
 	}
 
 	projects, err := d.GetProjects()
 	if err != nil {
-		return fmt.Errorf("Failed to retrieve current server configuration: %w", err)
+		return config, fmt.Errorf("Failed to retrieve current server configuration: %w", err)
 	}
 
 	for _, project := range projects {
@@ -86,12 +121,5 @@ func (c *cmdInit) RunDump(d lxd.InstanceServer) error {
 		config.Projects = append(config.Projects, projectsPost)
 	}
 
-	out, err := yaml.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("Failed to retrieve current server configuration: %w", err)
-	}
-
-	fmt.Printf("%s\n", out)
-
-	return nil
+	return config, nil
 }
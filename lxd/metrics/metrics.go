@@ -71,7 +71,9 @@ func (m *MetricSet) String() string {
 		metricTypeName := ""
 
 		// ProcsTotal is a gauge according to the OpenMetrics spec as its value can decrease.
-		if metricType == ProcsTotal {
+		if metricType == APIRequestDurationSeconds {
+			metricTypeName = "histogram"
+		} else if metricType == ProcsTotal {
 			metricTypeName = "gauge"
 		} else if strings.HasSuffix(MetricNames[metricType], "_total") {
 			metricTypeName = "counter"
@@ -109,9 +111,9 @@ func (m *MetricSet) String() string {
 			valueStr := strconv.FormatFloat(sample.Value, 'g', -1, 64)
 
 			if labels != "" {
-				_, err = out.WriteString(fmt.Sprintf("%s{%s} %s\n", MetricNames[metricType], labels, valueStr))
+				_, err = out.WriteString(fmt.Sprintf("%s%s{%s} %s\n", MetricNames[metricType], sample.Suffix, labels, valueStr))
 			} else {
-				_, err = out.WriteString(fmt.Sprintf("%s %s\n", MetricNames[metricType], valueStr))
+				_, err = out.WriteString(fmt.Sprintf("%s%s %s\n", MetricNames[metricType], sample.Suffix, valueStr))
 			}
 			if err != nil {
 				return ""
@@ -4,6 +4,9 @@ package metrics
 type Sample struct {
 	Labels map[string]string
 	Value  float64
+
+	// Suffix is appended to the metric name, used by histograms (_bucket, _sum and _count).
+	Suffix string
 }
 
 // MetricSet represents a set of metrics.
@@ -16,8 +19,12 @@ type MetricSet struct {
 type MetricType int
 
 const (
+	// APIRequestDurationSeconds represents the duration of the API requests
+	APIRequestDurationSeconds MetricType = iota
+	// APIRequestsTotal represents the number of API requests
+	APIRequestsTotal
 	// CPUSecondsTotal represents the total CPU seconds used
-	CPUSecondsTotal MetricType = iota
+	CPUSecondsTotal
 	// DiskReadBytesTotal represents the read bytes for a disk
 	DiskReadBytesTotal
 	// DiskReadsCompletedTotal represents the completed for a disk
@@ -92,6 +99,8 @@ const (
 
 // MetricNames associates a metric type to its name.
 var MetricNames = map[MetricType]string{
+	APIRequestDurationSeconds:   "lxd_api_request_duration_seconds",
+	APIRequestsTotal:            "lxd_api_requests_total",
 	CPUSecondsTotal:             "lxd_cpu_seconds_total",
 	DiskReadBytesTotal:          "lxd_disk_read_bytes_total",
 	DiskReadsCompletedTotal:     "lxd_disk_reads_completed_total",
@@ -132,6 +141,8 @@ var MetricNames = map[MetricType]string{
 
 // MetricHeaders represents the metric headers which contain help messages as specified by OpenMetrics.
 var MetricHeaders = map[MetricType]string{
+	APIRequestDurationSeconds:   "# HELP lxd_api_request_duration_seconds The duration of the API requests in seconds.",
+	APIRequestsTotal:            "# HELP lxd_api_requests_total The total number of API requests.",
 	CPUSecondsTotal:             "# HELP lxd_cpu_seconds_total The total number of CPU seconds used in milliseconds.",
 	DiskReadBytesTotal:          "# HELP lxd_disk_read_bytes_total The total number of bytes read.",
 	DiskReadsCompletedTotal:     "# HELP lxd_disk_reads_completed_total The total number of completed reads.",
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/binary"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -204,44 +203,6 @@ func (s *migrationSourceWs) checkForPreDumpSupport() (bool, int) {
 	return usePreDumps, maxIterations
 }
 
-// The function readCriuStatsDump() reads the CRIU 'stats-dump' file
-// in path and returns the pages_written, pages_skipped_parent, error.
-func readCriuStatsDump(path string) (uint64, uint64, error) {
-	statsDump := shared.AddSlash(path) + "stats-dump"
-	in, err := ioutil.ReadFile(statsDump)
-	if err != nil {
-		logger.Errorf("Error reading CRIU's 'stats-dump' file: %s", err.Error())
-		return 0, 0, err
-	}
-
-	// According to the CRIU file image format it starts with two magic values.
-	// First magic IMG_SERVICE: 1427134784
-	if binary.LittleEndian.Uint32(in[0:4]) != 1427134784 {
-		msg := "IMG_SERVICE(1427134784) criu magic not found"
-		logger.Errorf(msg)
-		return 0, 0, fmt.Errorf(msg)
-	}
-	// Second magic STATS: 1460220678
-	if binary.LittleEndian.Uint32(in[4:8]) != 1460220678 {
-		msg := "STATS(1460220678) criu magic not found"
-		logger.Errorf(msg)
-		return 0, 0, fmt.Errorf(msg)
-	}
-
-	// Next, read the size of the image payload
-	size := binary.LittleEndian.Uint32(in[8:12])
-
-	statsEntry := &migration.StatsEntry{}
-	if err = proto.Unmarshal(in[12:12+size], statsEntry); err != nil {
-		logger.Errorf("Failed to parse CRIU's 'stats-dump' file: %s", err.Error())
-		return 0, 0, err
-	}
-
-	written := statsEntry.GetDump().GetPagesWritten()
-	skipped := statsEntry.GetDump().GetPagesSkippedParent()
-	return written, skipped, nil
-}
-
 type preDumpLoopArgs struct {
 	checkpointDir string
 	bwlimit       string
@@ -290,7 +251,7 @@ func (s *migrationSourceWs) preDumpLoop(state *state.State, args *preDumpLoopArg
 	// Read the CRIU's 'stats-dump' file
 	dumpPath := shared.AddSlash(args.checkpointDir)
 	dumpPath += shared.AddSlash(args.dumpDir)
-	written, skippedParent, err := readCriuStatsDump(dumpPath)
+	written, skippedParent, err := migration.ReadCriuStatsDump(dumpPath)
 	if err != nil {
 		return final, err
 	}
@@ -300,7 +261,11 @@ func (s *migrationSourceWs) preDumpLoop(state *state.State, args *preDumpLoopArg
 
 	totalPages := written + skippedParent
 
-	percentageSkipped := int(100 - ((100 * written) / totalPages))
+	// Nothing left to transfer, treat it as fully in sync.
+	percentageSkipped := 100
+	if totalPages > 0 {
+		percentageSkipped = int(100 - ((100 * written) / totalPages))
+	}
 
 	logger.Debugf("CRIU pages skipped percentage %d%%", percentageSkipped)
 
@@ -641,7 +606,6 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 						return abort(err)
 					}
 					preDumpDir = fmt.Sprintf("%03d", preDumpCounter)
-					preDumpCounter++
 				}
 			} else {
 				logger.Debugf("The other side does not support pre-copy")
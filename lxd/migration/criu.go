@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ReadCriuStatsDump reads the CRIU 'stats-dump' file in path and returns the pages_written and
+// pages_skipped_parent values.
+func ReadCriuStatsDump(path string) (uint64, uint64, error) {
+	in, err := os.ReadFile(filepath.Join(path, "stats-dump"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("Failed reading CRIU's 'stats-dump' file: %w", err)
+	}
+
+	if len(in) < 12 {
+		return 0, 0, fmt.Errorf("CRIU's 'stats-dump' file is truncated")
+	}
+
+	// According to the CRIU file image format it starts with two magic values.
+	// First magic IMG_SERVICE: 1427134784
+	if binary.LittleEndian.Uint32(in[0:4]) != 1427134784 {
+		return 0, 0, fmt.Errorf("IMG_SERVICE(1427134784) criu magic not found")
+	}
+
+	// Second magic STATS: 1460220678
+	if binary.LittleEndian.Uint32(in[4:8]) != 1460220678 {
+		return 0, 0, fmt.Errorf("STATS(1460220678) criu magic not found")
+	}
+
+	// Next, read the size of the image payload
+	size := binary.LittleEndian.Uint32(in[8:12])
+	if uint64(len(in)) < 12+uint64(size) {
+		return 0, 0, fmt.Errorf("CRIU's 'stats-dump' file is truncated")
+	}
+
+	statsEntry := &StatsEntry{}
+	err = proto.Unmarshal(in[12:12+size], statsEntry)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Failed parsing CRIU's 'stats-dump' file: %w", err)
+	}
+
+	written := statsEntry.GetDump().GetPagesWritten()
+	skipped := statsEntry.GetDump().GetPagesSkippedParent()
+	return written, skipped, nil
+}
@@ -3,6 +3,7 @@ package acl
 import (
 	"fmt"
 
+	"github.com/lxc/lxd/lxd/db"
 	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
@@ -12,6 +13,64 @@ import (
 
 // FirewallApplyACLRules applies ACL rules to network firewall.
 func FirewallApplyACLRules(s *state.State, logger logger.Logger, aclProjectName string, aclNet NetworkACLUsage) error {
+	rules, err := firewallACLRules(s, aclProjectName, aclNet.Name, aclNet.Config)
+	if err != nil {
+		return fmt.Errorf("Failed generating ACL rules for network %q: %w", aclNet.Name, err)
+	}
+
+	return s.Firewall.NetworkApplyACLRules(aclNet.Name, rules)
+}
+
+// FirewallApplyNICACLRules applies the ACL rules of a bridged NIC to its host interface's firewall.
+func FirewallApplyNICACLRules(s *state.State, aclProjectName string, instProjectName string, instName string, devName string, hostName string, nicConfig map[string]string) error {
+	rules, err := firewallACLRules(s, aclProjectName, hostName, nicConfig)
+	if err != nil {
+		return fmt.Errorf("Failed generating ACL rules for device %q: %w", devName, err)
+	}
+
+	return s.Firewall.InstanceSetupACLRules(instProjectName, instName, devName, hostName, rules)
+}
+
+// firewallApplyNICsACLRules re-applies the ACL rules of the bridged NICs using the ACL on this member's running
+// instances. Returns whether any bridged NIC in the cluster uses the ACL.
+func firewallApplyNICsACLRules(s *state.State, aclProjectName string, aclName string) (bool, error) {
+	bridgedNICs := false
+
+	err := UsedBy(s, aclProjectName, func(_ []string, usageType any, nicName string, nicConfig map[string]string) error {
+		inst, ok := usageType.(db.Instance)
+		if !ok {
+			return nil
+		}
+
+		_, network, _, err := s.DB.Cluster.GetNetworkInAnyState(aclProjectName, nicConfig["network"])
+		if err != nil {
+			return fmt.Errorf("Failed to load network %q: %w", nicConfig["network"], err)
+		}
+
+		if network.Type != "bridge" {
+			return nil
+		}
+
+		bridgedNICs = true
+
+		// Only the NICs of running instances on this member have a host interface.
+		hostName := inst.Config[fmt.Sprintf("volatile.%s.host_name", nicName)]
+		if inst.Node != s.ServerName || hostName == "" || !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", hostName)) {
+			return nil
+		}
+
+		return FirewallApplyNICACLRules(s, aclProjectName, inst.Project, inst.Name, nicName, hostName, nicConfig)
+	}, aclName)
+	if err != nil {
+		return false, err
+	}
+
+	return bridgedNICs, nil
+}
+
+// firewallACLRules returns the firewall rules for the ACLs and default rules specified in the security.acls
+// settings of the config. The log prefix is used to name the logged rules.
+func firewallACLRules(s *state.State, aclProjectName string, logPrefix string, config map[string]string) ([]firewallDrivers.ACLRule, error) {
 	var dropRules []firewallDrivers.ACLRule
 	var rejectRules []firewallDrivers.ACLRule
 	var allowRules []firewallDrivers.ACLRule
@@ -56,23 +115,21 @@ func FirewallApplyACLRules(s *state.State, logger logger.Logger, aclProjectName
 		return nil
 	}
 
-	logPrefix := aclNet.Name
-
-	// Load ACLs specified by network.
-	for _, aclName := range shared.SplitNTrimSpace(aclNet.Config["security.acls"], ",", -1, true) {
+	// Load ACLs specified by config.
+	for _, aclName := range shared.SplitNTrimSpace(config["security.acls"], ",", -1, true) {
 		_, aclInfo, err := s.DB.Cluster.GetNetworkACL(aclProjectName, aclName)
 		if err != nil {
-			return fmt.Errorf("Failed loading ACL %q for network %q: %w", aclName, aclNet.Name, err)
+			return nil, fmt.Errorf("Failed loading ACL %q: %w", aclName, err)
 		}
 
 		err = convertACLRules("ingress", logPrefix, aclInfo.Ingress...)
 		if err != nil {
-			return fmt.Errorf("Failed converting ACL %q ingress rules for network %q: %w", aclInfo.Name, aclNet.Name, err)
+			return nil, fmt.Errorf("Failed converting ACL %q ingress rules: %w", aclInfo.Name, err)
 		}
 
 		err = convertACLRules("egress", logPrefix, aclInfo.Egress...)
 		if err != nil {
-			return fmt.Errorf("Failed converting ACL %q egress rules for network %q: %w", aclInfo.Name, aclNet.Name, err)
+			return nil, fmt.Errorf("Failed converting ACL %q egress rules: %w", aclInfo.Name, err)
 		}
 	}
 
@@ -81,9 +138,9 @@ func FirewallApplyACLRules(s *state.State, logger logger.Logger, aclProjectName
 	rules = append(rules, rejectRules...)
 	rules = append(rules, allowRules...)
 
-	// Add the automatic default ACL rule for the network.
-	egressAction, egressLogged := firewallACLDefaults(aclNet.Config, "egress")
-	ingressAction, ingressLogged := firewallACLDefaults(aclNet.Config, "ingress")
+	// Add the automatic default ACL rules.
+	egressAction, egressLogged := firewallACLDefaults(config, "egress")
+	ingressAction, ingressLogged := firewallACLDefaults(config, "ingress")
 
 	rules = append(rules, firewallDrivers.ACLRule{
 		Direction: "egress",
@@ -99,12 +156,12 @@ func FirewallApplyACLRules(s *state.State, logger logger.Logger, aclProjectName
 		LogName:   fmt.Sprintf("%s-ingress", logPrefix),
 	})
 
-	return s.Firewall.NetworkApplyACLRules(aclNet.Name, rules)
+	return rules, nil
 }
 
 // firewallACLDefaults returns the action and logging mode to use for the specified direction's default rule.
 // If the security.acls.default.{in,e}gress.action or security.acls.default.{in,e}gress.logged settings are not
-// specified in the network config, then it returns "reject" and false respectively.
+// specified in the config, then it returns "reject" and false respectively.
 func firewallACLDefaults(netConfig map[string]string, direction string) (string, bool) {
 	defaults := map[string]string{
 		fmt.Sprintf("security.acls.default.%s.action", direction): "reject",
@@ -277,7 +277,8 @@ func NetworkUsage(s *state.State, aclProjectName string, aclNames []string, aclN
 				return fmt.Errorf("Failed to load network %q: %w", nicConfig["network"], err)
 			}
 
-			if shared.StringInSlice(network.Type, supportedNetTypes) {
+			// Bridged NIC ACLs are applied to the NIC's host interface rather than to the network.
+			if network.Type == "ovn" {
 				if _, found := aclNets[network.Name]; !found {
 					aclNets[network.Name] = NetworkACLUsage{
 						ID:     networkID,
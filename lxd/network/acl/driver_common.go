@@ -629,6 +629,12 @@ func (d *common) Update(config *api.NetworkACLPut, clientType request.ClientType
 		}
 	}
 
+	// Apply ACL changes to the bridged NICs of the running instances on this member.
+	bridgedNICs, err := firewallApplyNICsACLRules(d.state, d.projectName, d.info.Name)
+	if err != nil {
+		return err
+	}
+
 	// If there are affected OVN networks, then apply the changes, but only if the request type is normal.
 	// This way we won't apply the same changes multiple times for each LXD cluster member.
 	if len(aclOVNNets) > 0 && clientType == request.ClientTypeNormal {
@@ -663,8 +669,8 @@ func (d *common) Update(config *api.NetworkACLPut, clientType request.ClientType
 		}
 	}
 
-	// Apply ACL changes to non-OVN networks on cluster members.
-	if clientType == request.ClientTypeNormal && len(aclNets) > 0 {
+	// Apply ACL changes to non-OVN networks and bridged NICs on cluster members.
+	if clientType == request.ClientTypeNormal && (len(aclNets) > 0 || bridgedNICs) {
 		// Notify all other nodes to update the network if no target specified.
 		notifier, err := cluster.NewNotifier(d.state, d.state.Endpoints.NetworkCert(), d.state.ServerCert(), cluster.NotifyAll)
 		if err != nil {
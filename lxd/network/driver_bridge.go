@@ -1,21 +1,28 @@
 package network
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/mdlayher/netx/eui64"
 
 	"github.com/lxc/lxd/client"
@@ -27,16 +34,20 @@ import (
 	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/device/nictype"
+	"github.com/lxc/lxd/lxd/dhcpd"
 	"github.com/lxc/lxd/lxd/dnsmasq"
 	"github.com/lxc/lxd/lxd/dnsmasq/dhcpalloc"
 	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
 	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/ip"
+	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/sys"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/lxd/warnings"
 	"github.com/lxc/lxd/shared"
@@ -53,6 +64,9 @@ const ForkdnsServersListPath = "forkdns.servers"
 // ForkdnsServersListFile file that contains the server candidates list.
 const ForkdnsServersListFile = "servers.conf"
 
+// ForkdnsStatsName is the name of the CHAOS class TXT record forkdns answers with its statistics.
+const ForkdnsStatsName = "stats.forkdns."
+
 var forkdnsServersLock sync.Mutex
 
 // bridge represents a LXD bridge network.
@@ -74,6 +88,9 @@ func (n *bridge) DBType() db.NetworkType {
 func (n *bridge) Info() Info {
 	info := n.common.Info()
 	info.AddressForwards = true
+	info.LoadBalancers = true
+	info.DHCPReservations = true
+	info.Peering = true
 
 	return info
 }
@@ -113,6 +130,10 @@ func (n *bridge) FillConfig(config map[string]string) error {
 		if config["ipv4.nat"] == "" {
 			config["ipv4.nat"] = "true"
 		}
+
+		if config["fan.overlay_subnet6"] == "auto" && config["ipv6.nat"] == "" {
+			config["ipv6.nat"] = "true"
+		}
 	} else {
 		if config["ipv4.address"] == "" {
 			config["ipv4.address"] = "auto"
@@ -178,6 +199,16 @@ func (n *bridge) populateAutoConfig(config map[string]string) error {
 		changedConfig = true
 	}
 
+	if config["fan.overlay_subnet6"] == "auto" && config["fan.underlay_subnet"] != "" {
+		subnet, err := fanOverlaySubnetV6(config["fan.underlay_subnet"])
+		if err != nil {
+			return err
+		}
+
+		config["fan.overlay_subnet6"] = subnet.String()
+		changedConfig = true
+	}
+
 	// Re-validate config if changed.
 	if changedConfig && n.state != nil {
 		return n.Validate(config)
@@ -218,8 +249,27 @@ func (n *bridge) Validate(config map[string]string) error {
 		"bridge.hwaddr": validate.Optional(validate.IsNetworkMAC),
 		"bridge.mtu":    validate.Optional(validate.IsNetworkMTU),
 		"bridge.mode":   validate.Optional(validate.IsOneOf("standard", "fan")),
+		"bridge.ovs.controller": validate.Optional(validate.IsListOf(func(value string) error {
+			target := strings.SplitN(value, ":", 2)
+			if len(target) != 2 || !shared.StringInSlice(target[0], []string{"tcp", "ssl", "unix", "ptcp", "pssl", "punix"}) {
+				return fmt.Errorf("Unsupported OpenFlow controller target type")
+			}
 
+			return nil
+		})),
+		"bridge.ovs.protocols":  validate.Optional(validate.IsListOf(validate.IsOneOf("OpenFlow10", "OpenFlow11", "OpenFlow12", "OpenFlow13", "OpenFlow14", "OpenFlow15"))),
+		"bridge.vlan_filtering": validate.Optional(validate.IsBool),
+
+		"fan.encryption":     validate.Optional(validate.IsOneOf("none", "ipsec")),
 		"fan.overlay_subnet": validate.Optional(validate.IsNetworkV4),
+		"fan.overlay_subnet6": validate.Optional(func(value string) error {
+			if value == "auto" {
+				return nil
+			}
+
+			return validate.IsNetworkV6(value)
+		}),
+		"fan.psk": validate.Optional(bridgeValidateIPsecPSK),
 		"fan.underlay_subnet": validate.Optional(func(value string) error {
 			if value == "auto" {
 				return nil
@@ -234,26 +284,27 @@ func (n *bridge) Validate(config map[string]string) error {
 				return nil
 			}
 
-			return validate.IsNetworkAddressCIDRV4(value)
+			return validate.IsListOf(validate.IsNetworkAddressCIDRV4)(value)
 		}),
-		"ipv4.firewall":     validate.Optional(validate.IsBool),
-		"ipv4.nat":          validate.Optional(validate.IsBool),
-		"ipv4.nat.order":    validate.Optional(validate.IsOneOf("before", "after")),
-		"ipv4.nat.address":  validate.Optional(validate.IsNetworkAddressV4),
-		"ipv4.dhcp":         validate.Optional(validate.IsBool),
-		"ipv4.dhcp.gateway": validate.Optional(validate.IsNetworkAddressV4),
-		"ipv4.dhcp.expiry":  validate.IsAny,
-		"ipv4.dhcp.ranges":  validate.Optional(validate.IsNetworkRangeV4List),
-		"ipv4.routes":       validate.Optional(validate.IsNetworkV4List),
-		"ipv4.routing":      validate.Optional(validate.IsBool),
-		"ipv4.ovn.ranges":   validate.Optional(validate.IsNetworkRangeV4List),
+		"ipv4.firewall":           validate.Optional(validate.IsBool),
+		"ipv4.nat":                validate.Optional(validate.IsBool),
+		"ipv4.nat.order":          validate.Optional(validate.IsOneOf("before", "after")),
+		"ipv4.nat.address":        validate.Optional(validate.IsNetworkAddressV4),
+		"ipv4.dhcp":               validate.Optional(validate.IsBool),
+		"ipv4.dhcp.gateway":       validate.Optional(validate.IsNetworkAddressV4),
+		"ipv4.dhcp.expiry":        validate.IsAny,
+		"ipv4.dhcp.ranges":        validate.Optional(validate.IsNetworkRangeV4List),
+		"ipv4.routes":             validate.Optional(validate.IsNetworkV4List),
+		"ipv4.routing":            validate.Optional(validate.IsBool),
+		"ipv4.routing.forwarding": validate.Optional(validate.IsBool),
+		"ipv4.ovn.ranges":         validate.Optional(validate.IsNetworkRangeV4List),
 
 		"ipv6.address": validate.Optional(func(value string) error {
 			if validate.IsOneOf("none", "auto")(value) == nil {
 				return nil
 			}
 
-			return validate.IsNetworkAddressCIDRV6(value)
+			return validate.IsListOf(validate.IsNetworkAddressCIDRV6)(value)
 		}),
 		"ipv6.firewall":                        validate.Optional(validate.IsBool),
 		"ipv6.nat":                             validate.Optional(validate.IsBool),
@@ -265,6 +316,8 @@ func (n *bridge) Validate(config map[string]string) error {
 		"ipv6.dhcp.ranges":                     validate.Optional(validate.IsNetworkRangeV6List),
 		"ipv6.routes":                          validate.Optional(validate.IsNetworkV6List),
 		"ipv6.routing":                         validate.Optional(validate.IsBool),
+		"ipv6.routing.accept_ra":               validate.Optional(validate.IsBool),
+		"ipv6.routing.forwarding":              validate.Optional(validate.IsBool),
 		"ipv6.ovn.ranges":                      validate.Optional(validate.IsNetworkRangeV6List),
 		"dns.domain":                           validate.IsAny,
 		"dns.mode":                             validate.Optional(validate.IsOneOf("dynamic", "managed", "none")),
@@ -273,8 +326,14 @@ func (n *bridge) Validate(config map[string]string) error {
 		"dns.zone.reverse.ipv4":                validate.Optional(n.validateZoneName),
 		"dns.zone.reverse.ipv6":                validate.Optional(n.validateZoneName),
 		"raw.dnsmasq":                          validate.IsAny,
+		"dns.provider":                         validate.Optional(validate.IsOneOf("dnsmasq", "builtin")),
 		"maas.subnet.ipv4":                     validate.IsAny,
 		"maas.subnet.ipv6":                     validate.IsAny,
+		"mirror.target":                        validate.Optional(validate.IsInterfaceName),
+		"mirror.direction":                     validate.Optional(validate.IsOneOf("both", "ingress", "egress")),
+		"mirror.vxlan.remote":                  validate.Optional(validate.IsNetworkAddress),
+		"mirror.vxlan.port":                    networkValidPort,
+		"mirror.vxlan.id":                      validate.Optional(validate.IsInRange(0, 16777215)),
 		"security.acls":                        validate.IsAny,
 		"security.acls.default.ingress.action": validate.Optional(validate.IsOneOf(acl.ValidActions...)),
 		"security.acls.default.egress.action":  validate.Optional(validate.IsOneOf(acl.ValidActions...)),
@@ -306,6 +365,10 @@ func (n *bridge) Validate(config map[string]string) error {
 				rules[k] = validate.Optional(validate.IsNetworkAddress)
 			case "remote":
 				rules[k] = validate.Optional(validate.IsNetworkAddress)
+			case "remote_backup":
+				rules[k] = validate.Optional(validate.IsNetworkAddress)
+			case "keepalive":
+				rules[k] = validate.Optional(validate.IsUint32)
 			case "port":
 				rules[k] = networkValidPort
 			case "group":
@@ -316,6 +379,10 @@ func (n *bridge) Validate(config map[string]string) error {
 				rules[k] = validate.IsInterfaceName
 			case "ttl":
 				rules[k] = validate.Optional(validate.IsUint8)
+			case "encryption":
+				rules[k] = validate.Optional(validate.IsOneOf("none", "ipsec"))
+			case "psk":
+				rules[k] = validate.Optional(bridgeValidateIPsecPSK)
 			}
 		}
 	}
@@ -344,6 +411,17 @@ func (n *bridge) Validate(config map[string]string) error {
 		return fmt.Errorf("Network name too long to use with the FAN (must be 11 characters or less)")
 	}
 
+	// Validate traffic mirroring.
+	if config["mirror.vxlan.remote"] != "" {
+		if config["mirror.target"] != "" {
+			return fmt.Errorf("Cannot use %q property in conjunction with %q property", "mirror.target", "mirror.vxlan.remote")
+		}
+
+		if len(n.name) > 8 {
+			return fmt.Errorf("Network name too long to use a VXLAN mirror collector (must be 8 characters or less)")
+		}
+	}
+
 	for k, v := range config {
 		key := k
 		// Bridge mode checks
@@ -352,7 +430,9 @@ func (n *bridge) Validate(config map[string]string) error {
 		}
 
 		if bridgeMode == "fan" && strings.HasPrefix(key, "ipv6.") && v != "" {
-			return fmt.Errorf("IPv6 configuration may not be set when in 'fan' mode")
+			if config["fan.overlay_subnet6"] == "" || !shared.StringInSlice(key, []string{"ipv6.dhcp", "ipv6.dhcp.expiry", "ipv6.dhcp.stateful", "ipv6.firewall", "ipv6.nat", "ipv6.nat.order"}) {
+				return fmt.Errorf("IPv6 configuration may not be set when in 'fan' mode (other than for the IPv6 overlay)")
+			}
 		}
 
 		if bridgeMode != "fan" && strings.HasPrefix(key, "fan.") && v != "" {
@@ -390,6 +470,69 @@ func (n *bridge) Validate(config map[string]string) error {
 		}
 	}
 
+	// Check the encrypted tunnels are fully configured.
+	if config["fan.encryption"] == "ipsec" && config["fan.psk"] == "" {
+		return fmt.Errorf(`"fan.psk" must be set when using fan encryption`)
+	}
+
+	for k, v := range config {
+		fields := strings.Split(k, ".")
+		if len(fields) != 3 || fields[0] != "tunnel" || fields[2] != "encryption" || v != "ipsec" {
+			continue
+		}
+
+		getConfig := func(key string) string {
+			return config[fmt.Sprintf("tunnel.%s.%s", fields[1], key)]
+		}
+
+		if getConfig("psk") == "" {
+			return fmt.Errorf("%q must be set when using tunnel encryption", fmt.Sprintf("tunnel.%s.psk", fields[1]))
+		}
+
+		if getConfig("local") == "" || getConfig("remote") == "" {
+			return fmt.Errorf("Tunnel encryption requires %q and %q to be set (multicast tunnels cannot be encrypted)", fmt.Sprintf("tunnel.%s.local", fields[1]), fmt.Sprintf("tunnel.%s.remote", fields[1]))
+		}
+	}
+
+	// Check the subnets of the network don't overlap.
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		subnets := []*net.IPNet{}
+		for _, address := range util.BridgeAddresses(config[key]) {
+			_, subnet, err := net.ParseCIDR(address)
+			if err != nil {
+				return err
+			}
+
+			for _, otherSubnet := range subnets {
+				if SubnetContains(otherSubnet, subnet) || SubnetContains(subnet, otherSubnet) {
+					return fmt.Errorf("Subnet %q overlaps with subnet %q in %q", subnet.String(), otherSubnet.String(), key)
+				}
+			}
+
+			subnets = append(subnets, subnet)
+		}
+	}
+
+	// Check the OpenFlow settings are only used with Open vSwitch bridges.
+	if config["bridge.driver"] != "openvswitch" && (config["bridge.ovs.controller"] != "" || config["bridge.ovs.protocols"] != "") {
+		return fmt.Errorf("OpenFlow controller settings can only be used with the openvswitch bridge driver")
+	}
+
+	// Check the features used are supported by the builtin DHCP server.
+	if config["dns.provider"] == "builtin" {
+		if config["raw.dnsmasq"] != "" {
+			return fmt.Errorf(`"raw.dnsmasq" cannot be used with the builtin DNS provider`)
+		}
+
+		if shared.IsTrue(config["ipv6.dhcp.stateful"]) {
+			return fmt.Errorf("Stateful DHCPv6 isn't supported by the builtin DNS provider")
+		}
+
+		if len(util.BridgeAddresses(config["ipv4.address"])) > 1 || len(util.BridgeAddresses(config["ipv6.address"])) > 1 {
+			return fmt.Errorf("Multiple subnets aren't supported by the builtin DNS provider")
+		}
+	}
+
 	// Check using same MAC address on every cluster node is safe.
 	if config["bridge.hwaddr"] != "" {
 		err = n.checkClusterWideMACSafe(config)
@@ -494,6 +637,100 @@ func (n *bridge) isRunning() bool {
 	return InterfaceExists(n.name)
 }
 
+// State returns the network state, including the Open vSwitch database and controller status for openvswitch bridges.
+func (n *bridge) State() (*api.NetworkState, error) {
+	state, err := n.common.State()
+	if err != nil {
+		return nil, err
+	}
+
+	state.DNS = n.dnsState()
+
+	if n.config["bridge.driver"] != "openvswitch" {
+		return state, nil
+	}
+
+	state.OVS = &api.NetworkStateOVS{Controllers: []api.NetworkStateOVSController{}}
+
+	ovs := openvswitch.NewOVS()
+	state.OVS.Version, err = ovs.Version()
+	if err != nil {
+		n.logger.Warn("Failed getting Open vSwitch version", logger.Ctx{"err": err})
+		return state, nil
+	}
+
+	state.OVS.DatabaseConnected = true
+
+	controllers, err := ovs.BridgeControllers(n.name)
+	if err != nil {
+		n.logger.Warn("Failed getting OpenFlow controllers", logger.Ctx{"err": err})
+		return state, nil
+	}
+
+	for _, controller := range controllers {
+		state.OVS.Controllers = append(state.OVS.Controllers, api.NetworkStateOVSController{
+			Target:    controller.Target,
+			Connected: controller.Connected,
+			Role:      controller.Role,
+		})
+	}
+
+	return state, nil
+}
+
+// dnsState returns the statistics of the network's DNS server and cluster DNS relay.
+// Returns nil if the network doesn't run a DNS server. Failures to get the statistics are only logged.
+func (n *bridge) dnsState() *api.NetworkStateDNS {
+	if n.config["dns.mode"] == "none" {
+		return nil
+	}
+
+	dnsState := &api.NetworkStateDNS{}
+
+	// Find an address dnsmasq listens on.
+	var listenIP string
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		ipAddress, _, err := net.ParseCIDR(util.BridgePrimaryAddress(n.config[key]))
+		if err == nil {
+			listenIP = ipAddress.String()
+			break
+		}
+	}
+
+	// The forkdns process only exists on clustered fan networks, its listen address is in its saved arguments.
+	// On fan networks dnsmasq listens on the same address.
+	pidPath := shared.VarPath("networks", n.name, "forkdns.pid")
+	if shared.PathExists(pidPath) {
+		p, err := subprocess.ImportProcess(pidPath)
+		if err == nil && len(p.Args) > 1 {
+			forkdnsIP, _, _ := net.SplitHostPort(p.Args[1])
+			if listenIP == "" {
+				listenIP = forkdnsIP
+			}
+
+			dnsState.Forkdns, err = ForkdnsStats(p.Args[1])
+		}
+
+		if err != nil {
+			n.logger.Warn("Failed getting cluster DNS relay statistics", logger.Ctx{"err": err})
+		}
+	}
+
+	if listenIP != "" {
+		var err error
+		dnsState.Cache, err = dnsmasq.CacheStats(net.JoinHostPort(listenIP, "53"))
+		if err != nil {
+			n.logger.Warn("Failed getting DNS cache statistics", logger.Ctx{"err": err})
+		}
+	}
+
+	if dnsState.Cache == nil && dnsState.Forkdns == nil {
+		return nil
+	}
+
+	return dnsState
+}
+
 // Delete deletes a network.
 func (n *bridge) Delete(clientType request.ClientType) error {
 	n.logger.Debug("Delete", logger.Ctx{"clientType": clientType})
@@ -583,6 +820,12 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		return nil
 	}
 
+	// Bridge networks rely on changing the network sysctls.
+	err := n.state.OS.CheckFeature(sys.FeatureSysctl)
+	if err != nil {
+		return err
+	}
+
 	n.logger.Debug("Setting up network")
 
 	revert := revert.New()
@@ -624,6 +867,20 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 	}
 
+	// Apply the OpenFlow controller settings.
+	if n.config["bridge.driver"] == "openvswitch" {
+		ovs := openvswitch.NewOVS()
+		err := ovs.BridgeProtocolsSet(n.name, shared.SplitNTrimSpace(n.config["bridge.ovs.protocols"], ",", -1, true))
+		if err != nil {
+			return fmt.Errorf("Failed setting OpenFlow protocols: %w", err)
+		}
+
+		err = ovs.BridgeControllerSet(n.name, shared.SplitNTrimSpace(n.config["bridge.ovs.controller"], ",", -1, true))
+		if err != nil {
+			return fmt.Errorf("Failed setting OpenFlow controller: %w", err)
+		}
+	}
+
 	// Get a list of tunnels.
 	tunnels := n.getTunnels()
 
@@ -667,6 +924,11 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 
 	// Cleanup any existing tunnel device.
 	for _, iface := range ifaces {
+		// Keep the mirror collector interface if unchanged as the instance NICs mirror their traffic to it.
+		if iface.Name == BridgeMirrorName(n.name) && n.config["mirror.vxlan.remote"] != "" && !n.mirrorChanged(oldConfig) {
+			continue
+		}
+
 		if strings.HasPrefix(iface.Name, fmt.Sprintf("%s-", n.name)) {
 			tunLink := &ip.Link{Name: iface.Name}
 			err = tunLink.Delete()
@@ -677,18 +939,7 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 	}
 
 	// Set the MTU.
-	mtu := ""
-	if n.config["bridge.mtu"] != "" {
-		mtu = n.config["bridge.mtu"]
-	} else if len(tunnels) > 0 {
-		mtu = "1400"
-	} else if n.config["bridge.mode"] == "fan" {
-		if n.config["fan.type"] == "ipip" {
-			mtu = "1480"
-		} else {
-			mtu = "1450"
-		}
-	}
+	mtu := n.bridgeMTU()
 
 	// Attempt to add a dummy device to the bridge to force the MTU.
 	if mtu != "" && n.config["bridge.driver"] != "openvswitch" {
@@ -715,42 +966,10 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		return err
 	}
 
-	// Always prefer static MAC address if set.
-	hwAddr := n.config["bridge.hwaddr"]
-
-	// If no cluster wide static MAC address set, then generate one.
-	if hwAddr == "" {
-		var seedNodeID int64
-
-		if n.checkClusterWideMACSafe(n.config) != nil {
-			// If not safe to use a cluster wide MAC or in in fan mode, then use cluster node's ID to
-			// generate a stable per-node & network derived random MAC.
-			seedNodeID = n.state.DB.Cluster.GetNodeID()
-		} else {
-			// If safe to use a cluster wide MAC, then use a static cluster node of 0 to generate a
-			// stable per-network derived random MAC.
-			seedNodeID = 0
-		}
-
-		// Load server certificate. This is needs to be the same certificate for all nodes in a cluster.
-		cert, err := util.LoadCert(n.state.OS.VarDir)
-		if err != nil {
-			return err
-		}
-
-		// Generate the random seed, this uses the server certificate fingerprint (to ensure that multiple
-		// standalone nodes with the same network ID connected to the same external network don't generate
-		// the same MAC for their networks). It relies on the certificate being the same for all nodes in a
-		// cluster to allow the same MAC to be generated on each bridge interface in the network when
-		// seedNodeID is 0 (when safe to do so).
-		seed := fmt.Sprintf("%s.%d.%d", cert.Fingerprint(), seedNodeID, n.ID())
-		r, err := util.GetStableRandomGenerator(seed)
-		if err != nil {
-			return fmt.Errorf("Failed generating stable random bridge MAC: %w", err)
-		}
-
-		hwAddr = randomHwaddr(r)
-		n.logger.Debug("Stable MAC generated", logger.Ctx{"seed": seed, "hwAddr": hwAddr})
+	// Get the static or generated MAC address.
+	hwAddr, err := n.bridgeHwaddr()
+	if err != nil {
+		return err
 	}
 
 	// Set the MAC address on the bridge interface if specified.
@@ -761,8 +980,13 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 	}
 
-	// Enable VLAN filtering for Linux bridges.
-	if n.config["bridge.driver"] != "openvswitch" {
+	// Enable VLAN filtering for Linux bridges unless disabled.
+	if n.config["bridge.driver"] != "openvswitch" && shared.IsFalse(n.config["bridge.vlan_filtering"]) {
+		err = BridgeVLANFilterSetStatus(n.name, "0")
+		if err != nil {
+			n.logger.Warn(fmt.Sprintf("%v", err))
+		}
+	} else if n.config["bridge.driver"] != "openvswitch" {
 		err = BridgeVLANFilterSetStatus(n.name, "1")
 		if err != nil {
 			n.logger.Warn(fmt.Sprintf("%v", err))
@@ -882,9 +1106,9 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			fwOpts.FeaturesV4.ICMPDHCPDNSAccess = true
 		}
 
-		// Allow forwarding.
+		// Allow forwarding (unless left to the host).
 		if n.config["bridge.mode"] == "fan" || n.config["ipv4.routing"] == "" || shared.IsTrue(n.config["ipv4.routing"]) {
-			err = util.SysctlSet("net/ipv4/ip_forward", "1")
+			err = n.setupForwarding("ipv4.routing.forwarding", "net/ipv4/ip_forward")
 			if err != nil {
 				return err
 			}
@@ -903,36 +1127,57 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		"--no-ping",   // --no-ping is very important to prevent delays to lease file updates.
 		fmt.Sprintf("--interface=%s", n.name)}
 
-	dnsmasqVersion, err := dnsmasq.GetVersion()
-	if err != nil {
-		return err
+	// The builtin DHCP server is configured alongside dnsmasq and used instead when selected.
+	dhcpdConfig := &dhcpd.Config{
+		Interface:  n.name,
+		LeasesPath: shared.VarPath("networks", n.name, "dnsmasq.leases"),
+		HostsPath:  shared.VarPath("networks", n.name, "dnsmasq.hosts"),
 	}
 
-	// --dhcp-rapid-commit option is only supported on >2.79.
-	minVer, _ := version.NewDottedVersion("2.79")
-	if dnsmasqVersion.Compare(minVer) > 0 {
-		dnsmasqCmd = append(dnsmasqCmd, "--dhcp-rapid-commit")
+	var dhcpdMTU uint64
+	if mtu != "1500" {
+		dhcpdMTU, _ = strconv.ParseUint(mtu, 10, 32)
 	}
 
-	if !daemon.Debug {
-		// --quiet options are only supported on >2.67.
-		minVer, _ := version.NewDottedVersion("2.67")
+	if !n.usesBuiltinDHCP() {
+		dnsmasqVersion, err := dnsmasq.GetVersion()
+		if err != nil {
+			return err
+		}
+
+		// --dhcp-rapid-commit option is only supported on >2.79.
+		minVer, _ := version.NewDottedVersion("2.79")
+		if dnsmasqVersion.Compare(minVer) > 0 {
+			dnsmasqCmd = append(dnsmasqCmd, "--dhcp-rapid-commit")
+		}
+
+		if !daemon.Debug {
+			// --quiet options are only supported on >2.67.
+			minVer, _ := version.NewDottedVersion("2.67")
 
-		if err == nil && dnsmasqVersion.Compare(minVer) > 0 {
-			dnsmasqCmd = append(dnsmasqCmd, []string{"--quiet-dhcp", "--quiet-dhcp6", "--quiet-ra"}...)
+			if dnsmasqVersion.Compare(minVer) > 0 {
+				dnsmasqCmd = append(dnsmasqCmd, []string{"--quiet-dhcp", "--quiet-dhcp6", "--quiet-ra"}...)
+			}
 		}
 	}
 
 	// Configure IPv4.
 	if !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"}) {
-		// Parse the subnet.
-		ipAddress, subnet, err := net.ParseCIDR(n.config["ipv4.address"])
-		if err != nil {
-			return fmt.Errorf("Failed parsing ipv4.address: %w", err)
+		// Parse the subnets (the first address is the primary address of the network).
+		addresses := util.BridgeAddresses(n.config["ipv4.address"])
+		subnets := make([]*net.IPNet, 0, len(addresses))
+		for _, address := range addresses {
+			ipAddress, subnet, err := net.ParseCIDR(address)
+			if err != nil {
+				return fmt.Errorf("Failed parsing ipv4.address: %w", err)
+			}
+
+			subnets = append(subnets, subnet)
+
+			// Update the dnsmasq config.
+			dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--listen-address=%s", ipAddress.String()))
 		}
 
-		// Update the dnsmasq config.
-		dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--listen-address=%s", ipAddress.String()))
 		if n.DHCPv4Subnet() != nil {
 			if !shared.StringInSlice("--dhcp-no-override", dnsmasqCmd) {
 				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-no-override", "--dhcp-authoritative", fmt.Sprintf("--dhcp-leasefile=%s", shared.VarPath("networks", n.name, "dnsmasq.leases")), fmt.Sprintf("--dhcp-hostsfile=%s", shared.VarPath("networks", n.name, "dnsmasq.hosts"))}...)
@@ -956,25 +1201,42 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				expiry = n.config["ipv4.dhcp.expiry"]
 			}
 
+			dhcpdConfig.IPv4 = &dhcpd.ConfigIPv4{
+				Address:      addresses[0],
+				Gateway:      n.config["ipv4.dhcp.gateway"],
+				LeaseTime:    expiry,
+				MTU:          uint32(dhcpdMTU),
+				DomainSearch: shared.SplitNTrimSpace(dnsSearch, ",", -1, true),
+			}
+
 			if n.config["ipv4.dhcp.ranges"] != "" {
 				for _, dhcpRange := range strings.Split(n.config["ipv4.dhcp.ranges"], ",") {
 					dhcpRange = strings.TrimSpace(dhcpRange)
 					dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%s", strings.Replace(dhcpRange, "-", ",", -1), expiry)}...)
+					dhcpdConfig.IPv4.Ranges = append(dhcpdConfig.IPv4.Ranges, dhcpRange)
 				}
 			} else {
-				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%s,%s", dhcpalloc.GetIP(subnet, 2).String(), dhcpalloc.GetIP(subnet, -2).String(), expiry)}...)
+				for _, subnet := range subnets {
+					dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%s,%s", dhcpalloc.GetIP(subnet, 2).String(), dhcpalloc.GetIP(subnet, -2).String(), expiry)}...)
+				}
+
+				// The builtin DHCP server only serves the primary subnet.
+				dhcpdConfig.IPv4.Ranges = append(dhcpdConfig.IPv4.Ranges, fmt.Sprintf("%s-%s", dhcpalloc.GetIP(subnets[0], 2).String(), dhcpalloc.GetIP(subnets[0], -2).String()))
 			}
 		}
 
-		// Add the address.
-		addr := &ip.Addr{
-			DevName: n.name,
-			Address: n.config["ipv4.address"],
-			Family:  ip.FamilyV4,
-		}
-		err = addr.Add()
-		if err != nil {
-			return err
+		// Add the addresses.
+		for _, address := range addresses {
+			addr := &ip.Addr{
+				DevName: n.name,
+				Address: address,
+				Family:  ip.FamilyV4,
+			}
+
+			err = addr.Add()
+			if err != nil {
+				return err
+			}
 		}
 
 		// Configure NAT.
@@ -987,7 +1249,7 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 
 			fwOpts.SNATV4 = &firewallDrivers.SNATOpts{
 				SNATAddress: srcIP,
-				Subnet:      subnet,
+				Subnets:     subnets,
 			}
 
 			if n.config["ipv4.nat.order"] == "after" {
@@ -1052,14 +1314,30 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			return err
 		}
 
-		// Parse the subnet.
-		ipAddress, subnet, err := net.ParseCIDR(n.config["ipv6.address"])
-		if err != nil {
-			return fmt.Errorf("Failed parsing ipv6.address: %w", err)
+		// Parse the subnets (the first address is the primary address of the network).
+		addresses := util.BridgeAddresses(n.config["ipv6.address"])
+		subnets := make([]*net.IPNet, 0, len(addresses))
+		largeSubnet := false
+		for _, address := range addresses {
+			ipAddress, subnet, err := net.ParseCIDR(address)
+			if err != nil {
+				return fmt.Errorf("Failed parsing ipv6.address: %w", err)
+			}
+
+			subnets = append(subnets, subnet)
+
+			subnetSize, _ := subnet.Mask.Size()
+			if subnetSize > 64 {
+				largeSubnet = true
+			}
+
+			// Update the dnsmasq config.
+			dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--listen-address=%s", ipAddress.String()))
 		}
-		subnetSize, _ := subnet.Mask.Size()
 
-		if subnetSize > 64 {
+		subnetSize, _ := subnets[0].Mask.Size()
+
+		if largeSubnet {
 			n.logger.Warn("IPv6 networks with a prefix larger than 64 aren't properly supported by dnsmasq")
 
 			err = n.state.DB.Cluster.UpsertWarningLocalNode(n.project, dbCluster.TypeNetwork, int(n.id), db.WarningLargerIPv6PrefixThanSupported, "")
@@ -1073,8 +1351,13 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			}
 		}
 
-		// Update the dnsmasq config.
-		dnsmasqCmd = append(dnsmasqCmd, []string{fmt.Sprintf("--listen-address=%s", ipAddress.String()), "--enable-ra"}...)
+		dnsmasqCmd = append(dnsmasqCmd, "--enable-ra")
+		dhcpdConfig.IPv6 = &dhcpd.ConfigIPv6{
+			Address:      addresses[0],
+			Autonomous:   subnetSize == 64,
+			MTU:          uint32(dhcpdMTU),
+			DomainSearch: shared.SplitNTrimSpace(n.config["dns.search"], ",", -1, true),
+		}
 		if n.DHCPv6Subnet() != nil {
 			if n.hasIPv6Firewall() {
 				fwOpts.FeaturesV6.ICMPDHCPDNSAccess = true
@@ -1094,10 +1377,23 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				if n.config["ipv6.dhcp.ranges"] != "" {
 					for _, dhcpRange := range strings.Split(n.config["ipv6.dhcp.ranges"], ",") {
 						dhcpRange = strings.TrimSpace(dhcpRange)
-						dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%d,%s", strings.Replace(dhcpRange, "-", ",", -1), subnetSize, expiry)}...)
+
+						// Use the prefix length of the subnet the range is part of.
+						rangeSubnetSize := subnetSize
+						for _, subnet := range subnets {
+							if subnet.Contains(net.ParseIP(strings.SplitN(dhcpRange, "-", 2)[0])) {
+								rangeSubnetSize, _ = subnet.Mask.Size()
+								break
+							}
+						}
+
+						dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%d,%s", strings.Replace(dhcpRange, "-", ",", -1), rangeSubnetSize, expiry)}...)
 					}
 				} else {
-					dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%s,%d,%s", dhcpalloc.GetIP(subnet, 2), dhcpalloc.GetIP(subnet, -1), subnetSize, expiry)}...)
+					for _, subnet := range subnets {
+						size, _ := subnet.Mask.Size()
+						dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%s,%d,%s", dhcpalloc.GetIP(subnet, 2), dhcpalloc.GetIP(subnet, -1), size, expiry)}...)
+					}
 				}
 			} else {
 				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("::,constructor:%s,ra-stateless,ra-names", n.name)}...)
@@ -1114,23 +1410,32 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 				return err
 			}
 
-			// First set accept_ra to 2 for everything.
-			for _, entry := range entries {
-				content, err := ioutil.ReadFile(fmt.Sprintf("/proc/sys/net/ipv6/conf/%s/accept_ra", entry.Name()))
-				if err == nil && string(content) != "1\n" {
-					continue
-				}
+			// First set accept_ra to 2 for everything (unless disabled).
+			if n.config["ipv6.routing.accept_ra"] == "" || shared.IsTrue(n.config["ipv6.routing.accept_ra"]) {
+				for _, entry := range entries {
+					content, err := ioutil.ReadFile(fmt.Sprintf("/proc/sys/net/ipv6/conf/%s/accept_ra", entry.Name()))
+					if err == nil && string(content) != "1\n" {
+						continue
+					}
 
-				err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/accept_ra", entry.Name()), "2")
-				if err != nil && !os.IsNotExist(err) {
-					return err
+					err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/accept_ra", entry.Name()), "2")
+					if err != nil && !os.IsNotExist(err) {
+						return err
+					}
 				}
 			}
 
-			// Then set forwarding for all of them.
-			for _, entry := range entries {
-				err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/forwarding", entry.Name()), "1")
-				if err != nil && !os.IsNotExist(err) {
+			// Then set forwarding for all of them (unless left to the host).
+			if n.config["ipv6.routing.forwarding"] == "" || shared.IsTrue(n.config["ipv6.routing.forwarding"]) {
+				for _, entry := range entries {
+					err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/forwarding", entry.Name()), "1")
+					if err != nil && !os.IsNotExist(err) {
+						return err
+					}
+				}
+			} else {
+				err = n.setupForwarding("ipv6.routing.forwarding", "net/ipv6/conf/all/forwarding")
+				if err != nil {
 					return err
 				}
 			}
@@ -1140,15 +1445,18 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			}
 		}
 
-		// Add the address.
-		addr := &ip.Addr{
-			DevName: n.name,
-			Address: n.config["ipv6.address"],
-			Family:  ip.FamilyV6,
-		}
-		err = addr.Add()
-		if err != nil {
-			return err
+		// Add the addresses.
+		for _, address := range addresses {
+			addr := &ip.Addr{
+				DevName: n.name,
+				Address: address,
+				Family:  ip.FamilyV6,
+			}
+
+			err = addr.Add()
+			if err != nil {
+				return err
+			}
 		}
 
 		// Configure NAT.
@@ -1161,7 +1469,7 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 
 			fwOpts.SNATV6 = &firewallDrivers.SNATOpts{
 				SNATAddress: srcIP,
-				Subnet:      subnet,
+				Subnets:     subnets,
 			}
 
 			if n.config["ipv6.nat.order"] == "after" {
@@ -1190,6 +1498,14 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		n.applyBootRoutesV6(ctRoutes)
 	}
 
+	// Setup the tunnels encryption before the tunnels are brought up.
+	err = n.ipsecSetup(oldConfig)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = n.ipsecClear(n.config) })
+
 	// Configure the fan.
 	dnsClustered := false
 	dnsClusteredAddress := ""
@@ -1285,6 +1601,14 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			fmt.Sprintf("--dhcp-hostsfile=%s", shared.VarPath("networks", n.name, "dnsmasq.hosts")),
 			"--dhcp-range", fmt.Sprintf("%s,%s,%s", dhcpalloc.GetIP(hostSubnet, 2).String(), dhcpalloc.GetIP(hostSubnet, -2).String(), expiry)}...)
 
+		fanMTU, _ := strconv.ParseUint(mtu, 10, 32)
+		dhcpdConfig.IPv4 = &dhcpd.ConfigIPv4{
+			Address:   fanAddress,
+			Ranges:    []string{fmt.Sprintf("%s-%s", dhcpalloc.GetIP(hostSubnet, 2).String(), dhcpalloc.GetIP(hostSubnet, -2).String())},
+			LeaseTime: expiry,
+			MTU:       uint32(fanMTU),
+		}
+
 		// Setup the tunnel.
 		if n.config["fan.type"] == "ipip" {
 			r := &ip.Route{
@@ -1355,7 +1679,7 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		if shared.IsTrue(n.config["ipv4.nat"]) {
 			fwOpts.SNATV4 = &firewallDrivers.SNATOpts{
 				SNATAddress: nil, // Use MASQUERADE mode.
-				Subnet:      overlaySubnet,
+				Subnets:     []*net.IPNet{overlaySubnet},
 			}
 
 			if n.config["ipv4.nat.order"] == "after" {
@@ -1379,105 +1703,139 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 
 		dnsClusteredAddress = strings.Split(fanAddress, "/")[0]
-	}
-
-	// Configure tunnels.
-	for _, tunnel := range tunnels {
-		getConfig := func(key string) string {
-			return n.config[fmt.Sprintf("tunnel.%s.%s", tunnel, key)]
-		}
-
-		tunProtocol := getConfig("protocol")
-		tunLocal := getConfig("local")
-		tunRemote := getConfig("remote")
-		tunName := fmt.Sprintf("%s-%s", n.name, tunnel)
 
-		// Configure the tunnel.
-		if tunProtocol == "gre" {
-			// Skip partial configs.
-			if tunProtocol == "" || tunLocal == "" || tunRemote == "" {
-				continue
+		// Configure the IPv6 overlay. Each member gets a /64 derived from its underlay address, which the
+		// other members reach through a 6rd tunnel mapping the overlay back to the underlay.
+		if n.config["fan.overlay_subnet6"] != "" {
+			_, overlaySubnet6, err := net.ParseCIDR(n.config["fan.overlay_subnet6"])
+			if err != nil {
+				return fmt.Errorf("Failed parsing fan.overlay_subnet6: %w", err)
 			}
 
-			gretap := &ip.Gretap{
-				Link:   ip.Link{Name: tunName},
-				Local:  tunLocal,
-				Remote: tunRemote,
-			}
-			err := gretap.Add()
+			fanAddress6, err := fanAddressV6(net.ParseIP(devAddr), underlaySubnet, overlaySubnet6)
 			if err != nil {
 				return err
 			}
-		} else if tunProtocol == "vxlan" {
-			tunGroup := getConfig("group")
-			tunInterface := getConfig("interface")
 
-			// Skip partial configs.
-			if tunProtocol == "" {
-				continue
+			err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/disable_ipv6", n.name), "0")
+			if err != nil {
+				return err
 			}
 
-			vxlan := &ip.Vxlan{
-				Link: ip.Link{Name: tunName},
+			// Setup the tunnel.
+			sit := &ip.Sit{
+				Link:             ip.Link{Name: fmt.Sprintf("%s-6rd", n.name), MTU: mtu},
+				Local:            devAddr,
+				SixRDPrefix:      overlaySubnet6.String(),
+				SixRDRelayPrefix: underlaySubnet.String(),
 			}
-			if tunLocal != "" && tunRemote != "" {
-				vxlan.Local = tunLocal
-				vxlan.Remote = tunRemote
-			} else {
-				if tunGroup == "" {
-					tunGroup = "239.0.0.1"
-				}
-
-				devName := tunInterface
-				if devName == "" {
-					_, devName, err = DefaultGatewaySubnetV4()
-					if err != nil {
-						return err
-					}
-				}
 
-				vxlan.Group = tunGroup
-				vxlan.DevName = devName
+			err = sit.Add()
+			if err != nil {
+				return err
 			}
 
-			tunPort := getConfig("port")
-			if tunPort == "" {
-				tunPort = "0"
+			err = sit.SetUp()
+			if err != nil {
+				return err
 			}
-			vxlan.DstPort = tunPort
 
-			tunID := getConfig("id")
-			if tunID == "" {
-				tunID = "1"
+			r := &ip.Route{
+				DevName: sit.Name,
+				Route:   overlaySubnet6.String(),
+				Proto:   "static",
+				Family:  ip.FamilyV6,
 			}
-			vxlan.VxlanID = tunID
 
-			tunTTL := getConfig("ttl")
-			if tunTTL == "" {
-				tunTTL = "1"
+			err = r.Add()
+			if err != nil {
+				return err
+			}
+
+			// Add the address.
+			ipAddr := &ip.Addr{
+				DevName: n.name,
+				Address: fanAddress6,
+				Family:  ip.FamilyV6,
 			}
-			vxlan.TTL = tunTTL
 
-			err := vxlan.Add()
+			err = ipAddr.Add()
 			if err != nil {
 				return err
 			}
-		}
 
-		// Bridge it and bring up.
-		err = AttachInterface(n.name, tunName)
-		if err != nil {
-			return err
-		}
+			// Update the dnsmasq config.
+			_, hostSubnet6, err := net.ParseCIDR(fanAddress6)
+			if err != nil {
+				return fmt.Errorf("Failed parsing fan IPv6 address: %w", err)
+			}
 
-		tunLink := &ip.Link{Name: tunName}
-		err = tunLink.SetMTU(mtu)
-		if err != nil {
-			return err
+			dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--listen-address=%s", strings.Split(fanAddress6, "/")[0]), "--enable-ra")
+			dhcpdConfig.IPv6 = &dhcpd.ConfigIPv6{
+				Address:      fanAddress6,
+				Autonomous:   true,
+				MTU:          uint32(fanMTU),
+				DomainSearch: shared.SplitNTrimSpace(n.config["dns.search"], ",", -1, true),
+			}
+
+			if n.hasDHCPv6() {
+				if n.hasIPv6Firewall() {
+					fwOpts.FeaturesV6.ICMPDHCPDNSAccess = true
+				}
+
+				if shared.IsTrue(n.config["ipv6.dhcp.stateful"]) {
+					expiry := "1h"
+					if n.config["ipv6.dhcp.expiry"] != "" {
+						expiry = n.config["ipv6.dhcp.expiry"]
+					}
+
+					dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("%s,%s,64,%s", dhcpalloc.GetIP(hostSubnet6, 2), dhcpalloc.GetIP(hostSubnet6, -1), expiry)}...)
+				} else {
+					dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("::,constructor:%s,ra-stateless,ra-names", n.name)}...)
+				}
+			} else {
+				dnsmasqCmd = append(dnsmasqCmd, []string{"--dhcp-range", fmt.Sprintf("::,constructor:%s,ra-only", n.name)}...)
+			}
+
+			// Allow forwarding, keeping router advertisements accepted on the underlay interface (unless
+			// disabled).
+			content, err := ioutil.ReadFile(fmt.Sprintf("/proc/sys/net/ipv6/conf/%s/accept_ra", devName))
+			if err == nil && string(content) == "1\n" && (n.config["ipv6.routing.accept_ra"] == "" || shared.IsTrue(n.config["ipv6.routing.accept_ra"])) {
+				err = util.SysctlSet(fmt.Sprintf("net/ipv6/conf/%s/accept_ra", devName), "2")
+				if err != nil {
+					return err
+				}
+			}
+
+			err = n.setupForwarding("ipv6.routing.forwarding", "net/ipv6/conf/all/forwarding")
+			if err != nil {
+				return err
+			}
+
+			if n.hasIPv6Firewall() {
+				fwOpts.FeaturesV6.ForwardingAllow = true
+			}
+
+			// Configure NAT.
+			if shared.IsTrue(n.config["ipv6.nat"]) {
+				fwOpts.SNATV6 = &firewallDrivers.SNATOpts{
+					SNATAddress: nil, // Use MASQUERADE mode.
+					Subnets:     []*net.IPNet{overlaySubnet6},
+				}
+
+				if n.config["ipv6.nat.order"] == "after" {
+					fwOpts.SNATV6.Append = true
+				}
+			}
 		}
+	}
+
+	// Configure tunnels.
+	for _, tunnel := range tunnels {
+		// Reset any keepalive state, the tunnel always starts on its primary remote.
+		n.tunnelHealthReset(tunnel)
 
-		// Bring up tunnel interface.
-		err = tunLink.SetUp()
+		err = n.tunnelSetup(tunnel, n.config[fmt.Sprintf("tunnel.%s.remote", tunnel)], mtu)
 		if err != nil {
 			return err
 		}
@@ -1489,25 +1847,30 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 	}
 
-	// Generate and load apparmor profiles.
-	err = apparmor.NetworkLoad(n.state.OS, n)
+	// Configure the traffic mirroring collector.
+	err = n.mirrorSetup()
 	if err != nil {
 		return err
 	}
 
-	// Kill any existing dnsmasq and forkdns daemon for this network.
-	err = dnsmasq.Kill(n.name, false)
+	// Generate and load apparmor profiles.
+	err = apparmor.NetworkLoad(n.state.OS, n)
 	if err != nil {
 		return err
 	}
 
-	err = n.killForkDNS()
-	if err != nil {
-		return err
-	}
+	// Configure the builtin DHCP server.
+	if n.UsesDNSMasq() && n.usesBuiltinDHCP() {
+		err = n.killDNSMasq()
+		if err != nil {
+			return err
+		}
 
-	// Configure dnsmasq.
-	if n.UsesDNSMasq() {
+		err = n.spawnDHCPD(dhcpdConfig)
+		if err != nil {
+			return err
+		}
+	} else if n.UsesDNSMasq() {
 		// Setup the dnsmasq domain.
 		dnsDomain := n.config["dns.domain"]
 		if dnsDomain == "" {
@@ -1526,11 +1889,6 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			}
 		}
 
-		// Create a config file to contain additional config (and to prevent dnsmasq from reading /etc/dnsmasq.conf)
-		err = ioutil.WriteFile(shared.VarPath("networks", n.name, "dnsmasq.raw"), []byte(fmt.Sprintf("%s\n", n.config["raw.dnsmasq"])), 0644)
-		if err != nil {
-			return err
-		}
 		dnsmasqCmd = append(dnsmasqCmd, fmt.Sprintf("--conf-file=%s", shared.VarPath("networks", n.name, "dnsmasq.raw")))
 
 		// Attempt to drop privileges.
@@ -1541,6 +1899,29 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			dnsmasqCmd = append(dnsmasqCmd, []string{"-g", n.state.OS.UnprivGroup}...)
 		}
 
+		rawConfig := []byte(fmt.Sprintf("%s\n", n.config["raw.dnsmasq"]))
+
+		apparmorProfile := ""
+		if n.config["raw.dnsmasq"] == "" {
+			apparmorProfile = apparmor.DnsmasqProfileName(n)
+		}
+
+		// Only restart dnsmasq if the way it is started has changed, otherwise the static leases update below
+		// makes it reload its hosts files.
+		reload := n.dnsmasqReloadable(dnsmasqCmd, apparmorProfile, rawConfig, dnsClustered)
+		if !reload {
+			err = n.killDNSMasq()
+			if err != nil {
+				return err
+			}
+		}
+
+		// Create a config file to contain additional config (and to prevent dnsmasq from reading /etc/dnsmasq.conf)
+		err = ioutil.WriteFile(shared.VarPath("networks", n.name, "dnsmasq.raw"), rawConfig, 0644)
+		if err != nil {
+			return err
+		}
+
 		// Create DHCP hosts directory.
 		if !shared.PathExists(shared.VarPath("networks", n.name, "dnsmasq.hosts")) {
 			err = os.MkdirAll(shared.VarPath("networks", n.name, "dnsmasq.hosts"), 0755)
@@ -1561,82 +1942,20 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 			return err
 		}
 
-		// Create subprocess object dnsmasq.
-		dnsmasqLogPath := shared.LogPath(fmt.Sprintf("dnsmasq.%s.log", n.name))
-		p, err := subprocess.NewProcess(command, dnsmasqCmd, "", dnsmasqLogPath)
-		if err != nil {
-			return fmt.Errorf("Failed to create subprocess: %s", err)
-		}
-
-		// Apply AppArmor confinement.
-		if n.config["raw.dnsmasq"] == "" {
-			p.SetApparmor(apparmor.DnsmasqProfileName(n))
-
-			err = warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(n.state.DB.Cluster, n.project, db.WarningAppArmorDisabledDueToRawDnsmasq, dbCluster.TypeNetwork, int(n.id))
-			if err != nil {
-				n.logger.Warn("Failed to resolve warning", logger.Ctx{"err": err})
-			}
+		if reload {
+			n.logger.Debug("Reloaded dnsmasq as its configuration is unchanged")
 		} else {
-			n.logger.Warn("Skipping AppArmor for dnsmasq due to raw.dnsmasq being set", logger.Ctx{"name": n.name})
-
-			err = n.state.DB.Cluster.UpsertWarningLocalNode(n.project, dbCluster.TypeNetwork, int(n.id), db.WarningAppArmorDisabledDueToRawDnsmasq, "")
+			err = n.spawnDNSMasq(command, dnsmasqCmd, dnsClustered, dnsClusteredAddress)
 			if err != nil {
-				n.logger.Warn("Failed to create warning", logger.Ctx{"err": err})
+				return err
 			}
 		}
-
-		// Start dnsmasq.
-		err = p.Start()
-		if err != nil {
-			return fmt.Errorf("Failed to run: %s %s: %w", command, strings.Join(dnsmasqCmd, " "), err)
-		}
-
-		// Check dnsmasq started OK.
-		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Millisecond*time.Duration(500)))
-		_, err = p.Wait(ctx)
-		if !errors.Is(err, context.DeadlineExceeded) {
-			stderr, _ := ioutil.ReadFile(dnsmasqLogPath)
-
-			// Just log an error if dnsmasq has exited, and still proceed with normal setup so we
-			// don't leave the firewall in an inconsistent state.
-			n.logger.Error("The dnsmasq process exited prematurely", logger.Ctx{"err": err, "stderr": strings.TrimSpace(string(stderr))})
-		}
-		cancel()
-
-		err = p.Save(shared.VarPath("networks", n.name, "dnsmasq.pid"))
+	} else {
+		err = n.killDNSMasq()
 		if err != nil {
-			// Kill Process if started, but could not save the file.
-			err2 := p.Stop()
-			if err != nil {
-				return fmt.Errorf("Could not kill subprocess while handling saving error: %s: %s", err, err2)
-			}
-
-			return fmt.Errorf("Failed to save subprocess details: %s", err)
+			return err
 		}
 
-		// Spawn DNS forwarder if needed (backgrounded to avoid deadlocks during cluster boot).
-		if dnsClustered {
-			// Create forkdns servers directory.
-			if !shared.PathExists(shared.VarPath("networks", n.name, ForkdnsServersListPath)) {
-				err = os.MkdirAll(shared.VarPath("networks", n.name, ForkdnsServersListPath), 0755)
-				if err != nil {
-					return err
-				}
-			}
-
-			// Create forkdns servers.conf file if doesn't exist.
-			f, err := os.OpenFile(shared.VarPath("networks", n.name, ForkdnsServersListPath+"/"+ForkdnsServersListFile), os.O_RDONLY|os.O_CREATE, 0666)
-			if err != nil {
-				return err
-			}
-			_ = f.Close()
-
-			err = n.spawnForkDNS(dnsClusteredAddress)
-			if err != nil {
-				return err
-			}
-		}
-	} else {
 		// Clean up old dnsmasq config if exists and we are not starting dnsmasq.
 		leasesPath := shared.VarPath("networks", n.name, "dnsmasq.leases")
 		if shared.PathExists(leasesPath) {
@@ -1656,6 +1975,31 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 	}
 
+	// Watch the DHCP leases to emit the lease lifecycle events.
+	if n.UsesDNSMasq() {
+		err = n.leasesWatchStart()
+		if err != nil {
+			return err
+		}
+	} else {
+		n.leasesWatchStop()
+	}
+
+	// Always allow forwarding the traffic with the peered networks and don't translate it.
+	peerInterfaces, peerSubnets, err := n.peerNetworks()
+	if err != nil {
+		return err
+	}
+
+	fwOpts.PeerInterfaces = peerInterfaces
+	for _, subnet := range peerSubnets {
+		if subnet.IP.To4() != nil && fwOpts.SNATV4 != nil {
+			fwOpts.SNATV4.Exclude = append(fwOpts.SNATV4.Exclude, subnet)
+		} else if subnet.IP.To4() == nil && fwOpts.SNATV6 != nil {
+			fwOpts.SNATV6.Exclude = append(fwOpts.SNATV6.Exclude, subnet)
+		}
+	}
+
 	// Setup firewall.
 	n.logger.Debug("Setting up firewall")
 	err = n.state.Firewall.NetworkSetup(n.name, fwOpts)
@@ -1684,6 +2028,12 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		return err
 	}
 
+	// Setup network load balancers.
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
 	// Setup BGP.
 	err = n.bgpSetup(oldConfig)
 	if err != nil {
@@ -1708,6 +2058,12 @@ func (n *bridge) Stop() error {
 		return err
 	}
 
+	// Clear the tunnels encryption.
+	err = n.ipsecClear(n.config)
+	if err != nil {
+		return err
+	}
+
 	// Destroy the bridge interface
 	if n.config["bridge.driver"] == "openvswitch" {
 		ovs := openvswitch.NewOVS()
@@ -1753,12 +2109,20 @@ func (n *bridge) Stop() error {
 		return err
 	}
 
+	// Stop watching the DHCP leases.
+	n.leasesWatchStop()
+
 	// Get a list of interfaces
 	ifaces, err := net.Interfaces()
 	if err != nil {
 		return err
 	}
 
+	// Clear tunnel keepalive state.
+	for _, tunnel := range n.getTunnels() {
+		n.tunnelHealthReset(tunnel)
+	}
+
 	// Cleanup any existing tunnel device
 	for _, iface := range ifaces {
 		if strings.HasPrefix(iface.Name, fmt.Sprintf("%s-", n.name)) {
@@ -1857,9 +2221,26 @@ func (n *bridge) Update(newNetwork api.NetworkPut, targetNode string, clientType
 		return err
 	}
 
-	// Restart the network if needed.
+	// Restart the network if needed, or apply link changes in place if that is all that changed.
 	if len(changedKeys) > 0 {
-		err = n.setup(oldNetwork.Config)
+		if n.linkConfigLiveUpdatable(changedKeys) {
+			err = n.applyLinkConfig(oldNetwork.Config)
+		} else {
+			err = n.setup(oldNetwork.Config)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Refresh the peered networks as their firewall depends on the network's subnets.
+		for _, key := range []string{"ipv4.address", "ipv6.address", "ipv4.routes", "ipv6.routes"} {
+			if shared.StringInSlice(key, changedKeys) {
+				err = n.peerNetworksRefresh()
+				break
+			}
+		}
+
 		if err != nil {
 			return err
 		}
@@ -1869,6 +2250,144 @@ func (n *bridge) Update(newNetwork api.NetworkPut, targetNode string, clientType
 	return nil
 }
 
+// spawnDNSMasq starts dnsmasq with the supplied arguments, as well as the DNS forwarder if needed.
+func (n *bridge) spawnDNSMasq(command string, dnsmasqCmd []string, dnsClustered bool, dnsClusteredAddress string) error {
+	// Create subprocess object dnsmasq.
+	dnsmasqLogPath := shared.LogPath(fmt.Sprintf("dnsmasq.%s.log", n.name))
+	p, err := subprocess.NewProcess(command, dnsmasqCmd, "", dnsmasqLogPath)
+	if err != nil {
+		return fmt.Errorf("Failed to create subprocess: %s", err)
+	}
+
+	// Apply AppArmor confinement.
+	if n.config["raw.dnsmasq"] == "" {
+		p.SetApparmor(apparmor.DnsmasqProfileName(n))
+
+		err = warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(n.state.DB.Cluster, n.project, db.WarningAppArmorDisabledDueToRawDnsmasq, dbCluster.TypeNetwork, int(n.id))
+		if err != nil {
+			n.logger.Warn("Failed to resolve warning", logger.Ctx{"err": err})
+		}
+	} else {
+		n.logger.Warn("Skipping AppArmor for dnsmasq due to raw.dnsmasq being set", logger.Ctx{"name": n.name})
+
+		err = n.state.DB.Cluster.UpsertWarningLocalNode(n.project, dbCluster.TypeNetwork, int(n.id), db.WarningAppArmorDisabledDueToRawDnsmasq, "")
+		if err != nil {
+			n.logger.Warn("Failed to create warning", logger.Ctx{"err": err})
+		}
+	}
+
+	// Start dnsmasq.
+	err = p.Start()
+	if err != nil {
+		return fmt.Errorf("Failed to run: %s %s: %w", command, strings.Join(dnsmasqCmd, " "), err)
+	}
+
+	// Check dnsmasq started OK.
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Millisecond*time.Duration(500)))
+	_, err = p.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		stderr, _ := ioutil.ReadFile(dnsmasqLogPath)
+
+		// Just log an error if dnsmasq has exited, and still proceed with normal setup so we
+		// don't leave the firewall in an inconsistent state.
+		n.logger.Error("The dnsmasq process exited prematurely", logger.Ctx{"err": err, "stderr": strings.TrimSpace(string(stderr))})
+	}
+	cancel()
+
+	err = p.Save(shared.VarPath("networks", n.name, "dnsmasq.pid"))
+	if err != nil {
+		// Kill Process if started, but could not save the file.
+		err2 := p.Stop()
+		if err != nil {
+			return fmt.Errorf("Could not kill subprocess while handling saving error: %s: %s", err, err2)
+		}
+
+		return fmt.Errorf("Failed to save subprocess details: %s", err)
+	}
+
+	// Spawn DNS forwarder if needed (backgrounded to avoid deadlocks during cluster boot).
+	if dnsClustered {
+		// Create forkdns servers directory.
+		if !shared.PathExists(shared.VarPath("networks", n.name, ForkdnsServersListPath)) {
+			err = os.MkdirAll(shared.VarPath("networks", n.name, ForkdnsServersListPath), 0755)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Create forkdns servers.conf file if doesn't exist.
+		f, err := os.OpenFile(shared.VarPath("networks", n.name, ForkdnsServersListPath+"/"+ForkdnsServersListFile), os.O_RDONLY|os.O_CREATE, 0666)
+		if err != nil {
+			return err
+		}
+		_ = f.Close()
+
+		err = n.spawnForkDNS(dnsClusteredAddress)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dnsmasqReloadable returns whether the running dnsmasq for this network was started with the same arguments,
+// AppArmor profile and raw config. In that case it can just be told to reload its hosts files rather than being
+// restarted, which would briefly interrupt DHCP and clear its DNS cache.
+func (n *bridge) dnsmasqReloadable(dnsmasqCmd []string, apparmorProfile string, rawConfig []byte, dnsClustered bool) bool {
+	pidPath := shared.VarPath("networks", n.name, "dnsmasq.pid")
+	if !shared.PathExists(pidPath) {
+		return false
+	}
+
+	p, err := subprocess.ImportProcess(pidPath)
+	if err != nil || p.Apparmor != apparmorProfile {
+		return false
+	}
+
+	// Check the process is still running with the same arguments (this also guards against a reused PID).
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", p.PID))
+	if err != nil {
+		return false
+	}
+
+	args := strings.Split(strings.TrimSuffix(string(cmdline), "\x00"), "\x00")
+	if len(args) < 1 || strings.Join(args[1:], "\x00") != strings.Join(dnsmasqCmd, "\x00") {
+		return false
+	}
+
+	// The raw config file is only read on startup.
+	oldRawConfig, err := ioutil.ReadFile(shared.VarPath("networks", n.name, "dnsmasq.raw"))
+	if err != nil || !bytes.Equal(oldRawConfig, rawConfig) {
+		return false
+	}
+
+	// The DNS forwarder is started alongside dnsmasq in clustered DNS mode and must still be running too.
+	if dnsClustered {
+		forkdns, err := subprocess.ImportProcess(shared.VarPath("networks", n.name, "forkdns.pid"))
+		if err != nil {
+			return false
+		}
+
+		_, err = forkdns.GetPid()
+		if err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// killDNSMasq stops any existing dnsmasq and forkdns daemon for this network.
+func (n *bridge) killDNSMasq() error {
+	err := dnsmasq.Kill(n.name, false)
+	if err != nil {
+		return err
+	}
+
+	return n.killForkDNS()
+}
+
 func (n *bridge) spawnForkDNS(listenAddress string) error {
 	// Setup the dnsmasq domain
 	dnsDomain := n.config["dns.domain"]
@@ -1915,36 +2434,102 @@ func (n *bridge) spawnForkDNS(listenAddress string) error {
 	return nil
 }
 
-// HandleHeartbeat refreshes forkdns servers. Retrieves the IPv4 address of each cluster node (excluding ourselves)
-// for this network. It then updates the forkdns server list file if there are changes.
-func (n *bridge) HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error {
-	// Make sure forkdns has been setup.
-	if !shared.PathExists(shared.VarPath("networks", n.name, "forkdns.pid")) {
-		return nil
+// usesBuiltinDHCP returns whether the network uses the builtin DHCP server instead of dnsmasq.
+func (n *bridge) usesBuiltinDHCP() bool {
+	return n.config["dns.provider"] == "builtin"
+}
+
+// spawnDHCPD starts the builtin DHCP server with the given config.
+func (n *bridge) spawnDHCPD(config *dhcpd.Config) error {
+	// Create DHCP hosts directory.
+	if !shared.PathExists(config.HostsPath) {
+		err := os.MkdirAll(config.HostsPath, 0755)
+		if err != nil {
+			return err
+		}
 	}
 
-	addresses := []string{}
-	localAddress, err := node.HTTPSAddress(n.state.DB.Node)
+	// Update the static leases.
+	err := UpdateDNSMasqStatic(n.state, n.name)
 	if err != nil {
 		return err
 	}
 
-	n.logger.Info("Refreshing forkdns peers")
-
-	networkCert := n.state.Endpoints.NetworkCert()
-	for _, node := range heartbeatData.Members {
-		if node.Address == localAddress {
-			// No need to query ourselves.
-			continue
+	// There is no local DNS server, so hand out the upstream nameservers of the host.
+	for _, nameserver := range dhcpd.HostNameservers() {
+		if config.IPv4 != nil && net.ParseIP(nameserver).To4() != nil {
+			config.IPv4.Nameservers = append(config.IPv4.Nameservers, nameserver)
+		} else if config.IPv6 != nil && net.ParseIP(nameserver).To4() == nil {
+			config.IPv6.Nameservers = append(config.IPv6.Nameservers, nameserver)
 		}
+	}
 
-		if !node.Online {
-			n.logger.Warn("Excluding offline member from DNS peers refresh", logger.Ctx{"address": node.Address, "ID": node.ID, "raftID": node.RaftID, "lastHeartbeat": node.LastHeartbeat})
-			continue
-		}
+	configPath := shared.VarPath("networks", n.name, "dhcpd.conf")
+	err = config.Save(configPath)
+	if err != nil {
+		return fmt.Errorf("Failed writing DHCP server config: %w", err)
+	}
 
-		client, err := cluster.Connect(node.Address, networkCert, n.state.ServerCert(), nil, true)
-		if err != nil {
+	// Spawn the daemon using subprocess.
+	command := n.state.OS.ExecPath
+	forkdhcpArgs := []string{"forkdhcp", configPath}
+	logPath := shared.LogPath(fmt.Sprintf("dhcpd.%s.log", n.name))
+
+	p, err := subprocess.NewProcess(command, forkdhcpArgs, logPath, logPath)
+	if err != nil {
+		return fmt.Errorf("Failed to create subprocess: %s", err)
+	}
+
+	err = p.Start()
+	if err != nil {
+		return fmt.Errorf("Failed to run: %s %s: %w", command, strings.Join(forkdhcpArgs, " "), err)
+	}
+
+	// Use the dnsmasq PID file so that the process is reloaded and stopped like dnsmasq.
+	err = p.Save(shared.VarPath("networks", n.name, "dnsmasq.pid"))
+	if err != nil {
+		// Kill Process if started, but could not save the file.
+		err2 := p.Stop()
+		if err2 != nil {
+			return fmt.Errorf("Could not kill subprocess while handling saving error: %s: %s", err, err2)
+		}
+
+		return fmt.Errorf("Failed to save subprocess details: %s", err)
+	}
+
+	return nil
+}
+
+// HandleHeartbeat refreshes forkdns servers. Retrieves the IPv4 address of each cluster node (excluding ourselves)
+// for this network. It then updates the forkdns server list file if there are changes.
+func (n *bridge) HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error {
+	// Make sure forkdns has been setup.
+	if !shared.PathExists(shared.VarPath("networks", n.name, "forkdns.pid")) {
+		return nil
+	}
+
+	addresses := []string{}
+	localAddress, err := node.HTTPSAddress(n.state.DB.Node)
+	if err != nil {
+		return err
+	}
+
+	n.logger.Info("Refreshing forkdns peers")
+
+	networkCert := n.state.Endpoints.NetworkCert()
+	for _, node := range heartbeatData.Members {
+		if node.Address == localAddress {
+			// No need to query ourselves.
+			continue
+		}
+
+		if !node.Online {
+			n.logger.Warn("Excluding offline member from DNS peers refresh", logger.Ctx{"address": node.Address, "ID": node.ID, "raftID": node.RaftID, "lastHeartbeat": node.LastHeartbeat})
+			continue
+		}
+
+		client, err := cluster.Connect(node.Address, networkCert, n.state.ServerCert(), nil, true)
+		if err != nil {
 			return err
 		}
 
@@ -1982,1106 +2567,3145 @@ func (n *bridge) HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error {
 	}
 
 	n.logger.Info("Updated forkdns server list", logger.Ctx{"nodes": addresses})
-	return nil
-}
-
-func (n *bridge) getTunnels() []string {
-	tunnels := []string{}
 
-	for k := range n.config {
-		if !strings.HasPrefix(k, "tunnel.") {
-			continue
+	// Refresh the fan tunnel encryption for the new list of peers.
+	if n.config["bridge.mode"] == "fan" && n.config["fan.encryption"] == "ipsec" {
+		tunnels, err := n.ipsecTunnels(n.config)
+		if err != nil {
+			return err
 		}
 
-		fields := strings.Split(k, ".")
-		if !shared.StringInSlice(fields[1], tunnels) {
-			tunnels = append(tunnels, fields[1])
+		for _, tunnel := range tunnels {
+			if tunnel.name == "fan" {
+				n.ipsecKeyingSetRemotes(tunnel.name, tunnel.remotes)
+			}
 		}
 	}
 
-	return tunnels
+	return nil
 }
 
-// bootRoutesV4 returns a list of IPv4 boot routes on the network's device.
-func (n *bridge) bootRoutesV4() ([]string, error) {
-	r := &ip.Route{
-		DevName: n.name,
-		Proto:   "boot",
-		Family:  ip.FamilyV4,
-	}
-	routes, err := r.Show()
-	if err != nil {
-		return nil, err
-	}
-	return routes, nil
+// BridgeMirrorName returns the name of the VXLAN interface sending the mirrored traffic of the instances connected
+// to the bridge network to the collector set in mirror.vxlan.remote.
+func BridgeMirrorName(networkName string) string {
+	return fmt.Sprintf("%s-mirror", networkName)
 }
 
-// bootRoutesV6 returns a list of IPv6 boot routes on the network's device.
-func (n *bridge) bootRoutesV6() ([]string, error) {
-	r := &ip.Route{
-		DevName: n.name,
-		Proto:   "boot",
-		Family:  ip.FamilyV6,
-	}
-	routes, err := r.Show()
-	if err != nil {
-		return nil, err
+// mirrorChanged returns whether the mirror collector settings differ from the old config.
+// Returns false when there is no old config so that an existing collector interface is kept on startup.
+func (n *bridge) mirrorChanged(oldConfig map[string]string) bool {
+	if oldConfig == nil {
+		return false
 	}
-	return routes, nil
-}
 
-// applyBootRoutesV4 applies a list of IPv4 boot routes to the network's device.
-func (n *bridge) applyBootRoutesV4(routes []string) {
-	for _, route := range routes {
-		r := &ip.Route{
-			DevName: n.name,
-			Proto:   "boot",
-			Family:  ip.FamilyV4,
-		}
-		err := r.Replace(strings.Fields(route))
-		if err != nil {
-			// If it fails, then we can't stop as the route has already gone, so just log and continue.
-			n.logger.Error("Failed to restore route", logger.Ctx{"err": err})
+	for _, key := range []string{"mirror.vxlan.remote", "mirror.vxlan.port", "mirror.vxlan.id"} {
+		if oldConfig[key] != n.config[key] {
+			return true
 		}
 	}
-}
 
-// applyBootRoutesV6 applies a list of IPv6 boot routes to the network's device.
-func (n *bridge) applyBootRoutesV6(routes []string) {
-	for _, route := range routes {
-		r := &ip.Route{
-			DevName: n.name,
-			Proto:   "boot",
-			Family:  ip.FamilyV6,
-		}
-		err := r.Replace(strings.Fields(route))
-		if err != nil {
-			// If it fails, then we can't stop as the route has already gone, so just log and continue.
-			n.logger.Error("Failed to restore route", logger.Ctx{"err": err})
-		}
-	}
+	return false
 }
 
-func (n *bridge) fanAddress(underlay *net.IPNet, overlay *net.IPNet) (string, string, string, error) {
-	// Quick checks.
-	underlaySize, _ := underlay.Mask.Size()
-	if underlaySize != 16 && underlaySize != 24 {
-		return "", "", "", fmt.Errorf("Only /16 or /24 underlays are supported at this time")
+// mirrorSetup creates the VXLAN interface sending the mirrored traffic to the collector in mirror.vxlan.remote.
+// The interface isn't connected to the bridge, the instance NICs mirror their traffic to it directly.
+func (n *bridge) mirrorSetup() error {
+	remote := n.config["mirror.vxlan.remote"]
+	if remote == "" {
+		return nil
 	}
 
-	overlaySize, _ := overlay.Mask.Size()
-	if overlaySize != 8 && overlaySize != 16 {
-		return "", "", "", fmt.Errorf("Only /8 or /16 overlays are supported at this time")
+	mirrorName := BridgeMirrorName(n.name)
+	if InterfaceExists(mirrorName) {
+		return nil
 	}
 
-	if overlaySize+(32-underlaySize)+8 > 32 {
-		return "", "", "", fmt.Errorf("Underlay or overlay networks too large to accommodate the FAN")
+	port := n.config["mirror.vxlan.port"]
+	if port == "" {
+		port = "4789"
 	}
 
-	// Get the IP
-	ip, dev, err := n.addressForSubnet(underlay)
-	if err != nil {
-		return "", "", "", err
+	vxlanID := n.config["mirror.vxlan.id"]
+	if vxlanID == "" {
+		vxlanID = "1"
 	}
-	ipStr := ip.String()
 
-	// Force into IPv4 format
-	ipBytes := ip.To4()
-	if ipBytes == nil {
-		return "", "", "", fmt.Errorf("Invalid IPv4: %s", ip)
+	vxlan := &ip.Vxlan{
+		Link:    ip.Link{Name: mirrorName},
+		Remote:  remote,
+		DstPort: port,
+		VxlanID: vxlanID,
 	}
 
-	// Compute the IP
-	ipBytes[0] = overlay.IP[0]
-	if overlaySize == 16 {
-		ipBytes[1] = overlay.IP[1]
-		ipBytes[2] = ipBytes[3]
-	} else if underlaySize == 24 {
-		ipBytes[1] = ipBytes[3]
-		ipBytes[2] = 0
-	} else if underlaySize == 16 {
-		ipBytes[1] = ipBytes[2]
-		ipBytes[2] = ipBytes[3]
+	err := vxlan.Add()
+	if err != nil {
+		return fmt.Errorf("Failed creating mirror collector interface %q: %w", mirrorName, err)
 	}
 
-	ipBytes[3] = 1
+	err = vxlan.SetUp()
+	if err != nil {
+		return fmt.Errorf("Failed bringing up mirror collector interface %q: %w", mirrorName, err)
+	}
 
-	return fmt.Sprintf("%s/%d", ipBytes.String(), overlaySize), dev, ipStr, err
+	return nil
 }
 
-func (n *bridge) addressForSubnet(subnet *net.IPNet) (net.IP, string, error) {
-	ifaces, err := net.Interfaces()
-	if err != nil {
-		return net.IP{}, "", err
-	}
+func (n *bridge) getTunnels() []string {
+	tunnels := []string{}
 
-	for _, iface := range ifaces {
-		// Skip addresses on lo interface in case VIPs are being used on that interface that are part of
-		// the underlay subnet as is unlikely to be the actual intended underlay subnet interface.
-		if iface.Name == "lo" {
+	for k := range n.config {
+		if !strings.HasPrefix(k, "tunnel.") {
 			continue
 		}
 
-		addrs, err := iface.Addrs()
-		if err != nil {
-			continue
+		fields := strings.Split(k, ".")
+		if !shared.StringInSlice(fields[1], tunnels) {
+			tunnels = append(tunnels, fields[1])
 		}
+	}
 
-		for _, addr := range addrs {
-			ip, _, err := net.ParseCIDR(addr.String())
-			if err != nil {
-				continue
-			}
+	return tunnels
+}
 
-			if subnet.Contains(ip) {
-				return ip, iface.Name, nil
-			}
+// bridgeMTU returns the MTU to force on the bridge, or an empty string if the default should be used.
+func (n *bridge) bridgeMTU() string {
+	if n.config["bridge.mtu"] != "" {
+		return n.config["bridge.mtu"]
+	} else if len(n.getTunnels()) > 0 {
+		return "1400"
+	} else if n.config["bridge.mode"] == "fan" {
+		if n.config["fan.type"] == "ipip" {
+			return "1480"
 		}
+
+		return "1450"
 	}
 
-	return net.IP{}, "", fmt.Errorf("No address found in subnet")
+	return ""
 }
 
-func (n *bridge) killForkDNS() error {
-	// Check if we have a running forkdns at all
-	pidPath := shared.VarPath("networks", n.name, "forkdns.pid")
+// bridgeHwaddr returns the MAC address to use for the bridge interface, generating a stable one if not set.
+func (n *bridge) bridgeHwaddr() (string, error) {
+	// Always prefer static MAC address if set.
+	hwAddr := n.config["bridge.hwaddr"]
+	if hwAddr != "" {
+		return hwAddr, nil
+	}
 
-	// If the pid file doesn't exist, there is no process to kill.
-	if !shared.PathExists(pidPath) {
-		return nil
+	// If no cluster wide static MAC address set, then generate one.
+	var seedNodeID int64
+
+	if n.checkClusterWideMACSafe(n.config) != nil {
+		// If not safe to use a cluster wide MAC or in in fan mode, then use cluster node's ID to
+		// generate a stable per-node & network derived random MAC.
+		seedNodeID = n.state.DB.Cluster.GetNodeID()
+	} else {
+		// If safe to use a cluster wide MAC, then use a static cluster node of 0 to generate a
+		// stable per-network derived random MAC.
+		seedNodeID = 0
 	}
 
-	p, err := subprocess.ImportProcess(pidPath)
+	// Load server certificate. This is needs to be the same certificate for all nodes in a cluster.
+	cert, err := util.LoadCert(n.state.OS.VarDir)
 	if err != nil {
-		return fmt.Errorf("Could not read pid file: %s", err)
+		return "", err
 	}
 
-	err = p.Stop()
-	if err != nil && err != subprocess.ErrNotRunning {
-		return fmt.Errorf("Unable to kill dnsmasq: %s", err)
+	// Generate the random seed, this uses the server certificate fingerprint (to ensure that multiple
+	// standalone nodes with the same network ID connected to the same external network don't generate
+	// the same MAC for their networks). It relies on the certificate being the same for all nodes in a
+	// cluster to allow the same MAC to be generated on each bridge interface in the network when
+	// seedNodeID is 0 (when safe to do so).
+	seed := fmt.Sprintf("%s.%d.%d", cert.Fingerprint(), seedNodeID, n.ID())
+	r, err := util.GetStableRandomGenerator(seed)
+	if err != nil {
+		return "", fmt.Errorf("Failed generating stable random bridge MAC: %w", err)
 	}
 
-	return nil
-}
-
-// updateForkdnsServersFile takes a list of node addresses and writes them atomically to
-// the forkdns.servers file ready for forkdns to notice and re-apply its config.
-func (n *bridge) updateForkdnsServersFile(addresses []string) error {
-	// We don't want to race with ourselves here
-	forkdnsServersLock.Lock()
-	defer forkdnsServersLock.Unlock()
+	hwAddr = randomHwaddr(r)
+	n.logger.Debug("Stable MAC generated", logger.Ctx{"seed": seed, "hwAddr": hwAddr})
 
-	permName := shared.VarPath("networks", n.name, ForkdnsServersListPath+"/"+ForkdnsServersListFile)
-	tmpName := permName + ".tmp"
+	return hwAddr, nil
+}
 
-	// Open tmp file and truncate
-	tmpFile, err := os.Create(tmpName)
-	if err != nil {
-		return err
+// linkConfigLiveUpdatable returns whether the changed keys can be applied to the running bridge in place using
+// applyLinkConfig, rather than having to set the whole network up again.
+func (n *bridge) linkConfigLiveUpdatable(changedKeys []string) bool {
+	if !n.isRunning() {
+		return false
 	}
-	defer func() { _ = tmpFile.Close() }()
 
-	for _, address := range addresses {
-		_, err := tmpFile.WriteString(address + "\n")
-		if err != nil {
-			return err
+	for _, key := range changedKeys {
+		if !shared.StringInSlice(key, []string{"bridge.mtu", "bridge.hwaddr"}) {
+			return false
 		}
 	}
 
-	err = tmpFile.Close()
-	if err != nil {
-		return err
+	// Open vSwitch bridges don't use the MTU dummy device and fan bridges derive their MTU from the overlay.
+	if n.config["bridge.driver"] == "openvswitch" || n.config["bridge.mode"] == "fan" {
+		return false
 	}
 
-	// Atomically rename finished file into permanent location so forkdns can pick it up.
-	err = os.Rename(tmpName, permName)
-	if err != nil {
-		return err
+	// The builtin DHCP server only gets its config (including the advertised MTU) on startup.
+	if shared.StringInSlice("bridge.mtu", changedKeys) && n.UsesDNSMasq() && n.usesBuiltinDHCP() {
+		return false
 	}
 
-	return nil
+	return true
 }
 
-// hasIPv4Firewall indicates whether the network has IPv4 firewall enabled.
-func (n *bridge) hasIPv4Firewall() bool {
-	// IPv4 firewall is only enabled if there is a bridge ipv4.address or fan mode, and ipv4.firewall enabled.
-	// When using fan bridge.mode, there can be an empty ipv4.address, so we assume it is active.
-	if (n.config["bridge.mode"] == "fan" || !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"})) && (n.config["ipv4.firewall"] == "" || shared.IsTrue(n.config["ipv4.firewall"])) {
-		return true
-	}
+// applyLinkConfig applies bridge.mtu and bridge.hwaddr changes to the running bridge without interrupting the
+// traffic of the instances connected to it. MTU changes are propagated to the bridge's own ports and to both ends
+// of the NICs of running instances that inherit the network's MTU.
+func (n *bridge) applyLinkConfig(oldConfig map[string]string) error {
+	bridgeLink := &ip.Link{Name: n.name}
 
-	return false
-}
+	if n.config["bridge.hwaddr"] != oldConfig["bridge.hwaddr"] {
+		hwAddr, err := n.bridgeHwaddr()
+		if err != nil {
+			return err
+		}
 
-// hasIPv6Firewall indicates whether the network has IPv6 firewall enabled.
-func (n *bridge) hasIPv6Firewall() bool {
-	// IPv6 firewall is only enabled if there is a bridge ipv6.address and ipv6.firewall enabled.
-	if !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"}) && (n.config["ipv6.firewall"] == "" || shared.IsTrue(n.config["ipv6.firewall"])) {
-		return true
+		err = bridgeLink.SetAddress(hwAddr)
+		if err != nil {
+			return err
+		}
 	}
 
-	return false
-}
-
-// hasDHCPv4 indicates whether the network has DHCPv4 enabled.
-// An empty ipv4.dhcp setting indicates enabled by default.
-func (n *bridge) hasDHCPv4() bool {
-	if n.config["ipv4.dhcp"] == "" || shared.IsTrue(n.config["ipv4.dhcp"]) {
-		return true
+	if n.config["bridge.mtu"] == oldConfig["bridge.mtu"] {
+		return nil
 	}
 
-	return false
-}
+	mtu := n.bridgeMTU()
 
-// hasDHCPv6 indicates whether the network has DHCPv6 enabled.
-// An empty ipv6.dhcp setting indicates enabled by default.
-func (n *bridge) hasDHCPv6() bool {
-	if n.config["ipv6.dhcp"] == "" || shared.IsTrue(n.config["ipv6.dhcp"]) {
-		return true
+	// Add or remove the dummy device used to force the MTU.
+	dummyName := fmt.Sprintf("%s-mtu", n.name)
+	if mtu == "" {
+		mtu = "1500"
+
+		if InterfaceExists(dummyName) {
+			dummy := &ip.Link{Name: dummyName}
+			err := dummy.Delete()
+			if err != nil {
+				return err
+			}
+		}
+	} else if !InterfaceExists(dummyName) {
+		dummy := &ip.Dummy{
+			Link: ip.Link{Name: dummyName, MTU: mtu},
+		}
+
+		err := dummy.Add()
+		if err == nil {
+			err = dummy.SetUp()
+			if err == nil {
+				_ = AttachInterface(n.name, dummyName)
+			}
+		}
 	}
 
-	return false
-}
+	err := bridgeLink.SetMTU(mtu)
+	if err != nil {
+		return err
+	}
 
-// DHCPv4Subnet returns the DHCPv4 subnet (if DHCP is enabled on network).
-func (n *bridge) DHCPv4Subnet() *net.IPNet {
-	// DHCP is disabled on this network.
-	if !n.hasDHCPv4() {
-		return nil
+	// Update the bridge's own ports.
+	ports := []string{dummyName}
+	for _, tunnel := range n.getTunnels() {
+		ports = append(ports, fmt.Sprintf("%s-%s", n.name, tunnel))
 	}
 
-	// Fan mode. Extract DHCP subnet from fan bridge address. Only detectable once network has started.
-	// But if there is no address on the fan bridge then DHCP won't work anyway.
-	if n.config["bridge.mode"] == "fan" {
-		iface, err := net.InterfaceByName(n.name)
+	for _, port := range ports {
+		if !InterfaceExists(port) {
+			continue
+		}
+
+		portLink := &ip.Link{Name: port}
+		err = portLink.SetMTU(mtu)
 		if err != nil {
-			return nil
+			return fmt.Errorf("Failed setting MTU on %q: %w", port, err)
 		}
+	}
 
-		addrs, err := iface.Addrs()
+	n.instancesApplyMTU(mtu)
+
+	// Update the MTU advertised over DHCP.
+	if n.UsesDNSMasq() && n.DHCPv4Subnet() != nil {
+		err = n.dnsmasqApplyMTU(mtu)
 		if err != nil {
-			return nil
+			return err
 		}
+	}
 
-		for _, addr := range addrs {
-			ip, subnet, err := net.ParseCIDR(addr.String())
+	n.logger.Debug("Applied link config to running bridge", logger.Ctx{"mtu": mtu})
+
+	return nil
+}
+
+// instancesApplyMTU sets the MTU on the NICs of the running local instances connected to the bridge that don't
+// have their own MTU set. For containers both ends of the veth pair are updated, for virtual machines only the
+// host side as the guest configures its own interface. Failures are logged rather than returned as an instance
+// may be stopping while this runs.
+func (n *bridge) instancesApplyMTU(mtu string) {
+	insts, err := instance.LoadNodeAll(n.state, instancetype.Any)
+	if err != nil {
+		n.logger.Warn("Failed loading instances to update MTU", logger.Ctx{"err": err})
+		return
+	}
+
+	for _, inst := range insts {
+		if !inst.IsRunning() {
+			continue
+		}
+
+		for devName, dev := range inst.ExpandedDevices() {
+			if dev["type"] != "nic" || dev["mtu"] != "" {
+				continue
+			}
+
+			hostName := inst.LocalConfig()[fmt.Sprintf("volatile.%s.host_name", devName)]
+			if hostName == "" {
+				continue
+			}
+
+			// Only consider interfaces actually connected to this bridge.
+			if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s/brif/%s", n.name, hostName)) {
+				continue
+			}
+
+			l := logger.AddContext(n.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "device": devName})
+
+			hostLink := &ip.Link{Name: hostName}
+			err = hostLink.SetMTU(mtu)
 			if err != nil {
+				l.Warn("Failed setting MTU on host interface", logger.Ctx{"err": err})
 				continue
 			}
 
-			if ip != nil && err == nil && ip.To4() != nil && ip.IsGlobalUnicast() {
-				return subnet // Use first IPv4 unicast address on host for DHCP subnet.
+			if inst.Type() != instancetype.Container {
+				continue
+			}
+
+			peerName := dev["name"]
+			if peerName == "" {
+				peerName = inst.LocalConfig()[fmt.Sprintf("volatile.%s.name", devName)]
+			}
+
+			pid := inst.InitPID()
+			if peerName == "" || pid <= 0 {
+				continue
+			}
+
+			_, err = shared.RunCommand(n.state.OS.ExecPath, "forknet", "mtu", "--", fmt.Sprintf("/proc/%d/ns/net", pid), peerName, mtu)
+			if err != nil {
+				l.Warn("Failed setting MTU on instance interface", logger.Ctx{"err": err})
 			}
 		}
+	}
+}
 
-		return nil // No addresses found, means DHCP must be disabled.
+// dnsmasqApplyMTU restarts the running dnsmasq with its DHCP MTU option updated, leaving its other arguments and
+// the DNS forwarder untouched.
+func (n *bridge) dnsmasqApplyMTU(mtu string) error {
+	p, err := subprocess.ImportProcess(shared.VarPath("networks", n.name, "dnsmasq.pid"))
+	if err != nil {
+		return nil // Not running, it will pick the MTU up when next started.
 	}
 
-	// Non-fan mode. Return configured bridge subnet directly.
-	_, subnet, err := net.ParseCIDR(n.config["ipv4.address"])
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", p.PID))
 	if err != nil {
 		return nil
 	}
 
-	return subnet
-}
-
-// DHCPv6Subnet returns the DHCPv6 subnet (if DHCP or SLAAC is enabled on network).
-func (n *bridge) DHCPv6Subnet() *net.IPNet {
-	// DHCP is disabled on this network.
-	if !n.hasDHCPv6() {
+	args := strings.Split(strings.TrimSuffix(string(cmdline), "\x00"), "\x00")
+	if len(args) < 1 {
 		return nil
 	}
 
-	_, subnet, err := net.ParseCIDR(n.config["ipv6.address"])
-	if err != nil {
-		return nil
+	// Keep the option in the same place as when dnsmasq is started by setup so its arguments still match.
+	mtuOption := ""
+	if mtu != "1500" {
+		mtuOption = fmt.Sprintf("--dhcp-option-force=26,%s", mtu)
 	}
 
-	return subnet
-}
+	dnsmasqCmd := []string{}
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "--dhcp-option-force=26,") {
+			if mtuOption != "" {
+				dnsmasqCmd = append(dnsmasqCmd, mtuOption)
+				mtuOption = ""
+			}
 
-// forwardConvertToFirewallForward converts forwards into format compatible with the firewall package.
-func (n *bridge) forwardConvertToFirewallForwards(listenAddress net.IP, defaultTargetAddress net.IP, portMaps []*forwardPortMap) []firewallDrivers.AddressForward {
-	var vips []firewallDrivers.AddressForward
+			continue
+		}
 
-	if defaultTargetAddress != nil {
-		vips = append(vips, firewallDrivers.AddressForward{
-			ListenAddress: listenAddress,
-			TargetAddress: defaultTargetAddress,
-		})
+		dnsmasqCmd = append(dnsmasqCmd, arg)
+
+		if mtuOption != "" && (strings.HasPrefix(arg, "--dhcp-option-force=3,") || (strings.HasPrefix(arg, "--dhcp-hostsfile=") && n.config["ipv4.dhcp.gateway"] == "")) {
+			dnsmasqCmd = append(dnsmasqCmd, mtuOption)
+			mtuOption = ""
+		}
 	}
 
-	for _, portMap := range portMaps {
-		vips = append(vips, firewallDrivers.AddressForward{
-			ListenAddress: listenAddress,
-			Protocol:      portMap.protocol,
-			TargetAddress: portMap.targetAddress,
-			ListenPorts:   portMap.listenPorts,
-			TargetPorts:   portMap.targetPorts,
-		})
+	if mtuOption != "" {
+		dnsmasqCmd = append(dnsmasqCmd, mtuOption)
 	}
 
-	return vips
+	err = dnsmasq.Kill(n.name, false)
+	if err != nil {
+		return err
+	}
+
+	return n.spawnDNSMasq("dnsmasq", dnsmasqCmd, false, "")
 }
 
-// bridgeProjectNetworks takes a map of all networks in all projects and returns a filtered map of bridge networks.
-func (n *bridge) bridgeProjectNetworks(projectNetworks map[string]map[int64]api.Network) map[string][]*api.Network {
-	bridgeProjectNetworks := make(map[string][]*api.Network)
-	for netProject, networks := range projectNetworks {
-		for _, ni := range networks {
-			network := ni // Local var creating pointer to rather than iterator.
+// tunnelSetup creates the tunnel interface using the specified remote address, connects it to the bridge and
+// brings it up.
+func (n *bridge) tunnelSetup(tunnel string, tunRemote string, mtu string) error {
+	getConfig := func(key string) string {
+		return n.config[fmt.Sprintf("tunnel.%s.%s", tunnel, key)]
+	}
 
-			// Skip non-bridge networks.
-			if network.Type != "bridge" {
-				continue
-			}
+	tunProtocol := getConfig("protocol")
+	tunLocal := getConfig("local")
+	tunName := fmt.Sprintf("%s-%s", n.name, tunnel)
 
-			if bridgeProjectNetworks[netProject] == nil {
-				bridgeProjectNetworks[netProject] = []*api.Network{&network}
-			} else {
-				bridgeProjectNetworks[netProject] = append(bridgeProjectNetworks[netProject], &network)
-			}
+	// Configure the tunnel.
+	if tunProtocol == "gre" {
+		// Skip partial configs.
+		if tunProtocol == "" || tunLocal == "" || tunRemote == "" {
+			return nil
 		}
-	}
 
-	return bridgeProjectNetworks
-}
+		gretap := &ip.Gretap{
+			Link:   ip.Link{Name: tunName},
+			Local:  tunLocal,
+			Remote: tunRemote,
+		}
+		err := gretap.Add()
+		if err != nil {
+			return err
+		}
+	} else if tunProtocol == "vxlan" {
+		tunGroup := getConfig("group")
+		tunInterface := getConfig("interface")
 
-// bridgeNetworkExternalSubnets returns a list of external subnets used by bridge networks. Networks are considered
-// to be using external subnets for their ipv4.address and/or ipv6.address if they have NAT disabled, and/or if
-// they have external NAT addresses specified.
-func (n *bridge) bridgeNetworkExternalSubnets(bridgeProjectNetworks map[string][]*api.Network) ([]externalSubnetUsage, error) {
-	externalSubnets := make([]externalSubnetUsage, 0)
-	for netProject, networks := range bridgeProjectNetworks {
-		for _, netInfo := range networks {
-			for _, keyPrefix := range []string{"ipv4", "ipv6"} {
-				// If NAT is disabled, then network subnet is an external subnet.
-				if shared.IsFalseOrEmpty(netInfo.Config[fmt.Sprintf("%s.nat", keyPrefix)]) {
-					key := fmt.Sprintf("%s.address", keyPrefix)
+		// Skip partial configs.
+		if tunProtocol == "" {
+			return nil
+		}
 
-					_, ipNet, err := net.ParseCIDR(netInfo.Config[key])
-					if err != nil {
-						continue // Skip invalid/unspecified network addresses.
-					}
+		vxlan := &ip.Vxlan{
+			Link: ip.Link{Name: tunName},
+		}
+		if tunLocal != "" && tunRemote != "" {
+			vxlan.Local = tunLocal
+			vxlan.Remote = tunRemote
+		} else {
+			if tunGroup == "" {
+				tunGroup = "239.0.0.1"
+			}
 
-					externalSubnets = append(externalSubnets, externalSubnetUsage{
-						subnet:         *ipNet,
-						networkProject: netProject,
-						networkName:    netInfo.Name,
-					})
+			devName := tunInterface
+			if devName == "" {
+				_, gwDevName, err := DefaultGatewaySubnetV4()
+				if err != nil {
+					return err
 				}
 
-				// Find any external subnets used for network SNAT.
-				if netInfo.Config[fmt.Sprintf("%s.nat.address", keyPrefix)] != "" {
-					key := fmt.Sprintf("%s.nat.address", keyPrefix)
+				devName = gwDevName
+			}
 
-					subnetSize := 128
-					if keyPrefix == "ipv4" {
-						subnetSize = 32
-					}
+			vxlan.Group = tunGroup
+			vxlan.DevName = devName
+		}
 
-					_, ipNet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", netInfo.Config[key], subnetSize))
-					if err != nil {
-						return nil, fmt.Errorf("Failed parsing %q of %q in project %q: %w", key, netInfo.Name, netProject, err)
-					}
+		tunPort := getConfig("port")
+		if tunPort == "" {
+			tunPort = "0"
+		}
+		vxlan.DstPort = tunPort
 
-					externalSubnets = append(externalSubnets, externalSubnetUsage{
-						subnet:         *ipNet,
-						networkProject: netProject,
-						networkName:    netInfo.Name,
-						networkSNAT:    true,
-					})
-				}
+		tunID := getConfig("id")
+		if tunID == "" {
+			tunID = "1"
+		}
+		vxlan.VxlanID = tunID
 
-				// Find any routes being used by the network.
-				for _, cidr := range shared.SplitNTrimSpace(netInfo.Config[fmt.Sprintf("%s.routes", keyPrefix)], ",", -1, true) {
-					_, ipNet, err := net.ParseCIDR(cidr)
-					if err != nil {
-						continue // Skip invalid/unspecified network addresses.
-					}
+		tunTTL := getConfig("ttl")
+		if tunTTL == "" {
+			tunTTL = "1"
+		}
+		vxlan.TTL = tunTTL
 
-					externalSubnets = append(externalSubnets, externalSubnetUsage{
-						subnet:         *ipNet,
-						networkProject: netProject,
-						networkName:    netInfo.Name,
-					})
-				}
-			}
+		err := vxlan.Add()
+		if err != nil {
+			return err
 		}
 	}
 
-	return externalSubnets, nil
+	// Bridge it and bring up.
+	err := AttachInterface(n.name, tunName)
+	if err != nil {
+		return err
+	}
+
+	tunLink := &ip.Link{Name: tunName}
+	err = tunLink.SetMTU(mtu)
+	if err != nil {
+		return err
+	}
+
+	// Bring up tunnel interface.
+	err = tunLink.SetUp()
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
-// bridgedNICExternalRoutes returns a list of external routes currently used by bridged NICs that are connected to
-// networks specified.
-func (n *bridge) bridgedNICExternalRoutes(bridgeProjectNetworks map[string][]*api.Network) ([]externalSubnetUsage, error) {
-	externalRoutes := make([]externalSubnetUsage, 0)
+// bridgeTunnelKeepaliveFailures is the number of consecutive failed keepalive probes after which a tunnel remote
+// is considered dead.
+const bridgeTunnelKeepaliveFailures = 3
+
+// bridgeTunnelHealth tracks the keepalive state of a bridge tunnel.
+type bridgeTunnelHealth struct {
+	mu        sync.Mutex // Serialises the probes of the tunnel.
+	remote    string     // Currently active remote address.
+	failures  int        // Number of consecutive failed keepalive probes of the active remote.
+	down      bool       // Whether the tunnel has been marked down.
+	lastCheck time.Time  // Time of the last keepalive probe.
+}
 
-	err := n.state.DB.Cluster.InstanceList(nil, func(inst db.Instance, p api.Project, profiles []api.Profile) error {
-		// Get the instance's effective network project name.
-		instNetworkProject := project.NetworkProjectFromRecord(&p)
+var bridgeTunnelHealthStates = make(map[string]*bridgeTunnelHealth)
 
-		if instNetworkProject != project.Default {
-			return nil // Managed bridge networks can only exist in default project.
-		}
+// bridgeTunnelHealthMu protects bridgeTunnelHealthStates. It isn't held while probing as the probes of different
+// tunnels run independently, each under its own lock.
+var bridgeTunnelHealthMu sync.Mutex
 
-		devices := db.ExpandInstanceDevices(deviceConfig.NewDevices(db.DevicesToAPI(inst.Devices)), profiles)
+// tunnelHealthKey returns the key used to store the keepalive state of a tunnel.
+func (n *bridge) tunnelHealthKey(tunnel string) string {
+	return fmt.Sprintf("%s/%s/%s", n.project, n.name, tunnel)
+}
 
-		// Iterate through each of the instance's devices, looking for bridged NICs that are linked to
-		// networks specified.
-		for devName, devConfig := range devices {
-			if devConfig["type"] != "nic" {
-				continue
-			}
-
-			// Check whether the NIC device references one of the networks supplied.
-			if !NICUsesNetwork(devConfig, bridgeProjectNetworks[instNetworkProject]...) {
-				continue
-			}
+// tunnelHealthReset clears any keepalive state of a tunnel.
+func (n *bridge) tunnelHealthReset(tunnel string) {
+	bridgeTunnelHealthMu.Lock()
+	delete(bridgeTunnelHealthStates, n.tunnelHealthKey(tunnel))
+	bridgeTunnelHealthMu.Unlock()
+}
 
-			// For bridged NICs that are connected to networks specified, check if they have any
-			// routes or external routes configured, and if so add them to the list to return.
-			for _, key := range []string{"ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external"} {
-				for _, cidr := range shared.SplitNTrimSpace(devConfig[key], ",", -1, true) {
-					_, ipNet, _ := net.ParseCIDR(cidr)
-					if ipNet == nil {
-						// Skip if NIC device doesn't have a valid route.
-						continue
-					}
+// TunnelsHealthCheck probes the remote endpoint of the tunnels that have keepalive enabled.
+// Tunnels whose remote stops responding are switched to their backup remote when one is configured and reachable,
+// otherwise they are marked down until the remote responds again.
+func (n *bridge) TunnelsHealthCheck() error {
+	if !n.isRunning() {
+		return nil
+	}
 
-					externalRoutes = append(externalRoutes, externalSubnetUsage{
-						subnet:          *ipNet,
-						networkProject:  instNetworkProject,
-						networkName:     devConfig["network"],
-						instanceProject: inst.Project,
-						instanceName:    inst.Name,
-						instanceDevice:  devName,
-					})
-				}
-			}
+	for _, tunnel := range n.getTunnels() {
+		err := n.tunnelHealthCheck(tunnel)
+		if err != nil {
+			return fmt.Errorf("Failed checking tunnel %q: %w", tunnel, err)
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
-	return externalRoutes, nil
+	return nil
 }
 
-// getExternalSubnetInUse returns information about usage of external subnets by bridge networks (and NICs
-// connected to them) on this member.
-func (n *bridge) getExternalSubnetInUse() ([]externalSubnetUsage, error) {
-	var err error
-	var projectNetworks map[string]map[int64]api.Network
-	var projectNetworksForwardsOnUplink map[string]map[int64][]string
+// tunnelHealthCheck runs a keepalive probe against a tunnel's active remote if its keepalive interval has elapsed.
+func (n *bridge) tunnelHealthCheck(tunnel string) error {
+	getConfig := func(key string) string {
+		return n.config[fmt.Sprintf("tunnel.%s.%s", tunnel, key)]
+	}
 
-	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		// Get all managed networks across all projects.
-		projectNetworks, err = tx.GetCreatedNetworks()
-		if err != nil {
-			return fmt.Errorf("Failed to load all networks: %w", err)
-		}
+	interval, err := strconv.ParseUint(getConfig("keepalive"), 10, 32)
+	if err != nil || interval == 0 {
+		return nil // Keepalive not enabled.
+	}
 
-		// Get all network forward listen addresses for forwards assigned to this specific cluster member.
-		projectNetworksForwardsOnUplink, err = tx.GetProjectNetworkForwardListenAddressesOnMember()
-		if err != nil {
-			return fmt.Errorf("Failed loading network forward listen addresses: %w", err)
-		}
+	primary := getConfig("remote")
+	backup := getConfig("remote_backup")
+	if primary == "" {
+		return nil // Multicast tunnels have no remote endpoint to probe.
+	}
 
-		return nil
-	})
-	if err != nil {
-		return nil, err
+	bridgeTunnelHealthMu.Lock()
+	key := n.tunnelHealthKey(tunnel)
+	health, found := bridgeTunnelHealthStates[key]
+	if !found {
+		health = &bridgeTunnelHealth{remote: primary}
+		bridgeTunnelHealthStates[key] = health
 	}
 
-	// Get managed bridge networks.
-	bridgeProjectNetworks := n.bridgeProjectNetworks(projectNetworks)
+	bridgeTunnelHealthMu.Unlock()
 
-	// Get external subnets used by other managed bridge networks.
-	bridgeNetworkExternalSubnets, err := n.bridgeNetworkExternalSubnets(bridgeProjectNetworks)
-	if err != nil {
-		return nil, err
-	}
+	health.mu.Lock()
+	defer health.mu.Unlock()
 
-	// Get external routes configured on bridged NICs.
-	bridgedNICExternalRoutes, err := n.bridgedNICExternalRoutes(bridgeProjectNetworks)
-	if err != nil {
-		return nil, err
+	if time.Since(health.lastCheck) < time.Duration(interval)*time.Second {
+		return nil
 	}
 
-	externalSubnets := make([]externalSubnetUsage, 0, len(bridgeNetworkExternalSubnets)+len(bridgedNICExternalRoutes))
-	externalSubnets = append(externalSubnets, bridgeNetworkExternalSubnets...)
-	externalSubnets = append(externalSubnets, bridgedNICExternalRoutes...)
+	health.lastCheck = time.Now()
+	tunLink := &ip.Link{Name: fmt.Sprintf("%s-%s", n.name, tunnel)}
 
-	// Add forward listen addresses to this list.
-	for projectName, networks := range projectNetworksForwardsOnUplink {
-		for networkID, listenAddresses := range networks {
-			for _, listenAddress := range listenAddresses {
-				// Convert listen address to subnet.
-				listenAddressNet, err := ParseIPToNet(listenAddress)
-				if err != nil {
-					return nil, fmt.Errorf("Invalid existing forward listen address %q", listenAddress)
-				}
+	if n.tunnelPing(health.remote) {
+		health.failures = 0
 
-				// Create an externalSubnetUsage for the listen address by using the network ID
-				// of the listen address to retrieve the already loaded network name from the
-				// projectNetworks map.
-				externalSubnets = append(externalSubnets, externalSubnetUsage{
-					subnet:         *listenAddressNet,
-					networkProject: projectName,
-					networkName:    projectNetworks[projectName][networkID].Name,
-				})
+		if health.down {
+			err = tunLink.SetUp()
+			if err != nil {
+				return err
 			}
-		}
-	}
 
-	return externalSubnets, nil
-}
+			health.down = false
+			n.logger.Info("Tunnel remote is reachable again", logger.Ctx{"tunnel": tunnel, "remote": health.remote})
+			n.state.Events.SendLifecycle(n.project, lifecycle.NetworkTunnelUp.Event(n, nil, map[string]any{"tunnel": tunnel, "remote": health.remote}))
+		}
 
-// ForwardCreate creates a network forward.
-func (n *bridge) ForwardCreate(forward api.NetworkForwardsPost, clientType request.ClientType) error {
-	memberSpecific := true // bridge supports per-member forwards.
+		// Fail back to the primary remote once it responds again.
+		if health.remote != primary && n.tunnelPing(primary) {
+			err = n.tunnelSwitchRemote(tunnel, health, primary)
+			if err != nil {
+				return err
+			}
+		}
 
-	// Check if there is an existing forward using the same listen address.
-	_, _, err := n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, forward.ListenAddress)
-	if err == nil {
-		return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
+		return nil
 	}
 
-	// Convert listen address to subnet so we can check its valid and can be used.
-	listenAddressNet, err := ParseIPToNet(forward.ListenAddress)
-	if err != nil {
-		return fmt.Errorf("Failed parsing address forward listen address %q: %w", forward.ListenAddress, err)
+	health.failures++
+	if health.failures < bridgeTunnelKeepaliveFailures {
+		return nil
 	}
 
-	_, err = n.forwardValidate(listenAddressNet.IP, &forward.NetworkForwardPut)
-	if err != nil {
-		return err
+	// Try switching to the other remote before giving up on the tunnel.
+	alternate := backup
+	if health.remote != primary {
+		alternate = primary
 	}
 
-	externalSubnetsInUse, err := n.getExternalSubnetInUse()
-	if err != nil {
-		return err
+	if alternate != "" && n.tunnelPing(alternate) {
+		return n.tunnelSwitchRemote(tunnel, health, alternate)
 	}
 
-	// Check the listen address subnet doesn't fall within any existing network external subnets.
-	for _, externalSubnetUser := range externalSubnetsInUse {
-		// Skip our own network's SNAT address (as it can be used for NICs in the network).
-		if externalSubnetUser.networkSNAT && externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name {
-			continue
+	if !health.down {
+		err = tunLink.SetDown()
+		if err != nil {
+			return err
 		}
 
-		// Skip our own network (but not NIC devices on our own network).
-		if externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name && externalSubnetUser.instanceDevice == "" {
-			continue
-		}
+		health.down = true
+		n.logger.Warn("Tunnel remote is unreachable, marking tunnel down", logger.Ctx{"tunnel": tunnel, "remote": health.remote})
+		n.state.Events.SendLifecycle(n.project, lifecycle.NetworkTunnelDown.Event(n, nil, map[string]any{"tunnel": tunnel, "remote": health.remote}))
+	}
 
-		if SubnetContains(&externalSubnetUser.subnet, listenAddressNet) || SubnetContains(listenAddressNet, &externalSubnetUser.subnet) {
-			// This error is purposefully vague so that it doesn't reveal any names of
-			// resources potentially outside of the network.
-			return fmt.Errorf("Forward listen address %q overlaps with another network or NIC", listenAddressNet.String())
-		}
+	return nil
+}
+
+// tunnelPing returns whether the tunnel remote address responds to a ping.
+func (n *bridge) tunnelPing(remote string) bool {
+	remoteIP := net.ParseIP(remote)
+	if remoteIP == nil {
+		return false
+	}
+
+	return pingIP(remoteIP)
+}
+
+// tunnelSwitchRemote re-creates the tunnel interface using the specified remote and updates its keepalive state.
+func (n *bridge) tunnelSwitchRemote(tunnel string, health *bridgeTunnelHealth, remote string) error {
+	tunName := fmt.Sprintf("%s-%s", n.name, tunnel)
+
+	// Keep the MTU the tunnel was configured with.
+	mtu := n.config["bridge.mtu"]
+	if mtu == "" {
+		mtu = "1400"
 	}
 
 	revert := revert.New()
 	defer revert.Fail()
 
-	// Create forward DB record.
-	forwardID, err := n.state.DB.Cluster.CreateNetworkForward(n.ID(), memberSpecific, &forward)
+	tunLink := &ip.Link{Name: tunName}
+	iface, err := net.InterfaceByName(tunName)
+	if err == nil {
+		mtu = fmt.Sprintf("%d", iface.MTU)
+
+		err = tunLink.Delete()
+		if err != nil {
+			return err
+		}
+
+		// Restore the tunnel using the previous remote if it can't be set up with the new one.
+		oldRemote := health.remote
+		revert.Add(func() {
+			_ = tunLink.Delete()
+
+			err := n.tunnelSetup(tunnel, oldRemote, mtu)
+			if err != nil {
+				n.logger.Error("Failed restoring tunnel", logger.Ctx{"tunnel": tunnel, "remote": oldRemote, "err": err})
+				return
+			}
+
+			if health.down {
+				_ = tunLink.SetDown()
+			}
+		})
+	}
+
+	err = n.tunnelSetup(tunnel, remote, mtu)
 	if err != nil {
 		return err
 	}
 
-	revert.Add(func() {
-		_ = n.state.DB.Cluster.DeleteNetworkForward(n.ID(), forwardID)
-		_ = n.forwardSetupFirewall()
-		_ = n.forwardBGPSetupPrefixes()
-	})
+	revert.Success()
 
-	err = n.forwardSetupFirewall()
-	if err != nil {
-		return err
+	n.logger.Warn("Switched tunnel remote", logger.Ctx{"tunnel": tunnel, "oldRemote": health.remote, "remote": remote})
+	n.state.Events.SendLifecycle(n.project, lifecycle.NetworkTunnelFailover.Event(n, nil, map[string]any{"tunnel": tunnel, "old_remote": health.remote, "remote": remote}))
+
+	health.remote = remote
+	health.failures = 0
+	health.down = false
+
+	return nil
+}
+
+// bridgeValidateIPsecPSK validates a tunnel encryption pre-shared key.
+func bridgeValidateIPsecPSK(value string) error {
+	if len(value) < 16 {
+		return fmt.Errorf("Pre-shared key must be at least 16 characters long")
 	}
 
-	// Check if hairpin mode needs to be enabled on active NIC bridge ports.
-	if n.config["bridge.driver"] != "openvswitch" {
-		brNetfilterEnabled := false
-		for _, ipVersion := range []uint{4, 6} {
-			if BridgeNetfilterEnabled(ipVersion) == nil {
-				brNetfilterEnabled = true
-				break
-			}
-		}
+	return nil
+}
 
-		// If br_netfilter is enabled and bridge has forwards, we enable hairpin mode on each NIC's bridge
-		// port in case any of the forwards target the NIC and the instance attempts to connect to the
-		// forward's listener. Without hairpin mode on the target of the forward will not be able to
-		// connect to the listener.
-		if brNetfilterEnabled {
-			listenAddresses, err := n.state.DB.Cluster.GetNetworkForwardListenAddresses(n.ID(), true)
-			if err != nil {
-				return fmt.Errorf("Failed loading network forwards: %w", err)
-			}
+// bridgeIPsecVxlanPort is the port used by VXLAN tunnels when no port is specified.
+const bridgeIPsecVxlanPort = "8472"
 
-			// If we are the first forward on this bridge, enable hairpin mode on active NIC ports.
-			if len(listenAddresses) <= 1 {
-				var localNode string
+// ipsecKeys derives the SPI and keys of the security association protecting the traffic from src to dst.
+// Both ends of a tunnel derive the same values from the pre-shared key and the nonces they exchanged (see
+// ipsecKeying), so that every start of the tunnel and every rekeying uses new keys.
+func ipsecKeys(psk string, src string, dst string, srcNonce []byte, dstNonce []byte) (uint32, []byte, []byte) {
+	derive := func(label string) []byte {
+		mac := hmac.New(sha256.New, []byte(psk))
+		_, _ = mac.Write([]byte(fmt.Sprintf("lxd-ipsec %s %s>%s ", label, src, dst)))
+		_, _ = mac.Write(srcNonce)
+		_, _ = mac.Write(dstNonce)
+		return mac.Sum(nil)
+	}
 
-				err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-					localNode, err = tx.GetLocalNodeName()
-					if err != nil {
-						return fmt.Errorf("Failed to get local member name: %w", err)
-					}
+	// SPI values below 256 are reserved.
+	spi := binary.BigEndian.Uint32(derive("spi"))
+	if spi < 256 {
+		spi += 256
+	}
 
-					return err
-				})
-				if err != nil {
-					return err
-				}
+	return spi, derive("enc"), derive("auth")
+}
 
-				filter := db.InstanceFilter{
-					Node: &localNode,
-				}
+// ipsecReqID returns the request ID of the IPsec states and policies of a tunnel ("fan" for the fan tunnel).
+func (n *bridge) ipsecReqID(tunnel string) uint32 {
+	reqID := crc32.ChecksumIEEE([]byte(fmt.Sprintf("%s/%s", n.name, tunnel)))
+	if reqID == 0 {
+		reqID = 1 // Request ID 0 matches any state.
+	}
 
-				err = n.state.DB.Cluster.InstanceList(&filter, func(inst db.Instance, p api.Project, profiles []api.Profile) error {
-					// Get the instance's effective network project name.
-					instNetworkProject := project.NetworkProjectFromRecord(&p)
+	return reqID
+}
 
-					if instNetworkProject != project.Default {
-						return nil // Managed bridge networks can only exist in default project.
-					}
-					devices := db.ExpandInstanceDevices(deviceConfig.NewDevices(db.DevicesToAPI(inst.Devices)), profiles)
+// ipsecTunnel represents the IPsec configuration of a tunnel.
+type ipsecTunnel struct {
+	name     string
+	psk      string
+	local    string
+	remotes  []string // Addresses of the tunnel remotes (or fan peers).
+	subnet   string   // Subnet of the fan peers (only for the fan tunnel).
+	policies []ip.XfrmPolicy
+}
 
-					// Iterate through each of the instance's devices, looking for bridged NICs
-					// that are linked to this network.
-					for devName, devConfig := range devices {
-						if devConfig["type"] != "nic" {
-							continue
-						}
+// ipsecTunnels returns the tunnels of config that have IPsec encryption enabled.
+func (n *bridge) ipsecTunnels(config map[string]string) ([]ipsecTunnel, error) {
+	tunnels := []ipsecTunnel{}
 
-						// Check whether the NIC device references our network..
-						if !NICUsesNetwork(devConfig, &api.Network{Name: n.Name()}) {
-							continue
-						}
+	for k, v := range config {
+		fields := strings.Split(k, ".")
+		if len(fields) != 3 || fields[0] != "tunnel" || fields[2] != "encryption" || v != "ipsec" {
+			continue
+		}
 
-						hostName := inst.Config[fmt.Sprintf("volatile.%s.host_name", devName)]
-						if InterfaceExists(hostName) {
-							link := &ip.Link{Name: hostName}
-							err = link.BridgeLinkSetHairpin(true)
-							if err != nil {
-								return fmt.Errorf("Error enabling hairpin mode on bridge port %q: %w", link.Name, err)
-							}
-							n.logger.Debug("Enabled hairpin mode on NIC bridge port", logger.Ctx{"inst": inst.Name, "project": inst.Project, "device": devName, "dev": link.Name})
-						}
-					}
+		getConfig := func(key string) string {
+			return config[fmt.Sprintf("tunnel.%s.%s", fields[1], key)]
+		}
 
-					return nil
-				})
-				if err != nil {
-					return err
-				}
+		proto := "47" // GRE.
+		port := ""
+		if getConfig("protocol") == "vxlan" {
+			proto = "udp"
+			port = getConfig("port")
+			if port == "" || port == "0" {
+				port = bridgeIPsecVxlanPort
 			}
 		}
+
+		tunnel := ipsecTunnel{name: fields[1], psk: getConfig("psk"), local: getConfig("local")}
+		for _, remote := range []string{getConfig("remote"), getConfig("remote_backup")} {
+			if remote == "" {
+				continue
+			}
+
+			tunnel.remotes = append(tunnel.remotes, remote)
+			tunnel.policies = append(tunnel.policies,
+				ip.XfrmPolicy{Src: tunnel.local, Dst: remote, Proto: proto, Port: port, Dir: "out", ReqID: n.ipsecReqID(tunnel.name)},
+				ip.XfrmPolicy{Src: remote, Dst: tunnel.local, Proto: proto, Port: port, Dir: "in", ReqID: n.ipsecReqID(tunnel.name)},
+			)
+		}
+
+		tunnels = append(tunnels, tunnel)
 	}
 
-	// Refresh exported BGP prefixes on local member.
-	err = n.forwardBGPSetupPrefixes()
-	if err != nil {
-		return fmt.Errorf("Failed applying BGP prefixes for address forwards: %w", err)
+	if config["bridge.mode"] == "fan" && config["fan.encryption"] == "ipsec" {
+		_, underlaySubnet, err := net.ParseCIDR(config["fan.underlay_subnet"])
+		if err != nil {
+			return nil, fmt.Errorf("Failed parsing fan.underlay_subnet: %w", err)
+		}
+
+		localIP, _, err := n.addressForSubnet(underlaySubnet)
+		if err != nil {
+			return nil, err
+		}
+
+		proto := "udp"
+		port := bridgeIPsecVxlanPort
+		if config["fan.type"] == "ipip" {
+			proto = "4" // IP in IP.
+			port = ""
+		}
+
+		tunnel := ipsecTunnel{name: "fan", psk: config["fan.psk"], local: localIP.String(), subnet: underlaySubnet.String()}
+		tunnel.remotes, err = n.ipsecFanPeers(config)
+		if err != nil {
+			return nil, err
+		}
+
+		tunnel.policies = []ip.XfrmPolicy{
+			{Src: tunnel.local, Dst: tunnel.subnet, Proto: proto, Port: port, Dir: "out", ReqID: n.ipsecReqID(tunnel.name)},
+			{Src: tunnel.subnet, Dst: tunnel.local, Proto: proto, Port: port, Dir: "in", ReqID: n.ipsecReqID(tunnel.name)},
+		}
+
+		tunnels = append(tunnels, tunnel)
 	}
 
-	revert.Success()
-	return nil
+	return tunnels, nil
 }
 
-// ForwardUpdate updates a network forward.
-func (n *bridge) ForwardUpdate(listenAddress string, req api.NetworkForwardPut, clientType request.ClientType) error {
-	memberSpecific := true // bridge supports per-member forwards.
-	curForwardID, curForward, err := n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, listenAddress)
+// ipsecFanPeers returns the underlay addresses of the other cluster members using the fan network.
+// They are derived from the fan addresses of the members that are tracked for forkdns.
+func (n *bridge) ipsecFanPeers(config map[string]string) ([]string, error) {
+	_, underlaySubnet, err := net.ParseCIDR(config["fan.underlay_subnet"])
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Failed parsing fan.underlay_subnet: %w", err)
 	}
 
-	_, err = n.forwardValidate(net.ParseIP(curForward.ListenAddress), &req)
-	if err != nil {
-		return err
+	overlay := config["fan.overlay_subnet"]
+	if overlay == "" {
+		overlay = "240.0.0.0/8"
 	}
 
-	curForwardEtagHash, err := util.EtagHash(curForward.Etag())
+	_, overlaySubnet, err := net.ParseCIDR(overlay)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Failed parsing fan.overlay_subnet: %w", err)
 	}
 
-	newForward := api.NetworkForward{
-		ListenAddress:     curForward.ListenAddress,
-		NetworkForwardPut: req,
+	fanAddresses, err := ForkdnsServersList(n.name)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
 	}
 
-	newForwardEtagHash, err := util.EtagHash(newForward.Etag())
-	if err != nil {
-		return err
+	peers := []string{}
+	for _, fanAddress := range fanAddresses {
+		peer := fanUnderlayAddress(net.ParseIP(fanAddress), underlaySubnet, overlaySubnet)
+		if peer != nil {
+			peers = append(peers, peer.String())
+		}
 	}
 
-	if curForwardEtagHash == newForwardEtagHash {
-		return nil // Nothing has changed.
-	}
-
-	revert := revert.New()
-	defer revert.Fail()
+	return peers, nil
+}
 
-	err = n.state.DB.Cluster.UpdateNetworkForward(n.ID(), curForwardID, &newForward.NetworkForwardPut)
-	if err != nil {
-		return err
+// fanUnderlayAddress returns the underlay address of the host using the specified fan address (the reverse of
+// the mapping done by fanAddress).
+func fanUnderlayAddress(fanAddress net.IP, underlay *net.IPNet, overlay *net.IPNet) net.IP {
+	fanBytes := fanAddress.To4()
+	if fanBytes == nil || !overlay.Contains(fanBytes) {
+		return nil
 	}
 
-	revert.Add(func() {
-		_ = n.state.DB.Cluster.UpdateNetworkForward(n.ID(), curForwardID, &curForward.NetworkForwardPut)
-		_ = n.forwardSetupFirewall()
-		_ = n.forwardBGPSetupPrefixes()
-	})
+	underlaySize, _ := underlay.Mask.Size()
+	overlaySize, _ := overlay.Mask.Size()
 
-	err = n.forwardSetupFirewall()
-	if err != nil {
-		return err
+	ipBytes := make(net.IP, net.IPv4len)
+	copy(ipBytes, underlay.IP.To4())
+
+	if overlaySize == 16 {
+		ipBytes[3] = fanBytes[2]
+	} else if underlaySize == 24 {
+		ipBytes[3] = fanBytes[1]
+	} else if underlaySize == 16 {
+		ipBytes[2] = fanBytes[1]
+		ipBytes[3] = fanBytes[2]
 	}
 
-	revert.Success()
-	return nil
+	return ipBytes
 }
 
-// ForwardDelete deletes a network forward.
-func (n *bridge) ForwardDelete(listenAddress string, clientType request.ClientType) error {
-	memberSpecific := true // bridge supports per-member forwards.
-	forwardID, forward, err := n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, listenAddress)
+// ipsecSetup sets up the IPsec states and policies encrypting the tunnel traffic (and handles config changes).
+func (n *bridge) ipsecSetup(oldConfig map[string]string) error {
+	err := n.ipsecClear(oldConfig)
 	if err != nil {
 		return err
 	}
 
-	revert := revert.New()
-	defer revert.Fail()
-
-	err = n.state.DB.Cluster.DeleteNetworkForward(n.ID(), forwardID)
+	tunnels, err := n.ipsecTunnels(n.config)
 	if err != nil {
 		return err
 	}
 
-	revert.Add(func() {
-		newForward := api.NetworkForwardsPost{
-			NetworkForwardPut: forward.NetworkForwardPut,
-			ListenAddress:     forward.ListenAddress,
+	for _, tunnel := range tunnels {
+		for _, policy := range tunnel.policies {
+			err = policy.Add()
+			if err != nil {
+				return fmt.Errorf("Failed adding IPsec policy for tunnel %q: %w", tunnel.name, err)
+			}
 		}
-		_, _ = n.state.DB.Cluster.CreateNetworkForward(n.ID(), memberSpecific, &newForward)
-		_ = n.forwardSetupFirewall()
-		_ = n.forwardBGPSetupPrefixes()
-	})
-
-	err = n.forwardSetupFirewall()
-	if err != nil {
-		return err
-	}
 
-	// Refresh exported BGP prefixes on local member.
-	err = n.forwardBGPSetupPrefixes()
-	if err != nil {
-		return fmt.Errorf("Failed applying BGP prefixes for address forwards: %w", err)
+		// The security associations are added once the nonces have been exchanged with the remotes.
+		err = n.ipsecKeyingStart(tunnel)
+		if err != nil {
+			return fmt.Errorf("Failed starting IPsec key exchange for tunnel %q: %w", tunnel.name, err)
+		}
 	}
 
-	revert.Success()
 	return nil
 }
 
-// forwardSetupFirewall applies all network address forwards defined for this network and this member.
-func (n *bridge) forwardSetupFirewall() error {
-	memberSpecific := true // Get all forwards for this cluster member.
-	forwards, err := n.state.DB.Cluster.GetNetworkForwards(n.ID(), memberSpecific)
-	if err != nil {
-		return fmt.Errorf("Failed loading network forwards: %w", err)
-	}
-
-	var fwForwards []firewallDrivers.AddressForward
-	ipVersions := make(map[uint]struct{})
-
-	for _, forward := range forwards {
-		// Convert listen address to subnet so we can check its valid and can be used.
-		listenAddressNet, err := ParseIPToNet(forward.ListenAddress)
-		if err != nil {
-			return fmt.Errorf("Failed parsing address forward listen address %q: %w", forward.ListenAddress, err)
+// ipsecClear removes the IPsec states and policies of the tunnels in config.
+func (n *bridge) ipsecClear(config map[string]string) error {
+	// The states can be removed without knowing the tunnel addresses.
+	names := []string{}
+	for k, v := range config {
+		fields := strings.Split(k, ".")
+		if len(fields) == 3 && fields[0] == "tunnel" && fields[2] == "encryption" && v == "ipsec" {
+			names = append(names, fields[1])
 		}
+	}
 
-		// Track which IP versions we are using.
-		if listenAddressNet.IP.To4() == nil {
-			ipVersions[6] = struct{}{}
-		} else {
-			ipVersions[4] = struct{}{}
-		}
+	if config["bridge.mode"] == "fan" && config["fan.encryption"] == "ipsec" {
+		names = append(names, "fan")
+	}
 
-		portMaps, err := n.forwardValidate(listenAddressNet.IP, &forward.NetworkForwardPut)
-		if err != nil {
-			return fmt.Errorf("Failed validating firewall address forward for listen address %q: %w", forward.ListenAddress, err)
-		}
+	if len(names) == 0 {
+		return nil
+	}
 
-		fwForwards = append(fwForwards, n.forwardConvertToFirewallForwards(listenAddressNet.IP, net.ParseIP(forward.Config["target_address"]), portMaps)...)
+	for _, name := range names {
+		n.ipsecKeyingStop(name)
 	}
 
-	if len(forwards) > 0 {
-		// Check if br_netfilter is enabled to, and warn if not.
-		brNetfilterWarning := false
-		for ipVersion := range ipVersions {
-			err = BridgeNetfilterEnabled(ipVersion)
-			if err != nil {
-				brNetfilterWarning = true
-				msg := fmt.Sprintf("IPv%d bridge netfilter not enabled. Instances using the bridge will not be able to connect to the forward listen IPs", ipVersion)
-				n.logger.Warn(msg, logger.Ctx{"err": err})
-				err = n.state.DB.Cluster.UpsertWarningLocalNode(n.project, dbCluster.TypeNetwork, int(n.id), db.WarningProxyBridgeNetfilterNotEnabled, fmt.Sprintf("%s: %v", msg, err))
-				if err != nil {
-					n.logger.Warn("Failed to create warning", logger.Ctx{"err": err})
-				}
-			}
-		}
+	tunnels, err := n.ipsecTunnels(config)
+	if err != nil {
+		n.logger.Warn("Failed getting IPsec policies to remove", logger.Ctx{"err": err})
+	}
 
-		if !brNetfilterWarning {
-			err = warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(n.state.DB.Cluster, n.project, db.WarningProxyBridgeNetfilterNotEnabled, dbCluster.TypeNetwork, int(n.id))
-			if err != nil {
-				n.logger.Warn("Failed to resolve warning", logger.Ctx{"err": err})
-			}
+	for _, tunnel := range tunnels {
+		for _, policy := range tunnel.policies {
+			_ = policy.Delete()
 		}
 	}
 
-	err = n.state.Firewall.NetworkApplyForwards(n.name, fwForwards)
-	if err != nil {
-		return fmt.Errorf("Failed applying firewall address forwards: %w", err)
+	for _, name := range names {
+		err = ip.XfrmStateFlush(n.ipsecReqID(name))
+		if err != nil {
+			return fmt.Errorf("Failed removing IPsec states of tunnel %q: %w", name, err)
+		}
 	}
 
 	return nil
 }
 
-// Leases returns a list of leases for the bridged network. It will reach out to other cluster members as needed.
-// The projectName passed here refers to the initial project from the API request which may differ from the network's project.
-func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]api.NetworkLease, error) {
-	leases := []api.NetworkLease{}
-	projectMacs := []string{}
+// bridgeLeasesSettleTime is how long the leases file must be left unchanged before it is compared against the
+// known leases. This avoids reading the file while dnsmasq is rewriting it.
+const bridgeLeasesSettleTime = 500 * time.Millisecond
 
-	// Get all static leases.
-	if clientType == request.ClientTypeNormal {
-		// Get the downstream networks.
-		if n.project == project.Default {
-			var err error
+// bridgeLeasesExpiryInterval is the interval at which the known leases are checked for expiry, as dnsmasq only
+// removes expired leases from the leases file on its next write.
+const bridgeLeasesExpiryInterval = 30 * time.Second
 
-			// Load all the networks.
-			var projectNetworks map[string]map[int64]api.Network
-			err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-				projectNetworks, err = tx.GetCreatedNetworks()
-				return err
-			})
-			if err != nil {
-				return nil, err
-			}
+var bridgeLeasesWatchers = make(map[string]context.CancelFunc)
+var bridgeLeasesWatchersMu sync.Mutex
 
-			// Look for networks using the current network as an uplink.
-			for projectName, networks := range projectNetworks {
-				for _, network := range networks {
-					if network.Config["network"] != n.name {
-						continue
-					}
+// leasesWatchStart starts watching the network's DHCP leases file (if not already watched) and emits lifecycle
+// events when leases are created, renewed or expire.
+func (n *bridge) leasesWatchStart() error {
+	bridgeLeasesWatchersMu.Lock()
+	defer bridgeLeasesWatchersMu.Unlock()
 
-					// Found a network, add leases.
-					for _, k := range []string{"volatile.network.ipv4.address", "volatile.network.ipv6.address"} {
-						v := network.Config[k]
-						if v != "" {
-							leases = append(leases, api.NetworkLease{
-								Hostname: fmt.Sprintf("%s-%s.uplink", projectName, network.Name),
-								Address:  v,
-								Type:     "uplink",
-							})
-						}
-					}
-				}
-			}
-		}
+	key := fmt.Sprintf("%s/%s", n.project, n.name)
+	_, found := bridgeLeasesWatchers[key]
+	if found {
+		return nil
+	}
 
-		// Get all the instances.
-		instances, err := instance.LoadByProject(n.state, projectName)
-		if err != nil {
-			return nil, err
-		}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("Failed creating DHCP leases watcher: %w", err)
+	}
 
-		for _, inst := range instances {
-			// Go through all its devices (including profiles).
-			for k, dev := range inst.ExpandedDevices() {
-				// Skip uninteresting entries.
-				if dev["type"] != "nic" {
-					continue
-				}
+	// Watch the directory rather than the file as the file is replaced when rewritten by the builtin DHCP server.
+	err = watcher.Add(shared.VarPath("networks", n.name))
+	if err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("Failed watching DHCP leases: %w", err)
+	}
 
-				nicType, err := nictype.NICType(n.state, inst.Project(), dev)
-				if err != nil || nicType != "bridged" {
-					continue
-				}
+	ctx, cancel := context.WithCancel(n.state.ShutdownCtx)
+	bridgeLeasesWatchers[key] = cancel
 
-				// Temporarily populate parent from network setting if used.
-				if dev["network"] != "" {
-					dev["parent"] = dev["network"]
-				}
+	go n.leasesWatch(ctx, watcher)
 
-				if dev["parent"] != n.name {
-					continue
-				}
+	return nil
+}
 
-				// Fill in the hwaddr from volatile.
-				if dev["hwaddr"] == "" {
-					dev["hwaddr"] = inst.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", k)]
-				}
+// leasesWatchStop stops watching the network's DHCP leases file.
+func (n *bridge) leasesWatchStop() {
+	bridgeLeasesWatchersMu.Lock()
+	defer bridgeLeasesWatchersMu.Unlock()
 
-				// Record the MAC.
-				if dev["hwaddr"] != "" {
-					projectMacs = append(projectMacs, dev["hwaddr"])
-				}
+	key := fmt.Sprintf("%s/%s", n.project, n.name)
+	cancel, found := bridgeLeasesWatchers[key]
+	if found {
+		cancel()
+		delete(bridgeLeasesWatchers, key)
+	}
+}
 
-				// Add the lease.
-				if dev["ipv4.address"] != "" {
-					leases = append(leases, api.NetworkLease{
-						Hostname: inst.Name(),
-						Address:  dev["ipv4.address"],
-						Hwaddr:   dev["hwaddr"],
-						Type:     "static",
-						Location: inst.Location(),
-					})
-				}
+// leasesWatch compares the leases file against the known leases whenever it changes (and periodically to detect
+// expired leases) until the context is cancelled.
+func (n *bridge) leasesWatch(ctx context.Context, watcher *fsnotify.Watcher) {
+	defer func() { _ = watcher.Close() }()
 
-				if dev["ipv6.address"] != "" {
-					leases = append(leases, api.NetworkLease{
-						Hostname: inst.Name(),
-						Address:  dev["ipv6.address"],
-						Hwaddr:   dev["hwaddr"],
-						Type:     "static",
-						Location: inst.Location(),
-					})
-				}
+	// Existing leases are considered known so that restarting LXD or the network doesn't emit events for them.
+	known, err := n.leasesActive(time.Now())
+	if err != nil {
+		n.logger.Warn("Failed reading DHCP leases", logger.Ctx{"err": err})
+		known = map[string]dnsmasq.DHCPLease{}
+	}
 
-				// Add EUI64 records.
-				ipv6Address := n.config["ipv6.address"]
-				if ipv6Address != "" && ipv6Address != "none" && shared.IsFalseOrEmpty(n.config["ipv6.dhcp.stateful"]) {
-					_, netAddress, _ := net.ParseCIDR(ipv6Address)
-					hwAddr, _ := net.ParseMAC(dev["hwaddr"])
-					if netAddress != nil && hwAddr != nil {
-						ipv6, err := eui64.ParseMAC(netAddress.IP, hwAddr)
-						if err == nil {
-							leases = append(leases, api.NetworkLease{
-								Hostname: inst.Name(),
-								Address:  ipv6.String(),
-								Hwaddr:   dev["hwaddr"],
-								Type:     "dynamic",
-								Location: inst.Location(),
-							})
-						}
-					}
-				}
+	settle := time.NewTimer(bridgeLeasesSettleTime)
+	settle.Stop()
+	defer settle.Stop()
+
+	ticker := time.NewTicker(bridgeLeasesExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			if filepath.Base(event.Name) == "dnsmasq.leases" {
+				settle.Reset(bridgeLeasesSettleTime)
+			}
+
+			continue
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
 			}
+
+			n.logger.Warn("Failed watching DHCP leases", logger.Ctx{"err": err})
+			continue
+		case <-settle.C:
+		case <-ticker.C:
 		}
+
+		known = n.leasesCompare(known, time.Now())
 	}
+}
 
-	// Local server name.
-	var err error
-	var serverName string
-	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		serverName, err = tx.GetLocalNodeName()
-		return err
-	})
+// leasesActive returns the unexpired leases of the network keyed by IP address.
+func (n *bridge) leasesActive(now time.Time) (map[string]dnsmasq.DHCPLease, error) {
+	leases, err := dnsmasq.DHCPLeases(n.name)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get dynamic leases.
-	leaseFile := shared.VarPath("networks", n.name, "dnsmasq.leases")
-	if !shared.PathExists(leaseFile) {
-		return leases, nil
+	active := make(map[string]dnsmasq.DHCPLease, len(leases))
+	for _, lease := range leases {
+		if !lease.Expiry.IsZero() && lease.Expiry.Before(now) {
+			continue
+		}
+
+		active[lease.IP.String()] = lease
 	}
 
-	content, err := ioutil.ReadFile(leaseFile)
+	return active, nil
+}
+
+// leasesCompare emits the lifecycle events for the differences between the known and the active leases and
+// returns the active leases.
+func (n *bridge) leasesCompare(known map[string]dnsmasq.DHCPLease, now time.Time) map[string]dnsmasq.DHCPLease {
+	active, err := n.leasesActive(now)
 	if err != nil {
-		return nil, err
+		n.logger.Warn("Failed reading DHCP leases", logger.Ctx{"err": err})
+		return known
 	}
 
-	for _, lease := range strings.Split(string(content), "\n") {
-		fields := strings.Fields(lease)
-		if len(fields) >= 5 {
-			// Parse the MAC.
+	leaseContext := func(lease dnsmasq.DHCPLease) map[string]any {
+		ctx := map[string]any{"address": lease.IP.String()}
+
+		if lease.MAC != nil {
+			ctx["hwaddr"] = lease.MAC.String()
+		}
+
+		if lease.Hostname != "" {
+			ctx["hostname"] = lease.Hostname
+		}
+
+		if !lease.Expiry.IsZero() {
+			ctx["expiry"] = lease.Expiry.UTC()
+		}
+
+		return ctx
+	}
+
+	for address, oldLease := range known {
+		newLease, found := active[address]
+		if found && newLease.MAC.String() == oldLease.MAC.String() {
+			continue
+		}
+
+		n.state.Events.SendLifecycle(n.project, lifecycle.NetworkLeaseExpired.Event(n, nil, leaseContext(oldLease)))
+	}
+
+	for address, newLease := range active {
+		oldLease, found := known[address]
+		if !found || newLease.MAC.String() != oldLease.MAC.String() {
+			n.state.Events.SendLifecycle(n.project, lifecycle.NetworkLeaseCreated.Event(n, nil, leaseContext(newLease)))
+		} else if newLease.Expiry.After(oldLease.Expiry) {
+			n.state.Events.SendLifecycle(n.project, lifecycle.NetworkLeaseRenewed.Event(n, nil, leaseContext(newLease)))
+		}
+	}
+
+	return active
+}
+
+// bootRoutesV4 returns a list of IPv4 boot routes on the network's device.
+func (n *bridge) bootRoutesV4() ([]string, error) {
+	r := &ip.Route{
+		DevName: n.name,
+		Proto:   "boot",
+		Family:  ip.FamilyV4,
+	}
+	routes, err := r.Show()
+	if err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// bootRoutesV6 returns a list of IPv6 boot routes on the network's device.
+func (n *bridge) bootRoutesV6() ([]string, error) {
+	r := &ip.Route{
+		DevName: n.name,
+		Proto:   "boot",
+		Family:  ip.FamilyV6,
+	}
+	routes, err := r.Show()
+	if err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// applyBootRoutesV4 applies a list of IPv4 boot routes to the network's device.
+func (n *bridge) applyBootRoutesV4(routes []string) {
+	for _, route := range routes {
+		r := &ip.Route{
+			DevName: n.name,
+			Proto:   "boot",
+			Family:  ip.FamilyV4,
+		}
+		err := r.Replace(strings.Fields(route))
+		if err != nil {
+			// If it fails, then we can't stop as the route has already gone, so just log and continue.
+			n.logger.Error("Failed to restore route", logger.Ctx{"err": err})
+		}
+	}
+}
+
+// applyBootRoutesV6 applies a list of IPv6 boot routes to the network's device.
+func (n *bridge) applyBootRoutesV6(routes []string) {
+	for _, route := range routes {
+		r := &ip.Route{
+			DevName: n.name,
+			Proto:   "boot",
+			Family:  ip.FamilyV6,
+		}
+		err := r.Replace(strings.Fields(route))
+		if err != nil {
+			// If it fails, then we can't stop as the route has already gone, so just log and continue.
+			n.logger.Error("Failed to restore route", logger.Ctx{"err": err})
+		}
+	}
+}
+
+func (n *bridge) fanAddress(underlay *net.IPNet, overlay *net.IPNet) (string, string, string, error) {
+	// Quick checks.
+	underlaySize, _ := underlay.Mask.Size()
+	if underlaySize != 16 && underlaySize != 24 {
+		return "", "", "", fmt.Errorf("Only /16 or /24 underlays are supported at this time")
+	}
+
+	overlaySize, _ := overlay.Mask.Size()
+	if overlaySize != 8 && overlaySize != 16 {
+		return "", "", "", fmt.Errorf("Only /8 or /16 overlays are supported at this time")
+	}
+
+	if overlaySize+(32-underlaySize)+8 > 32 {
+		return "", "", "", fmt.Errorf("Underlay or overlay networks too large to accommodate the FAN")
+	}
+
+	// Get the IP
+	ip, dev, err := n.addressForSubnet(underlay)
+	if err != nil {
+		return "", "", "", err
+	}
+	ipStr := ip.String()
+
+	// Force into IPv4 format
+	ipBytes := ip.To4()
+	if ipBytes == nil {
+		return "", "", "", fmt.Errorf("Invalid IPv4: %s", ip)
+	}
+
+	// Compute the IP
+	ipBytes[0] = overlay.IP[0]
+	if overlaySize == 16 {
+		ipBytes[1] = overlay.IP[1]
+		ipBytes[2] = ipBytes[3]
+	} else if underlaySize == 24 {
+		ipBytes[1] = ipBytes[3]
+		ipBytes[2] = 0
+	} else if underlaySize == 16 {
+		ipBytes[1] = ipBytes[2]
+		ipBytes[2] = ipBytes[3]
+	}
+
+	ipBytes[3] = 1
+
+	return fmt.Sprintf("%s/%d", ipBytes.String(), overlaySize), dev, ipStr, err
+}
+
+// fanAddressV6 returns the address of the host on the IPv6 overlay of the fan. The bits identifying the host in
+// the underlay follow the overlay prefix (the same mapping as done by a 6rd tunnel) to form a /64 for the host.
+func fanAddressV6(underlayAddress net.IP, underlay *net.IPNet, overlay *net.IPNet) (string, error) {
+	underlaySize, _ := underlay.Mask.Size()
+	overlaySize, _ := overlay.Mask.Size()
+	hostBits := 32 - underlaySize
+
+	if overlaySize+hostBits > 64 {
+		return "", fmt.Errorf("IPv6 overlay network too small to accommodate the FAN (must be /%d or larger)", 64-hostBits)
+	}
+
+	ipBytes := underlayAddress.To4()
+	if ipBytes == nil {
+		return "", fmt.Errorf("Invalid IPv4: %s", underlayAddress)
+	}
+
+	host := uint64(binary.BigEndian.Uint32(ipBytes)) & (1<<hostBits - 1)
+	prefix := binary.BigEndian.Uint64(overlay.IP.To16()[:8]) | host<<(64-overlaySize-hostBits)
+
+	address := make(net.IP, net.IPv6len)
+	binary.BigEndian.PutUint64(address[:8], prefix)
+	address[15] = 1
+
+	return fmt.Sprintf("%s/64", address.String()), nil
+}
+
+// fanOverlaySubnetV6 returns a unique local /48 IPv6 subnet derived from the fan underlay subnet, so that all the
+// members of the fan generate the same overlay.
+func fanOverlaySubnetV6(underlay string) (*net.IPNet, error) {
+	_, underlaySubnet, err := net.ParseCIDR(underlay)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing fan.underlay_subnet: %w", err)
+	}
+
+	hash := sha256.Sum256([]byte(underlaySubnet.String()))
+
+	subnet := &net.IPNet{IP: make(net.IP, net.IPv6len), Mask: net.CIDRMask(48, 128)}
+	subnet.IP[0] = 0xfd
+	copy(subnet.IP[1:6], hash[:5])
+
+	return subnet, nil
+}
+
+func (n *bridge) addressForSubnet(subnet *net.IPNet) (net.IP, string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return net.IP{}, "", err
+	}
+
+	for _, iface := range ifaces {
+		// Skip addresses on lo interface in case VIPs are being used on that interface that are part of
+		// the underlay subnet as is unlikely to be the actual intended underlay subnet interface.
+		if iface.Name == "lo" {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ip, _, err := net.ParseCIDR(addr.String())
+			if err != nil {
+				continue
+			}
+
+			if subnet.Contains(ip) {
+				return ip, iface.Name, nil
+			}
+		}
+	}
+
+	return net.IP{}, "", fmt.Errorf("No address found in subnet")
+}
+
+func (n *bridge) killForkDNS() error {
+	// Check if we have a running forkdns at all
+	pidPath := shared.VarPath("networks", n.name, "forkdns.pid")
+
+	// If the pid file doesn't exist, there is no process to kill.
+	if !shared.PathExists(pidPath) {
+		return nil
+	}
+
+	p, err := subprocess.ImportProcess(pidPath)
+	if err != nil {
+		return fmt.Errorf("Could not read pid file: %s", err)
+	}
+
+	err = p.Stop()
+	if err != nil && err != subprocess.ErrNotRunning {
+		return fmt.Errorf("Unable to kill dnsmasq: %s", err)
+	}
+
+	return nil
+}
+
+// updateForkdnsServersFile takes a list of node addresses and writes them atomically to
+// the forkdns.servers file ready for forkdns to notice and re-apply its config.
+func (n *bridge) updateForkdnsServersFile(addresses []string) error {
+	// We don't want to race with ourselves here
+	forkdnsServersLock.Lock()
+	defer forkdnsServersLock.Unlock()
+
+	permName := shared.VarPath("networks", n.name, ForkdnsServersListPath+"/"+ForkdnsServersListFile)
+	tmpName := permName + ".tmp"
+
+	// Open tmp file and truncate
+	tmpFile, err := os.Create(tmpName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tmpFile.Close() }()
+
+	for _, address := range addresses {
+		_, err := tmpFile.WriteString(address + "\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tmpFile.Close()
+	if err != nil {
+		return err
+	}
+
+	// Atomically rename finished file into permanent location so forkdns can pick it up.
+	err = os.Rename(tmpName, permName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setupForwarding enables forwarding through the given sysctl, unless the configuration key is set to false.
+// In that case, forwarding is left to the host and a warning is logged if it isn't enabled.
+func (n *bridge) setupForwarding(key string, path string) error {
+	if n.config[key] == "" || shared.IsTrue(n.config[key]) {
+		return util.SysctlSet(path, "1")
+	}
+
+	value, err := util.SysctlGet(path)
+	if err != nil {
+		return fmt.Errorf("Error reading net sysctl %s: %w", path, err)
+	}
+
+	if strings.TrimSpace(value) != "1" {
+		n.logger.Warn("Forwarding isn't enabled on the host, traffic won't be routed in and out of the bridge", logger.Ctx{"sysctl": strings.Replace(path, "/", ".", -1), "key": key})
+	}
+
+	return nil
+}
+
+// hasIPv4Firewall indicates whether the network has IPv4 firewall enabled.
+func (n *bridge) hasIPv4Firewall() bool {
+	// IPv4 firewall is only enabled if there is a bridge ipv4.address or fan mode, and ipv4.firewall enabled.
+	// When using fan bridge.mode, there can be an empty ipv4.address, so we assume it is active.
+	if (n.config["bridge.mode"] == "fan" || !shared.StringInSlice(n.config["ipv4.address"], []string{"", "none"})) && (n.config["ipv4.firewall"] == "" || shared.IsTrue(n.config["ipv4.firewall"])) {
+		return true
+	}
+
+	return false
+}
+
+// hasIPv6Firewall indicates whether the network has IPv6 firewall enabled.
+func (n *bridge) hasIPv6Firewall() bool {
+	// IPv6 firewall is only enabled if there is a bridge ipv6.address and ipv6.firewall enabled.
+	if !shared.StringInSlice(n.config["ipv6.address"], []string{"", "none"}) && (n.config["ipv6.firewall"] == "" || shared.IsTrue(n.config["ipv6.firewall"])) {
+		return true
+	}
+
+	return false
+}
+
+// hasDHCPv4 indicates whether the network has DHCPv4 enabled.
+// An empty ipv4.dhcp setting indicates enabled by default.
+func (n *bridge) hasDHCPv4() bool {
+	if n.config["ipv4.dhcp"] == "" || shared.IsTrue(n.config["ipv4.dhcp"]) {
+		return true
+	}
+
+	return false
+}
+
+// hasDHCPv6 indicates whether the network has DHCPv6 enabled.
+// An empty ipv6.dhcp setting indicates enabled by default.
+func (n *bridge) hasDHCPv6() bool {
+	if n.config["ipv6.dhcp"] == "" || shared.IsTrue(n.config["ipv6.dhcp"]) {
+		return true
+	}
+
+	return false
+}
+
+// DHCPv4Subnet returns the DHCPv4 subnet (if DHCP is enabled on network).
+func (n *bridge) DHCPv4Subnet() *net.IPNet {
+	// DHCP is disabled on this network.
+	if !n.hasDHCPv4() {
+		return nil
+	}
+
+	// Fan mode. Extract DHCP subnet from fan bridge address. Only detectable once network has started.
+	// But if there is no address on the fan bridge then DHCP won't work anyway.
+	if n.config["bridge.mode"] == "fan" {
+		iface, err := net.InterfaceByName(n.name)
+		if err != nil {
+			return nil
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil
+		}
+
+		for _, addr := range addrs {
+			ip, subnet, err := net.ParseCIDR(addr.String())
+			if err != nil {
+				continue
+			}
+
+			if ip != nil && err == nil && ip.To4() != nil && ip.IsGlobalUnicast() {
+				return subnet // Use first IPv4 unicast address on host for DHCP subnet.
+			}
+		}
+
+		return nil // No addresses found, means DHCP must be disabled.
+	}
+
+	// Non-fan mode. Return configured primary bridge subnet directly.
+	_, subnet, err := net.ParseCIDR(util.BridgePrimaryAddress(n.config["ipv4.address"]))
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
+
+// DHCPv6Subnet returns the DHCPv6 subnet (if DHCP or SLAAC is enabled on network).
+func (n *bridge) DHCPv6Subnet() *net.IPNet {
+	// DHCP is disabled on this network.
+	if !n.hasDHCPv6() {
+		return nil
+	}
+
+	// Fan mode. Extract DHCP subnet from the fan bridge IPv6 overlay address, only set once network has started.
+	if n.config["bridge.mode"] == "fan" {
+		if n.config["fan.overlay_subnet6"] == "" {
+			return nil
+		}
+
+		iface, err := net.InterfaceByName(n.name)
+		if err != nil {
+			return nil
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil
+		}
+
+		for _, addr := range addrs {
+			ip, subnet, err := net.ParseCIDR(addr.String())
+			if err != nil {
+				continue
+			}
+
+			if ip.To4() == nil && ip.IsGlobalUnicast() {
+				return subnet // Use first IPv6 unicast address on host for DHCP subnet.
+			}
+		}
+
+		return nil
+	}
+
+	_, subnet, err := net.ParseCIDR(util.BridgePrimaryAddress(n.config["ipv6.address"]))
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
+
+// forwardConvertToFirewallForward converts forwards into format compatible with the firewall package.
+func (n *bridge) forwardConvertToFirewallForwards(listenAddress net.IP, defaultTargetAddress net.IP, portMaps []*forwardPortMap) []firewallDrivers.AddressForward {
+	var vips []firewallDrivers.AddressForward
+
+	if defaultTargetAddress != nil {
+		vips = append(vips, firewallDrivers.AddressForward{
+			ListenAddress: listenAddress,
+			TargetAddress: defaultTargetAddress,
+		})
+	}
+
+	for _, portMap := range portMaps {
+		vips = append(vips, firewallDrivers.AddressForward{
+			ListenAddress: listenAddress,
+			Protocol:      portMap.protocol,
+			TargetAddress: portMap.targetAddress,
+			ListenPorts:   portMap.listenPorts,
+			TargetPorts:   portMap.targetPorts,
+		})
+	}
+
+	return vips
+}
+
+// bridgeProjectNetworks takes a map of all networks in all projects and returns a filtered map of bridge networks.
+func (n *bridge) bridgeProjectNetworks(projectNetworks map[string]map[int64]api.Network) map[string][]*api.Network {
+	bridgeProjectNetworks := make(map[string][]*api.Network)
+	for netProject, networks := range projectNetworks {
+		for _, ni := range networks {
+			network := ni // Local var creating pointer to rather than iterator.
+
+			// Skip non-bridge networks.
+			if network.Type != "bridge" {
+				continue
+			}
+
+			if bridgeProjectNetworks[netProject] == nil {
+				bridgeProjectNetworks[netProject] = []*api.Network{&network}
+			} else {
+				bridgeProjectNetworks[netProject] = append(bridgeProjectNetworks[netProject], &network)
+			}
+		}
+	}
+
+	return bridgeProjectNetworks
+}
+
+// bridgeNetworkExternalSubnets returns a list of external subnets used by bridge networks. Networks are considered
+// to be using external subnets for their ipv4.address and/or ipv6.address if they have NAT disabled, and/or if
+// they have external NAT addresses specified.
+func (n *bridge) bridgeNetworkExternalSubnets(bridgeProjectNetworks map[string][]*api.Network) ([]externalSubnetUsage, error) {
+	externalSubnets := make([]externalSubnetUsage, 0)
+	for netProject, networks := range bridgeProjectNetworks {
+		for _, netInfo := range networks {
+			for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+				// If NAT is disabled, then network subnet is an external subnet.
+				if shared.IsFalseOrEmpty(netInfo.Config[fmt.Sprintf("%s.nat", keyPrefix)]) {
+					key := fmt.Sprintf("%s.address", keyPrefix)
+
+					_, ipNet, err := net.ParseCIDR(netInfo.Config[key])
+					if err != nil {
+						continue // Skip invalid/unspecified network addresses.
+					}
+
+					externalSubnets = append(externalSubnets, externalSubnetUsage{
+						subnet:         *ipNet,
+						networkProject: netProject,
+						networkName:    netInfo.Name,
+					})
+				}
+
+				// Find any external subnets used for network SNAT.
+				if netInfo.Config[fmt.Sprintf("%s.nat.address", keyPrefix)] != "" {
+					key := fmt.Sprintf("%s.nat.address", keyPrefix)
+
+					subnetSize := 128
+					if keyPrefix == "ipv4" {
+						subnetSize = 32
+					}
+
+					_, ipNet, err := net.ParseCIDR(fmt.Sprintf("%s/%d", netInfo.Config[key], subnetSize))
+					if err != nil {
+						return nil, fmt.Errorf("Failed parsing %q of %q in project %q: %w", key, netInfo.Name, netProject, err)
+					}
+
+					externalSubnets = append(externalSubnets, externalSubnetUsage{
+						subnet:         *ipNet,
+						networkProject: netProject,
+						networkName:    netInfo.Name,
+						networkSNAT:    true,
+					})
+				}
+
+				// Find any routes being used by the network.
+				for _, cidr := range shared.SplitNTrimSpace(netInfo.Config[fmt.Sprintf("%s.routes", keyPrefix)], ",", -1, true) {
+					_, ipNet, err := net.ParseCIDR(cidr)
+					if err != nil {
+						continue // Skip invalid/unspecified network addresses.
+					}
+
+					externalSubnets = append(externalSubnets, externalSubnetUsage{
+						subnet:         *ipNet,
+						networkProject: netProject,
+						networkName:    netInfo.Name,
+					})
+				}
+			}
+		}
+	}
+
+	return externalSubnets, nil
+}
+
+// bridgedNICExternalRoutes returns a list of external routes currently used by bridged NICs that are connected to
+// networks specified.
+func (n *bridge) bridgedNICExternalRoutes(bridgeProjectNetworks map[string][]*api.Network) ([]externalSubnetUsage, error) {
+	externalRoutes := make([]externalSubnetUsage, 0)
+
+	err := n.state.DB.Cluster.InstanceList(nil, func(inst db.Instance, p api.Project, profiles []api.Profile) error {
+		// Get the instance's effective network project name.
+		instNetworkProject := project.NetworkProjectFromRecord(&p)
+
+		if instNetworkProject != project.Default {
+			return nil // Managed bridge networks can only exist in default project.
+		}
+
+		devices := db.ExpandInstanceDevices(deviceConfig.NewDevices(db.DevicesToAPI(inst.Devices)), profiles)
+
+		// Iterate through each of the instance's devices, looking for bridged NICs that are linked to
+		// networks specified.
+		for devName, devConfig := range devices {
+			if devConfig["type"] != "nic" {
+				continue
+			}
+
+			// Check whether the NIC device references one of the networks supplied.
+			if !NICUsesNetwork(devConfig, bridgeProjectNetworks[instNetworkProject]...) {
+				continue
+			}
+
+			// For bridged NICs that are connected to networks specified, check if they have any
+			// routes or external routes configured, and if so add them to the list to return.
+			for _, key := range []string{"ipv4.routes", "ipv6.routes", "ipv4.routes.external", "ipv6.routes.external"} {
+				for _, cidr := range shared.SplitNTrimSpace(devConfig[key], ",", -1, true) {
+					_, ipNet, _ := net.ParseCIDR(cidr)
+					if ipNet == nil {
+						// Skip if NIC device doesn't have a valid route.
+						continue
+					}
+
+					externalRoutes = append(externalRoutes, externalSubnetUsage{
+						subnet:          *ipNet,
+						networkProject:  instNetworkProject,
+						networkName:     devConfig["network"],
+						instanceProject: inst.Project,
+						instanceName:    inst.Name,
+						instanceDevice:  devName,
+					})
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return externalRoutes, nil
+}
+
+// getExternalSubnetInUse returns information about usage of external subnets by bridge networks (and NICs
+// connected to them) on this member.
+func (n *bridge) getExternalSubnetInUse() ([]externalSubnetUsage, error) {
+	var err error
+	var projectNetworks map[string]map[int64]api.Network
+	var projectNetworksForwardsOnUplink map[string]map[int64][]string
+	var projectNetworksLoadBalancersOnUplink map[string]map[int64][]string
+
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		// Get all managed networks across all projects.
+		projectNetworks, err = tx.GetCreatedNetworks()
+		if err != nil {
+			return fmt.Errorf("Failed to load all networks: %w", err)
+		}
+
+		// Get all network forward listen addresses for forwards assigned to this specific cluster member.
+		projectNetworksForwardsOnUplink, err = tx.GetProjectNetworkForwardListenAddressesOnMember()
+		if err != nil {
+			return fmt.Errorf("Failed loading network forward listen addresses: %w", err)
+		}
+
+		// Get all network load balancer listen addresses for load balancers assigned to this specific cluster member.
+		projectNetworksLoadBalancersOnUplink, err = tx.GetProjectNetworkLoadBalancerListenAddressesOnMember()
+		if err != nil {
+			return fmt.Errorf("Failed loading network load balancer listen addresses: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Get managed bridge networks.
+	bridgeProjectNetworks := n.bridgeProjectNetworks(projectNetworks)
+
+	// Get external subnets used by other managed bridge networks.
+	bridgeNetworkExternalSubnets, err := n.bridgeNetworkExternalSubnets(bridgeProjectNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get external routes configured on bridged NICs.
+	bridgedNICExternalRoutes, err := n.bridgedNICExternalRoutes(bridgeProjectNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	externalSubnets := make([]externalSubnetUsage, 0, len(bridgeNetworkExternalSubnets)+len(bridgedNICExternalRoutes))
+	externalSubnets = append(externalSubnets, bridgeNetworkExternalSubnets...)
+	externalSubnets = append(externalSubnets, bridgedNICExternalRoutes...)
+
+	// Add forward and load balancer listen addresses to this list.
+	for _, projectNetworksListenAddresses := range []map[string]map[int64][]string{projectNetworksForwardsOnUplink, projectNetworksLoadBalancersOnUplink} {
+		for projectName, networks := range projectNetworksListenAddresses {
+			for networkID, listenAddresses := range networks {
+				for _, listenAddress := range listenAddresses {
+					// Convert listen address to subnet.
+					listenAddressNet, err := ParseIPToNet(listenAddress)
+					if err != nil {
+						return nil, fmt.Errorf("Invalid existing listen address %q", listenAddress)
+					}
+
+					// Create an externalSubnetUsage for the listen address by using the network ID
+					// of the listen address to retrieve the already loaded network name from the
+					// projectNetworks map.
+					externalSubnets = append(externalSubnets, externalSubnetUsage{
+						subnet:         *listenAddressNet,
+						networkProject: projectName,
+						networkName:    projectNetworks[projectName][networkID].Name,
+					})
+				}
+			}
+		}
+	}
+
+	return externalSubnets, nil
+}
+
+// bridgePortsSetHairpin enables hairpin mode on the bridge ports of the active NICs connected to this network on
+// this member if br_netfilter is enabled. This is needed in case any of the forwards or load balancers target a
+// NIC and the instance attempts to connect to the listener. Without hairpin mode on the target will not be able to
+// connect to the listener.
+func (n *bridge) bridgePortsSetHairpin() error {
+	if n.config["bridge.driver"] == "openvswitch" {
+		return nil
+	}
+
+	brNetfilterEnabled := false
+	for _, ipVersion := range []uint{4, 6} {
+		if BridgeNetfilterEnabled(ipVersion) == nil {
+			brNetfilterEnabled = true
+			break
+		}
+	}
+
+	if !brNetfilterEnabled {
+		return nil
+	}
+
+	var err error
+	var localNode string
+
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		localNode, err = tx.GetLocalNodeName()
+		if err != nil {
+			return fmt.Errorf("Failed to get local member name: %w", err)
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	filter := db.InstanceFilter{
+		Node: &localNode,
+	}
+
+	return n.state.DB.Cluster.InstanceList(&filter, func(inst db.Instance, p api.Project, profiles []api.Profile) error {
+		// Get the instance's effective network project name.
+		instNetworkProject := project.NetworkProjectFromRecord(&p)
+
+		if instNetworkProject != project.Default {
+			return nil // Managed bridge networks can only exist in default project.
+		}
+
+		devices := db.ExpandInstanceDevices(deviceConfig.NewDevices(db.DevicesToAPI(inst.Devices)), profiles)
+
+		// Iterate through each of the instance's devices, looking for bridged NICs
+		// that are linked to this network.
+		for devName, devConfig := range devices {
+			if devConfig["type"] != "nic" {
+				continue
+			}
+
+			// Check whether the NIC device references our network..
+			if !NICUsesNetwork(devConfig, &api.Network{Name: n.Name()}) {
+				continue
+			}
+
+			hostName := inst.Config[fmt.Sprintf("volatile.%s.host_name", devName)]
+			if InterfaceExists(hostName) {
+				link := &ip.Link{Name: hostName}
+				err = link.BridgeLinkSetHairpin(true)
+				if err != nil {
+					return fmt.Errorf("Error enabling hairpin mode on bridge port %q: %w", link.Name, err)
+				}
+
+				n.logger.Debug("Enabled hairpin mode on NIC bridge port", logger.Ctx{"inst": inst.Name, "project": inst.Project, "device": devName, "dev": link.Name})
+			}
+		}
+
+		return nil
+	})
+}
+
+// ForwardCreate creates a network forward.
+func (n *bridge) ForwardCreate(forward api.NetworkForwardsPost, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member forwards.
+
+	// Check if there is an existing forward using the same listen address.
+	_, _, err := n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, forward.ListenAddress)
+	if err == nil {
+		return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
+	}
+
+	// Check if there is an existing load balancer using the same listen address.
+	_, _, err = n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, forward.ListenAddress)
+	if err == nil {
+		return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+	}
+
+	// Convert listen address to subnet so we can check its valid and can be used.
+	listenAddressNet, err := ParseIPToNet(forward.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("Failed parsing address forward listen address %q: %w", forward.ListenAddress, err)
+	}
+
+	_, err = n.forwardValidate(listenAddressNet.IP, &forward.NetworkForwardPut)
+	if err != nil {
+		return err
+	}
+
+	externalSubnetsInUse, err := n.getExternalSubnetInUse()
+	if err != nil {
+		return err
+	}
+
+	// Check the listen address subnet doesn't fall within any existing network external subnets.
+	for _, externalSubnetUser := range externalSubnetsInUse {
+		// Skip our own network's SNAT address (as it can be used for NICs in the network).
+		if externalSubnetUser.networkSNAT && externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name {
+			continue
+		}
+
+		// Skip our own network (but not NIC devices on our own network).
+		if externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name && externalSubnetUser.instanceDevice == "" {
+			continue
+		}
+
+		if SubnetContains(&externalSubnetUser.subnet, listenAddressNet) || SubnetContains(listenAddressNet, &externalSubnetUser.subnet) {
+			// This error is purposefully vague so that it doesn't reveal any names of
+			// resources potentially outside of the network.
+			return fmt.Errorf("Forward listen address %q overlaps with another network or NIC", listenAddressNet.String())
+		}
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create forward DB record.
+	forwardID, err := n.state.DB.Cluster.CreateNetworkForward(n.ID(), memberSpecific, &forward)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = n.state.DB.Cluster.DeleteNetworkForward(n.ID(), forwardID)
+		_ = n.forwardSetupFirewall()
+		_ = n.forwardBGPSetupPrefixes()
+	})
+
+	err = n.forwardSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	// If we are the first forward on this bridge, enable hairpin mode on active NIC bridge ports.
+	listenAddresses, err := n.state.DB.Cluster.GetNetworkForwardListenAddresses(n.ID(), memberSpecific)
+	if err != nil {
+		return fmt.Errorf("Failed loading network forwards: %w", err)
+	}
+
+	if len(listenAddresses) <= 1 {
+		err = n.bridgePortsSetHairpin()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err = n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for address forwards: %w", err)
+	}
+
+	revert.Success()
+	return nil
+}
+
+// ForwardUpdate updates a network forward.
+func (n *bridge) ForwardUpdate(listenAddress string, req api.NetworkForwardPut, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member forwards.
+	curForwardID, curForward, err := n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	_, err = n.forwardValidate(net.ParseIP(curForward.ListenAddress), &req)
+	if err != nil {
+		return err
+	}
+
+	curForwardEtagHash, err := util.EtagHash(curForward.Etag())
+	if err != nil {
+		return err
+	}
+
+	newForward := api.NetworkForward{
+		ListenAddress:     curForward.ListenAddress,
+		NetworkForwardPut: req,
+	}
+
+	newForwardEtagHash, err := util.EtagHash(newForward.Etag())
+	if err != nil {
+		return err
+	}
+
+	if curForwardEtagHash == newForwardEtagHash {
+		return nil // Nothing has changed.
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = n.state.DB.Cluster.UpdateNetworkForward(n.ID(), curForwardID, &newForward.NetworkForwardPut)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = n.state.DB.Cluster.UpdateNetworkForward(n.ID(), curForwardID, &curForward.NetworkForwardPut)
+		_ = n.forwardSetupFirewall()
+		_ = n.forwardBGPSetupPrefixes()
+	})
+
+	err = n.forwardSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// ForwardDelete deletes a network forward.
+func (n *bridge) ForwardDelete(listenAddress string, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member forwards.
+	forwardID, forward, err := n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = n.state.DB.Cluster.DeleteNetworkForward(n.ID(), forwardID)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		newForward := api.NetworkForwardsPost{
+			NetworkForwardPut: forward.NetworkForwardPut,
+			ListenAddress:     forward.ListenAddress,
+		}
+		_, _ = n.state.DB.Cluster.CreateNetworkForward(n.ID(), memberSpecific, &newForward)
+		_ = n.forwardSetupFirewall()
+		_ = n.forwardBGPSetupPrefixes()
+	})
+
+	err = n.forwardSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err = n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for address forwards: %w", err)
+	}
+
+	revert.Success()
+	return nil
+}
+
+// forwardSetupFirewall applies all network address forwards defined for this network and this member.
+func (n *bridge) forwardSetupFirewall() error {
+	memberSpecific := true // Get all forwards for this cluster member.
+	forwards, err := n.state.DB.Cluster.GetNetworkForwards(n.ID(), memberSpecific)
+	if err != nil {
+		return fmt.Errorf("Failed loading network forwards: %w", err)
+	}
+
+	var fwForwards []firewallDrivers.AddressForward
+	ipVersions := make(map[uint]struct{})
+
+	for _, forward := range forwards {
+		// Convert listen address to subnet so we can check its valid and can be used.
+		listenAddressNet, err := ParseIPToNet(forward.ListenAddress)
+		if err != nil {
+			return fmt.Errorf("Failed parsing address forward listen address %q: %w", forward.ListenAddress, err)
+		}
+
+		// Track which IP versions we are using.
+		if listenAddressNet.IP.To4() == nil {
+			ipVersions[6] = struct{}{}
+		} else {
+			ipVersions[4] = struct{}{}
+		}
+
+		portMaps, err := n.forwardValidate(listenAddressNet.IP, &forward.NetworkForwardPut)
+		if err != nil {
+			return fmt.Errorf("Failed validating firewall address forward for listen address %q: %w", forward.ListenAddress, err)
+		}
+
+		fwForwards = append(fwForwards, n.forwardConvertToFirewallForwards(listenAddressNet.IP, net.ParseIP(forward.Config["target_address"]), portMaps)...)
+	}
+
+	if len(forwards) > 0 {
+		// Check if br_netfilter is enabled to, and warn if not.
+		brNetfilterWarning := false
+		for ipVersion := range ipVersions {
+			err = BridgeNetfilterEnabled(ipVersion)
+			if err != nil {
+				brNetfilterWarning = true
+				msg := fmt.Sprintf("IPv%d bridge netfilter not enabled. Instances using the bridge will not be able to connect to the forward listen IPs", ipVersion)
+				n.logger.Warn(msg, logger.Ctx{"err": err})
+				err = n.state.DB.Cluster.UpsertWarningLocalNode(n.project, dbCluster.TypeNetwork, int(n.id), db.WarningProxyBridgeNetfilterNotEnabled, fmt.Sprintf("%s: %v", msg, err))
+				if err != nil {
+					n.logger.Warn("Failed to create warning", logger.Ctx{"err": err})
+				}
+			}
+		}
+
+		if !brNetfilterWarning {
+			err = warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(n.state.DB.Cluster, n.project, db.WarningProxyBridgeNetfilterNotEnabled, dbCluster.TypeNetwork, int(n.id))
+			if err != nil {
+				n.logger.Warn("Failed to resolve warning", logger.Ctx{"err": err})
+			}
+		}
+	}
+
+	err = n.state.Firewall.NetworkApplyForwards(n.name, fwForwards)
+	if err != nil {
+		return fmt.Errorf("Failed applying firewall address forwards: %w", err)
+	}
+
+	return nil
+}
+
+// LoadBalancerCreate creates a network load balancer.
+func (n *bridge) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member load balancers.
+
+	// Check if there is an existing load balancer or forward using the same listen address.
+	_, _, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, loadBalancer.ListenAddress)
+	if err == nil {
+		return api.StatusErrorf(http.StatusConflict, "A load balancer for that listen address already exists")
+	}
+
+	_, _, err = n.state.DB.Cluster.GetNetworkForward(n.ID(), memberSpecific, loadBalancer.ListenAddress)
+	if err == nil {
+		return api.StatusErrorf(http.StatusConflict, "A forward for that listen address already exists")
+	}
+
+	// Convert listen address to subnet so we can check its valid and can be used.
+	listenAddressNet, err := ParseIPToNet(loadBalancer.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("Failed parsing load balancer listen address %q: %w", loadBalancer.ListenAddress, err)
+	}
+
+	_, err = n.loadBalancerValidate(listenAddressNet.IP, &loadBalancer.NetworkLoadBalancerPut)
+	if err != nil {
+		return err
+	}
+
+	externalSubnetsInUse, err := n.getExternalSubnetInUse()
+	if err != nil {
+		return err
+	}
+
+	// Check the listen address subnet doesn't fall within any existing network external subnets.
+	for _, externalSubnetUser := range externalSubnetsInUse {
+		// Skip our own network's SNAT address (as it can be used for NICs in the network).
+		if externalSubnetUser.networkSNAT && externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name {
+			continue
+		}
+
+		// Skip our own network (but not NIC devices on our own network).
+		if externalSubnetUser.networkProject == n.project && externalSubnetUser.networkName == n.name && externalSubnetUser.instanceDevice == "" {
+			continue
+		}
+
+		if SubnetContains(&externalSubnetUser.subnet, listenAddressNet) || SubnetContains(listenAddressNet, &externalSubnetUser.subnet) {
+			// This error is purposefully vague so that it doesn't reveal any names of
+			// resources potentially outside of the network.
+			return fmt.Errorf("Load balancer listen address %q overlaps with another network or NIC", listenAddressNet.String())
+		}
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create load balancer DB record.
+	loadBalancerID, err := n.state.DB.Cluster.CreateNetworkLoadBalancer(n.ID(), memberSpecific, &loadBalancer)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = n.state.DB.Cluster.DeleteNetworkLoadBalancer(n.ID(), loadBalancerID)
+		_ = n.loadBalancerSetupFirewall()
+		_ = n.forwardBGPSetupPrefixes()
+	})
+
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	// If we are the first load balancer on this bridge, enable hairpin mode on active NIC bridge ports.
+	listenAddresses, err := n.state.DB.Cluster.GetNetworkLoadBalancerListenAddresses(n.ID(), memberSpecific)
+	if err != nil {
+		return fmt.Errorf("Failed loading network load balancers: %w", err)
+	}
+
+	if len(listenAddresses) <= 1 {
+		err = n.bridgePortsSetHairpin()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err = n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerUpdate updates a network load balancer.
+func (n *bridge) LoadBalancerUpdate(listenAddress string, req api.NetworkLoadBalancerPut, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member load balancers.
+	curLoadBalancerID, curLoadBalancer, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	_, err = n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &req)
+	if err != nil {
+		return err
+	}
+
+	curLoadBalancerEtagHash, err := util.EtagHash(curLoadBalancer.Etag())
+	if err != nil {
+		return err
+	}
+
+	newLoadBalancer := api.NetworkLoadBalancer{
+		ListenAddress:          curLoadBalancer.ListenAddress,
+		NetworkLoadBalancerPut: req,
+	}
+
+	newLoadBalancerEtagHash, err := util.EtagHash(newLoadBalancer.Etag())
+	if err != nil {
+		return err
+	}
+
+	if curLoadBalancerEtagHash == newLoadBalancerEtagHash {
+		return nil // Nothing has changed.
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = n.state.DB.Cluster.UpdateNetworkLoadBalancer(n.ID(), curLoadBalancerID, &newLoadBalancer.NetworkLoadBalancerPut)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = n.state.DB.Cluster.UpdateNetworkLoadBalancer(n.ID(), curLoadBalancerID, &curLoadBalancer.NetworkLoadBalancerPut)
+		_ = n.loadBalancerSetupFirewall()
+	})
+
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerDelete deletes a network load balancer.
+func (n *bridge) LoadBalancerDelete(listenAddress string, clientType request.ClientType) error {
+	memberSpecific := true // bridge supports per-member load balancers.
+	loadBalancerID, loadBalancer, err := n.state.DB.Cluster.GetNetworkLoadBalancer(n.ID(), memberSpecific, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = n.state.DB.Cluster.DeleteNetworkLoadBalancer(n.ID(), loadBalancerID)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		newLoadBalancer := api.NetworkLoadBalancersPost{
+			NetworkLoadBalancerPut: loadBalancer.NetworkLoadBalancerPut,
+			ListenAddress:          loadBalancer.ListenAddress,
+		}
+		_, _ = n.state.DB.Cluster.CreateNetworkLoadBalancer(n.ID(), memberSpecific, &newLoadBalancer)
+		_ = n.loadBalancerSetupFirewall()
+		_ = n.forwardBGPSetupPrefixes()
+	})
+
+	err = n.loadBalancerSetupFirewall()
+	if err != nil {
+		return err
+	}
+
+	// Refresh exported BGP prefixes on local member.
+	err = n.forwardBGPSetupPrefixes()
+	if err != nil {
+		return fmt.Errorf("Failed applying BGP prefixes for load balancers: %w", err)
+	}
+
+	revert.Success()
+	return nil
+}
+
+// loadBalancerSetupFirewall applies all network load balancers defined for this network and this member.
+func (n *bridge) loadBalancerSetupFirewall() error {
+	memberSpecific := true // Get all load balancers for this cluster member.
+	loadBalancers, err := n.state.DB.Cluster.GetNetworkLoadBalancers(n.ID(), memberSpecific)
+	if err != nil {
+		return fmt.Errorf("Failed loading network load balancers: %w", err)
+	}
+
+	var fwLoadBalancers []firewallDrivers.LoadBalancer
+	ipVersions := make(map[uint]struct{})
+
+	for _, loadBalancer := range loadBalancers {
+		// Convert listen address to subnet so we can check its valid and can be used.
+		listenAddressNet, err := ParseIPToNet(loadBalancer.ListenAddress)
+		if err != nil {
+			return fmt.Errorf("Failed parsing load balancer listen address %q: %w", loadBalancer.ListenAddress, err)
+		}
+
+		// Track which IP versions we are using.
+		if listenAddressNet.IP.To4() == nil {
+			ipVersions[6] = struct{}{}
+		} else {
+			ipVersions[4] = struct{}{}
+		}
+
+		portMaps, err := n.loadBalancerValidate(listenAddressNet.IP, &loadBalancer.NetworkLoadBalancerPut)
+		if err != nil {
+			return fmt.Errorf("Failed validating firewall load balancer for listen address %q: %w", loadBalancer.ListenAddress, err)
+		}
+
+		policy := loadBalancer.Config["policy"]
+		if policy == "" {
+			policy = firewallDrivers.LoadBalancerPolicyRoundRobin
+		}
+
+		fwLoadBalancers = append(fwLoadBalancers, n.loadBalancerConvertToFirewallLoadBalancers(listenAddressNet.IP, policy, portMaps)...)
+	}
+
+	// Check if br_netfilter is enabled, and warn if not.
+	for ipVersion := range ipVersions {
+		err = BridgeNetfilterEnabled(ipVersion)
+		if err != nil {
+			n.logger.Warn(fmt.Sprintf("IPv%d bridge netfilter not enabled. Instances using the bridge will not be able to connect to the load balancer listen IPs", ipVersion), logger.Ctx{"err": err})
+		}
+	}
+
+	err = n.state.Firewall.NetworkApplyLoadBalancers(n.name, fwLoadBalancers)
+	if err != nil {
+		return fmt.Errorf("Failed applying firewall load balancers: %w", err)
+	}
+
+	return nil
+}
+
+// loadBalancerConvertToFirewallLoadBalancers converts load balancer port maps into firewall load balancers, one
+// for each listen port.
+func (n *bridge) loadBalancerConvertToFirewallLoadBalancers(listenAddress net.IP, policy string, portMaps []*loadBalancerPortMap) []firewallDrivers.LoadBalancer {
+	var fwLoadBalancers []firewallDrivers.LoadBalancer
+
+	for _, portMap := range portMaps {
+		for i, listenPort := range portMap.listenPorts {
+			fwLoadBalancer := firewallDrivers.LoadBalancer{
+				ListenAddress: listenAddress,
+				Protocol:      portMap.protocol,
+				ListenPort:    listenPort,
+				Targets:       make([]firewallDrivers.LoadBalancerTarget, 0, len(portMap.targets)),
+				Policy:        policy,
+			}
+
+			for _, target := range portMap.targets {
+				// Use the target port that corresponds to the listen port (unless only 1 is specified, in
+				// which case use same target port for all listen ports).
+				var targetPort uint64
+
+				switch {
+				case len(target.targetPorts) <= 0:
+					// No target ports specified, use same port as listen port.
+					targetPort = listenPort
+				case len(target.targetPorts) == 1:
+					// Single target port specified, use that for all listen ports.
+					targetPort = target.targetPorts[0]
+				default:
+					// Multiple target ports specified, use port associated with listen port index.
+					targetPort = target.targetPorts[i]
+				}
+
+				fwLoadBalancer.Targets = append(fwLoadBalancer.Targets, firewallDrivers.LoadBalancerTarget{
+					Address: target.address,
+					Port:    targetPort,
+				})
+			}
+
+			fwLoadBalancers = append(fwLoadBalancers, fwLoadBalancer)
+		}
+	}
+
+	return fwLoadBalancers
+}
+
+// Leases returns a list of leases for the bridged network. It will reach out to other cluster members as needed.
+// The projectName passed here refers to the initial project from the API request which may differ from the network's project.
+func (n *bridge) Leases(projectName string, clientType request.ClientType) ([]api.NetworkLease, error) {
+	leases := []api.NetworkLease{}
+	projectMacs := []string{}
+
+	// Instances owning the MACs in projectMacs, as "project/instance".
+	macOwners := map[string]string{}
+
+	// setOwner fills in the project and instance a lease belongs to using its MAC.
+	setOwner := func(lease *api.NetworkLease) {
+		owner, ok := macOwners[lease.Hwaddr]
+		if !ok {
+			return
+		}
+
+		lease.Project, lease.Instance, _ = strings.Cut(owner, "/")
+		if lease.Hostname == "" || lease.Hostname == "*" {
+			lease.Hostname = lease.Instance
+		}
+	}
+
+	// Get all static leases.
+	if clientType == request.ClientTypeNormal {
+		// Get the downstream networks.
+		if n.project == project.Default {
+			var err error
+
+			// Load all the networks.
+			var projectNetworks map[string]map[int64]api.Network
+			err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+				projectNetworks, err = tx.GetCreatedNetworks()
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// Look for networks using the current network as an uplink.
+			for projectName, networks := range projectNetworks {
+				for _, network := range networks {
+					if network.Config["network"] != n.name {
+						continue
+					}
+
+					// Found a network, add leases.
+					for _, k := range []string{"volatile.network.ipv4.address", "volatile.network.ipv6.address"} {
+						v := network.Config[k]
+						if v != "" {
+							leases = append(leases, api.NetworkLease{
+								Hostname: fmt.Sprintf("%s-%s.uplink", projectName, network.Name),
+								Address:  v,
+								Type:     "uplink",
+								Project:  projectName,
+							})
+						}
+					}
+				}
+			}
+		}
+
+		// Get all the instances.
+		instances, err := instance.LoadByProject(n.state, projectName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, inst := range instances {
+			// Go through all its devices (including profiles).
+			for k, dev := range inst.ExpandedDevices() {
+				// Skip uninteresting entries.
+				if dev["type"] != "nic" {
+					continue
+				}
+
+				nicType, err := nictype.NICType(n.state, inst.Project(), dev)
+				if err != nil || nicType != "bridged" {
+					continue
+				}
+
+				// Temporarily populate parent from network setting if used.
+				if dev["network"] != "" {
+					dev["parent"] = dev["network"]
+				}
+
+				if dev["parent"] != n.name {
+					continue
+				}
+
+				// Fill in the hwaddr from volatile.
+				if dev["hwaddr"] == "" {
+					dev["hwaddr"] = inst.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", k)]
+				}
+
+				// Record the MAC.
+				if dev["hwaddr"] != "" {
+					projectMacs = append(projectMacs, dev["hwaddr"])
+					macOwners[dev["hwaddr"]] = fmt.Sprintf("%s/%s", inst.Project(), inst.Name())
+				}
+
+				// Add the lease.
+				if dev["ipv4.address"] != "" {
+					leases = append(leases, api.NetworkLease{
+						Hostname: inst.Name(),
+						Address:  dev["ipv4.address"],
+						Hwaddr:   dev["hwaddr"],
+						Type:     "static",
+						Location: inst.Location(),
+						Project:  inst.Project(),
+						Instance: inst.Name(),
+					})
+				}
+
+				if dev["ipv6.address"] != "" {
+					leases = append(leases, api.NetworkLease{
+						Hostname: inst.Name(),
+						Address:  dev["ipv6.address"],
+						Hwaddr:   dev["hwaddr"],
+						Type:     "static",
+						Location: inst.Location(),
+						Project:  inst.Project(),
+						Instance: inst.Name(),
+					})
+				}
+
+				// Add EUI64 records (one per subnet of the network).
+				if shared.IsFalseOrEmpty(n.config["ipv6.dhcp.stateful"]) {
+					for _, ipv6Address := range util.BridgeAddresses(n.config["ipv6.address"]) {
+						_, netAddress, _ := net.ParseCIDR(ipv6Address)
+						hwAddr, _ := net.ParseMAC(dev["hwaddr"])
+						if netAddress != nil && hwAddr != nil {
+							ipv6, err := eui64.ParseMAC(netAddress.IP, hwAddr)
+							if err == nil {
+								leases = append(leases, api.NetworkLease{
+									Hostname: inst.Name(),
+									Address:  ipv6.String(),
+									Hwaddr:   dev["hwaddr"],
+									Type:     "dynamic",
+									Location: inst.Location(),
+									Project:  inst.Project(),
+									Instance: inst.Name(),
+								})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Local server name.
+	var err error
+	var serverName string
+	err = n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		serverName, err = tx.GetLocalNodeName()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Get dynamic leases.
+	leaseFile := shared.VarPath("networks", n.name, "dnsmasq.leases")
+	if !shared.PathExists(leaseFile) {
+		return leases, nil
+	}
+
+	content, err := ioutil.ReadFile(leaseFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the DHCP reservations so the leases handed out for them are reported as static.
+	reservations, err := n.state.DB.Cluster.GetNetworkReservations(n.ID())
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading network reservations: %w", err)
+	}
+
+	for _, lease := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(lease)
+		if len(fields) >= 5 {
+			// Parse the MAC.
 			mac := GetMACSlice(fields[1])
 			macStr := strings.Join(mac, ":")
 
-			if len(macStr) < 17 && fields[4] != "" {
-				macStr = fields[4][len(fields[4])-17:]
-			}
+			if len(macStr) < 17 && fields[4] != "" {
+				macStr = fields[4][len(fields[4])-17:]
+			}
+
+			// Look for an existing static entry.
+			found := false
+			for _, entry := range leases {
+				if entry.Hwaddr == macStr && entry.Address == fields[2] {
+					found = true
+					break
+				}
+			}
+
+			if found {
+				continue
+			}
+
+			// Leases matching a DHCP reservation are static.
+			leaseType := "dynamic"
+			for _, reservation := range reservations {
+				if reservation.Hwaddr == macStr && (reservation.IPv4Address == fields[2] || reservation.IPv6Address == fields[2]) {
+					leaseType = "static"
+					break
+				}
+			}
+
+			// The first field is the expiry time of the lease, 0 meaning it never expires.
+			var expiresAt *time.Time
+			expiry, err := strconv.ParseInt(fields[0], 10, 64)
+			if err == nil && expiry > 0 {
+				expiryTime := time.Unix(expiry, 0).UTC()
+				expiresAt = &expiryTime
+			}
+
+			// DHCPv6 leases can't be tracked down to a MAC so clear the field.
+			// This means that instance project filtering will not work on IPv6 leases.
+			if strings.Contains(fields[2], ":") {
+				macStr = ""
+			}
+
+			// Skip leases that don't match any of the instance MACs from the project (only when we
+			// have populated the projectMacs list in ClientTypeNormal mode). Otherwise get all local
+			// leases and they will be filtered on the server handling the end user request.
+			if clientType == request.ClientTypeNormal && macStr != "" && !shared.StringInSlice(macStr, projectMacs) {
+				continue
+			}
+
+			// Add the lease to the list.
+			dynamicLease := api.NetworkLease{
+				Hostname:  fields[3],
+				Address:   fields[2],
+				Hwaddr:    macStr,
+				Type:      leaseType,
+				Location:  serverName,
+				ExpiresAt: expiresAt,
+			}
+
+			setOwner(&dynamicLease)
+			leases = append(leases, dynamicLease)
+		}
+	}
+
+	// Collect leases from other servers.
+	if clientType == request.ClientTypeNormal {
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return nil, err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			memberLeases, err := client.GetNetworkLeases(n.name)
+			if err != nil {
+				return err
+			}
+
+			// Add local leases from other members, filtering them for MACs that belong to the project.
+			for _, lease := range memberLeases {
+				if lease.Hwaddr != "" && shared.StringInSlice(lease.Hwaddr, projectMacs) {
+					setOwner(&lease)
+					leases = append(leases, lease)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return leases, nil
+}
+
+// reservationValidate validates a network DHCP reservation against the network's DHCP configuration and the other
+// reservations of the network.
+func (n *bridge) reservationValidate(reservation *api.NetworkReservationsPost) error {
+	err := validate.IsNetworkMAC(reservation.Hwaddr)
+	if err != nil {
+		return fmt.Errorf("Invalid MAC address %q: %w", reservation.Hwaddr, err)
+	}
+
+	if reservation.IPv4Address == "" && reservation.IPv6Address == "" {
+		return fmt.Errorf("At least one of IPv4 or IPv6 address must be specified")
+	}
+
+	if reservation.Hostname != "" {
+		err = validate.IsHostname(reservation.Hostname)
+		if err != nil {
+			return fmt.Errorf("Invalid host name %q: %w", reservation.Hostname, err)
+		}
+	}
+
+	if reservation.IPv4Address != "" {
+		ip := net.ParseIP(reservation.IPv4Address)
+		if ip == nil || ip.To4() == nil {
+			return fmt.Errorf("Invalid IPv4 address %q", reservation.IPv4Address)
+		}
+
+		subnet := n.DHCPv4Subnet()
+		if subnet == nil {
+			return fmt.Errorf("Cannot reserve an IPv4 address when DHCPv4 is disabled on the network")
+		}
+
+		if !subnet.Contains(ip) {
+			return fmt.Errorf("IPv4 address %q is not within the network subnet %q", ip.String(), subnet.String())
+		}
+	}
+
+	if reservation.IPv6Address != "" {
+		ip := net.ParseIP(reservation.IPv6Address)
+		if ip == nil || ip.To4() != nil {
+			return fmt.Errorf("Invalid IPv6 address %q", reservation.IPv6Address)
+		}
+
+		subnet := n.DHCPv6Subnet()
+		if subnet == nil || !shared.IsTrue(n.config["ipv6.dhcp.stateful"]) {
+			return fmt.Errorf("Cannot reserve an IPv6 address when stateful DHCPv6 is disabled on the network")
+		}
+
+		if !subnet.Contains(ip) {
+			return fmt.Errorf("IPv6 address %q is not within the network subnet %q", ip.String(), subnet.String())
+		}
+	}
+
+	// Check the addresses aren't already used by another reservation of the network.
+	reservations, err := n.state.DB.Cluster.GetNetworkReservations(n.ID())
+	if err != nil {
+		return fmt.Errorf("Failed loading network reservations: %w", err)
+	}
+
+	for _, other := range reservations {
+		if reservation.IPv4Address != "" && other.IPv4Address == reservation.IPv4Address {
+			return api.StatusErrorf(http.StatusConflict, "IPv4 address %q is already reserved for %q", reservation.IPv4Address, other.Hwaddr)
+		}
+
+		if reservation.IPv6Address != "" && other.IPv6Address == reservation.IPv6Address {
+			return api.StatusErrorf(http.StatusConflict, "IPv6 address %q is already reserved for %q", reservation.IPv6Address, other.Hwaddr)
+		}
+	}
+
+	// Check the MAC address and addresses aren't already used by an instance NIC connected to the network.
+	err = usedByInstanceDevices(n.state, n.project, n.name, func(inst db.Instance, nicName string, nicConfig map[string]string) error {
+		hwaddr := nicConfig["hwaddr"]
+		if hwaddr == "" {
+			hwaddr = inst.Config[fmt.Sprintf("volatile.%s.hwaddr", nicName)]
+		}
+
+		if macAddressEqual(hwaddr, reservation.Hwaddr) {
+			return api.StatusErrorf(http.StatusConflict, "MAC address %q is already used by instance %q", reservation.Hwaddr, project.Instance(inst.Project, inst.Name))
+		}
+
+		if ipAddressEqual(nicConfig["ipv4.address"], reservation.IPv4Address) {
+			return api.StatusErrorf(http.StatusConflict, "IPv4 address %q is already used by instance %q", reservation.IPv4Address, project.Instance(inst.Project, inst.Name))
+		}
+
+		if ipAddressEqual(nicConfig["ipv6.address"], reservation.IPv6Address) {
+			return api.StatusErrorf(http.StatusConflict, "IPv6 address %q is already used by instance %q", reservation.IPv6Address, project.Instance(inst.Project, inst.Name))
+		}
 
-			// Look for an existing static entry.
-			found := false
-			for _, entry := range leases {
-				if entry.Hwaddr == macStr && entry.Address == fields[2] {
-					found = true
-					break
-				}
-			}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-			if found {
-				continue
-			}
+	return nil
+}
 
-			// DHCPv6 leases can't be tracked down to a MAC so clear the field.
-			// This means that instance project filtering will not work on IPv6 leases.
-			if strings.Contains(fields[2], ":") {
-				macStr = ""
-			}
+// Reservations returns the DHCP reservations of the network.
+func (n *bridge) Reservations() ([]api.NetworkReservation, error) {
+	records, err := n.state.DB.Cluster.GetNetworkReservations(n.ID())
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading network reservations: %w", err)
+	}
 
-			// Skip leases that don't match any of the instance MACs from the project (only when we
-			// have populated the projectMacs list in ClientTypeNormal mode). Otherwise get all local
-			// leases and they will be filtered on the server handling the end user request.
-			if clientType == request.ClientTypeNormal && macStr != "" && !shared.StringInSlice(macStr, projectMacs) {
-				continue
-			}
+	reservations := make([]api.NetworkReservation, 0, len(records))
+	for _, record := range records {
+		reservations = append(reservations, *record)
+	}
 
-			// Add the lease to the list.
-			leases = append(leases, api.NetworkLease{
-				Hostname: fields[3],
-				Address:  fields[2],
-				Hwaddr:   macStr,
-				Type:     "dynamic",
-				Location: serverName,
-			})
+	sort.Slice(reservations, func(i, j int) bool { return reservations[i].Hwaddr < reservations[j].Hwaddr })
+
+	return reservations, nil
+}
+
+// ReservationCreate creates a network DHCP reservation.
+// Reservations apply to all cluster members, so other members are notified to refresh their dnsmasq configuration.
+func (n *bridge) ReservationCreate(reservation api.NetworkReservationsPost, clientType request.ClientType) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if clientType != request.ClientTypeNotifier {
+		// Compare MAC addresses in their canonical form.
+		reservation.Normalise()
+
+		// Check if there is an existing reservation for the same MAC address.
+		_, _, err := n.state.DB.Cluster.GetNetworkReservation(n.ID(), reservation.Hwaddr)
+		if err == nil {
+			return api.StatusErrorf(http.StatusConflict, "A reservation for that MAC address already exists")
+		}
+
+		err = n.reservationValidate(&reservation)
+		if err != nil {
+			return err
+		}
+
+		reservationID, err := n.state.DB.Cluster.CreateNetworkReservation(n.ID(), &reservation)
+		if err != nil {
+			return err
 		}
+
+		revert.Add(func() {
+			_ = n.state.DB.Cluster.DeleteNetworkReservation(n.ID(), reservationID)
+			_ = UpdateDNSMasqStatic(n.state, n.name)
+		})
 	}
 
-	// Collect leases from other servers.
-	if clientType == request.ClientTypeNormal {
+	err := UpdateDNSMasqStatic(n.state, n.name)
+	if err != nil {
+		return fmt.Errorf("Failed applying network reservations: %w", err)
+	}
+
+	if clientType != request.ClientTypeNotifier {
 		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		err = notifier(func(client lxd.InstanceServer) error {
-			memberLeases, err := client.GetNetworkLeases(n.name)
+			return client.UseProject(n.project).CreateNetworkReservation(n.name, reservation)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// ReservationDelete deletes a network DHCP reservation.
+// Reservations apply to all cluster members, so other members are notified to refresh their dnsmasq configuration.
+func (n *bridge) ReservationDelete(hwaddr string, clientType request.ClientType) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	if clientType != request.ClientTypeNotifier {
+		reservationID, reservation, err := n.state.DB.Cluster.GetNetworkReservation(n.ID(), hwaddr)
+		if err != nil {
+			return err
+		}
+
+		err = n.state.DB.Cluster.DeleteNetworkReservation(n.ID(), reservationID)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			newReservation := api.NetworkReservationsPost{
+				NetworkReservationPut: reservation.NetworkReservationPut,
+				Hwaddr:                reservation.Hwaddr,
+			}
+
+			_, _ = n.state.DB.Cluster.CreateNetworkReservation(n.ID(), &newReservation)
+			_ = UpdateDNSMasqStatic(n.state, n.name)
+		})
+	}
+
+	err := UpdateDNSMasqStatic(n.state, n.name)
+	if err != nil {
+		return fmt.Errorf("Failed applying network reservations: %w", err)
+	}
+
+	if clientType != request.ClientTypeNotifier {
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.UseProject(n.project).DeleteNetworkReservation(n.name, hwaddr)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// PeerCreate creates a network peering with another bridge network.
+// Once the peering is mutual, the traffic between the two networks is always forwarded and isn't translated.
+// Peerings apply to all cluster members, so other members are notified to refresh their firewall.
+func (n *bridge) PeerCreate(peer api.NetworkPeersPost, clientType request.ClientType) error {
+	if clientType == request.ClientTypeNotifier {
+		return n.peerRefresh()
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Default to network's project if target project not specified.
+	if peer.TargetProject == "" {
+		peer.TargetProject = n.Project()
+	}
+
+	// Target network is required.
+	if peer.TargetNetwork == "" {
+		return api.StatusErrorf(http.StatusBadRequest, "Target network is required")
+	}
+
+	if peer.TargetProject == n.Project() && peer.TargetNetwork == n.Name() {
+		return api.StatusErrorf(http.StatusBadRequest, "A network cannot be peered with itself")
+	}
+
+	// Check if there is an existing peer using the same name, or whether there is already a peering (in any
+	// state) to the target network.
+	peers, err := n.state.DB.Cluster.GetNetworkPeers(n.ID())
+	if err != nil {
+		return fmt.Errorf("Failed loading network peers: %w", err)
+	}
+
+	for _, existingPeer := range peers {
+		if peer.Name == existingPeer.Name {
+			return api.StatusErrorf(http.StatusConflict, "A peer for that name already exists")
+		}
+
+		if peer.TargetProject == existingPeer.TargetProject && peer.TargetNetwork == existingPeer.TargetNetwork {
+			return api.StatusErrorf(http.StatusConflict, "A peer for that target network already exists")
+		}
+	}
+
+	// Perform general (create and update) validation.
+	err = n.peerValidate(peer.Name, &peer.NetworkPeerPut)
+	if err != nil {
+		return err
+	}
+
+	// Create peer DB record.
+	peerID, mutualExists, err := n.state.DB.Cluster.CreateNetworkPeer(n.ID(), &peer)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = n.state.DB.Cluster.DeleteNetworkPeer(n.ID(), peerID)
+		_ = n.peerRefresh()
+	})
+
+	// Nothing to apply until the target network is peered with this network too.
+	if !mutualExists {
+		revert.Success()
+		return nil
+	}
+
+	err = n.peerRefresh()
+	if err != nil {
+		return err
+	}
+
+	notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+	if err != nil {
+		return err
+	}
+
+	err = notifier(func(client lxd.InstanceServer) error {
+		return client.UseProject(n.project).CreateNetworkPeer(n.name, peer)
+	})
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// PeerDelete deletes a network peering.
+// Peerings apply to all cluster members, so other members are notified to refresh their firewall.
+func (n *bridge) PeerDelete(peerName string, clientType request.ClientType) error {
+	if clientType == request.ClientTypeNotifier {
+		return n.peerRefresh()
+	}
+
+	peerID, peer, err := n.state.DB.Cluster.GetNetworkPeer(n.ID(), peerName)
+	if err != nil {
+		return err
+	}
+
+	isUsed, err := n.peerIsUsed(peer.Name)
+	if err != nil {
+		return err
+	}
+
+	if isUsed {
+		return fmt.Errorf("Cannot delete a Peer that is in use")
+	}
+
+	err = n.state.DB.Cluster.DeleteNetworkPeer(n.ID(), peerID)
+	if err != nil {
+		return err
+	}
+
+	// Only a mutual peering has been applied.
+	if peer.Status != api.NetworkStatusCreated {
+		return nil
+	}
+
+	err = n.peerRefresh()
+	if err != nil {
+		return err
+	}
+
+	notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+	if err != nil {
+		return err
+	}
+
+	return notifier(func(client lxd.InstanceServer) error {
+		return client.UseProject(n.project).DeleteNetworkPeer(n.name, peerName)
+	})
+}
+
+// peerNetworks returns the interfaces and subnets (including routes) of the bridge networks mutually peered with
+// the network.
+func (n *bridge) peerNetworks() ([]string, []*net.IPNet, error) {
+	peers, err := n.state.DB.Cluster.GetNetworkPeers(n.ID())
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed loading network peers: %w", err)
+	}
+
+	interfaces := []string{}
+	subnets := []*net.IPNet{}
+	for _, peer := range peers {
+		if peer.Status != api.NetworkStatusCreated {
+			continue
+		}
+
+		targetNet, err := LoadByName(n.state, peer.TargetProject, peer.TargetNetwork)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed loading peer network %q: %w", peer.TargetNetwork, err)
+		}
+
+		// Peerings with other types of networks are never applied.
+		if targetNet.Type() != n.Type() {
+			continue
+		}
+
+		interfaces = append(interfaces, targetNet.Name())
+
+		targetConfig := targetNet.Config()
+		cidrs := append(util.BridgeAddresses(targetConfig["ipv4.address"]), util.BridgeAddresses(targetConfig["ipv6.address"])...)
+		cidrs = append(cidrs, shared.SplitNTrimSpace(targetConfig["ipv4.routes"], ",", -1, true)...)
+		cidrs = append(cidrs, shared.SplitNTrimSpace(targetConfig["ipv6.routes"], ",", -1, true)...)
+
+		for _, cidr := range cidrs {
+			_, subnet, err := net.ParseCIDR(cidr)
 			if err != nil {
-				return err
+				return nil, nil, fmt.Errorf("Failed parsing subnet %q of peer network %q: %w", cidr, peer.TargetNetwork, err)
 			}
 
-			// Add local leases from other members, filtering them for MACs that belong to the project.
-			for _, lease := range memberLeases {
-				if lease.Hwaddr != "" && shared.StringInSlice(lease.Hwaddr, projectMacs) {
-					leases = append(leases, lease)
+			subnets = append(subnets, subnet)
+		}
+	}
+
+	return interfaces, subnets, nil
+}
+
+// peerRefresh reapplies the local configuration of the network and of the networks related to it by a peering.
+func (n *bridge) peerRefresh() error {
+	if n.isRunning() {
+		err := n.setup(n.config)
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.peerNetworksRefresh()
+}
+
+// peerNetworksRefresh reapplies the local configuration of the bridge networks peered with the network.
+// As a removed peering can't be traced back to its target network anymore, the networks with a peering which
+// isn't mutual are refreshed too.
+func (n *bridge) peerNetworksRefresh() error {
+	var projectNetworks map[string]map[int64]api.Network
+	err := n.state.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		var err error
+		projectNetworks, err = tx.GetCreatedNetworks()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for projectName, networks := range projectNetworks {
+		for networkID, network := range networks {
+			if network.Type != n.Type() || networkID == n.ID() {
+				continue
+			}
+
+			peers, err := n.state.DB.Cluster.GetNetworkPeers(networkID)
+			if err != nil {
+				return fmt.Errorf("Failed loading peers of network %q: %w", network.Name, err)
+			}
+
+			related := false
+			for _, peer := range peers {
+				if peer.Status != api.NetworkStatusCreated || (peer.TargetProject == n.Project() && peer.TargetNetwork == n.Name()) {
+					related = true
+					break
 				}
 			}
 
-			return nil
-		})
-		if err != nil {
-			return nil, err
+			if !related {
+				continue
+			}
+
+			peerNet, err := LoadByName(n.state, projectName, network.Name)
+			if err != nil {
+				return fmt.Errorf("Failed loading network %q: %w", network.Name, err)
+			}
+
+			peerBridge, ok := peerNet.(*bridge)
+			if !ok || !peerBridge.isRunning() {
+				continue
+			}
+
+			err = peerBridge.setup(peerBridge.config)
+			if err != nil {
+				return fmt.Errorf("Failed refreshing network %q: %w", network.Name, err)
+			}
 		}
 	}
 
-	return leases, nil
+	return nil
 }
 
 // UsesDNSMasq indicates if network's config indicates if it needs to use dnsmasq.
@@ -0,0 +1,469 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lxc/lxd/lxd/ip"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// bridgeIPsecKeyingPort is the UDP port used to exchange the nonces the tunnel encryption keys are derived from.
+const bridgeIPsecKeyingPort = 8475
+
+// bridgeIPsecRetransmitInterval is the interval at which the nonces are sent to the remotes that aren't keyed yet.
+const bridgeIPsecRetransmitInterval = 10 * time.Second
+
+// bridgeIPsecRekeyInterval is the interval at which new nonces, and so new keys, are used.
+const bridgeIPsecRekeyInterval = time.Hour
+
+// bridgeIPsecNonceSize is the size of the nonces.
+const bridgeIPsecNonceSize = 16
+
+// bridgeIPsecHelloMagic starts every key exchange message.
+var bridgeIPsecHelloMagic = []byte("LXDIPSEC")
+
+// bridgeIPsecHelloReady is the flag set in key exchange messages once the sender accepts traffic encrypted with
+// the keys derived from the nonces of the message.
+const bridgeIPsecHelloReady byte = 1
+
+// ipsecHello represents a key exchange message. It carries the nonce of the sender and the last nonce it received
+// from the recipient. As nonces are random and only used until the next rekeying, a message echoing the current
+// nonce of the recipient can't be a replay of an older message.
+type ipsecHello struct {
+	ready     bool
+	nonce     []byte
+	peerNonce []byte
+}
+
+// ipsecHelloMAC returns the authentication code of a key exchange message sent from src to dst.
+func ipsecHelloMAC(psk string, src string, dst string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(psk))
+	_, _ = mac.Write([]byte(fmt.Sprintf("lxd-ipsec hello %s>%s ", src, dst)))
+	_, _ = mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// encode returns the authenticated key exchange message sent from src to dst.
+func (h *ipsecHello) encode(psk string, src string, dst string) []byte {
+	flags := byte(0)
+	if h.ready {
+		flags |= bridgeIPsecHelloReady
+	}
+
+	peerNonce := h.peerNonce
+	if peerNonce == nil {
+		peerNonce = make([]byte, bridgeIPsecNonceSize)
+	}
+
+	body := append([]byte{}, bridgeIPsecHelloMagic...)
+	body = append(body, flags)
+	body = append(body, h.nonce...)
+	body = append(body, peerNonce...)
+
+	return append(body, ipsecHelloMAC(psk, src, dst, body)...)
+}
+
+// ipsecHelloDecode authenticates and decodes a key exchange message received by dst from src.
+func ipsecHelloDecode(psk string, src string, dst string, msg []byte) (*ipsecHello, error) {
+	bodyLen := len(bridgeIPsecHelloMagic) + 1 + 2*bridgeIPsecNonceSize
+	if len(msg) != bodyLen+sha256.Size || !bytes.HasPrefix(msg, bridgeIPsecHelloMagic) {
+		return nil, fmt.Errorf("Invalid key exchange message")
+	}
+
+	if !hmac.Equal(msg[bodyLen:], ipsecHelloMAC(psk, src, dst, msg[:bodyLen])) {
+		return nil, fmt.Errorf("Invalid key exchange message authentication code")
+	}
+
+	offset := len(bridgeIPsecHelloMagic)
+	hello := &ipsecHello{ready: msg[offset]&bridgeIPsecHelloReady != 0}
+	offset++
+
+	hello.nonce = append([]byte{}, msg[offset:offset+bridgeIPsecNonceSize]...)
+	offset += bridgeIPsecNonceSize
+	hello.peerNonce = append([]byte{}, msg[offset:offset+bridgeIPsecNonceSize]...)
+
+	return hello, nil
+}
+
+// ipsecPeer represents the key exchange state with a tunnel remote.
+type ipsecPeer struct {
+	nonce      []byte          // Nonce of the remote, only set once proven fresh.
+	superseded map[string]bool // Nonces of the remote replaced while using the current local nonce.
+	inbound    []*ip.XfrmState // Inbound states, the previous one is kept until the remote has switched.
+	inPair     string          // Nonces the current inbound state is derived from.
+	outbound   *ip.XfrmState
+	outPair    string // Nonces the current outbound state is derived from.
+}
+
+// ipsecKeying exchanges nonces with the remotes of an encrypted tunnel and sets up the security associations
+// derived from them and the pre-shared key. A new local nonce is used each time the tunnel is set up and on every
+// rekeying, so the ESP sequence numbers never restart for a given key and the replay protection stays effective.
+type ipsecKeying struct {
+	mu       sync.Mutex
+	logger   logger.Logger
+	psk      string
+	local    string
+	reqID    uint32
+	nonce    []byte
+	rotated  time.Time
+	peers    map[string]*ipsecPeer
+	listener *ipsecListener
+	cancel   context.CancelFunc
+	stopped  bool
+}
+
+// ipsecListener receives the key exchange messages sent to a local address.
+type ipsecListener struct {
+	conn    *net.UDPConn
+	keyings map[*ipsecKeying]struct{}
+}
+
+var bridgeIPsecKeyings = make(map[string]*ipsecKeying)
+var bridgeIPsecListeners = make(map[string]*ipsecListener)
+
+// bridgeIPsecMu protects bridgeIPsecKeyings and bridgeIPsecListeners.
+var bridgeIPsecMu sync.Mutex
+
+// ipsecKeyingKey returns the key used to store the key exchange state of a tunnel.
+func (n *bridge) ipsecKeyingKey(tunnel string) string {
+	return fmt.Sprintf("%s/%s/%s", n.project, n.name, tunnel)
+}
+
+// ipsecNonce returns a new random nonce.
+func ipsecNonce() ([]byte, error) {
+	nonce := make([]byte, bridgeIPsecNonceSize)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("Failed generating IPsec nonce: %w", err)
+	}
+
+	return nonce, nil
+}
+
+// ipsecKeyingStart starts the key exchange with the remotes of the tunnel, replacing any existing one.
+func (n *bridge) ipsecKeyingStart(tunnel ipsecTunnel) error {
+	localIP := net.ParseIP(tunnel.local)
+	if localIP == nil {
+		return fmt.Errorf("Invalid local address %q", tunnel.local)
+	}
+
+	n.ipsecKeyingStop(tunnel.name)
+
+	nonce, err := ipsecNonce()
+	if err != nil {
+		return err
+	}
+
+	bridgeIPsecMu.Lock()
+	defer bridgeIPsecMu.Unlock()
+
+	local := localIP.String()
+	listener, found := bridgeIPsecListeners[local]
+	if !found {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: localIP, Port: bridgeIPsecKeyingPort})
+		if err != nil {
+			return fmt.Errorf("Failed listening for IPsec key exchange: %w", err)
+		}
+
+		listener = &ipsecListener{conn: conn, keyings: map[*ipsecKeying]struct{}{}}
+		bridgeIPsecListeners[local] = listener
+
+		go ipsecListen(listener)
+	}
+
+	ctx, cancel := context.WithCancel(n.state.ShutdownCtx)
+	k := &ipsecKeying{
+		logger:   n.logger.AddContext(logger.Ctx{"tunnel": tunnel.name}),
+		psk:      tunnel.psk,
+		local:    local,
+		reqID:    n.ipsecReqID(tunnel.name),
+		nonce:    nonce,
+		rotated:  time.Now(),
+		peers:    map[string]*ipsecPeer{},
+		listener: listener,
+		cancel:   cancel,
+	}
+
+	listener.keyings[k] = struct{}{}
+	bridgeIPsecKeyings[n.ipsecKeyingKey(tunnel.name)] = k
+
+	k.setRemotes(tunnel.remotes)
+
+	go k.run(ctx)
+
+	return nil
+}
+
+// ipsecKeyingStop stops the key exchange of the tunnel. Its security associations are left in place.
+func (n *bridge) ipsecKeyingStop(tunnel string) {
+	bridgeIPsecMu.Lock()
+	defer bridgeIPsecMu.Unlock()
+
+	key := n.ipsecKeyingKey(tunnel)
+	k, found := bridgeIPsecKeyings[key]
+	if !found {
+		return
+	}
+
+	delete(bridgeIPsecKeyings, key)
+
+	k.mu.Lock()
+	k.stopped = true
+	k.cancel()
+	k.mu.Unlock()
+
+	delete(k.listener.keyings, k)
+	if len(k.listener.keyings) == 0 {
+		_ = k.listener.conn.Close()
+		delete(bridgeIPsecListeners, k.local)
+	}
+}
+
+// ipsecKeyingSetRemotes updates the remotes the tunnel exchanges nonces with.
+func (n *bridge) ipsecKeyingSetRemotes(tunnel string, remotes []string) {
+	bridgeIPsecMu.Lock()
+	k, found := bridgeIPsecKeyings[n.ipsecKeyingKey(tunnel)]
+	bridgeIPsecMu.Unlock()
+
+	if found {
+		k.mu.Lock()
+		k.setRemotes(remotes)
+		k.mu.Unlock()
+	}
+}
+
+// ipsecListen dispatches the key exchange messages received by the listener until its socket is closed.
+func ipsecListen(listener *ipsecListener) {
+	buf := make([]byte, 512)
+
+	for {
+		size, addr, err := listener.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			continue
+		}
+
+		bridgeIPsecMu.Lock()
+		keyings := make([]*ipsecKeying, 0, len(listener.keyings))
+		for k := range listener.keyings {
+			keyings = append(keyings, k)
+		}
+
+		bridgeIPsecMu.Unlock()
+
+		// Several tunnels may share the same endpoints, the message is for the one whose key authenticates it.
+		for _, k := range keyings {
+			if k.handle(addr.IP.String(), buf[:size]) {
+				break
+			}
+		}
+	}
+}
+
+// run periodically rekeys and retransmits the nonces until the context is cancelled.
+func (k *ipsecKeying) run(ctx context.Context) {
+	ticker := time.NewTicker(bridgeIPsecRetransmitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		k.mu.Lock()
+		if !k.stopped {
+			if time.Since(k.rotated) >= bridgeIPsecRekeyInterval {
+				k.rotate()
+			}
+
+			for remote, peer := range k.peers {
+				if peer.nonce == nil || peer.outPair != k.pair(k.nonce, peer.nonce) {
+					k.send(remote, peer.nonce, peer.inPair != "")
+				}
+			}
+		}
+
+		k.mu.Unlock()
+	}
+}
+
+// pair returns the identifier of the states derived from the specified nonces.
+func (k *ipsecKeying) pair(srcNonce []byte, dstNonce []byte) string {
+	return fmt.Sprintf("%x/%x", srcNonce, dstNonce)
+}
+
+// setRemotes adds and removes peers to match the list of remotes. Must be called with the keying lock held.
+func (k *ipsecKeying) setRemotes(remotes []string) {
+	wanted := map[string]bool{}
+	for _, remote := range remotes {
+		remoteIP := net.ParseIP(remote)
+		if remoteIP == nil {
+			continue
+		}
+
+		remote = remoteIP.String()
+		wanted[remote] = true
+
+		_, found := k.peers[remote]
+		if !found {
+			k.peers[remote] = &ipsecPeer{superseded: map[string]bool{}}
+			k.send(remote, nil, false)
+		}
+	}
+
+	for remote, peer := range k.peers {
+		if wanted[remote] {
+			continue
+		}
+
+		for _, state := range peer.inbound {
+			_ = state.Delete()
+		}
+
+		if peer.outbound != nil {
+			_ = peer.outbound.Delete()
+		}
+
+		delete(k.peers, remote)
+	}
+}
+
+// rotate switches to a new local nonce. Must be called with the keying lock held.
+func (k *ipsecKeying) rotate() {
+	nonce, err := ipsecNonce()
+	if err != nil {
+		k.logger.Warn("Failed rekeying IPsec tunnel", logger.Ctx{"err": err})
+		return
+	}
+
+	k.nonce = nonce
+	k.rotated = time.Now()
+
+	for remote, peer := range k.peers {
+		peer.superseded = map[string]bool{}
+
+		// The remote nonce was proven fresh, so the inbound state for the new pair can be added right away.
+		if peer.nonce != nil {
+			k.installInbound(remote, peer)
+		}
+
+		k.send(remote, peer.nonce, peer.inPair != "")
+	}
+}
+
+// handle processes a key exchange message received from src. Returns false if the message isn't for this tunnel.
+func (k *ipsecKeying) handle(src string, msg []byte) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	peer, found := k.peers[src]
+	if k.stopped || !found {
+		return false
+	}
+
+	hello, err := ipsecHelloDecode(k.psk, src, k.local, msg)
+	if err != nil {
+		return false
+	}
+
+	// A message not echoing the current local nonce may be replayed. Answer with the current local nonce so that
+	// the remote can prove its nonce is fresh.
+	if !bytes.Equal(hello.peerNonce, k.nonce) {
+		k.send(src, hello.nonce, false)
+		return true
+	}
+
+	// Ignore replays of messages using a nonce the remote has since replaced.
+	if peer.superseded[string(hello.nonce)] {
+		return true
+	}
+
+	if peer.nonce != nil && !bytes.Equal(peer.nonce, hello.nonce) {
+		peer.superseded[string(peer.nonce)] = true
+	}
+
+	peer.nonce = hello.nonce
+
+	changed := false
+	if peer.inPair != k.pair(peer.nonce, k.nonce) {
+		k.installInbound(src, peer)
+		changed = true
+	}
+
+	// Only send traffic with the new keys once the remote is ready to receive it.
+	if hello.ready && peer.inPair != "" && peer.outPair != k.pair(k.nonce, peer.nonce) {
+		k.installOutbound(src, peer)
+		changed = true
+	}
+
+	if changed || !hello.ready {
+		k.send(src, peer.nonce, peer.inPair != "")
+	}
+
+	return true
+}
+
+// installInbound adds the state for the traffic from the remote derived from the current nonces, keeping the
+// previous one until the remote has switched to the new keys. Must be called with the keying lock held.
+func (k *ipsecKeying) installInbound(remote string, peer *ipsecPeer) {
+	spi, encKey, authKey := ipsecKeys(k.psk, remote, k.local, peer.nonce, k.nonce)
+	state := &ip.XfrmState{Src: remote, Dst: k.local, SPI: spi, ReqID: k.reqID, EncKey: encKey, AuthKey: authKey}
+	err := state.Add()
+	if err != nil {
+		k.logger.Warn("Failed adding IPsec state", logger.Ctx{"src": remote, "dst": k.local, "err": err})
+		return
+	}
+
+	if len(peer.inbound) > 1 {
+		_ = peer.inbound[0].Delete()
+		peer.inbound = peer.inbound[1:]
+	}
+
+	peer.inbound = append(peer.inbound, state)
+	peer.inPair = k.pair(peer.nonce, k.nonce)
+}
+
+// installOutbound replaces the state for the traffic to the remote with the one derived from the current nonces.
+// Must be called with the keying lock held.
+func (k *ipsecKeying) installOutbound(remote string, peer *ipsecPeer) {
+	spi, encKey, authKey := ipsecKeys(k.psk, k.local, remote, k.nonce, peer.nonce)
+	state := &ip.XfrmState{Src: k.local, Dst: remote, SPI: spi, ReqID: k.reqID, EncKey: encKey, AuthKey: authKey}
+	err := state.Add()
+	if err != nil {
+		k.logger.Warn("Failed adding IPsec state", logger.Ctx{"src": k.local, "dst": remote, "err": err})
+		return
+	}
+
+	if peer.outbound != nil {
+		_ = peer.outbound.Delete()
+	}
+
+	peer.outbound = state
+	peer.outPair = k.pair(k.nonce, peer.nonce)
+	k.logger.Debug("Keyed IPsec tunnel remote", logger.Ctx{"remote": remote})
+}
+
+// send sends the local nonce to the remote. Must be called with the keying lock held.
+func (k *ipsecKeying) send(remote string, peerNonce []byte, ready bool) {
+	hello := &ipsecHello{ready: ready, nonce: k.nonce, peerNonce: peerNonce}
+	msg := hello.encode(k.psk, k.local, remote)
+
+	_, err := k.listener.conn.WriteToUDP(msg, &net.UDPAddr{IP: net.ParseIP(remote), Port: bridgeIPsecKeyingPort})
+	if err != nil {
+		k.logger.Debug("Failed sending IPsec key exchange message", logger.Ctx{"remote": remote, "err": err})
+	}
+}
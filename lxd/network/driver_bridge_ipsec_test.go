@@ -0,0 +1,62 @@
+package network
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPsecHello(t *testing.T) {
+	psk := "0123456789abcdef"
+	hello := &ipsecHello{ready: true, nonce: bytes.Repeat([]byte{1}, bridgeIPsecNonceSize)}
+
+	msg := hello.encode(psk, "192.0.2.1", "192.0.2.2")
+
+	decoded, err := ipsecHelloDecode(psk, "192.0.2.1", "192.0.2.2", msg)
+	require.NoError(t, err)
+	require.True(t, decoded.ready)
+	require.Equal(t, hello.nonce, decoded.nonce)
+	require.Equal(t, make([]byte, bridgeIPsecNonceSize), decoded.peerNonce)
+
+	// The message is bound to the key and to the addresses of both ends.
+	_, err = ipsecHelloDecode("fedcba9876543210", "192.0.2.1", "192.0.2.2", msg)
+	require.Error(t, err)
+
+	_, err = ipsecHelloDecode(psk, "192.0.2.3", "192.0.2.2", msg)
+	require.Error(t, err)
+
+	// Tampered messages are rejected.
+	msg[len(bridgeIPsecHelloMagic)] = 0
+	_, err = ipsecHelloDecode(psk, "192.0.2.1", "192.0.2.2", msg)
+	require.Error(t, err)
+
+	_, err = ipsecHelloDecode(psk, "192.0.2.1", "192.0.2.2", msg[:10])
+	require.Error(t, err)
+}
+
+func TestIPsecKeys(t *testing.T) {
+	psk := "0123456789abcdef"
+	nonceA := bytes.Repeat([]byte{1}, bridgeIPsecNonceSize)
+	nonceB := bytes.Repeat([]byte{2}, bridgeIPsecNonceSize)
+	nonceC := bytes.Repeat([]byte{3}, bridgeIPsecNonceSize)
+
+	spi, encKey, authKey := ipsecKeys(psk, "192.0.2.1", "192.0.2.2", nonceA, nonceB)
+	require.GreaterOrEqual(t, spi, uint32(256))
+	require.NotEqual(t, encKey, authKey)
+
+	// Both ends derive the same values.
+	spi2, encKey2, authKey2 := ipsecKeys(psk, "192.0.2.1", "192.0.2.2", nonceA, nonceB)
+	require.Equal(t, spi, spi2)
+	require.Equal(t, encKey, encKey2)
+	require.Equal(t, authKey, authKey2)
+
+	// Each direction and each new nonce uses different values.
+	spi3, encKey3, _ := ipsecKeys(psk, "192.0.2.2", "192.0.2.1", nonceB, nonceA)
+	require.NotEqual(t, spi, spi3)
+	require.NotEqual(t, encKey, encKey3)
+
+	spi4, encKey4, _ := ipsecKeys(psk, "192.0.2.1", "192.0.2.2", nonceC, nonceB)
+	require.NotEqual(t, spi, spi4)
+	require.NotEqual(t, encKey, encKey4)
+}
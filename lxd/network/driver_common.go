@@ -7,16 +7,20 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/cluster/request"
 	"github.com/lxc/lxd/lxd/db"
 	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
 	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/resources"
+	"github.com/lxc/lxd/lxd/secrets"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
@@ -24,12 +28,17 @@ import (
 	"github.com/lxc/lxd/shared/version"
 )
 
+// clusterNotifyTimeout is how long to wait for each cluster member to apply a network change.
+const clusterNotifyTimeout = time.Minute
+
 // Info represents information about a network driver.
 type Info struct {
 	Projects           bool // Indicates if driver can be used in network enabled projects.
 	NodeSpecificConfig bool // Whether driver has cluster node specific config as a prerequisite for creation.
 	AddressForwards    bool // Indicates if driver supports address forwards.
+	LoadBalancers      bool // Indicates if driver supports load balancers.
 	Peering            bool // Indicates if the driver supports network peering.
+	DHCPReservations   bool // Indicates if the driver supports DHCP reservations.
 }
 
 // forwardPortMap represents a mapping of listen port(s) to target port(s) for a protocol/target address pair.
@@ -40,6 +49,19 @@ type forwardPortMap struct {
 	protocol      string
 }
 
+// loadBalancerPortMap represents a mapping of listen port(s) to the target backend(s) for a protocol.
+type loadBalancerPortMap struct {
+	listenPorts []uint64
+	protocol    string
+	targets     []loadBalancerTarget
+}
+
+// loadBalancerTarget represents a load balancer backend address and the target port(s) used for the listen ports.
+type loadBalancerTarget struct {
+	address     net.IP
+	targetPorts []uint64
+}
+
 // externalSubnetUsage represents usage of a subnet by a network or NIC.
 type externalSubnetUsage struct {
 	subnet          net.IPNet
@@ -292,7 +314,8 @@ func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clientTy
 	if clientType != request.ClientTypeNotifier {
 		if targetNode == "" {
 			// Notify all other nodes to update the network if no target specified.
-			notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), cluster.NotifyAll)
+			// Offline members are skipped and will apply the new config from the database when they start.
+			notifier, err := cluster.NewResultNotifier(n.state, n.state.Endpoints.NetworkCert(), n.state.ServerCert(), clusterNotifyTimeout)
 			if err != nil {
 				return err
 			}
@@ -308,11 +331,18 @@ func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clientTy
 				sendNetwork.Config[k] = v
 			}
 
-			err = notifier(func(client lxd.InstanceServer) error {
+			results := notifier(func(client lxd.InstanceServer) error {
 				return client.UseProject(n.project).UpdateNetwork(n.name, sendNetwork, "")
 			})
+
+			skipped := results.WithStatus(cluster.NotifySkipped)
+			if len(skipped) > 0 {
+				n.logger.Warn("Skipped updating network on offline cluster members", logger.Ctx{"members": skipped.Names()})
+			}
+
+			err = results.Err()
 			if err != nil {
-				return err
+				return fmt.Errorf("Failed updating network: %w", err)
 			}
 		}
 
@@ -449,6 +479,11 @@ func (n *common) HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error {
 	return nil
 }
 
+// TunnelsHealthCheck is a no-op.
+func (n *common) TunnelsHealthCheck() error {
+	return nil
+}
+
 // notifyDependentNetworks allows any dependent networks to apply changes to themselves when this network changes.
 func (n *common) notifyDependentNetworks(changedKeys []string) {
 	if n.Project() != project.Default {
@@ -624,7 +659,13 @@ func (n *common) bgpSetupPeers(oldConfig map[string]string) error {
 			return err
 		}
 
-		err = n.state.BGP.AddPeer(net.ParseIP(fields[0]), uint32(asn), fields[2])
+		// The password may be a reference to a secret stored in an external secrets backend.
+		password, err := secrets.Resolve(fields[2])
+		if err != nil {
+			return err
+		}
+
+		err = n.state.BGP.AddPeer(net.ParseIP(fields[0]), uint32(asn), password)
 		if err != nil {
 			return err
 		}
@@ -685,17 +726,18 @@ func (n *common) bgpSetupPrefixes(oldConfig map[string]string) error {
 					return err
 				}
 			}
-		} else if !shared.StringInSlice(n.config[fmt.Sprintf("ipv%d.address", ipVersion)], []string{"", "none"}) {
-			// If network has NAT disabled, then export the network's subnet if specified.
-			netAddress := n.config[fmt.Sprintf("ipv%d.address", ipVersion)]
-			_, subnet, err := net.ParseCIDR(netAddress)
-			if err != nil {
-				return fmt.Errorf("Failed parsing network address %q: %w", netAddress, err)
-			}
+		} else {
+			// If network has NAT disabled, then export the network's subnets if specified.
+			for _, netAddress := range util.BridgeAddresses(n.config[fmt.Sprintf("ipv%d.address", ipVersion)]) {
+				_, subnet, err := net.ParseCIDR(netAddress)
+				if err != nil {
+					return fmt.Errorf("Failed parsing network address %q: %w", netAddress, err)
+				}
 
-			err = n.state.BGP.AddPrefix(*subnet, nextHopAddr, bgpOwner)
-			if err != nil {
-				return err
+				err = n.state.BGP.AddPrefix(*subnet, nextHopAddr, bgpOwner)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -747,15 +789,29 @@ func (n *common) forwardValidate(listenAddress net.IP, forward *api.NetworkForwa
 		netIPKey = "ipv6.address"
 	}
 
-	netIPAddress := n.config[netIPKey]
-
-	var err error
-	var netSubnet *net.IPNet
-	if netIPAddress != "" {
-		_, netSubnet, err = net.ParseCIDR(n.config[netIPKey])
+	var netSubnets []*net.IPNet
+	for _, netIPAddress := range util.BridgeAddresses(n.config[netIPKey]) {
+		_, netSubnet, err := net.ParseCIDR(netIPAddress)
 		if err != nil {
 			return nil, err
 		}
+
+		netSubnets = append(netSubnets, netSubnet)
+	}
+
+	// inNetSubnets returns true if the address is within one of the network's subnets (or if it has none).
+	inNetSubnets := func(ip net.IP) bool {
+		if len(netSubnets) == 0 {
+			return true
+		}
+
+		for _, netSubnet := range netSubnets {
+			if SubnetContainsIP(netSubnet, ip) {
+				return true
+			}
+		}
+
+		return false
 	}
 
 	// Look for any unknown config fields.
@@ -786,7 +842,7 @@ func (n *common) forwardValidate(listenAddress net.IP, forward *api.NetworkForwa
 		}
 
 		// Check default target address is within network's subnet.
-		if netSubnet != nil && !SubnetContainsIP(netSubnet, defaultTargetAddress) {
+		if !inNetSubnets(defaultTargetAddress) {
 			return nil, fmt.Errorf("Default target address is not within the network subnet")
 		}
 	}
@@ -823,7 +879,7 @@ func (n *common) forwardValidate(listenAddress net.IP, forward *api.NetworkForwa
 		}
 
 		// Check target address is within network's subnet.
-		if netSubnet != nil && !SubnetContainsIP(netSubnet, targetAddress) {
+		if !inNetSubnets(targetAddress) {
 			return nil, fmt.Errorf("Target address is not within the network subnet in port specification %d", portSpecID)
 		}
 
@@ -891,7 +947,201 @@ func (n *common) forwardValidate(listenAddress net.IP, forward *api.NetworkForwa
 		portMaps = append(portMaps, portMap)
 	}
 
-	return portMaps, err
+	return portMaps, nil
+}
+
+// loadBalancerValidate validates the load balancer request.
+func (n *common) loadBalancerValidate(listenAddress net.IP, loadBalancer *api.NetworkLoadBalancerPut) ([]*loadBalancerPortMap, error) {
+	if listenAddress == nil {
+		return nil, fmt.Errorf("Invalid listen address")
+	}
+
+	listenIsIP4 := listenAddress.To4() != nil
+
+	// For checking target addresses are within network's subnet.
+	netIPKey := "ipv4.address"
+	if !listenIsIP4 {
+		netIPKey = "ipv6.address"
+	}
+
+	var netSubnets []*net.IPNet
+	for _, netIPAddress := range util.BridgeAddresses(n.config[netIPKey]) {
+		_, netSubnet, err := net.ParseCIDR(netIPAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		netSubnets = append(netSubnets, netSubnet)
+	}
+
+	// inNetSubnets returns true if the address is within one of the network's subnets (or if it has none).
+	inNetSubnets := func(ip net.IP) bool {
+		if len(netSubnets) == 0 {
+			return true
+		}
+
+		for _, netSubnet := range netSubnets {
+			if SubnetContainsIP(netSubnet, ip) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// Validate config.
+	rules := map[string]func(value string) error{
+		"policy": validate.Optional(func(value string) error {
+			if value == firewallDrivers.LoadBalancerPolicyLeastConn {
+				return fmt.Errorf("Policy %q isn't supported, as the firewall rules don't track the active connections per backend", value)
+			}
+
+			return validate.IsOneOf(firewallDrivers.LoadBalancerPolicyRoundRobin, firewallDrivers.LoadBalancerPolicyRandom)(value)
+		}),
+	}
+
+	for k, v := range loadBalancer.Config {
+		// User keys are not validated.
+		if shared.IsUserConfig(k) {
+			continue
+		}
+
+		validator, found := rules[k]
+		if !found {
+			return nil, fmt.Errorf("Invalid option %q", k)
+		}
+
+		err := validator(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value for option %q: %w", k, err)
+		}
+	}
+
+	// Validate backends.
+	backendsMap := make(map[string]*loadBalancerTarget, len(loadBalancer.Backends))
+
+	for backendSpecID, backendSpec := range loadBalancer.Backends {
+		if backendSpec.Name == "" {
+			return nil, fmt.Errorf("Missing name in backend specification %d", backendSpecID)
+		}
+
+		_, found := backendsMap[backendSpec.Name]
+		if found {
+			return nil, fmt.Errorf("Duplicate name %q in backend specification %d", backendSpec.Name, backendSpecID)
+		}
+
+		targetAddress := net.ParseIP(backendSpec.TargetAddress)
+		if targetAddress == nil {
+			return nil, fmt.Errorf("Invalid target address in backend specification %d", backendSpecID)
+		}
+
+		targetIsIP4 := targetAddress.To4() != nil
+		if listenIsIP4 != targetIsIP4 {
+			return nil, fmt.Errorf("Cannot mix IP versions in listen address and backend specification %d target address", backendSpecID)
+		}
+
+		// Check target address is within network's subnet.
+		if !inNetSubnets(targetAddress) {
+			return nil, fmt.Errorf("Target address is not within the network subnet in backend specification %d", backendSpecID)
+		}
+
+		target := loadBalancerTarget{
+			address:     targetAddress,
+			targetPorts: make([]uint64, 0),
+		}
+
+		for _, pr := range shared.SplitNTrimSpace(backendSpec.TargetPort, ",", -1, true) {
+			portFirst, portRange, err := ParsePortRange(pr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid target port in backend specification %d", backendSpecID)
+			}
+
+			for i := int64(0); i < portRange; i++ {
+				target.targetPorts = append(target.targetPorts, uint64(portFirst+i))
+			}
+		}
+
+		backendsMap[backendSpec.Name] = &target
+	}
+
+	// Validate port rules.
+	validPortProcols := []string{"tcp", "udp"}
+
+	// Used to ensure that each listen port is only used once.
+	listenPorts := map[string]map[int64]struct{}{
+		"tcp": make(map[int64]struct{}),
+		"udp": make(map[int64]struct{}),
+	}
+
+	portMaps := make([]*loadBalancerPortMap, 0, len(loadBalancer.Ports))
+
+	for portSpecID, portSpec := range loadBalancer.Ports {
+		if !shared.StringInSlice(portSpec.Protocol, validPortProcols) {
+			return nil, fmt.Errorf("Invalid port protocol in port specification %d, protocol must be one of: %s", portSpecID, strings.Join(validPortProcols, ", "))
+		}
+
+		// Check valid listen port(s) supplied.
+		listenPortRanges := shared.SplitNTrimSpace(portSpec.ListenPort, ",", -1, true)
+		if len(listenPortRanges) <= 0 {
+			return nil, fmt.Errorf("Missing listen port in port specification %d", portSpecID)
+		}
+
+		portMap := loadBalancerPortMap{
+			listenPorts: make([]uint64, 0),
+			protocol:    portSpec.Protocol,
+		}
+
+		for _, pr := range listenPortRanges {
+			portFirst, portRange, err := ParsePortRange(pr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid listen port in port specification %d: %w", portSpecID, err)
+			}
+
+			for i := int64(0); i < portRange; i++ {
+				port := portFirst + i
+				if _, found := listenPorts[portSpec.Protocol][port]; found {
+					return nil, fmt.Errorf("Duplicate listen port %d for protocol %q in port specification %d", port, portSpec.Protocol, portSpecID)
+				}
+
+				listenPorts[portSpec.Protocol][port] = struct{}{}
+				portMap.listenPorts = append(portMap.listenPorts, uint64(port))
+			}
+		}
+
+		// Check valid target backend(s) supplied.
+		if len(portSpec.TargetBackend) <= 0 {
+			return nil, fmt.Errorf("Missing target backend in port specification %d", portSpecID)
+		}
+
+		targetBackends := make(map[string]struct{}, len(portSpec.TargetBackend))
+
+		for _, backendName := range portSpec.TargetBackend {
+			target, found := backendsMap[backendName]
+			if !found {
+				return nil, fmt.Errorf("Invalid target backend %q in port specification %d", backendName, portSpecID)
+			}
+
+			_, found = targetBackends[backendName]
+			if found {
+				return nil, fmt.Errorf("Duplicate target backend %q in port specification %d", backendName, portSpecID)
+			}
+
+			targetBackends[backendName] = struct{}{}
+
+			// Only check if the backend's target port count matches the listen port count if the target ports
+			// don't equal 1, because we allow many-to-one type mapping.
+			targetPortsLen := len(target.targetPorts)
+			if targetPortsLen > 1 && len(portMap.listenPorts) != targetPortsLen {
+				return nil, fmt.Errorf("Mismatch of listen port(s) and target backend %q port(s) count in port specification %d", backendName, portSpecID)
+			}
+
+			portMap.targets = append(portMap.targets, *target)
+		}
+
+		portMaps = append(portMaps, &portMap)
+	}
+
+	return portMaps, nil
 }
 
 // ForwardCreate returns ErrNotImplemented for drivers that do not support forwards.
@@ -909,24 +1159,62 @@ func (n *common) ForwardDelete(listenAddress string, clientType request.ClientTy
 	return ErrNotImplemented
 }
 
-// forwardBGPSetupPrefixes exports external forward addresses as prefixes.
+// LoadBalancerCreate returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerUpdate returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerUpdate(listenAddress string, newLoadBalancer api.NetworkLoadBalancerPut, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerDelete returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerDelete(listenAddress string, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// Reservations returns ErrNotImplemented for drivers that do not support DHCP reservations.
+func (n *common) Reservations() ([]api.NetworkReservation, error) {
+	return nil, ErrNotImplemented
+}
+
+// ReservationCreate returns ErrNotImplemented for drivers that do not support DHCP reservations.
+func (n *common) ReservationCreate(reservation api.NetworkReservationsPost, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// ReservationDelete returns ErrNotImplemented for drivers that do not support DHCP reservations.
+func (n *common) ReservationDelete(hwaddr string, clientType request.ClientType) error {
+	return ErrNotImplemented
+}
+
+// forwardBGPSetupPrefixes exports external forward and load balancer addresses as prefixes.
 func (n *common) forwardBGPSetupPrefixes() error {
-	// Retrieve network forwards before clearing existing prefixes, and separate them by IP family.
+	// Retrieve network forwards and load balancers before clearing existing prefixes, and separate them by IP
+	// family.
 	fwdListenAddresses, err := n.state.DB.Cluster.GetNetworkForwardListenAddresses(n.ID(), true)
 	if err != nil {
 		return fmt.Errorf("Failed loading network forwards: %w", err)
 	}
 
+	lbListenAddresses, err := n.state.DB.Cluster.GetNetworkLoadBalancerListenAddresses(n.ID(), true)
+	if err != nil {
+		return fmt.Errorf("Failed loading network load balancers: %w", err)
+	}
+
 	fwdListenAddressesByFamily := map[uint][]string{
 		4: make([]string, 0),
 		6: make([]string, 0),
 	}
 
-	for _, fwdListenAddress := range fwdListenAddresses {
-		if strings.Contains(fwdListenAddress, ":") {
-			fwdListenAddressesByFamily[6] = append(fwdListenAddressesByFamily[6], fwdListenAddress)
-		} else {
-			fwdListenAddressesByFamily[4] = append(fwdListenAddressesByFamily[4], fwdListenAddress)
+	for _, listenAddresses := range []map[int64]string{fwdListenAddresses, lbListenAddresses} {
+		for _, fwdListenAddress := range listenAddresses {
+			if strings.Contains(fwdListenAddress, ":") {
+				fwdListenAddressesByFamily[6] = append(fwdListenAddressesByFamily[6], fwdListenAddress)
+			} else {
+				fwdListenAddressesByFamily[4] = append(fwdListenAddressesByFamily[4], fwdListenAddress)
+			}
 		}
 	}
 
@@ -944,7 +1232,13 @@ func (n *common) forwardBGPSetupPrefixes() error {
 	for _, ipVersion := range []uint{4, 6} {
 		nextHopAddr := n.bgpNextHopAddress(ipVersion)
 		natEnabled := shared.IsTrue(n.config[fmt.Sprintf("ipv%d.nat", ipVersion)])
-		_, netSubnet, _ := net.ParseCIDR(n.config[fmt.Sprintf("ipv%d.address", ipVersion)])
+		netSubnets := []*net.IPNet{}
+		for _, netAddress := range util.BridgeAddresses(n.config[fmt.Sprintf("ipv%d.address", ipVersion)]) {
+			_, netSubnet, _ := net.ParseCIDR(netAddress)
+			if netSubnet != nil {
+				netSubnets = append(netSubnets, netSubnet)
+			}
+		}
 
 		routeSubnetSize := 128
 		if ipVersion == 4 {
@@ -955,8 +1249,16 @@ func (n *common) forwardBGPSetupPrefixes() error {
 		for _, fwdListenAddress := range fwdListenAddressesByFamily[ipVersion] {
 			fwdListenAddr := net.ParseIP(fwdListenAddress)
 
-			// Don't export internal address forwards (those inside the NAT enabled network's subnet).
-			if natEnabled && netSubnet != nil && netSubnet.Contains(fwdListenAddr) {
+			// Don't export internal address forwards (those inside the NAT enabled network's subnets).
+			internal := false
+			for _, netSubnet := range netSubnets {
+				if natEnabled && netSubnet.Contains(fwdListenAddr) {
+					internal = true
+					break
+				}
+			}
+
+			if internal {
 				continue
 			}
 
@@ -981,7 +1283,7 @@ func (n *common) Leases(projectName string, clientType request.ClientType) ([]ap
 }
 
 // PeerCrete returns ErrNotImplemented for drivers that do not support forwards.
-func (n *common) PeerCreate(forward api.NetworkPeersPost) error {
+func (n *common) PeerCreate(forward api.NetworkPeersPost, clientType request.ClientType) error {
 	return ErrNotImplemented
 }
 
@@ -991,7 +1293,7 @@ func (n *common) PeerUpdate(peerName string, newPeer api.NetworkPeerPut) error {
 }
 
 // PeerDelete returns ErrNotImplemented for drivers that do not support forwards.
-func (n *common) PeerDelete(peerName string) error {
+func (n *common) PeerDelete(peerName string, clientType request.ClientType) error {
 	return ErrNotImplemented
 }
 
@@ -874,12 +874,12 @@ func (n *ovn) allocateUplinkPortIPs(uplinkNet Network, routerMAC net.HardwareAdd
 	v.extSwitchProviderName = uplinkNet.Name()
 
 	// Detect uplink gateway setting.
-	uplinkIPv4CIDR := uplinkNetConf["ipv4.address"]
+	uplinkIPv4CIDR := util.BridgePrimaryAddress(uplinkNetConf["ipv4.address"])
 	if uplinkIPv4CIDR == "" {
 		uplinkIPv4CIDR = uplinkNetConf["ipv4.gateway"]
 	}
 
-	uplinkIPv6CIDR := uplinkNetConf["ipv6.address"]
+	uplinkIPv6CIDR := util.BridgePrimaryAddress(uplinkNetConf["ipv6.address"])
 	if uplinkIPv6CIDR == "" {
 		uplinkIPv6CIDR = uplinkNetConf["ipv6.gateway"]
 	}
@@ -4328,7 +4328,7 @@ func (n *ovn) Leases(projectName string, clientType request.ClientType) ([]api.N
 			// Add the leases.
 			for _, ip := range devIPs {
 				leaseType := "dynamic"
-				if dev["ipv4.addres"] == ip.String() || dev["ipv6.address"] == ip.String() {
+				if dev["ipv4.address"] == ip.String() || dev["ipv6.address"] == ip.String() {
 					leaseType = "static"
 				}
 
@@ -4338,6 +4338,8 @@ func (n *ovn) Leases(projectName string, clientType request.ClientType) ([]api.N
 					Hwaddr:   dev["hwaddr"],
 					Type:     leaseType,
 					Location: inst.Location(),
+					Project:  inst.Project(),
+					Instance: inst.Name(),
 				})
 			}
 		}
@@ -4347,7 +4349,7 @@ func (n *ovn) Leases(projectName string, clientType request.ClientType) ([]api.N
 }
 
 // PeerCreate creates a network peering.
-func (n *ovn) PeerCreate(peer api.NetworkPeersPost) error {
+func (n *ovn) PeerCreate(peer api.NetworkPeersPost, clientType request.ClientType) error {
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -4646,7 +4648,7 @@ func (n *ovn) PeerUpdate(peerName string, req api.NetworkPeerPut) error {
 }
 
 // PeerDelete deletes a network peering.
-func (n *ovn) PeerDelete(peerName string) error {
+func (n *ovn) PeerDelete(peerName string, clientType request.ClientType) error {
 	peerID, peer, err := n.state.DB.Cluster.GetNetworkPeer(n.ID(), peerName)
 	if err != nil {
 		return err
@@ -51,6 +51,7 @@ type Network interface {
 	Rename(name string) error
 	Update(newNetwork api.NetworkPut, targetNode string, clientType request.ClientType) error
 	HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error
+	TunnelsHealthCheck() error
 	Delete(clientType request.ClientType) error
 	handleDependencyChange(netName string, netConfig map[string]string, changedKeys []string) error
 
@@ -63,9 +64,19 @@ type Network interface {
 	ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clientType request.ClientType) error
 	ForwardDelete(listenAddress string, clientType request.ClientType) error
 
+	// Load Balancers.
+	LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clientType request.ClientType) error
+	LoadBalancerUpdate(listenAddress string, newLoadBalancer api.NetworkLoadBalancerPut, clientType request.ClientType) error
+	LoadBalancerDelete(listenAddress string, clientType request.ClientType) error
+
+	// DHCP reservations.
+	Reservations() ([]api.NetworkReservation, error)
+	ReservationCreate(reservation api.NetworkReservationsPost, clientType request.ClientType) error
+	ReservationDelete(hwaddr string, clientType request.ClientType) error
+
 	// Peerings.
-	PeerCreate(forward api.NetworkPeersPost) error
+	PeerCreate(forward api.NetworkPeersPost, clientType request.ClientType) error
 	PeerUpdate(peerName string, newPeer api.NetworkPeerPut) error
-	PeerDelete(peerName string) error
+	PeerDelete(peerName string, clientType request.ClientType) error
 	PeerUsedBy(peerName string) ([]string, error)
 }
@@ -0,0 +1,136 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// netplanConfig represents the parts of a netplan definition which map to LXD networks.
+type netplanConfig struct {
+	Network netplanNetwork `yaml:"network"`
+}
+
+type netplanNetwork struct {
+	Version int                      `yaml:"version"`
+	Bridges map[string]netplanBridge `yaml:"bridges,omitempty"`
+}
+
+type netplanBridge struct {
+	Interfaces  []string  `yaml:"interfaces,omitempty"`
+	Addresses   []string  `yaml:"addresses,omitempty"`
+	MTU         uint64    `yaml:"mtu,omitempty"`
+	MACAddress  string    `yaml:"macaddress,omitempty"`
+	OpenVSwitch *struct{} `yaml:"openvswitch,omitempty"`
+}
+
+// NetplanExport renders the configuration of a bridge network as a netplan definition.
+func NetplanExport(name string, netType string, config map[string]string) (string, error) {
+	if netType != "bridge" {
+		return "", fmt.Errorf("Netplan format is only supported for bridge networks")
+	}
+
+	bridge := netplanBridge{
+		Interfaces: shared.SplitNTrimSpace(config["bridge.external_interfaces"], ",", -1, true),
+		MACAddress: config["bridge.hwaddr"],
+	}
+
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		if !shared.StringInSlice(config[key], []string{"", "none", "auto"}) {
+			bridge.Addresses = append(bridge.Addresses, config[key])
+		}
+	}
+
+	if config["bridge.mtu"] != "" {
+		mtu, err := strconv.ParseUint(config["bridge.mtu"], 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("Invalid bridge.mtu %q: %w", config["bridge.mtu"], err)
+		}
+
+		bridge.MTU = mtu
+	}
+
+	if config["bridge.driver"] == "openvswitch" {
+		bridge.OpenVSwitch = &struct{}{}
+	}
+
+	netplan := netplanConfig{
+		Network: netplanNetwork{
+			Version: 2,
+			Bridges: map[string]netplanBridge{name: bridge},
+		},
+	}
+
+	data, err := yaml.Marshal(&netplan)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// NetplanImportConfig returns the bridge network config matching the bridge of the given name in a netplan
+// definition. Only a single address of each family is supported, a missing family gets disabled.
+func NetplanImportConfig(name string, data string) (map[string]string, error) {
+	netplan := netplanConfig{}
+
+	err := yaml.Unmarshal([]byte(data), &netplan)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing netplan definition: %w", err)
+	}
+
+	if netplan.Network.Version != 2 {
+		return nil, fmt.Errorf("Unsupported netplan version %d", netplan.Network.Version)
+	}
+
+	bridge, found := netplan.Network.Bridges[name]
+	if !found {
+		return nil, fmt.Errorf("Bridge %q not found in netplan definition", name)
+	}
+
+	config := map[string]string{
+		"ipv4.address": "none",
+		"ipv6.address": "none",
+	}
+
+	for _, address := range bridge.Addresses {
+		ip, _, err := net.ParseCIDR(address)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid address %q: %w", address, err)
+		}
+
+		key := "ipv6.address"
+		if ip.To4() != nil {
+			key = "ipv4.address"
+		}
+
+		if config[key] != "none" {
+			return nil, fmt.Errorf("Only a single address per family is supported (%q and %q)", config[key], address)
+		}
+
+		config[key] = address
+	}
+
+	if len(bridge.Interfaces) > 0 {
+		config["bridge.external_interfaces"] = strings.Join(bridge.Interfaces, ",")
+	}
+
+	if bridge.MTU > 0 {
+		config["bridge.mtu"] = strconv.FormatUint(bridge.MTU, 10)
+	}
+
+	if bridge.MACAddress != "" {
+		config["bridge.hwaddr"] = bridge.MACAddress
+	}
+
+	if bridge.OpenVSwitch != nil {
+		config["bridge.driver"] = "openvswitch"
+	}
+
+	return config, nil
+}
@@ -0,0 +1,57 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetplanExportImport(t *testing.T) {
+	config := map[string]string{
+		"ipv4.address":               "10.0.0.1/24",
+		"ipv6.address":               "none",
+		"bridge.mtu":                 "1400",
+		"bridge.hwaddr":              "00:16:3e:00:00:01",
+		"bridge.external_interfaces": "eth1,eth2",
+	}
+
+	netplan, err := NetplanExport("lxdbr0", "bridge", config)
+	require.NoError(t, err)
+
+	imported, err := NetplanImportConfig("lxdbr0", netplan)
+	require.NoError(t, err)
+	require.Equal(t, config, imported)
+
+	_, err = NetplanImportConfig("lxdbr1", netplan)
+	require.Error(t, err)
+
+	_, err = NetplanExport("ovn0", "ovn", config)
+	require.Error(t, err)
+}
+
+func TestNetplanImportConfig(t *testing.T) {
+	imported, err := NetplanImportConfig("br0", `
+network:
+  version: 2
+  bridges:
+    br0:
+      openvswitch: {}
+      addresses: ["fd00::1/64"]
+`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"ipv4.address":  "none",
+		"ipv6.address":  "fd00::1/64",
+		"bridge.driver": "openvswitch",
+	}, imported)
+
+	// Only a single address per family is supported.
+	_, err = NetplanImportConfig("br0", `
+network:
+  version: 2
+  bridges:
+    br0:
+      addresses: [10.0.0.1/24, 10.0.1.1/24]
+`)
+	require.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -16,6 +17,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/miekg/dns"
+
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/db/cluster"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
@@ -502,6 +505,39 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 			}
 		}
 
+		// Add the network DHCP reservations.
+		reservations, err := s.DB.Cluster.GetNetworkReservations(n.ID())
+		if err != nil {
+			return fmt.Errorf("Failed loading network reservations: %w", err)
+		}
+
+		for _, reservation := range reservations {
+			// Skip reservations for MACs or addresses that are already used by an instance NIC.
+			duplicate := false
+			for _, entry := range entries {
+				if macAddressEqual(entry[0], reservation.Hwaddr) {
+					logger.Warn("Skipping network reservation for MAC address in use by an instance", logger.Ctx{"network": network, "hwaddr": reservation.Hwaddr, "instance": project.Instance(entry[1], entry[2])})
+					duplicate = true
+					break
+				}
+
+				if ipAddressEqual(entry[3], reservation.IPv4Address) || ipAddressEqual(entry[4], reservation.IPv6Address) {
+					logger.Warn("Skipping network reservation for IP address in use by an instance", logger.Ctx{"network": network, "hwaddr": reservation.Hwaddr, "instance": project.Instance(entry[1], entry[2])})
+					duplicate = true
+					break
+				}
+			}
+
+			if duplicate {
+				continue
+			}
+
+			err = dnsmasq.UpdateReservationEntry(network, reservation.Hwaddr, reservation.IPv4Address, reservation.IPv6Address, reservation.Hostname)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Signal dnsmasq.
 		err = dnsmasq.Kill(network, true)
 		if err != nil {
@@ -535,6 +571,35 @@ func ForkdnsServersList(networkName string) ([]string, error) {
 	return servers, nil
 }
 
+// ForkdnsStats queries the statistics of the forkdns process listening on the specified address.
+func ForkdnsStats(address string) (*api.NetworkStateDNSForkdns, error) {
+	req := dns.Msg{}
+	req.Id = dns.Id()
+	req.Question = []dns.Question{{Name: ForkdnsStatsName, Qtype: dns.TypeTXT, Qclass: dns.ClassCHAOS}}
+
+	resp, err := dns.Exchange(&req, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 1 {
+		return nil, fmt.Errorf("Unexpected stats response (rcode %s)", dns.RcodeToString[resp.Rcode])
+	}
+
+	txt, ok := resp.Answer[0].(*dns.TXT)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected stats record type")
+	}
+
+	stats := api.NetworkStateDNSForkdns{}
+	err = json.Unmarshal([]byte(strings.Join(txt.Txt, "")), &stats)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
 func randomSubnetV4() (string, error) {
 	for i := 0; i < 100; i++ {
 		cidr := fmt.Sprintf("10.%d.%d.1/24", rand.Intn(255), rand.Intn(255))
@@ -1317,3 +1382,62 @@ func BridgeNetfilterEnabled(ipVersion uint) error {
 
 	return nil
 }
+
+// macAddressEqual returns whether two MAC addresses are the same, regardless of their notation.
+func macAddressEqual(a string, b string) bool {
+	macA, err := net.ParseMAC(a)
+	if err != nil {
+		return false
+	}
+
+	macB, err := net.ParseMAC(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(macA, macB)
+}
+
+// ipAddressEqual returns whether two IP addresses are the same, regardless of their notation.
+func ipAddressEqual(a string, b string) bool {
+	ipA := net.ParseIP(a)
+	ipB := net.ParseIP(b)
+
+	return ipA != nil && ipB != nil && ipA.Equal(ipB)
+}
+
+// IsSecretConfigKey returns whether the network config key holds a secret that isn't exposed through the API.
+func IsSecretConfigKey(key string) bool {
+	if key == "fan.psk" {
+		return true
+	}
+
+	fields := strings.Split(key, ".")
+
+	return len(fields) == 3 && fields[0] == "tunnel" && fields[2] == "psk"
+}
+
+// HideSecretConfig returns a copy of the network config with the secret values rendered as "true", like the hidden
+// server config keys.
+func HideSecretConfig(config map[string]string) map[string]string {
+	hidden := make(map[string]string, len(config))
+	for k, v := range config {
+		if IsSecretConfigKey(k) && v != "" {
+			v = "true"
+		}
+
+		hidden[k] = v
+	}
+
+	return hidden
+}
+
+// RestoreSecretConfig replaces the secret values set to "true" in the new network config, meaning "keep it
+// unchanged", with the current values.
+func RestoreSecretConfig(curConfig map[string]string, newConfig map[string]string) {
+	for k, v := range newConfig {
+		if IsSecretConfigKey(k) && v == "true" && curConfig[k] != "" {
+			newConfig[k] = curConfig[k]
+		}
+	}
+}
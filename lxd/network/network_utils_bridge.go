@@ -3,6 +3,7 @@ package network
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"strings"
 
 	"github.com/lxc/lxd/lxd/ip"
@@ -55,6 +56,97 @@ func IsNativeBridge(bridgeName string) bool {
 	return shared.PathExists(fmt.Sprintf("/sys/class/net/%s/bridge", bridgeName))
 }
 
+// BridgeAdoptConfig inspects an existing unmanaged native bridge interface and returns the bridge network config
+// needed to take over its management without disruption. This includes its global IP addresses, MTU, MAC address
+// and attached external interfaces. The host side of instance NICs (veth and tap devices) are not imported.
+func BridgeAdoptConfig(bridgeName string) (map[string]string, error) {
+	iface, err := net.InterfaceByName(bridgeName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading interface %q: %w", bridgeName, err)
+	}
+
+	if !IsNativeBridge(bridgeName) {
+		return nil, fmt.Errorf("Interface %q is not a native bridge", bridgeName)
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting interface addresses for %q: %w", bridgeName, err)
+	}
+
+	var ipv4Addresses, ipv6Addresses []string
+	for _, addr := range addrs {
+		ip, subnet, err := net.ParseCIDR(addr.String())
+		if err != nil || !ip.IsGlobalUnicast() {
+			continue
+		}
+
+		ones, _ := subnet.Mask.Size()
+		address := fmt.Sprintf("%s/%d", ip.String(), ones)
+
+		if ip.To4() != nil {
+			ipv4Addresses = append(ipv4Addresses, address)
+		} else {
+			ipv6Addresses = append(ipv6Addresses, address)
+		}
+	}
+
+	ports, err := ioutil.ReadDir(fmt.Sprintf("/sys/class/net/%s/brif", bridgeName))
+	if err != nil {
+		return nil, fmt.Errorf("Failed getting bridge ports for %q: %w", bridgeName, err)
+	}
+
+	var externalInterfaces []string
+	for _, port := range ports {
+		portName := port.Name()
+		if strings.HasPrefix(portName, "veth") || strings.HasPrefix(portName, "tap") || strings.HasPrefix(portName, fmt.Sprintf("%s-", bridgeName)) {
+			continue
+		}
+
+		externalInterfaces = append(externalInterfaces, portName)
+	}
+
+	config := map[string]string{
+		"ipv4.address": "none",
+		"ipv6.address": "none",
+	}
+
+	if len(ipv4Addresses) > 0 {
+		config["ipv4.address"] = strings.Join(ipv4Addresses, ",")
+	}
+
+	if len(ipv6Addresses) > 0 {
+		config["ipv6.address"] = strings.Join(ipv6Addresses, ",")
+	}
+
+	if iface.MTU != 1500 {
+		config["bridge.mtu"] = fmt.Sprintf("%d", iface.MTU)
+	}
+
+	// Keep VLAN filtering disabled if it is, so that tagged traffic on the existing ports isn't dropped.
+	vlanFiltering, err := BridgeVLANFilteringStatus(bridgeName)
+	if err == nil && vlanFiltering == "0" {
+		config["bridge.vlan_filtering"] = "false"
+	}
+
+	if len(externalInterfaces) > 0 {
+		config["bridge.external_interfaces"] = strings.Join(externalInterfaces, ",")
+
+		// The bridge is connected to an existing network segment that is likely to have its own DHCP
+		// server already, so don't start competing with it.
+		config["ipv4.dhcp"] = "false"
+		config["ipv6.dhcp"] = "false"
+	}
+
+	// Keep the existing MAC address, unless the bridge has external interfaces but no IP addresses as a
+	// static MAC address isn't allowed in that case.
+	if len(externalInterfaces) == 0 || len(ipv4Addresses) > 0 || len(ipv6Addresses) > 0 {
+		config["bridge.hwaddr"] = iface.HardwareAddr.String()
+	}
+
+	return config, nil
+}
+
 // AttachInterface attaches an interface to a bridge.
 func AttachInterface(bridgeName string, devName string) error {
 	if IsNativeBridge(bridgeName) {
@@ -1,9 +1,12 @@
 package openvswitch
 
 import (
+	"encoding/csv"
 	"fmt"
 	"net"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -365,3 +368,120 @@ func (o *OVS) OVNSouthboundDBRemoteAddress() (string, error) {
 
 	return addr, nil
 }
+
+// Version returns the Open vSwitch version reported by the database.
+// Uses a timeout so that it can be used to check whether the database is reachable.
+func (o *OVS) Version() (string, error) {
+	version, err := shared.RunCommand("ovs-vsctl", "--timeout=5", "get", "open_vswitch", ".", "ovs_version")
+	if err != nil {
+		return "", err
+	}
+
+	version, err = unquote(strings.TrimSpace(version))
+	if err != nil {
+		return "", fmt.Errorf("Failed unquoting: %w", err)
+	}
+
+	return version, nil
+}
+
+// BridgeControllerSet sets the OpenFlow controller targets of the bridge (removes them if none specified).
+func (o *OVS) BridgeControllerSet(bridgeName string, targets []string) error {
+	args := []string{"del-controller", bridgeName}
+	if len(targets) > 0 {
+		args = append([]string{"set-controller", bridgeName}, targets...)
+	}
+
+	_, err := shared.RunCommand("ovs-vsctl", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BridgeProtocolsSet sets the OpenFlow versions enabled on the bridge (resets to the default if none specified).
+func (o *OVS) BridgeProtocolsSet(bridgeName string, protocols []string) error {
+	args := []string{"clear", "bridge", bridgeName, "protocols"}
+	if len(protocols) > 0 {
+		args = []string{"set", "bridge", bridgeName, fmt.Sprintf("protocols=%s", strings.Join(protocols, ","))}
+	}
+
+	_, err := shared.RunCommand("ovs-vsctl", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BridgeController represents an OpenFlow controller of a bridge.
+type BridgeController struct {
+	Target    string
+	Connected bool
+	Role      string
+}
+
+// BridgeControllers returns the OpenFlow controllers of the bridge along with their connection status.
+func (o *OVS) BridgeControllers(bridgeName string) ([]BridgeController, error) {
+	// The controller column is a set of controller record UUIDs in the form "[uuid1, uuid2]".
+	uuids, err := shared.RunCommand("ovs-vsctl", "--timeout=5", "get", "bridge", bridgeName, "controller")
+	if err != nil {
+		return nil, err
+	}
+
+	uuids = strings.Trim(strings.TrimSpace(uuids), "[]")
+	if uuids == "" {
+		return []BridgeController{}, nil
+	}
+
+	args := []string{"--timeout=5", "--format=csv", "--no-headings", "--data=bare", "--columns=target,is_connected,role", "list", "controller"}
+	args = append(args, shared.SplitNTrimSpace(uuids, ",", -1, true)...)
+
+	output, err := shared.RunCommand("ovs-vsctl", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing controllers: %w", err)
+	}
+
+	controllers := make([]BridgeController, 0, len(records))
+	for _, record := range records {
+		if len(record) != 3 {
+			continue
+		}
+
+		controllers = append(controllers, BridgeController{
+			Target:    record[0],
+			Connected: record[1] == "true",
+			Role:      record[2],
+		})
+	}
+
+	return controllers, nil
+}
+
+// InterfaceSetExternalIDs sets the specified external IDs on the interface.
+func (o *OVS) InterfaceSetExternalIDs(interfaceName string, externalIDs map[string]string) error {
+	keys := make([]string, 0, len(externalIDs))
+	for key := range externalIDs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	args := []string{"set", "interface", interfaceName}
+	for _, key := range keys {
+		args = append(args, fmt.Sprintf("external_ids:%s=%s", key, strconv.Quote(externalIDs[key])))
+	}
+
+	_, err := shared.RunCommand("ovs-vsctl", args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -5,10 +5,15 @@ import (
 	"net"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/mdlayher/netx/eui64"
 
 	"github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/cluster/request"
+	"github.com/lxc/lxd/lxd/device/nictype"
+	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/response"
 	"github.com/lxc/lxd/lxd/revert"
@@ -288,6 +293,167 @@ func (d *zone) Delete() error {
 	return nil
 }
 
+// zoneInstanceNIC represents the DNS settings of an instance NIC connected to a network of the zone.
+type zoneInstanceNIC struct {
+	inst        instance.Instance
+	names       []string // Names the NIC addresses are registered under (the lease host name if empty).
+	disabled    bool     // Whether the NIC addresses are registered at all.
+	hwaddr      net.HardwareAddr
+	ipv6Address net.IP // Static IPv6 address of the NIC (if any).
+}
+
+// sameSettings returns whether both NICs register their addresses the same way.
+func (nic *zoneInstanceNIC) sameSettings(other *zoneInstanceNIC) bool {
+	return nic.disabled == other.disabled && strings.Join(nic.names, ",") == strings.Join(other.names, ",")
+}
+
+// zoneNICForAddress returns the NIC, among those of an instance connected to the network, that a DHCPv6 lease
+// address belongs to. The address is matched against the static IPv6 address and the EUI64 address of each NIC.
+// If it can't be determined, a NIC is only returned if all of them share the same DNS settings.
+func zoneNICForAddress(nics []*zoneInstanceNIC, address string) *zoneInstanceNIC {
+	if len(nics) == 0 {
+		return nil
+	}
+
+	addr := net.ParseIP(address)
+	if addr != nil {
+		for _, nic := range nics {
+			if nic.ipv6Address != nil && nic.ipv6Address.Equal(addr) {
+				return nic
+			}
+
+			if nic.hwaddr != nil {
+				eui64Addr, err := eui64.ParseMAC(addr.Mask(net.CIDRMask(64, 128)), nic.hwaddr)
+				if err == nil && eui64Addr.Equal(addr) {
+					return nic
+				}
+			}
+		}
+	}
+
+	for _, nic := range nics[1:] {
+		if !nic.sameSettings(nics[0]) {
+			return nil
+		}
+	}
+
+	return nics[0]
+}
+
+// instanceNICs returns the DNS settings of the bridged NICs of the instances connected to the network, indexed by
+// MAC address and by instance name (an instance can have several NICs connected to the network).
+func (d *zone) instanceNICs(instances []instance.Instance, netName string) (map[string]*zoneInstanceNIC, map[string][]*zoneInstanceNIC) {
+	nicsByMAC := map[string]*zoneInstanceNIC{}
+	nicsByName := map[string][]*zoneInstanceNIC{}
+
+	for _, inst := range instances {
+		for devName, dev := range inst.ExpandedDevices() {
+			if dev["type"] != "nic" {
+				continue
+			}
+
+			// Use the parent if the NIC isn't connected through the network setting.
+			devNetwork := dev["network"]
+			if devNetwork == "" {
+				devNetwork = dev["parent"]
+			}
+
+			if devNetwork != netName {
+				continue
+			}
+
+			nicType, err := nictype.NICType(d.state, inst.Project(), dev)
+			if err != nil || nicType != "bridged" {
+				continue
+			}
+
+			nic := &zoneInstanceNIC{inst: inst}
+			if dev["dns.names"] == "none" {
+				nic.disabled = true
+			} else if dev["dns.names"] != "" {
+				nic.names = shared.SplitNTrimSpace(dev["dns.names"], ",", -1, true)
+			}
+
+			nic.ipv6Address = net.ParseIP(dev["ipv6.address"])
+
+			hwaddr := dev["hwaddr"]
+			if hwaddr == "" {
+				hwaddr = inst.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", devName)]
+			}
+
+			if hwaddr != "" {
+				nic.hwaddr, _ = net.ParseMAC(hwaddr)
+				nicsByMAC[strings.ToLower(hwaddr)] = nic
+			}
+
+			nicsByName[inst.Name()] = append(nicsByName[inst.Name()], nic)
+		}
+	}
+
+	return nicsByMAC, nicsByName
+}
+
+// instanceRecords returns the additional CNAME and TXT records from the instance's dns.cname.* and dns.txt.*
+// config keys.
+func (d *zone) instanceRecords(inst instance.Instance) []map[string]string {
+	records := []map[string]string{}
+
+	for k, v := range inst.ExpandedConfig() {
+		fields := strings.SplitN(k, ".", 3)
+		if len(fields) != 3 || fields[0] != "dns" || v == "" {
+			continue
+		}
+
+		record := map[string]string{}
+		record["ttl"] = "300"
+		record["name"] = fields[2]
+
+		switch fields[1] {
+		case "cname":
+			// Targets that aren't fully qualified are relative to the zone.
+			if !strings.HasSuffix(v, ".") {
+				v = v + "." + d.info.Name + "."
+			}
+
+			record["type"] = "CNAME"
+			record["value"] = v
+		case "txt":
+			record["type"] = "TXT"
+			record["value"] = zoneTXTValue(v)
+		default:
+			continue
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// zoneTXTMaxLength is the maximum length of each character string of a TXT record.
+const zoneTXTMaxLength = 255
+
+// zoneTXTValue returns the TXT record value for the text, split into quoted character strings of at most 255 bytes.
+func zoneTXTValue(text string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+	parts := []string{}
+	for len(text) > zoneTXTMaxLength {
+		// Don't split multi-byte characters.
+		end := zoneTXTMaxLength
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+
+		parts = append(parts, `"`+escaper.Replace(text[:end])+`"`)
+		text = text[end:]
+	}
+
+	parts = append(parts, `"`+escaper.Replace(text)+`"`)
+
+	return strings.Join(parts, " ")
+}
+
 // Content returns the DNS zone content.
 func (d *zone) Content() (*strings.Builder, error) {
 	records := []map[string]string{}
@@ -301,6 +467,15 @@ func (d *zone) Content() (*strings.Builder, error) {
 		return nil, err
 	}
 
+	// Load the instances of the project to apply the DNS settings of their NICs.
+	instances, err := instance.LoadByProject(d.state, d.projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep track of the instances registered in the zone to add their additional records.
+	registered := map[string]instance.Instance{}
+
 	for _, netName := range networks {
 		// Load the network.
 		n, err := network.LoadByName(d.state, d.projectName, netName)
@@ -378,21 +553,43 @@ func (d *zone) Content() (*strings.Builder, error) {
 		}
 
 		// Convert leases to usable records.
+		nicsByMAC, nicsByName := d.instanceNICs(instances, netName)
 		for _, lease := range leases {
-			// Get the record.
-			record := genRecord(lease.Hostname, lease.Address)
-			if record == nil {
-				continue
+			names := []string{lease.Hostname}
+
+			// Apply the DNS settings of the instance NIC the lease belongs to.
+			// DHCPv6 leases have no MAC address so are matched on the instance name instead.
+			nic := nicsByMAC[strings.ToLower(lease.Hwaddr)]
+			if nic == nil && lease.Hwaddr == "" {
+				nic = zoneNICForAddress(nicsByName[lease.Hostname], lease.Address)
 			}
 
-			records = append(records, record)
+			if nic != nil {
+				if nic.disabled {
+					continue
+				}
+
+				if len(nic.names) > 0 {
+					names = nic.names
+				}
+
+				registered[nic.inst.Name()] = nic.inst
+			}
+
+			for _, name := range names {
+				// Get the record.
+				record := genRecord(name, lease.Address)
+				if record == nil {
+					continue
+				}
+
+				records = append(records, record)
+			}
 		}
 
 		// Add gateways.
-		for _, addr := range []string{n.Config()["ipv4.address"], n.Config()["ipv6.address"]} {
-			if addr == "" || addr == "none" {
-				continue
-			}
+		addrs := append(util.BridgeAddresses(n.Config()["ipv4.address"]), util.BridgeAddresses(n.Config()["ipv6.address"])...)
+		for _, addr := range addrs {
 
 			// Strip the mask.
 			addr = strings.Split(addr, "/")[0]
@@ -407,6 +604,13 @@ func (d *zone) Content() (*strings.Builder, error) {
 		}
 	}
 
+	// Add the additional records of the registered instances.
+	if !strings.HasSuffix(d.info.Name, ip4Arpa) && !strings.HasSuffix(d.info.Name, ip6Arpa) {
+		for _, inst := range registered {
+			records = append(records, d.instanceRecords(inst)...)
+		}
+	}
+
 	// Add the extra records.
 	extraRecords, err := d.GetRecords()
 	if err != nil {
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	clusterRequest "github.com/lxc/lxd/lxd/cluster/request"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkReservationsCmd = APIEndpoint{
+	Path: "networks/{networkName}/reservations",
+
+	Get:  APIEndpointAction{Handler: networkReservationsGet, AccessHandler: allowProjectPermission("networks", "view")},
+	Post: APIEndpointAction{Handler: networkReservationsPost, AccessHandler: allowProjectPermission("networks", "manage-networks")},
+}
+
+var networkReservationCmd = APIEndpoint{
+	Path: "networks/{networkName}/reservations/{hwaddr}",
+
+	Delete: APIEndpointAction{Handler: networkReservationDelete, AccessHandler: allowProjectPermission("networks", "manage-networks")},
+	Get:    APIEndpointAction{Handler: networkReservationGet, AccessHandler: allowProjectPermission("networks", "view")},
+}
+
+// networkReservationLoad loads the network from the request and checks it supports DHCP reservations.
+func networkReservationLoad(d *Daemon, r *http.Request) (network.Network, error) {
+	projectName, _, err := project.NetworkProject(d.State().DB.Cluster, projectParam(r))
+	if err != nil {
+		return nil, err
+	}
+
+	networkName, err := url.PathUnescape(mux.Vars(r)["networkName"])
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading network: %w", err)
+	}
+
+	if !n.Info().DHCPReservations {
+		return nil, api.StatusErrorf(http.StatusBadRequest, "Network driver %q does not support DHCP reservations", n.Type())
+	}
+
+	return n, nil
+}
+
+// networkReservationHwaddr returns the canonical form of the MAC address from the request.
+func networkReservationHwaddr(r *http.Request) (string, error) {
+	hwaddr, err := url.PathUnescape(mux.Vars(r)["hwaddr"])
+	if err != nil {
+		return "", err
+	}
+
+	mac, err := net.ParseMAC(hwaddr)
+	if err != nil {
+		return "", api.StatusErrorf(http.StatusBadRequest, "Invalid MAC address %q", hwaddr)
+	}
+
+	return mac.String(), nil
+}
+
+// API endpoints
+
+// swagger:operation GET /1.0/networks/{networkName}/reservations network-reservations network_reservations_get
+//
+// Get the network DHCP reservations
+//
+// Returns a list of network DHCP reservations (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/networks/lxdbr0/reservations/00:16:3e:1a:2b:3c",
+//               "/1.0/networks/lxdbr0/reservations/00:16:3e:4d:5e:6f"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/networks/{networkName}/reservations?recursion=1 network-reservations network_reservations_get_recursion1
+//
+// Get the network DHCP reservations
+//
+// Returns a list of network DHCP reservations (structs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of network DHCP reservations
+//           items:
+//             $ref: "#/definitions/NetworkReservation"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkReservationsGet(d *Daemon, r *http.Request) response.Response {
+	n, err := networkReservationLoad(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	reservations, err := n.Reservations()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if util.IsRecursionRequest(r) {
+		return response.SyncResponse(true, reservations)
+	}
+
+	reservationURLs := make([]string, 0, len(reservations))
+	for _, reservation := range reservations {
+		reservationURLs = append(reservationURLs, fmt.Sprintf("/%s/networks/%s/reservations/%s", version.APIVersion, url.PathEscape(n.Name()), url.PathEscape(reservation.Hwaddr)))
+	}
+
+	return response.SyncResponse(true, reservationURLs)
+}
+
+// swagger:operation POST /1.0/networks/{networkName}/reservations network-reservations network_reservations_post
+//
+// Add a network DHCP reservation
+//
+// Creates a new network DHCP reservation.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: reservation
+//     description: Reservation
+//     required: true
+//     schema:
+//       $ref: "#/definitions/NetworkReservationsPost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkReservationsPost(d *Daemon, r *http.Request) response.Response {
+	n, err := networkReservationLoad(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Parse the request into a record.
+	req := api.NetworkReservationsPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	req.Normalise() // So we handle the request in normalised/canonical form.
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.ReservationCreate(req, clientType)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed creating reservation: %w", err))
+	}
+
+	if clientType != clusterRequest.ClientTypeNotifier {
+		d.State().Events.SendLifecycle(n.Project(), lifecycle.NetworkReservationCreated.Event(n, req.Hwaddr, request.CreateRequestor(r), nil))
+	}
+
+	url := fmt.Sprintf("/%s/networks/%s/reservations/%s", version.APIVersion, url.PathEscape(n.Name()), url.PathEscape(req.Hwaddr))
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+// swagger:operation DELETE /1.0/networks/{networkName}/reservations/{hwaddr} network-reservations network_reservation_delete
+//
+// Delete the network DHCP reservation
+//
+// Removes the network DHCP reservation.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkReservationDelete(d *Daemon, r *http.Request) response.Response {
+	n, err := networkReservationLoad(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	hwaddr, err := networkReservationHwaddr(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	clientType := clusterRequest.UserAgentClientType(r.Header.Get("User-Agent"))
+
+	err = n.ReservationDelete(hwaddr, clientType)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed deleting reservation: %w", err))
+	}
+
+	if clientType != clusterRequest.ClientTypeNotifier {
+		d.State().Events.SendLifecycle(n.Project(), lifecycle.NetworkReservationDeleted.Event(n, hwaddr, request.CreateRequestor(r), nil))
+	}
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation GET /1.0/networks/{networkName}/reservations/{hwaddr} network-reservations network_reservation_get
+//
+// Get the network DHCP reservation
+//
+// Gets a specific network DHCP reservation.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: DHCP reservation
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/NetworkReservation"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkReservationGet(d *Daemon, r *http.Request) response.Response {
+	n, err := networkReservationLoad(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	hwaddr, err := networkReservationHwaddr(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, reservation, err := d.State().DB.Cluster.GetNetworkReservation(n.ID(), hwaddr)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, reservation, reservation.Etag())
+}
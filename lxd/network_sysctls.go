@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+var networkSysctlsCmd = APIEndpoint{
+	Path: "network-sysctls",
+
+	Get: APIEndpointAction{Handler: networkSysctlsGet},
+}
+
+// API endpoints
+
+// swagger:operation GET /1.0/network-sysctls network-sysctls network_sysctls_get
+//
+// Get the sysctls changed by LXD
+//
+// Returns the host sysctls that LXD changed on this cluster member since it booted, along with their
+// previous and current values. Sysctls of interfaces that don't exist anymore aren't listed.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of sysctls
+//           items:
+//             $ref: "#/definitions/NetworkSysctl"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkSysctlsGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	changes := util.SysctlChanges()
+
+	sysctls := make([]api.NetworkSysctl, 0, len(changes))
+	for _, change := range changes {
+		sysctl := networkSysctlFromChange(change)
+		sysctl.Location = d.State().ServerName
+
+		sysctls = append(sysctls, sysctl)
+	}
+
+	return response.SyncResponse(true, sysctls)
+}
+
+// networkSysctlFromChange converts a sysctl change into its API representation, using the key notation of
+// sysctl(8) and extracting the interface from per-interface sysctl paths.
+func networkSysctlFromChange(change util.SysctlChange) api.NetworkSysctl {
+	// Swap the separators so that dots in interface names (e.g. VLAN interfaces) are preserved.
+	key := strings.Map(func(r rune) rune {
+		switch r {
+		case '/':
+			return '.'
+		case '.':
+			return '/'
+		}
+
+		return r
+	}, change.Path)
+
+	// Per-interface sysctls are of the form net/<family>/<conf|neigh>/<interface>/<name>.
+	var iface string
+	fields := strings.Split(change.Path, "/")
+	if len(fields) == 5 && fields[0] == "net" && shared.StringInSlice(fields[2], []string{"conf", "neigh"}) && !shared.StringInSlice(fields[3], []string{"all", "default"}) {
+		iface = fields[3]
+	}
+
+	currentValue, _ := util.SysctlGet(change.Path)
+
+	return api.NetworkSysctl{
+		Key:           key,
+		Interface:     iface,
+		Value:         change.Value,
+		PreviousValue: change.PreviousValue,
+		CurrentValue:  strings.TrimSpace(currentValue),
+	}
+}
@@ -71,6 +71,12 @@ var networkStateCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: networkStateGet, AccessHandler: allowProjectPermission("networks", "view")},
 }
 
+var networkNetplanCmd = APIEndpoint{
+	Path: "networks/{name}/netplan",
+
+	Get: APIEndpointAction{Handler: networkNetplanGet, AccessHandler: allowProjectPermission("networks", "view")},
+}
+
 // API endpoints
 
 // swagger:operation GET /1.0/networks networks networks_get
@@ -313,6 +319,29 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Network type does not support non-default projects"))
 	}
 
+	if req.Adopt && netType.Type() != "bridge" {
+		return response.BadRequest(fmt.Errorf("Network type %q does not support adopting existing interfaces", netType.Type()))
+	}
+
+	// Import the settings of the netplan definition, letting any config in the request take precedence.
+	if req.Netplan != "" {
+		if netType.Type() != "bridge" {
+			return response.BadRequest(fmt.Errorf("Network type %q does not support importing netplan definitions", netType.Type()))
+		}
+
+		netplanConfig, err := network.NetplanImportConfig(req.Name, req.Netplan)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		for k, v := range netplanConfig {
+			_, found := req.Config[k]
+			if !found {
+				req.Config[k] = v
+			}
+		}
+	}
+
 	// Check if project has limits.network and if so check we are allowed to create another network.
 	if projectName != project.Default && projectConfig != nil && projectConfig["limits.networks"] != "" {
 		networksLimit, err := strconv.Atoi(projectConfig["limits.networks"])
@@ -333,6 +362,23 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	// Check if project has limits.networks.subnets and if so check the subnets of the new network fit in it.
+	if projectName != project.Default && projectConfig != nil && projectConfig["limits.networks.subnets"] != "" && !isClusterNotification(r) {
+		// Populate default config on a copy so that automatically allocated subnets are accounted for.
+		subnetsConfig := util.CopyConfig(req.Config)
+		err = netType.FillConfig(subnetsConfig)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		err = d.db.Cluster.Transaction(r.Context(), func(ctx context.Context, tx *db.ClusterTx) error {
+			return project.AllowNetworkSubnets(tx, projectName, req.Name, subnetsConfig)
+		})
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	url := fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name)
 	resp := response.SyncResponseLocation(true, nil, url)
 
@@ -355,6 +401,10 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 
 	targetNode := queryParam(r, "target")
 	if targetNode != "" {
+		if req.Adopt {
+			return response.BadRequest(fmt.Errorf("Adopting existing interfaces is not supported when clustered"))
+		}
+
 		if !netTypeInfo.NodeSpecificConfig {
 			return response.BadRequest(fmt.Errorf("Network type %q does not support node specific config", netType.Type()))
 		}
@@ -392,6 +442,10 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	if req.Adopt && count > 1 {
+		return response.BadRequest(fmt.Errorf("Adopting existing interfaces is not supported when clustered"))
+	}
+
 	// No targetNode was specified and we're clustered or there is an existing partially created single node
 	// network, either way finalize the config in the db and actually create the network on all cluster nodes.
 	if count > 1 || (netInfo != nil && netInfo.Status != api.NetworkStatusCreated) {
@@ -436,6 +490,21 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 	revert := revert.New()
 	defer revert.Fail()
 
+	// Import the existing interface's settings, letting any config in the request take precedence.
+	if req.Adopt {
+		adoptConfig, err := network.BridgeAdoptConfig(req.Name)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		for k, v := range adoptConfig {
+			_, found := req.Config[k]
+			if !found {
+				req.Config[k] = v
+			}
+		}
+	}
+
 	// Populate default config.
 	err = netType.FillConfig(req.Config)
 	if err != nil {
@@ -454,7 +523,12 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	err = doNetworksCreate(d, n, clientType)
+	if req.Adopt {
+		err = doNetworksAdopt(d, n)
+	} else {
+		err = doNetworksCreate(d, n, clientType)
+	}
+
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -650,7 +724,7 @@ func doNetworksCreate(d *Daemon, n network.Network, clientType clusterRequest.Cl
 		// is checked.
 		err := n.Validate(n.Config())
 		if err != nil {
-			return err
+			return api.ErrorWithType(api.ErrorTypeInvalidNetworkConfig, err)
 		}
 	}
 
@@ -689,6 +763,32 @@ func doNetworksCreate(d *Daemon, n network.Network, clientType clusterRequest.Cl
 	return nil
 }
 
+// doNetworksAdopt takes over the management of an existing unmanaged interface. Unlike doNetworksCreate it doesn't
+// run the driver's initial creation step and doesn't remove the interface if starting the network fails.
+func doNetworksAdopt(d *Daemon, n network.Network) error {
+	err := n.Validate(n.Config())
+	if err != nil {
+		return api.ErrorWithType(api.ErrorTypeInvalidNetworkConfig, err)
+	}
+
+	err = n.Start()
+	if err != nil {
+		return err
+	}
+
+	// Mark local as status as networkCreated.
+	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		return tx.NetworkNodeCreated(n.ID())
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Debug("Marked adopted network local status as created", logger.Ctx{"project": n.Project(), "network": n.Name()})
+
+	return nil
+}
+
 // swagger:operation GET /1.0/networks/{name} networks network_get
 //
 // Get the network
@@ -805,7 +905,7 @@ func doNetworkGet(d *Daemon, r *http.Request, allNodes bool, projectName string,
 	if n != nil {
 		apiNet.Managed = true
 		apiNet.Description = n.Description()
-		apiNet.Config = n.Config()
+		apiNet.Config = network.HideSecretConfig(n.Config())
 		apiNet.Type = n.Type()
 
 		// If no member is specified, we omit the node-specific fields.
@@ -1282,10 +1382,23 @@ func doNetworkUpdate(d *Daemon, projectName string, n network.Network, req api.N
 		}
 	}
 
+	// Secret values are hidden from the API, keep the current ones when they are sent back unchanged.
+	network.RestoreSecretConfig(n.Config(), req.Config)
+
 	// Validate the merged configuration.
 	err := n.Validate(req.Config)
 	if err != nil {
-		return response.BadRequest(err)
+		return response.BadRequest(api.ErrorWithType(api.ErrorTypeInvalidNetworkConfig, err))
+	}
+
+	// Check the updated subnets fit within the project's limits.networks.subnets (if any).
+	if targetNode == "" && clientType != clusterRequest.ClientTypeNotifier {
+		err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+			return project.AllowNetworkSubnets(tx, projectName, n.Name(), req.Config)
+		})
+		if err != nil {
+			return response.BadRequest(err)
+		}
 	}
 
 	// Apply the new configuration (will also notify other cluster nodes if needed).
@@ -1712,3 +1825,84 @@ func networkStateGet(d *Daemon, r *http.Request) response.Response {
 
 	return response.SyncResponse(true, state)
 }
+
+// swagger:operation GET /1.0/networks/{name}/netplan networks networks_netplan_get
+//
+// Get the network as netplan
+//
+// Returns the configuration of a managed bridge network as a netplan definition.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: Netplan definition
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: string
+//           description: Netplan definition
+//           example: "network:\n  version: 2\n  bridges:\n    lxdbr0:\n      addresses:\n      - 10.0.0.1/24\n"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func networkNetplanGet(d *Daemon, r *http.Request) response.Response {
+	// If a target was specified, forward the request to the relevant node.
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	projectName, _, err := project.NetworkProject(d.State().DB.Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	netplan, err := network.NetplanExport(n.Name(), n.Type(), n.Config())
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return response.SyncResponse(true, netplan)
+}
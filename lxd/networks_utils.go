@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"time"
+
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/network"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/shared/logger"
 )
 
@@ -52,6 +56,42 @@ func networkUpdateForkdnsServersTask(s *state.State, heartbeatData *cluster.APIH
 	return nil
 }
 
+// networkTunnelsHealthCheckTask runs every 10s and probes the remotes of bridge tunnels that have keepalive enabled.
+// Each tunnel is only probed once its own tunnel.NAME.keepalive interval has elapsed.
+func networkTunnelsHealthCheckTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		// Use project.Default here as bridge networks don't support projects.
+		projectName := project.Default
+
+		networks, err := s.DB.Cluster.GetCreatedNetworks(projectName)
+		if err != nil {
+			logger.Error("Failed to get networks for tunnel health check", logger.Ctx{"err": err})
+			return
+		}
+
+		for _, name := range networks {
+			n, err := network.LoadByName(s, projectName, name)
+			if err != nil {
+				logger.Errorf("Failed to load network %q from project %q for tunnel health check", name, projectName)
+				continue
+			}
+
+			if n.Type() != "bridge" {
+				continue
+			}
+
+			err = n.TunnelsHealthCheck()
+			if err != nil {
+				logger.Error("Failed tunnel health check", logger.Ctx{"network": name, "err": err})
+			}
+		}
+	}
+
+	return f, task.Every(10 * time.Second)
+}
+
 // networkUpdateOVNChassis gets called on heartbeats to check if OVN needs reconfiguring.
 func networkUpdateOVNChassis(s *state.State, heartbeatData *cluster.APIHeartbeat, localAddress string) error {
 	// Check if we have at least one active OVN chassis.
@@ -97,6 +97,12 @@ func (c *Config) MAASMachine() string {
 	return c.m.GetString("maas.machine")
 }
 
+// SecretsVault returns the address of the Vault server used to resolve secret references, along with the token
+// and the CA certificate to use to connect to it.
+func (c *Config) SecretsVault() (string, string, string) {
+	return c.m.GetString("secrets.vault.address"), c.m.GetString("secrets.vault.token"), c.m.GetString("secrets.vault.ca_cert")
+}
+
 // StorageBackupsVolume returns the name of the pool/volume to use for storing backup tarballs
 func (c *Config) StorageBackupsVolume() string {
 	return c.m.GetString("storage.backups_volume")
@@ -280,6 +286,11 @@ var ConfigSchema = config.Schema{
 	// MAAS machine this LXD instance is associated with
 	"maas.machine": {},
 
+	// Vault server used to resolve secret references
+	"secrets.vault.address": {},
+	"secrets.vault.ca_cert": {},
+	"secrets.vault.token":   {Hidden: true},
+
 	// Storage volumes to store backups/images on
 	"storage.backups_volume": {},
 	"storage.images_volume":  {},
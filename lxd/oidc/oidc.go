@@ -0,0 +1,341 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// keysRefreshInterval is the minimum time between two fetches of the provider keys.
+// This prevents clients sending tokens with unknown key IDs from hammering the provider.
+const keysRefreshInterval = time.Minute
+
+// clockSkew is the leeway allowed when checking the token validity period.
+const clockSkew = time.Minute
+
+// signatureAlgorithms are the accepted token signature algorithms.
+// Only asymmetric algorithms are supported as the provider keys are public.
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// Identity represents the user authenticated by a token.
+type Identity struct {
+	Username string
+	Groups   []string
+}
+
+// Verifier validates the bearer tokens issued by an OpenID Connect provider.
+type Verifier struct {
+	issuer      string
+	audience    string
+	claim       string
+	groupsClaim string
+	client      *http.Client
+
+	keys          *jose.JSONWebKeySet
+	keysURL       string
+	keysRefreshed time.Time
+	keysLock      sync.Mutex
+}
+
+// NewVerifier returns a Verifier for tokens issued by the given provider.
+// The audience defaults to the client ID and the username claim to "sub".
+// If groupsClaim is empty, the groups of the user aren't extracted from the tokens.
+func NewVerifier(issuer string, clientID string, audience string, claim string, groupsClaim string, client *http.Client) (*Verifier, error) {
+	if issuer == "" {
+		return nil, fmt.Errorf("No OIDC issuer provided")
+	}
+
+	if audience == "" {
+		audience = clientID
+	}
+
+	if audience == "" {
+		return nil, fmt.Errorf("No OIDC client ID or audience provided")
+	}
+
+	if claim == "" {
+		claim = "sub"
+	}
+
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	return &Verifier{
+		issuer:      strings.TrimSuffix(issuer, "/"),
+		audience:    audience,
+		claim:       claim,
+		groupsClaim: groupsClaim,
+		client:      client,
+	}, nil
+}
+
+// ValidateIssuer checks that the issuer is a valid provider URL.
+func ValidateIssuer(issuer string) error {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return fmt.Errorf("Invalid OIDC issuer %q: %w", issuer, err)
+	}
+
+	if (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("Invalid OIDC issuer %q (must be an HTTP or HTTPS URL)", issuer)
+	}
+
+	return nil
+}
+
+// IsRequest returns whether the request carries a bearer token.
+func IsRequest(r *http.Request) bool {
+	_, ok := bearerToken(r)
+	return ok
+}
+
+// Auth validates the bearer token of the request and returns the identity it was issued for.
+func (v *Verifier) Auth(r *http.Request) (*Identity, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, fmt.Errorf("No bearer token provided")
+	}
+
+	return v.Verify(r.Context(), token)
+}
+
+// Verify validates the token and returns the identity it was issued for.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("Malformed token: %w", err)
+	}
+
+	if len(parsed.Headers) != 1 {
+		return nil, fmt.Errorf("Token must have a single signature")
+	}
+
+	header := parsed.Headers[0]
+	if !algorithmSupported(header.Algorithm) {
+		return nil, fmt.Errorf("Unsupported token algorithm %q", header.Algorithm)
+	}
+
+	key, err := v.key(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the signature and extract the registered claims along with the username and groups claims.
+	claims := jwt.Claims{}
+	extraClaims := map[string]any{}
+	err = parsed.Claims(key.Key, &claims, &extraClaims)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid token: %w", err)
+	}
+
+	if strings.TrimSuffix(claims.Issuer, "/") != v.issuer {
+		return nil, fmt.Errorf("Token issued by unexpected issuer %q", claims.Issuer)
+	}
+
+	if claims.Expiry == nil {
+		return nil, fmt.Errorf("Token is missing the expiry time")
+	}
+
+	err = claims.ValidateWithLeeway(jwt.Expected{Audience: jwt.Audience{v.audience}, Time: time.Now()}, clockSkew)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid token: %w", err)
+	}
+
+	username, ok := extraClaims[v.claim].(string)
+	if !ok || username == "" {
+		return nil, fmt.Errorf("Token is missing the %q claim", v.claim)
+	}
+
+	identity := &Identity{Username: username}
+
+	if v.groupsClaim != "" {
+		switch groups := extraClaims[v.groupsClaim].(type) {
+		case string:
+			identity.Groups = []string{groups}
+		case []any:
+			for _, group := range groups {
+				name, ok := group.(string)
+				if ok && name != "" {
+					identity.Groups = append(identity.Groups, name)
+				}
+			}
+		}
+	}
+
+	return identity, nil
+}
+
+// algorithmSupported returns whether the token signature algorithm is accepted.
+func algorithmSupported(alg string) bool {
+	for _, supported := range signatureAlgorithms {
+		if alg == string(supported) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// key returns the provider key with the given ID, fetching the provider keys if needed.
+func (v *Verifier) key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	v.keysLock.Lock()
+	defer v.keysLock.Unlock()
+
+	key, ok := v.lookupKey(kid)
+	if ok {
+		return key, nil
+	}
+
+	// The provider may have rotated its keys, refresh them.
+	if time.Since(v.keysRefreshed) < keysRefreshInterval {
+		return nil, fmt.Errorf("Unknown token signing key %q", kid)
+	}
+
+	err := v.refreshKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok = v.lookupKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("Unknown token signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+// lookupKey returns the cached key with the given ID.
+// An empty ID is accepted when the provider only publishes a single key.
+func (v *Verifier) lookupKey(kid string) (*jose.JSONWebKey, bool) {
+	if v.keys == nil {
+		return nil, false
+	}
+
+	if kid == "" && len(v.keys.Keys) == 1 {
+		return &v.keys.Keys[0], true
+	}
+
+	keys := v.keys.Key(kid)
+	if len(keys) == 0 {
+		return nil, false
+	}
+
+	return &keys[0], true
+}
+
+// refreshKeys fetches the signing keys published by the provider.
+func (v *Verifier) refreshKeys(ctx context.Context) error {
+	v.keysRefreshed = time.Now()
+
+	if v.keysURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+
+		err := v.getJSON(ctx, v.issuer+"/.well-known/openid-configuration", &discovery)
+		if err != nil {
+			return fmt.Errorf("Failed discovering OIDC provider: %w", err)
+		}
+
+		if strings.TrimSuffix(discovery.Issuer, "/") != v.issuer {
+			return fmt.Errorf("OIDC provider issuer %q doesn't match %q", discovery.Issuer, v.issuer)
+		}
+
+		if discovery.JWKSURI == "" {
+			return fmt.Errorf("OIDC provider doesn't publish its signing keys")
+		}
+
+		v.keysURL = discovery.JWKSURI
+	}
+
+	// Decode the keys one by one so that a single unsupported key doesn't prevent using the others.
+	var keySet struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+
+	err := v.getJSON(ctx, v.keysURL, &keySet)
+	if err != nil {
+		return fmt.Errorf("Failed fetching OIDC provider keys: %w", err)
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	for _, data := range keySet.Keys {
+		jwk := jose.JSONWebKey{}
+		err := jwk.UnmarshalJSON(data)
+		if err != nil {
+			logger.Warn("Ignoring invalid OIDC provider key", logger.Ctx{"err": err})
+			continue
+		}
+
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		if !jwk.IsPublic() || !jwk.Valid() {
+			logger.Warn("Ignoring invalid OIDC provider key", logger.Ctx{"kid": jwk.KeyID})
+			continue
+		}
+
+		keys.Keys = append(keys.Keys, jwk)
+	}
+
+	v.keys = keys
+
+	return nil
+}
+
+// getJSON fetches the address and decodes the JSON response into target.
+func (v *Verifier) getJSON(ctx context.Context, address string, target any) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", address, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status code %d from %q", resp.StatusCode, address)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+// bearerToken returns the token from the Authorization header of the request.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(header[7:])
+	if token == "" {
+		return "", false
+	}
+
+	return token, true
+}
@@ -0,0 +1,170 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProvider starts a fake provider publishing the public part of the key.
+func newTestProvider(t *testing.T, key *rsa.PrivateKey) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   server.URL,
+			"jwks_uri": server.URL + "/keys",
+		})
+	})
+
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": "test",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+
+	return server
+}
+
+// newTestToken returns a token with the given claims signed by the key.
+func newTestToken(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	provider := newTestProvider(t, key)
+
+	verifier, err := NewVerifier(provider.URL, "lxd", "", "email", "groups", provider.Client())
+	require.NoError(t, err)
+
+	claims := func(changes map[string]any) map[string]any {
+		result := map[string]any{
+			"iss":    provider.URL,
+			"aud":    []string{"lxd", "other"},
+			"sub":    "1234",
+			"email":  "user@example.com",
+			"groups": []string{"admins", "devs"},
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		}
+
+		for k, v := range changes {
+			if v == nil {
+				delete(result, k)
+				continue
+			}
+
+			result[k] = v
+		}
+
+		return result
+	}
+
+	identity, err := verifier.Verify(context.Background(), newTestToken(t, key, claims(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", identity.Username)
+	assert.Equal(t, []string{"admins", "devs"}, identity.Groups)
+
+	tests := []struct {
+		name  string
+		key   *rsa.PrivateKey
+		token map[string]any
+	}{
+		{"wrong key", otherKey, claims(nil)},
+		{"wrong issuer", key, claims(map[string]any{"iss": "https://example.com"})},
+		{"wrong audience", key, claims(map[string]any{"aud": "other"})},
+		{"expired", key, claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})},
+		{"not valid yet", key, claims(map[string]any{"nbf": time.Now().Add(time.Hour).Unix()})},
+		{"no expiry", key, claims(map[string]any{"exp": nil})},
+		{"no username", key, claims(map[string]any{"email": nil})},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), newTestToken(t, test.key, test.token))
+			assert.Error(t, err)
+		})
+	}
+
+	// Tokens signed with a symmetric algorithm using the public key as the secret are rejected.
+	t.Run("symmetric algorithm", func(t *testing.T) {
+		header, err := json.Marshal(map[string]string{"alg": "HS256", "kid": "test", "typ": "JWT"})
+		require.NoError(t, err)
+
+		payload, err := json.Marshal(claims(nil))
+		require.NoError(t, err)
+
+		signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		mac := hmac.New(sha256.New, key.N.Bytes())
+		_, _ = mac.Write([]byte(signed))
+
+		_, err = verifier.Verify(context.Background(), signed+"."+base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+		assert.Error(t, err)
+	})
+}
+
+func TestVerifier_Auth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	provider := newTestProvider(t, key)
+
+	verifier, err := NewVerifier(provider.URL, "lxd", "", "", "", provider.Client())
+	require.NoError(t, err)
+
+	token := newTestToken(t, key, map[string]any{
+		"iss": provider.URL,
+		"aud": "lxd",
+		"sub": "1234",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	r := httptest.NewRequest("GET", "/1.0", nil)
+	assert.False(t, IsRequest(r))
+
+	r.Header.Set("Authorization", "Bearer "+token)
+	assert.True(t, IsRequest(r))
+
+	identity, err := verifier.Auth(r)
+	require.NoError(t, err)
+	assert.Equal(t, "1234", identity.Username)
+	assert.Nil(t, identity.Groups)
+}
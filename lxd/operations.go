@@ -13,6 +13,7 @@ import (
 
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
 	"github.com/lxc/lxd/lxd/lifecycle"
 	"github.com/lxc/lxd/lxd/node"
 	"github.com/lxc/lxd/lxd/operations"
@@ -136,6 +137,67 @@ func waitForOperations(ctx context.Context, cluster *db.Cluster, consoleShutdown
 
 // API functions
 
+// operationAccessible returns whether the requestor is allowed to see an operation of the given project.
+// Operations which aren't tied to a project are only visible to administrators.
+func operationAccessible(r *http.Request, projectName string) bool {
+	if rbac.UserIsAdmin(r) {
+		return true
+	}
+
+	if projectName == "" {
+		return false
+	}
+
+	return rbac.UserHasPermission(r, projectName, "view")
+}
+
+// operationRemoteAddress returns the address of the cluster member running the operation.
+// If checkAccess is true, the operation is reported as not found if the requestor isn't allowed to see it.
+func operationRemoteAddress(d *Daemon, r *http.Request, id string, checkAccess bool) (string, error) {
+	var address string
+	err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		filter := db.OperationFilter{UUID: &id}
+		ops, err := tx.GetOperations(filter)
+		if err != nil {
+			return err
+		}
+
+		if len(ops) < 1 {
+			return api.StatusErrorf(http.StatusNotFound, "Operation not found")
+		}
+
+		if len(ops) > 1 {
+			return fmt.Errorf("More than one operation matches")
+		}
+
+		operation := ops[0]
+
+		if checkAccess {
+			projectName := ""
+			if operation.ProjectID != nil {
+				projects, err := dbCluster.GetProjectIDsToNames(ctx, tx.Tx())
+				if err != nil {
+					return err
+				}
+
+				projectName = projects[*operation.ProjectID]
+			}
+
+			if !operationAccessible(r, projectName) {
+				return api.StatusErrorf(http.StatusNotFound, "Operation not found")
+			}
+		}
+
+		address = operation.NodeAddress
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return address, nil
+}
+
 // swagger:operation GET /1.0/operations/{id} operations operation_get
 //
 // Get the operation state
@@ -181,6 +243,10 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 	// First check if the query is for a local operation from this node
 	op, err := operations.OperationGetInternal(id)
 	if err == nil {
+		if !operationAccessible(r, op.Project()) {
+			return response.NotFound(fmt.Errorf("Operation not found"))
+		}
+
 		_, body, err = op.Render()
 		if err != nil {
 			return response.SmartError(err)
@@ -190,25 +256,7 @@ func operationGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
-	var address string
-	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		filter := db.OperationFilter{UUID: &id}
-		ops, err := tx.GetOperations(filter)
-		if err != nil {
-			return err
-		}
-		if len(ops) < 1 {
-			return api.StatusErrorf(http.StatusNotFound, "Operation not found")
-		}
-		if len(ops) > 1 {
-			return fmt.Errorf("More than one operation matches")
-		}
-
-		operation := ops[0]
-
-		address = operation.NodeAddress
-		return nil
-	})
+	address, err := operationRemoteAddress(d, r, id, true)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -248,6 +296,10 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 	// First check if the query is for a local operation from this node
 	op, err := operations.OperationGetInternal(id)
 	if err == nil {
+		if !operationAccessible(r, op.Project()) {
+			return response.NotFound(fmt.Errorf("Operation not found"))
+		}
+
 		projectName := op.Project()
 		if op.Permission() != "" {
 			if projectName == "" {
@@ -270,25 +322,7 @@ func operationDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
-	var address string
-	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		filter := db.OperationFilter{UUID: &id}
-		ops, err := tx.GetOperations(filter)
-		if err != nil {
-			return err
-		}
-		if len(ops) < 1 {
-			return api.StatusErrorf(http.StatusNotFound, "Operation not found")
-		}
-		if len(ops) > 1 {
-			return fmt.Errorf("More than one operation matches")
-		}
-
-		operation := ops[0]
-
-		address = operation.NodeAddress
-		return nil
-	})
+	address, err := operationRemoteAddress(d, r, id, true)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -365,6 +399,16 @@ func operationCancel(d *Daemon, r *http.Request, projectName string, op *api.Ope
 // ---
 // produces:
 //   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: all-projects
+//     description: Retrieve operations from all projects (administrators only)
+//     type: boolean
 // responses:
 //   "200":
 //     description: API endpoints
@@ -411,6 +455,16 @@ func operationCancel(d *Daemon, r *http.Request, projectName string, op *api.Ope
 // ---
 // produces:
 //   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: all-projects
+//     description: Retrieve operations from all projects (administrators only)
+//     type: boolean
 // responses:
 //   "200":
 //     description: API endpoints
@@ -441,7 +495,37 @@ func operationCancel(d *Daemon, r *http.Request, projectName string, op *api.Ope
 //     $ref: "#/responses/InternalServerError"
 func operationsGet(d *Daemon, r *http.Request) response.Response {
 	projectName := projectParam(r)
+	allProjects := shared.IsTrue(queryParam(r, "all-projects"))
 	recursion := util.IsRecursionRequest(r)
+	admin := rbac.UserIsAdmin(r)
+
+	if allProjects && queryParam(r, "project") != "" {
+		return response.BadRequest(fmt.Errorf("Cannot specify a project when requesting operations for all projects"))
+	}
+
+	// Only administrators can see the operations of all projects.
+	if allProjects && !admin {
+		return response.Forbidden(fmt.Errorf("Only administrators can request operations for all projects"))
+	}
+
+	// Restricted clients can only see the operations of the projects they have access to.
+	if !allProjects && !rbac.UserHasPermission(r, projectName, "view") {
+		return response.Forbidden(nil)
+	}
+
+	// operationVisible returns whether an operation of the given project should be listed.
+	// Server wide operations are only listed for administrators.
+	operationVisible := func(opProjectName string) bool {
+		if allProjects {
+			return true
+		}
+
+		if opProjectName == "" {
+			return admin
+		}
+
+		return opProjectName == projectName
+	}
 
 	localOperationURLs := func() (shared.Jmap, error) {
 		// Get all the operations.
@@ -451,7 +535,7 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		body := shared.Jmap{}
 
 		for _, v := range localOps {
-			if v.Project() != "" && v.Project() != projectName {
+			if !operationVisible(v.Project()) {
 				continue
 			}
 
@@ -475,7 +559,7 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		body := shared.Jmap{}
 
 		for _, v := range localOps {
-			if v.Project() != "" && v.Project() != projectName {
+			if !operationVisible(v.Project()) {
 				continue
 			}
 
@@ -552,7 +636,12 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
 		var err error
 
-		membersWithOps, err = tx.GetNodesWithOperations(projectName)
+		if allProjects {
+			membersWithOps, err = tx.GetNodesWithOperationsAllProjects()
+		} else {
+			membersWithOps, err = tx.GetNodesWithOperations(projectName)
+		}
+
 		if err != nil {
 			return fmt.Errorf("Failed getting members with operations: %w", err)
 		}
@@ -611,7 +700,13 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		}
 
 		// Get operation data.
-		ops, err := client.UseProject(projectName).GetOperations()
+		var ops []api.Operation
+		if allProjects {
+			ops, err = client.GetOperationsAllProjects()
+		} else {
+			ops, err = client.UseProject(projectName).GetOperations()
+		}
+
 		if err != nil {
 			logger.Warn("Failed getting operations from member", logger.Ctx{"address": memberAddress, "err": err})
 			continue
@@ -620,6 +715,10 @@ func operationsGet(d *Daemon, r *http.Request) response.Response {
 		// Merge with existing data.
 		for _, o := range ops {
 			op := o // Local var for pointer.
+
+			if !operationVisible(op.Project) {
+				continue
+			}
 			status := strings.ToLower(op.Status)
 
 			_, ok := md[status]
@@ -862,7 +961,7 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 
 	secret := r.FormValue("secret")
 
-	trusted, _, _, _ := d.Authenticate(nil, r)
+	trusted, _, _, _, _ := d.Authenticate(nil, r)
 	if !trusted && secret == "" {
 		return response.Forbidden(nil)
 	}
@@ -879,6 +978,10 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 			return response.Forbidden(nil)
 		}
 
+		if secret == "" && !operationAccessible(r, op.Project()) {
+			return response.NotFound(fmt.Errorf("Operation not found"))
+		}
+
 		var ctx context.Context
 		var cancel context.CancelFunc
 
@@ -904,25 +1007,7 @@ func operationWaitGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Then check if the query is from an operation on another node, and, if so, forward it
-	var address string
-	err = d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
-		filter := db.OperationFilter{UUID: &id}
-		ops, err := tx.GetOperations(filter)
-		if err != nil {
-			return err
-		}
-		if len(ops) < 1 {
-			return api.StatusErrorf(http.StatusNotFound, "Operation not found")
-		}
-		if len(ops) > 1 {
-			return fmt.Errorf("More than one operation matches")
-		}
-
-		operation := ops[0]
-
-		address = operation.NodeAddress
-		return nil
-	})
+	address, err := operationRemoteAddress(d, r, id, secret == "")
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -491,6 +491,7 @@ func (op *Operation) Render() (string, *api.Operation, error) {
 		Metadata:    op.metadata,
 		MayCancel:   op.mayCancel(),
 		Location:    op.state.ServerName,
+		Project:     op.projectName,
 	}
 
 	if op.err != nil {
@@ -461,6 +461,9 @@ func profilePut(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	unlock := profileUpdateLock(projectName, name)
+	defer unlock()
+
 	var id int64
 	var profile *api.Profile
 
@@ -524,6 +527,9 @@ func profilePut(d *Daemon, r *http.Request) response.Response {
 //
 // Updates a subset of the profile configuration.
 //
+// Devices are updated individually: a device passed in the request replaces the existing
+// device of the same name, a device set to null is removed and other devices are kept.
+//
 // ---
 // consumes:
 //   - application/json
@@ -563,6 +569,9 @@ func profilePatch(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	unlock := profileUpdateLock(projectName, name)
+	defer unlock()
+
 	var id int64
 	var profile *api.Profile
 
@@ -632,9 +641,25 @@ func profilePatch(d *Daemon, r *http.Request) response.Response {
 	if req.Devices == nil {
 		req.Devices = profile.Devices
 	} else {
+		// Devices set to null are removed.
+		removedDevices := []string{}
+		for k, v := range req.Devices {
+			if v != nil {
+				continue
+			}
+
+			_, ok := profile.Devices[k]
+			if !ok {
+				return response.BadRequest(fmt.Errorf("Device %q doesn't exist", k))
+			}
+
+			delete(req.Devices, k)
+			removedDevices = append(removedDevices, k)
+		}
+
 		for k, v := range profile.Devices {
 			_, ok := req.Devices[k]
-			if !ok {
+			if !ok && !shared.StringInSlice(k, removedDevices) {
 				req.Devices[k] = v
 			}
 		}
@@ -9,11 +9,18 @@ import (
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/locking"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 )
 
+// profileUpdateLock acquires a lock for updating a profile and returns the unlock function.
+// This serializes updates so that the ETag check of a request and the update it makes are atomic.
+func profileUpdateLock(projectName string, name string) locking.UnlockFunc {
+	return locking.Lock(fmt.Sprintf("ProfileUpdate_%s", project.Instance(projectName, name)))
+}
+
 func doProfileUpdate(d *Daemon, projectName string, name string, id int64, profile *api.Profile, req api.ProfilePut) error {
 	// Check project limits.
 	err := d.db.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
@@ -47,23 +47,32 @@ func AllowInstanceCreation(tx *db.ClusterTx, projectName string, req api.Instanc
 		req.Profiles = []string{"default"}
 	}
 
-	err = checkInstanceCountLimit(info, instanceType)
-	if err != nil {
-		return err
+	// Check the limits for each of the instances being created.
+	count := req.Count
+	if count < 1 {
+		count = 1
 	}
 
-	err = checkTotalInstanceCountLimit(info)
-	if err != nil {
-		return err
-	}
+	for i := 0; i < count; i++ {
+		err = checkInstanceCountLimit(info, instanceType)
+		if err != nil {
+			return err
+		}
 
-	// Add the instance being created.
-	info.Instances = append(info.Instances, db.Instance{
-		Name:     req.Name,
-		Profiles: req.Profiles,
-		Config:   req.Config,
-		Project:  projectName,
-	})
+		err = checkTotalInstanceCountLimit(info)
+		if err != nil {
+			return err
+		}
+
+		// Add the instance being created.
+		info.Instances = append(info.Instances, db.Instance{
+			Name:     req.Name,
+			Type:     instanceType,
+			Profiles: req.Profiles,
+			Config:   req.Config,
+			Project:  projectName,
+		})
+	}
 
 	// Special case restriction checks on volatile.* keys.
 	strip := false
@@ -95,7 +104,7 @@ func checkTotalInstanceCountLimit(info *projectInfo) error {
 	}
 
 	if limit >= 0 && count >= limit {
-		return fmt.Errorf("Reached maximum number of instances in project %q", info.Project.Name)
+		return api.ErrorWithType(api.ErrorTypeProjectLimit, fmt.Errorf("Reached maximum number of instances in project %q", info.Project.Name))
 	}
 
 	return nil
@@ -123,7 +132,7 @@ func checkInstanceCountLimit(info *projectInfo, instanceType instancetype.Type)
 	}
 
 	if limit >= 0 && count >= limit {
-		return fmt.Errorf("Reached maximum number of instances of type %q in project %q", instanceType, info.Project.Name)
+		return api.ErrorWithType(api.ErrorTypeProjectLimit, fmt.Errorf("Reached maximum number of instances of type %q in project %q", instanceType, info.Project.Name))
 	}
 
 	return nil
@@ -405,9 +414,9 @@ func checkAggregateLimits(info *projectInfo, aggregateKeys []string) error {
 		}
 
 		if totals[key] > max {
-			return fmt.Errorf(
+			return api.ErrorWithType(api.ErrorTypeProjectLimit, fmt.Errorf(
 				"Reached maximum aggregate value %s for %q in project %s",
-				info.Project.Config[key], key, info.Project.Name)
+				info.Project.Config[key], key, info.Project.Name))
 		}
 	}
 	return nil
@@ -680,6 +689,13 @@ func checkRestrictions(project api.Project, instances []db.Instance, profiles []
 		}
 
 		for name, device := range devices {
+			// Templated values are rendered per instance after these checks, so they aren't allowed.
+			for key, value := range device {
+				if strings.Contains(value, "{{") || strings.Contains(value, "{%") {
+					return fmt.Errorf("Invalid device %q on %s %q of project %q: Templated value of %q isn't allowed in restricted projects", name, entityTypeLabel, entityName, project.Name, key)
+				}
+			}
+
 			check, ok := devicesChecks[device["type"]]
 			if !ok {
 				continue
@@ -815,6 +831,8 @@ func isVMLowLevelOptionForbidden(key string) bool {
 	if shared.StringInSlice(key, []string{
 		"boot.host_shutdown_timeout",
 		"limits.memory.hugepages",
+		"limits.memory.hugepages.1GB",
+		"limits.memory.hugepages.2MB",
 		"raw.idmap",
 		"raw.qemu",
 	}) {
@@ -975,6 +993,26 @@ func AllowProjectUpdate(tx *db.ClusterTx, projectName string, config map[string]
 			if err != nil {
 				return fmt.Errorf("Can't change %q in project %q: %w", key, projectName, err)
 			}
+		case "limits.networks.subnets":
+			count, err := getNetworkSubnetsUsage(tx, projectName, "")
+			if err != nil {
+				return err
+			}
+
+			err = validateNetworkCountLimit(count, key, config[key], "subnets", projectName)
+			if err != nil {
+				return fmt.Errorf("Can't change %q in project %q: %w", key, projectName, err)
+			}
+		case "limits.networks.forwards":
+			count, err := tx.GetProjectNetworkForwardsCount(projectName)
+			if err != nil {
+				return err
+			}
+
+			err = validateNetworkCountLimit(count, key, config[key], "network forwards", projectName)
+			if err != nil {
+				return fmt.Errorf("Can't change %q in project %q: %w", key, projectName, err)
+			}
 		case "limits.processes":
 			fallthrough
 		case "limits.cpu":
@@ -1058,6 +1096,24 @@ func validateInstanceCountLimit(instances []db.Instance, key, value, project str
 	return nil
 }
 
+// Check that limits.networks.subnets or limits.networks.forwards is equal or above the current count.
+func validateNetworkCountLimit(count int, key, value, entity, project string) error {
+	if value == "" {
+		return nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	if limit < count {
+		return fmt.Errorf("'%s' is too low: there currently are %d %s in project %s", key, count, entity, project)
+	}
+
+	return nil
+}
+
 var countConfigInstanceType = map[string]api.InstanceType{
 	"limits.containers":       api.InstanceTypeContainer,
 	"limits.virtual-machines": api.InstanceTypeVM,
@@ -1484,3 +1540,113 @@ func AllowSnapshotCreation(tx *db.ClusterTx, dbProject *cluster.Project) error {
 	}
 	return nil
 }
+
+// NetworkSubnetsCount returns the number of subnets a network config allocates via its ipv4.address and
+// ipv6.address keys.
+func NetworkSubnetsCount(config map[string]string) int {
+	count := 0
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		if !shared.StringInSlice(config[key], []string{"", "none"}) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// getNetworkSubnetsUsage returns the number of subnets allocated by the networks of a project, excluding the
+// network with the given name (if any).
+func getNetworkSubnetsUsage(tx *db.ClusterTx, projectName string, excludeNetworkName string) (int, error) {
+	networks, err := tx.GetCreatedNetworksByProject(projectName)
+	if err != nil {
+		return -1, err
+	}
+
+	count := 0
+	for _, network := range networks {
+		if network.Name == excludeNetworkName {
+			continue
+		}
+
+		count += NetworkSubnetsCount(network.Config)
+	}
+
+	return count, nil
+}
+
+// getProjectConfig returns the config of the project with the given name.
+func getProjectConfig(tx *db.ClusterTx, projectName string) (map[string]string, error) {
+	ctx := context.Background()
+	dbProject, err := cluster.GetProject(ctx, tx.Tx(), projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return cluster.GetProjectConfig(ctx, tx.Tx(), dbProject.ID)
+}
+
+// AllowNetworkSubnets returns an error if setting the given config on a network would make the project exceed
+// its limits.networks.subnets limit.
+func AllowNetworkSubnets(tx *db.ClusterTx, projectName string, networkName string, config map[string]string) error {
+	if projectName == Default {
+		return nil
+	}
+
+	projectConfig, err := getProjectConfig(tx, projectName)
+	if err != nil {
+		return err
+	}
+
+	if projectConfig["limits.networks.subnets"] == "" {
+		return nil
+	}
+
+	limit, err := strconv.Atoi(projectConfig["limits.networks.subnets"])
+	if err != nil {
+		return fmt.Errorf("Invalid project limits.networks.subnets value: %w", err)
+	}
+
+	count, err := getNetworkSubnetsUsage(tx, projectName, networkName)
+	if err != nil {
+		return fmt.Errorf("Failed loading project's networks for limits check: %w", err)
+	}
+
+	if count+NetworkSubnetsCount(config) > limit {
+		return fmt.Errorf("Network subnets limit has been reached for project")
+	}
+
+	return nil
+}
+
+// AllowNetworkForwardCreation returns an error if creating another network forward would make the project
+// exceed its limits.networks.forwards limit.
+func AllowNetworkForwardCreation(tx *db.ClusterTx, projectName string) error {
+	if projectName == Default {
+		return nil
+	}
+
+	projectConfig, err := getProjectConfig(tx, projectName)
+	if err != nil {
+		return err
+	}
+
+	if projectConfig["limits.networks.forwards"] == "" {
+		return nil
+	}
+
+	limit, err := strconv.Atoi(projectConfig["limits.networks.forwards"])
+	if err != nil {
+		return fmt.Errorf("Invalid project limits.networks.forwards value: %w", err)
+	}
+
+	count, err := tx.GetProjectNetworkForwardsCount(projectName)
+	if err != nil {
+		return fmt.Errorf("Failed loading project's network forwards for limits check: %w", err)
+	}
+
+	if count >= limit {
+		return fmt.Errorf("Network forwards limit has been reached for project")
+	}
+
+	return nil
+}
@@ -89,6 +89,41 @@ func TestAllowInstanceCreation_Above(t *testing.T) {
 	assert.EqualError(t, err, `Reached maximum number of instances of type "container" in project "p1"`)
 }
 
+// If a limit is configured and creating the requested count of instances
+// would exceed it, the check fails.
+func TestAllowInstanceCreation_AboveCount(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	id, err := cluster.CreateProject(ctx, tx.Tx(), cluster.Project{Name: "p1"})
+	require.NoError(t, err)
+
+	err = cluster.CreateProjectConfig(ctx, tx.Tx(), id, map[string]string{"limits.containers": "3"})
+	require.NoError(t, err)
+
+	_, err = tx.CreateInstance(db.Instance{
+		Project:      "p1",
+		Name:         "c1",
+		Type:         instancetype.Container,
+		Architecture: 1,
+		Node:         "none",
+	})
+	require.NoError(t, err)
+
+	req := api.InstancesPost{
+		Type:  api.InstanceTypeContainer,
+		Count: 2,
+	}
+
+	err = project.AllowInstanceCreation(tx, "p1", req)
+	assert.NoError(t, err)
+
+	req.Count = 3
+	err = project.AllowInstanceCreation(tx, "p1", req)
+	assert.EqualError(t, err, `Reached maximum number of instances of type "container" in project "p1"`)
+}
+
 // If a limit is configured, but for a different instance type, the check
 // passes.
 func TestAllowInstanceCreation_DifferentType(t *testing.T) {
@@ -151,6 +186,38 @@ func TestAllowInstanceCreation_AboveInstances(t *testing.T) {
 	assert.EqualError(t, err, `Reached maximum number of instances in project "p1"`)
 }
 
+// If a project is restricted, templated device values are rejected as they'd bypass the device restrictions.
+func TestAllowInstanceCreation_RestrictedTemplatedDevice(t *testing.T) {
+	tx, cleanup := db.NewTestClusterTx(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	id, err := cluster.CreateProject(ctx, tx.Tx(), cluster.Project{Name: "p1"})
+	require.NoError(t, err)
+
+	err = cluster.CreateProjectConfig(ctx, tx.Tx(), id, map[string]string{"features.profiles": "true", "restricted": "true"})
+	require.NoError(t, err)
+
+	profileID, err := cluster.CreateProfile(ctx, tx.Tx(), cluster.Profile{Project: "p1", Name: "default"})
+	require.NoError(t, err)
+
+	err = cluster.CreateProfileDevice(ctx, tx.Tx(), profileID, cluster.Device{
+		Name:   "host",
+		Type:   cluster.TypeDisk,
+		Config: map[string]string{"type": "disk", "path": "/mnt", "source": "/", "pool": "{{ '' }}"},
+	})
+	require.NoError(t, err)
+
+	req := api.InstancesPost{
+		Name: "c1",
+		Type: api.InstanceTypeContainer,
+	}
+
+	err = project.AllowInstanceCreation(tx, "p1", req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Templated value of "pool" isn't allowed in restricted projects`)
+}
+
 // If a direct targeting is blocked, the check fails.
 func TestCheckClusterTargetRestriction_RestrictedTrue(t *testing.T) {
 	tx, cleanup := db.NewTestClusterTx(t)
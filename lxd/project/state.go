@@ -87,5 +87,47 @@ func GetCurrentAllocations(tx *db.ClusterTx, projectName string) (map[string]api
 		Usage: int64(len(networks[projectName])),
 	}
 
+	// Get the network subnets limit and usage.
+	limit, err = getProjectLimit(info.Project.Config, "limits.networks.subnets")
+	if err != nil {
+		return nil, err
+	}
+
+	count = 0
+	for _, network := range networks[projectName] {
+		count += NetworkSubnetsCount(network.Config)
+	}
+
+	result["networks.subnets"] = api.ProjectStateResource{
+		Limit: int64(limit),
+		Usage: int64(count),
+	}
+
+	// Get the network forwards limit and usage.
+	limit, err = getProjectLimit(info.Project.Config, "limits.networks.forwards")
+	if err != nil {
+		return nil, err
+	}
+
+	count, err = tx.GetProjectNetworkForwardsCount(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	result["networks.forwards"] = api.ProjectStateResource{
+		Limit: int64(limit),
+		Usage: int64(count),
+	}
+
 	return result, nil
 }
+
+// getProjectLimit returns the value of the given count limit key or -1 if unset.
+func getProjectLimit(config map[string]string, key string) (int, error) {
+	value, ok := config[key]
+	if !ok {
+		return -1, nil
+	}
+
+	return strconv.Atoi(value)
+}
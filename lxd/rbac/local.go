@@ -0,0 +1,122 @@
+package rbac
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// Local represents an RBAC backend using the roles stored in the LXD database.
+type Local struct {
+	permissions     map[string]*UserAccess
+	permissionsLock sync.Mutex
+
+	// UserAccessFunc loads the access granted to a user and its groups by the local roles.
+	// It must return nil if no role applies to the user.
+	UserAccessFunc func(username string, groups []string) (*UserAccess, error)
+}
+
+// NewLocal returns a new local RBAC backend.
+func NewLocal() *Local {
+	return &Local{
+		permissions: make(map[string]*UserAccess),
+	}
+}
+
+// UserAccess returns a UserAccess struct for the user.
+func (r *Local) UserAccess(username string) (*UserAccess, error) {
+	return r.UserGroupsAccess(username, nil)
+}
+
+// UserGroupsAccess returns a UserAccess struct for the user, including the roles assigned to its groups.
+func (r *Local) UserGroupsAccess(username string, groups []string) (*UserAccess, error) {
+	if r.UserAccessFunc == nil {
+		return nil, fmt.Errorf("UserAccessFunc isn't configured yet, cannot check permissions")
+	}
+
+	// The role users with the group prefix refer to groups, don't let a user name match them.
+	if strings.HasPrefix(username, GroupPrefix) {
+		return nil, fmt.Errorf("Invalid user name %q (the %q prefix is reserved for groups)", username, GroupPrefix)
+	}
+
+	r.permissionsLock.Lock()
+	defer r.permissionsLock.Unlock()
+
+	// The same user may be a member of different groups depending on the token it presents.
+	sortedGroups := append([]string{}, groups...)
+	sort.Strings(sortedGroups)
+	key := strings.Join(append([]string{username}, sortedGroups...), "\000")
+
+	access, cached := r.permissions[key]
+	if !cached {
+		var err error
+		access, err = r.UserAccessFunc(username, sortedGroups)
+		if err != nil {
+			return nil, err
+		}
+
+		r.permissions[key] = access
+	}
+
+	// Users without any role are unknown.
+	if access == nil {
+		return nil, errUnknownUser
+	}
+
+	// Return a copy so that callers can't alter the cache.
+	result := UserAccess{
+		Admin:           access.Admin,
+		Projects:        make(map[string][]string, len(access.Projects)),
+		ProjectPatterns: make(map[string][]string, len(access.ProjectPatterns)),
+	}
+
+	for projectName, permissions := range access.Projects {
+		result.Projects[projectName] = append([]string{}, permissions...)
+	}
+
+	for pattern, permissions := range access.ProjectPatterns {
+		result.ProjectPatterns[pattern] = append([]string{}, permissions...)
+	}
+
+	return &result, nil
+}
+
+// SyncProjects does nothing as the local roles refer to the projects directly.
+func (r *Local) SyncProjects() error {
+	return nil
+}
+
+// AddProject does nothing as new projects can only be granted access through the patterns, which are
+// matched when checking the permissions.
+func (r *Local) AddProject(id int64, name string) error {
+	return nil
+}
+
+// DeleteProject flushes the cache as the local roles refer to the projects directly.
+func (r *Local) DeleteProject(id int64) error {
+	r.FlushCache()
+	return nil
+}
+
+// RenameProject flushes the cache as the local roles refer to the projects directly.
+func (r *Local) RenameProject(id int64, name string) error {
+	r.FlushCache()
+	return nil
+}
+
+// FlushCache discards the cached user permissions.
+func (r *Local) FlushCache() {
+	r.permissionsLock.Lock()
+	defer r.permissionsLock.Unlock()
+
+	logger.Debug("Flushing local RBAC permissions cache")
+
+	r.permissions = make(map[string]*UserAccess)
+}
+
+// Stop does nothing as the local backend has no background task.
+func (r *Local) Stop() {
+}
@@ -0,0 +1,116 @@
+package rbac
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// Errors
+var errUnknownUser = fmt.Errorf("Unknown RBAC user")
+
+// ProjectPermissions lists the permissions which can be granted on a project.
+var ProjectPermissions = []string{
+	"view",
+	"manage-containers",
+	"manage-images",
+	"manage-networks",
+	"manage-profiles",
+	"manage-projects",
+	"manage-storage-volumes",
+	"operate-containers",
+}
+
+// UserAccess struct for permission checks.
+type UserAccess struct {
+	Admin    bool
+	Projects map[string][]string
+
+	// ProjectPatterns maps project name patterns (e.g. "dev-*") to the permissions granted on the
+	// projects matching them.
+	ProjectPatterns map[string][]string
+}
+
+// ProjectPermissions returns the permissions granted on the project, either directly or through a pattern.
+func (ua *UserAccess) ProjectPermissions(projectName string) []string {
+	permissions := append([]string{}, ua.Projects[projectName]...)
+
+	for pattern, patternPermissions := range ua.ProjectPatterns {
+		if !MatchProjectPattern(pattern, projectName) {
+			continue
+		}
+
+		for _, permission := range patternPermissions {
+			if !shared.StringInSlice(permission, permissions) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+
+	return permissions
+}
+
+// ValidateProjectPattern checks that the project name pattern is valid.
+func ValidateProjectPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("Empty project pattern")
+	}
+
+	_, err := path.Match(pattern, "")
+	if err != nil {
+		return fmt.Errorf("Invalid project pattern %q: %w", pattern, err)
+	}
+
+	return nil
+}
+
+// MatchProjectPattern returns whether the project name matches the pattern.
+// The pattern uses shell file name syntax, so "dev-*" matches all the projects starting with "dev-".
+func MatchProjectPattern(pattern string, projectName string) bool {
+	match, err := path.Match(pattern, projectName)
+	if err != nil {
+		return false
+	}
+
+	return match
+}
+
+// matchAnyProjectPattern returns whether the project name matches any of the patterns.
+func matchAnyProjectPattern(patterns []string, projectName string) bool {
+	for _, pattern := range patterns {
+		if MatchProjectPattern(pattern, projectName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorizer represents an RBAC backend.
+type Authorizer interface {
+	// UserAccess returns the permissions of the user, or an error if the user is unknown.
+	UserAccess(username string) (*UserAccess, error)
+
+	// Project sync.
+	SyncProjects() error
+	AddProject(id int64, name string) error
+	DeleteProject(id int64) error
+	RenameProject(id int64, name string) error
+
+	// FlushCache discards any cached permissions.
+	FlushCache()
+
+	// Stop stops any background task of the backend.
+	Stop()
+}
+
+// GroupPrefix is the prefix of the role users referring to a group of users rather than a single user.
+const GroupPrefix = "group:"
+
+// GroupAuthorizer is implemented by the RBAC backends able to grant access through the groups of a user,
+// as provided by OpenID Connect tokens.
+type GroupAuthorizer interface {
+	// UserGroupsAccess returns the permissions of the user and its groups, or an error if both are unknown.
+	UserGroupsAccess(username string, groups []string) (*UserAccess, error)
+}
@@ -30,5 +30,5 @@ func UserHasPermission(r *http.Request, project string, permission string) bool
 		return true
 	}
 
-	return shared.StringInSlice(permission, ua.Projects[project])
+	return shared.StringInSlice(permission, ua.ProjectPermissions(project))
 }
@@ -38,19 +38,13 @@ type rbacResourcePostResponse struct {
 	SyncID string `json:"sync-id"`
 }
 
+// projectPatternPrefix is the prefix of the RBAC resource identifiers representing project patterns.
+const projectPatternPrefix = "pattern:"
+
 type rbacStatus struct {
 	LastChange string `json:"last-change"`
 }
 
-// Errors
-var errUnknownUser = fmt.Errorf("Unknown RBAC user")
-
-// UserAccess struct for permission checks.
-type UserAccess struct {
-	Admin    bool
-	Projects map[string][]string
-}
-
 // Server represents an RBAC server.
 type Server struct {
 	apiURL string
@@ -63,8 +57,9 @@ type Server struct {
 	ctx       context.Context
 	ctxCancel context.CancelFunc
 
-	resources     map[string]string // Maps name to identifier
-	resourcesLock sync.Mutex
+	resources       map[string]string // Maps name to identifier
+	projectPatterns []string
+	resourcesLock   sync.Mutex
 
 	permissions map[string]map[string][]string
 
@@ -193,7 +188,7 @@ func (r *Server) StartStatusCheck() {
 
 			r.lastChange = status.LastChange
 			logger.Debugf("RBAC change detected, flushing cache")
-			r.flushCache()
+			r.FlushCache()
 		}
 	}()
 }
@@ -203,6 +198,27 @@ func (r *Server) StopStatusCheck() {
 	r.ctxCancel()
 }
 
+// Stop stops the periodic status checker.
+func (r *Server) Stop() {
+	r.StopStatusCheck()
+}
+
+// SetProjectPatterns sets the project name patterns synced as RBAC resources.
+// Projects matching one of the patterns aren't synced individually and get the permissions granted on the pattern.
+func (r *Server) SetProjectPatterns(patterns []string) {
+	r.resourcesLock.Lock()
+	r.projectPatterns = append([]string{}, patterns...)
+	r.resourcesLock.Unlock()
+}
+
+// matchProjectPatterns returns whether the project is covered by one of the project patterns.
+func (r *Server) matchProjectPatterns(name string) bool {
+	r.resourcesLock.Lock()
+	defer r.resourcesLock.Unlock()
+
+	return matchAnyProjectPattern(r.projectPatterns, name)
+}
+
 // SyncProjects updates the list of projects in RBAC
 func (r *Server) SyncProjects() error {
 	if r.ProjectsFunc == nil {
@@ -218,8 +234,25 @@ func (r *Server) SyncProjects() error {
 		return err
 	}
 
+	r.resourcesLock.Lock()
+	patterns := append([]string{}, r.projectPatterns...)
+	r.resourcesLock.Unlock()
+
+	// Add the project patterns
+	for _, pattern := range patterns {
+		resources = append(resources, rbacResource{
+			Name:       pattern,
+			Identifier: projectPatternPrefix + pattern,
+		})
+	}
+
 	// Convert to RBAC format
 	for id, name := range projects {
+		// Projects matching a pattern are covered by the pattern resource.
+		if matchAnyProjectPattern(patterns, name) {
+			continue
+		}
+
 		resources = append(resources, rbacResource{
 			Name:       name,
 			Identifier: strconv.FormatInt(id, 10),
@@ -244,6 +277,11 @@ func (r *Server) SyncProjects() error {
 
 // AddProject adds a new project resource to RBAC.
 func (r *Server) AddProject(id int64, name string) error {
+	// Projects matching a pattern don't need to be synced.
+	if r.matchProjectPatterns(name) {
+		return nil
+	}
+
 	resource := rbacResource{
 		Name:       name,
 		Identifier: strconv.FormatInt(id, 10),
@@ -263,10 +301,27 @@ func (r *Server) AddProject(id int64, name string) error {
 	return nil
 }
 
-// DeleteProject adds a new project resource to RBAC.
+// DeleteProject removes a project resource from RBAC.
 func (r *Server) DeleteProject(id int64) error {
+	identifier := strconv.FormatInt(id, 10)
+
+	// Projects matching a pattern were never synced.
+	r.resourcesLock.Lock()
+	synced := false
+	for _, v := range r.resources {
+		if v == identifier {
+			synced = true
+			break
+		}
+	}
+	r.resourcesLock.Unlock()
+
+	if !synced {
+		return nil
+	}
+
 	// Update RBAC
-	err := r.postResources(nil, []string{strconv.FormatInt(id, 10)}, false)
+	err := r.postResources(nil, []string{identifier}, false)
 	if err != nil {
 		return err
 	}
@@ -274,7 +329,7 @@ func (r *Server) DeleteProject(id int64) error {
 	// Update project map
 	r.resourcesLock.Lock()
 	for k, v := range r.resources {
-		if v == strconv.FormatInt(id, 10) {
+		if v == identifier {
 			delete(r.resources, k)
 			break
 		}
@@ -286,6 +341,11 @@ func (r *Server) DeleteProject(id int64) error {
 
 // RenameProject renames an existing project resource in RBAC.
 func (r *Server) RenameProject(id int64, name string) error {
+	// Drop the project resource if the project is now covered by a pattern.
+	if r.matchProjectPatterns(name) {
+		return r.DeleteProject(id)
+	}
+
 	return r.AddProject(id, name)
 }
 
@@ -309,16 +369,30 @@ func (r *Server) UserAccess(username string) (*UserAccess, error) {
 
 	// Prepare the response.
 	access := UserAccess{
-		Admin:    shared.StringInSlice("admin", permissions[""]),
-		Projects: map[string][]string{},
+		Admin:           shared.StringInSlice("admin", permissions[""]),
+		Projects:        map[string][]string{},
+		ProjectPatterns: map[string][]string{},
 	}
 
+	r.resourcesLock.Lock()
+	defer r.resourcesLock.Unlock()
+
 	for k, v := range permissions {
 		// Skip the global permissions.
 		if k == "" {
 			continue
 		}
 
+		// Handle the project patterns.
+		if strings.HasPrefix(k, projectPatternPrefix) {
+			pattern := strings.TrimPrefix(k, projectPatternPrefix)
+			if shared.StringInSlice(pattern, r.projectPatterns) {
+				access.ProjectPatterns[pattern] = v
+			}
+
+			continue
+		}
+
 		// Look for project name.
 		for projectName, resourceID := range r.resources {
 			if k != resourceID {
@@ -335,7 +409,8 @@ func (r *Server) UserAccess(username string) (*UserAccess, error) {
 	return &access, nil
 }
 
-func (r *Server) flushCache() {
+// FlushCache discards the cached user permissions.
+func (r *Server) FlushCache() {
 	r.permissionsLock.Lock()
 	defer r.permissionsLock.Unlock()
 
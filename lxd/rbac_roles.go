@@ -0,0 +1,535 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/lifecycle"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/rbac"
+	"github.com/lxc/lxd/lxd/request"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var rbacRolesCmd = APIEndpoint{
+	Path: "rbac/roles",
+
+	Get:  APIEndpointAction{Handler: rbacRolesGet},
+	Post: APIEndpointAction{Handler: rbacRolesPost},
+}
+
+var rbacRoleCmd = APIEndpoint{
+	Path: "rbac/roles/{name}",
+
+	Delete: APIEndpointAction{Handler: rbacRoleDelete},
+	Get:    APIEndpointAction{Handler: rbacRoleGet},
+	Post:   APIEndpointAction{Handler: rbacRolePost},
+	Put:    APIEndpointAction{Handler: rbacRolePut},
+}
+
+// rbacRoleValidateName checks the name of a local RBAC role is valid.
+func rbacRoleValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("No name provided")
+	}
+
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("Role names may not contain slashes")
+	}
+
+	return nil
+}
+
+// rbacRoleValidate checks the fields of a local RBAC role are valid.
+func rbacRoleValidate(info *api.RBACRolePut) error {
+	for _, permission := range info.Permissions {
+		if !shared.StringInSlice(permission, rbac.ProjectPermissions) {
+			return fmt.Errorf("Invalid permission %q (must be one of %s)", permission, strings.Join(rbac.ProjectPermissions, ", "))
+		}
+	}
+
+	for _, pattern := range info.ProjectPatterns {
+		err := rbac.ValidateProjectPattern(pattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, username := range info.Users {
+		if username == "" {
+			return fmt.Errorf("Empty user names aren't allowed")
+		}
+	}
+
+	return nil
+}
+
+// rbacRoleEtag returns the fields used to compute the ETag of a local RBAC role.
+func rbacRoleEtag(role *api.RBACRole) []any {
+	return []any{role.Name, role.Description, role.Admin, role.Permissions, role.Projects, role.ProjectPatterns, role.Users}
+}
+
+// rbacRoleNotify asks the other cluster members to flush their RBAC cache by replaying the request.
+func rbacRoleNotify(d *Daemon, hook func(client lxd.InstanceServer) error) error {
+	notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), d.serverCert(), cluster.NotifyAlive)
+	if err != nil {
+		return err
+	}
+
+	return notifier(hook)
+}
+
+// rbacRoleFlushCache flushes the local cache of the RBAC backend.
+func rbacRoleFlushCache(d *Daemon) {
+	if d.rbac != nil {
+		d.rbac.FlushCache()
+	}
+}
+
+// API endpoints
+
+// swagger:operation GET /1.0/rbac/roles rbac rbac_roles_get
+//
+// Get the local RBAC roles
+//
+// Returns a list of local RBAC roles (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/rbac/roles/developers",
+//               "/1.0/rbac/roles/operators"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/rbac/roles?recursion=1 rbac rbac_roles_get_recursion1
+//
+// Get the local RBAC roles
+//
+// Returns a list of local RBAC roles (structs).
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of local RBAC roles
+//           items:
+//             $ref: "#/definitions/RBACRole"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func rbacRolesGet(d *Daemon, r *http.Request) response.Response {
+	names, err := d.db.Cluster.GetRBACRoleNames()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if util.IsRecursionRequest(r) {
+		roles := make([]*api.RBACRole, 0, len(names))
+		for _, name := range names {
+			_, role, err := d.db.Cluster.GetRBACRole(name)
+			if err != nil {
+				return response.SmartError(err)
+			}
+
+			roles = append(roles, role)
+		}
+
+		return response.SyncResponse(true, roles)
+	}
+
+	roleURLs := make([]string, 0, len(names))
+	for _, name := range names {
+		roleURLs = append(roleURLs, fmt.Sprintf("/%s/rbac/roles/%s", version.APIVersion, url.PathEscape(name)))
+	}
+
+	return response.SyncResponse(true, roleURLs)
+}
+
+// swagger:operation POST /1.0/rbac/roles rbac rbac_roles_post
+//
+// Add a local RBAC role
+//
+// Creates a new local RBAC role.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: body
+//     name: role
+//     description: Role
+//     required: true
+//     schema:
+//       $ref: "#/definitions/RBACRolesPost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func rbacRolesPost(d *Daemon, r *http.Request) response.Response {
+	req := api.RBACRolesPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !isClusterNotification(r) {
+		err = rbacRoleValidateName(req.Name)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		err = rbacRoleValidate(&req.RBACRolePut)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		_, _, err = d.db.Cluster.GetRBACRole(req.Name)
+		if err == nil {
+			return response.Conflict(fmt.Errorf("RBAC role %q already exists", req.Name))
+		} else if !response.IsNotFoundError(err) {
+			return response.SmartError(err)
+		}
+
+		_, err = d.db.Cluster.CreateRBACRole(&req)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed creating RBAC role: %w", err))
+		}
+
+		err = rbacRoleNotify(d, func(client lxd.InstanceServer) error {
+			return client.CreateRBACRole(req)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		d.State().Events.SendLifecycle(project.Default, lifecycle.RBACRoleCreated.Event(req.Name, request.CreateRequestor(r), nil))
+	}
+
+	rbacRoleFlushCache(d)
+
+	url := fmt.Sprintf("/%s/rbac/roles/%s", version.APIVersion, url.PathEscape(req.Name))
+	return response.SyncResponseLocation(true, nil, url)
+}
+
+// swagger:operation DELETE /1.0/rbac/roles/{name} rbac rbac_role_delete
+//
+// Delete the local RBAC role
+//
+// Removes the local RBAC role.
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func rbacRoleDelete(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !isClusterNotification(r) {
+		roleID, _, err := d.db.Cluster.GetRBACRole(name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		err = d.db.Cluster.DeleteRBACRole(roleID)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed deleting RBAC role: %w", err))
+		}
+
+		err = rbacRoleNotify(d, func(client lxd.InstanceServer) error {
+			return client.DeleteRBACRole(name)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		d.State().Events.SendLifecycle(project.Default, lifecycle.RBACRoleDeleted.Event(name, request.CreateRequestor(r), nil))
+	}
+
+	rbacRoleFlushCache(d)
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation GET /1.0/rbac/roles/{name} rbac rbac_role_get
+//
+// Get the local RBAC role
+//
+// Gets a specific local RBAC role.
+//
+// ---
+// produces:
+//   - application/json
+// responses:
+//   "200":
+//     description: Local RBAC role
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/RBACRole"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func rbacRoleGet(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, role, err := d.db.Cluster.GetRBACRole(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseETag(true, role, rbacRoleEtag(role))
+}
+
+// swagger:operation PUT /1.0/rbac/roles/{name} rbac rbac_role_put
+//
+// Update the local RBAC role
+//
+// Updates the entire local RBAC role configuration.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: body
+//     name: role
+//     description: Role configuration
+//     required: true
+//     schema:
+//       $ref: "#/definitions/RBACRolePut"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "412":
+//     $ref: "#/responses/PreconditionFailed"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func rbacRolePut(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.RBACRolePut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !isClusterNotification(r) {
+		roleID, role, err := d.db.Cluster.GetRBACRole(name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		// Validate the ETag.
+		err = util.EtagCheck(r, rbacRoleEtag(role))
+		if err != nil {
+			return response.PreconditionFailed(err)
+		}
+
+		err = rbacRoleValidate(&req)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		err = d.db.Cluster.UpdateRBACRole(roleID, &req)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed updating RBAC role: %w", err))
+		}
+
+		err = rbacRoleNotify(d, func(client lxd.InstanceServer) error {
+			return client.UpdateRBACRole(name, req, "")
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		d.State().Events.SendLifecycle(project.Default, lifecycle.RBACRoleUpdated.Event(name, request.CreateRequestor(r), nil))
+	}
+
+	rbacRoleFlushCache(d)
+
+	return response.EmptySyncResponse
+}
+
+// swagger:operation POST /1.0/rbac/roles/{name} rbac rbac_role_post
+//
+// Rename the local RBAC role
+//
+// Renames an existing local RBAC role.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: body
+//     name: role
+//     description: Role rename request
+//     required: true
+//     schema:
+//       $ref: "#/definitions/RBACRolePost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func rbacRolePost(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.RBACRolePost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if !isClusterNotification(r) {
+		err = rbacRoleValidateName(req.Name)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		roleID, _, err := d.db.Cluster.GetRBACRole(name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		_, _, err = d.db.Cluster.GetRBACRole(req.Name)
+		if err == nil {
+			return response.Conflict(fmt.Errorf("RBAC role %q already exists", req.Name))
+		} else if !response.IsNotFoundError(err) {
+			return response.SmartError(err)
+		}
+
+		err = d.db.Cluster.RenameRBACRole(roleID, req.Name)
+		if err != nil {
+			return response.SmartError(fmt.Errorf("Failed renaming RBAC role: %w", err))
+		}
+
+		err = rbacRoleNotify(d, func(client lxd.InstanceServer) error {
+			return client.RenameRBACRole(name, req)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		d.State().Events.SendLifecycle(project.Default, lifecycle.RBACRoleRenamed.Event(req.Name, request.CreateRequestor(r), map[string]any{"old_name": name}))
+	}
+
+	rbacRoleFlushCache(d)
+
+	url := fmt.Sprintf("/%s/rbac/roles/%s", version.APIVersion, url.PathEscape(req.Name))
+	return response.SyncResponseLocation(true, nil, url)
+}
@@ -1,17 +1,34 @@
 package main
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/resources"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/shared/api"
 )
 
+// resourcesCacheTTL is how long the local resources are cached for.
+const resourcesCacheTTL = 5 * time.Second
+
+var resourcesCache *api.Resources
+var resourcesCacheExpiry time.Time
+
+// resourcesCacheGeneration is incremented each time the cache is invalidated, so that resources gathered before
+// the invalidation aren't cached.
+var resourcesCacheGeneration uint64
+var resourcesCacheLock sync.Mutex
+var resourcesLock sync.Mutex
+
 var api10ResourcesCmd = APIEndpoint{
 	Path: "resources",
 
@@ -72,7 +89,7 @@ func api10ResourcesGet(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Get the local resource usage
-	res, err := resources.GetResources()
+	res, err := resourcesGetCached(d.State())
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -80,6 +97,85 @@ func api10ResourcesGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponse(true, res)
 }
 
+// resourcesGetCached returns the local resources, re-using the previous result if it hasn't expired.
+func resourcesGetCached(s *state.State) (*api.Resources, error) {
+	resourcesCacheLock.Lock()
+	if resourcesCache != nil && resourcesCacheExpiry.After(time.Now()) {
+		res := resourcesCache
+		resourcesCacheLock.Unlock()
+
+		return resourcesCopy(res)
+	}
+
+	resourcesCacheLock.Unlock()
+
+	// Acquire update lock so concurrent requests don't all gather the resources.
+	resourcesLock.Lock()
+	defer resourcesLock.Unlock()
+
+	// Check if the cache has been filled in the meantime.
+	resourcesCacheLock.Lock()
+	if resourcesCache != nil && resourcesCacheExpiry.After(time.Now()) {
+		res := resourcesCache
+		resourcesCacheLock.Unlock()
+
+		return resourcesCopy(res)
+	}
+
+	generation := resourcesCacheGeneration
+	resourcesCacheLock.Unlock()
+
+	res, err := resources.GetResources()
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill in the memory reserved by instances from the huge page pools.
+	reserved, err := instance.HugepagesReservedAll(s, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, pool := range res.Memory.HugepagesPools {
+		res.Memory.HugepagesPools[i].Reserved = reserved[pool.Size]
+	}
+
+	resourcesCacheLock.Lock()
+	if resourcesCacheGeneration == generation {
+		resourcesCache = res
+		resourcesCacheExpiry = time.Now().Add(resourcesCacheTTL)
+	}
+
+	resourcesCacheLock.Unlock()
+
+	return resourcesCopy(res)
+}
+
+// resourcesCopy returns a deep copy of the resources so that callers can't modify the cached ones.
+func resourcesCopy(res *api.Resources) (*api.Resources, error) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := &api.Resources{}
+	err = json.Unmarshal(data, copied)
+	if err != nil {
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+// resourcesCacheInvalidate drops the cached local resources.
+func resourcesCacheInvalidate() {
+	resourcesCacheLock.Lock()
+	defer resourcesCacheLock.Unlock()
+
+	resourcesCache = nil
+	resourcesCacheGeneration++
+}
+
 // swagger:operation GET /1.0/storage-pools/{name}/resources storage storage_pool_resources
 //
 // Get storage pool resources information
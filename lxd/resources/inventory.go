@@ -0,0 +1,150 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/units"
+)
+
+// GetInventory returns a summary of the local hardware, used to track hardware changes over time.
+func GetInventory() (*api.ClusterMemberInventory, error) {
+	cpu, err := GetCPU()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve CPU information: %w", err)
+	}
+
+	memory, err := GetMemory()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve memory information: %w", err)
+	}
+
+	storage, err := GetStorage()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve storage information: %w", err)
+	}
+
+	network, err := GetNetwork()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve network information: %w", err)
+	}
+
+	inventory := api.ClusterMemberInventory{
+		CreatedAt:   time.Now(),
+		CPUSockets:  uint64(len(cpu.Sockets)),
+		CPUThreads:  cpu.Total,
+		MemoryTotal: memory.Total,
+		Disks:       []api.ClusterMemberInventoryDisk{},
+		NICs:        []api.ClusterMemberInventoryNIC{},
+	}
+
+	for _, disk := range storage.Disks {
+		// Removable disks are expected to come and go.
+		if disk.Removable {
+			continue
+		}
+
+		// Prefer identifiers which don't change across reboots.
+		id := disk.WWN
+		if id == "" {
+			id = disk.DevicePath
+		}
+
+		if id == "" {
+			id = disk.ID
+		}
+
+		inventory.Disks = append(inventory.Disks, api.ClusterMemberInventoryDisk{
+			ID:    id,
+			Model: disk.Model,
+			Size:  disk.Size,
+		})
+	}
+
+	for _, card := range network.Cards {
+		for _, port := range card.Ports {
+			if port.Address == "" {
+				continue
+			}
+
+			inventory.NICs = append(inventory.NICs, api.ClusterMemberInventoryNIC{
+				Address: port.Address,
+				Name:    port.ID,
+				Driver:  card.Driver,
+			})
+		}
+	}
+
+	sort.Slice(inventory.Disks, func(i, j int) bool { return inventory.Disks[i].ID < inventory.Disks[j].ID })
+	sort.Slice(inventory.NICs, func(i, j int) bool { return inventory.NICs[i].Address < inventory.NICs[j].Address })
+
+	return &inventory, nil
+}
+
+// InventoryEqual returns whether two inventories describe the same hardware.
+func InventoryEqual(a *api.ClusterMemberInventory, b *api.ClusterMemberInventory) bool {
+	if a.CPUSockets != b.CPUSockets || a.CPUThreads != b.CPUThreads || a.MemoryTotal != b.MemoryTotal {
+		return false
+	}
+
+	if len(a.Disks) != len(b.Disks) || len(a.NICs) != len(b.NICs) {
+		return false
+	}
+
+	for i := range a.Disks {
+		if a.Disks[i] != b.Disks[i] {
+			return false
+		}
+	}
+
+	for i := range a.NICs {
+		if a.NICs[i] != b.NICs[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InventoryDrift returns a description of the hardware that disappeared or shrunk between the old and the new
+// inventory. Added hardware isn't reported as it is unlikely to cause failures.
+func InventoryDrift(oldInventory *api.ClusterMemberInventory, newInventory *api.ClusterMemberInventory) []string {
+	drift := []string{}
+
+	if newInventory.CPUSockets < oldInventory.CPUSockets || newInventory.CPUThreads < oldInventory.CPUThreads {
+		drift = append(drift, fmt.Sprintf("CPU threads reduced from %d to %d", oldInventory.CPUThreads, newInventory.CPUThreads))
+	}
+
+	if newInventory.MemoryTotal < oldInventory.MemoryTotal {
+		drift = append(drift, fmt.Sprintf("Memory reduced from %s to %s", units.GetByteSizeStringIEC(int64(oldInventory.MemoryTotal), 2), units.GetByteSizeStringIEC(int64(newInventory.MemoryTotal), 2)))
+	}
+
+	disks := map[string]api.ClusterMemberInventoryDisk{}
+	for _, disk := range newInventory.Disks {
+		disks[disk.ID] = disk
+	}
+
+	for _, oldDisk := range oldInventory.Disks {
+		disk, ok := disks[oldDisk.ID]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("Disk %q (%s) disappeared", oldDisk.ID, oldDisk.Model))
+		} else if disk.Size < oldDisk.Size {
+			drift = append(drift, fmt.Sprintf("Disk %q shrunk from %s to %s", oldDisk.ID, units.GetByteSizeStringIEC(int64(oldDisk.Size), 2), units.GetByteSizeStringIEC(int64(disk.Size), 2)))
+		}
+	}
+
+	nics := map[string]bool{}
+	for _, nic := range newInventory.NICs {
+		nics[nic.Address] = true
+	}
+
+	for _, oldNIC := range oldInventory.NICs {
+		if !nics[oldNIC.Address] {
+			drift = append(drift, fmt.Sprintf("Network interface %q (%s) disappeared", oldNIC.Name, oldNIC.Address))
+		}
+	}
+
+	return drift
+}
@@ -0,0 +1,44 @@
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+var procLoadavg = "/proc/loadavg"
+
+// GetLoad returns a filled api.ResourcesLoad struct ready for use by LXD.
+func GetLoad() (*api.ResourcesLoad, error) {
+	content, err := ioutil.ReadFile(procLoadavg)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %q: %w", procLoadavg, err)
+	}
+
+	// The expected format is "0.42 0.51 0.48 2/412 12345".
+	fields := strings.Fields(string(content))
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("Unexpected content in %q", procLoadavg)
+	}
+
+	load := api.ResourcesLoad{}
+	for i, value := range []*float64{&load.Average1, &load.Average5, &load.Average15} {
+		*value, err = strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse load average %q: %w", fields[i], err)
+		}
+	}
+
+	_, processes, found := strings.Cut(fields[3], "/")
+	if found {
+		load.Processes, err = strconv.ParseUint(processes, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse process count %q: %w", processes, err)
+		}
+	}
+
+	return &load, nil
+}
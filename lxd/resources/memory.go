@@ -15,6 +15,7 @@ import (
 
 var sysDevicesNode = "/sys/devices/system/node"
 var sysDevicesSystemMemory = "/sys/devices/system/memory"
+var sysKernelMMHugepages = "/sys/kernel/mm/hugepages"
 
 type meminfo struct {
 	Cached         uint64
@@ -196,6 +197,54 @@ func getTotalMemory(sysDevicesBase string) uint64 {
 	return blockSize * count
 }
 
+// GetHugepagesPools returns the huge page pools of the system, one per supported page size.
+// The Reserved field isn't filled as it depends on the instances using the pools.
+func GetHugepagesPools() ([]api.ResourcesMemoryHugepages, error) {
+	pools := []api.ResourcesMemoryHugepages{}
+
+	if !sysfsExists(sysKernelMMHugepages) {
+		return pools, nil
+	}
+
+	entries, err := ioutil.ReadDir(sysKernelMMHugepages)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list %q: %w", sysKernelMMHugepages, err)
+	}
+
+	for _, entry := range entries {
+		entryName := entry.Name()
+		entryPath := filepath.Join(sysKernelMMHugepages, entryName)
+
+		// Entries look like "hugepages-2048kB".
+		if !strings.HasPrefix(entryName, "hugepages-") {
+			continue
+		}
+
+		size, err := units.ParseByteSizeString(strings.Replace(strings.TrimPrefix(entryName, "hugepages-"), "kB", "KiB", 1))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse huge page size of %q: %w", entryPath, err)
+		}
+
+		total, err := readUint(filepath.Join(entryPath, "nr_hugepages"))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %q: %w", filepath.Join(entryPath, "nr_hugepages"), err)
+		}
+
+		free, err := readUint(filepath.Join(entryPath, "free_hugepages"))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read %q: %w", filepath.Join(entryPath, "free_hugepages"), err)
+		}
+
+		pools = append(pools, api.ResourcesMemoryHugepages{
+			Size:  uint64(size),
+			Total: total * uint64(size),
+			Free:  free * uint64(size),
+		})
+	}
+
+	return pools, nil
+}
+
 // GetMemory returns a filled api.ResourcesMemory struct ready for use by LXD
 func GetMemory() (*api.ResourcesMemory, error) {
 	memory := api.ResourcesMemory{}
@@ -219,6 +268,11 @@ func GetMemory() (*api.ResourcesMemory, error) {
 	memory.HugepagesTotal = info.HugepagesTotal * info.HugepagesSize
 	memory.HugepagesSize = info.HugepagesSize
 
+	memory.HugepagesPools, err = GetHugepagesPools()
+	if err != nil {
+		return nil, err
+	}
+
 	memory.Used = info.Total - info.Free - info.Cached - info.Buffers
 	memory.Total = info.Total
 
@@ -155,79 +155,80 @@ func (r *syncResponse) String() string {
 
 // Error response
 type errorResponse struct {
-	code int    // Code to return in both the HTTP header and Code field of the response body.
-	msg  string // Message to return in the Error field of the response body.
+	code    int           // Code to return in both the HTTP header and Code field of the response body.
+	msg     string        // Message to return in the Error field of the response body.
+	errType api.ErrorType // Type to return in the ErrorType field of the response body (derived from code if empty).
 }
 
 // ErrorResponse returns an error response with the given code and msg.
 func ErrorResponse(code int, msg string) Response {
-	return &errorResponse{code, msg}
+	return &errorResponse{code: code, msg: msg}
+}
+
+// newErrorResponse returns an error response with the given code, using the message and type of err.
+func newErrorResponse(code int, err error) *errorResponse {
+	return &errorResponse{code: code, msg: err.Error(), errType: api.ErrorTypeGet(err)}
 }
 
 // BadRequest returns a bad request response (400) with the given error.
 func BadRequest(err error) Response {
-	return &errorResponse{http.StatusBadRequest, err.Error()}
+	return newErrorResponse(http.StatusBadRequest, err)
 }
 
 // Conflict returns a conflict response (409) with the given error.
 func Conflict(err error) Response {
-	message := "already exists"
 	if err != nil {
-		message = err.Error()
+		return newErrorResponse(http.StatusConflict, err)
 	}
 
-	return &errorResponse{http.StatusConflict, message}
+	return &errorResponse{code: http.StatusConflict, msg: "already exists"}
 }
 
 // Forbidden returns a forbidden response (403) with the given error.
 func Forbidden(err error) Response {
-	message := "not authorized"
 	if err != nil {
-		message = err.Error()
+		return newErrorResponse(http.StatusForbidden, err)
 	}
 
-	return &errorResponse{http.StatusForbidden, message}
+	return &errorResponse{code: http.StatusForbidden, msg: "not authorized"}
 }
 
 // InternalError returns an internal error response (500) with the given error.
 func InternalError(err error) Response {
-	return &errorResponse{http.StatusInternalServerError, err.Error()}
+	return newErrorResponse(http.StatusInternalServerError, err)
 }
 
 // NotFound returns a not found response (404) with the given error.
 func NotFound(err error) Response {
-	message := "not found"
 	if err != nil {
-		message = err.Error()
+		return newErrorResponse(http.StatusNotFound, err)
 	}
 
-	return &errorResponse{http.StatusNotFound, message}
+	return &errorResponse{code: http.StatusNotFound, msg: "not found"}
 }
 
 // NotImplemented returns a not implemented response (501) with the given error.
 func NotImplemented(err error) Response {
-	message := "not implemented"
 	if err != nil {
-		message = err.Error()
+		return newErrorResponse(http.StatusNotImplemented, err)
 	}
 
-	return &errorResponse{http.StatusNotImplemented, message}
+	return &errorResponse{code: http.StatusNotImplemented, msg: "not implemented"}
 }
 
 // PreconditionFailed returns a precondition failed response (412) with the
 // given error.
 func PreconditionFailed(err error) Response {
-	return &errorResponse{http.StatusPreconditionFailed, err.Error()}
+	return newErrorResponse(http.StatusPreconditionFailed, err)
 }
 
 // Unavailable return an unavailable response (503) with the given error.
 func Unavailable(err error) Response {
-	message := "unavailable"
 	if err != nil {
-		message = err.Error()
+		return newErrorResponse(http.StatusServiceUnavailable, err)
 	}
 
-	return &errorResponse{http.StatusServiceUnavailable, message}
+	return &errorResponse{code: http.StatusServiceUnavailable, msg: "unavailable"}
 }
 
 func (r *errorResponse) String() string {
@@ -245,10 +246,16 @@ func (r *errorResponse) Render(w http.ResponseWriter) error {
 		output = io.MultiWriter(buf, captured)
 	}
 
+	errType := r.errType
+	if errType == "" {
+		errType = api.ErrorTypeFromStatus(r.code)
+	}
+
 	resp := api.ResponseRaw{
-		Type:  api.ErrorResponse,
-		Error: r.msg,
-		Code:  r.code, // Set the error code in the Code field of the response body.
+		Type:      api.ErrorResponse,
+		Error:     r.msg,
+		Code:      r.code, // Set the error code in the Code field of the response body.
+		ErrorType: errType,
 	}
 
 	err := json.NewEncoder(output).Encode(resp)
@@ -24,7 +24,7 @@ func SmartError(err error) Response {
 	}
 
 	if statusCode, found := api.StatusErrorMatch(err); found {
-		return &errorResponse{statusCode, err.Error()}
+		return newErrorResponse(statusCode, err)
 	}
 
 	for httpStatusCode, checkErrs := range httpResponseErrors {
@@ -32,17 +32,17 @@ func SmartError(err error) Response {
 			if errors.Is(err, checkErr) {
 				if err != checkErr {
 					// If the error has been wrapped return the top-level error message.
-					return &errorResponse{httpStatusCode, err.Error()}
+					return newErrorResponse(httpStatusCode, err)
 				}
 
 				// If the error hasn't been wrapped, replace the error message with the generic
 				// HTTP status text.
-				return &errorResponse{httpStatusCode, http.StatusText(httpStatusCode)}
+				return &errorResponse{code: httpStatusCode, msg: http.StatusText(httpStatusCode)}
 			}
 		}
 	}
 
-	return &errorResponse{http.StatusInternalServerError, err.Error()}
+	return newErrorResponse(http.StatusInternalServerError, err)
 }
 
 // IsNotFoundError returns true if the error is considered a Not Found error.
@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Backend retrieves secrets from an external secrets store.
+type Backend interface {
+	// Get returns the secret identified by the reference (with the scheme prefix removed).
+	Get(reference string) (string, error)
+}
+
+// SchemeVault is the scheme of references to secrets stored in HashiCorp Vault.
+const SchemeVault = "vault"
+
+// schemes lists the supported reference schemes, whether or not their backend is configured.
+var schemes = []string{SchemeVault}
+
+var backends = map[string]Backend{}
+var backendsMu sync.RWMutex
+
+// SetBackend sets the backend resolving the references with the given scheme.
+// A nil backend removes it.
+func SetBackend(scheme string, backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if backend == nil {
+		delete(backends, scheme)
+		return
+	}
+
+	backends[scheme] = backend
+}
+
+// parseReference returns the scheme and the reference of a value in the "<scheme>://<reference>" form.
+// Returns an empty scheme if the value isn't a reference to an externally stored secret.
+func parseReference(value string) (string, string) {
+	for _, scheme := range schemes {
+		reference := strings.TrimPrefix(value, scheme+"://")
+		if reference != value {
+			return scheme, reference
+		}
+	}
+
+	return "", value
+}
+
+// IsReference returns whether the value is a reference to an externally stored secret.
+func IsReference(value string) bool {
+	scheme, _ := parseReference(value)
+
+	return scheme != ""
+}
+
+// Resolve returns the secret a value refers to, or the value itself if it isn't a reference.
+func Resolve(value string) (string, error) {
+	scheme, reference := parseReference(value)
+	if scheme == "" {
+		return value, nil
+	}
+
+	backendsMu.RLock()
+	backend := backends[scheme]
+	backendsMu.RUnlock()
+
+	if backend == nil {
+		return "", fmt.Errorf("Secrets backend %q isn't configured", scheme)
+	}
+
+	secret, err := backend.Get(reference)
+	if err != nil {
+		return "", fmt.Errorf("Failed retrieving secret %q from %q: %w", reference, scheme, err)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,66 @@
+package secrets_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lxc/lxd/lxd/secrets"
+)
+
+func TestResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/lxd":
+			_, _ = fmt.Fprint(w, `{"data": {"data": {"bgp": "kv2", "value": "default"}, "metadata": {"version": 1}}}`)
+		case "/v1/kv/lxd":
+			_, _ = fmt.Fprint(w, `{"data": {"bgp": "kv1"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Plain values are returned unchanged.
+	value, err := secrets.Resolve("password")
+	require.NoError(t, err)
+	assert.Equal(t, "password", value)
+
+	// References can't be resolved until the backend is configured.
+	assert.True(t, secrets.IsReference("vault://secret/data/lxd#bgp"))
+	_, err = secrets.Resolve("vault://secret/data/lxd#bgp")
+	assert.EqualError(t, err, `Secrets backend "vault" isn't configured`)
+
+	backend, err := secrets.NewVault(server.URL, "token", "")
+	require.NoError(t, err)
+
+	secrets.SetBackend(secrets.SchemeVault, backend)
+	defer secrets.SetBackend(secrets.SchemeVault, nil)
+
+	cases := map[string]string{
+		"vault://secret/data/lxd#bgp": "kv2",
+		"vault://secret/data/lxd":     "default",
+		"vault://kv/lxd#bgp":          "kv1",
+	}
+
+	for reference, expected := range cases {
+		value, err := secrets.Resolve(reference)
+		require.NoError(t, err, reference)
+		assert.Equal(t, expected, value, reference)
+	}
+
+	_, err = secrets.Resolve("vault://kv/lxd#missing")
+	assert.EqualError(t, err, `Failed retrieving secret "kv/lxd#missing" from "vault": Secret has no "missing" field`)
+
+	_, err = secrets.Resolve("vault://kv/other")
+	assert.EqualError(t, err, `Failed retrieving secret "kv/other" from "vault": Unexpected response from Vault: 404 Not Found`)
+}
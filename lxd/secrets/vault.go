@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lxc/lxd/shared"
+)
+
+// vault retrieves secrets from a HashiCorp Vault server using its HTTP API.
+type vault struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// NewVault returns a backend retrieving secrets from the Vault server at the given address.
+// References are in the "<path>#<field>" form (the field defaulting to "value") and work with both version 1 and
+// version 2 of the KV secrets engine, e.g. "secret/data/lxd#bgp_password".
+func NewVault(address string, token string, caCert string) (Backend, error) {
+	tlsConfig, err := shared.GetTLSConfigMem("", "", caCert, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("Failed setting up TLS configuration: %w", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: 10 * time.Second,
+	}
+
+	return &vault{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		client:  client,
+	}, nil
+}
+
+// Get returns the field of the secret at the referenced path.
+func (v *vault) Get(reference string) (string, error) {
+	path, field, _ := strings.Cut(reference, "#")
+	if field == "" {
+		field = "value"
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/%s", v.address, strings.TrimPrefix(path, "/")), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unexpected response from Vault: %s", resp.Status)
+	}
+
+	secret := struct {
+		Data map[string]any `json:"data"`
+	}{}
+
+	err = json.NewDecoder(resp.Body).Decode(&secret)
+	if err != nil {
+		return "", fmt.Errorf("Failed parsing Vault response: %w", err)
+	}
+
+	// The KV version 2 secrets engine nests the secret data along with its metadata.
+	data := secret.Data
+	nested, ok := data["data"].(map[string]any)
+	if ok && data["metadata"] != nil {
+		data = nested
+	}
+
+	value, ok := data[field].(string)
+	if !ok {
+		return "", fmt.Errorf("Secret has no %q field", field)
+	}
+
+	return value, nil
+}
@@ -117,6 +117,8 @@ func storageStartup(s *state.State, forceCheck bool) error {
 			return false
 		}
 
+		storagePools.ProbeIdmappedMounts(s, pool)
+
 		logger.Info("Initialized storage pool", logger.Ctx{"pool": poolName})
 		_ = warnings.ResolveWarningsByLocalNodeAndProjectAndTypeAndEntity(s.DB.Cluster, "", db.WarningStoragePoolUnvailable, cluster.TypeStoragePool, int(pool.ID()))
 
@@ -46,6 +46,10 @@ import (
 var unavailablePools = make(map[string]struct{})
 var unavailablePoolsMu = sync.Mutex{}
 
+// discardStatus records the outcome of the last discard of each pool on this server.
+var discardStatus = make(map[string]api.StoragePoolStateDiscard)
+var discardStatusMu = sync.Mutex{}
+
 // instanceDiskVolumeEffectiveFields fields from the instance disks that are applied to the volume's effective
 // config (but not stored in the disk's volume database record).
 var instanceDiskVolumeEffectiveFields = []string{
@@ -252,6 +256,103 @@ func (b *lxdBackend) GetResources() (*api.ResourcesStoragePool, error) {
 	return b.driver.GetResources()
 }
 
+// GetState returns the health and utilisation information about the pool.
+func (b *lxdBackend) GetState() (*api.StoragePoolState, error) {
+	l := logger.AddContext(b.logger, nil)
+	l.Debug("GetState started")
+	defer l.Debug("GetState finished")
+
+	state := api.StoragePoolState{
+		Health:   api.StoragePoolStateUnknown,
+		Messages: []string{},
+		Volumes:  map[string]api.StoragePoolStateVolumes{},
+	}
+
+	health, err := b.driver.HealthInfo()
+	if err != nil && !errors.Is(err, drivers.ErrNotSupported) {
+		return nil, fmt.Errorf("Failed getting storage pool health: %w", err)
+	}
+
+	if health != nil {
+		state.Health = api.StoragePoolStateHealthy
+		if health.Degraded {
+			state.Health = api.StoragePoolStateDegraded
+		}
+
+		state.Messages = append(state.Messages, health.Messages...)
+	}
+
+	state.Resources, err = b.driver.GetResources()
+	if err != nil {
+		return nil, err
+	}
+
+	vols, err := b.driver.ListVolumes()
+	if err != nil && !errors.Is(err, drivers.ErrNotSupported) {
+		return nil, fmt.Errorf("Failed listing storage pool volumes: %w", err)
+	}
+
+	for _, vol := range vols {
+		if vol.IsSnapshot() {
+			continue
+		}
+
+		volType := string(vol.Type())
+		volumes := state.Volumes[volType]
+		volumes.Count++
+
+		// Not every driver can report the usage of volumes that aren't mounted.
+		used, err := b.driver.GetVolumeUsage(vol)
+		if err != nil {
+			if !errors.Is(err, drivers.ErrNotSupported) {
+				l.Debug("Failed getting volume usage", logger.Ctx{"volName": vol.Name(), "volType": volType, "err": err})
+			}
+		} else if used > 0 {
+			volumes.Used += uint64(used)
+		}
+
+		state.Volumes[volType] = volumes
+	}
+
+	discardStatusMu.Lock()
+	discard, ok := discardStatus[b.name]
+	discardStatusMu.Unlock()
+
+	if ok {
+		state.Discard = &discard
+	}
+
+	return &state, nil
+}
+
+// Discard releases the unused blocks of the pool back to the underlying storage and records the outcome.
+func (b *lxdBackend) Discard(op *operations.Operation) error {
+	l := logger.AddContext(b.logger, nil)
+	l.Debug("Discard started")
+	defer l.Debug("Discard finished")
+
+	err := b.driver.Discard()
+	if errors.Is(err, drivers.ErrNotSupported) {
+		return fmt.Errorf("Storage pool driver %q doesn't support discarding unused blocks", b.driver.Info().Name)
+	}
+
+	status := api.StoragePoolStateDiscard{
+		LastRun: time.Now().UTC(),
+		Status:  api.StoragePoolDiscardStatusSuccess,
+	}
+
+	if err != nil {
+		status.Status = api.StoragePoolDiscardStatusFailure
+		status.Error = err.Error()
+	}
+
+	discardStatusMu.Lock()
+	discardStatus[b.name] = status
+	discardStatusMu.Unlock()
+
+	return err
+}
+
 // IsUsed returns whether the storage pool is used by any volumes or profiles (excluding image volumes).
 func (b *lxdBackend) IsUsed() (bool, error) {
 	// Get all users of the storage pool.
@@ -2234,11 +2335,16 @@ func (b *lxdBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCl
 }
 
 // BackupInstance creates an instance backup.
-func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
-	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "optimized": optimized, "snapshots": snapshots})
+// If incrementalBase is set, only the changes since that snapshot are included in the backup.
+func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, incrementalBase string, op *operations.Operation) error {
+	l := logger.AddContext(b.logger, logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "optimized": optimized, "snapshots": snapshots, "incrementalBase": incrementalBase})
 	l.Debug("BackupInstance started")
 	defer l.Debug("BackupInstance finished")
 
+	if incrementalBase != "" && (!optimized || !snapshots || !b.driver.Info().IncrementalBackups) {
+		return fmt.Errorf("Incremental backups require an optimized backup including snapshots on a storage driver supporting them")
+	}
+
 	volType, err := InstanceTypeToVolumeType(inst.Type())
 	if err != nil {
 		return err
@@ -2277,7 +2383,7 @@ func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancew
 		}
 	}
 
-	err = b.driver.BackupVolume(*vol, tarWriter, optimized, snapNames, op)
+	err = b.driver.BackupVolume(*vol, tarWriter, optimized, snapNames, incrementalBase, op)
 	if err != nil {
 		return err
 	}
@@ -2305,6 +2411,22 @@ func (b *lxdBackend) GetInstanceUsage(inst instance.Instance) (int64, error) {
 	return b.driver.GetVolumeUsage(vol)
 }
 
+// GetInstanceEncryption returns the encryption status of the instance's root volume.
+// Returns ErrNotSupported if the storage driver can't report it.
+func (b *lxdBackend) GetInstanceEncryption(inst instance.Instance) (*drivers.VolumeEncryption, error) {
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := InstanceContentType(inst)
+
+	volStorageName := project.Instance(inst.Project(), inst.Name())
+	vol := b.GetVolume(volType, contentType, volStorageName, nil)
+
+	return b.driver.GetVolumeEncryption(vol)
+}
+
 // SetInstanceQuota sets the quota on the instance's root volume.
 // Returns ErrInUse if the instance is running and the storage driver doesn't support online resizing.
 func (b *lxdBackend) SetInstanceQuota(inst instance.Instance, size string, vmStateSize string, op *operations.Operation) error {
@@ -2324,6 +2446,12 @@ func (b *lxdBackend) SetInstanceQuota(inst instance.Instance, size string, vmSta
 	// Apply the main volume quota.
 	// There's no need to pass config as it's not needed when setting quotas.
 	vol := b.GetVolume(volType, contentVolume, volStorageName, nil)
+
+	// Running VMs are told about their grown root disk, so allow growing it while in use.
+	if inst.Type() == instancetype.VM && inst.IsRunning() {
+		vol.SetAllowInUseGrow(true)
+	}
+
 	err = b.driver.SetVolumeQuota(vol, size, false, op)
 	if err != nil {
 		return err
@@ -4082,6 +4210,20 @@ func (b *lxdBackend) GetCustomVolumeUsage(projectName, volName string) (int64, e
 	return b.driver.GetVolumeUsage(vol)
 }
 
+// GetCustomVolumeEncryption returns the encryption status of a custom volume.
+// Returns ErrNotSupported if the storage driver can't report it.
+func (b *lxdBackend) GetCustomVolumeEncryption(projectName, volName string) (*drivers.VolumeEncryption, error) {
+	_, volume, err := b.state.DB.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.id)
+	if err != nil {
+		return nil, err
+	}
+
+	volStorageName := project.StorageVolume(projectName, volName)
+	vol := b.GetVolume(drivers.VolumeTypeCustom, drivers.ContentType(volume.ContentType), volStorageName, nil)
+
+	return b.driver.GetVolumeEncryption(vol)
+}
+
 // MountCustomVolume mounts a custom volume.
 func (b *lxdBackend) MountCustomVolume(projectName, volName string, op *operations.Operation) error {
 	l := logger.AddContext(b.logger, logger.Ctx{"project": projectName, "volName": volName})
@@ -4502,7 +4644,8 @@ func (b *lxdBackend) GenerateCustomVolumeBackupConfig(projectName string, volNam
 	}
 
 	config := &backupConfig.Config{
-		Volume: vol,
+		Version: backupConfig.Version,
+		Volume:  vol,
 	}
 
 	if snapshots {
@@ -4561,6 +4704,7 @@ func (b *lxdBackend) GenerateInstanceBackupConfig(inst instance.Instance, snapsh
 	}
 
 	config := &backupConfig.Config{
+		Version:   backupConfig.Version,
 		Container: ci.(*api.Instance),
 		Pool:      &b.db,
 		Volume:    volume,
@@ -5290,7 +5434,7 @@ func (b *lxdBackend) BackupCustomVolume(projectName string, volName string, tarW
 
 	vol := b.GetVolume(drivers.VolumeTypeCustom, drivers.ContentType(volume.ContentType), volStorageName, volume.Config)
 
-	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapNames, op)
+	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapNames, "", op)
 	if err != nil {
 		return err
 	}
@@ -74,6 +74,14 @@ func (b *mockBackend) GetResources() (*api.ResourcesStoragePool, error) {
 	return nil, nil
 }
 
+func (b *mockBackend) GetState() (*api.StoragePoolState, error) {
+	return nil, nil
+}
+
+func (b *mockBackend) Discard(op *operations.Operation) error {
+	return nil
+}
+
 func (b *mockBackend) IsUsed() (bool, error) {
 	return false, nil
 }
@@ -174,7 +182,7 @@ func (b *mockBackend) RefreshInstance(inst instance.Instance, src instance.Insta
 	return nil
 }
 
-func (b *mockBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (b *mockBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, incrementalBase string, op *operations.Operation) error {
 	return nil
 }
 
@@ -182,6 +190,10 @@ func (b *mockBackend) GetInstanceUsage(inst instance.Instance) (int64, error) {
 	return 0, nil
 }
 
+func (b *mockBackend) GetInstanceEncryption(inst instance.Instance) (*drivers.VolumeEncryption, error) {
+	return nil, nil
+}
+
 func (b *mockBackend) SetInstanceQuota(inst instance.Instance, size string, vmStateSize string, op *operations.Operation) error {
 	return nil
 }
@@ -270,6 +282,10 @@ func (b *mockBackend) GetCustomVolumeUsage(projectName string, volName string) (
 	return 0, nil
 }
 
+func (b *mockBackend) GetCustomVolumeEncryption(projectName string, volName string) (*drivers.VolumeEncryption, error) {
+	return nil, nil
+}
+
 func (b *mockBackend) MountCustomVolume(projectName string, volName string, op *operations.Operation) error {
 	return nil
 }
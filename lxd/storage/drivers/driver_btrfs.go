@@ -88,6 +88,7 @@ func (d *btrfs) Info() Info {
 		OptimizedImages:       true,
 		OptimizedBackups:      true,
 		OptimizedBackupHeader: true,
+		IncrementalBackups:    true,
 		PreservesInodes:       !d.state.OS.RunningInUserNS,
 		Remote:                d.isRemote(),
 		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
@@ -408,6 +409,33 @@ func (d *btrfs) GetResources() (*api.ResourcesStoragePool, error) {
 	return genericVFSGetResources(d)
 }
 
+// HealthInfo returns the health of the devices backing the storage pool.
+func (d *btrfs) HealthInfo() (*HealthInfo, error) {
+	out, err := shared.RunCommand("btrfs", "device", "stats", GetPoolMountPath(d.name))
+	if err != nil {
+		return nil, err
+	}
+
+	// Each line is of the form "[/dev/sda].write_io_errs    0".
+	info := HealthInfo{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[1] == "0" {
+			continue
+		}
+
+		info.Degraded = true
+		info.Messages = append(info.Messages, fmt.Sprintf("Device error counter %s is %s", fields[0], fields[1]))
+	}
+
+	return &info, nil
+}
+
+// Discard releases the unused blocks of the pool back to the underlying storage.
+func (d *btrfs) Discard() error {
+	return fstrim(GetPoolMountPath(d.name))
+}
+
 // MigrationType returns the type of transfer methods to be used when doing migrations between pools in preference order.
 func (d *btrfs) MigrationTypes(contentType ContentType, refresh bool) []migration.Type {
 	var rsyncFeatures []string
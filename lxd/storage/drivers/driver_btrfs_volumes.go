@@ -142,7 +142,20 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 		return genericVFSBackupUnpack(d, d.state.OS, vol, srcBackup.Snapshots, srcData, op)
 	}
 
-	if d.HasVolume(vol) {
+	// Incremental backups are restored on top of the existing volume, which must have the base snapshot.
+	snapshots := srcBackup.Snapshots
+	if srcBackup.Incremental != nil {
+		var err error
+		snapshots, err = backup.IncrementalSnapshots(srcBackup.Snapshots, srcBackup.Incremental.Base)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		baseVol, _ := vol.NewSnapshot(srcBackup.Incremental.Base)
+		if !d.HasVolume(baseVol) {
+			return nil, nil, fmt.Errorf("Cannot restore incremental backup, base snapshot %q doesn't exist on target", srcBackup.Incremental.Base)
+		}
+	} else if d.HasVolume(vol) {
 		return nil, nil, fmt.Errorf("Cannot restore volume, already exists on target")
 	}
 
@@ -152,14 +165,20 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 	// Define a revert function that will be used both to revert if an error occurs inside this
 	// function but also return it for use from the calling functions if no error internally.
 	revertHook := func() {
-		for _, snapName := range srcBackup.Snapshots {
+		for _, snapName := range snapshots {
 			fullSnapshotName := GetSnapshotVolumeName(vol.name, snapName)
 			snapVol := NewVolume(d, d.name, vol.volType, vol.contentType, fullSnapshotName, vol.config, vol.poolConfig)
 			_ = d.DeleteVolumeSnapshot(snapVol, op)
 		}
 
-		// And lastly the main volume.
-		_ = d.DeleteVolume(vol, op)
+		// And lastly the main volume, which is recreated from the base snapshot for incremental backups.
+		if srcBackup.Incremental != nil {
+			baseVol, _ := vol.NewSnapshot(srcBackup.Incremental.Base)
+			_ = d.deleteSubvolume(vol.MountPath(), true)
+			_ = d.snapshotSubvolume(baseVol.MountPath(), vol.MountPath(), true)
+		} else {
+			_ = d.DeleteVolume(vol, op)
+		}
 	}
 	// Only execute the revert function if we have had an error internally.
 	revert.Add(revertHook)
@@ -188,7 +207,7 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 	// optimized header file. This approach can only be used to restore root subvolumes (not sub-subvolumes).
 	if optimizedHeader == nil {
 		optimizedHeader = &BTRFSMetaDataHeader{}
-		for _, snapName := range srcBackup.Snapshots {
+		for _, snapName := range snapshots {
 			optimizedHeader.Subvolumes = append(optimizedHeader.Subvolumes, BTRFSSubVolume{
 				Snapshot: snapName,
 				Path:     string(filepath.Separator),
@@ -297,7 +316,7 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 		return nil
 	}
 
-	if len(srcBackup.Snapshots) > 0 {
+	if len(snapshots) > 0 {
 		// Create new snapshots directory.
 		err := createParentSnapshotDirIfMissing(d.name, vol.volType, vol.name)
 		if err != nil {
@@ -305,7 +324,7 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 		}
 
 		// Restore backup snapshots from oldest to newest.
-		for _, snapName := range srcBackup.Snapshots {
+		for _, snapName := range snapshots {
 			snapVol, _ := vol.NewSnapshot(snapName)
 			snapDir := "snapshots"
 			srcFilePrefix := snapName
@@ -343,6 +362,14 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 		return nil, nil, err
 	}
 
+	// Replace the existing main volume now that everything has been received.
+	if srcBackup.Incremental != nil {
+		err = d.deleteSubvolume(vol.MountPath(), true)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	for _, copyOp := range copyOps {
 		err = d.setSubvolumeReadonlyProperty(copyOp.src, false)
 		if err != nil {
@@ -985,8 +1012,8 @@ func (d *btrfs) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool,
 
 		// Move the GPT alt header to end of disk if needed and resize has taken place (not needed in
 		// unsafe resize mode as it is expected the caller will do all necessary post resize actions
-		// themselves).
-		if vol.IsVMBlock() && resized && !allowUnsafeResize {
+		// themselves, nor when grown while in use as the running VM takes care of it).
+		if vol.IsVMBlock() && resized && !allowUnsafeResize && !vol.MountInUse() {
 			err = d.moveGPTAltHeader(rootBlockPath)
 			if err != nil {
 				return err
@@ -1414,7 +1441,7 @@ func (d *btrfs) migrateVolumeOptimized(vol Volume, conn io.ReadWriteCloser, volS
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, op *operations.Operation) error {
+func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, incrementalBase string, op *operations.Operation) error {
 	// Handle the non-optimized tarballs through the generic packer.
 	if !optimized {
 		// Because the generic backup method will not take a consistent backup if files are being modified
@@ -1446,6 +1473,20 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 		}
 	}
 
+	// Incremental backups only contain the snapshots newer than the base, the first one being sent relative
+	// to the base snapshot.
+	lastVolPath := "" // Used as parent for differential exports.
+	if incrementalBase != "" {
+		var err error
+		snapshots, err = backup.IncrementalSnapshots(snapshots, incrementalBase)
+		if err != nil {
+			return err
+		}
+
+		baseVol, _ := vol.NewSnapshot(incrementalBase)
+		lastVolPath = baseVol.MountPath()
+	}
+
 	// Generate driver restoration header.
 	optimizedHeader, err := d.restorationHeader(vol, snapshots)
 	if err != nil {
@@ -1582,7 +1623,6 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 	}
 
 	// Backup snapshots if populated.
-	lastVolPath := "" // Used as parent for differential exports.
 	for _, snapName := range snapshots {
 		snapVol, _ := vol.NewSnapshot(snapName)
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strings"
 
 	"github.com/lxc/lxd/lxd/migration"
@@ -286,6 +287,11 @@ func (d *ceph) Unmount() (bool, error) {
 	return true, nil
 }
 
+// Discard releases the unused blocks of the mounted volumes back to the underlying storage.
+func (d *ceph) Discard() error {
+	return discardMountedVolumes(d)
+}
+
 // GetResources returns the pool resource usage information.
 func (d *ceph) GetResources() (*api.ResourcesStoragePool, error) {
 	var stdout bytes.Buffer
@@ -344,6 +350,57 @@ func (d *ceph) GetResources() (*api.ResourcesStoragePool, error) {
 	return &res, nil
 }
 
+// HealthInfo returns the health of the Ceph cluster backing the storage pool.
+func (d *ceph) HealthInfo() (*HealthInfo, error) {
+	var stdout bytes.Buffer
+
+	err := shared.RunCommandWithFds(nil, &stdout,
+		"ceph",
+		"--name", fmt.Sprintf("client.%s", d.config["ceph.user.name"]),
+		"--cluster", d.config["ceph.cluster_name"],
+		"health",
+		"-f", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	// Temporary structs for parsing.
+	type cephHealthCheck struct {
+		Severity string `json:"severity"`
+		Summary  struct {
+			Message string `json:"message"`
+		} `json:"summary"`
+	}
+
+	type cephHealth struct {
+		Status string                     `json:"status"`
+		Checks map[string]cephHealthCheck `json:"checks"`
+	}
+
+	// Parse the JSON output.
+	health := cephHealth{}
+	err = json.NewDecoder(&stdout).Decode(&health)
+	if err != nil {
+		return nil, err
+	}
+
+	info := HealthInfo{}
+	if health.Status == "HEALTH_OK" {
+		return &info, nil
+	}
+
+	info.Degraded = true
+	info.Messages = append(info.Messages, fmt.Sprintf("Ceph cluster %q is in state %s", d.config["ceph.cluster_name"], health.Status))
+	for name, check := range health.Checks {
+		info.Messages = append(info.Messages, fmt.Sprintf("%s (%s): %s", name, check.Severity, check.Summary.Message))
+	}
+
+	// Keep the health checks in a stable order.
+	sort.Strings(info.Messages[1:])
+
+	return &info, nil
+}
+
 // MigrationType returns the type of transfer methods to be used when doing migrations between pools in preference order.
 func (d *ceph) MigrationTypes(contentType ContentType, refresh bool) []migration.Type {
 	var rsyncFeatures []string
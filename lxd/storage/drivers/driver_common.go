@@ -68,6 +68,16 @@ func (d *common) validatePool(config map[string]string, driverRules map[string]f
 			continue
 		}
 
+		// Volume preset keys are validated when the preset is applied to a volume.
+		if strings.HasPrefix(k, "volume.preset.") {
+			fields := strings.SplitN(k, ".", 4)
+			if len(fields) != 4 || fields[2] == "" || fields[3] == "" {
+				return fmt.Errorf("Invalid volume preset option %q, expected volume.preset.NAME.KEY", k)
+			}
+
+			continue
+		}
+
 		return fmt.Errorf("Invalid option %q", k)
 	}
 
@@ -130,6 +140,23 @@ func (d *common) validateVolume(vol Volume, driverRules map[string]func(value st
 	return nil
 }
 
+// HealthInfo returns the health of the storage backing the pool.
+// Drivers that can't detect degraded storage return ErrNotSupported.
+func (d *common) HealthInfo() (*HealthInfo, error) {
+	return nil, ErrNotSupported
+}
+
+// GetVolumeEncryption returns the encryption status of a volume.
+// Drivers that can't detect encryption return ErrNotSupported.
+func (d *common) GetVolumeEncryption(vol Volume) (*VolumeEncryption, error) {
+	return nil, ErrNotSupported
+}
+
+// Discard releases the unused blocks of the pool back to the underlying storage.
+func (d *common) Discard() error {
+	return ErrNotSupported
+}
+
 // MigrationType returns the type of transfer methods to be used when doing migrations between pools
 // in preference order.
 func (d *common) MigrationTypes(contentType ContentType, refresh bool) []migration.Type {
@@ -139,6 +139,11 @@ func (d *dir) Unmount() (bool, error) {
 	return forceUnmount(path)
 }
 
+// Discard releases the unused blocks of the pool back to the underlying storage.
+func (d *dir) Discard() error {
+	return fstrim(GetPoolMountPath(d.name))
+}
+
 // GetResources returns the pool resource usage information.
 func (d *dir) GetResources() (*api.ResourcesStoragePool, error) {
 	return genericVFSGetResources(d)
@@ -0,0 +1,254 @@
+package drivers
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+var linstorAllowedFilesystems = []string{"btrfs", "ext4", "xfs"}
+var linstorVersion string
+var linstorLoaded bool
+
+type linstor struct {
+	common
+}
+
+// load is used to run one-time action per-driver rather than per-pool.
+func (d *linstor) load() error {
+	// Register the patches.
+	d.patches = map[string]func() error{
+		"storage_lvm_skipactivation": nil,
+	}
+
+	// Done if previously loaded.
+	if linstorLoaded {
+		return nil
+	}
+
+	// Validate the required binaries.
+	_, err := exec.LookPath("drbdadm")
+	if err != nil {
+		return fmt.Errorf("Required tool 'drbdadm' is missing")
+	}
+
+	// Detect and record the version of the DRBD kernel module.
+	if linstorVersion == "" {
+		out, err := shared.RunCommand("drbdadm", "--version")
+		if err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.SplitN(strings.TrimSpace(line), "=", 2)
+			if len(fields) == 2 && fields[0] == "DRBD_KERNEL_VERSION" {
+				linstorVersion = fields[1]
+				break
+			}
+		}
+
+		if linstorVersion == "" {
+			return fmt.Errorf("The DRBD kernel module isn't loaded")
+		}
+	}
+
+	linstorLoaded = true
+	return nil
+}
+
+// isRemote returns true indicating this driver uses remote storage.
+func (d *linstor) isRemote() bool {
+	return true
+}
+
+// Info returns info about the driver and its environment.
+func (d *linstor) Info() Info {
+	return Info{
+		Name:              "linstor",
+		Version:           linstorVersion,
+		OptimizedImages:   false,
+		PreservesInodes:   false,
+		Remote:            d.isRemote(),
+		VolumeTypes:       []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		BlockBacking:      true,
+		RunningCopyFreeze: true,
+		DirectIO:          true,
+		MountedRoot:       false,
+	}
+}
+
+// Create is called during pool creation and is effectively using an empty driver struct.
+// WARNING: The Create() function cannot rely on any of the struct attributes being set.
+func (d *linstor) Create() error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Set default properties if missing.
+	if d.config["linstor.controller_connection"] == "" {
+		d.config["linstor.controller_connection"] = LinstorDefaultControllerConnection
+	}
+
+	if d.config["linstor.resource_group.name"] == "" {
+		d.config["linstor.resource_group.name"] = d.name
+	}
+
+	if d.config["linstor.resource_group.place_count"] == "" {
+		d.config["linstor.resource_group.place_count"] = linstorDefaultPlaceCount
+	}
+
+	exists, err := d.resourceGroupExists()
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		err = d.createResourceGroup()
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() { _ = d.deleteResourceGroup() })
+
+		d.config["volatile.pool.pristine"] = "true"
+	} else {
+		// Check that no other LXD storage pool is using the resource group.
+		rds := []linstorResourceDefinition{}
+		err = d.linstorRequest("GET", "/v1/resource-definitions", nil, &rds)
+		if err != nil {
+			return fmt.Errorf("Failed listing LINSTOR resource definitions: %w", err)
+		}
+
+		for _, rd := range rds {
+			if rd.ResourceGroupName == d.resourceGroupName() && rd.Props[linstorPropPool] != "" && rd.Props[linstorPropPool] != d.name {
+				return fmt.Errorf("LINSTOR resource group %q is in use by LXD storage pool %q", d.resourceGroupName(), rd.Props[linstorPropPool])
+			}
+		}
+
+		// Apply the requested placement to the existing resource group.
+		err = d.updateResourceGroup()
+		if err != nil {
+			return err
+		}
+
+		d.config["volatile.pool.pristine"] = "false"
+	}
+
+	revert.Success()
+	return nil
+}
+
+// Delete removes the storage pool from the storage device.
+func (d *linstor) Delete(op *operations.Operation) error {
+	// Check whether we own the resource group and only remove in this case.
+	if shared.IsTrue(d.config["volatile.pool.pristine"]) {
+		err := d.deleteResourceGroup()
+		if err != nil {
+			return err
+		}
+	}
+
+	// If the user completely destroyed it, call it done.
+	if !shared.PathExists(GetPoolMountPath(d.name)) {
+		return nil
+	}
+
+	// On delete, wipe everything in the directory.
+	err := wipeDirectory(GetPoolMountPath(d.name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks that all provide keys are supported and that no conflicting or missing configuration is present.
+func (d *linstor) Validate(config map[string]string) error {
+	rules := map[string]func(value string) error{
+		"linstor.controller_connection": validate.Optional(func(value string) error {
+			u, err := url.Parse(value)
+			if err != nil {
+				return err
+			}
+
+			if !shared.StringInSlice(u.Scheme, []string{"http", "https"}) || u.Host == "" {
+				return fmt.Errorf("Expected an http or https URL")
+			}
+
+			return nil
+		}),
+		"linstor.resource_group.name":         validate.IsAny,
+		"linstor.resource_group.place_count":  validate.Optional(validate.IsInRange(1, 16)),
+		"linstor.resource_group.storage_pool": validate.IsAny,
+		"volatile.pool.pristine":              validate.IsAny,
+		"volume.block.filesystem":             validate.Optional(validate.IsOneOf(linstorAllowedFilesystems...)),
+		"volume.block.mount_options":          validate.IsAny,
+	}
+
+	return d.validatePool(config, rules)
+}
+
+// Update applies any driver changes required from a configuration change.
+func (d *linstor) Update(changedConfig map[string]string) error {
+	_, changed := changedConfig["linstor.resource_group.name"]
+	if changed {
+		return fmt.Errorf("linstor.resource_group.name cannot be changed")
+	}
+
+	_, placeCountChanged := changedConfig["linstor.resource_group.place_count"]
+	_, storagePoolChanged := changedConfig["linstor.resource_group.storage_pool"]
+	if placeCountChanged || storagePoolChanged {
+		return d.updateResourceGroup()
+	}
+
+	return nil
+}
+
+// Mount mounts the storage pool.
+func (d *linstor) Mount() (bool, error) {
+	// Nothing to do here.
+	return true, nil
+}
+
+// Unmount unmounts the storage pool.
+func (d *linstor) Unmount() (bool, error) {
+	// Nothing to do here.
+	return true, nil
+}
+
+// Discard releases the unused blocks of the mounted volumes back to the underlying storage.
+func (d *linstor) Discard() error {
+	return discardMountedVolumes(d)
+}
+
+// GetResources returns the pool resource usage information.
+func (d *linstor) GetResources() (*api.ResourcesStoragePool, error) {
+	pools, err := d.storagePools()
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := d.selectFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	var total, free uint64
+	for _, pool := range pools {
+		total += uint64(pool.TotalCapacity) * 1024
+		free += uint64(pool.FreeCapacity) * 1024
+	}
+
+	// Every volume is stored on place_count servers.
+	res := api.ResourcesStoragePool{}
+	res.Space.Total = total / uint64(filter.PlaceCount)
+	res.Space.Used = (total - free) / uint64(filter.PlaceCount)
+
+	return &res, nil
+}
@@ -0,0 +1,612 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// LinstorDefaultControllerConnection represents the default LINSTOR controller REST API address.
+const LinstorDefaultControllerConnection = "http://localhost:3370"
+
+// linstorDefaultPlaceCount represents the default number of replicas of each volume.
+const linstorDefaultPlaceCount = "2"
+
+// linstorRequestTimeout is the maximum time a single request to the LINSTOR controller may take.
+const linstorRequestTimeout = 5 * time.Minute
+
+// LINSTOR resource definitions are given generated names, the LXD volume they hold is recorded in these
+// auxiliary properties.
+const linstorPropPool = "Aux/Lxd/pool"
+const linstorPropType = "Aux/Lxd/type"
+const linstorPropContentType = "Aux/Lxd/content"
+const linstorPropName = "Aux/Lxd/name"
+
+// linstorPropSnapshotPrefix is the prefix of the resource definition properties mapping LINSTOR snapshot
+// names to LXD snapshot names.
+const linstorPropSnapshotPrefix = "Aux/Lxd/snapshot/"
+
+// linstorAPICallRc represents a return code entry of the LINSTOR REST API.
+type linstorAPICallRc struct {
+	RetCode int64  `json:"ret_code"`
+	Message string `json:"message"`
+	Cause   string `json:"cause"`
+}
+
+// linstorResourceDefinition represents a LINSTOR resource definition.
+type linstorResourceDefinition struct {
+	Name              string            `json:"name"`
+	ResourceGroupName string            `json:"resource_group_name"`
+	Props             map[string]string `json:"props"`
+}
+
+// linstorResource represents a LINSTOR resource deployed on a node.
+type linstorResource struct {
+	Name     string   `json:"name"`
+	NodeName string   `json:"node_name"`
+	Flags    []string `json:"flags"`
+}
+
+// linstorVolume represents a LINSTOR volume deployed on a node.
+type linstorVolume struct {
+	VolumeNumber int    `json:"volume_number"`
+	DevicePath   string `json:"device_path"`
+}
+
+// linstorSelectFilter represents the autoplacer settings of a resource group.
+type linstorSelectFilter struct {
+	PlaceCount      int      `json:"place_count,omitempty"`
+	StoragePoolList []string `json:"storage_pool_list,omitempty"`
+}
+
+// linstorResourceGroup represents a LINSTOR resource group.
+type linstorResourceGroup struct {
+	Name         string              `json:"name"`
+	Description  string              `json:"description,omitempty"`
+	SelectFilter linstorSelectFilter `json:"select_filter"`
+}
+
+// linstorStoragePool represents a LINSTOR storage pool on a node.
+type linstorStoragePool struct {
+	StoragePoolName string `json:"storage_pool_name"`
+	NodeName        string `json:"node_name"`
+	ProviderKind    string `json:"provider_kind"`
+	FreeCapacity    int64  `json:"free_capacity"`
+	TotalCapacity   int64  `json:"total_capacity"`
+}
+
+var linstorHTTPClient = &http.Client{}
+
+// linstorRequest performs a request against the LINSTOR controller REST API.
+// If resp is not nil, the JSON response body is decoded into it.
+func (d *linstor) linstorRequest(method string, path string, req any, resp any) error {
+	var body io.Reader
+	if req != nil {
+		buf, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(buf)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), linstorRequestTimeout)
+	defer cancel()
+
+	controller := strings.TrimSuffix(d.config["linstor.controller_connection"], "/")
+	if controller == "" {
+		controller = LinstorDefaultControllerConnection
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, controller+path, body)
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Accept", "application/json")
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := linstorHTTPClient.Do(r)
+	if err != nil {
+		return fmt.Errorf("Failed contacting LINSTOR controller: %w", err)
+	}
+
+	defer func() { _ = res.Body.Close() }()
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("Failed reading LINSTOR controller response: %w", err)
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		rcs := []linstorAPICallRc{}
+		_ = json.Unmarshal(content, &rcs)
+
+		msgs := make([]string, 0, len(rcs))
+		for _, rc := range rcs {
+			if rc.RetCode >= 0 {
+				continue
+			}
+
+			msg := rc.Message
+			if rc.Cause != "" {
+				msg = fmt.Sprintf("%s (%s)", msg, rc.Cause)
+			}
+
+			msgs = append(msgs, msg)
+		}
+
+		if len(msgs) == 0 {
+			msgs = append(msgs, http.StatusText(res.StatusCode))
+		}
+
+		return api.StatusErrorf(res.StatusCode, "LINSTOR %s %q failed: %s", method, path, strings.Join(msgs, ", "))
+	}
+
+	if resp != nil && len(content) > 0 {
+		err = json.Unmarshal(content, resp)
+		if err != nil {
+			return fmt.Errorf("Failed decoding LINSTOR controller response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// resourceGroupName returns the name of the LINSTOR resource group used by the pool.
+func (d *linstor) resourceGroupName() string {
+	if d.config["linstor.resource_group.name"] != "" {
+		return d.config["linstor.resource_group.name"]
+	}
+
+	return d.name
+}
+
+// selectFilter returns the autoplacer settings derived from the pool config.
+func (d *linstor) selectFilter() (linstorSelectFilter, error) {
+	filter := linstorSelectFilter{}
+
+	placeCount := d.config["linstor.resource_group.place_count"]
+	if placeCount == "" {
+		placeCount = linstorDefaultPlaceCount
+	}
+
+	_, err := fmt.Sscanf(placeCount, "%d", &filter.PlaceCount)
+	if err != nil {
+		return filter, fmt.Errorf("Invalid place count %q: %w", placeCount, err)
+	}
+
+	if d.config["linstor.resource_group.storage_pool"] != "" {
+		filter.StoragePoolList = []string{d.config["linstor.resource_group.storage_pool"]}
+	}
+
+	return filter, nil
+}
+
+// resourceGroupExists returns whether the pool's resource group exists.
+func (d *linstor) resourceGroupExists() (bool, error) {
+	err := d.linstorRequest("GET", fmt.Sprintf("/v1/resource-groups/%s", url.PathEscape(d.resourceGroupName())), nil, nil)
+	if err != nil {
+		if api.StatusErrorCheck(err, http.StatusNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// createResourceGroup creates the pool's resource group along with its single volume group.
+func (d *linstor) createResourceGroup() error {
+	filter, err := d.selectFilter()
+	if err != nil {
+		return err
+	}
+
+	rg := linstorResourceGroup{
+		Name:         d.resourceGroupName(),
+		Description:  fmt.Sprintf("LXD storage pool %q", d.name),
+		SelectFilter: filter,
+	}
+
+	err = d.linstorRequest("POST", "/v1/resource-groups", rg, nil)
+	if err != nil {
+		return fmt.Errorf("Failed creating LINSTOR resource group %q: %w", rg.Name, err)
+	}
+
+	err = d.linstorRequest("POST", fmt.Sprintf("/v1/resource-groups/%s/volume-groups", url.PathEscape(rg.Name)), map[string]any{}, nil)
+	if err != nil {
+		_ = d.deleteResourceGroup()
+		return fmt.Errorf("Failed creating LINSTOR volume group in %q: %w", rg.Name, err)
+	}
+
+	return nil
+}
+
+// updateResourceGroup applies the autoplacer settings from the pool config to the resource group.
+func (d *linstor) updateResourceGroup() error {
+	filter, err := d.selectFilter()
+	if err != nil {
+		return err
+	}
+
+	req := map[string]any{"select_filter": filter}
+
+	err = d.linstorRequest("PUT", fmt.Sprintf("/v1/resource-groups/%s", url.PathEscape(d.resourceGroupName())), req, nil)
+	if err != nil {
+		return fmt.Errorf("Failed updating LINSTOR resource group %q: %w", d.resourceGroupName(), err)
+	}
+
+	return nil
+}
+
+// deleteResourceGroup deletes the pool's resource group.
+func (d *linstor) deleteResourceGroup() error {
+	err := d.linstorRequest("DELETE", fmt.Sprintf("/v1/resource-groups/%s", url.PathEscape(d.resourceGroupName())), nil, nil)
+	if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return fmt.Errorf("Failed deleting LINSTOR resource group %q: %w", d.resourceGroupName(), err)
+	}
+
+	return nil
+}
+
+// nodeName returns the name of the LINSTOR satellite running on this server.
+func (d *linstor) nodeName() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("Failed getting hostname: %w", err)
+	}
+
+	return hostname, nil
+}
+
+// resourceDefinitions returns the resource definitions belonging to the pool.
+func (d *linstor) resourceDefinitions() ([]linstorResourceDefinition, error) {
+	rds := []linstorResourceDefinition{}
+
+	err := d.linstorRequest("GET", "/v1/resource-definitions", nil, &rds)
+	if err != nil {
+		return nil, fmt.Errorf("Failed listing LINSTOR resource definitions: %w", err)
+	}
+
+	poolRDs := make([]linstorResourceDefinition, 0, len(rds))
+	for _, rd := range rds {
+		if rd.ResourceGroupName != d.resourceGroupName() || rd.Props[linstorPropPool] != d.name {
+			continue
+		}
+
+		poolRDs = append(poolRDs, rd)
+	}
+
+	return poolRDs, nil
+}
+
+// getResourceDefinition returns the resource definition holding the volume or nil if it doesn't exist.
+func (d *linstor) getResourceDefinition(vol Volume) (*linstorResourceDefinition, error) {
+	rds, err := d.resourceDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rd := range rds {
+		if rd.Props[linstorPropType] == string(vol.volType) && rd.Props[linstorPropContentType] == string(vol.contentType) && rd.Props[linstorPropName] == vol.name {
+			return &rd, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// loadResourceDefinition returns the resource definition holding the volume and fails if it doesn't exist.
+func (d *linstor) loadResourceDefinition(vol Volume) (*linstorResourceDefinition, error) {
+	rd, err := d.getResourceDefinition(vol)
+	if err != nil {
+		return nil, err
+	}
+
+	if rd == nil {
+		return nil, fmt.Errorf("LINSTOR resource definition for volume %q not found", vol.name)
+	}
+
+	return rd, nil
+}
+
+// volumeProps returns the resource definition properties identifying the volume.
+func (d *linstor) volumeProps(vol Volume) map[string]string {
+	return map[string]string{
+		linstorPropPool:        d.name,
+		linstorPropType:        string(vol.volType),
+		linstorPropContentType: string(vol.contentType),
+		linstorPropName:        vol.name,
+	}
+}
+
+// setResourceDefinitionProps sets and deletes properties on a resource definition.
+func (d *linstor) setResourceDefinitionProps(rdName string, props map[string]string, deleteProps []string) error {
+	req := map[string]any{
+		"override_props": props,
+		"delete_props":   deleteProps,
+	}
+
+	err := d.linstorRequest("PUT", fmt.Sprintf("/v1/resource-definitions/%s", url.PathEscape(rdName)), req, nil)
+	if err != nil {
+		return fmt.Errorf("Failed updating LINSTOR resource definition %q: %w", rdName, err)
+	}
+
+	return nil
+}
+
+// newResourceDefinitionName returns a new unique resource definition name.
+func (d *linstor) newResourceDefinitionName() string {
+	return fmt.Sprintf("lxd-%s", uuid.New())
+}
+
+// createResourceDefinition spawns a new resource definition from the pool's resource group and records the
+// volume it holds. Returns the name of the new resource definition.
+func (d *linstor) createResourceDefinition(vol Volume, sizeBytes int64) (string, error) {
+	rdName := d.newResourceDefinitionName()
+
+	req := map[string]any{
+		"resource_definition_name": rdName,
+		"volume_sizes":             []int64{d.sizeKiB(sizeBytes)},
+	}
+
+	err := d.linstorRequest("POST", fmt.Sprintf("/v1/resource-groups/%s/spawn", url.PathEscape(d.resourceGroupName())), req, nil)
+	if err != nil {
+		return "", fmt.Errorf("Failed spawning LINSTOR resource definition: %w", err)
+	}
+
+	err = d.setResourceDefinitionProps(rdName, d.volumeProps(vol), nil)
+	if err != nil {
+		_ = d.deleteResourceDefinition(rdName)
+		return "", err
+	}
+
+	d.logger.Debug("LINSTOR resource definition created", logger.Ctx{"rd": rdName, "volName": vol.name, "size": fmt.Sprintf("%db", sizeBytes)})
+
+	return rdName, nil
+}
+
+// deleteResourceDefinition deletes a resource definition along with all its resources.
+func (d *linstor) deleteResourceDefinition(rdName string) error {
+	err := d.linstorRequest("DELETE", fmt.Sprintf("/v1/resource-definitions/%s", url.PathEscape(rdName)), nil, nil)
+	if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return fmt.Errorf("Failed deleting LINSTOR resource definition %q: %w", rdName, err)
+	}
+
+	return nil
+}
+
+// removeDisklessResource removes the resource from this server if it is only attached without local disk.
+func (d *linstor) removeDisklessResource(rdName string) error {
+	node, err := d.nodeName()
+	if err != nil {
+		return err
+	}
+
+	resources := []linstorResource{}
+	err = d.linstorRequest("GET", fmt.Sprintf("/v1/resource-definitions/%s/resources", url.PathEscape(rdName)), nil, &resources)
+	if err != nil {
+		return fmt.Errorf("Failed listing LINSTOR resources of %q: %w", rdName, err)
+	}
+
+	for _, resource := range resources {
+		if resource.NodeName != node {
+			continue
+		}
+
+		if !shared.StringInSlice("DISKLESS", resource.Flags) && !shared.StringInSlice("DRBD_DISKLESS", resource.Flags) {
+			return nil
+		}
+
+		err = d.linstorRequest("DELETE", fmt.Sprintf("/v1/resource-definitions/%s/resources/%s", url.PathEscape(rdName), url.PathEscape(node)), nil, nil)
+		if err != nil {
+			return fmt.Errorf("Failed removing diskless LINSTOR resource %q: %w", rdName, err)
+		}
+	}
+
+	return nil
+}
+
+// resourceDevPath makes the resource available on this server (attaching it without local disk if needed)
+// and returns the path of its DRBD device.
+func (d *linstor) resourceDevPath(rdName string) (string, error) {
+	node, err := d.nodeName()
+	if err != nil {
+		return "", err
+	}
+
+	err = d.linstorRequest("POST", fmt.Sprintf("/v1/resource-definitions/%s/resources/%s/make-available", url.PathEscape(rdName), url.PathEscape(node)), map[string]any{"diskful": false}, nil)
+	if err != nil {
+		return "", fmt.Errorf("Failed making LINSTOR resource %q available on %q: %w", rdName, node, err)
+	}
+
+	volumes := []linstorVolume{}
+	err = d.linstorRequest("GET", fmt.Sprintf("/v1/resource-definitions/%s/resources/%s/volumes", url.PathEscape(rdName), url.PathEscape(node)), nil, &volumes)
+	if err != nil {
+		return "", fmt.Errorf("Failed getting LINSTOR volumes of %q: %w", rdName, err)
+	}
+
+	for _, volume := range volumes {
+		if volume.VolumeNumber != 0 || volume.DevicePath == "" {
+			continue
+		}
+
+		if !tryExists(volume.DevicePath) {
+			return "", fmt.Errorf("DRBD device %q of LINSTOR resource %q did not appear", volume.DevicePath, rdName)
+		}
+
+		return volume.DevicePath, nil
+	}
+
+	return "", fmt.Errorf("LINSTOR resource %q has no device on %q", rdName, node)
+}
+
+// volumeDevPath returns the path of the DRBD device holding the volume.
+func (d *linstor) volumeDevPath(vol Volume) (string, error) {
+	rd, err := d.loadResourceDefinition(vol)
+	if err != nil {
+		return "", err
+	}
+
+	return d.resourceDevPath(rd.Name)
+}
+
+// volumeSizeBytes returns the size of the resource definition's volume.
+func (d *linstor) volumeSizeBytes(rdName string) (int64, error) {
+	vd := struct {
+		SizeKiB int64 `json:"size_kib"`
+	}{}
+
+	err := d.linstorRequest("GET", fmt.Sprintf("/v1/resource-definitions/%s/volume-definitions/0", url.PathEscape(rdName)), nil, &vd)
+	if err != nil {
+		return -1, fmt.Errorf("Failed getting LINSTOR volume definition of %q: %w", rdName, err)
+	}
+
+	return vd.SizeKiB * 1024, nil
+}
+
+// resizeVolume grows the resource definition's volume to the given size.
+func (d *linstor) resizeVolume(rdName string, sizeBytes int64) error {
+	err := d.linstorRequest("PUT", fmt.Sprintf("/v1/resource-definitions/%s/volume-definitions/0", url.PathEscape(rdName)), map[string]any{"size_kib": d.sizeKiB(sizeBytes)}, nil)
+	if err != nil {
+		return fmt.Errorf("Failed resizing LINSTOR volume definition of %q: %w", rdName, err)
+	}
+
+	return nil
+}
+
+// sizeKiB converts a size in bytes to KiB, rounding up.
+func (d *linstor) sizeKiB(sizeBytes int64) int64 {
+	return (sizeBytes + 1023) / 1024
+}
+
+// snapshotName returns the LINSTOR snapshot name of an LXD snapshot or an empty string if it doesn't exist.
+func (d *linstor) snapshotName(rd *linstorResourceDefinition, snapName string) string {
+	for key, value := range rd.Props {
+		if strings.HasPrefix(key, linstorPropSnapshotPrefix) && value == snapName {
+			return strings.TrimPrefix(key, linstorPropSnapshotPrefix)
+		}
+	}
+
+	return ""
+}
+
+// createSnapshot takes a LINSTOR snapshot of the resource definition and records its LXD name.
+// Returns the name of the LINSTOR snapshot.
+func (d *linstor) createSnapshot(rdName string, snapName string) (string, error) {
+	linstorSnapName := fmt.Sprintf("snap-%s", uuid.New())
+
+	err := d.linstorRequest("POST", fmt.Sprintf("/v1/resource-definitions/%s/snapshots", url.PathEscape(rdName)), map[string]any{"name": linstorSnapName}, nil)
+	if err != nil {
+		return "", fmt.Errorf("Failed creating LINSTOR snapshot of %q: %w", rdName, err)
+	}
+
+	err = d.setResourceDefinitionProps(rdName, map[string]string{linstorPropSnapshotPrefix + linstorSnapName: snapName}, nil)
+	if err != nil {
+		_ = d.deleteSnapshot(rdName, linstorSnapName)
+		return "", err
+	}
+
+	return linstorSnapName, nil
+}
+
+// deleteSnapshot deletes a LINSTOR snapshot and the record of its LXD name.
+func (d *linstor) deleteSnapshot(rdName string, linstorSnapName string) error {
+	err := d.linstorRequest("DELETE", fmt.Sprintf("/v1/resource-definitions/%s/snapshots/%s", url.PathEscape(rdName), url.PathEscape(linstorSnapName)), nil, nil)
+	if err != nil && !api.StatusErrorCheck(err, http.StatusNotFound) {
+		return fmt.Errorf("Failed deleting LINSTOR snapshot %q of %q: %w", linstorSnapName, rdName, err)
+	}
+
+	return d.setResourceDefinitionProps(rdName, nil, []string{linstorPropSnapshotPrefix + linstorSnapName})
+}
+
+// restoreSnapshot restores a LINSTOR snapshot into a new resource definition holding the given volume.
+// Returns the name of the new resource definition.
+func (d *linstor) restoreSnapshot(rdName string, linstorSnapName string, vol Volume) (string, error) {
+	newRDName := d.newResourceDefinitionName()
+
+	rd := map[string]any{
+		"resource_definition": linstorResourceDefinition{
+			Name:              newRDName,
+			ResourceGroupName: d.resourceGroupName(),
+			Props:             d.volumeProps(vol),
+		},
+	}
+
+	err := d.linstorRequest("POST", "/v1/resource-definitions", rd, nil)
+	if err != nil {
+		return "", fmt.Errorf("Failed creating LINSTOR resource definition: %w", err)
+	}
+
+	req := map[string]any{"to_resource": newRDName}
+
+	err = d.linstorRequest("POST", fmt.Sprintf("/v1/resource-definitions/%s/snapshot-restore-volume-definition/%s", url.PathEscape(rdName), url.PathEscape(linstorSnapName)), req, nil)
+	if err == nil {
+		err = d.linstorRequest("POST", fmt.Sprintf("/v1/resource-definitions/%s/snapshot-restore-resource/%s", url.PathEscape(rdName), url.PathEscape(linstorSnapName)), req, nil)
+	}
+
+	if err != nil {
+		_ = d.deleteResourceDefinition(newRDName)
+		return "", fmt.Errorf("Failed restoring LINSTOR snapshot %q of %q: %w", linstorSnapName, rdName, err)
+	}
+
+	return newRDName, nil
+}
+
+// rollbackSnapshot reverts the resource definition to the LINSTOR snapshot.
+func (d *linstor) rollbackSnapshot(rdName string, linstorSnapName string) error {
+	err := d.linstorRequest("POST", fmt.Sprintf("/v1/resource-definitions/%s/snapshot-rollback/%s", url.PathEscape(rdName), url.PathEscape(linstorSnapName)), nil, nil)
+	if err != nil {
+		return fmt.Errorf("Failed rolling back LINSTOR resource definition %q to snapshot %q: %w", rdName, linstorSnapName, err)
+	}
+
+	return nil
+}
+
+// tmpSnapshotVolume returns the volume used to hold a temporary writable copy of the snapshot.
+func (d *linstor) tmpSnapshotVolume(snapVol Volume) Volume {
+	tmpVolName := fmt.Sprintf("%s%s", snapVol.name, tmpVolSuffix)
+	return NewVolume(d, d.name, snapVol.volType, snapVol.contentType, tmpVolName, snapVol.config, snapVol.poolConfig)
+}
+
+// storagePools returns the LINSTOR storage pools that can hold replicas of the pool's volumes.
+func (d *linstor) storagePools() ([]linstorStoragePool, error) {
+	pools := []linstorStoragePool{}
+
+	err := d.linstorRequest("GET", "/v1/view/storage-pools", nil, &pools)
+	if err != nil {
+		return nil, fmt.Errorf("Failed listing LINSTOR storage pools: %w", err)
+	}
+
+	result := make([]linstorStoragePool, 0, len(pools))
+	for _, pool := range pools {
+		if pool.ProviderKind == "DISKLESS" {
+			continue
+		}
+
+		if d.config["linstor.resource_group.storage_pool"] != "" && pool.StoragePoolName != d.config["linstor.resource_group.storage_pool"] {
+			continue
+		}
+
+		result = append(result, pool)
+	}
+
+	return result, nil
+}
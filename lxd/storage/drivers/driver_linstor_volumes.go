@@ -0,0 +1,977 @@
+package drivers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/lxd/backup"
+	"github.com/lxc/lxd/lxd/migration"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/storage/filesystem"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/instancewriter"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/units"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// CreateVolume creates an empty volume and can optionally fill it by executing the supplied filler function.
+func (d *linstor) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Operation) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	sizeBytes, err := units.ParseByteSizeString(vol.ConfigSize())
+	if err != nil {
+		return err
+	}
+
+	rdName, err := d.createResourceDefinition(vol, sizeBytes)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = d.deleteResourceDefinition(rdName) })
+
+	if vol.contentType == ContentTypeFS {
+		devPath, err := d.resourceDevPath(rdName)
+		if err != nil {
+			return err
+		}
+
+		_, err = makeFSType(devPath, vol.ConfigBlockFilesystem(), nil)
+		if err != nil {
+			return fmt.Errorf("Error making filesystem on LINSTOR volume: %w", err)
+		}
+	}
+
+	volPath := vol.MountPath()
+	err = vol.EnsureMountPath()
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = os.RemoveAll(volPath) })
+
+	// For VMs, also create the filesystem volume.
+	if vol.IsVMBlock() {
+		fsVol := vol.NewVMBlockFilesystemVolume()
+		err := d.CreateVolume(fsVol, nil, op)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() { _ = d.DeleteVolume(fsVol, op) })
+	}
+
+	err = vol.MountTask(func(mountPath string, op *operations.Operation) error {
+		// Run the volume filler function if supplied.
+		if filler != nil && filler.Fill != nil {
+			var err error
+			var devPath string
+
+			if vol.contentType == ContentTypeBlock {
+				// Get the device path.
+				devPath, err = d.GetVolumeDiskPath(vol)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Allow filler to resize the initial volume as needed. This is safe because if for some
+			// reason an error occurs the volume will be discarded rather than leaving a corrupt
+			// filesystem.
+			err = d.runFiller(vol, devPath, filler, true)
+			if err != nil {
+				return err
+			}
+
+			// Move the GPT alt header to end of disk if needed.
+			if vol.IsVMBlock() {
+				err = d.moveGPTAltHeader(devPath)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if vol.contentType == ContentTypeFS {
+			// Run EnsureMountPath again after mounting and filling to ensure the mount directory has
+			// the correct permissions set.
+			err = vol.EnsureMountPath()
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, op)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// CreateVolumeFromBackup restores a backup tarball onto the storage device.
+func (d *linstor) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (VolumePostHook, revert.Hook, error) {
+	return genericVFSBackupUnpack(d, d.state.OS, vol, srcBackup.Snapshots, srcData, op)
+}
+
+// CreateVolumeFromCopy provides same-pool volume copying functionality.
+func (d *linstor) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool, allowInconsistent bool, op *operations.Operation) error {
+	var err error
+	var srcSnapshots []Volume
+
+	if copySnapshots && !srcVol.IsSnapshot() {
+		// Get the list of snapshots from the source.
+		srcSnapshots, err = srcVol.Snapshots(op)
+		if err != nil {
+			return err
+		}
+	}
+
+	return genericVFSCopyVolume(d, nil, vol, srcVol, srcSnapshots, false, allowInconsistent, op)
+}
+
+// CreateVolumeFromMigration creates a volume being sent via a migration.
+func (d *linstor) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, volTargetArgs migration.VolumeTargetArgs, preFiller *VolumeFiller, op *operations.Operation) error {
+	return genericVFSCreateVolumeFromMigration(d, nil, vol, conn, volTargetArgs, preFiller, op)
+}
+
+// RefreshVolume provides same-pool volume and specific snapshots syncing functionality.
+func (d *linstor) RefreshVolume(vol Volume, srcVol Volume, srcSnapshots []Volume, allowInconsistent bool, op *operations.Operation) error {
+	return genericVFSCopyVolume(d, nil, vol, srcVol, srcSnapshots, true, allowInconsistent, op)
+}
+
+// DeleteVolume deletes a volume of the storage device. If any snapshots of the volume remain then this function
+// will return an error.
+func (d *linstor) DeleteVolume(vol Volume, op *operations.Operation) error {
+	snapshots, err := d.VolumeSnapshots(vol, op)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) > 0 {
+		return fmt.Errorf("Cannot remove a volume that has snapshots")
+	}
+
+	rd, err := d.getResourceDefinition(vol)
+	if err != nil {
+		return err
+	}
+
+	if rd != nil {
+		if vol.contentType == ContentTypeFS {
+			_, err = d.UnmountVolume(vol, false, op)
+			if err != nil {
+				return fmt.Errorf("Error unmounting LINSTOR volume: %w", err)
+			}
+		}
+
+		err = d.deleteResourceDefinition(rd.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if vol.contentType == ContentTypeFS {
+		// Remove the volume from the storage device.
+		mountPath := vol.MountPath()
+		err = os.RemoveAll(mountPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Error removing LINSTOR volume mount path %q: %w", mountPath, err)
+		}
+
+		// Although the volume snapshot directory should already be removed, lets remove it here to just in
+		// case the top-level directory is left.
+		err = deleteParentSnapshotDirIfEmpty(d.name, vol.volType, vol.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	// For VMs, also delete the filesystem volume.
+	if vol.IsVMBlock() {
+		fsVol := vol.NewVMBlockFilesystemVolume()
+		err := d.DeleteVolume(fsVol, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasVolume indicates whether a specific volume exists on the storage pool.
+func (d *linstor) HasVolume(vol Volume) bool {
+	rd, err := d.getResourceDefinition(vol)
+	if err != nil {
+		return false
+	}
+
+	return rd != nil
+}
+
+// FillVolumeConfig populate volume with default config.
+func (d *linstor) FillVolumeConfig(vol Volume) error {
+	// Only validate filesystem config keys for filesystem volumes or VM block volumes (which have an
+	// associated filesystem volume).
+	if vol.ContentType() == ContentTypeFS || vol.IsVMBlock() {
+		// Inherit filesystem from pool if not set.
+		if vol.config["block.filesystem"] == "" {
+			vol.config["block.filesystem"] = d.config["volume.block.filesystem"]
+		}
+
+		// Default filesystem if neither volume nor pool specify an override.
+		if vol.config["block.filesystem"] == "" {
+			// Unchangeable volume property: Set unconditionally.
+			vol.config["block.filesystem"] = DefaultFilesystem
+		}
+
+		// Inherit filesystem mount options from pool if not set.
+		if vol.config["block.mount_options"] == "" {
+			vol.config["block.mount_options"] = d.config["volume.block.mount_options"]
+		}
+
+		// Default filesystem mount options if neither volume nor pool specify an override.
+		if vol.config["block.mount_options"] == "" {
+			// Unchangeable volume property: Set unconditionally.
+			vol.config["block.mount_options"] = "discard"
+		}
+	}
+
+	return nil
+}
+
+// ValidateVolume validates the supplied volume config.
+func (d *linstor) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
+	rules := map[string]func(value string) error{
+		"block.filesystem":    validate.Optional(validate.IsOneOf(linstorAllowedFilesystems...)),
+		"block.mount_options": validate.IsAny,
+	}
+
+	return d.validateVolume(vol, rules, removeUnknownKeys)
+}
+
+// UpdateVolume applies config changes to the volume.
+func (d *linstor) UpdateVolume(vol Volume, changedConfig map[string]string) error {
+	newSize, sizeChanged := changedConfig["size"]
+	if sizeChanged {
+		err := d.SetVolumeQuota(vol, newSize, false, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetVolumeUsage returns the disk space used by the volume.
+func (d *linstor) GetVolumeUsage(vol Volume) (int64, error) {
+	// For non-snapshot filesystem volumes, we only return usage when the volume is mounted, as the usage
+	// of the DRBD device cannot be queried from LINSTOR.
+	if !vol.IsSnapshot() && vol.contentType == ContentTypeFS && filesystem.IsMountPoint(vol.MountPath()) {
+		var stat unix.Statfs_t
+		err := unix.Statfs(vol.MountPath(), &stat)
+		if err != nil {
+			return -1, err
+		}
+
+		return int64(stat.Blocks-stat.Bfree) * int64(stat.Bsize), nil
+	}
+
+	return -1, ErrNotSupported
+}
+
+// SetVolumeQuota applies a size limit on volume.
+// Does nothing if supplied with an empty/zero size.
+func (d *linstor) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op *operations.Operation) error {
+	// Do nothing if size isn't specified.
+	if size == "" || size == "0" {
+		return nil
+	}
+
+	sizeBytes, err := units.ParseByteSizeString(size)
+	if err != nil {
+		return err
+	}
+
+	// LINSTOR volume sizes are set in KiB.
+	sizeBytes = d.sizeKiB(sizeBytes) * 1024
+
+	rd, err := d.loadResourceDefinition(vol)
+	if err != nil {
+		return err
+	}
+
+	oldSizeBytes, err := d.volumeSizeBytes(rd.Name)
+	if err != nil {
+		return err
+	}
+
+	if sizeBytes == oldSizeBytes {
+		return nil
+	}
+
+	// LINSTOR doesn't support shrinking volumes.
+	if sizeBytes < oldSizeBytes {
+		return fmt.Errorf("LINSTOR volumes cannot be shrunk: %w", ErrCannotBeShrunk)
+	}
+
+	// We don't allow online resizing of block volumes unless in "unsafe" mode.
+	if vol.contentType == ContentTypeBlock && !allowUnsafeResize && vol.blockResizeInUse() {
+		return ErrInUse
+	}
+
+	err = d.resizeVolume(rd.Name, sizeBytes)
+	if err != nil {
+		return err
+	}
+
+	devPath, err := d.resourceDevPath(rd.Name)
+	if err != nil {
+		return err
+	}
+
+	if vol.contentType == ContentTypeFS {
+		// Grow the filesystem to fill block device.
+		err = growFileSystem(vol.ConfigBlockFilesystem(), devPath, vol)
+		if err != nil {
+			return err
+		}
+
+		d.logger.Debug("LINSTOR volume filesystem grown", logger.Ctx{"dev": devPath, "size": fmt.Sprintf("%db", sizeBytes)})
+	} else if vol.IsVMBlock() && !allowUnsafeResize && !vol.MountInUse() {
+		// Move the VM GPT alt header to end of disk if needed (not needed in unsafe resize mode as it is
+		// expected the caller will do all necessary post resize actions themselves, nor when grown while
+		// in use as the running VM takes care of it).
+		err = d.moveGPTAltHeader(devPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetVolumeDiskPath returns the location of a disk volume.
+func (d *linstor) GetVolumeDiskPath(vol Volume) (string, error) {
+	if vol.IsVMBlock() || (vol.volType == VolumeTypeCustom && vol.contentType == ContentTypeBlock) {
+		// Snapshots are accessed through the temporary volume they are restored into when mounted.
+		if vol.IsSnapshot() {
+			return d.volumeDevPath(d.tmpSnapshotVolume(vol))
+		}
+
+		return d.volumeDevPath(vol)
+	}
+
+	return "", ErrNotSupported
+}
+
+// ListVolumes returns a list of LXD volumes in storage pool.
+func (d *linstor) ListVolumes() ([]Volume, error) {
+	vols := make(map[string]Volume)
+
+	rds, err := d.resourceDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rd := range rds {
+		volType := VolumeType(rd.Props[linstorPropType])
+		contentType := ContentType(rd.Props[linstorPropContentType])
+		volName := rd.Props[linstorPropName]
+
+		knownType := false
+		for _, volumeType := range d.Info().VolumeTypes {
+			if volType == volumeType {
+				knownType = true
+				break
+			}
+		}
+
+		if !knownType {
+			d.logger.Debug("Ignoring unrecognised volume type", logger.Ctx{"rd": rd.Name, "type": volType})
+			continue // Ignore unrecognised volume.
+		}
+
+		if strings.HasSuffix(volName, tmpVolSuffix) {
+			continue // Ignore temporary volumes holding mounted snapshots.
+		}
+
+		if volType == VolumeTypeVM && contentType == ContentTypeFS {
+			continue // Ignore VM filesystem volumes as we will just return the VM's block volume.
+		}
+
+		// If a new volume has been found, or the volume will replace an existing image filesystem volume
+		// then proceed to add the volume to the map. We allow image volumes to overwrite existing
+		// filesystem volumes of the same name so that for VM images we only return the block content type
+		// volume (so that only the single "logical" volume is returned).
+		existingVol, foundExisting := vols[volName]
+		if !foundExisting || (existingVol.Type() == VolumeTypeImage && existingVol.ContentType() == ContentTypeFS) {
+			vols[volName] = NewVolume(d, d.name, volType, contentType, volName, make(map[string]string), d.config)
+			continue
+		}
+
+		return nil, fmt.Errorf("Unexpected duplicate volume %q found", volName)
+	}
+
+	volList := make([]Volume, 0, len(vols))
+	for _, v := range vols {
+		volList = append(volList, v)
+	}
+
+	return volList, nil
+}
+
+// MountVolume mounts a volume and increments ref counter. Please call UnmountVolume() when done with the volume.
+func (d *linstor) MountVolume(vol Volume, op *operations.Operation) error {
+	unlock := vol.MountLock()
+	defer unlock()
+
+	if vol.contentType == ContentTypeFS {
+		// Check if already mounted.
+		mountPath := vol.MountPath()
+		if !filesystem.IsMountPoint(mountPath) {
+			devPath, err := d.volumeDevPath(vol)
+			if err != nil {
+				return err
+			}
+
+			fsType := vol.ConfigBlockFilesystem()
+
+			if vol.mountFilesystemProbe {
+				fsType, err = fsProbe(devPath)
+				if err != nil {
+					return fmt.Errorf("Failed probing filesystem: %w", err)
+				}
+			}
+
+			err = vol.EnsureMountPath()
+			if err != nil {
+				return err
+			}
+
+			mountFlags, mountOptions := resolveMountOptions(vol.ConfigBlockMountOptions())
+			err = TryMount(devPath, mountPath, fsType, mountFlags, mountOptions)
+			if err != nil {
+				return fmt.Errorf("Failed to mount LINSTOR volume: %w", err)
+			}
+
+			d.logger.Debug("Mounted LINSTOR volume", logger.Ctx{"dev": devPath, "path": mountPath, "options": mountOptions})
+		}
+	} else if vol.contentType == ContentTypeBlock {
+		// Make sure the DRBD device is available on this server.
+		_, err := d.volumeDevPath(vol)
+		if err != nil {
+			return err
+		}
+
+		// For VMs, mount the filesystem volume.
+		if vol.IsVMBlock() {
+			fsVol := vol.NewVMBlockFilesystemVolume()
+			err = d.MountVolume(fsVol, op)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	vol.MountRefCountIncrement() // From here on it is up to caller to call UnmountVolume() when done.
+	return nil
+}
+
+// UnmountVolume unmounts volume if mounted and not in use. Returns true if this unmounted the volume.
+// The DRBD device is left attached as it is only promoted to primary while open, so keepBlockDev has no effect.
+func (d *linstor) UnmountVolume(vol Volume, keepBlockDev bool, op *operations.Operation) (bool, error) {
+	unlock := vol.MountLock()
+	defer unlock()
+
+	var err error
+	ourUnmount := false
+	mountPath := vol.MountPath()
+
+	refCount := vol.MountRefCountDecrement()
+
+	// Check if already mounted.
+	if vol.contentType == ContentTypeFS && filesystem.IsMountPoint(mountPath) {
+		if refCount > 0 {
+			d.logger.Debug("Skipping unmount as in use", logger.Ctx{"volName": vol.name, "refCount": refCount})
+			return false, ErrInUse
+		}
+
+		err = TryUnmount(mountPath, 0)
+		if err != nil {
+			return false, fmt.Errorf("Failed to unmount LINSTOR volume: %w", err)
+		}
+
+		d.logger.Debug("Unmounted LINSTOR volume", logger.Ctx{"volName": vol.name, "path": mountPath})
+
+		ourUnmount = true
+	} else if vol.contentType == ContentTypeBlock {
+		// For VMs, unmount the filesystem volume.
+		if vol.IsVMBlock() {
+			fsVol := vol.NewVMBlockFilesystemVolume()
+			ourUnmount, err = d.UnmountVolume(fsVol, false, op)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return ourUnmount, nil
+}
+
+// RenameVolume renames a volume and its snapshots.
+func (d *linstor) RenameVolume(vol Volume, newVolName string, op *operations.Operation) error {
+	return vol.UnmountTask(func(op *operations.Operation) error {
+		revert := revert.New()
+		defer revert.Fail()
+
+		rd, err := d.loadResourceDefinition(vol)
+		if err != nil {
+			return err
+		}
+
+		// The snapshots are recorded on the resource definition, so only its name needs to change.
+		err = d.setResourceDefinitionProps(rd.Name, map[string]string{linstorPropName: newVolName}, nil)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() { _ = d.setResourceDefinitionProps(rd.Name, map[string]string{linstorPropName: vol.name}, nil) })
+
+		// Rename volume dir.
+		if vol.contentType == ContentTypeFS {
+			err = genericVFSRenameVolume(d, vol, newVolName, op)
+			if err != nil {
+				return err
+			}
+		}
+
+		// For VMs, also rename the filesystem volume.
+		if vol.IsVMBlock() {
+			fsVol := vol.NewVMBlockFilesystemVolume()
+			err = d.RenameVolume(fsVol, newVolName, op)
+			if err != nil {
+				return err
+			}
+		}
+
+		revert.Success()
+		return nil
+	}, false, op)
+}
+
+// MigrateVolume sends a volume for migration.
+func (d *linstor) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *migration.VolumeSourceArgs, op *operations.Operation) error {
+	// If data is set, this request is coming from the clustering code.
+	// In this case, we only need to detach the volume from this server and rename it to the specified
+	// storage volume name.
+	if volSrcArgs.Data != nil {
+		data, ok := volSrcArgs.Data.(string)
+		if ok {
+			rd, err := d.loadResourceDefinition(vol)
+			if err != nil {
+				return err
+			}
+
+			err = d.removeDisklessResource(rd.Name)
+			if err != nil {
+				return err
+			}
+
+			// Rename volume.
+			if vol.name != data {
+				err = d.setResourceDefinitionProps(rd.Name, map[string]string{linstorPropName: data}, nil)
+				if err != nil {
+					return err
+				}
+			}
+
+			if vol.IsVMBlock() {
+				fsVol := vol.NewVMBlockFilesystemVolume()
+				err = d.MigrateVolume(fsVol, conn, volSrcArgs, op)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+
+	return genericVFSMigrateVolume(d, d.state, vol, conn, volSrcArgs, op)
+}
+
+// BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
+// This driver does not support optimized backups.
+func (d *linstor) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, _ bool, snapshots []string, incrementalBase string, op *operations.Operation) error {
+	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+}
+
+// CreateVolumeSnapshot creates a snapshot of a volume.
+func (d *linstor) CreateVolumeSnapshot(snapVol Volume, op *operations.Operation) error {
+	parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapVol.name)
+	parentVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, parentName, snapVol.config, snapVol.poolConfig)
+	snapPath := snapVol.MountPath()
+
+	// Create the parent directory.
+	err := createParentSnapshotDirIfMissing(d.name, snapVol.volType, parentName)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create snapshot directory.
+	err = snapVol.EnsureMountPath()
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = os.RemoveAll(snapPath) })
+
+	rd, err := d.loadResourceDefinition(parentVol)
+	if err != nil {
+		return err
+	}
+
+	linstorSnapName, err := d.createSnapshot(rd.Name, snapName)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { _ = d.deleteSnapshot(rd.Name, linstorSnapName) })
+
+	// For VMs, also snapshot the filesystem.
+	if snapVol.IsVMBlock() {
+		fsVol := snapVol.NewVMBlockFilesystemVolume()
+		err = d.CreateVolumeSnapshot(fsVol, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// DeleteVolumeSnapshot removes a snapshot from the storage device.
+func (d *linstor) DeleteVolumeSnapshot(snapVol Volume, op *operations.Operation) error {
+	parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapVol.name)
+	parentVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, parentName, snapVol.config, snapVol.poolConfig)
+
+	rd, err := d.getResourceDefinition(parentVol)
+	if err != nil {
+		return err
+	}
+
+	if rd != nil {
+		linstorSnapName := d.snapshotName(rd, snapName)
+		if linstorSnapName != "" {
+			_, err = d.UnmountVolumeSnapshot(snapVol, op)
+			if err != nil {
+				return fmt.Errorf("Error unmounting LINSTOR snapshot: %w", err)
+			}
+
+			err = d.deleteSnapshot(rd.Name, linstorSnapName)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// For VMs, also remove the snapshot filesystem volume.
+	if snapVol.IsVMBlock() {
+		fsVol := snapVol.NewVMBlockFilesystemVolume()
+		err = d.DeleteVolumeSnapshot(fsVol, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the snapshot mount path from the storage device.
+	snapPath := snapVol.MountPath()
+	err = os.RemoveAll(snapPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Error removing LINSTOR snapshot mount path %q: %w", snapPath, err)
+	}
+
+	// Remove the parent snapshot directory if this is the last snapshot being removed.
+	err = deleteParentSnapshotDirIfEmpty(d.name, snapVol.volType, parentName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MountVolumeSnapshot sets up a read-only mount on top of the snapshot to avoid accidental modifications.
+// As LINSTOR snapshots cannot be attached directly, the snapshot is restored into a temporary volume which is
+// removed again by UnmountVolumeSnapshot.
+func (d *linstor) MountVolumeSnapshot(snapVol Volume, op *operations.Operation) error {
+	unlock := snapVol.MountLock()
+	defer unlock()
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	tmpVol := d.tmpSnapshotVolume(snapVol)
+	tmpRD, err := d.getResourceDefinition(tmpVol)
+	if err != nil {
+		return err
+	}
+
+	if tmpRD == nil {
+		parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapVol.name)
+		parentVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, parentName, snapVol.config, snapVol.poolConfig)
+
+		rd, err := d.loadResourceDefinition(parentVol)
+		if err != nil {
+			return err
+		}
+
+		linstorSnapName := d.snapshotName(rd, snapName)
+		if linstorSnapName == "" {
+			return fmt.Errorf("LINSTOR snapshot for volume %q not found", snapVol.name)
+		}
+
+		tmpRDName, err := d.restoreSnapshot(rd.Name, linstorSnapName, tmpVol)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() { _ = d.deleteResourceDefinition(tmpRDName) })
+
+		tmpRD = &linstorResourceDefinition{Name: tmpRDName}
+	}
+
+	devPath, err := d.resourceDevPath(tmpRD.Name)
+	if err != nil {
+		return err
+	}
+
+	mountPath := snapVol.MountPath()
+
+	// Check if already mounted.
+	if snapVol.contentType == ContentTypeFS && !filesystem.IsMountPoint(mountPath) {
+		err = snapVol.EnsureMountPath()
+		if err != nil {
+			return err
+		}
+
+		fsType := snapVol.ConfigBlockFilesystem()
+		mountFlags, mountOptions := resolveMountOptions(snapVol.ConfigBlockMountOptions())
+
+		// The temporary volume shares the filesystem UUID of its parent, so regenerate it if needed to
+		// allow both to be mounted at the same time.
+		if renegerateFilesystemUUIDNeeded(fsType) {
+			// When mounting XFS filesystems temporarily we can use the nouuid option rather than fully
+			// regenerating the filesystem UUID.
+			if fsType == "xfs" {
+				idx := strings.Index(mountOptions, "nouuid")
+				if idx < 0 {
+					mountOptions += ",nouuid"
+				}
+			} else {
+				d.logger.Debug("Regenerating filesystem UUID", logger.Ctx{"dev": devPath, "fs": fsType})
+				err = regenerateFilesystemUUID(fsType, devPath)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		err = TryMount(devPath, mountPath, fsType, mountFlags|unix.MS_RDONLY, mountOptions)
+		if err != nil {
+			return fmt.Errorf("Failed to mount LINSTOR snapshot volume: %w", err)
+		}
+
+		d.logger.Debug("Mounted LINSTOR snapshot volume", logger.Ctx{"dev": devPath, "path": mountPath, "options": mountOptions})
+	} else if snapVol.contentType == ContentTypeBlock {
+		// For VMs, mount the filesystem volume.
+		if snapVol.IsVMBlock() {
+			fsVol := snapVol.NewVMBlockFilesystemVolume()
+			err = d.MountVolumeSnapshot(fsVol, op)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	snapVol.MountRefCountIncrement() // From here on it is up to caller to call UnmountVolumeSnapshot() when done.
+	revert.Success()
+	return nil
+}
+
+// UnmountVolumeSnapshot removes the read-only mount placed on top of a snapshot.
+// The temporary volume the snapshot was restored into is removed as well.
+func (d *linstor) UnmountVolumeSnapshot(snapVol Volume, op *operations.Operation) (bool, error) {
+	unlock := snapVol.MountLock()
+	defer unlock()
+
+	var err error
+	ourUnmount := false
+	mountPath := snapVol.MountPath()
+
+	refCount := snapVol.MountRefCountDecrement()
+
+	// Check if already mounted.
+	if snapVol.contentType == ContentTypeFS && filesystem.IsMountPoint(mountPath) {
+		if refCount > 0 {
+			d.logger.Debug("Skipping unmount as in use", logger.Ctx{"volName": snapVol.name, "refCount": refCount})
+			return false, ErrInUse
+		}
+
+		err = TryUnmount(mountPath, 0)
+		if err != nil {
+			return false, fmt.Errorf("Failed to unmount LINSTOR snapshot volume: %w", err)
+		}
+
+		d.logger.Debug("Unmounted LINSTOR snapshot volume", logger.Ctx{"path": mountPath})
+
+		ourUnmount = true
+	} else if snapVol.contentType == ContentTypeBlock {
+		// For VMs, unmount the filesystem volume.
+		if snapVol.IsVMBlock() {
+			fsVol := snapVol.NewVMBlockFilesystemVolume()
+			ourUnmount, err = d.UnmountVolumeSnapshot(fsVol, op)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if refCount > 0 {
+			d.logger.Debug("Skipping unmount as in use", logger.Ctx{"volName": snapVol.name, "refCount": refCount})
+			return false, ErrInUse
+		}
+	}
+
+	// Check if a temporary volume exists, and if so remove it.
+	tmpRD, err := d.getResourceDefinition(d.tmpSnapshotVolume(snapVol))
+	if err != nil {
+		return ourUnmount, err
+	}
+
+	if tmpRD != nil {
+		err = d.deleteResourceDefinition(tmpRD.Name)
+		if err != nil {
+			return ourUnmount, fmt.Errorf("Failed to remove temporary LINSTOR snapshot volume: %w", err)
+		}
+
+		ourUnmount = true
+	}
+
+	return ourUnmount, nil
+}
+
+// VolumeSnapshots returns a list of snapshots for the volume (in no particular order).
+func (d *linstor) VolumeSnapshots(vol Volume, op *operations.Operation) ([]string, error) {
+	snapshots := []string{}
+
+	rd, err := d.getResourceDefinition(vol)
+	if err != nil {
+		return nil, err
+	}
+
+	if rd == nil {
+		return snapshots, nil
+	}
+
+	for key, value := range rd.Props {
+		if strings.HasPrefix(key, linstorPropSnapshotPrefix) {
+			snapshots = append(snapshots, value)
+		}
+	}
+
+	return snapshots, nil
+}
+
+// RestoreVolume restores a volume from a snapshot.
+func (d *linstor) RestoreVolume(vol Volume, snapshotName string, op *operations.Operation) error {
+	rd, err := d.loadResourceDefinition(vol)
+	if err != nil {
+		return err
+	}
+
+	linstorSnapName := d.snapshotName(rd, snapshotName)
+	if linstorSnapName == "" {
+		return fmt.Errorf("LINSTOR snapshot %q of volume %q not found", snapshotName, vol.name)
+	}
+
+	_, err = d.UnmountVolume(vol, false, op)
+	if err != nil {
+		return fmt.Errorf("Error unmounting LINSTOR volume: %w", err)
+	}
+
+	err = d.rollbackSnapshot(rd.Name, linstorSnapName)
+	if err != nil {
+		return err
+	}
+
+	// For VMs, also restore the filesystem volume.
+	if vol.IsVMBlock() {
+		fsVol := vol.NewVMBlockFilesystemVolume()
+		err = d.RestoreVolume(fsVol, snapshotName, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenameVolumeSnapshot renames a volume snapshot.
+func (d *linstor) RenameVolumeSnapshot(snapVol Volume, newSnapshotName string, op *operations.Operation) error {
+	revert := revert.New()
+	defer revert.Fail()
+
+	parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapVol.name)
+	parentVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, parentName, nil, nil)
+
+	rd, err := d.loadResourceDefinition(parentVol)
+	if err != nil {
+		return err
+	}
+
+	linstorSnapName := d.snapshotName(rd, snapName)
+	if linstorSnapName == "" {
+		return fmt.Errorf("LINSTOR snapshot for volume %q not found", snapVol.name)
+	}
+
+	err = d.setResourceDefinitionProps(rd.Name, map[string]string{linstorPropSnapshotPrefix + linstorSnapName: newSnapshotName}, nil)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		_ = d.setResourceDefinitionProps(rd.Name, map[string]string{linstorPropSnapshotPrefix + linstorSnapName: snapName}, nil)
+	})
+
+	if snapVol.contentType == ContentTypeFS {
+		err = genericVFSRenameVolumeSnapshot(d, snapVol, newSnapshotName, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	// For VMs, also rename the filesystem volume snapshot.
+	if snapVol.IsVMBlock() {
+		fsVol := snapVol.NewVMBlockFilesystemVolume()
+		err = d.RenameVolumeSnapshot(fsVol, newSnapshotName, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
@@ -581,6 +581,11 @@ func (d *lvm) Unmount() (bool, error) {
 	return false, nil
 }
 
+// Discard releases the unused blocks of the mounted volumes back to the underlying storage.
+func (d *lvm) Discard() error {
+	return discardMountedVolumes(d)
+}
+
 // GetResources returns utilisation and space info about the pool.
 func (d *lvm) GetResources() (*api.ResourcesStoragePool, error) {
 	res := api.ResourcesStoragePool{}
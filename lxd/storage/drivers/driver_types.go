@@ -10,6 +10,7 @@ type Info struct {
 	OptimizedImages       bool         // Whether driver stores images as separate volume.
 	OptimizedBackups      bool         // Whether driver supports optimized volume backups.
 	OptimizedBackupHeader bool         // Whether driver generates an optimised backup header file in backup.
+	IncrementalBackups    bool         // Whether driver supports optimized backups relative to a previous backup.
 	PreservesInodes       bool         // Whether driver preserves inodes when volumes are moved hosts.
 	BlockBacking          bool         // Whether driver uses block devices as backing store.
 	RunningCopyFreeze     bool         // Whether instance should be frozen during snapshot if running.
@@ -17,6 +18,19 @@ type Info struct {
 	MountedRoot           bool         // Whether the pool directory itself is a mount.
 }
 
+// HealthInfo represents the health of the storage backing a pool.
+type HealthInfo struct {
+	Degraded bool     // Whether the storage is in a degraded state.
+	Messages []string // Details about the health of the storage.
+}
+
+// VolumeEncryption represents the encryption status of a volume.
+type VolumeEncryption struct {
+	Enabled   bool   // Whether the volume is encrypted.
+	Algorithm string // Encryption algorithm (cipher) in use.
+	KeyStatus string // Whether the key is loaded (e.g. "available" or "unavailable").
+}
+
 // VolumeFiller provides a struct for filling a volume.
 type VolumeFiller struct {
 	Fill func(vol Volume, rootBlockPath string, allowUnsafeResize bool) (int64, error) // Function to fill the volume.
@@ -107,17 +107,18 @@ func (d *zfs) load() error {
 // Info returns info about the driver and its environment.
 func (d *zfs) Info() Info {
 	info := Info{
-		Name:              "zfs",
-		Version:           zfsVersion,
-		OptimizedImages:   true,
-		OptimizedBackups:  true,
-		PreservesInodes:   true,
-		Remote:            d.isRemote(),
-		VolumeTypes:       []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
-		BlockBacking:      false,
-		RunningCopyFreeze: false,
-		DirectIO:          zfsDirectIO,
-		MountedRoot:       false,
+		Name:               "zfs",
+		Version:            zfsVersion,
+		OptimizedImages:    true,
+		OptimizedBackups:   true,
+		IncrementalBackups: true,
+		PreservesInodes:    true,
+		Remote:             d.isRemote(),
+		VolumeTypes:        []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		BlockBacking:       false,
+		RunningCopyFreeze:  false,
+		DirectIO:           zfsDirectIO,
+		MountedRoot:        false,
 	}
 
 	return info
@@ -482,6 +483,52 @@ func (d *zfs) GetResources() (*api.ResourcesStoragePool, error) {
 	return &res, nil
 }
 
+// HealthInfo returns the health of the zpool backing the storage pool.
+func (d *zfs) HealthInfo() (*HealthInfo, error) {
+	poolName := strings.SplitN(d.config["zfs.pool_name"], "/", 2)[0]
+
+	health, err := shared.RunCommand("zpool", "list", "-H", "-o", "health", poolName)
+	if err != nil {
+		return nil, err
+	}
+
+	health = strings.TrimSpace(health)
+	info := HealthInfo{}
+	if health == "ONLINE" {
+		return &info, nil
+	}
+
+	info.Degraded = true
+	info.Messages = append(info.Messages, fmt.Sprintf("ZFS pool %q is %s", poolName, health))
+
+	// Include the status and recommended action reported by ZFS.
+	out, err := shared.RunCommand("zpool", "status", "-x", poolName)
+	if err == nil {
+		for _, line := range strings.Split(out, "\n") {
+			line = strings.TrimSpace(line)
+			for _, prefix := range []string{"status:", "action:"} {
+				if strings.HasPrefix(line, prefix) {
+					info.Messages = append(info.Messages, strings.TrimSpace(strings.TrimPrefix(line, prefix)))
+				}
+			}
+		}
+	}
+
+	return &info, nil
+}
+
+// Discard releases the unused blocks of the zpool back to the underlying storage.
+func (d *zfs) Discard() error {
+	poolName := strings.SplitN(d.config["zfs.pool_name"], "/", 2)[0]
+
+	_, err := shared.RunCommand("zpool", "trim", poolName)
+	if err != nil {
+		return fmt.Errorf("Failed trimming ZFS pool %q: %w", poolName, err)
+	}
+
+	return nil
+}
+
 // MigrationType returns the type of transfer methods to be used when doing migrations between pools in preference order.
 func (d *zfs) MigrationTypes(contentType ContentType, refresh bool) []migration.Type {
 	var rsyncFeatures []string
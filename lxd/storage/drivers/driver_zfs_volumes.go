@@ -298,7 +298,20 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 		return genericVFSBackupUnpack(d, d.state.OS, vol, srcBackup.Snapshots, srcData, op)
 	}
 
-	if d.HasVolume(vol) {
+	// Incremental backups are restored on top of the existing volume, which must have the base snapshot.
+	snapshots := srcBackup.Snapshots
+	if srcBackup.Incremental != nil {
+		var err error
+		snapshots, err = backup.IncrementalSnapshots(srcBackup.Snapshots, srcBackup.Incremental.Base)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		baseVol, _ := vol.NewSnapshot(srcBackup.Incremental.Base)
+		if !d.HasVolume(baseVol) {
+			return nil, nil, fmt.Errorf("Cannot restore incremental backup, base snapshot %q doesn't exist on target", srcBackup.Incremental.Base)
+		}
+	} else if d.HasVolume(vol) {
 		return nil, nil, fmt.Errorf("Cannot restore volume, already exists on target")
 	}
 
@@ -308,14 +321,18 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 	// Define a revert function that will be used both to revert if an error occurs inside this
 	// function but also return it for use from the calling functions if no error internally.
 	revertHook := func() {
-		for _, snapName := range srcBackup.Snapshots {
+		for _, snapName := range snapshots {
 			fullSnapshotName := GetSnapshotVolumeName(vol.name, snapName)
 			snapVol := NewVolume(d, d.name, vol.volType, vol.contentType, fullSnapshotName, vol.config, vol.poolConfig)
 			_ = d.DeleteVolumeSnapshot(snapVol, op)
 		}
 
-		// And lastly the main volume.
-		_ = d.DeleteVolume(vol, op)
+		// And lastly the main volume, which is rolled back to the base snapshot for incremental backups.
+		if srcBackup.Incremental != nil {
+			_ = d.RestoreVolume(vol, srcBackup.Incremental.Base, op)
+		} else {
+			_ = d.DeleteVolume(vol, op)
+		}
 	}
 
 	// Only execute the revert function if we have had an error internally.
@@ -392,7 +409,7 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 		}
 
 		// Restore backups from oldest to newest.
-		for _, snapName := range srcBackup.Snapshots {
+		for _, snapName := range snapshots {
 			prefix := "snapshots"
 			fileName := fmt.Sprintf("%s.bin", snapName)
 			if v.volType == VolumeTypeVM {
@@ -1313,6 +1330,33 @@ func (d *zfs) UpdateVolume(vol Volume, changedConfig map[string]string) error {
 	return nil
 }
 
+// GetVolumeEncryption returns the ZFS native encryption status of the volume's dataset.
+// Datasets inherit the encryption of their parent, so this reflects how the pool's source dataset was created.
+func (d *zfs) GetVolumeEncryption(vol Volume) (*VolumeEncryption, error) {
+	dataset := d.dataset(vol, false)
+
+	algorithm, err := d.getDatasetProperty(dataset, "encryption")
+	if err != nil {
+		return nil, err
+	}
+
+	// ZFS versions without native encryption don't know the property.
+	if algorithm == "" || algorithm == "-" || algorithm == "off" {
+		return &VolumeEncryption{}, nil
+	}
+
+	keyStatus, err := d.getDatasetProperty(dataset, "keystatus")
+	if err != nil {
+		return nil, err
+	}
+
+	return &VolumeEncryption{
+		Enabled:   true,
+		Algorithm: algorithm,
+		KeyStatus: keyStatus,
+	}, nil
+}
+
 // GetVolumeUsage returns the disk space used by the volume.
 func (d *zfs) GetVolumeUsage(vol Volume) (int64, error) {
 	// Determine what key to use.
@@ -1400,7 +1444,7 @@ func (d *zfs) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op
 				return fmt.Errorf("Block volumes cannot be shrunk: %w", ErrCannotBeShrunk)
 			}
 
-			if vol.MountInUse() {
+			if vol.blockResizeInUse() {
 				return ErrInUse // We don't allow online resizing of block volumes.
 			}
 		}
@@ -1411,8 +1455,9 @@ func (d *zfs) SetVolumeQuota(vol Volume, size string, allowUnsafeResize bool, op
 		}
 
 		// Move the VM GPT alt header to end of disk if needed (not needed in unsafe resize mode as
-		// it is expected the caller will do all necessary post resize actions themselves).
-		if vol.IsVMBlock() && !allowUnsafeResize {
+		// it is expected the caller will do all necessary post resize actions themselves, nor when
+		// grown while in use as the running VM takes care of it).
+		if vol.IsVMBlock() && !allowUnsafeResize && !vol.MountInUse() {
 			err = vol.MountTask(func(mountPath string, op *operations.Operation) error {
 				devPath, err := d.GetVolumeDiskPath(vol)
 				if err != nil {
@@ -2103,7 +2148,7 @@ func (d *zfs) readonlySnapshot(vol Volume) (string, *revert.Reverter, error) {
 }
 
 // BackupVolume creates an exported version of a volume.
-func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, op *operations.Operation) error {
+func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots []string, incrementalBase string, op *operations.Operation) error {
 	// Handle the non-optimized tarballs through the generic packer.
 	if !optimized {
 		// Because the generic backup method will not take a consistent backup if files are being modified
@@ -2138,12 +2183,26 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 	// Backup VM config volumes first.
 	if vol.IsVMBlock() {
 		fsVol := vol.NewVMBlockFilesystemVolume()
-		err := d.BackupVolume(fsVol, tarWriter, optimized, snapshots, op)
+		err := d.BackupVolume(fsVol, tarWriter, optimized, snapshots, incrementalBase, op)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Incremental backups only contain the snapshots newer than the base, the first one being sent relative
+	// to the base snapshot.
+	finalParent := ""
+	if incrementalBase != "" {
+		var err error
+		snapshots, err = backup.IncrementalSnapshots(snapshots, incrementalBase)
+		if err != nil {
+			return err
+		}
+
+		baseSnapshot, _ := vol.NewSnapshot(incrementalBase)
+		finalParent = d.dataset(baseSnapshot, false)
+	}
+
 	// Handle the optimized tarballs.
 	sendToFile := func(path string, parent string, fileName string) error {
 		// Prepare zfs send arguments.
@@ -2186,17 +2245,12 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 	}
 
 	// Handle snapshots.
-	finalParent := ""
 	if len(snapshots) > 0 {
-		for i, snapName := range snapshots {
+		for _, snapName := range snapshots {
 			snapshot, _ := vol.NewSnapshot(snapName)
 
 			// Figure out parent and current subvolumes.
-			parent := ""
-			if i > 0 {
-				oldSnapshot, _ := vol.NewSnapshot(snapshots[i-1])
-				parent = d.dataset(oldSnapshot, false)
-			}
+			parent := finalParent
 
 			// Make a binary zfs backup.
 			prefix := "snapshots"
@@ -226,6 +226,37 @@ func fsProbe(path string) (string, error) {
 	return strings.TrimSpace(val), nil
 }
 
+// fstrim discards the unused blocks of the filesystem mounted at path.
+func fstrim(path string) error {
+	_, err := shared.RunCommand("fstrim", path)
+	if err != nil {
+		return fmt.Errorf("Failed discarding unused blocks of %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// discardMountedVolumes discards the unused blocks of the filesystem volumes of the pool mounted on this server.
+func discardMountedVolumes(d Driver) error {
+	vols, err := d.ListVolumes()
+	if err != nil {
+		return err
+	}
+
+	for _, vol := range vols {
+		if vol.ContentType() != ContentTypeFS || !filesystem.IsMountPoint(vol.MountPath()) {
+			continue
+		}
+
+		err = fstrim(vol.MountPath())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetPoolMountPath returns the mountpoint of the given pool.
 // {LXD_DIR}/storage-pools/<pool>
 func GetPoolMountPath(poolName string) string {
@@ -370,7 +401,7 @@ func ensureVolumeBlockFile(vol Volume, path string, sizeBytes int64, allowUnsafe
 				return false, fmt.Errorf("Block volumes cannot be shrunk: %w", ErrCannotBeShrunk)
 			}
 
-			if vol.MountInUse() {
+			if vol.blockResizeInUse() {
 				return false, ErrInUse // We don't allow online resizing of block volumes.
 			}
 		}
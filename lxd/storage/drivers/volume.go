@@ -85,6 +85,7 @@ type Volume struct {
 	driver               Driver
 	mountCustomPath      string // Mount the filesystem volume at a custom location.
 	mountFilesystemProbe bool   // Probe filesystem type when mounting volume (when needed).
+	allowInUseGrow       bool   // Allow growing the block volume while in use (when its user handles the new size).
 }
 
 // NewVolume instantiates a new Volume struct.
@@ -525,3 +526,15 @@ func (v Volume) ConfigSizeFromSource(srcVol Volume) (string, error) {
 func (v *Volume) SetMountFilesystemProbe(probe bool) {
 	v.mountFilesystemProbe = probe
 }
+
+// SetAllowInUseGrow enables or disables growing the block volume while it is in use.
+// This is used for running VMs, which are then told about the new size of their disk.
+func (v *Volume) SetAllowInUseGrow(allow bool) {
+	v.allowInUseGrow = allow
+}
+
+// blockResizeInUse returns whether the block volume is in use and so can't be resized.
+// Only called for growing, shrinking in use block volumes is never allowed.
+func (v Volume) blockResizeInUse() bool {
+	return v.MountInUse() && !v.allowInUseGrow
+}
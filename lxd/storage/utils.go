@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/sys/unix"
@@ -29,6 +30,7 @@ import (
 	"github.com/lxc/lxd/lxd/sys"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/idmap"
 	"github.com/lxc/lxd/shared/ioprogress"
 	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/validate"
@@ -204,6 +206,39 @@ func VolumeDBGet(pool Pool, projectName string, volumeName string, volumeType dr
 	return vol, nil
 }
 
+// volumePresetApply expands the pool volume preset named in the volume's "preset" key into the volume config.
+// The preset is defined on the pool using "volume.preset.NAME.KEY" keys. Keys already set in the volume config take
+// precedence over the preset values, which in turn take precedence over the pool's "volume.*" defaults.
+func volumePresetApply(poolConfig map[string]string, volumeConfig map[string]string) error {
+	presetName := volumeConfig["preset"]
+	if presetName == "" {
+		return nil
+	}
+
+	prefix := fmt.Sprintf("volume.preset.%s.", presetName)
+	found := false
+
+	for k, v := range poolConfig {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		found = true
+
+		key := strings.TrimPrefix(k, prefix)
+		_, set := volumeConfig[key]
+		if !set {
+			volumeConfig[key] = v
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("Volume preset %q is not defined on the pool", presetName)
+	}
+
+	return nil
+}
+
 // VolumeDBCreate creates a volume in the database.
 // If volumeConfig is supplied, it is modified with any driver level default config options (if not set).
 // If removeUnknownKeys is true, any unknown config keys are removed from volumeConfig rather than failing.
@@ -245,6 +280,12 @@ func VolumeDBCreate(pool Pool, projectName string, volumeName string, volumeDesc
 		return err
 	}
 
+	// Expand the pool volume preset (if requested) before the pool and driver defaults are applied.
+	err = volumePresetApply(pool.Driver().Config(), volumeConfig)
+	if err != nil {
+		return err
+	}
+
 	vol := drivers.NewVolume(pool.Driver(), pool.Name(), volType, contentType, volumeName, volumeConfig, pool.Driver().Config())
 
 	// Fill default config.
@@ -316,11 +357,14 @@ func VolumeDBSnapshotsGet(pool Pool, projectName string, volume string, volumeTy
 // validatePoolCommonRules returns a map of pool config rules common to all drivers.
 func validatePoolCommonRules() map[string]func(string) error {
 	return map[string]func(string) error{
-		"source":                  validate.IsAny,
-		"volatile.initial_source": validate.IsAny,
-		"volume.size":             validate.Optional(validate.IsSize),
-		"rsync.bwlimit":           validate.Optional(validate.IsSize),
-		"rsync.compression":       validate.Optional(validate.IsBool),
+		"backups.compression_algorithm": validate.Optional(validate.IsCompressionAlgorithm),
+		"source":                        validate.IsAny,
+		"volatile.initial_source":       validate.IsAny,
+		"volume.size":                   validate.Optional(validate.IsSize),
+		"rsync.bwlimit":                 validate.Optional(validate.IsSize),
+		"rsync.compression":             validate.Optional(validate.IsBool),
+
+		"maintenance.discard.schedule": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly"})),
 	}
 }
 
@@ -337,6 +381,7 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 		},
 		"snapshots.schedule": validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly"})),
 		"snapshots.pattern":  validate.IsAny,
+		"preset":             validate.IsAny,
 	}
 
 	// volatile.idmap settings only make sense for filesystem volumes.
@@ -356,6 +401,12 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 		rules["block.filesystem"] = validate.IsAny
 	}
 
+	// volatile.trash settings are only used for custom volumes kept after their deletion.
+	if vol.Type() == drivers.VolumeTypeCustom {
+		rules["volatile.trash.date"] = validate.IsAny
+		rules["volatile.trash.name"] = validate.IsAny
+	}
+
 	// security.shifted and security.unmapped are only relevant for custom filesystem volumes.
 	if vol.Type() == drivers.VolumeTypeCustom && vol.ContentType() == drivers.ContentTypeFS {
 		rules["security.shifted"] = validate.Optional(validate.IsBool)
@@ -916,3 +967,79 @@ func InstanceDiskBlockSize(pool Pool, inst instance.Instance, op *operations.Ope
 
 	return blockDiskSize, nil
 }
+
+// idmappedMountsProbe is the result of probing idmapped mounts support on a storage pool.
+type idmappedMountsProbe struct {
+	driver    string
+	supported bool
+}
+
+// idmappedMountsPools records whether idmapped mounts work with the custom volumes of each storage pool.
+var idmappedMountsPools = make(map[string]idmappedMountsProbe)
+var idmappedMountsPoolsMu sync.Mutex
+
+// ProbeIdmappedMounts checks whether idmapped mounts work with the custom volumes of the pool and records the result.
+// Only the pools storing their volumes on the pool's own filesystem can be checked, the others are recorded as not
+// supporting idmapped mounts so that their volumes keep being shifted on disk.
+func ProbeIdmappedMounts(s *state.State, pool Pool) bool {
+	supported := false
+
+	if s.OS.IdmappedMounts && s.OS.LXCFeatures["idmapped_mounts_v2"] && pool.Driver().Info().MountedRoot {
+		probePath := drivers.GetPoolMountPath(pool.Name())
+
+		// Prefer the custom volumes directory if it's a separate mount.
+		customPath := filepath.Join(probePath, string(drivers.VolumeTypeCustom))
+		if shared.PathExists(customPath) {
+			probePath = customPath
+		}
+
+		supported = idmap.CanIdmapMount(probePath)
+	}
+
+	idmappedMountsPoolsMu.Lock()
+	idmappedMountsPools[pool.Name()] = idmappedMountsProbe{driver: pool.Driver().Info().Name, supported: supported}
+	idmappedMountsPoolsMu.Unlock()
+
+	logger.Debug("Probed idmapped mounts support", logger.Ctx{"pool": pool.Name(), "supported": supported})
+
+	return supported
+}
+
+// IdmappedMountsSupport returns whether idmapped mounts were found to work with the custom volumes of a storage pool.
+// Returns false if the pool hasn't been probed.
+func IdmappedMountsSupport(poolName string) bool {
+	idmappedMountsPoolsMu.Lock()
+	defer idmappedMountsPoolsMu.Unlock()
+
+	return idmappedMountsPools[poolName].supported
+}
+
+// ForgetIdmappedMounts removes the recorded idmapped mounts support of a deleted storage pool.
+func ForgetIdmappedMounts(poolName string) {
+	idmappedMountsPoolsMu.Lock()
+	defer idmappedMountsPoolsMu.Unlock()
+
+	delete(idmappedMountsPools, poolName)
+}
+
+// IdmappedMountsDriverSupport returns whether idmapped mounts were found to work with the custom volumes of all the
+// probed storage pools using the driver. Returns false if no pool of the driver has been probed.
+func IdmappedMountsDriverSupport(driverName string) bool {
+	idmappedMountsPoolsMu.Lock()
+	defer idmappedMountsPoolsMu.Unlock()
+
+	probed := false
+	for _, probe := range idmappedMountsPools {
+		if probe.driver != driverName {
+			continue
+		}
+
+		if !probe.supported {
+			return false
+		}
+
+		probed = true
+	}
+
+	return probed
+}
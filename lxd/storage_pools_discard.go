@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// storagePoolsDiscardTask discards the unused blocks of the storage pools whose maintenance.discard.schedule is
+// due (minutely check of configurable cron expression).
+func storagePoolsDiscardTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		poolNames, err := s.DB.Cluster.GetCreatedStoragePoolNames()
+		if err != nil {
+			if !response.IsNotFoundError(err) {
+				logger.Error("Failed getting storage pools for discard task", logger.Ctx{"err": err})
+			}
+
+			return
+		}
+
+		pools := []storagePools.Pool{}
+		for _, poolName := range poolNames {
+			if !storagePools.IsAvailable(poolName) {
+				continue
+			}
+
+			pool, err := storagePools.LoadByName(s, poolName)
+			if err != nil {
+				logger.Error("Failed loading storage pool for discard task", logger.Ctx{"pool": poolName, "err": err})
+				continue
+			}
+
+			schedule := pool.ToAPI().Config["maintenance.discard.schedule"]
+			if schedule == "" || !snapshotIsScheduledNow(schedule, pool.ID()) {
+				continue
+			}
+
+			pools = append(pools, pool)
+		}
+
+		if len(pools) == 0 {
+			return
+		}
+
+		opRun := func(op *operations.Operation) error {
+			failed := []string{}
+			for _, pool := range pools {
+				err := pool.Discard(op)
+				if err != nil {
+					logger.Error("Failed discarding unused storage pool blocks", logger.Ctx{"pool": pool.Name(), "err": err})
+					failed = append(failed, pool.Name())
+				}
+			}
+
+			if len(failed) > 0 {
+				return fmt.Errorf("Failed discarding unused blocks of storage pools: %s", strings.Join(failed, ", "))
+			}
+
+			return nil
+		}
+
+		op, err := operations.OperationCreate(s, "", operations.OperationClassTask, db.OperationStoragePoolDiscard, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start storage pool discard operation", logger.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Discarding unused storage pool blocks")
+		err = op.Start()
+		if err != nil {
+			logger.Error("Failed to discard unused storage pool blocks", logger.Ctx{"err": err})
+		}
+
+		op.Wait(ctx)
+		logger.Info("Done discarding unused storage pool blocks")
+	}
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Minute
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+)
+
+var storagePoolStateCmd = APIEndpoint{
+	Path: "storage-pools/{name}/state",
+
+	Get: APIEndpointAction{Handler: storagePoolStateGet, AccessHandler: allowAuthenticated},
+}
+
+// swagger:operation GET /1.0/storage-pools/{name}/state storage storage_pool_state_get
+//
+// Get the storage pool state
+//
+// Gets the health of the storage backing the pool along with its disk usage, broken down per volume type.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: Storage pool state
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/StoragePoolState"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func storagePoolStateGet(d *Daemon, r *http.Request) response.Response {
+	// If a target was specified, forward the request to the relevant node.
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	poolName, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	pool, err := storagePools.LoadByName(d.State(), poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	state, err := pool.GetState()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, state)
+}
@@ -119,6 +119,8 @@ func storagePoolCreateLocal(state *state.State, poolID int64, req api.StoragePoo
 		return nil, err
 	}
 
+	storagePools.ProbeIdmappedMounts(state, pool)
+
 	// In case the storage pool config was changed during the pool creation, we need to update the database to
 	// reflect this change. This can e.g. happen, when we create a loop file image. This means we append ".img"
 	// to the path the user gave us and update the config in the storage callback. So diff the config here to
@@ -169,5 +171,7 @@ func dbStoragePoolDeleteAndUpdateCache(s *state.State, poolName string) error {
 	// Update the storage drivers cache in api_1.0.go.
 	storagePoolDriversCacheUpdate(s)
 
+	storagePools.ForgetIdmappedMounts(poolName)
+
 	return err
 }
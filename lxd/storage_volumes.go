@@ -220,7 +220,20 @@ func storagePoolVolumesGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	// Trashed volumes and their snapshots are only listed through the trash API.
+	trashed := map[string]bool{}
 	for _, volume := range custVolumes {
+		if volume.Config["volatile.trash.name"] != "" {
+			trashed[volume.Name] = true
+		}
+	}
+
+	for _, volume := range custVolumes {
+		volName, _, _ := shared.InstanceGetParentAndSnapshotName(volume.Name)
+		if trashed[volName] {
+			continue
+		}
+
 		volumes = append(volumes, volume)
 	}
 
@@ -454,9 +467,28 @@ func storagePoolVolumesTypeGet(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	// Trashed volumes are only listed through the trash API.
+	trashed := map[string]bool{}
+	if volumeType == db.StoragePoolVolumeTypeCustom {
+		custVolumes, err := d.db.Cluster.GetStoragePoolVolumes(projectName, poolID, []int{db.StoragePoolVolumeTypeCustom})
+		if err != nil && !response.IsNotFoundError(err) {
+			return response.SmartError(err)
+		}
+
+		for _, volume := range custVolumes {
+			if volume.Config["volatile.trash.name"] != "" {
+				trashed[volume.Name] = true
+			}
+		}
+	}
+
 	resultString := []string{}
 	resultMap := []*api.StorageVolume{}
 	for _, volume := range volumes {
+		if trashed[volume] {
+			continue
+		}
+
 		if !recursion {
 			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, poolName, volumeTypeName, volume))
 		} else {
@@ -1752,13 +1784,26 @@ func storagePoolVolumeDelete(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	protected := false
+	if volumeType == db.StoragePoolVolumeTypeCustom {
+		protected, _, err = projectDeletionProtection(d.State(), projectName)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
 	// Use an empty operation for this sync response to pass the requestor
 	op := &operations.Operation{}
 	op.SetRequestor(r)
 
 	switch volumeType {
 	case db.StoragePoolVolumeTypeCustom:
-		err = pool.DeleteCustomVolume(projectName, volumeName, op)
+		// Keep the volume in the trash, unless it's already there.
+		if protected && volume.Config["volatile.trash.name"] == "" {
+			err = storagePoolVolumeTrash(d.State(), pool, projectName, volume, op)
+		} else {
+			err = pool.DeleteCustomVolume(projectName, volumeName, op)
+		}
 	case db.StoragePoolVolumeTypeImage:
 		err = pool.DeleteImage(volumeName, op)
 	default:
@@ -1783,12 +1828,20 @@ func createStoragePoolVolumeFromBackup(d *Daemon, r *http.Request, requestProjec
 	defer func() { _ = os.Remove(backupFile.Name()) }()
 	revert.Add(func() { _ = backupFile.Close() })
 
-	// Stream uploaded backup data into temporary file.
-	_, err = io.Copy(backupFile, data)
+	// Stream uploaded backup data into temporary file, verifying it against its manifest at the same time.
+	verifier := backup.NewManifestVerifier(d.State().OS, backupFile.Name())
+	_, err = io.Copy(io.MultiWriter(backupFile, verifier), data)
 	if err != nil {
+		_, _ = verifier.Verify()
 		return response.InternalError(err)
 	}
 
+	logger.Debug("Verifying backup file against its manifest")
+	manifestChecked, err := verifier.Verify()
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	// Detect squashfs compression and convert to tarball.
 	_, err = backupFile.Seek(0, 0)
 	if err != nil {
@@ -1835,6 +1888,16 @@ func createStoragePoolVolumeFromBackup(d *Daemon, r *http.Request, requestProjec
 	if err != nil {
 		return response.BadRequest(err)
 	}
+
+	// Backups which couldn't be verified while uploaded are verified once converted to a tarball.
+	if !manifestChecked {
+		logger.Debug("Verifying converted backup file against its manifest")
+		err = backup.VerifyManifest(backupFile, d.State().OS, backupFile.Name())
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	bInfo.Project = projectName
 
 	// Override pool.
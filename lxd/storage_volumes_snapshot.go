@@ -1058,6 +1058,7 @@ func pruneExpireCustomVolumeSnapshotsTask(d *Daemon) (task.Func, task.Schedule)
 		expiredSnapshots, err := d.db.Cluster.GetExpiredStorageVolumeSnapshots()
 		if err != nil {
 			logger.Error("Unable to retrieve the list of expired custom volume snapshots", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 			return
 		}
 
@@ -1066,12 +1067,15 @@ func pruneExpireCustomVolumeSnapshotsTask(d *Daemon) (task.Func, task.Schedule)
 		}
 
 		opRun := func(op *operations.Operation) error {
-			return pruneExpiredCustomVolumeSnapshots(ctx, d, expiredSnapshots)
+			err := pruneExpiredCustomVolumeSnapshots(ctx, d, expiredSnapshots)
+			task.ReportError(ctx, err)
+			return err
 		}
 
 		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationCustomVolumeSnapshotsExpire, nil, nil, opRun, nil, nil, nil)
 		if err != nil {
 			logger.Error("Failed to start expired custom volume snapshots operation", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 			return
 		}
 
@@ -1079,6 +1083,7 @@ func pruneExpireCustomVolumeSnapshotsTask(d *Daemon) (task.Func, task.Schedule)
 		err = op.Start()
 		if err != nil {
 			logger.Error("Failed to expire backups", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
 		}
 
 		op.Wait(ctx)
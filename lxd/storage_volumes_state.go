@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -13,6 +14,7 @@ import (
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
 	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/units"
@@ -111,14 +113,20 @@ func storagePoolVolumeTypeStateGet(d *Daemon, r *http.Request) response.Response
 		return response.SmartError(err)
 	}
 
-	// Fetch the current usage.
+	// Fetch the current usage and encryption status.
 	var used int64
+	var encryption *drivers.VolumeEncryption
 	if volumeType == db.StoragePoolVolumeTypeCustom {
 		// Custom volumes.
 		used, err = pool.GetCustomVolumeUsage(projectName, volumeName)
 		if err != nil {
 			return response.SmartError(err)
 		}
+
+		encryption, err = pool.GetCustomVolumeEncryption(projectName, volumeName)
+		if err != nil && !errors.Is(err, drivers.ErrNotSupported) {
+			return response.SmartError(err)
+		}
 	} else {
 		resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, volumeName, instancetype.Any)
 		if err != nil {
@@ -138,6 +146,11 @@ func storagePoolVolumeTypeStateGet(d *Daemon, r *http.Request) response.Response
 		if err != nil {
 			return response.SmartError(err)
 		}
+
+		encryption, err = pool.GetInstanceEncryption(inst)
+		if err != nil && !errors.Is(err, drivers.ErrNotSupported) {
+			return response.SmartError(err)
+		}
 	}
 
 	// Prepare the state struct.
@@ -163,5 +176,13 @@ func storagePoolVolumeTypeStateGet(d *Daemon, r *http.Request) response.Response
 		state.Usage.Total = total
 	}
 
+	if encryption != nil {
+		state.Encryption = &api.StorageVolumeStateEncryption{
+			Enabled:   encryption.Enabled,
+			Algorithm: encryption.Algorithm,
+			KeyStatus: encryption.KeyStatus,
+		}
+	}
+
 	return response.SyncResponse(true, state)
 }
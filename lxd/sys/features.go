@@ -0,0 +1,83 @@
+//go:build linux && cgo && !agent
+
+package sys
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// Host features which may be unavailable when LXD runs in a restricted environment, such as inside a container.
+const (
+	// FeatureSysctl is the ability to change the network sysctls in /proc/sys.
+	FeatureSysctl = "sysctl"
+
+	// FeatureSysfs is the ability to change device settings through /sys.
+	FeatureSysfs = "sysfs"
+
+	// FeatureKernelModules is the ability to load kernel modules.
+	FeatureKernelModules = "kernel_modules"
+)
+
+// featureDescriptions are the human readable descriptions of the features used in errors.
+var featureDescriptions = map[string]string{
+	FeatureSysctl:        "Changing network sysctls",
+	FeatureSysfs:         "Changing device settings through /sys",
+	FeatureKernelModules: "Loading kernel modules",
+}
+
+// initUnavailableFeatures detects the host features LXD can't use in its environment, recording why.
+func (s *OS) initUnavailableFeatures() {
+	s.UnavailableFeatures = map[string]string{}
+
+	// Network sysctls are namespaced, so they're usually writable from within a container unless /proc/sys is
+	// mounted read-only or the network namespace isn't owned by the container.
+	err := unix.Access("/proc/sys/net/ipv4/ip_forward", unix.W_OK)
+	if err != nil {
+		if errors.Is(err, unix.EROFS) {
+			s.UnavailableFeatures[FeatureSysctl] = "/proc/sys is mounted read-only"
+		} else {
+			s.UnavailableFeatures[FeatureSysctl] = fmt.Sprintf("/proc/sys/net isn't writable: %v", err)
+		}
+	}
+
+	var statfs unix.Statfs_t
+	err = unix.Statfs("/sys", &statfs)
+	if err != nil {
+		s.UnavailableFeatures[FeatureSysfs] = fmt.Sprintf("Failed checking /sys: %v", err)
+	} else if statfs.Flags&unix.ST_RDONLY != 0 {
+		s.UnavailableFeatures[FeatureSysfs] = "/sys is mounted read-only"
+	}
+
+	if s.RunningInUserNS {
+		s.UnavailableFeatures[FeatureKernelModules] = "LXD is running in a user namespace"
+	} else {
+		content, err := ioutil.ReadFile("/proc/sys/kernel/modules_disabled")
+		if err == nil && strings.TrimSpace(string(content)) == "1" {
+			s.UnavailableFeatures[FeatureKernelModules] = "Loading kernel modules is disabled"
+		} else if s.Uname != nil && !shared.PathExists(fmt.Sprintf("/lib/modules/%s", s.Uname.Release)) {
+			s.UnavailableFeatures[FeatureKernelModules] = "No modules are available for the running kernel"
+		}
+	}
+
+	for feature, reason := range s.UnavailableFeatures {
+		logger.Info("Host feature unavailable", logger.Ctx{"feature": feature, "reason": reason})
+	}
+}
+
+// CheckFeature returns an error explaining why the feature can't be used if it's unavailable.
+func (s *OS) CheckFeature(feature string) error {
+	reason, found := s.UnavailableFeatures[feature]
+	if !found {
+		return nil
+	}
+
+	return fmt.Errorf("%s isn't possible in the LXD environment: %s", featureDescriptions[feature], reason)
+}
@@ -93,6 +93,9 @@ type OS struct {
 	// LXC features
 	LXCFeatures map[string]bool
 
+	// Host features LXD can't use in its environment, with the reason why.
+	UnavailableFeatures map[string]string
+
 	// VM features
 	VsockID uint32
 
@@ -208,6 +211,8 @@ func (s *OS) Init() ([]db.Warning, error) {
 		s.KernelVersion = *kernelVersion
 	}
 
+	s.initUnavailableFeatures()
+
 	return dbWarnings, nil
 }
 
@@ -14,7 +14,7 @@ import (
 type Group struct {
 	cancel  func()
 	wg      sync.WaitGroup
-	tasks   []Task
+	tasks   []*Task
 	running map[int]bool
 	mu      sync.Mutex
 }
@@ -24,12 +24,24 @@ func (g *Group) Add(f Func, schedule Schedule) *Task {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	i := len(g.tasks)
-	g.tasks = append(g.tasks, Task{
+	g.tasks = append(g.tasks, &Task{
 		f:        f,
 		schedule: schedule,
 		reset:    make(chan struct{}, 16), // Buffered to not block senders
+		trigger:  make(chan struct{}, 1),  // Buffered to hold a pending trigger
 	})
-	return &g.tasks[i]
+	return g.tasks[i]
+}
+
+// Tasks returns the tasks of the group.
+func (g *Group) Tasks() []*Task {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tasks := make([]*Task, len(g.tasks))
+	copy(tasks, g.tasks)
+
+	return tasks
 }
 
 // Start all the tasks in the group.
@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -11,6 +12,68 @@ type Task struct {
 	f        Func          // Function to execute.
 	schedule Schedule      // Decides if and when to execute f.
 	reset    chan struct{} // Resets the shedule and starts over.
+	trigger  chan struct{} // Executes f right away.
+
+	mu     sync.Mutex
+	name   string
+	status Status
+	runErr error // Failure reported by the current execution of f.
+}
+
+// Status captures the execution history of a task.
+type Status struct {
+	Running        bool          // Whether the task function is currently executing.
+	Paused         bool          // Whether scheduled executions are skipped.
+	LastRun        time.Time     // Start time of the last execution.
+	LastDuration   time.Duration // Duration of the last execution.
+	NextRun        time.Time     // Time of the next scheduled execution (zero if none).
+	Runs           int           // Number of executions.
+	Failures       int           // Number of executions which reported an error.
+	LastFailure    time.Time     // Start time of the last execution which reported an error.
+	LastFailureMsg string        // Error reported by the last failed execution.
+}
+
+type contextKey struct{}
+
+// ReportError records the given error as the outcome of the execution of the
+// task function which was passed the given context.
+//
+// It's a no-op if the context doesn't come from a task.
+func ReportError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+
+	t, ok := ctx.Value(contextKey{}).(*Task)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.runErr = err
+}
+
+// SetName sets the name used to identify the task, returning the task itself.
+func (t *Task) SetName(name string) *Task {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.name = name
+	return t
+}
+
+// Name returns the name of the task.
+func (t *Task) Name() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.name
+}
+
+// Status returns the current execution status of the task.
+func (t *Task) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
 }
 
 // Reset the state of the task as if it had just been started.
@@ -22,6 +85,31 @@ func (t *Task) Reset() {
 	t.reset <- struct{}{}
 }
 
+// Trigger an immediate execution of the task function, regardless of the
+// schedule and of whether the task is paused.
+//
+// Triggering a task which already has a pending trigger is a no-op.
+func (t *Task) Trigger() {
+	select {
+	case t.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Pause the task, skipping its scheduled executions until resumed.
+func (t *Task) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Paused = true
+}
+
+// Resume the scheduled executions of a paused task.
+func (t *Task) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Paused = false
+}
+
 // Execute the our task function according to our schedule, until the given
 // context gets cancelled.
 func (t *Task) loop(ctx context.Context) {
@@ -31,6 +119,7 @@ func (t *Task) loop(ctx context.Context) {
 
 	for {
 		var timer <-chan time.Time
+		var next time.Time
 
 		schedule, err := t.schedule()
 		switch err {
@@ -50,6 +139,7 @@ func (t *Task) loop(ctx context.Context) {
 			// returning values greater than zero).
 			if schedule > 0 {
 				timer = time.After(delay)
+				next = time.Now().Add(delay)
 			} else {
 				timer = make(chan time.Time)
 			}
@@ -58,25 +148,30 @@ func (t *Task) loop(ctx context.Context) {
 			// task and return immediately. Otherwise set up the
 			// timer to retry after that amount of time.
 			if schedule <= 0 {
+				t.setNextRun(time.Time{})
 				return
 			}
 			timer = time.After(schedule)
 		}
 
+		t.setNextRun(next)
+
 		select {
 		case <-timer:
-			if err == nil {
+			if err == nil && !t.Status().Paused {
 				// Execute the task function synchronously. Consumers
 				// are responsible for implementing proper cancellation
 				// of the task function itself using the tomb's context.
-				start := time.Now()
-				t.f(ctx)
-				duration := time.Since(start)
+				duration := t.run(ctx)
 
 				delay = schedule - duration
 				if delay < 0 {
 					delay = immediately
 				}
+			} else if err == nil {
+				// Paused, wait for a whole schedule interval
+				// before checking again.
+				delay = schedule
 			} else {
 				// Don't execute the task function, and set the
 				// delay to run it immediately whenever the
@@ -84,12 +179,58 @@ func (t *Task) loop(ctx context.Context) {
 				delay = immediately
 			}
 		case <-ctx.Done():
+			t.setNextRun(time.Time{})
 			return
 
 		case <-t.reset:
 			delay = immediately
+
+		case <-t.trigger:
+			duration := t.run(ctx)
+
+			delay = schedule - duration
+			if delay < 0 {
+				delay = immediately
+			}
 		}
 	}
 }
 
+// Execute the task function once, recording its outcome.
+func (t *Task) run(ctx context.Context) time.Duration {
+	start := time.Now()
+
+	t.mu.Lock()
+	t.status.Running = true
+	t.status.NextRun = time.Time{}
+	t.runErr = nil
+	t.mu.Unlock()
+
+	t.f(context.WithValue(ctx, contextKey{}, t))
+	duration := time.Since(start)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Running = false
+	t.status.LastRun = start
+	t.status.LastDuration = duration
+	t.status.Runs++
+
+	if t.runErr != nil {
+		t.status.Failures++
+		t.status.LastFailure = start
+		t.status.LastFailureMsg = t.runErr.Error()
+		t.runErr = nil
+	}
+
+	return duration
+}
+
+func (t *Task) setNextRun(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.NextRun = next
+}
+
 const immediately = 0 * time.Second
@@ -90,6 +90,67 @@ func TestTask_SkipFirst(t *testing.T) {
 	assert.Equal(t, 1, i) // The function got executed only once, not twice.
 }
 
+// If the task is triggered, the task function is executed right away even if
+// its schedule says otherwise.
+func TestTask_Trigger(t *testing.T) {
+	f, wait := newFunc(t, 2)
+	group := &task.Group{}
+	tsk := group.Add(f, task.Every(time.Hour))
+	group.Start(context.Background())
+	defer func() { assert.NoError(t, group.Stop(time.Second)) }()
+
+	wait(50 * time.Millisecond) // First execution, immediately
+	tsk.Trigger()
+	wait(50 * time.Millisecond) // Second execution, triggered
+}
+
+// If the task is paused, its scheduled executions are skipped until it gets
+// resumed.
+func TestTask_Pause(t *testing.T) {
+	i := 0
+	f := func(context.Context) {
+		i++
+	}
+
+	group := &task.Group{}
+	tsk := group.Add(f, task.Every(100*time.Millisecond, task.SkipFirst))
+	tsk.Pause()
+	group.Start(context.Background())
+
+	time.Sleep(250 * time.Millisecond)
+	assert.True(t, tsk.Status().Paused)
+	assert.NoError(t, group.Stop(time.Second))
+	assert.Equal(t, 0, i)
+}
+
+// The status of the task records its executions and the errors they reported.
+func TestTask_Status(t *testing.T) {
+	runs := make(chan struct{})
+	f := func(ctx context.Context) {
+		task.ReportError(ctx, fmt.Errorf("boom"))
+		runs <- struct{}{}
+	}
+
+	group := &task.Group{}
+	tsk := group.Add(f, task.Every(time.Hour)).SetName("boom")
+	group.Start(context.Background())
+	defer func() { assert.NoError(t, group.Stop(time.Second)) }()
+
+	<-runs
+
+	// Wait for the outcome of the execution to be recorded.
+	for tsk.Status().Running {
+		time.Sleep(time.Millisecond)
+	}
+
+	status := tsk.Status()
+	assert.Equal(t, "boom", tsk.Name())
+	assert.Equal(t, 1, status.Runs)
+	assert.Equal(t, 1, status.Failures)
+	assert.Equal(t, "boom", status.LastFailureMsg)
+	assert.False(t, status.LastRun.IsZero())
+}
+
 // Create a new task function that sends a notification to a channel every time
 // it's run.
 //
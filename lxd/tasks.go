@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var tasksCmd = APIEndpoint{
+	Path: "tasks",
+
+	Get: APIEndpointAction{Handler: tasksGet},
+}
+
+var taskCmd = APIEndpoint{
+	Path: "tasks/{name}",
+
+	Get:  APIEndpointAction{Handler: taskGet},
+	Post: APIEndpointAction{Handler: taskPost},
+}
+
+// daemonTasks returns the named background tasks of the daemon, sorted by name.
+func daemonTasks(d *Daemon) []*task.Task {
+	tasks := []*task.Task{}
+	for _, t := range append(d.tasks.Tasks(), d.clusterTasks.Tasks()...) {
+		if t.Name() == "" {
+			continue
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name() < tasks[j].Name() })
+
+	return tasks
+}
+
+// daemonTask returns the background task of the daemon with the given name.
+func daemonTask(d *Daemon, name string) (*task.Task, error) {
+	for _, t := range daemonTasks(d) {
+		if t.Name() == name {
+			return t, nil
+		}
+	}
+
+	return nil, api.StatusErrorf(http.StatusNotFound, "Task not found")
+}
+
+// taskToAPI converts the background task to its API representation.
+func taskToAPI(d *Daemon, t *task.Task) api.Task {
+	status := t.Status()
+
+	apiTask := api.Task{
+		Name:          t.Name(),
+		Location:      d.State().ServerName,
+		Running:       status.Running,
+		Paused:        status.Paused,
+		LastRunAt:     status.LastRun,
+		NextRunAt:     status.NextRun,
+		Runs:          status.Runs,
+		Failures:      status.Failures,
+		LastFailureAt: status.LastFailure,
+		LastFailure:   status.LastFailureMsg,
+	}
+
+	if !status.LastRun.IsZero() {
+		apiTask.LastRunDuration = status.LastDuration.String()
+	}
+
+	return apiTask
+}
+
+// swagger:operation GET /1.0/tasks tasks tasks_get
+//
+// Get the background tasks
+//
+// Returns a list of the periodic background tasks of the server (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/tasks/prune-images",
+//               "/1.0/tasks/update-images"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/tasks?recursion=1 tasks tasks_get_recursion1
+//
+// Get the background tasks
+//
+// Returns a list of the periodic background tasks of the server (structs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of background tasks
+//           items:
+//             $ref: "#/definitions/Task"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func tasksGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	tasks := daemonTasks(d)
+
+	if util.IsRecursionRequest(r) {
+		apiTasks := make([]api.Task, 0, len(tasks))
+		for _, t := range tasks {
+			apiTasks = append(apiTasks, taskToAPI(d, t))
+		}
+
+		return response.SyncResponse(true, apiTasks)
+	}
+
+	taskURLs := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		taskURLs = append(taskURLs, api.NewURL().Path(version.APIVersion, "tasks", t.Name()).String())
+	}
+
+	return response.SyncResponse(true, taskURLs)
+}
+
+// swagger:operation GET /1.0/tasks/{name} tasks task_get
+//
+// Get the background task
+//
+// Gets a specific periodic background task of the server.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     description: Background task
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/Task"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func taskGet(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	t, err := daemonTask(d, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, taskToAPI(d, t))
+}
+
+// swagger:operation POST /1.0/tasks/{name} tasks task_post
+//
+// Control the background task
+//
+// Runs the background task right away (run), skips its scheduled runs (pause)
+// or schedules it again (resume).
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: task
+//     description: Task action
+//     required: true
+//     schema:
+//       $ref: "#/definitions/TaskPost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func taskPost(d *Daemon, r *http.Request) response.Response {
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.TaskPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	t, err := daemonTask(d, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	switch req.Action {
+	case "run":
+		t.Trigger()
+	case "pause":
+		t.Pause()
+	case "resume":
+		t.Resume()
+	default:
+		return response.BadRequest(fmt.Errorf("Invalid task action %q", req.Action))
+	}
+
+	return response.EmptySyncResponse
+}
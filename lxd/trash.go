@@ -0,0 +1,1148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	dbCluster "github.com/lxc/lxd/lxd/db/cluster"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/state"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/task"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// trashRetentionDefault is how long deleted instances and volumes are kept when deletion.retention isn't set.
+const trashRetentionDefault = "7d"
+
+var trashCmd = APIEndpoint{
+	Path: "trash",
+
+	Get: APIEndpointAction{Handler: trashGet, AccessHandler: allowProjectPermission("containers", "view")},
+}
+
+var trashInstanceCmd = APIEndpoint{
+	Path: "trash/instances/{name}",
+
+	Delete: APIEndpointAction{Handler: trashInstanceDelete, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+	Get:    APIEndpointAction{Handler: trashInstanceGet, AccessHandler: allowProjectPermission("containers", "view")},
+	Post:   APIEndpointAction{Handler: trashInstancePost, AccessHandler: allowProjectPermission("containers", "manage-containers")},
+}
+
+var trashStoragePoolVolumeCmd = APIEndpoint{
+	Path: "trash/storage-pools/{pool}/volumes/{name}",
+
+	Delete: APIEndpointAction{Handler: trashStoragePoolVolumeDelete, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Get:    APIEndpointAction{Handler: trashStoragePoolVolumeGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post:   APIEndpointAction{Handler: trashStoragePoolVolumePost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+// projectTrashRetention returns how long the deleted instances and volumes of a project are kept for.
+func projectTrashRetention(config map[string]string) string {
+	if config["deletion.retention"] == "" {
+		return trashRetentionDefault
+	}
+
+	return config["deletion.retention"]
+}
+
+// projectDeletionProtection returns whether the deleted instances and volumes of the project are kept in the
+// trash, along with how long they are kept for.
+func projectDeletionProtection(s *state.State, projectName string) (bool, string, error) {
+	var config map[string]string
+	err := s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		p, err := dbCluster.GetProject(ctx, tx.Tx(), projectName)
+		if err != nil {
+			return err
+		}
+
+		config, err = dbCluster.GetProjectConfig(ctx, tx.Tx(), p.ID)
+		return err
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("Failed loading project %q: %w", projectName, err)
+	}
+
+	return shared.IsTrue(config["deletion.protection"]), projectTrashRetention(config), nil
+}
+
+// trashName returns a new name to keep a deleted instance or volume under.
+// It is a valid instance and volume name which is unlikely to clash with the ones picked by users.
+func trashName() string {
+	return fmt.Sprintf("trash-%x", time.Now().UnixNano())
+}
+
+// trashExpiry returns the deletion and purge dates of a trashed instance or volume.
+func trashExpiry(deletedAt string, retention string) (time.Time, time.Time, error) {
+	date, err := time.Parse(time.RFC3339, deletedAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid deletion date %q: %w", deletedAt, err)
+	}
+
+	expiry, err := shared.GetSnapshotExpiry(date, retention)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid retention %q: %w", retention, err)
+	}
+
+	return date, expiry, nil
+}
+
+// trashEntries returns the trashed instances and custom volumes of the project.
+func trashEntries(s *state.State, projectName string) ([]api.TrashEntry, error) {
+	_, retention, err := projectDeletionProtection(s, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Custom volumes may be held by the default project.
+	volProjectName, err := project.StorageVolumeProject(s.DB.Cluster, projectName, db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return nil, err
+	}
+
+	_, volRetention, err := projectDeletionProtection(s, volProjectName)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []api.TrashEntry{}
+	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		instances, err := tx.GetTrashedInstances([]string{projectName})
+		if err != nil {
+			return fmt.Errorf("Failed loading trashed instances: %w", err)
+		}
+
+		for _, inst := range instances {
+			entry := api.TrashEntry{
+				Type:      "instance",
+				Name:      inst.OriginalName,
+				TrashName: inst.Name,
+				Project:   inst.Project,
+				Location:  inst.Node,
+			}
+
+			// Keep listing entries with a broken deletion date so they can still be handled.
+			entry.DeletedAt, entry.ExpiresAt, _ = trashExpiry(inst.DeletedAt, retention)
+			entries = append(entries, entry)
+		}
+
+		nodes, err := tx.GetNodes()
+		if err != nil {
+			return fmt.Errorf("Failed loading cluster members: %w", err)
+		}
+
+		nodeNames := make(map[int64]string, len(nodes))
+		for _, n := range nodes {
+			nodeNames[n.ID] = n.Name
+		}
+
+		volumes, err := tx.GetStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return fmt.Errorf("Failed loading custom volumes: %w", err)
+		}
+
+		for _, vol := range volumes {
+			if vol.ProjectName != volProjectName || vol.Config["volatile.trash.name"] == "" {
+				continue
+			}
+
+			entry := api.TrashEntry{
+				Type:      "volume",
+				Name:      vol.Config["volatile.trash.name"],
+				TrashName: vol.Name,
+				Project:   vol.ProjectName,
+				Pool:      vol.PoolName,
+				Location:  nodeNames[vol.NodeID],
+			}
+
+			entry.DeletedAt, entry.ExpiresAt, _ = trashExpiry(vol.Config["volatile.trash.date"], volRetention)
+			entries = append(entries, entry)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// trashEntryURL returns the URL of the trash entry.
+func trashEntryURL(entry api.TrashEntry) string {
+	if entry.Type == "volume" {
+		return api.NewURL().Path(version.APIVersion, "trash", "storage-pools", entry.Pool, "volumes", entry.TrashName).Project(entry.Project).String()
+	}
+
+	return api.NewURL().Path(version.APIVersion, "trash", "instances", entry.TrashName).Project(entry.Project).String()
+}
+
+// projectTrashURLs returns the trash URLs of the trashed instances and custom volumes held by the project, indexed
+// by their regular URLs.
+func projectTrashURLs(tx *db.ClusterTx, projectName string) (map[string]string, error) {
+	urls := map[string]string{}
+
+	instances, err := tx.GetTrashedInstances([]string{projectName})
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading trashed instances: %w", err)
+	}
+
+	for _, inst := range instances {
+		uri := api.NewURL().Path(version.APIVersion, "instances", inst.Name).Project(inst.Project)
+		urls[uri.String()] = trashEntryURL(api.TrashEntry{Type: "instance", TrashName: inst.Name, Project: inst.Project})
+	}
+
+	nodes, err := tx.GetNodes()
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading cluster members: %w", err)
+	}
+
+	nodeNames := make(map[int64]string, len(nodes))
+	for _, n := range nodes {
+		nodeNames[n.ID] = n.Name
+	}
+
+	volumes, err := tx.GetStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading custom volumes: %w", err)
+	}
+
+	for _, vol := range volumes {
+		if vol.ProjectName != projectName || vol.Config["volatile.trash.name"] == "" {
+			continue
+		}
+
+		// Same as the URLs returned by GetStorageVolumeURIs.
+		uri := api.NewURL().Path(version.APIVersion, "storage-pools", fmt.Sprintf("%s/volumes/custom/%s", vol.PoolName, vol.Name)).Project(projectName)
+		if vol.NodeID != -1 {
+			uri.Target(nodeNames[vol.NodeID])
+		}
+
+		urls[uri.String()] = trashEntryURL(api.TrashEntry{Type: "volume", TrashName: vol.Name, Project: vol.ProjectName, Pool: vol.PoolName})
+	}
+
+	return urls, nil
+}
+
+// instanceIsTrashed returns whether the instance is kept in the trash.
+func instanceIsTrashed(inst instance.Instance) bool {
+	return inst.LocalConfig()["volatile.trash.name"] != ""
+}
+
+// instanceTrashedCheck returns an error if the instance is kept in the trash.
+// Trashed instances are left alone until they get restored.
+func instanceTrashedCheck(inst instance.Instance) error {
+	if instanceIsTrashed(inst) {
+		return api.StatusErrorf(http.StatusBadRequest, "Instance %q is in the trash and must be restored first", inst.Name())
+	}
+
+	return nil
+}
+
+// instanceTrash renames the instance into the trash rather than deleting it.
+func instanceTrash(inst instance.Instance) error {
+	name := inst.Name()
+
+	err := inst.Rename(trashName(), false)
+	if err != nil {
+		return fmt.Errorf("Failed moving instance to the trash: %w", err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	revert.Add(func() { _ = inst.Rename(name, false) })
+
+	err = inst.VolatileSet(map[string]string{
+		"volatile.trash.name": name,
+		"volatile.trash.date": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// instanceTrashRestore renames the trashed instance back out of the trash.
+func instanceTrashRestore(inst instance.Instance, name string) error {
+	trashedName := inst.Name()
+
+	err := inst.Rename(name, false)
+	if err != nil {
+		return fmt.Errorf("Failed restoring instance from the trash: %w", err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	revert.Add(func() { _ = inst.Rename(trashedName, false) })
+
+	err = inst.VolatileSet(map[string]string{
+		"volatile.trash.name": "",
+		"volatile.trash.date": "",
+	})
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// storagePoolVolumeTrash renames the custom volume into the trash rather than deleting it.
+func storagePoolVolumeTrash(s *state.State, pool storagePools.Pool, projectName string, vol *api.StorageVolume, op *operations.Operation) error {
+	newName := trashName()
+
+	err := pool.RenameCustomVolume(projectName, vol.Name, newName, op)
+	if err != nil {
+		return fmt.Errorf("Failed moving volume to the trash: %w", err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	revert.Add(func() { _ = pool.RenameCustomVolume(projectName, newName, vol.Name, op) })
+
+	config := make(map[string]string, len(vol.Config)+2)
+	for k, v := range vol.Config {
+		config[k] = v
+	}
+
+	config["volatile.trash.name"] = vol.Name
+	config["volatile.trash.date"] = time.Now().UTC().Format(time.RFC3339)
+
+	err = s.DB.Cluster.UpdateStoragePoolVolume(projectName, newName, db.StoragePoolVolumeTypeCustom, pool.ID(), vol.Description, config)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// storagePoolVolumeTrashRestore renames the trashed custom volume back out of the trash.
+func storagePoolVolumeTrashRestore(s *state.State, pool storagePools.Pool, projectName string, vol *api.StorageVolume, name string, op *operations.Operation) error {
+	err := pool.RenameCustomVolume(projectName, vol.Name, name, op)
+	if err != nil {
+		return fmt.Errorf("Failed restoring volume from the trash: %w", err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	revert.Add(func() { _ = pool.RenameCustomVolume(projectName, name, vol.Name, op) })
+
+	config := make(map[string]string, len(vol.Config))
+	for k, v := range vol.Config {
+		config[k] = v
+	}
+
+	delete(config, "volatile.trash.name")
+	delete(config, "volatile.trash.date")
+
+	err = s.DB.Cluster.UpdateStoragePoolVolume(projectName, name, db.StoragePoolVolumeTypeCustom, pool.ID(), vol.Description, config)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// trashInstanceLoad loads the trashed instance from the request.
+func trashInstanceLoad(s *state.State, projectName string, name string) (instance.Instance, error) {
+	inst, err := instance.LoadByProjectAndName(s, projectName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !instanceIsTrashed(inst) {
+		return nil, api.StatusErrorf(http.StatusNotFound, "Instance %q isn't in the trash", name)
+	}
+
+	return inst, nil
+}
+
+// trashStoragePoolVolumeLoad loads the pool and trashed custom volume from the request.
+func trashStoragePoolVolumeLoad(s *state.State, projectName string, poolName string, name string) (storagePools.Pool, *api.StorageVolume, error) {
+	pool, err := storagePools.LoadByName(s, poolName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, vol, err := s.DB.Cluster.GetLocalStoragePoolVolume(projectName, name, db.StoragePoolVolumeTypeCustom, pool.ID())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if vol.Config["volatile.trash.name"] == "" {
+		return nil, nil, api.StatusErrorf(http.StatusNotFound, "Volume %q isn't in the trash", name)
+	}
+
+	return pool, vol, nil
+}
+
+// trashStoragePoolVolumeVars returns the effective project, pool and volume names of the request.
+func trashStoragePoolVolumeVars(d *Daemon, r *http.Request) (string, string, string, error) {
+	projectName, err := project.StorageVolumeProject(d.State().DB.Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	poolName, err := url.PathUnescape(mux.Vars(r)["pool"])
+	if err != nil {
+		return "", "", "", err
+	}
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return projectName, poolName, name, nil
+}
+
+// API endpoints
+
+// swagger:operation GET /1.0/trash trash trash_get
+//
+// Get the trash entries
+//
+// Returns a list of trashed instances and custom storage volumes (URLs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of endpoints
+//           items:
+//             type: string
+//           example: |-
+//             [
+//               "/1.0/trash/instances/trash-16f8a1b2c3d4e5f6",
+//               "/1.0/trash/storage-pools/local/volumes/trash-16f8a1b2c3d4e5f7"
+//             ]
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+
+// swagger:operation GET /1.0/trash?recursion=1 trash trash_get_recursion1
+//
+// Get the trash entries
+//
+// Returns a list of trashed instances and custom storage volumes (structs).
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: API endpoints
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           type: array
+//           description: List of trash entries
+//           items:
+//             $ref: "#/definitions/TrashEntry"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func trashGet(d *Daemon, r *http.Request) response.Response {
+	entries, err := trashEntries(d.State(), projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if util.IsRecursionRequest(r) {
+		return response.SyncResponse(true, entries)
+	}
+
+	entryURLs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entryURLs = append(entryURLs, trashEntryURL(entry))
+	}
+
+	return response.SyncResponse(true, entryURLs)
+}
+
+// swagger:operation GET /1.0/trash/instances/{name} trash trash_instance_get
+//
+// Get the trashed instance
+//
+// Gets a specific trashed instance.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: Trash entry
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/TrashEntry"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func trashInstanceGet(d *Daemon, r *http.Request) response.Response {
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	entries, err := trashEntries(d.State(), projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	for _, entry := range entries {
+		if entry.Type == "instance" && entry.TrashName == name {
+			return response.SyncResponse(true, entry)
+		}
+	}
+
+	return response.NotFound(fmt.Errorf("Instance %q isn't in the trash", name))
+}
+
+// swagger:operation POST /1.0/trash/instances/{name} trash trash_instance_post
+//
+// Restore the trashed instance
+//
+// Moves the instance out of the trash, under its name before deletion unless another one is provided.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: body
+//     name: entry
+//     description: Restore request
+//     schema:
+//       $ref: "#/definitions/TrashEntryPost"
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func trashInstancePost(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Handle requests targeted to an instance on a different member.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instancetype.Any)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	req := api.TrashEntryPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return response.BadRequest(err)
+	}
+
+	inst, err := trashInstanceLoad(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if req.Name == "" {
+		req.Name = inst.LocalConfig()["volatile.trash.name"]
+	}
+
+	err = instance.ValidName(req.Name, false)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Check that the name isn't already in use.
+	id, _ := d.db.Cluster.GetInstanceID(projectName, req.Name)
+	if id > 0 {
+		return response.Conflict(fmt.Errorf("Name %q already in use", req.Name))
+	}
+
+	run := func(op *operations.Operation) error {
+		return instanceTrashRestore(inst, req.Name)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceRename, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// swagger:operation DELETE /1.0/trash/instances/{name} trash trash_instance_delete
+//
+// Purge the trashed instance
+//
+// Deletes the trashed instance for good, without waiting for the end of its retention.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "202":
+//     $ref: "#/responses/Operation"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func trashInstanceDelete(d *Daemon, r *http.Request) response.Response {
+	projectName := projectParam(r)
+
+	name, err := url.PathUnescape(mux.Vars(r)["name"])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Handle requests targeted to an instance on a different member.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, projectName, name, instancetype.Any)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if resp != nil {
+		return resp
+	}
+
+	inst, err := trashInstanceLoad(d.State(), projectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.IsRunning() {
+		return response.BadRequest(api.ErrorWithType(api.ErrorTypeInstanceRunning, fmt.Errorf("Instance is running")))
+	}
+
+	run := func(op *operations.Operation) error {
+		return inst.Delete(false)
+	}
+
+	resources := map[string][]string{}
+	resources["instances"] = []string{name}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationInstanceDelete, resources, nil, run, nil, nil, r)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+// swagger:operation GET /1.0/trash/storage-pools/{pool}/volumes/{name} trash trash_storage_pool_volume_get
+//
+// Get the trashed storage volume
+//
+// Gets a specific trashed custom storage volume.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+// responses:
+//   "200":
+//     description: Trash entry
+//     schema:
+//       type: object
+//       description: Sync response
+//       properties:
+//         type:
+//           type: string
+//           description: Response type
+//           example: sync
+//         status:
+//           type: string
+//           description: Status description
+//           example: Success
+//         status_code:
+//           type: integer
+//           description: Status code
+//           example: 200
+//         metadata:
+//           $ref: "#/definitions/TrashEntry"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func trashStoragePoolVolumeGet(d *Daemon, r *http.Request) response.Response {
+	_, poolName, name, err := trashStoragePoolVolumeVars(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	entries, err := trashEntries(d.State(), projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	for _, entry := range entries {
+		if entry.Type == "volume" && entry.Pool == poolName && entry.TrashName == name {
+			return response.SyncResponse(true, entry)
+		}
+	}
+
+	return response.NotFound(fmt.Errorf("Volume %q isn't in the trash", name))
+}
+
+// swagger:operation POST /1.0/trash/storage-pools/{pool}/volumes/{name} trash trash_storage_pool_volume_post
+//
+// Restore the trashed storage volume
+//
+// Moves the custom storage volume out of the trash, under its name before deletion unless another one is provided.
+//
+// ---
+// consumes:
+//   - application/json
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+//   - in: body
+//     name: entry
+//     description: Restore request
+//     schema:
+//       $ref: "#/definitions/TrashEntryPost"
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func trashStoragePoolVolumePost(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, name, err := trashStoragePoolVolumeVars(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	resp = forwardedResponseIfVolumeIsRemote(d, r, poolName, projectName, name, db.StoragePoolVolumeTypeCustom)
+	if resp != nil {
+		return resp
+	}
+
+	req := api.TrashEntryPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return response.BadRequest(err)
+	}
+
+	pool, vol, err := trashStoragePoolVolumeLoad(d.State(), projectName, poolName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if req.Name == "" {
+		req.Name = vol.Config["volatile.trash.name"]
+	}
+
+	if shared.IsSnapshot(req.Name) {
+		return response.BadRequest(fmt.Errorf("Storage volume names may not contain slashes"))
+	}
+
+	// Check that the name isn't already in use.
+	_, err = d.db.Cluster.GetStoragePoolNodeVolumeID(projectName, req.Name, db.StoragePoolVolumeTypeCustom, pool.ID())
+	if !response.IsNotFoundError(err) {
+		if err != nil {
+			return response.InternalError(err)
+		}
+
+		return response.Conflict(fmt.Errorf("Volume by that name already exists"))
+	}
+
+	// Use an empty operation for this sync response to pass the requestor.
+	op := &operations.Operation{}
+	op.SetRequestor(r)
+
+	err = storagePoolVolumeTrashRestore(d.State(), pool, projectName, vol, req.Name, op)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/storage-pools/%s/volumes/%s/%s", version.APIVersion, pool.Name(), db.StoragePoolVolumeTypeNameCustom, req.Name))
+}
+
+// swagger:operation DELETE /1.0/trash/storage-pools/{pool}/volumes/{name} trash trash_storage_pool_volume_delete
+//
+// Purge the trashed storage volume
+//
+// Deletes the trashed custom storage volume for good, without waiting for the end of its retention.
+//
+// ---
+// produces:
+//   - application/json
+// parameters:
+//   - in: query
+//     name: project
+//     description: Project name
+//     type: string
+//     example: default
+//   - in: query
+//     name: target
+//     description: Cluster member name
+//     type: string
+//     example: lxd01
+// responses:
+//   "200":
+//     $ref: "#/responses/EmptySyncResponse"
+//   "400":
+//     $ref: "#/responses/BadRequest"
+//   "403":
+//     $ref: "#/responses/Forbidden"
+//   "404":
+//     $ref: "#/responses/NotFound"
+//   "500":
+//     $ref: "#/responses/InternalServerError"
+func trashStoragePoolVolumeDelete(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, name, err := trashStoragePoolVolumeVars(d, r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	resp = forwardedResponseIfVolumeIsRemote(d, r, poolName, projectName, name, db.StoragePoolVolumeTypeCustom)
+	if resp != nil {
+		return resp
+	}
+
+	pool, _, err := trashStoragePoolVolumeLoad(d.State(), projectName, poolName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Use an empty operation for this sync response to pass the requestor.
+	op := &operations.Operation{}
+	op.SetRequestor(r)
+
+	err = pool.DeleteCustomVolume(projectName, name, op)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// pruneExpiredTrashTask purges the trashed instances and custom volumes past their project's retention (hourly).
+func pruneExpiredTrashTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		s := d.State()
+
+		instances, volumes, err := trashExpired(d)
+		if err != nil {
+			logger.Error("Failed getting the expired trash entries", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
+			return
+		}
+
+		if len(instances) == 0 && len(volumes) == 0 {
+			return
+		}
+
+		opRun := func(op *operations.Operation) error {
+			pruneExpiredTrash(ctx, s, instances, volumes)
+			return nil
+		}
+
+		op, err := operations.OperationCreate(s, "", operations.OperationClassTask, db.OperationTrashExpire, nil, nil, opRun, nil, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start expired trash operation", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
+			return
+		}
+
+		logger.Info("Pruning expired trash")
+		err = op.Start()
+		if err != nil {
+			logger.Error("Failed to prune expired trash", logger.Ctx{"err": err})
+			task.ReportError(ctx, err)
+		}
+
+		op.Wait(ctx)
+		logger.Info("Done pruning expired trash")
+	}
+
+	return f, task.Hourly()
+}
+
+// trashExpired returns the trashed instances and custom volumes past their retention which this member purges.
+// Volumes on remote pools are purged by the leader.
+func trashExpired(d *Daemon) ([]instance.Instance, []db.StorageVolumeArgs, error) {
+	s := d.State()
+
+	isLeader := true
+	localAddress, err := node.ClusterAddress(d.db.Node)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if localAddress != "" {
+		leader, err := d.gateway.LeaderAddress()
+		if err != nil && !errors.Is(err, cluster.ErrNodeIsNotClustered) {
+			return nil, nil, err
+		}
+
+		isLeader = err != nil || leader == localAddress
+	}
+
+	now := time.Now()
+	retentions := map[string]string{}
+	volumes := []db.StorageVolumeArgs{}
+	err = s.DB.Cluster.Transaction(context.TODO(), func(ctx context.Context, tx *db.ClusterTx) error {
+		projects, err := dbCluster.GetProjects(ctx, tx.Tx(), dbCluster.ProjectFilter{})
+		if err != nil {
+			return fmt.Errorf("Failed loading projects: %w", err)
+		}
+
+		for _, p := range projects {
+			config, err := dbCluster.GetProjectConfig(ctx, tx.Tx(), p.ID)
+			if err != nil {
+				return fmt.Errorf("Failed loading project %q config: %w", p.Name, err)
+			}
+
+			retentions[p.Name] = projectTrashRetention(config)
+		}
+
+		allVolumes, err := tx.GetStoragePoolVolumesWithType(db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return fmt.Errorf("Failed loading custom volumes: %w", err)
+		}
+
+		localNodeID := tx.GetNodeID()
+		for _, vol := range allVolumes {
+			if vol.Config["volatile.trash.name"] == "" {
+				continue
+			}
+
+			if vol.NodeID != localNodeID && (vol.NodeID >= 0 || !isLeader) {
+				continue
+			}
+
+			_, expiry, err := trashExpiry(vol.Config["volatile.trash.date"], retentions[vol.ProjectName])
+			if err != nil {
+				logger.Warn("Skipping trashed volume", logger.Ctx{"project": vol.ProjectName, "pool": vol.PoolName, "volume": vol.Name, "err": err})
+				continue
+			}
+
+			if expiry.Before(now) {
+				volumes = append(volumes, vol)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allInstances, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed loading instances: %w", err)
+	}
+
+	instances := []instance.Instance{}
+	for _, inst := range allInstances {
+		if inst.LocalConfig()["volatile.trash.name"] == "" {
+			continue
+		}
+
+		_, expiry, err := trashExpiry(inst.LocalConfig()["volatile.trash.date"], retentions[inst.Project()])
+		if err != nil {
+			logger.Warn("Skipping trashed instance", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+			continue
+		}
+
+		if expiry.Before(now) {
+			instances = append(instances, inst)
+		}
+	}
+
+	return instances, volumes, nil
+}
+
+// pruneExpiredTrash deletes the given trashed instances and custom volumes.
+func pruneExpiredTrash(ctx context.Context, s *state.State, instances []instance.Instance, volumes []db.StorageVolumeArgs) {
+	for _, inst := range instances {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := inst.Delete(false)
+		if err != nil {
+			logger.Error("Failed purging trashed instance", logger.Ctx{"project": inst.Project(), "instance": inst.Name(), "err": err})
+		}
+	}
+
+	for _, vol := range volumes {
+		if ctx.Err() != nil {
+			return
+		}
+
+		pool, err := storagePools.LoadByName(s, vol.PoolName)
+		if err != nil {
+			logger.Error("Failed loading pool of trashed volume", logger.Ctx{"project": vol.ProjectName, "pool": vol.PoolName, "volume": vol.Name, "err": err})
+			continue
+		}
+
+		err = pool.DeleteCustomVolume(vol.ProjectName, vol.Name, nil)
+		if err != nil {
+			logger.Error("Failed purging trashed volume", logger.Ctx{"project": vol.ProjectName, "pool": vol.PoolName, "volume": vol.Name, "err": err})
+		}
+	}
+}
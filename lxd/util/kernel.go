@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/units"
 )
 
 // LoadModule loads the kernel module with the given name, by invoking
@@ -77,3 +78,83 @@ func HugepagesPath() (string, error) {
 
 	return matches[0], nil
 }
+
+// hugepagesDefaultSize returns the default huge page size of the system in bytes.
+func hugepagesDefaultSize() (uint64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "Hugepagesize:" && fields[2] == "kB" {
+			size, err := units.ParseByteSizeString(fmt.Sprintf("%sKiB", fields[1]))
+			if err != nil {
+				return 0, err
+			}
+
+			return uint64(size), nil
+		}
+	}
+
+	return 0, fmt.Errorf("Couldn't determine the default huge page size")
+}
+
+// HugepagesPathForSize attempts to locate the mount point of a hugepages filesystem using the given page size.
+func HugepagesPathForSize(pageSize uint64) (string, error) {
+	file, err := os.Open("/proc/mounts")
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	matches := []string{}
+	for scanner.Scan() {
+		cols := strings.Fields(scanner.Text())
+		if len(cols) < 4 || cols[2] != "hugetlbfs" {
+			continue
+		}
+
+		// Mounts without a pagesize option use the default huge page size.
+		mountPageSize := uint64(0)
+		for _, option := range strings.Split(cols[3], ",") {
+			if !strings.HasPrefix(option, "pagesize=") {
+				continue
+			}
+
+			size, err := units.ParseByteSizeString(fmt.Sprintf("%siB", strings.TrimPrefix(option, "pagesize=")))
+			if err != nil {
+				return "", fmt.Errorf("Failed parsing huge page size of %q: %w", cols[1], err)
+			}
+
+			mountPageSize = uint64(size)
+		}
+
+		if mountPageSize == 0 {
+			mountPageSize, err = hugepagesDefaultSize()
+			if err != nil {
+				return "", err
+			}
+		}
+
+		if mountPageSize == pageSize {
+			matches = append(matches, cols[1])
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("No hugetlbfs mount found for %s huge pages", units.GetByteSizeStringIEC(int64(pageSize), 0))
+	}
+
+	if shared.StringInSlice("/dev/hugepages", matches) {
+		return "/dev/hugepages", nil
+	}
+
+	return matches[0], nil
+}
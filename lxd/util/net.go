@@ -6,6 +6,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
 
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
@@ -277,6 +283,99 @@ func SysctlGet(path string) (string, error) {
 	return string(content), nil
 }
 
+// SysctlChange represents a sysctl changed by SysctlSet.
+type SysctlChange struct {
+	Path          string `yaml:"path"`           // Path of the sysctl relative to /proc/sys.
+	Value         string `yaml:"value"`          // Last value written.
+	PreviousValue string `yaml:"previous_value"` // Value before the sysctl was first changed.
+}
+
+// sysctlChangesFile is the on-disk record of the sysctls changed by SysctlSet.
+type sysctlChangesFile struct {
+	BootID  string         `yaml:"boot_id"`
+	Changes []SysctlChange `yaml:"changes"`
+}
+
+// sysctlChanges records the sysctls changed by SysctlSet, keyed on path. It's loaded from disk on first use.
+var sysctlChanges map[string]*SysctlChange
+var sysctlChangesMu sync.Mutex
+
+// sysctlChangesPath returns the path of the file recording the sysctls changed by SysctlSet, so that they're
+// still known after LXD restarts.
+func sysctlChangesPath() string {
+	return shared.VarPath("sysctls.yaml")
+}
+
+// sysctlChangesRead reads the sysctl changes recorded in the file at path, keyed on path.
+// Changes recorded during another boot are discarded, as sysctls don't persist across reboots.
+func sysctlChangesRead(path string, bootID string) (map[string]*SysctlChange, error) {
+	changes := make(map[string]*SysctlChange)
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return changes, nil
+		}
+
+		return nil, err
+	}
+
+	file := sysctlChangesFile{}
+	err = yaml.Unmarshal(content, &file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed parsing %q: %w", path, err)
+	}
+
+	if file.BootID != bootID {
+		return changes, nil
+	}
+
+	for i := range file.Changes {
+		changes[file.Changes[i].Path] = &file.Changes[i]
+	}
+
+	return changes, nil
+}
+
+// sysctlChangesWrite records the sysctl changes in the file at path.
+func sysctlChangesWrite(path string, bootID string, changes map[string]*SysctlChange) error {
+	file := sysctlChangesFile{BootID: bootID, Changes: make([]SysctlChange, 0, len(changes))}
+	for _, change := range changes {
+		file.Changes = append(file.Changes, *change)
+	}
+
+	sort.Slice(file.Changes, func(i, j int) bool { return file.Changes[i].Path < file.Changes[j].Path })
+
+	content, err := yaml.Marshal(&file)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+// sysctlBootID returns the ID of the current boot.
+func sysctlBootID() string {
+	bootID, _ := SysctlGet("kernel/random/boot_id")
+	return strings.TrimSpace(bootID)
+}
+
+// sysctlChangesLoad loads the recorded sysctl changes if not done already.
+// Must be called with sysctlChangesMu held.
+func sysctlChangesLoad() {
+	if sysctlChanges != nil {
+		return
+	}
+
+	changes, err := sysctlChangesRead(sysctlChangesPath(), sysctlBootID())
+	if err != nil {
+		logger.Warn("Failed loading recorded sysctl changes", logger.Ctx{"err": err})
+		changes = make(map[string]*SysctlChange)
+	}
+
+	sysctlChanges = changes
+}
+
 // SysctlSet writes a value to a sysctl file in /proc/sys.
 // Requires an even number of arguments as key/value pairs. E.g. SysctlSet("path1", "value1", "path2", "value2")
 func SysctlSet(parts ...string) error {
@@ -291,16 +390,92 @@ func SysctlSet(parts ...string) error {
 
 		// Get current value.
 		currentValue, err := SysctlGet(path)
+		currentValue = strings.TrimSpace(currentValue)
 		if err == nil && currentValue == newValue {
 			// Nothing to update.
-			return nil
+			continue
 		}
 
 		err = ioutil.WriteFile(fmt.Sprintf("/proc/sys/%s", path), []byte(newValue), 0)
 		if err != nil {
 			return err
 		}
+
+		// Record the change, keeping the value from before the first change.
+		sysctlChangesMu.Lock()
+		sysctlChangesLoad()
+
+		change, found := sysctlChanges[path]
+		if !found {
+			change = &SysctlChange{Path: path, PreviousValue: currentValue}
+			sysctlChanges[path] = change
+		}
+
+		change.Value = newValue
+
+		err = sysctlChangesWrite(sysctlChangesPath(), sysctlBootID(), sysctlChanges)
+		if err != nil {
+			logger.Warn("Failed recording sysctl change", logger.Ctx{"path": path, "err": err})
+		}
+
+		sysctlChangesMu.Unlock()
 	}
 
 	return nil
 }
+
+// SysctlChanges returns the sysctls changed by SysctlSet since the host booted, sorted by path.
+// Sysctls that don't exist anymore (e.g. because the interface was removed) are forgotten.
+func SysctlChanges() []SysctlChange {
+	sysctlChangesMu.Lock()
+	defer sysctlChangesMu.Unlock()
+
+	sysctlChangesLoad()
+
+	changes := make([]SysctlChange, 0, len(sysctlChanges))
+	for path, change := range sysctlChanges {
+		if !shared.PathExists(fmt.Sprintf("/proc/sys/%s", path)) {
+			delete(sysctlChanges, path)
+			continue
+		}
+
+		changes = append(changes, *change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	return changes
+}
+
+// BridgeAddresses returns the addresses of a bridge network's ipv4.address or ipv6.address value, which can be a
+// comma-separated list of addresses in CIDR format. The first one is the primary address of the network.
+// Returns nil if the value doesn't contain addresses.
+func BridgeAddresses(value string) []string {
+	if shared.StringInSlice(value, []string{"", "none", "auto"}) {
+		return nil
+	}
+
+	addresses := []string{}
+	for _, address := range shared.SplitNTrimSpace(value, ",", -1, true) {
+		if address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	return addresses
+}
+
+// BridgePrimaryAddress returns the primary address of a bridge network's ipv4.address or ipv6.address value.
+// The value is returned as is if it doesn't contain addresses.
+func BridgePrimaryAddress(value string) string {
+	addresses := BridgeAddresses(value)
+	if len(addresses) == 0 {
+		return value
+	}
+
+	return addresses[0]
+}
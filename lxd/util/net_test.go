@@ -119,3 +119,20 @@ func TestListenImplicitIPv6Wildcard(t *testing.T) {
 
 	assert.Equal(t, "[::]:9999", listener.Addr().String())
 }
+
+func TestBridgeAddresses(t *testing.T) {
+	assert.Nil(t, util.BridgeAddresses(""))
+	assert.Nil(t, util.BridgeAddresses("none"))
+	assert.Nil(t, util.BridgeAddresses("auto"))
+	assert.Equal(t, []string{"10.0.0.1/24"}, util.BridgeAddresses("10.0.0.1/24"))
+	assert.Equal(t, []string{"10.0.0.1/24", "10.0.1.1/24"}, util.BridgeAddresses("10.0.0.1/24, 10.0.1.1/24"))
+	assert.Equal(t, []string{"fd42::1/64", "fd43::1/64"}, util.BridgeAddresses("fd42::1/64,fd43::1/64,"))
+}
+
+func TestBridgePrimaryAddress(t *testing.T) {
+	assert.Equal(t, "", util.BridgePrimaryAddress(""))
+	assert.Equal(t, "none", util.BridgePrimaryAddress("none"))
+	assert.Equal(t, "auto", util.BridgePrimaryAddress("auto"))
+	assert.Equal(t, "10.0.0.1/24", util.BridgePrimaryAddress("10.0.0.1/24"))
+	assert.Equal(t, "10.0.0.1/24", util.BridgePrimaryAddress(" 10.0.0.1/24 ,10.0.1.1/24"))
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/base64"
 	"encoding/json"
+	"time"
 )
 
 // Cluster represents high-level information about a LXD cluster.
@@ -252,6 +253,83 @@ type ClusterMemberStatePost struct {
 	Mode string `json:"mode" yaml:"mode"`
 }
 
+// ClusterMemberState represents the state of a cluster member.
+//
+// swagger:model
+//
+// API extension: cluster_member_inventory
+type ClusterMemberState struct {
+	// Hardware inventory of the cluster member as recorded over time, oldest first
+	InventoryHistory []ClusterMemberInventory `json:"inventory_history" yaml:"inventory_history"`
+}
+
+// ClusterMemberInventory represents a summary of a cluster member's hardware at a point in time.
+//
+// swagger:model
+//
+// API extension: cluster_member_inventory
+type ClusterMemberInventory struct {
+	// When this inventory was first recorded
+	// Example: 2021-03-23T17:38:37.753398689-04:00
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// Number of CPU sockets
+	// Example: 1
+	CPUSockets uint64 `json:"cpu_sockets" yaml:"cpu_sockets"`
+
+	// Total number of CPU threads
+	// Example: 8
+	CPUThreads uint64 `json:"cpu_threads" yaml:"cpu_threads"`
+
+	// Total system memory in bytes
+	// Example: 16777216000
+	MemoryTotal uint64 `json:"memory_total" yaml:"memory_total"`
+
+	// Disks of the cluster member
+	Disks []ClusterMemberInventoryDisk `json:"disks" yaml:"disks"`
+
+	// Network interfaces of the cluster member
+	NICs []ClusterMemberInventoryNIC `json:"nics" yaml:"nics"`
+}
+
+// ClusterMemberInventoryDisk represents a disk in a cluster member's hardware inventory.
+//
+// swagger:model
+//
+// API extension: cluster_member_inventory
+type ClusterMemberInventoryDisk struct {
+	// Stable identifier of the disk (WWN, device path or device name)
+	// Example: eui.0025388b71c71522
+	ID string `json:"id" yaml:"id"`
+
+	// Disk model name
+	// Example: INTEL SSDPEKKW256G7
+	Model string `json:"model" yaml:"model"`
+
+	// Total size of the disk in bytes
+	// Example: 256060514304
+	Size uint64 `json:"size" yaml:"size"`
+}
+
+// ClusterMemberInventoryNIC represents a network interface in a cluster member's hardware inventory.
+//
+// swagger:model
+//
+// API extension: cluster_member_inventory
+type ClusterMemberInventoryNIC struct {
+	// MAC address of the interface
+	// Example: 00:16:3e:2e:5b:1a
+	Address string `json:"address" yaml:"address"`
+
+	// Interface name
+	// Example: eth0
+	Name string `json:"name" yaml:"name"`
+
+	// Kernel driver of the network card
+	// Example: e1000e
+	Driver string `json:"driver" yaml:"driver"`
+}
+
 // ClusterGroupsPost represents the fields available for a new cluster group.
 //
 // swagger:model
@@ -70,3 +70,104 @@ func StatusErrorCheck(err error, matchStatusCodes ...int) bool {
 	_, found := StatusErrorMatch(err, matchStatusCodes...)
 	return found
 }
+
+// ErrorType is a machine-readable identifier of the cause of an error, returned alongside the error message.
+//
+// API extension: api_error_types
+type ErrorType string
+
+// Generic error types, returned based on the HTTP status code when no more specific type is available.
+const (
+	ErrorTypeBadRequest         ErrorType = "bad_request"
+	ErrorTypeForbidden          ErrorType = "forbidden"
+	ErrorTypeNotFound           ErrorType = "not_found"
+	ErrorTypeConflict           ErrorType = "conflict"
+	ErrorTypePreconditionFailed ErrorType = "precondition_failed"
+	ErrorTypeInternal           ErrorType = "internal"
+	ErrorTypeNotImplemented     ErrorType = "not_implemented"
+	ErrorTypeUnavailable        ErrorType = "unavailable"
+)
+
+// Specific error types.
+const (
+	ErrorTypeInvalidDeviceConfig  ErrorType = "invalid_device_config"
+	ErrorTypeInvalidNetworkConfig ErrorType = "invalid_network_config"
+	ErrorTypeInstanceRunning      ErrorType = "instance_running"
+	ErrorTypeInstanceNotRunning   ErrorType = "instance_not_running"
+	ErrorTypeProjectLimit         ErrorType = "project_limit"
+)
+
+// ErrorTypeFromStatus returns the generic error type for the specified HTTP status code.
+func ErrorTypeFromStatus(status int) ErrorType {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrorTypeBadRequest
+	case http.StatusForbidden:
+		return ErrorTypeForbidden
+	case http.StatusNotFound:
+		return ErrorTypeNotFound
+	case http.StatusConflict:
+		return ErrorTypeConflict
+	case http.StatusPreconditionFailed:
+		return ErrorTypePreconditionFailed
+	case http.StatusNotImplemented:
+		return ErrorTypeNotImplemented
+	case http.StatusServiceUnavailable:
+		return ErrorTypeUnavailable
+	}
+
+	return ErrorTypeInternal
+}
+
+// typedError wraps an error with an error type.
+type typedError struct {
+	errType ErrorType
+	err     error
+}
+
+// Error returns the message of the wrapped error.
+func (e typedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e typedError) Unwrap() error {
+	return e.err
+}
+
+// ErrorWithType returns err annotated with the specified error type.
+// The message and any StatusError of err are kept, as the returned error wraps it.
+func ErrorWithType(errType ErrorType, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return typedError{errType: errType, err: err}
+}
+
+// ErrorTypeGet returns the type of the outermost typed error in err's chain, or an empty type if there is none.
+func ErrorTypeGet(err error) ErrorType {
+	var typedErr typedError
+
+	if errors.As(err, &typedErr) {
+		return typedErr.errType
+	}
+
+	return ""
+}
+
+// ErrorTypeCheck returns whether or not err has one of the specified error types.
+func ErrorTypeCheck(err error, matchErrorTypes ...ErrorType) bool {
+	errType := ErrorTypeGet(err)
+	if errType == "" {
+		return false
+	}
+
+	for _, t := range matchErrorTypes {
+		if errType == t {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorWithType(t *testing.T) {
+	err := ErrorWithType(ErrorTypeInvalidDeviceConfig, StatusErrorf(http.StatusBadRequest, "Invalid device"))
+	wrapped := fmt.Errorf("Failed creating instance: %w", err)
+
+	if ErrorTypeGet(wrapped) != ErrorTypeInvalidDeviceConfig {
+		t.Errorf("Unexpected error type %q", ErrorTypeGet(wrapped))
+	}
+
+	if !ErrorTypeCheck(wrapped, ErrorTypeNotFound, ErrorTypeInvalidDeviceConfig) {
+		t.Error("Expected error type to match")
+	}
+
+	if !StatusErrorCheck(wrapped, http.StatusBadRequest) {
+		t.Error("Expected status error to be kept")
+	}
+
+	if wrapped.Error() != "Failed creating instance: Invalid device" {
+		t.Errorf("Unexpected error message %q", wrapped.Error())
+	}
+
+	if ErrorTypeGet(fmt.Errorf("Plain error")) != "" {
+		t.Error("Expected no error type for plain error")
+	}
+
+	if ErrorWithType(ErrorTypeInternal, nil) != nil {
+		t.Error("Expected nil error to stay nil")
+	}
+}
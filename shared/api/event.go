@@ -138,6 +138,12 @@ type EventLifecycle struct {
 
 	// API extension: event_lifecycle_requestor
 	Requestor *EventLifecycleRequestor `yaml:"requestor,omitempty" json:"requestor,omitempty"`
+
+	// Version of the context schema (see EventLifecycleSchemaVersion)
+	// Example: 1
+	//
+	// API extension: event_lifecycle_schema
+	SchemaVersion int `yaml:"schema_version,omitempty" json:"schema_version,omitempty"`
 }
 
 // EventLifecycleRequestor represents the initial requestor for an event
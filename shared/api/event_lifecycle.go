@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// EventLifecycleSchemaVersion is the version of the lifecycle event context schema.
+// It is increased whenever a field of one of the typed lifecycle contexts below is removed or changes meaning.
+//
+// API extension: event_lifecycle_schema
+const EventLifecycleSchemaVersion = 1
+
+// EventLifecycleRenamed is the context of the *-renamed lifecycle events
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleRenamed struct {
+	// Name of the entity before the rename
+	// Example: foo
+	OldName string `yaml:"old_name" json:"old_name"`
+}
+
+// EventLifecycleRestored is the context of the instance-restored and storage-volume-restored lifecycle events
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleRestored struct {
+	// Name of the snapshot that was restored
+	// Example: snap0
+	Snapshot string `yaml:"snapshot" json:"snapshot"`
+}
+
+// EventLifecycleInstanceExec is the context of the instance-exec lifecycle event
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleInstanceExec struct {
+	// Command that was run
+	// Example: ["bash"]
+	Command []string `yaml:"command" json:"command"`
+}
+
+// EventLifecycleInstanceConsole is the context of the instance-console lifecycle event
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleInstanceConsole struct {
+	// Type of console that was attached (console or vga)
+	// Example: console
+	Type string `yaml:"type" json:"type"`
+}
+
+// EventLifecycleFile is the context of the instance-file-* and instance-metadata-template-* lifecycle events
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleFile struct {
+	// Path of the file or template
+	// Example: /etc/hosts
+	Path string `yaml:"path" json:"path"`
+}
+
+// EventLifecycleTyped is the context of the image-created, storage-volume-created, storage-volume-snapshot-created
+// and storage-volume-backup-created lifecycle events
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleTyped struct {
+	// Type of the image or storage volume
+	// Example: container
+	Type string `yaml:"type" json:"type"`
+}
+
+// EventLifecycleTarget is the context of the image-alias-created, image-alias-updated, image-retrieved,
+// storage-pool-created and storage-pool-updated lifecycle events
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleTarget struct {
+	// Target of the image alias, fingerprint of the retrieved image or cluster member the storage pool was set up on
+	// Example: 06b86454720d36b20f94e31c6812e05ec51c1b568cf3a8abd273769d213394bb
+	Target string `yaml:"target,omitempty" json:"target,omitempty"`
+}
+
+// EventLifecycleNetworkTunnel is the context of the network-tunnel-* lifecycle events
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleNetworkTunnel struct {
+	// Name of the tunnel
+	// Example: vxlan0
+	Tunnel string `yaml:"tunnel" json:"tunnel"`
+
+	// Remote address of the tunnel
+	// Example: 10.0.0.2
+	Remote string `yaml:"remote" json:"remote"`
+
+	// Previous remote address of the tunnel (network-tunnel-failover only)
+	// Example: 10.0.0.1
+	OldRemote string `yaml:"old_remote,omitempty" json:"old_remote,omitempty"`
+}
+
+// EventLifecycleClusterGroupUpdated is the context of the cluster-group-updated lifecycle event
+//
+// API extension: event_lifecycle_schema
+type EventLifecycleClusterGroupUpdated struct {
+	// Description of the cluster group
+	// Example: AMD64 servers
+	Description string `yaml:"description" json:"description"`
+
+	// List of members in the cluster group
+	// Example: ["server01", "server02"]
+	Members []string `yaml:"members" json:"members"`
+}
+
+// NewEventLifecycleContext returns a pointer to a new typed context matching the given lifecycle action,
+// or nil if the action doesn't carry any context.
+//
+// API extension: event_lifecycle_schema
+func NewEventLifecycleContext(action string) any {
+	switch action {
+	case "instance-restored", "storage-volume-restored":
+		return &EventLifecycleRestored{}
+	case "instance-exec":
+		return &EventLifecycleInstanceExec{}
+	case "instance-console":
+		return &EventLifecycleInstanceConsole{}
+	case "instance-file-retrieved", "instance-file-pushed", "instance-file-deleted":
+		return &EventLifecycleFile{}
+	case "image-created", "storage-volume-created", "storage-volume-snapshot-created", "storage-volume-backup-created":
+		return &EventLifecycleTyped{}
+	case "image-alias-created", "image-alias-updated", "image-retrieved", "storage-pool-created", "storage-pool-updated":
+		return &EventLifecycleTarget{}
+	case "cluster-group-updated":
+		return &EventLifecycleClusterGroupUpdated{}
+	}
+
+	if strings.HasPrefix(action, "instance-metadata-template-") {
+		return &EventLifecycleFile{}
+	}
+
+	if strings.HasPrefix(action, "network-tunnel-") {
+		return &EventLifecycleNetworkTunnel{}
+	}
+
+	if strings.HasSuffix(action, "-renamed") {
+		return &EventLifecycleRenamed{}
+	}
+
+	return nil
+}
+
+// ContextInto decodes the context of the lifecycle event into the given typed context struct.
+//
+// API extension: event_lifecycle_schema
+func (e EventLifecycle) ContextInto(target any) error {
+	if e.Context == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(e.Context)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"fmt"
+)
+
+func ExampleEventLifecycle_ContextInto() {
+	event := EventLifecycle{
+		Action:  "network-tunnel-failover",
+		Context: map[string]any{"tunnel": "vxlan0", "old_remote": "10.0.0.1", "remote": "10.0.0.2"},
+	}
+
+	ctx := NewEventLifecycleContext(event.Action)
+	err := event.ContextInto(ctx)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	tunnel := ctx.(*EventLifecycleNetworkTunnel)
+	fmt.Println(tunnel.Tunnel, tunnel.OldRemote, tunnel.Remote)
+	fmt.Println(NewEventLifecycleContext("instance-snapshot-renamed"))
+	fmt.Println(NewEventLifecycleContext("instance-started"))
+
+	// Output: vxlan0 10.0.0.1 10.0.0.2
+	// &{}
+	// <nil>
+}
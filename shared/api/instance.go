@@ -38,6 +38,12 @@ type InstancesPost struct {
 	// Type (container or virtual-machine)
 	// Example: container
 	Type InstanceType `json:"type" yaml:"type"`
+
+	// Number of identical instances to create (named <name>-<index>, or generated if no name is given)
+	// Example: 3
+	//
+	// API extension: instances_create_count
+	Count int `json:"count,omitempty" yaml:"count,omitempty"`
 }
 
 // InstancesPut represents the fields available for a mass update.
@@ -96,6 +102,12 @@ type InstancePost struct {
 	//
 	// API extension: instance_allow_inconsistent_copy
 	AllowInconsistent bool `json:"allow_inconsistent" yaml:"allow_inconsistent"`
+
+	// Whether to only check that the instance can be migrated to the target cluster member
+	// Example: false
+	//
+	// API extension: instance_migration_check
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
 }
 
 // InstancePostTarget represents the migration target host and operation.
@@ -117,6 +129,66 @@ type InstancePostTarget struct {
 	Websockets map[string]string `json:"secrets,omitempty" yaml:"secrets,omitempty"`
 }
 
+// InstanceRebuildPost represents the request to rebuild an instance from a new image.
+//
+// swagger:model
+//
+// API extension: instance_rebuild
+type InstanceRebuildPost struct {
+	// Source of the new root volume (type "image" or "none")
+	Source InstanceSource `json:"source" yaml:"source"`
+}
+
+// InstanceMigrationCheckPassed migration check found no problem.
+const InstanceMigrationCheckPassed = "passed"
+
+// InstanceMigrationCheckWarning migration check found a problem which doesn't prevent the migration.
+const InstanceMigrationCheckWarning = "warning"
+
+// InstanceMigrationCheckFailed migration check found a problem which prevents the migration.
+const InstanceMigrationCheckFailed = "failed"
+
+// InstanceMigrationCheck represents the result of an instance migration pre-flight check.
+//
+// swagger:model
+//
+// API extension: instance_migration_check
+type InstanceMigrationCheck struct {
+	// Name of the target cluster member
+	// Example: lxd02
+	Target string `json:"target" yaml:"target"`
+
+	// Whether the instance can be migrated to the target
+	// Example: true
+	Compatible bool `json:"compatible" yaml:"compatible"`
+
+	// List of individual checks
+	Checks []InstanceMigrationCheckResult `json:"checks" yaml:"checks"`
+}
+
+// InstanceMigrationCheckResult represents a single instance migration pre-flight check.
+//
+// swagger:model
+//
+// API extension: instance_migration_check
+type InstanceMigrationCheckResult struct {
+	// What was checked (member, architecture, cpu, idmap, storage_pool, network or device)
+	// Example: network
+	Type string `json:"type" yaml:"type"`
+
+	// Name of the checked entity (device, network or storage pool name)
+	// Example: lxdbr0
+	Name string `json:"name" yaml:"name"`
+
+	// Result of the check (passed, warning or failed)
+	// Example: passed
+	Status string `json:"status" yaml:"status"`
+
+	// Description of the result
+	// Example: Network is available on the target
+	Message string `json:"message" yaml:"message"`
+}
+
 // InstancePut represents the modifiable fields of a LXD instance.
 //
 // swagger:model
@@ -334,4 +406,10 @@ type InstanceSource struct {
 	//
 	// API extension: instance_allow_inconsistent_copy
 	AllowInconsistent bool `json:"allow_inconsistent" yaml:"allow_inconsistent"`
+
+	// Key of the backup object in the configured S3 bucket (for backup)
+	// Example: backups/default/c1/backup0
+	//
+	// API extension: backup_s3
+	ObjectKey string `json:"object_key,omitempty" yaml:"object_key,omitempty"`
 }
@@ -0,0 +1,63 @@
+package api
+
+// InstanceAdmissionRequest represents the proposed configuration of an instance submitted to the admission hook
+//
+// API extension: instances_admission_hook
+type InstanceAdmissionRequest struct {
+	// Operation being admitted (create or update)
+	// Example: create
+	Operation string `json:"operation" yaml:"operation"`
+
+	// Project of the instance
+	// Example: default
+	Project string `json:"project" yaml:"project"`
+
+	// Name of the instance
+	// Example: c1
+	Name string `json:"name" yaml:"name"`
+
+	// Type of the instance (container or virtual-machine)
+	// Example: container
+	Type string `json:"type" yaml:"type"`
+
+	// List of profiles applied to the instance
+	// Example: ["default"]
+	Profiles []string `json:"profiles" yaml:"profiles"`
+
+	// Instance configuration (excluding profiles)
+	// Example: {"limits.cpu": "2"}
+	Config map[string]string `json:"config" yaml:"config"`
+
+	// Instance devices (excluding profiles)
+	// Example: {"root": {"type": "disk", "pool": "default", "path": "/"}}
+	Devices map[string]map[string]string `json:"devices" yaml:"devices"`
+
+	// Instance configuration (including profiles)
+	// Example: {"limits.cpu": "2", "security.nesting": "true"}
+	ExpandedConfig map[string]string `json:"expanded_config" yaml:"expanded_config"`
+
+	// Instance devices (including profiles)
+	// Example: {"eth0": {"type": "nic", "network": "lxdbr0", "name": "eth0"}, "root": {"type": "disk", "pool": "default", "path": "/"}}
+	ExpandedDevices map[string]map[string]string `json:"expanded_devices" yaml:"expanded_devices"`
+}
+
+// InstanceAdmissionResponse represents the decision of the admission hook
+//
+// API extension: instances_admission_hook
+type InstanceAdmissionResponse struct {
+	// Whether the proposed configuration is allowed
+	// Example: false
+	Allowed bool `json:"allowed" yaml:"allowed"`
+
+	// Reason for rejecting the proposed configuration
+	// Example: limits.memory must be set
+	Reason string `json:"reason" yaml:"reason"`
+
+	// Replacement instance configuration (optional, excluding volatile keys)
+	// Example: {"limits.cpu": "2", "limits.memory": "4GiB"}
+	Config map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+
+	// Replacement instance devices (optional)
+	// Example: {"root": {"type": "disk", "pool": "default", "path": "/", "size": "10GiB"}}
+	Devices map[string]map[string]string `json:"devices,omitempty" yaml:"devices,omitempty"`
+}
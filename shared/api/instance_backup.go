@@ -33,6 +33,31 @@ type InstanceBackupsPost struct {
 	//
 	// API extension: backup_compression_algorithm
 	CompressionAlgorithm string `json:"compression_algorithm" yaml:"compression_algorithm"`
+
+	// Backup format (lxd or oci for an OCI image layout of a container)
+	// Example: oci
+	//
+	// API extension: instance_backup_oci
+	Format string `json:"format" yaml:"format"`
+
+	// Where to store the backup (empty for the server or s3 for the configured S3 bucket)
+	// Example: s3
+	//
+	// API extension: backup_s3
+	Target string `json:"target" yaml:"target"`
+
+	// Name of a previous optimized backup to only include the changes since (ZFS and btrfs only)
+	// Example: backup0
+	//
+	// API extension: backup_incremental
+	IncrementalFrom string `json:"incremental_from" yaml:"incremental_from"`
+
+	// Paths to leave out of the backup of a container, either absolute paths inside the container or file name
+	// patterns matched at any depth (in addition to those in the backups.exclude configuration key)
+	// Example: ["/var/cache", "node_modules"]
+	//
+	// API extension: backup_exclude
+	Exclude []string `json:"exclude" yaml:"exclude"`
 }
 
 // InstanceBackup represents a LXD instance backup.
@@ -64,6 +89,12 @@ type InstanceBackup struct {
 	// Whether to use a pool-optimized binary format (instead of plain tarball)
 	// Example: true
 	OptimizedStorage bool `json:"optimized_storage" yaml:"optimized_storage"`
+
+	// Key of the object the backup is stored in (for backups stored in S3)
+	// Example: backups/default/c1/backup0
+	//
+	// API extension: backup_s3
+	ObjectKey string `json:"object_key" yaml:"object_key"`
 }
 
 // InstanceBackupPost represents the fields available for the renaming of a instance backup.
@@ -53,4 +53,10 @@ type InstanceExecPost struct {
 	// Current working directory for the command
 	// Example: /home/foo/
 	Cwd string `json:"cwd" yaml:"cwd"`
+
+	// Number of additional file descriptors (starting at 3) to pass to the command (containers only)
+	// Example: 1
+	//
+	// API extension: instance_exec_extra_fds
+	ExtraFDs int `json:"extra-fds" yaml:"extra-fds"`
 }
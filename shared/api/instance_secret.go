@@ -0,0 +1,17 @@
+package api
+
+// InstanceSecretsPost represents the fields available for a new secret staged for an instance.
+// The value can't be read back through the API, only once from inside the instance through /dev/lxd.
+//
+// swagger:model
+//
+// API extension: instance_secrets
+type InstanceSecretsPost struct {
+	// Secret name
+	// Example: db-password
+	Name string `json:"name" yaml:"name"`
+
+	// Secret value
+	// Example: s3cr3t
+	Value string `json:"value" yaml:"value"`
+}
@@ -1,12 +1,16 @@
 package api
 
+import (
+	"time"
+)
+
 // InstanceStatePut represents the modifiable fields of a LXD instance's state.
 //
 // swagger:model
 //
 // API extension: instances
 type InstanceStatePut struct {
-	// State change action (start, stop, restart, freeze, unfreeze)
+	// State change action (start, stop, restart, freeze, unfreeze, network-pause, network-resume)
 	// Example: start
 	Action string `json:"action" yaml:"action"`
 
@@ -56,6 +60,55 @@ type InstanceState struct {
 
 	// CPU usage information
 	CPU InstanceStateCPU `json:"cpu" yaml:"cpu"`
+
+	// Dict of sampled NIC traffic counters, as seen from the host
+	//
+	// API extension: instance_nic_counters_history
+	NetworkCounters map[string]InstanceStateNetworkCountersHistory `json:"network_counters" yaml:"network_counters"`
+
+	// Dict of device start status
+	//
+	// API extension: instance_device_start_policy
+	Devices map[string]InstanceStateDevice `json:"devices" yaml:"devices"`
+
+	// Connection tracking usage (when limits.network.conntrack is set)
+	//
+	// API extension: instance_conntrack_limits
+	Conntrack *InstanceStateConntrack `json:"conntrack,omitempty" yaml:"conntrack,omitempty"`
+}
+
+// InstanceStateConntrack represents the connection tracking usage of a running LXD instance.
+//
+// swagger:model
+//
+// API extension: instance_conntrack_limits
+type InstanceStateConntrack struct {
+	// Conntrack zone the connections of the instance's NICs are placed in
+	// Example: 42
+	Zone int64 `json:"zone" yaml:"zone"`
+
+	// Number of tracked connections (-1 if it couldn't be retrieved)
+	// Example: 150
+	Usage int64 `json:"usage" yaml:"usage"`
+
+	// Maximum number of tracked connections
+	// Example: 4096
+	Limit int64 `json:"limit" yaml:"limit"`
+}
+
+// InstanceStateDevice represents the start status of a device of a running LXD instance.
+//
+// swagger:model
+//
+// API extension: instance_device_start_policy
+type InstanceStateDevice struct {
+	// Device status (started or failed)
+	// Example: failed
+	Status string `json:"status" yaml:"status"`
+
+	// Error that prevented the device from starting
+	// Example: Failed to start device "eth1": Parent device "eno2" doesn't exist
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
 }
 
 // InstanceStateDisk represents the disk information section of a LXD instance's state.
@@ -199,3 +252,38 @@ type InstanceStateNetworkCounters struct {
 	// Example: 179
 	PacketsDroppedInbound int64 `json:"packets_dropped_inbound" yaml:"packets_dropped_inbound"`
 }
+
+// InstanceStateNetworkCountersHistory represents the sampled traffic counters of an instance NIC.
+//
+// swagger:model
+//
+// API extension: instance_nic_counters_history
+type InstanceStateNetworkCountersHistory struct {
+	// Name of the interface on the host the counters are read from
+	// Example: vethbbcd39c7
+	HostName string `json:"host_name" yaml:"host_name"`
+
+	// Sampling interval in seconds
+	// Example: 60
+	Interval int64 `json:"interval" yaml:"interval"`
+
+	// Traffic since LXD started sampling the NIC, across interface re-creations (from the instance's point of view)
+	Total InstanceStateNetworkCounters `json:"total" yaml:"total"`
+
+	// List of samples (oldest first)
+	Samples []InstanceStateNetworkCountersSample `json:"samples" yaml:"samples"`
+}
+
+// InstanceStateNetworkCountersSample represents the traffic of an instance NIC during a sampling interval.
+//
+// swagger:model
+//
+// API extension: instance_nic_counters_history
+type InstanceStateNetworkCountersSample struct {
+	// When the sample was taken
+	// Example: 2021-03-23T20:00:00-04:00
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+
+	// Traffic since the previous sample (from the instance's point of view)
+	Counters InstanceStateNetworkCounters `json:"counters" yaml:"counters"`
+}
@@ -1,5 +1,9 @@
 package api
 
+import (
+	"time"
+)
+
 // NetworksPost represents the fields of a new LXD network
 //
 // swagger:model
@@ -15,6 +19,18 @@ type NetworksPost struct {
 	// The network type (refer to doc/networks.md)
 	// Example: bridge
 	Type string `json:"type" yaml:"type"`
+
+	// Whether to adopt an existing unmanaged interface of the same name (bridge only)
+	// Example: false
+	//
+	// API extension: network_bridge_adopt
+	Adopt bool `json:"adopt" yaml:"adopt"`
+
+	// Netplan definition to import the configuration of the bridge of the same name from (bridge only)
+	// Example: network: {version: 2, bridges: {lxdbr1: {addresses: [10.0.0.1/24]}}}
+	//
+	// API extension: network_netplan
+	Netplan string `json:"netplan,omitempty" yaml:"netplan,omitempty"`
 }
 
 // NetworkPost represents the fields required to rename a LXD network
@@ -135,6 +151,24 @@ type NetworkLease struct {
 	//
 	// API extension: network_leases_location
 	Location string `json:"location" yaml:"location"`
+
+	// When the lease expires (unset for static and infinite leases)
+	// Example: 2022-08-10T14:30:00Z
+	//
+	// API extension: network_leases_metadata
+	ExpiresAt *time.Time `json:"expires_at" yaml:"expires_at"`
+
+	// Project of the instance the record belongs to
+	// Example: default
+	//
+	// API extension: network_leases_metadata
+	Project string `json:"project" yaml:"project"`
+
+	// Name of the instance the record belongs to
+	// Example: c1
+	//
+	// API extension: network_leases_metadata
+	Instance string `json:"instance" yaml:"instance"`
 }
 
 // NetworkState represents the network state
@@ -182,6 +216,105 @@ type NetworkState struct {
 	//
 	// API extension: network_state_ovn
 	OVN *NetworkStateOVN `json:"ovn" yaml:"ovn"`
+
+	// Additional Open vSwitch bridge information
+	//
+	// API extension: network_bridge_ovs_controller
+	OVS *NetworkStateOVS `json:"ovs" yaml:"ovs"`
+
+	// Additional DNS server information
+	//
+	// API extension: network_state_dns
+	DNS *NetworkStateDNS `json:"dns" yaml:"dns"`
+}
+
+// NetworkStateDNS represents the state of the DNS services of a network
+//
+// swagger:model
+//
+// API extension: network_state_dns
+type NetworkStateDNS struct {
+	// Cache statistics of the DNS server
+	Cache *NetworkStateDNSCache `json:"cache" yaml:"cache"`
+
+	// Statistics of the cluster DNS relay (only set on clustered fan networks)
+	Forkdns *NetworkStateDNSForkdns `json:"forkdns" yaml:"forkdns"`
+}
+
+// NetworkStateDNSCache represents the cache statistics of a network's DNS server
+//
+// swagger:model
+//
+// API extension: network_state_dns
+type NetworkStateDNSCache struct {
+	// Maximum number of cached records
+	// Example: 150
+	Size int64 `json:"size" yaml:"size"`
+
+	// Number of records inserted in the cache
+	// Example: 120
+	Insertions int64 `json:"insertions" yaml:"insertions"`
+
+	// Number of records evicted from the cache before expiring
+	// Example: 0
+	Evictions int64 `json:"evictions" yaml:"evictions"`
+
+	// Number of queries answered from the cache
+	// Example: 430
+	Hits int64 `json:"hits" yaml:"hits"`
+
+	// Number of queries which weren't answered from the cache
+	// Example: 95
+	Misses int64 `json:"misses" yaml:"misses"`
+}
+
+// NetworkStateDNSForkdns represents the statistics of the cluster DNS relay of a network
+//
+// swagger:model
+//
+// API extension: network_state_dns
+type NetworkStateDNSForkdns struct {
+	// Number of queries received
+	// Example: 52
+	Queries int64 `json:"queries" yaml:"queries"`
+
+	// Number of queries from other cluster members answered from the local leases
+	// Example: 10
+	LocalAnswers int64 `json:"local_answers" yaml:"local_answers"`
+
+	// Number of queries relayed to the other cluster members
+	// Example: 40
+	RelayedQueries int64 `json:"relayed_queries" yaml:"relayed_queries"`
+
+	// Number of relayed queries answered by another cluster member
+	// Example: 38
+	RelayedAnswers int64 `json:"relayed_answers" yaml:"relayed_answers"`
+
+	// Number of queries answered with NXDOMAIN
+	// Example: 4
+	NotFound int64 `json:"not_found" yaml:"not_found"`
+
+	// Per cluster member relay statistics
+	Upstreams []NetworkStateDNSForkdnsUpstream `json:"upstreams" yaml:"upstreams"`
+}
+
+// NetworkStateDNSForkdnsUpstream represents the relay statistics for another cluster member
+//
+// swagger:model
+//
+// API extension: network_state_dns
+type NetworkStateDNSForkdnsUpstream struct {
+	// Address of the cluster member's DNS relay
+	// Example: 240.1.0.1
+	Address string `json:"address" yaml:"address"`
+
+	// Number of queries relayed to the member
+	// Example: 40
+	Queries int64 `json:"queries" yaml:"queries"`
+
+	// Number of queries to the member which failed (timeout or error)
+	// Example: 2
+	Failures int64 `json:"failures" yaml:"failures"`
 }
 
 // NetworkStateAddress represents a network address
@@ -316,3 +449,40 @@ type NetworkStateOVN struct {
 	// OVN network chassis name
 	Chassis string `json:"chassis" yaml:"chassis"`
 }
+
+// NetworkStateOVS represents Open vSwitch bridge specific state
+//
+// swagger:model
+//
+// API extension: network_bridge_ovs_controller
+type NetworkStateOVS struct {
+	// Whether the Open vSwitch database is reachable
+	// Example: true
+	DatabaseConnected bool `json:"database_connected" yaml:"database_connected"`
+
+	// Open vSwitch version
+	// Example: 2.17.2
+	Version string `json:"version" yaml:"version"`
+
+	// List of OpenFlow controllers of the bridge
+	Controllers []NetworkStateOVSController `json:"controllers" yaml:"controllers"`
+}
+
+// NetworkStateOVSController represents the state of an OpenFlow controller of an Open vSwitch bridge
+//
+// swagger:model
+//
+// API extension: network_bridge_ovs_controller
+type NetworkStateOVSController struct {
+	// Controller target
+	// Example: tcp:192.0.2.10:6653
+	Target string `json:"target" yaml:"target"`
+
+	// Whether the bridge is connected to the controller
+	// Example: true
+	Connected bool `json:"connected" yaml:"connected"`
+
+	// Role of the bridge towards the controller
+	// Example: master
+	Role string `json:"role" yaml:"role"`
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net"
+	"strings"
+)
+
+// NetworkReservationsPost represents the fields of a new LXD network DHCP reservation
+//
+// swagger:model
+//
+// API extension: network_dhcp_reservations
+type NetworkReservationsPost struct {
+	NetworkReservationPut `yaml:",inline"`
+
+	// MAC address of the client the reservation is for
+	// Example: 00:16:3e:1a:2b:3c
+	Hwaddr string `json:"hwaddr" yaml:"hwaddr"`
+}
+
+// Normalise normalises the fields in the reservation so that they are comparable with ones stored.
+func (r *NetworkReservationsPost) Normalise() {
+	mac, err := net.ParseMAC(r.Hwaddr)
+	if err == nil {
+		r.Hwaddr = mac.String() // Replace with canonical form if specified.
+	}
+
+	r.NetworkReservationPut.Normalise()
+}
+
+// NetworkReservationPut represents the modifiable fields of a LXD network DHCP reservation
+//
+// swagger:model
+//
+// API extension: network_dhcp_reservations
+type NetworkReservationPut struct {
+	// Description of the reservation
+	// Example: Printer on the second floor
+	Description string `json:"description" yaml:"description"`
+
+	// Host name handed out to the client (optional)
+	// Example: printer01
+	Hostname string `json:"hostname" yaml:"hostname"`
+
+	// Reserved IPv4 address (optional)
+	// Example: 10.0.0.100
+	IPv4Address string `json:"ipv4_address" yaml:"ipv4_address"`
+
+	// Reserved IPv6 address (optional)
+	// Example: fd42:4242:4242:1010::100
+	IPv6Address string `json:"ipv6_address" yaml:"ipv6_address"`
+}
+
+// Normalise normalises the fields in the reservation so that they are comparable with ones stored.
+func (r *NetworkReservationPut) Normalise() {
+	r.Description = strings.TrimSpace(r.Description)
+	r.Hostname = strings.TrimSpace(r.Hostname)
+
+	ip := net.ParseIP(r.IPv4Address)
+	if ip != nil {
+		r.IPv4Address = ip.String() // Replace with canonical form if specified.
+	}
+
+	ip = net.ParseIP(r.IPv6Address)
+	if ip != nil {
+		r.IPv6Address = ip.String() // Replace with canonical form if specified.
+	}
+}
+
+// NetworkReservation used for displaying a network DHCP reservation.
+//
+// swagger:model
+//
+// API extension: network_dhcp_reservations
+type NetworkReservation struct {
+	NetworkReservationPut `yaml:",inline"`
+
+	// MAC address of the client the reservation is for
+	// Example: 00:16:3e:1a:2b:3c
+	Hwaddr string `json:"hwaddr" yaml:"hwaddr"`
+}
+
+// Etag returns the values used for etag generation.
+func (r *NetworkReservation) Etag() []any {
+	return []any{r.Hwaddr, r.Description, r.Hostname, r.IPv4Address, r.IPv6Address}
+}
+
+// Writable converts a full NetworkReservation struct into a NetworkReservationPut struct (filters read-only fields).
+func (r *NetworkReservation) Writable() NetworkReservationPut {
+	return r.NetworkReservationPut
+}
@@ -0,0 +1,32 @@
+package api
+
+// NetworkSysctl represents a host sysctl changed by LXD.
+//
+// swagger:model
+//
+// API extension: network_sysctls
+type NetworkSysctl struct {
+	// Sysctl key
+	// Example: net.ipv6.conf.eth0.accept_ra
+	Key string `json:"key" yaml:"key"`
+
+	// Host interface the sysctl applies to (empty for host wide sysctls)
+	// Example: eth0
+	Interface string `json:"interface" yaml:"interface"`
+
+	// Value set by LXD
+	// Example: 2
+	Value string `json:"value" yaml:"value"`
+
+	// Value before LXD first changed it
+	// Example: 1
+	PreviousValue string `json:"previous_value" yaml:"previous_value"`
+
+	// Current value (differs from Value if something else changed the sysctl since)
+	// Example: 2
+	CurrentValue string `json:"current_value" yaml:"current_value"`
+
+	// What cluster member this record was found on
+	// Example: lxd01
+	Location string `json:"location" yaml:"location"`
+}
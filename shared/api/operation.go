@@ -67,6 +67,12 @@ type Operation struct {
 	//
 	// API extension: operation_location
 	Location string `json:"location" yaml:"location"`
+
+	// Project the operation belongs to (empty for server wide operations)
+	// Example: default
+	//
+	// API extension: operations_all_projects
+	Project string `json:"project,omitempty" yaml:"project,omitempty"`
 }
 
 // ToCertificateAddToken creates a certificate add token from the operation metadata.
@@ -0,0 +1,70 @@
+package api
+
+// RBACRolePost used for renaming a local RBAC role.
+//
+// swagger:model
+//
+// API extension: rbac_local
+type RBACRolePost struct {
+	// The new name for the role
+	// Example: developers
+	Name string `json:"name" yaml:"name"`
+}
+
+// RBACRolePut used for updating a local RBAC role.
+//
+// swagger:model
+//
+// API extension: rbac_local
+type RBACRolePut struct {
+	// Description of the role
+	// Example: Developers of the web team
+	Description string `json:"description" yaml:"description"`
+
+	// Whether the role grants full administrative access to LXD
+	// Example: false
+	Admin bool `json:"admin" yaml:"admin"`
+
+	// Permissions granted on the role's projects
+	// Example: ["view", "operate-containers"]
+	Permissions []string `json:"permissions" yaml:"permissions"`
+
+	// Projects the permissions apply to
+	// Example: ["web", "staging"]
+	Projects []string `json:"projects" yaml:"projects"`
+
+	// Project name patterns the permissions apply to
+	// Example: ["dev-*"]
+	//
+	// API extension: rbac_project_patterns
+	ProjectPatterns []string `json:"project_patterns" yaml:"project_patterns"`
+
+	// Users the role is assigned to (and OIDC groups prefixed with "group:")
+	// Example: ["alice", "bob", "group:lxd-admins"]
+	Users []string `json:"users" yaml:"users"`
+}
+
+// RBACRole used for displaying a local RBAC role.
+//
+// swagger:model
+//
+// API extension: rbac_local
+type RBACRole struct {
+	RBACRolePost `yaml:",inline"`
+	RBACRolePut  `yaml:",inline"`
+}
+
+// Writable converts a full RBACRole struct into a RBACRolePut struct (filters read-only fields).
+func (role *RBACRole) Writable() RBACRolePut {
+	return role.RBACRolePut
+}
+
+// RBACRolesPost used for creating a local RBAC role.
+//
+// swagger:model
+//
+// API extension: rbac_local
+type RBACRolesPost struct {
+	RBACRolePost `yaml:",inline"`
+	RBACRolePut  `yaml:",inline"`
+}
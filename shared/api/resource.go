@@ -41,6 +41,34 @@ type Resources struct {
 	//
 	// API extension: resources_system
 	System ResourcesSystem `json:"system" yaml:"system"`
+
+	// System load information
+	//
+	// API extension: resources_load
+	Load ResourcesLoad `json:"load" yaml:"load"`
+}
+
+// ResourcesLoad represents the load averages of the system
+//
+// swagger:model
+//
+// API extension: resources_load
+type ResourcesLoad struct {
+	// Load average over the last minute
+	// Example: 0.42
+	Average1 float64 `json:"average_1" yaml:"average_1"`
+
+	// Load average over the last 5 minutes
+	// Example: 0.51
+	Average5 float64 `json:"average_5" yaml:"average_5"`
+
+	// Load average over the last 15 minutes
+	// Example: 0.48
+	Average15 float64 `json:"average_15" yaml:"average_15"`
+
+	// Number of processes
+	// Example: 412
+	Processes uint64 `json:"processes" yaml:"processes"`
 }
 
 // ResourcesCPU represents the cpu resources available on the system
@@ -98,6 +126,12 @@ type ResourcesCPUSocket struct {
 	// Maximum CPU frequency (Mhz)
 	// Example: 3500
 	FrequencyTurbo uint64 `json:"frequency_turbo,omitempty" yaml:"frequency_turbo,omitempty"`
+
+	// List of CPU flags
+	// Example: ["fpu", "vme", "sse2", "avx2"]
+	//
+	// API extension: instance_migration_check
+	Flags []string `json:"flags,omitempty" yaml:"flags,omitempty"`
 }
 
 // ResourcesCPUCache represents a CPU cache
@@ -710,6 +744,12 @@ type ResourcesMemory struct {
 	// Example: 2097152
 	HugepagesSize uint64 `json:"hugepages_size" yaml:"hugepages_size"`
 
+	// Huge page pools by page size
+	// Example: null
+	//
+	// API extension: instance_hugepages_pools
+	HugepagesPools []ResourcesMemoryHugepages `json:"hugepages_pools,omitempty" yaml:"hugepages_pools,omitempty"`
+
 	// Used system memory (bytes)
 	// Example: 557450502144
 	Used uint64 `json:"used" yaml:"used"`
@@ -719,6 +759,29 @@ type ResourcesMemory struct {
 	Total uint64 `json:"total" yaml:"total"`
 }
 
+// ResourcesMemoryHugepages represents a pool of huge pages of a given size
+//
+// swagger:model
+//
+// API extension: instance_hugepages_pools
+type ResourcesMemoryHugepages struct {
+	// Size of the huge pages (bytes)
+	// Example: 2097152
+	Size uint64 `json:"size" yaml:"size"`
+
+	// Total memory in the pool (bytes)
+	// Example: 4294967296
+	Total uint64 `json:"total" yaml:"total"`
+
+	// Free memory in the pool (bytes)
+	// Example: 2147483648
+	Free uint64 `json:"free" yaml:"free"`
+
+	// Memory of the pool reserved by instances (bytes)
+	// Example: 1073741824
+	Reserved uint64 `json:"reserved" yaml:"reserved"`
+}
+
 // ResourcesMemoryNode represents the node-specific memory resources available on the system
 //
 // swagger:model
@@ -115,6 +115,12 @@ type ServerEnvironment struct {
 
 	// List of supported storage drivers
 	StorageSupportedDrivers []ServerStorageDriverInfo `json:"storage_supported_drivers" yaml:"storage_supported_drivers"`
+
+	// Map of host features LXD can't use in its environment along with the reason
+	// Example: {"kernel_modules": "LXD is running in a user namespace"}
+	//
+	// API extension: server_unavailable_features
+	UnavailableFeatures map[string]string `json:"unavailable_features" yaml:"unavailable_features"`
 }
 
 // ServerStorageDriverInfo represents the read-only info about a storage driver
@@ -140,6 +146,12 @@ type ServerStorageDriverInfo struct {
 	//
 	// API extension: server_supported_storage_drivers
 	Remote bool
+
+	// Whether the storage pools of the driver have been found to support idmapped mounts
+	// Example: true
+	//
+	// API extension: storage_volumes_idmapped_mounts
+	IdmappedMounts bool
 }
 
 // ServerPut represents the modifiable fields of a LXD server configuration
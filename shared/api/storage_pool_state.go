@@ -0,0 +1,80 @@
+package api
+
+import (
+	"time"
+)
+
+// StoragePoolStateHealthy indicates that the storage backing the pool is healthy.
+const StoragePoolStateHealthy = "healthy"
+
+// StoragePoolStateDegraded indicates that the storage backing the pool is degraded.
+const StoragePoolStateDegraded = "degraded"
+
+// StoragePoolStateUnknown indicates that the health of the storage backing the pool can't be determined.
+const StoragePoolStateUnknown = "unknown"
+
+// StoragePoolState represents the live state of a storage pool
+//
+// swagger:model
+//
+// API extension: storage_pool_state
+type StoragePoolState struct {
+	// Health of the storage backing the pool (healthy, degraded or unknown)
+	// Example: healthy
+	Health string `json:"health" yaml:"health"`
+
+	// Details about the health of the storage
+	// Example: ["Ceph cluster \"ceph\" is in state HEALTH_WARN"]
+	Messages []string `json:"messages" yaml:"messages"`
+
+	// Disk space and inode usage
+	Resources *ResourcesStoragePool `json:"resources" yaml:"resources"`
+
+	// Disk usage of the volumes per volume type
+	Volumes map[string]StoragePoolStateVolumes `json:"volumes" yaml:"volumes"`
+
+	// Outcome of the last scheduled discard of unused blocks on this server
+	//
+	// API extension: storage_pool_discard_schedule
+	Discard *StoragePoolStateDiscard `json:"discard" yaml:"discard"`
+}
+
+// StoragePoolDiscardStatusSuccess indicates that the last discard of the pool succeeded.
+const StoragePoolDiscardStatusSuccess = "success"
+
+// StoragePoolDiscardStatusFailure indicates that the last discard of the pool failed.
+const StoragePoolDiscardStatusFailure = "failure"
+
+// StoragePoolStateDiscard represents the outcome of the last discard of unused blocks of a storage pool
+//
+// swagger:model
+//
+// API extension: storage_pool_discard_schedule
+type StoragePoolStateDiscard struct {
+	// When the last discard ran
+	// Example: 2022-08-01T02:00:00Z
+	LastRun time.Time `json:"last_run" yaml:"last_run"`
+
+	// Outcome of the last discard (success or failure)
+	// Example: success
+	Status string `json:"status" yaml:"status"`
+
+	// Error returned by the last discard
+	// Example: Failed discarding unused blocks of "/var/lib/lxd/storage-pools/default": exit status 1
+	Error string `json:"error" yaml:"error"`
+}
+
+// StoragePoolStateVolumes represents the disk usage of the volumes of a given type
+//
+// swagger:model
+//
+// API extension: storage_pool_state
+type StoragePoolStateVolumes struct {
+	// Number of volumes
+	// Example: 3
+	Count uint64 `json:"count" yaml:"count"`
+
+	// Used space in bytes
+	// Example: 1693552640
+	Used uint64 `json:"used" yaml:"used"`
+}
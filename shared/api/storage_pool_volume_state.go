@@ -8,6 +8,30 @@ package api
 type StorageVolumeState struct {
 	// Volume usage
 	Usage *StorageVolumeStateUsage `json:"usage" yaml:"usage"`
+
+	// Volume encryption (only reported when the storage driver can detect it)
+	//
+	// API extension: storage_volume_state_encryption
+	Encryption *StorageVolumeStateEncryption `json:"encryption,omitempty" yaml:"encryption,omitempty"`
+}
+
+// StorageVolumeStateEncryption represents the encryption status of a volume
+//
+// swagger:model
+//
+// API extension: storage_volume_state_encryption
+type StorageVolumeStateEncryption struct {
+	// Whether the volume is encrypted
+	// Example: true
+	Enabled bool `json:"enabled" yaml:"enabled"`
+
+	// Encryption algorithm
+	// Example: aes-256-gcm
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+
+	// Whether the encryption key is loaded
+	// Example: available
+	KeyStatus string `json:"key_status,omitempty" yaml:"key_status,omitempty"`
 }
 
 // StorageVolumeStateUsage represents the disk usage of a volume
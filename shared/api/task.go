@@ -0,0 +1,67 @@
+package api
+
+import (
+	"time"
+)
+
+// Task represents a periodic background task of the LXD daemon
+//
+// swagger:model
+//
+// API extension: daemon_tasks
+type Task struct {
+	// Name of the task
+	// Example: prune-images
+	Name string `json:"name" yaml:"name"`
+
+	// Cluster member the task runs on
+	// Example: lxd01
+	Location string `json:"location" yaml:"location"`
+
+	// Whether the task is currently running
+	// Example: false
+	Running bool `json:"running" yaml:"running"`
+
+	// Whether the scheduled runs of the task are paused
+	// Example: false
+	Paused bool `json:"paused" yaml:"paused"`
+
+	// When the task last ran
+	// Example: 2021-03-23T20:00:00-04:00
+	LastRunAt time.Time `json:"last_run_at" yaml:"last_run_at"`
+
+	// How long the last run of the task took
+	// Example: 1.5s
+	LastRunDuration string `json:"last_run_duration" yaml:"last_run_duration"`
+
+	// When the task is next scheduled to run (unset if not scheduled)
+	// Example: 2021-03-24T20:00:00-04:00
+	NextRunAt time.Time `json:"next_run_at" yaml:"next_run_at"`
+
+	// Number of runs since the daemon started
+	// Example: 12
+	Runs int `json:"runs" yaml:"runs"`
+
+	// Number of runs which failed since the daemon started
+	// Example: 1
+	Failures int `json:"failures" yaml:"failures"`
+
+	// When the last failed run started
+	// Example: 2021-03-23T20:00:00-04:00
+	LastFailureAt time.Time `json:"last_failure_at" yaml:"last_failure_at"`
+
+	// Error reported by the last failed run
+	// Example: Failed to connect to the image server
+	LastFailure string `json:"last_failure" yaml:"last_failure"`
+}
+
+// TaskPost represents an action to apply to a periodic background task
+//
+// swagger:model
+//
+// API extension: daemon_tasks
+type TaskPost struct {
+	// Action to apply (run, pause or resume)
+	// Example: run
+	Action string `json:"action" yaml:"action"`
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"time"
+)
+
+// TrashEntry represents an instance or custom storage volume kept after its deletion
+//
+// swagger:model
+//
+// API extension: deletion_protection
+type TrashEntry struct {
+	// Type of the deleted object (instance or volume)
+	// Example: instance
+	Type string `json:"type" yaml:"type"`
+
+	// Name of the object before its deletion
+	// Example: foo
+	Name string `json:"name" yaml:"name"`
+
+	// Name under which the object is kept until purged
+	// Example: trash-16f8a1b2c3d4e5f6
+	TrashName string `json:"trash_name" yaml:"trash_name"`
+
+	// Project the object belongs to
+	// Example: default
+	Project string `json:"project" yaml:"project"`
+
+	// Storage pool of the volume (empty for instances)
+	// Example: local
+	Pool string `json:"pool" yaml:"pool"`
+
+	// Cluster member the object is located on
+	// Example: lxd01
+	Location string `json:"location" yaml:"location"`
+
+	// When the object was deleted
+	// Example: 2021-03-23T20:00:00-04:00
+	DeletedAt time.Time `json:"deleted_at" yaml:"deleted_at"`
+
+	// When the object will be purged
+	// Example: 2021-03-30T20:00:00-04:00
+	ExpiresAt time.Time `json:"expires_at" yaml:"expires_at"`
+}
+
+// TrashEntryPost represents the fields required to restore a trashed instance or custom storage volume
+//
+// swagger:model
+//
+// API extension: deletion_protection
+type TrashEntryPost struct {
+	// Name to restore the object under (defaults to its name before deletion)
+	// Example: bar
+	Name string `json:"name" yaml:"name"`
+}
@@ -23,6 +23,10 @@ const (
 	Restart  InstanceAction = "restart"
 	Freeze   InstanceAction = "freeze"
 	Unfreeze InstanceAction = "unfreeze"
+
+	// API extension: instance_network_pause.
+	NetworkPause  InstanceAction = "network-pause"
+	NetworkResume InstanceAction = "network-resume"
 )
 
 // ConfigVolatilePrefix indicates the prefix used for volatile config keys.
@@ -80,6 +84,7 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	"boot.autostart":             validate.Optional(validate.IsBool),
 	"boot.autostart.delay":       validate.Optional(validate.IsInt64),
 	"boot.autostart.priority":    validate.Optional(validate.IsInt64),
+	"boot.depends":               validate.Optional(validate.IsListOf(validate.IsAny)),
 	"boot.stop.priority":         validate.Optional(validate.IsInt64),
 	"boot.host_shutdown_timeout": validate.Optional(validate.IsInt64),
 
@@ -87,7 +92,8 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	"cloud-init.user-data":      validate.Optional(validate.IsAny),
 	"cloud-init.vendor-data":    validate.Optional(validate.IsAny),
 
-	"cluster.evacuate": validate.Optional(validate.IsOneOf("auto", "migrate", "live-migrate", "stop")),
+	"cluster.evacuate":  validate.Optional(validate.IsOneOf("auto", "migrate", "live-migrate", "stop")),
+	"cluster.placement": validate.Optional(validate.IsOneOf("spread", "binpack")),
 
 	"limits.cpu": func(value string) error {
 		if value == "" {
@@ -142,11 +148,21 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 
 		return nil
 	},
-	"limits.network.priority": validate.Optional(validate.IsPriority),
+	"limits.memory.hugepages.2MB": validate.Optional(validate.IsSize),
+	"limits.memory.hugepages.1GB": validate.Optional(validate.IsSize),
+	"limits.network.conntrack":    validate.Optional(validate.IsUint32),
+	"limits.network.priority":     validate.Optional(validate.IsPriority),
+	"limits.numa.nodes":           validate.Optional(validate.IsListOf(validate.IsUint32)),
+	"limits.numa.policy":          validate.Optional(validate.IsOneOf("balanced", "isolated", "manual")),
+
+	"publish.alias":     validate.IsAny,
+	"publish.retention": validate.Optional(validate.IsUint32),
+	"publish.schedule":  validate.Optional(validate.IsCron([]string{"@hourly", "@daily", "@midnight", "@weekly", "@monthly", "@annually", "@yearly", "@never"})),
 
 	// Caller is responsible for full validation of any raw.* value.
 	"raw.apparmor": validate.IsAny,
 
+	"security.core_scheduling":   validate.Optional(validate.IsBool),
 	"security.devlxd":            validate.Optional(validate.IsBool),
 	"security.protection.delete": validate.Optional(validate.IsBool),
 
@@ -169,7 +185,13 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 	"volatile.idmap.base":             validate.IsAny,
 	"volatile.idmap.current":          validate.IsAny,
 	"volatile.idmap.next":             validate.IsAny,
+	"volatile.network.paused":         validate.Optional(validate.IsBool),
+	"volatile.numa.nodes":             validate.Optional(validate.IsListOf(validate.IsUint32)),
+	"volatile.publish.fingerprints":   validate.IsAny,
+	"volatile.snapshot_overlay":       validate.Optional(validate.IsBool),
 	"volatile.apply_quota":            validate.IsAny,
+	"volatile.trash.date":             validate.IsAny,
+	"volatile.trash.name":             validate.IsAny,
 	"volatile.uuid":                   validate.Optional(validate.IsUUID),
 	"volatile.vsock_id":               validate.Optional(validate.IsInt64),
 
@@ -179,6 +201,8 @@ var InstanceConfigKeysAny = map[string]func(value string) error{
 
 // InstanceConfigKeysContainer is a map of config key to validator. (keys applying to containers only)
 var InstanceConfigKeysContainer = map[string]func(value string) error{
+	"backups.exclude": validate.Optional(validate.IsListOf(validate.IsBackupExclusion)),
+
 	"limits.cpu.allowance": func(value string) error {
 		if value == "" {
 			return nil
@@ -213,6 +237,7 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 		return nil
 	},
 	"limits.cpu.priority":   validate.Optional(validate.IsPriority),
+	"limits.gpu.share":      validate.Optional(validate.IsInRange(1, 10000)),
 	"limits.hugepages.64KB": validate.Optional(validate.IsSize),
 	"limits.hugepages.1MB":  validate.Optional(validate.IsSize),
 	"limits.hugepages.2MB":  validate.Optional(validate.IsSize),
@@ -227,7 +252,8 @@ var InstanceConfigKeysContainer = map[string]func(value string) error{
 
 	"migration.incremental.memory":            validate.Optional(validate.IsBool),
 	"migration.incremental.memory.iterations": validate.Optional(validate.IsUint32),
-	"migration.incremental.memory.goal":       validate.Optional(validate.IsUint32),
+	"migration.incremental.memory.goal":       validate.Optional(validate.IsInRange(0, 100)),
+	"migration.incremental.memory.stateful":   validate.Optional(validate.IsBool),
 
 	"nvidia.runtime":             validate.Optional(validate.IsBool),
 	"nvidia.driver.capabilities": validate.IsAny,
@@ -274,6 +300,8 @@ var InstanceConfigKeysVM = map[string]func(value string) error{
 
 	"migration.stateful": validate.Optional(validate.IsBool),
 
+	"snapshots.stateful.background": validate.Optional(validate.IsBool),
+
 	// Caller is responsible for full validation of any raw.* value.
 	"raw.qemu": validate.IsAny,
 
@@ -345,6 +373,10 @@ func ConfigKeyChecker(key string, instanceType instancetype.Type) (func(value st
 			return validate.IsAny, nil
 		}
 
+		if strings.HasSuffix(key, ".last_state.pci.port") {
+			return validate.IsAny, nil
+		}
+
 		if strings.HasSuffix(key, ".apply_quota") {
 			return validate.IsAny, nil
 		}
@@ -360,6 +392,14 @@ func ConfigKeyChecker(key string, instanceType instancetype.Type) (func(value st
 		if strings.HasSuffix(key, ".uuid") {
 			return validate.IsAny, nil
 		}
+
+		if strings.HasSuffix(key, ".failover.active") {
+			return validate.IsAny, nil
+		}
+
+		if strings.HasSuffix(key, ".start_error") {
+			return validate.IsAny, nil
+		}
 	}
 
 	if strings.HasPrefix(key, "environment.") {
@@ -374,6 +414,31 @@ func ConfigKeyChecker(key string, instanceType instancetype.Type) (func(value st
 		return validate.IsAny, nil
 	}
 
+	if strings.HasPrefix(key, "dns.cname.") || strings.HasPrefix(key, "dns.txt.") {
+		fields := strings.SplitN(key, ".", 3)
+		for _, label := range strings.Split(fields[2], ".") {
+			err := validate.IsHostname(label)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid DNS record name in configuration key %q: %w", key, err)
+			}
+		}
+
+		// CNAME targets must be DNS names and TXT values can't contain control characters as the
+		// records end up in the zone file.
+		if fields[1] == "cname" {
+			return validate.IsDNSName, nil
+		}
+
+		return func(value string) error {
+			err := validate.IsNotEmpty(value)
+			if err != nil {
+				return err
+			}
+
+			return validate.IsNoControlCharacters(value)
+		}, nil
+	}
+
 	if strings.HasPrefix(key, "limits.kernel.") &&
 		(len(key) > len("limits.kernel.")) {
 		return validate.IsAny, nil
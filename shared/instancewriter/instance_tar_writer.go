@@ -4,9 +4,12 @@ package instancewriter
 
 import (
 	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/lxc/lxd/shared"
@@ -16,9 +19,17 @@ import (
 
 // InstanceTarWriter provides a TarWriter implementation that handles ID shifting and hardlink tracking.
 type InstanceTarWriter struct {
-	tarWriter *tar.Writer
-	idmapSet  *idmap.IdmapSet
-	linkMap   map[uint64]string
+	tarWriter  *tar.Writer
+	idmapSet   *idmap.IdmapSet
+	linkMap    map[uint64]string
+	checksums  map[string]FileChecksum
+	exclusions []string
+}
+
+// FileChecksum represents the size and SHA256 checksum of a regular file written to the tarball.
+type FileChecksum struct {
+	Size   int64
+	SHA256 string
 }
 
 // NewInstanceTarWriter returns a ContainerTarWriter for the provided target Writer and id map.
@@ -27,9 +38,91 @@ func NewInstanceTarWriter(writer io.Writer, idmapSet *idmap.IdmapSet) *InstanceT
 	ctw.tarWriter = tar.NewWriter(writer)
 	ctw.idmapSet = idmapSet
 	ctw.linkMap = map[uint64]string{}
+	ctw.checksums = map[string]FileChecksum{}
 	return ctw
 }
 
+// Checksums returns the size and SHA256 checksum of the regular files written to the tarball so far.
+func (ctw *InstanceTarWriter) Checksums() map[string]FileChecksum {
+	checksums := make(map[string]FileChecksum, len(ctw.checksums))
+	for name, checksum := range ctw.checksums {
+		checksums[name] = checksum
+	}
+
+	return checksums
+}
+
+// copyFile copies the content of a regular file into the tarball, recording its size and checksum.
+func (ctw *InstanceTarWriter) copyFile(name string, src io.Reader) error {
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(ctw.tarWriter, hash), src)
+	if err != nil {
+		return err
+	}
+
+	ctw.checksums[name] = FileChecksum{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}
+
+	return nil
+}
+
+// SetExclusions sets the paths to leave out of the instance root filesystems written to the tarball.
+// Each is either an absolute path inside the instance or a file name pattern matched at any depth.
+// Excluded directories are kept empty so that they're recreated on restore.
+func (ctw *InstanceTarWriter) SetExclusions(exclusions []string) {
+	ctw.exclusions = exclusions
+}
+
+// excluded returns whether the file with the given name in the tarball is excluded.
+func (ctw *InstanceTarWriter) excluded(name string, isDir bool) bool {
+	if len(ctw.exclusions) == 0 {
+		return false
+	}
+
+	// Find the path inside the root filesystem of the instance or of one of its snapshots.
+	parts := strings.Split(name, "/")
+	rootfs := -1
+	if len(parts) > 0 && parts[0] == "rootfs" {
+		rootfs = 0
+	} else if len(parts) > 2 && parts[0] == "backup" && parts[1] == "container" && parts[2] == "rootfs" {
+		rootfs = 2
+	} else if len(parts) > 3 && parts[0] == "backup" && parts[1] == "snapshots" && parts[3] == "rootfs" {
+		rootfs = 3
+	}
+
+	if rootfs < 0 {
+		return false
+	}
+
+	parts = parts[rootfs+1:]
+	if len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+
+	path := "/" + strings.Join(parts, "/")
+	for _, exclusion := range ctw.exclusions {
+		if strings.HasPrefix(exclusion, "/") {
+			if path == exclusion {
+				return !isDir
+			}
+
+			if strings.HasPrefix(path, exclusion+"/") {
+				return true
+			}
+
+			continue
+		}
+
+		for i, part := range parts {
+			match, _ := filepath.Match(exclusion, part)
+			if match {
+				return i < len(parts)-1 || !isDir
+			}
+		}
+	}
+
+	return false
+}
+
 // ResetHardLinkMap resets the hard link map. Use when copying multiple instances (or snapshots) into a tarball.
 // So that the hard link map doesn't work across different instances/snapshots.
 func (ctw *InstanceTarWriter) ResetHardLinkMap() {
@@ -46,6 +139,11 @@ func (ctw *InstanceTarWriter) WriteFile(name string, srcPath string, fi os.FileI
 	var nlink int
 	var ino uint64
 
+	// Skip excluded files, only keeping the excluded directories themselves.
+	if ctw.excluded(name, fi.IsDir()) {
+		return nil
+	}
+
 	link := ""
 	if fi.Mode()&os.ModeSymlink == os.ModeSymlink {
 		link, err = os.Readlink(srcPath)
@@ -153,7 +251,7 @@ func (ctw *InstanceTarWriter) WriteFile(name string, srcPath string, fi os.FileI
 			r = io.LimitReader(r, fi.Size())
 		}
 
-		_, err = io.Copy(ctw.tarWriter, r)
+		err = ctw.copyFile(hdr.Name, r)
 		if err != nil {
 			return fmt.Errorf("Failed to copy file content %q: %w", srcPath, err)
 		}
@@ -180,8 +278,7 @@ func (ctw *InstanceTarWriter) WriteFileFromReader(src io.Reader, fi os.FileInfo)
 		return fmt.Errorf("Failed to write tar header: %w", err)
 	}
 
-	_, err = io.Copy(ctw.tarWriter, src)
-	return err
+	return ctw.copyFile(hdr.Name, src)
 }
 
 // Close finishes writing the tarball.
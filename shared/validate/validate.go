@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/kballard/go-shellquote"
 	"github.com/pborman/uuid"
@@ -676,6 +677,33 @@ func IsArchitecture(value string) error {
 	return IsOneOf(osarch.SupportedArchitectures()...)(value)
 }
 
+// IsBackupExclusion validates whether the value is a path to exclude from instance backups, either an absolute
+// path inside the instance (such as /var/cache) or a file name pattern matched at any depth (such as node_modules).
+func IsBackupExclusion(value string) error {
+	if value == "" {
+		return fmt.Errorf("Empty exclusion")
+	}
+
+	if strings.HasPrefix(value, "/") {
+		if value == "/" || filepath.Clean(value) != value {
+			return fmt.Errorf("Exclusion %q isn't a clean path below /", value)
+		}
+
+		return nil
+	}
+
+	if strings.Contains(value, "/") {
+		return fmt.Errorf("Exclusion %q must be either an absolute path or a file name pattern", value)
+	}
+
+	_, err := filepath.Match(value, "")
+	if err != nil {
+		return fmt.Errorf("Invalid exclusion pattern %q: %w", value, err)
+	}
+
+	return nil
+}
+
 // IsCron checks that it's a valid cron pattern or alias.
 func IsCron(aliases []string) func(value string) error {
 	return func(value string) error {
@@ -836,6 +864,48 @@ func IsHostname(name string) error {
 	return nil
 }
 
+// IsDNSName validates a DNS domain name, which can be fully qualified with a trailing full stop. Each label must be
+// 1-63 characters long, contain only alphanumeric, hyphen and underscore characters and not start or end with a
+// hyphen.
+func IsDNSName(name string) error {
+	labels := strings.TrimSuffix(name, ".")
+	if len(labels) < 1 || len(labels) > 253 {
+		return fmt.Errorf("Name must be 1-253 characters long")
+	}
+
+	for _, label := range strings.Split(labels, ".") {
+		if len(label) < 1 || len(label) > 63 {
+			return fmt.Errorf("Label %q must be 1-63 characters long", label)
+		}
+
+		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf(`Label %q must not start or end with "-" character`, label)
+		}
+
+		match, err := regexp.MatchString(`^[\-_a-zA-Z0-9]+$`, label)
+		if err != nil {
+			return err
+		}
+
+		if !match {
+			return fmt.Errorf("Label %q can only contain alphanumeric, hyphen and underscore characters", label)
+		}
+	}
+
+	return nil
+}
+
+// IsNoControlCharacters checks the value doesn't contain control characters (such as new lines).
+func IsNoControlCharacters(value string) error {
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("Value must not contain control characters")
+		}
+	}
+
+	return nil
+}
+
 // IsDeviceName checks name is 1-63 characters long, doesn't start with a full stop and contains only alphanumeric,
 // forward slash, hyphen, colon, underscore and full stop characters.
 func IsDeviceName(name string) error {
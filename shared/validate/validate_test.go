@@ -103,3 +103,53 @@ func ExampleRequired() {
 	// <nil> Invalid value for a boolean "foo"
 	// <nil> <nil>
 }
+
+func ExampleIsDNSName() {
+	tests := []string{
+		"www",
+		"www.example.com",
+		"www.example.com.",
+		"_acme-challenge.example.com",
+		"1.example.com",
+		"-www.example.com", // starts with hyphen
+		"www..example.com", // empty label
+		"www example.com",  // space
+		".",
+		"",
+	}
+
+	for _, v := range tests {
+		err := validate.IsDNSName(v)
+		fmt.Printf("%s, %t\n", v, err == nil)
+	}
+
+	// Output: www, true
+	// www.example.com, true
+	// www.example.com., true
+	// _acme-challenge.example.com, true
+	// 1.example.com, true
+	// -www.example.com, false
+	// www..example.com, false
+	// www example.com, false
+	// ., false
+	// , false
+}
+
+func ExampleIsNoControlCharacters() {
+	tests := []string{
+		"v=spf1 -all",
+		"line\nbreak",
+		"nul\x00",
+		"tab\t",
+	}
+
+	for _, v := range tests {
+		err := validate.IsNoControlCharacters(v)
+		fmt.Printf("%q, %t\n", v, err == nil)
+	}
+
+	// Output: "v=spf1 -all", true
+	// "line\nbreak", false
+	// "nul\x00", false
+	// "tab\t", false
+}
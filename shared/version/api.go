@@ -334,6 +334,90 @@ var APIExtensions = []string{
 	"container_syscall_intercept_sysinfo",
 	"clustering_evacuation_mode",
 	"resources_pci_vpd",
+	"network_tunnel_keepalive",
+	"instance_vm_snapshot_overlay",
+	"storage_volume_presets",
+	"instance_placement_storage_pool",
+	"instance_hugepages_pools",
+	"instance_numa_policy",
+	"container_stateful_hardening",
+	"vm_stateful_snapshots_background",
+	"api_access_log",
+	"event_lifecycle_schema",
+	"network_dhcp_reservations",
+	"operations_all_projects",
+	"instance_nic_bridged_port_mirroring",
+	"network_dns_provider_builtin",
+	"instance_network_pause",
+	"network_lease_events",
+	"instance_nic_bridged_acls",
+	"backup_manifest",
+	"network_bridge_ovs_controller",
+	"network_tunnel_encryption",
+	"network_bridge_multiple_subnets",
+	"instance_dns_records",
+	"instance_nic_counters_history",
+	"gpu_dri_gid_hotplug",
+	"disk_recursive_readonly",
+	"network_zones_dns_queries",
+	"storage_volumes_idmapped_mounts",
+	"network_state_dns",
+	"instance_migration_check",
+	"rbac_local",
+	"projects_images_local_aliases",
+	"rbac_project_patterns",
+	"network_nic_bridged_failover",
+	"oidc",
+	"instances_limits_gpu_share",
+	"storage_driver_linstor",
+	"storage_pool_state",
+	"storage_pool_discard_schedule",
+	"instance_rebuild",
+	"instances_create_count",
+	"instance_nic_bridged_promiscuous",
+	"network_bridge_fan_ipv6",
+	"instance_backup_oci",
+	"instance_device_start_policy",
+	"api_error_types",
+	"vm_root_disk_live_resize",
+	"server_export",
+	"instance_publish_schedule",
+	"instance_boot_depends",
+	"storage_volume_state_encryption",
+	"projects_limits_networks_subnets_forwards",
+	"resources_load",
+	"clustering_placement_policy",
+	"secrets_vault",
+	"proxy_limits",
+	"network_leases_metadata",
+	"cluster_notify_concurrency",
+	"network_bridge_mirror",
+	"network_bridge_peers",
+	"network_bridge_adopt",
+	"vm_pci_hotplug",
+	"instances_admission_hook",
+	"network_bridge_live_mtu",
+	"devices_patch",
+	"network_bridge_vlan_filtering",
+	"cluster_member_inventory",
+	"device_config_templates",
+	"instance_exec_extra_fds",
+	"instance_core_scheduling",
+	"proxy_connect_balance",
+	"network_netplan",
+	"proxy_vm_agent",
+	"deletion_protection",
+	"daemon_tasks",
+	"proxy_unix_recreate",
+	"backup_compression_pool",
+	"instance_conntrack_limits",
+	"backup_s3",
+	"server_unavailable_features",
+	"backup_incremental",
+	"instance_secrets",
+	"backup_exclude",
+	"network_load_balancer",
+	"network_sysctls",
 }
 
 // APIExtensionsCount returns the number of available API extensions.